@@ -17,6 +17,10 @@ interface AuthCommand {
   token: string;
 }
 
+interface StatusCommand {
+  type: 'status';
+}
+
 interface BridgeMessage {
   type: 'message' | 'status' | 'qr' | 'error';
   [key: string]: unknown;
@@ -27,7 +31,9 @@ export class BridgeServer {
   private wa: WhatsAppClient | null = null;
   private clients: Set<WebSocket> = new Set();
   private lastStatus: string | null = null;
+  private lastStatusAt: number | null = null;
   private lastQR: string | null = null;
+  private lastQRAt: number | null = null;
 
   constructor(private port: number, private authDir: string, private token?: string) {}
 
@@ -43,11 +49,13 @@ export class BridgeServer {
       onMessage: (msg) => this.broadcast({ type: 'message', ...msg }),
       onQR: (qr) => {
         this.lastQR = qr;
-        this.broadcast({ type: 'qr', qr });
+        this.lastQRAt = Date.now();
+        this.broadcast({ type: 'qr', qr, at: this.lastQRAt });
       },
       onStatus: (status) => {
         this.lastStatus = status;
-        this.broadcast({ type: 'status', status });
+        this.lastStatusAt = Date.now();
+        this.broadcast({ type: 'status', status, at: this.lastStatusAt });
       },
       proxyUrl: process.env.PROXY_URL || process.env.HTTPS_PROXY || process.env.HTTP_PROXY || process.env.ALL_PROXY,
     });
@@ -91,19 +99,29 @@ export class BridgeServer {
     this.clients.add(ws);
 
     if (this.lastStatus) {
-      ws.send(JSON.stringify({ type: 'status', status: this.lastStatus }));
+      ws.send(JSON.stringify({ type: 'status', status: this.lastStatus, at: this.lastStatusAt }));
     }
     if (this.lastQR) {
-      ws.send(JSON.stringify({ type: 'qr', qr: this.lastQR }));
+      ws.send(JSON.stringify({ type: 'qr', qr: this.lastQR, at: this.lastQRAt }));
     }
 
     ws.on('message', async (data) => {
       try {
-        const cmd = JSON.parse(data.toString()) as SendCommand | AuthCommand;
+        const cmd = JSON.parse(data.toString()) as SendCommand | AuthCommand | StatusCommand;
         if (cmd.type === 'auth') {
           // Ignore best-effort auth handshake when BRIDGE_TOKEN is not enabled.
           return;
         }
+        if (cmd.type === 'status') {
+          // Status snapshot for `maxclaw whatsapp status`
+          ws.send(JSON.stringify({
+            type: 'status',
+            status: this.lastStatus ?? 'unknown',
+            at: this.lastStatusAt,
+            qrAt: this.lastQRAt,
+          }));
+          return;
+        }
         await this.handleCommand(cmd);
         ws.send(JSON.stringify({ type: 'sent', to: cmd.to }));
       } catch (error) {
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+)
+
+// channelStreamer 把流式内容按段落推送到频道，并记录最后一段模型输出用于判断最终回复是否已送达
+type channelStreamer struct {
+	bus       *bus.MessageBus
+	inbound   *bus.InboundMessage
+	buf       strings.Builder
+	iteration int
+	segment   strings.Builder
+	sent      bool
+}
+
+func newChannelStreamer(msgBus *bus.MessageBus, inbound *bus.InboundMessage) *channelStreamer {
+	return &channelStreamer{bus: msgBus, inbound: inbound}
+}
+
+// handleEvent 消费 processMessageWithIC 的结构化事件
+func (s *channelStreamer) handleEvent(event StreamEvent) {
+	switch event.Type {
+	case "content_delta":
+		if event.Iteration != s.iteration {
+			s.flush()
+			s.iteration = event.Iteration
+			s.segment.Reset()
+		}
+		s.segment.WriteString(event.Delta)
+		s.write(event.Delta)
+	case "tool_start":
+		// 工具执行前先把已生成的说明发出去
+		s.flush()
+	}
+}
+
+func (s *channelStreamer) write(delta string) {
+	s.buf.WriteString(delta)
+	text := s.buf.String()
+	idx := strings.LastIndex(text, "\n\n")
+	if idx < 0 {
+		return
+	}
+	s.publish(text[:idx])
+	s.buf.Reset()
+	s.buf.WriteString(text[idx+2:])
+}
+
+func (s *channelStreamer) flush() {
+	s.publish(s.buf.String())
+	s.buf.Reset()
+}
+
+func (s *channelStreamer) publish(text string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return
+	}
+	if err := s.bus.PublishOutbound(bus.NewReply(s.inbound, text)); err == nil {
+		s.sent = true
+	}
+}
+
+// delivered 判断最终回复是否就是已经流式发出的最后一段内容
+func (s *channelStreamer) delivered(finalContent string) bool {
+	final := strings.TrimSpace(finalContent)
+	return s.sent && final != "" && final == strings.TrimSpace(s.segment.String())
+}
+
+// toolNoticeHandler 在工具执行完成后向频道发送简短提示；level 为 off 时返回 nil
+func toolNoticeHandler(msgBus *bus.MessageBus, inbound *bus.InboundMessage, level string) func(StreamEvent) {
+	level = config.NormalizeToolNotices(level)
+	if level == config.ToolNoticesOff {
+		return nil
+	}
+	return func(event StreamEvent) {
+		if event.Type != "tool_result" || event.ToolName == "" {
+			return
+		}
+		text := "🔧 ran " + event.ToolName
+		if level == config.ToolNoticesVerbose && strings.TrimSpace(event.Summary) != "" {
+			text = "🔧 " + event.Summary
+		}
+		msgBus.PublishOutbound(bus.NewReply(inbound, text))
+	}
+}
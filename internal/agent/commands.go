@@ -0,0 +1,190 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/Lichas/maxclaw/internal/memory"
+	"github.com/Lichas/maxclaw/internal/session"
+	"github.com/Lichas/maxclaw/pkg/tools"
+)
+
+// SlashCommand 聊天消息以 /<name> 开头时在调用模型之前拦截处理的命令
+type SlashCommand struct {
+	// Name 命令名，不含前导 /，不区分大小写
+	Name string
+	// Description /help 中显示的说明
+	Description string
+	// AcceptsArgs 命令是否接受参数；为 false 时带参数的消息不当作命令，照常交给模型
+	AcceptsArgs bool
+	// Handler 返回直接回复给用户的文本
+	Handler func(ctx context.Context, req SlashCommandRequest) (string, error)
+}
+
+// SlashCommandRequest 命令执行时的上下文
+type SlashCommandRequest struct {
+	Loop    *AgentLoop
+	Message *bus.InboundMessage
+	Session *session.Session
+	// Args 命令名之后的参数（已去除首尾空白）
+	Args string
+	// Model 本轮实际使用的模型（含调用方指定的覆盖模型）
+	Model string
+}
+
+// RegisterSlashCommand 注册或替换 slash 命令；/help 按注册顺序列出
+func (a *AgentLoop) RegisterSlashCommand(cmd SlashCommand) error {
+	name := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(cmd.Name), "/"))
+	if name == "" || strings.ContainsAny(name, " \t\n/@") {
+		return fmt.Errorf("invalid slash command name %q", cmd.Name)
+	}
+	if cmd.Handler == nil {
+		return fmt.Errorf("slash command /%s has no handler", name)
+	}
+	cmd.Name = name
+
+	a.commandsMu.Lock()
+	defer a.commandsMu.Unlock()
+	if a.slashCommands == nil {
+		a.slashCommands = make(map[string]SlashCommand)
+	}
+	if _, exists := a.slashCommands[name]; !exists {
+		a.slashCommandOrder = append(a.slashCommandOrder, name)
+	}
+	a.slashCommands[name] = cmd
+	return nil
+}
+
+// SlashCommands 按注册顺序返回已注册的命令
+func (a *AgentLoop) SlashCommands() []SlashCommand {
+	a.commandsMu.RLock()
+	defer a.commandsMu.RUnlock()
+	cmds := make([]SlashCommand, 0, len(a.slashCommandOrder))
+	for _, name := range a.slashCommandOrder {
+		cmds = append(cmds, a.slashCommands[name])
+	}
+	return cmds
+}
+
+// parseSlashCommand 解析 "/name args"。群聊中的 "/name@bot" 由频道按自己的机器人用户名去掉后缀，
+// 仍带 @ 的命令指向其他机器人，不当作命令
+func parseSlashCommand(content string) (name, args string, ok bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "/") {
+		return "", "", false
+	}
+	head, rest := content, ""
+	if i := strings.IndexFunc(content, unicode.IsSpace); i >= 0 {
+		head, rest = content[:i], content[i:]
+	}
+	name = strings.ToLower(strings.TrimPrefix(head, "/"))
+	if name == "" || strings.Contains(name, "@") {
+		return "", "", false
+	}
+	return name, strings.TrimSpace(rest), true
+}
+
+// handleSlashCommand 消息是已注册的命令时执行并返回回复；未注册的 / 开头消息（如路径）照常交给模型
+func (a *AgentLoop) handleSlashCommand(ctx context.Context, msg *bus.InboundMessage, sess *session.Session, model string) (*bus.OutboundMessage, bool) {
+	name, args, ok := parseSlashCommand(msg.Content)
+	if !ok {
+		return nil, false
+	}
+	a.commandsMu.RLock()
+	cmd, exists := a.slashCommands[name]
+	a.commandsMu.RUnlock()
+	if !exists || (args != "" && !cmd.AcceptsArgs) {
+		return nil, false
+	}
+
+	reply, err := cmd.Handler(ctx, SlashCommandRequest{
+		Loop:    a,
+		Message: msg,
+		Session: sess,
+		Args:    args,
+		Model:   model,
+	})
+	if err != nil {
+		if lg := logging.Get(); lg != nil && lg.Session != nil {
+			lg.Session.Printf("slash command /%s failed: %v", name, err)
+		}
+		reply = fmt.Sprintf("/%s failed: %v", name, err)
+	}
+	return bus.NewReply(msg, reply), true
+}
+
+// registerDefaultSlashCommands 注册内置命令
+func (a *AgentLoop) registerDefaultSlashCommands() {
+	_ = a.RegisterSlashCommand(SlashCommand{
+		Name:        "new",
+		Description: "Start a new conversation",
+		Handler: func(ctx context.Context, req SlashCommandRequest) (string, error) {
+			req.Loop.resetSession(req.Session, "/new")
+			return "New session started.", nil
+		},
+	})
+	_ = a.RegisterSlashCommand(SlashCommand{
+		Name:        "reset",
+		Description: "Clear the conversation history",
+		Handler: func(ctx context.Context, req SlashCommandRequest) (string, error) {
+			req.Loop.resetSession(req.Session, "/reset")
+			return "Conversation cleared.", nil
+		},
+	})
+	_ = a.RegisterSlashCommand(SlashCommand{
+		Name:        "help",
+		Description: "Show available commands and tools",
+		Handler: func(ctx context.Context, req SlashCommandRequest) (string, error) {
+			return req.Loop.slashHelpText(), nil
+		},
+	})
+	_ = a.RegisterSlashCommand(SlashCommand{
+		Name:        "model",
+		Description: "Show the active model",
+		Handler: func(ctx context.Context, req SlashCommandRequest) (string, error) {
+			if strings.TrimSpace(req.Model) == "" {
+				return "No model is configured.", nil
+			}
+			return "Active model: " + req.Model, nil
+		},
+	})
+}
+
+// resetSession 归档后清空会话及其待办清单
+func (a *AgentLoop) resetSession(sess *session.Session, command string) {
+	if _, err := memory.ArchiveSessionAll(a.Workspace, sess); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Session != nil {
+			lg.Session.Printf("archive session on %s failed: %v", command, err)
+		}
+	}
+	sess.Clear()
+	_ = a.sessions.Save(sess)
+	if err := tools.ClearTodoList(a.Workspace, sess.Key); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Session != nil {
+			lg.Session.Printf("clear todo list on %s failed: %v", command, err)
+		}
+	}
+}
+
+func (a *AgentLoop) slashHelpText() string {
+	var b strings.Builder
+	b.WriteString("maxclaw commands:")
+	for _, cmd := range a.SlashCommands() {
+		b.WriteString("\n/" + cmd.Name)
+		if cmd.Description != "" {
+			b.WriteString(" - " + cmd.Description)
+		}
+	}
+
+	names := a.tools.List()
+	if len(names) > 0 {
+		sort.Strings(names)
+		b.WriteString("\n\nTools: " + strings.Join(names, ", "))
+	}
+	return b.String()
+}
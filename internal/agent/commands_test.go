@@ -0,0 +1,200 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/cron"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingProvider 记录模型调用次数，用于确认 slash 命令没有调用模型
+type countingProvider struct {
+	calls int32
+}
+
+func (p *countingProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	atomic.AddInt32(&p.calls, 1)
+	return &providers.Response{Content: "llm reply"}, nil
+}
+
+func (p *countingProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	atomic.AddInt32(&p.calls, 1)
+	handler.OnContent("llm reply")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *countingProvider) GetDefaultModel() string          { return "test-model" }
+func (p *countingProvider) SupportsImageInput(m string) bool { return false }
+
+func newCommandTestLoop(t *testing.T, provider providers.LLMProvider) *AgentLoop {
+	t.Helper()
+	workspace := t.TempDir()
+	return NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		workspace,
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		cron.NewService(filepath.Join(workspace, ".cron", "jobs.json")),
+		nil,
+		false,
+	)
+}
+
+func TestParseSlashCommand(t *testing.T) {
+	tests := []struct {
+		in   string
+		name string
+		args string
+		ok   bool
+	}{
+		{"/help", "help", "", true},
+		{"  /Reset  ", "reset", "", true},
+		{"/model gpt-4o", "model", "gpt-4o", true},
+		{"/new\nstart over", "new", "start over", true},
+		{"/reset@other_bot", "", "", false},
+		{"hello /help", "", "", false},
+		{"/", "", "", false},
+		{"/@bot", "", "", false},
+	}
+	for _, tt := range tests {
+		name, args, ok := parseSlashCommand(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		assert.Equal(t, tt.name, name, tt.in)
+		assert.Equal(t, tt.args, args, tt.in)
+	}
+}
+
+func TestSlashResetClearsSessionWithoutLLMCall(t *testing.T) {
+	provider := &countingProvider{}
+	loop := newCommandTestLoop(t, provider)
+
+	sess := loop.sessions.GetOrCreate("telegram:chat-42")
+	sess.AddMessage("user", "old")
+	sess.AddMessage("assistant", "old-reply")
+	require.NoError(t, loop.sessions.Save(sess))
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-42", "/reset"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "Conversation cleared.", resp.Content)
+	assert.Empty(t, loop.sessions.GetOrCreate("telegram:chat-42").Messages)
+	assert.Zero(t, atomic.LoadInt32(&provider.calls))
+}
+
+func TestSlashCommandWithUnexpectedArgsGoesToModel(t *testing.T) {
+	provider := &countingProvider{}
+	loop := newCommandTestLoop(t, provider)
+
+	sess := loop.sessions.GetOrCreate("telegram:chat-42")
+	sess.AddMessage("user", "old")
+	require.NoError(t, loop.sessions.Save(sess))
+
+	// /reset 不接受参数：带文字的消息交给模型，不清空会话也不丢弃文字
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-42", "/reset the router config"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "llm reply", resp.Content)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+	messages := loop.sessions.GetOrCreate("telegram:chat-42").Messages
+	require.NotEmpty(t, messages)
+	assert.Equal(t, "old", messages[0].Content)
+}
+
+func TestSlashCommandForOtherBotIsNotHandled(t *testing.T) {
+	provider := &countingProvider{}
+	loop := newCommandTestLoop(t, provider)
+
+	sess := loop.sessions.GetOrCreate("telegram:chat-42")
+	sess.AddMessage("user", "old")
+	require.NoError(t, loop.sessions.Save(sess))
+
+	_, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-42", "/reset@someotherbot"))
+	require.NoError(t, err)
+	messages := loop.sessions.GetOrCreate("telegram:chat-42").Messages
+	require.NotEmpty(t, messages)
+	assert.Equal(t, "old", messages[0].Content)
+}
+
+func TestSlashHelpListsCommandsAndToolsWithoutLLMCall(t *testing.T) {
+	provider := &countingProvider{}
+	loop := newCommandTestLoop(t, provider)
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-42", "/help"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	for _, want := range []string{"/new", "/reset", "/help", "/model", "Tools:", "exec", "read_file", "web_fetch", "cron"} {
+		assert.Contains(t, resp.Content, want)
+	}
+	assert.Zero(t, atomic.LoadInt32(&provider.calls))
+	assert.Empty(t, loop.sessions.GetOrCreate("telegram:chat-42").Messages)
+}
+
+func TestSlashModelShowsActiveModel(t *testing.T) {
+	loop := newCommandTestLoop(t, &countingProvider{})
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("cli", "user", "direct", "/model"))
+	require.NoError(t, err)
+	assert.Equal(t, "Active model: test-model", resp.Content)
+
+	loop.UpdateRuntimeModel(&countingProvider{}, "deepseek-chat")
+	resp, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("cli", "user", "direct", "/model"))
+	require.NoError(t, err)
+	assert.Equal(t, "Active model: deepseek-chat", resp.Content)
+}
+
+func TestRegisterSlashCommandExtendsCommandSet(t *testing.T) {
+	provider := &countingProvider{}
+	loop := newCommandTestLoop(t, provider)
+
+	require.Error(t, loop.RegisterSlashCommand(SlashCommand{Name: "bad name", Handler: func(context.Context, SlashCommandRequest) (string, error) { return "", nil }}))
+	require.Error(t, loop.RegisterSlashCommand(SlashCommand{Name: "nohandler"}))
+
+	require.NoError(t, loop.RegisterSlashCommand(SlashCommand{
+		Name:        "/Echo",
+		Description: "Repeat the arguments",
+		AcceptsArgs: true,
+		Handler: func(ctx context.Context, req SlashCommandRequest) (string, error) {
+			return req.Message.Channel + ":" + req.Args, nil
+		},
+	}))
+	require.NoError(t, loop.RegisterSlashCommand(SlashCommand{
+		Name: "fail",
+		Handler: func(ctx context.Context, req SlashCommandRequest) (string, error) {
+			return "", errors.New("boom")
+		},
+	}))
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("slack", "u", "c", "/echo hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, "slack:hello world", resp.Content)
+
+	resp, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("slack", "u", "c", "/fail"))
+	require.NoError(t, err)
+	assert.Equal(t, "/fail failed: boom", resp.Content)
+
+	resp, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("slack", "u", "c", "/help"))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "/echo - Repeat the arguments")
+	assert.Zero(t, atomic.LoadInt32(&provider.calls))
+
+	// 未注册的 / 开头消息照常交给模型
+	resp, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("slack", "u", "c", "/usr/bin/python3 crashes on start"))
+	require.NoError(t, err)
+	assert.Equal(t, "llm reply", resp.Content)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+}
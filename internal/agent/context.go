@@ -14,6 +14,7 @@ import (
 
 	"github.com/Lichas/maxclaw/internal/bus"
 	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
 )
 
 //go:embed prompts/system_prompt.md
@@ -166,6 +167,29 @@ func isMediaPlaceholder(content string) bool {
 	}
 }
 
+// todoSectionMarker 标记系统提示中由 WithTodoList 追加的待办清单段落，刷新时从最后一个标记处截断；
+// 使用不会出现在工作区文件中的注释，避免误截用户在 AGENTS.md 等文件中写的同名标题
+const todoSectionMarker = "\n\n<!-- maxclaw:todo-list -->"
+
+// todoSectionHeader 待办清单段落的标题
+const todoSectionHeader = "\n## Todo List\n"
+
+// WithTodoList 在系统提示末尾附加（或刷新）会话的待办清单；清单为空时去掉该段落
+func (b *ContextBuilder) WithTodoList(messages []providers.Message, sessionKey string) []providers.Message {
+	if len(messages) == 0 || messages[0].Role != "system" {
+		return messages
+	}
+	content := messages[0].Content
+	if idx := strings.LastIndex(content, todoSectionMarker); idx >= 0 {
+		content = content[:idx]
+	}
+	if items, err := tools.LoadTodoList(b.workspace, sessionKey); err == nil && len(items) > 0 {
+		content += todoSectionMarker + todoSectionHeader + "Your checklist for this conversation; keep it current with the todo tool.\n" + tools.FormatTodoList(items)
+	}
+	messages[0].Content = content
+	return messages
+}
+
 // AddAssistantMessage 添加助手消息
 func (b *ContextBuilder) AddAssistantMessage(messages []providers.Message, content string, toolCalls []providers.ToolCall) []providers.Message {
 	msg := providers.Message{
@@ -190,6 +214,23 @@ func (b *ContextBuilder) AddToolResult(messages []providers.Message, toolCallID,
 	return messages
 }
 
+// AddToolImages 把工具读取的图片作为一条多模态用户消息追加在工具结果之后（多数接口的 tool 消息不支持图片）
+func (b *ContextBuilder) AddToolImages(messages []providers.Message, images []providers.ContentPart) []providers.Message {
+	if len(images) == 0 {
+		return messages
+	}
+	text := "Image loaded by the read_image tool."
+	if len(images) > 1 {
+		text = fmt.Sprintf("%d images loaded by the read_image tool.", len(images))
+	}
+	parts := append([]providers.ContentPart{{Type: "text", Text: text}}, images...)
+	return append(messages, providers.Message{
+		Role:    "user",
+		Content: text,
+		Parts:   parts,
+	})
+}
+
 // buildSystemPrompt 构建系统提示
 func (b *ContextBuilder) buildSystemPrompt(channel, chatID, currentMessage string, explicitSkillRefs []string) string {
 	var parts []string
@@ -470,6 +511,7 @@ func (b *ContextBuilder) buildMemoryHintsSection() string {
 	return strings.Join([]string{
 		"## Memory System",
 		fmt.Sprintf("- Long-term memory: %s (always loaded)", memoryPath),
+		"- To save a durable fact, call the memory tool with action=append (optionally with a section); use action=search to look up saved entries",
 		fmt.Sprintf("- History log: %s (append-only, grep-searchable, not auto-loaded)", historyPath),
 		fmt.Sprintf("- To recall past events, use exec with grep, for example: grep -i \"keyword\" %s", historyPath),
 	}, "\n")
@@ -700,7 +742,13 @@ func globPaths(pattern string) []string {
 
 // BuildSystemPromptWithPlan creates system prompt with plan context
 func (cb *ContextBuilder) BuildSystemPromptWithPlan(plan *Plan) string {
-	basePrompt := cb.buildSystemPrompt("", "", "", nil)
+	return cb.BuildSystemPromptWithPlanForChat(plan, "", "")
+}
+
+// BuildSystemPromptWithPlanForChat creates system prompt with plan context,
+// keeping the current channel/chat in the environment section
+func (cb *ContextBuilder) BuildSystemPromptWithPlanForChat(plan *Plan, channel, chatID string) string {
+	basePrompt := cb.buildSystemPrompt(channel, chatID, "", nil)
 
 	if plan == nil {
 		return basePrompt
@@ -734,7 +782,7 @@ func (cb *ContextBuilder) BuildMessagesWithPlanAndSkillRefs(
 	channel, chatID string,
 	plan *Plan,
 ) []providers.Message {
-	systemPrompt := cb.BuildSystemPromptWithPlan(plan)
+	systemPrompt := cb.BuildSystemPromptWithPlanForChat(plan, channel, chatID)
 	// Reuse existing logic from BuildMessagesWithSkillRefs but with our systemPrompt
 	messages := cb.BuildMessagesWithSkillRefs(history, userContent, skillRefs, media, channel, chatID)
 	if len(messages) > 0 && messages[0].Role == "system" {
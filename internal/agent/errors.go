@@ -0,0 +1,112 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/providers"
+)
+
+// 本轮处理失败的错误类别
+const (
+	ErrorClassAllProvidersFailed = "all_providers_failed"
+	ErrorClassAuth               = "auth"
+	ErrorClassRateLimit          = "rate_limit"
+	ErrorClassTimeout            = "timeout"
+	ErrorClassTool               = "tool"
+	ErrorClassUnknown            = "unknown"
+)
+
+// 各错误类别的内置用户提示
+const (
+	defaultAllProvidersFailedMessage = "Sorry, all AI models are unavailable right now. Please try again later."
+	defaultAuthErrorMessage          = "Sorry, I can't reach the AI model right now because it rejected the configured credentials. Please ask the administrator to check the API key."
+	defaultRateLimitErrorMessage     = "The AI service is busy or rate limited right now. Please try again in a moment."
+	defaultTimeoutErrorMessage       = "Sorry, this request took too long and was stopped. Please try again, or split it into smaller steps."
+	defaultToolErrorMessage          = "Sorry, a tool I needed failed while handling your request. Please try again."
+	defaultErrorMessage              = "Sorry, something went wrong while processing your message. Please try again."
+)
+
+var (
+	authErrorMarkers      = []string{"status 401", "status 403", "unauthorized", "forbidden", "api key", "api_key", "authentication"}
+	rateLimitErrorMarkers = []string{"status 429", "rate limit", "rate_limit", "too many requests", "quota"}
+	timeoutErrorMarkers   = []string{"timed out", "timeout", "deadline exceeded"}
+	// toolErrorPattern 匹配独立的 tool 单词，避免 tool_calls 之类的字段名误判
+	toolErrorPattern = regexp.MustCompile(`\btools?\b`)
+)
+
+// classifyTurnError 根据错误内容判断类别；provider 错误只有文本，按常见的状态码与关键字匹配。
+// 主模型与备用模型全部失败时单独归类，不再按其中某个模型的错误判断
+func classifyTurnError(err error) string {
+	if err == nil {
+		return ErrorClassUnknown
+	}
+	var allFailed *providers.AllProvidersFailedError
+	if errors.As(err, &allFailed) {
+		return ErrorClassAllProvidersFailed
+	}
+	text := strings.ToLower(err.Error())
+	switch {
+	case containsAny(text, authErrorMarkers):
+		return ErrorClassAuth
+	case containsAny(text, rateLimitErrorMarkers):
+		return ErrorClassRateLimit
+	case toolErrorPattern.MatchString(text):
+		return ErrorClassTool
+	case errors.Is(err, context.DeadlineExceeded) || containsAny(text, timeoutErrorMarkers):
+		return ErrorClassTimeout
+	default:
+		return ErrorClassUnknown
+	}
+}
+
+// SetErrorMessages 设置 Run 处理失败时按错误类别回复的提示（原始错误写入 gateway 日志），可在运行期间热更新
+func (a *AgentLoop) SetErrorMessages(messages config.ErrorMessagesConfig) {
+	a.runtimeMu.Lock()
+	defer a.runtimeMu.Unlock()
+	a.errorMessages = messages
+}
+
+func (a *AgentLoop) errorMessagesConfig() config.ErrorMessagesConfig {
+	a.runtimeMu.RLock()
+	defer a.runtimeMu.RUnlock()
+	return a.errorMessages
+}
+
+// friendlyErrorMessage 返回错误类别与回复给用户的提示，优先使用配置中的文案
+func friendlyErrorMessage(err error, messages config.ErrorMessagesConfig) (string, string) {
+	class := classifyTurnError(err)
+	configured, fallback := messages.Default, defaultErrorMessage
+	switch class {
+	case ErrorClassAllProvidersFailed:
+		configured, fallback = messages.AllProvidersFailed, defaultAllProvidersFailedMessage
+	case ErrorClassAuth:
+		configured, fallback = messages.Auth, defaultAuthErrorMessage
+	case ErrorClassRateLimit:
+		configured, fallback = messages.RateLimit, defaultRateLimitErrorMessage
+	case ErrorClassTimeout:
+		configured, fallback = messages.Timeout, defaultTimeoutErrorMessage
+	case ErrorClassTool:
+		configured, fallback = messages.Tool, defaultToolErrorMessage
+	}
+	if text := strings.TrimSpace(configured); text != "" {
+		return class, text
+	}
+	// 未单独配置的类别退回到自定义的通用提示
+	if text := strings.TrimSpace(messages.Default); text != "" {
+		return class, text
+	}
+	return class, fallback
+}
+
+func containsAny(text string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(text, marker) {
+			return true
+		}
+	}
+	return false
+}
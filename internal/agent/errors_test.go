@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFriendlyErrorMessageMapsEachClass(t *testing.T) {
+	messages := config.ErrorMessagesConfig{
+		Auth:               "auth problem",
+		RateLimit:          "slow down",
+		Timeout:            "too slow",
+		Tool:               "tool broke",
+		Default:            "generic problem",
+		AllProvidersFailed: "no model available",
+	}
+	allFailed := &providers.AllProvidersFailedError{
+		Models: []string{"gpt-4o", "deepseek-chat"},
+		Errors: []error{errors.New("status 401: bad key"), errors.New("status 429: rate limit")},
+	}
+
+	tests := []struct {
+		name  string
+		err   error
+		class string
+		text  string
+	}{
+		{"auth", errors.New("LLM stream error: chat completion failed: status 401: Incorrect API key provided"), ErrorClassAuth, "auth problem"},
+		{"missing key", errors.New(`no API key configured for provider "openai"`), ErrorClassAuth, "auth problem"},
+		{"rate limit", errors.New("LLM stream error: chat completion failed after 3 attempts: status 429: Rate limit reached"), ErrorClassRateLimit, "slow down"},
+		{"turn timeout", errors.New("turn timed out after 30s"), ErrorClassTimeout, "too slow"},
+		{"deadline", fmt.Errorf("LLM stream error: %w", context.DeadlineExceeded), ErrorClassTimeout, "too slow"},
+		{"tool", errors.New("tool web_fetch failed: dial tcp 10.0.0.5:443"), ErrorClassTool, "tool broke"},
+		{"tool_calls field is not a tool error", errors.New("LLM stream error: bad tool_calls payload"), ErrorClassUnknown, "generic problem"},
+		{"all providers failed", fmt.Errorf("LLM stream error: %w", allFailed), ErrorClassAllProvidersFailed, "no model available"},
+		{"unknown", errors.New("open /home/alice/.maxclaw/sessions/x.json: permission denied"), ErrorClassUnknown, "generic problem"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class, text := friendlyErrorMessage(tt.err, messages)
+			assert.Equal(t, tt.class, class)
+			assert.Equal(t, tt.text, text)
+		})
+	}
+}
+
+func TestFriendlyErrorMessageFallbacks(t *testing.T) {
+	rateLimited := errors.New("status 429: too many requests")
+
+	_, text := friendlyErrorMessage(rateLimited, config.ErrorMessagesConfig{})
+	assert.Equal(t, defaultRateLimitErrorMessage, text)
+
+	// 未配置的类别使用自定义的通用提示
+	_, text = friendlyErrorMessage(rateLimited, config.ErrorMessagesConfig{Default: "  oops  "})
+	assert.Equal(t, "oops", text)
+
+	_, text = friendlyErrorMessage(errors.New("boom"), config.ErrorMessagesConfig{})
+	assert.Equal(t, defaultErrorMessage, text)
+}
+
+type failingProvider struct {
+	err error
+}
+
+func (p *failingProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, p.err
+}
+
+func (p *failingProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	return p.err
+}
+
+func (p *failingProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func (p *failingProvider) SupportsImageInput(model string) bool {
+	return false
+}
+
+func TestAgentLoopRunRepliesWithFriendlyErrorAndLogsRawError(t *testing.T) {
+	lg, err := logging.Init(t.TempDir())
+	require.NoError(t, err)
+	require.NotNil(t, lg.Gateway)
+	var logs bytes.Buffer
+	lg.Gateway.SetOutput(&logs)
+
+	messageBus := bus.NewMessageBus(10)
+	rawErr := errors.New("chat completion failed: status 401: invalid key for https://llm.internal.example/v1")
+	loop := NewAgentLoop(
+		messageBus,
+		&failingProvider{err: rawErr},
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	loop.SetErrorMessages(config.ErrorMessagesConfig{Auth: "The assistant is not configured correctly."})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loop.Run(ctx)
+
+	require.NoError(t, messageBus.PublishInbound(bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi")))
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer waitCancel()
+	out, err := messageBus.ConsumeOutbound(waitCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "chat-1", out.ChatID)
+	assert.Equal(t, "The assistant is not configured correctly.", out.Content)
+	assert.NotContains(t, out.Content, "llm.internal.example")
+
+	logText := logs.String()
+	assert.Contains(t, logText, "turn failed")
+	assert.Contains(t, logText, "class=auth")
+	assert.Contains(t, logText, "https://llm.internal.example/v1")
+}
+
+func TestAgentLoopRunRepliesWhenAllProvidersFail(t *testing.T) {
+	lg, err := logging.Init(t.TempDir())
+	require.NoError(t, err)
+	var logs bytes.Buffer
+	lg.Gateway.SetOutput(&logs)
+
+	messageBus := bus.NewMessageBus(10)
+	provider := providers.NewFallbackProvider(
+		&failingProvider{err: errors.New("chat completion failed: status 401: invalid key")},
+		&failingProvider{err: errors.New("status 503: upstream overloaded")},
+		&failingProvider{err: errors.New("dial tcp 10.0.0.9:443: connection refused")},
+	)
+	loop := NewAgentLoop(
+		messageBus,
+		provider,
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	loop.SetErrorMessages(config.ErrorMessagesConfig{
+		Auth:               "auth problem",
+		AllProvidersFailed: "All models are down, please try again later.",
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loop.Run(ctx)
+
+	require.NoError(t, messageBus.PublishInbound(bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi")))
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer waitCancel()
+	out, err := messageBus.ConsumeOutbound(waitCtx)
+	require.NoError(t, err)
+	assert.Equal(t, "All models are down, please try again later.", out.Content)
+
+	logText := logs.String()
+	assert.Contains(t, logText, "all providers failed")
+	assert.Contains(t, logText, "class=all_providers_failed")
+	assert.Contains(t, logText, "connection refused")
+}
@@ -33,6 +33,8 @@ type InterruptibleContext struct {
 	onInterrupt func(InterruptRequest)
 	appendQueue []*bus.InboundMessage
 	parentBus   *bus.MessageBus
+	// sessionKey 本轮所属会话，供按会话取消时匹配
+	sessionKey string
 }
 
 // NewInterruptibleContext 创建可中断上下文
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/providers"
 )
 
 func TestInterruptibleContext_Cancel(t *testing.T) {
@@ -138,3 +139,50 @@ func TestInterruptibleContext_OnInterruptCallback(t *testing.T) {
 		t.Fatal("callback was not called")
 	}
 }
+
+// waitingProvider 通知调用开始后阻塞到 ctx 取消
+type waitingProvider struct {
+	started chan struct{}
+}
+
+func (p *waitingProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *waitingProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	close(p.started)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p *waitingProvider) GetDefaultModel() string          { return "test-model" }
+func (p *waitingProvider) SupportsImageInput(m string) bool { return false }
+
+func TestCancelSessionTurnMatchesSession(t *testing.T) {
+	provider := &waitingProvider{started: make(chan struct{})}
+	loop := newRepeatTestLoop(t, provider, 3)
+	defer loop.Close()
+
+	if loop.CancelSessionTurn("telegram:42") {
+		t.Fatal("no turn is running")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "42", "42", "hi"))
+		done <- err
+	}()
+	<-provider.started
+
+	if loop.CancelSessionTurn("telegram:7") {
+		t.Fatal("turn of another session must not be cancelled")
+	}
+	if !loop.CancelSessionTurn("telegram:42") {
+		t.Fatal("expected the running turn to be cancelled")
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("turn did not stop after cancel")
+	}
+}
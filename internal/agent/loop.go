@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -25,6 +26,10 @@ const (
 	sessionConsolidateThreshold  = 120
 	sessionConsolidateKeepRecent = 40
 	autoModeIterationMultiplier  = 5
+
+	// CLI 推理内容的暗色显示控制码
+	cliDim   = "\033[2m"
+	cliReset = "\033[0m"
 )
 
 // AgentLoop Agent 循环
@@ -35,27 +40,60 @@ type AgentLoop struct {
 	Model               string
 	MaxIterations       int
 	BraveAPIKey         string
+	WebSearchMaxResults int
 	WebFetchOptions     tools.WebFetchOptions
 	ExecConfig          config.ExecToolConfig
 	RestrictToWorkspace bool
 	CronService         *cron.Service
 	MCPServers          map[string]config.MCPServerConfig
+	// StreamToChannels 为 true 时 Run 处理的频道消息按段落流式发送，最终回复不再重复发送
+	StreamToChannels bool
+	// ToolNotices 控制 Run 处理频道消息时工具执行提示的详细程度（off / brief / verbose）
+	ToolNotices string
+	// MaxParallelTools 单轮内并发执行只读工具的上限，<=0 使用默认值，1 表示全部顺序执行
+	MaxParallelTools int
+	// ToolTimeout 单次工具调用的超时，<=0 表示不限制
+	ToolTimeout time.Duration
+	// TurnTimeout ProcessMessage / Run 处理单条消息的总超时，<=0 表示不限制
+	TurnTimeout time.Duration
+	// ToolCallWarnThreshold 单会话 10 分钟内工具调用超过该次数时记录告警，<=0 使用默认值
+	ToolCallWarnThreshold int
+	// RepeatedToolCallWindow 同一轮内记住的最近工具调用数，与其中某次完全相同（工具名 + 参数）的调用不再执行，<=0 使用默认值
+	RepeatedToolCallWindow int
 
 	context  *ContextBuilder
 	sessions *session.Manager
 	tools    *tools.Registry
 
+	toolStats *toolCallTracker
+	// abandonedTools 超时后仍在后台运行的工具调用
+	abandonedTools abandonedToolRuns
+
 	mcpConnector   *tools.MCPConnector
 	mcpConnectOnce sync.Once
 	runtimeMu      sync.RWMutex
 	executionMode  string
 
+	// 按频道覆盖的模型及其 provider 缓存，由 runtimeMu 保护
+	channelModels        map[string]string
+	modelProviderFactory ModelProviderFactory
+	modelProviders       map[string]providers.LLMProvider
+	// modelProvidersGen 每次丢弃 provider 缓存时递增，避免把按旧配置创建的 provider 写回缓存
+	modelProvidersGen uint64
+	// errorMessages Run 处理失败时按错误类别回复的提示，由 runtimeMu 保护
+	errorMessages config.ErrorMessagesConfig
+
 	// 中断处理相关
 	intentAnalyzer *IntentAnalyzer
 	currentIC      *InterruptibleContext
 	icMu           sync.RWMutex
 
 	PlanManager *PlanManager // Task plan manager for multi-step execution
+
+	// slash 命令，按注册顺序在 /help 中列出
+	commandsMu        sync.RWMutex
+	slashCommands     map[string]SlashCommand
+	slashCommandOrder []string
 }
 
 // StreamEvent is a structured event for UI streaming consumers.
@@ -80,7 +118,7 @@ func NewAgentLoop(
 	workspace string,
 	model string,
 	maxIterations int,
-	braveAPIKey string,
+	webSearch config.WebSearchConfig,
 	webFetch tools.WebFetchOptions,
 	execConfig config.ExecToolConfig,
 	restrictToWorkspace bool,
@@ -104,7 +142,8 @@ func NewAgentLoop(
 		Workspace:           workspace,
 		Model:               model,
 		MaxIterations:       maxIterations,
-		BraveAPIKey:         braveAPIKey,
+		BraveAPIKey:         webSearch.APIKey,
+		WebSearchMaxResults: webSearch.MaxResults,
 		WebFetchOptions:     webFetch,
 		ExecConfig:          execConfig,
 		RestrictToWorkspace: restrictToWorkspace,
@@ -115,6 +154,7 @@ func NewAgentLoop(
 		tools:               tools.NewRegistry(),
 		intentAnalyzer:      NewIntentAnalyzer(),
 		PlanManager:         NewPlanManager(workspace),
+		toolStats:           newToolCallTracker(),
 		executionMode:       config.ExecutionModeAsk,
 	}
 	loop.context.SetExecutionMode(loop.executionMode)
@@ -124,6 +164,7 @@ func NewAgentLoop(
 	}
 
 	loop.registerDefaultTools()
+	loop.registerDefaultSlashCommands()
 	return loop
 }
 
@@ -131,16 +172,41 @@ func NewAgentLoop(
 func (a *AgentLoop) registerDefaultTools() {
 	// 文件工具
 	a.tools.Register(tools.NewReadFileTool())
+	a.tools.Register(tools.NewReadImageTool())
+	a.tools.Register(tools.NewReadPDFTool())
+	a.tools.Register(tools.NewReadManyFilesTool())
 	a.tools.Register(tools.NewWriteFileTool())
 	a.tools.Register(tools.NewEditFileTool())
 	a.tools.Register(tools.NewListDirTool())
+	a.tools.Register(tools.NewStatTool())
+
+	// 长期记忆工具
+	a.tools.Register(tools.NewMemoryTool(a.Workspace))
 
 	// Shell 工具
-	a.tools.Register(tools.NewExecTool(a.Workspace, a.ExecConfig.Timeout, a.RestrictToWorkspace))
+	execTool, err := tools.NewExecToolWithPatterns(a.Workspace, a.ExecConfig.Timeout, a.RestrictToWorkspace,
+		a.ExecConfig.DangerousPatterns, a.ExecConfig.ReplaceDangerousDefaults)
+	if err != nil {
+		// 自定义模式非法时拒绝所有命令：回退到其他模式会悄悄放宽用户配置的限制
+		if lg := logging.Get(); lg != nil && lg.Tools != nil {
+			lg.Tools.Printf("exec disabled: invalid tools.exec.dangerousPatterns: %v", err)
+		}
+		execTool = tools.NewDisabledExecTool(a.Workspace, a.ExecConfig.Timeout, a.RestrictToWorkspace,
+			fmt.Errorf("invalid tools.exec.dangerousPatterns: %w", err))
+	}
+	execTool.AllowedCommands = a.ExecConfig.AllowedCommands
+	execTool.StreamOutput = a.ExecConfig.StreamOutput
+	execTool.SetMaxTimeout(a.ExecConfig.MaxTimeout)
+	execTool.SetRestrictedPrompt(a.ExecConfig.RestrictedPrompt)
+	a.tools.Register(execTool)
+	a.tools.Register(tools.NewExecOutputTool(execTool))
 
 	// Web 工具
-	a.tools.Register(tools.NewWebSearchTool(a.BraveAPIKey, 5))
-	a.tools.Register(tools.NewWebFetchTool(a.WebFetchOptions))
+	a.tools.Register(tools.NewWebSearchTool(a.BraveAPIKey, a.WebSearchMaxResults))
+	webFetchTool := tools.NewWebFetchTool(a.WebFetchOptions)
+	a.tools.Register(webFetchTool)
+	a.tools.Register(tools.NewWebFetchManyTool(webFetchTool))
+	a.tools.Register(tools.NewHTTPRequestTool(webFetchTool))
 	a.tools.Register(tools.NewBrowserTool(tools.BrowserOptionsFromWebFetch(a.WebFetchOptions)))
 
 	// 消息工具
@@ -171,11 +237,64 @@ func (a *AgentLoop) registerDefaultTools() {
 	})
 	a.tools.Register(spawnTool)
 
+	// 技能工具
+	a.tools.Register(tools.NewSkillsTool(skillsService{loop: a}))
+
+	// 待办清单工具
+	a.tools.Register(tools.NewTodoTool(a.Workspace))
+
 	// 定时任务工具
+	var cronService tools.CronService
 	if a.CronService != nil {
+		cronService = a.CronService
 		cronTool := tools.NewCronTool(a.CronService)
 		a.tools.Register(cronTool)
 	}
+
+	// 能力概览工具
+	a.tools.Register(tools.NewCapabilitiesTool(a.tools, skillsService{loop: a}, cronService))
+}
+
+// ListSpawnTasks 列出已注册 spawn 工具中正在运行的后台子任务
+func (a *AgentLoop) ListSpawnTasks() []*tools.SpawnTask {
+	tool, ok := a.tools.Get("spawn")
+	if !ok {
+		return nil
+	}
+	spawnTool, ok := tool.(*tools.SpawnTool)
+	if !ok {
+		return nil
+	}
+	return spawnTool.ListRunningTasks()
+}
+
+// SetSessionFormat 设置会话持久化格式（json / jsonl）
+func (a *AgentLoop) SetSessionFormat(format string) {
+	a.sessions.SetFormat(format)
+}
+
+// SetToolResultLimits 按工具名设置返回给模型的结果大小上限（字节）
+func (a *AgentLoop) SetToolResultLimits(limits map[string]int) {
+	a.tools.SetResultLimits(limits)
+}
+
+// SetFileExtensionPolicy 设置 write_file / edit_file 允许写入的扩展名
+func (a *AgentLoop) SetFileExtensionPolicy(policy tools.ExtensionPolicy) {
+	if tool, ok := a.tools.Get("write_file"); ok {
+		if writeTool, ok := tool.(*tools.WriteFileTool); ok {
+			writeTool.SetExtensions(policy)
+		}
+	}
+	if tool, ok := a.tools.Get("edit_file"); ok {
+		if editTool, ok := tool.(*tools.EditFileTool); ok {
+			editTool.SetExtensions(policy)
+		}
+	}
+}
+
+// RegisterTool 注册额外工具（如按配置开启的可选工具）
+func (a *AgentLoop) RegisterTool(tool tools.Tool) error {
+	return a.tools.Register(tool)
 }
 
 // Run 运行 Agent 循环
@@ -199,18 +318,19 @@ func (a *AgentLoop) Run(ctx context.Context) error {
 		}
 
 		// 处理消息
-		response, err := a.ProcessMessage(ctx, msg)
+		response, err := a.processInbound(ctx, msg, msg.Channel != "cli")
 		if err != nil {
-			// 发送错误响应
-			a.Bus.PublishOutbound(bus.NewOutboundMessage(
-				msg.Channel,
-				msg.ChatID,
-				fmt.Sprintf("Error: %v", err),
-			))
+			// 原始错误可能包含 URL、路径等内部信息，只写日志，用户只看到对应类别的提示
+			class, text := friendlyErrorMessage(err, a.errorMessagesConfig())
+			if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+				lg.Gateway.Error("turn failed", "channel", msg.Channel, "chat", msg.ChatID, "class", class, "error", err)
+			}
+			a.Bus.PublishOutbound(bus.NewReply(msg, text))
 			continue
 		}
 
-		if response != nil {
+		// 已经流式送达的回复不再重复发送
+		if response != nil && !response.Delivered {
 			a.Bus.PublishOutbound(response)
 		}
 	}
@@ -225,6 +345,10 @@ type streamHandler struct {
 	toolCalls         []providers.ToolCall
 	accumulatingCalls map[string]*providers.ToolCall
 	onDelta           func(string)
+	// onToolCallDelta 工具调用参数片段回调，用于向流式客户端实时展示正在生成的工具调用
+	onToolCallDelta func(id, name, delta string)
+	// onReasoning 推理内容回调；推理内容不写入最终回复
+	onReasoning func(token string)
 }
 
 func newStreamHandler(channel, chatID string, msgBus *bus.MessageBus, onDelta func(string)) *streamHandler {
@@ -244,6 +368,12 @@ func (h *streamHandler) OnContent(token string) {
 	}
 }
 
+func (h *streamHandler) OnReasoning(token string) {
+	if h.onReasoning != nil {
+		h.onReasoning(token)
+	}
+}
+
 func (h *streamHandler) OnToolCallStart(id, name string) {
 	h.accumulatingCalls[id] = &providers.ToolCall{
 		ID:       id,
@@ -255,6 +385,9 @@ func (h *streamHandler) OnToolCallStart(id, name string) {
 func (h *streamHandler) OnToolCallDelta(id, delta string) {
 	if tc, ok := h.accumulatingCalls[id]; ok {
 		tc.Function.Arguments += delta
+		if h.onToolCallDelta != nil && delta != "" {
+			h.onToolCallDelta(id, tc.Function.Name, delta)
+		}
 	}
 }
 
@@ -285,12 +418,57 @@ func (a *AgentLoop) runtimeSnapshot() (providers.LLMProvider, string, int) {
 	return a.Provider, a.Model, a.MaxIterations
 }
 
+// RuntimeLimits 运行期间可热更新的执行参数，字段含义同 AgentLoop 的同名字段
+type RuntimeLimits struct {
+	MaxParallelTools       int
+	ToolTimeout            time.Duration
+	TurnTimeout            time.Duration
+	ToolCallWarnThreshold  int
+	RepeatedToolCallWindow int
+}
+
+// UpdateRuntimeLimits 更新新请求使用的并发、超时与重复调用检测参数
+func (a *AgentLoop) UpdateRuntimeLimits(limits RuntimeLimits) {
+	a.runtimeMu.Lock()
+	defer a.runtimeMu.Unlock()
+	a.MaxParallelTools = limits.MaxParallelTools
+	a.ToolTimeout = limits.ToolTimeout
+	a.TurnTimeout = limits.TurnTimeout
+	a.ToolCallWarnThreshold = limits.ToolCallWarnThreshold
+	a.RepeatedToolCallWindow = limits.RepeatedToolCallWindow
+}
+
+func (a *AgentLoop) runtimeLimits() RuntimeLimits {
+	a.runtimeMu.RLock()
+	defer a.runtimeMu.RUnlock()
+	return RuntimeLimits{
+		MaxParallelTools:       a.MaxParallelTools,
+		ToolTimeout:            a.ToolTimeout,
+		TurnTimeout:            a.TurnTimeout,
+		ToolCallWarnThreshold:  a.ToolCallWarnThreshold,
+		RepeatedToolCallWindow: a.RepeatedToolCallWindow,
+	}
+}
+
 func (a *AgentLoop) executionModeSnapshot() string {
 	a.runtimeMu.RLock()
 	defer a.runtimeMu.RUnlock()
 	return config.NormalizeExecutionMode(a.executionMode)
 }
 
+// CancelSessionTurn 取消 sessionKey 正在处理的一轮对话；该会话没有进行中的对话时返回 false
+func (a *AgentLoop) CancelSessionTurn(sessionKey string) bool {
+	a.icMu.RLock()
+	ic := a.currentIC
+	a.icMu.RUnlock()
+
+	if ic == nil || sessionKey == "" || ic.sessionKey != sessionKey {
+		return false
+	}
+	ic.RequestInterrupt(InterruptRequest{Mode: InterruptCancel, Timestamp: time.Now()})
+	return true
+}
+
 // HandleInterruption 处理插话请求
 // explicitMode 为可选参数，如果提供则直接使用，否则通过意图分析判断
 func (a *AgentLoop) HandleInterruption(msg *bus.InboundMessage, explicitMode ...InterruptMode) InterruptMode {
@@ -343,8 +521,27 @@ func (a *AgentLoop) HandleInterruption(msg *bus.InboundMessage, explicitMode ...
 
 // ProcessMessage 处理单个消息（流式版本）
 func (a *AgentLoop) ProcessMessage(ctx context.Context, msg *bus.InboundMessage) (*bus.OutboundMessage, error) {
+	return a.processInbound(ctx, msg, false)
+}
+
+// processInbound 处理单个入站消息；toChannel 为 true 表示回复发往聊天频道，
+// 此时按配置流式发送段落和工具执行提示
+func (a *AgentLoop) processInbound(ctx context.Context, msg *bus.InboundMessage, toChannel bool) (*bus.OutboundMessage, error) {
+	turnTimeout := a.runtimeLimits().TurnTimeout
+	ctx, cancel := withTurnTimeout(ctx, turnTimeout)
+	defer cancel()
+	resp, err := a.processInboundWithContext(ctx, msg, toChannel)
+	if err != nil && turnTimeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("turn timed out after %s", turnTimeout)
+	}
+	return resp, err
+}
+
+// processInboundWithContext processInbound 的实际处理逻辑，ctx 已附带本轮超时
+func (a *AgentLoop) processInboundWithContext(ctx context.Context, msg *bus.InboundMessage, toChannel bool) (*bus.OutboundMessage, error) {
 	// 创建可中断上下文
 	ic := NewInterruptibleContext(ctx, a.Bus)
+	ic.sessionKey = msg.SessionKey
 
 	a.icMu.Lock()
 	a.currentIC = ic
@@ -361,7 +558,35 @@ func (a *AgentLoop) ProcessMessage(ctx context.Context, msg *bus.InboundMessage)
 	go a.checkIncomingMessages(ic, msg, stopCheck)
 	defer close(stopCheck)
 
-	return a.processMessageWithIC(ic, msg, nil, nil, "")
+	if !toChannel {
+		return a.processMessageWithIC(ic, msg, nil, nil, "")
+	}
+
+	var handlers []func(StreamEvent)
+	var streamer *channelStreamer
+	if a.StreamToChannels {
+		streamer = newChannelStreamer(a.Bus, msg)
+		handlers = append(handlers, streamer.handleEvent)
+	}
+	if notice := toolNoticeHandler(a.Bus, msg, a.ToolNotices); notice != nil {
+		handlers = append(handlers, notice)
+	}
+	if len(handlers) == 0 {
+		return a.processMessageWithIC(ic, msg, nil, nil, "")
+	}
+
+	resp, err := a.processMessageWithIC(ic, msg, nil, func(event StreamEvent) {
+		for _, handle := range handlers {
+			handle(event)
+		}
+	}, "")
+	if streamer != nil {
+		streamer.flush()
+		if resp != nil {
+			resp.Delivered = streamer.delivered(resp.Content)
+		}
+	}
+	return resp, err
 }
 
 // checkIncomingMessages 定期检查是否有同一会话的新消息
@@ -412,6 +637,21 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 	// 获取或创建会话
 	sess := a.sessions.GetOrCreate(msg.SessionKey)
 
+	// 未显式指定模型时使用频道配置的模型
+	modelOverride = strings.TrimSpace(modelOverride)
+	if modelOverride == "" {
+		modelOverride = a.channelModel(msg.Channel)
+	}
+
+	// slash 命令在恢复计划、合并补充消息之前处理，不调用模型
+	commandModel := modelOverride
+	if commandModel == "" {
+		_, commandModel, _ = a.runtimeSnapshot()
+	}
+	if resp, handled := a.handleSlashCommand(ctx, msg, sess, commandModel); handled {
+		return resp, nil
+	}
+
 	// Load existing plan or check for continue intent
 	plan, _ := a.PlanManager.Load(msg.SessionKey)
 	executionMode := a.executionModeSnapshot()
@@ -443,26 +683,6 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 		}
 	}
 
-	// 统一 slash 命令
-	cmd := strings.TrimSpace(strings.ToLower(msg.Content))
-	switch cmd {
-	case "/new":
-		if _, err := memory.ArchiveSessionAll(a.Workspace, sess); err != nil {
-			if lg := logging.Get(); lg != nil && lg.Session != nil {
-				lg.Session.Printf("archive session on /new failed: %v", err)
-			}
-		}
-		sess.Clear()
-		_ = a.sessions.Save(sess)
-		return bus.NewOutboundMessage(msg.Channel, msg.ChatID, "New session started."), nil
-	case "/help":
-		return bus.NewOutboundMessage(
-			msg.Channel,
-			msg.ChatID,
-			"maxclaw commands:\n/new - Start a new conversation\n/help - Show available commands",
-		), nil
-	}
-
 	// Persist user input before long-running model execution so session list
 	// can reflect in-flight conversations immediately.
 	sess.AddMessage("user", msg.Content)
@@ -477,6 +697,10 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 
 	// 构建消息
 	selectedSkillRefs := normalizeSkillRefs(msg.SelectedSkills)
+	if len(selectedSkillRefs) == 0 && !skills.HasRefs(msg.Content) {
+		// 消息中没有显式选择技能时使用会话上激活的技能
+		selectedSkillRefs = normalizeSkillRefs(sess.ActiveSkills)
+	}
 
 	// Build messages with plan context if exists
 	var messages []providers.Message
@@ -485,14 +709,18 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 	} else {
 		messages = a.context.BuildMessagesWithSkillRefs(history, msg.Content, selectedSkillRefs, msg.Media, msg.Channel, msg.ChatID)
 	}
+	messages = a.context.WithTodoList(messages, msg.SessionKey)
 
 	// Agent 循环
 	var finalContent string
+	finalStreamed := false
 	maxIterationReached := true
 	toolDefs := a.tools.GetDefinitions()
-	_, activeModel, maxIterations := a.runtimeSnapshot()
-	if strings.TrimSpace(modelOverride) != "" {
-		activeModel = strings.TrimSpace(modelOverride)
+	_, _, maxIterations := a.runtimeSnapshot()
+	// 本轮使用的 provider 在循环前确定，覆盖模型不可用时状态中显示实际回退的默认模型
+	provider, activeModel := a.providerForModel(modelOverride)
+	if provider == nil {
+		return nil, fmt.Errorf("LLM provider is not configured")
 	}
 	effectiveMaxIterations := maxIterations
 	if executionMode == config.ExecutionModeAuto {
@@ -507,6 +735,9 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 
 	stepDetector := NewStepDetector()
 	iterationsInCurrentStep := 0
+	turnToolCalls := 0
+	repeats := newRepeatedCallDetector(a.effectiveRepeatedToolCallWindow())
+	stuckTool := ""
 
 	for i := 0; i < effectiveMaxIterations; i++ {
 		iteration := i + 1
@@ -525,8 +756,19 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 		})
 
 		deltaCallback := onDelta
+		var reasoningCallback func(string)
 		if deltaCallback == nil && msg.Channel == "cli" {
+			// CLI 中推理内容以暗色显示，正文开始前换行分隔
+			reasoningOpen := false
+			reasoningCallback = func(token string) {
+				reasoningOpen = true
+				fmt.Print(cliDim + token + cliReset)
+			}
 			deltaCallback = func(delta string) {
+				if reasoningOpen {
+					reasoningOpen = false
+					fmt.Print("\n\n")
+				}
 				fmt.Print(delta)
 			}
 		}
@@ -543,14 +785,29 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 
 		// 流式调用 LLM
 		handler := newStreamHandler(msg.Channel, msg.ChatID, a.Bus, streamCallback)
-		provider, model, _ := a.runtimeSnapshot()
-		if provider == nil {
-			return nil, fmt.Errorf("LLM provider is not configured")
+		handler.onReasoning = func(token string) {
+			if reasoningCallback != nil {
+				reasoningCallback(token)
+			}
+			emitEvent(StreamEvent{
+				Type:      "reasoning_delta",
+				Delta:     token,
+				Iteration: iteration,
+			})
 		}
-
-		err := provider.ChatStream(ctx, messages, toolDefs, model, handler)
+		handler.onToolCallDelta = func(id, name, delta string) {
+			emitEvent(StreamEvent{
+				Type:      "tool_call_delta",
+				Iteration: iteration,
+				ToolID:    id,
+				ToolName:  name,
+				Delta:     delta,
+			})
+		}
+		// 所有 agent（含子代理、定时任务）共享全局并发上限，超出时排队等待
+		err := providers.WithRequestLimit(provider, providers.DefaultRequestLimiter).ChatStream(ctx, messages, toolDefs, activeModel, handler)
 		if err != nil {
-			if err == context.Canceled {
+			if errors.Is(err, context.Canceled) {
 				return nil, err
 			}
 			return nil, fmt.Errorf("LLM stream error: %w", err)
@@ -585,6 +842,7 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 
 			// Rebuild messages with plan context
 			messages = a.context.BuildMessagesWithPlanAndSkillRefs(history, msg.Content, selectedSkillRefs, msg.Media, msg.Channel, msg.ChatID, plan)
+			messages = a.context.WithTodoList(messages, msg.SessionKey)
 		}
 
 		// 处理工具调用
@@ -598,8 +856,21 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 			// 添加助手消息（带工具调用）
 			messages = a.context.AddAssistantMessage(messages, content, toolCalls)
 
-			// 执行工具调用并显示结果
-			for _, tc := range toolCalls {
+			// 执行工具调用并显示结果：连续的只读工具并发执行，结果仍按调用顺序写回
+			results := make([]toolExecResult, len(toolCalls))
+			callArgs := make([]map[string]interface{}, len(toolCalls))
+			callKeys := make([]string, len(toolCalls))
+			repeated := make([]bool, len(toolCalls))
+			var images []providers.ContentPart
+			for idx, tc := range toolCalls {
+				var args map[string]interface{}
+				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
+					args = map[string]interface{}{}
+				}
+				callArgs[idx] = args
+				callKeys[idx] = toolCallKey(tc.Function.Name, args)
+			}
+			for _, batch := range planToolBatches(toolCalls, a.tools.IsConcurrencySafe) {
 				// 检查是否被取消
 				select {
 				case <-ic.Done():
@@ -607,46 +878,95 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 				default:
 				}
 
-				emitEvent(StreamEvent{
-					Type:      "tool_start",
-					Iteration: iteration,
-					ToolID:    tc.ID,
-					ToolName:  tc.Function.Name,
-					ToolArgs:  truncateEventText(tc.Function.Arguments, 600),
-					Summary:   summarizeToolStart(tc.Function.Name, tc.Function.Arguments),
-				})
-
-				var args map[string]interface{}
-				if err := json.Unmarshal([]byte(tc.Function.Arguments), &args); err != nil {
-					args = map[string]interface{}{}
+				// 与本轮最近调用完全相同的调用不再执行，直接把上次结果告诉模型
+				for _, idx := range batch {
+					name := toolCalls[idx].Function.Name
+					if a.tools.IsRepeatable(name, callArgs[idx]) {
+						continue
+					}
+					if prior, ok := repeats.lookup(callKeys[idx]); ok {
+						repeated[idx] = true
+						results[idx] = toolExecResult{result: repeatedToolCallResult(name, prior)}
+						if repeats.hit(callKeys[idx]) >= maxRepeatedToolCallHits {
+							stuckTool = name
+						}
+					}
 				}
 
-				toolCtx := tools.WithRuntimeContextWithSession(ctx, msg.Channel, msg.ChatID, msg.SessionKey)
-				result, execErr := a.tools.Execute(toolCtx, tc.Function.Name, args)
-				if execErr != nil {
-					result = fmt.Sprintf("Error: %v", execErr)
+				for _, idx := range batch {
+					tc := toolCalls[idx]
+					emitEvent(StreamEvent{
+						Type:      "tool_start",
+						Iteration: iteration,
+						ToolID:    tc.ID,
+						ToolName:  tc.Function.Name,
+						ToolArgs:  truncateEventText(tc.Function.Arguments, 600),
+						Summary:   summarizeToolStart(tc.Function.Name, tc.Function.Arguments),
+					})
 				}
 
-				if lg := logging.Get(); lg != nil && lg.Tools != nil {
-					lg.Tools.Printf("tool name=%s args=%q result_len=%d", tc.Function.Name, logging.Truncate(tc.Function.Arguments, 300), len(result))
-				}
-
-				// 显示工具执行结果
-				if msg.Channel == "cli" {
-					fmt.Printf("[Result: %s]\n%s\n\n", tc.Function.Name, result)
-				}
-
-				emitEvent(StreamEvent{
-					Type:       "tool_result",
-					Iteration:  iteration,
-					ToolID:     tc.ID,
-					ToolName:   tc.Function.Name,
-					ToolResult: truncateEventText(result, 2000),
-					Summary:    summarizeToolResult(tc.Function.Name, result, execErr),
+				runToolBatch(batch, a.effectiveMaxParallelTools(), func(idx int) {
+					if repeated[idx] {
+						return
+					}
+					tc := toolCalls[idx]
+					args := callArgs[idx]
+
+					toolCtx := tools.WithRuntimeContextWithSession(ctx, msg.Channel, msg.ChatID, msg.SessionKey)
+					if onOutput := toolOutputHandler(msg.Channel, tc, iteration, onDelta, onEvent); onOutput != nil {
+						toolCtx = tools.WithOutputHandler(toolCtx, onOutput)
+					}
+					result, execErr := a.executeToolWithTimeout(toolCtx, tc.Function.Name, args)
+					if execErr != nil {
+						result = fmt.Sprintf("Error: %v", execErr)
+					}
+					results[idx] = toolExecResult{result: result, err: execErr}
 				})
 
-				messages = a.context.AddToolResult(messages, tc.ID, tc.Function.Name, result)
+				for _, idx := range batch {
+					tc := toolCalls[idx]
+					result, execErr := results[idx].result, results[idx].err
+
+					// read_image 的图片结果不以 base64 文本进入上下文，改为在工具结果之后以多模态消息附上；
+					// 其他工具恰好返回的 data URL 与被截断的结果按普通文本处理
+					if tc.Function.Name == "read_image" && execErr == nil {
+						if mimeType, size, ok := tools.ParseImageDataURL(result); ok {
+							images = append(images, providers.ContentPart{Type: "image_url", ImageURL: result, MimeType: mimeType})
+							result = fmt.Sprintf("Image loaded (%s, %d bytes); it is attached in the next message.", mimeType, size)
+						}
+					}
+
+					if repeated[idx] {
+						if lg := logging.Get(); lg != nil && lg.Tools != nil {
+							lg.Tools.Printf("tool repeated name=%s args=%q skipped", tc.Function.Name, logging.Truncate(tc.Function.Arguments, 300))
+						}
+					} else {
+						repeats.record(callKeys[idx], result, !a.tools.IsConcurrencySafe(tc.Function.Name))
+						if lg := logging.Get(); lg != nil && lg.Tools != nil {
+							lg.Tools.Printf("tool name=%s args=%q result_len=%d", tc.Function.Name, logging.Truncate(tc.Function.Arguments, 300), len(result))
+						}
+					}
+
+					// 显示工具执行结果
+					if msg.Channel == "cli" {
+						fmt.Printf("[Result: %s]\n%s\n\n", tc.Function.Name, result)
+					}
+
+					emitEvent(StreamEvent{
+						Type:       "tool_result",
+						Iteration:  iteration,
+						ToolID:     tc.ID,
+						ToolName:   tc.Function.Name,
+						ToolResult: truncateEventText(result, 2000),
+						Summary:    summarizeToolResult(tc.Function.Name, result, execErr),
+					})
+
+					messages = a.context.AddToolResult(messages, tc.ID, tc.Function.Name, result)
+				}
 			}
+			messages = a.context.AddToolImages(messages, images)
+			turnToolCalls += len(toolCalls)
+			a.toolStats.record(msg.SessionKey, len(toolCalls), a.runtimeLimits().ToolCallWarnThreshold)
 
 			// After tool execution, update plan and refresh messages with latest plan context
 			if plan != nil && plan.Status == PlanStatusRunning {
@@ -671,12 +991,29 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 
 				// Update system message with latest plan context for next iteration
 				if len(messages) > 0 && messages[0].Role == "system" {
-					messages[0].Content = a.context.BuildSystemPromptWithPlan(plan)
+					messages[0].Content = a.context.BuildSystemPromptWithPlanForChat(plan, msg.Channel, msg.ChatID)
+				}
+			}
+			// 工具可能更新了待办清单，下一次迭代使用最新内容
+			messages = a.context.WithTodoList(messages, msg.SessionKey)
+
+			// 被告知后仍重复同一调用，继续迭代只会浪费请求
+			if stuckTool != "" {
+				if lg := logging.Get(); lg != nil && lg.Session != nil {
+					lg.Session.Printf("turn stopped: repeated tool call session=%s tool=%s iteration=%d", msg.SessionKey, stuckTool, iteration)
 				}
+				finalContent = fmt.Sprintf("Stopped: the model kept repeating the same %s call with identical arguments.", stuckTool)
+				maxIterationReached = false
+				if plan != nil && plan.Status == PlanStatusRunning {
+					plan.Status = PlanStatusPaused
+					a.PlanManager.Save(msg.SessionKey, plan)
+				}
+				break
 			}
 		} else {
 			// 没有工具调用，但可能有步骤声明或任务完成
 			finalContent = content
+			finalStreamed = true
 			maxIterationReached = false
 
 			// Update plan: extract step declarations even without tool calls
@@ -699,6 +1036,7 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 	}
 
 	if finalContent == "" {
+		finalStreamed = false
 		if maxIterationReached {
 			finalContent = fmt.Sprintf("Reached %d iterations without completion.", effectiveMaxIterations)
 
@@ -720,6 +1058,8 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 		}
 	}
 
+	a.toolStats.finishTurn(msg.SessionKey, turnToolCalls)
+
 	if lg := logging.Get(); lg != nil && lg.Session != nil {
 		lg.Session.Printf("outbound channel=%s chat=%s content=%q", msg.Channel, msg.ChatID, logging.Truncate(finalContent, 400))
 	}
@@ -740,7 +1080,10 @@ func (a *AgentLoop) processMessageWithIC(ic *InterruptibleContext, msg *bus.Inbo
 	}
 	a.sessions.Save(sess)
 
-	return bus.NewOutboundMessage(msg.Channel, msg.ChatID, finalContent), nil
+	out := bus.NewReply(msg, finalContent)
+	// CLI 模式下最终回复已在流式过程中打印
+	out.Delivered = finalStreamed && msg.Channel == "cli" && onDelta == nil && onEvent == nil
+	return out, nil
 }
 
 // summarizeTimeline extracts a summary from timeline entries
@@ -770,6 +1113,9 @@ func (a *AgentLoop) UpdateRuntimeModel(provider providers.LLMProvider, model str
 	if model != "" {
 		a.Model = model
 	}
+	// 覆盖模型的 provider 可能依赖旧配置，按需重新创建
+	a.modelProviders = nil
+	a.modelProvidersGen++
 }
 
 // UpdateRuntimeMaxIterations updates the max iteration limit used by new requests.
@@ -820,11 +1166,8 @@ func (a *AgentLoop) ProcessDirectWithMediaAndSkills(
 	if err != nil {
 		return "", err
 	}
-	if resp == nil {
-		return "", nil
-	}
-	// CLI 模式下流式输出已实时打印，返回空字符串避免重复输出
-	if channel == "cli" {
+	// 已流式送达（如 CLI 实时打印）时返回空字符串避免重复输出
+	if resp == nil || resp.Delivered {
 		return "", nil
 	}
 	return resp.Content, nil
@@ -989,6 +1332,7 @@ func (a *AgentLoop) ProcessDirectStream(
 
 	// 创建可中断上下文
 	ic := NewInterruptibleContext(ctx, a.Bus)
+	ic.sessionKey = msg.SessionKey
 	a.icMu.Lock()
 	a.currentIC = ic
 	a.icMu.Unlock()
@@ -1042,6 +1386,7 @@ func (a *AgentLoop) ProcessDirectEventStreamWithMediaAndSkills(
 
 	// 创建可中断上下文
 	ic := NewInterruptibleContext(ctx, a.Bus)
+	ic.sessionKey = msg.SessionKey
 	a.icMu.Lock()
 	a.currentIC = ic
 	a.icMu.Unlock()
@@ -1127,6 +1472,27 @@ func sanitizeSkillRef(raw string) string {
 	return b.String()
 }
 
+// toolOutputHandler 把工具的增量输出转发给事件流；纯 CLI 模式直接打印
+func toolOutputHandler(channel string, tc providers.ToolCall, iteration int, onDelta func(string), onEvent func(StreamEvent)) tools.OutputFunc {
+	if onEvent != nil {
+		return func(chunk string) {
+			onEvent(StreamEvent{
+				Type:      "tool_output",
+				Iteration: iteration,
+				ToolID:    tc.ID,
+				ToolName:  tc.Function.Name,
+				Delta:     chunk,
+			})
+		}
+	}
+	if channel == "cli" && onDelta == nil {
+		return func(chunk string) {
+			fmt.Print(chunk)
+		}
+	}
+	return nil
+}
+
 func appendTimelineFromEvent(timeline []session.TimelineEntry, event StreamEvent) []session.TimelineEntry {
 	switch event.Type {
 	case "content_delta":
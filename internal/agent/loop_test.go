@@ -1,7 +1,11 @@
 package agent
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"image"
+	"image/png"
 	"os"
 	"path/filepath"
 	"strings"
@@ -71,15 +75,19 @@ func (p *staticProvider) SupportsImageInput(model string) bool {
 	return false
 }
 
-type endlessToolProvider struct{}
+// endlessToolProvider 每次都调用工具、从不给出最终回复；参数每次不同，避免被重复调用检测拦截
+type endlessToolProvider struct {
+	calls int
+}
 
 func (p *endlessToolProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
 	return nil, nil
 }
 
 func (p *endlessToolProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	p.calls++
 	handler.OnToolCallStart("call_1", "does_not_exist")
-	handler.OnToolCallDelta("call_1", `{}`)
+	handler.OnToolCallDelta("call_1", fmt.Sprintf(`{"attempt":%d}`, p.calls))
 	handler.OnToolCallEnd("call_1")
 	handler.OnComplete()
 	return nil
@@ -180,7 +188,7 @@ func TestAgentLoopProcessMessageInjectsRuntimeContextForCron(t *testing.T) {
 		workspace,
 		"test-model",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -202,6 +210,267 @@ func TestAgentLoopProcessMessageInjectsRuntimeContextForCron(t *testing.T) {
 	assert.Equal(t, "Ping me", jobs[0].Payload.Message)
 }
 
+// messageToolProvider 首轮调用 message 工具，拿到工具结果后结束
+type messageToolProvider struct{}
+
+func (p *messageToolProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *messageToolProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	if len(messages) > 0 && messages[len(messages)-1].Role == "tool" {
+		handler.OnContent("ok")
+		handler.OnComplete()
+		return nil
+	}
+	handler.OnToolCallStart("tool_1", "message")
+	handler.OnToolCallDelta("tool_1", `{"content":"notice"}`)
+	handler.OnToolCallEnd("tool_1")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *messageToolProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func (p *messageToolProvider) SupportsImageInput(model string) bool {
+	return false
+}
+
+func TestAgentLoopProcessMessageRoutesToolOutputToPerCallChat(t *testing.T) {
+	workspace := t.TempDir()
+	messageBus := bus.NewMessageBus(10)
+
+	loop := NewAgentLoop(
+		messageBus,
+		&messageToolProvider{},
+		workspace,
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	for _, target := range []struct{ channel, chatID string }{
+		{"telegram", "chat-a"},
+		{"discord", "chat-b"},
+	} {
+		msg := bus.NewInboundMessage(target.channel, "user-1", target.chatID, "hello")
+		_, err := loop.ProcessMessage(context.Background(), msg)
+		require.NoError(t, err)
+
+		out, ok := messageBus.TryConsumeOutbound()
+		require.True(t, ok)
+		assert.Equal(t, target.channel, out.Channel)
+		assert.Equal(t, target.chatID, out.ChatID)
+		assert.Equal(t, "notice", out.Content)
+	}
+}
+
+func TestAgentLoopUsesConfiguredWebSearchMaxResults(t *testing.T) {
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		&staticProvider{},
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{APIKey: "brave-key", MaxResults: 8},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	tool, ok := loop.tools.Get("web_search")
+	require.True(t, ok)
+	searchTool, ok := tool.(*tools.WebSearchTool)
+	require.True(t, ok)
+	assert.Equal(t, 8, searchTool.MaxResults)
+	assert.Equal(t, "brave-key", searchTool.APIKey)
+}
+
+// paragraphProvider 首轮先说明再调用 message 工具，第二轮输出两段最终回复
+type paragraphProvider struct{}
+
+func (p *paragraphProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *paragraphProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	if len(messages) > 0 && messages[len(messages)-1].Role == "tool" {
+		for _, token := range []string{"First para", "graph.\n", "\nSecond ", "paragraph."} {
+			handler.OnContent(token)
+		}
+		handler.OnComplete()
+		return nil
+	}
+	handler.OnContent("Checking...")
+	handler.OnToolCallStart("tool_1", "list_dir")
+	handler.OnToolCallDelta("tool_1", `{"path":"."}`)
+	handler.OnToolCallEnd("tool_1")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *paragraphProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func (p *paragraphProvider) SupportsImageInput(model string) bool {
+	return false
+}
+
+func TestAgentLoopRunStreamsToChannelWithoutResendingFinal(t *testing.T) {
+	workspace := t.TempDir()
+	messageBus := bus.NewMessageBus(10)
+
+	loop := NewAgentLoop(
+		messageBus,
+		&paragraphProvider{},
+		workspace,
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	loop.StreamToChannels = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loop.Run(ctx)
+
+	require.NoError(t, messageBus.PublishInbound(bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi")))
+
+	var contents []string
+	for {
+		waitCtx, waitCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		out, err := messageBus.ConsumeOutbound(waitCtx)
+		waitCancel()
+		if err != nil {
+			break
+		}
+		assert.Equal(t, "telegram", out.Channel)
+		assert.Equal(t, "chat-1", out.ChatID)
+		contents = append(contents, out.Content)
+	}
+
+	assert.Equal(t, []string{"Checking...", "First paragraph.", "Second paragraph."}, contents)
+}
+
+func TestAgentLoopRunSendsToolNoticesToChannel(t *testing.T) {
+	messageBus := bus.NewMessageBus(10)
+
+	loop := NewAgentLoop(
+		messageBus,
+		&paragraphProvider{},
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	loop.ToolNotices = config.ToolNoticesBrief
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go loop.Run(ctx)
+
+	inbound := bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi")
+	inbound.MessageID = "msg-7"
+	require.NoError(t, messageBus.PublishInbound(inbound))
+
+	var contents []string
+	for {
+		waitCtx, waitCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		out, err := messageBus.ConsumeOutbound(waitCtx)
+		waitCancel()
+		if err != nil {
+			break
+		}
+		assert.Equal(t, "telegram", out.Channel)
+		assert.Equal(t, "chat-1", out.ChatID)
+		// 提示与最终回复都关联到触发本轮的入站消息
+		assert.Equal(t, "msg-7", out.ReplyTo)
+		contents = append(contents, out.Content)
+	}
+
+	assert.Equal(t, []string{"🔧 ran list_dir", "First paragraph.\n\nSecond paragraph."}, contents)
+}
+
+func TestAgentLoopEndToEndWithMockProvider(t *testing.T) {
+	workspace := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "notes.txt"), []byte("hi"), 0644))
+
+	provider, err := providers.NewProvider("", "", "", providers.MockModel, 0, 0, nil)
+	require.NoError(t, err)
+
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		workspace,
+		providers.MockModel,
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "echo: hello", resp.Content)
+
+	script := fmt.Sprintf(`tool:read_file {"path":%q}`, filepath.Join(workspace, "notes.txt"))
+	resp, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", script))
+	require.NoError(t, err)
+	assert.Equal(t, "tool result: hi", resp.Content)
+}
+
+func TestAgentLoopProcessMessageWithoutStreamingIsNotDelivered(t *testing.T) {
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		&paragraphProvider{},
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	loop.StreamToChannels = true
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.False(t, resp.Delivered)
+	assert.Equal(t, "First paragraph.\n\nSecond paragraph.", resp.Content)
+}
+
 func TestAgentLoopProcessMessageMCPFailureDoesNotBreakMainFlow(t *testing.T) {
 	workspace := t.TempDir()
 	messageBus := bus.NewMessageBus(10)
@@ -214,7 +483,7 @@ func TestAgentLoopProcessMessageMCPFailureDoesNotBreakMainFlow(t *testing.T) {
 		workspace,
 		"test-model",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -244,7 +513,7 @@ func TestAgentLoopProcessDirectUsesProvidedSessionKey(t *testing.T) {
 		workspace,
 		"test-model",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -278,7 +547,7 @@ func TestAgentLoopProcessesPureImageWithoutAgentSideShortCircuit(t *testing.T) {
 		workspace,
 		"glm-5",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -310,7 +579,7 @@ func TestAgentLoopProcessMessageSlashHelp(t *testing.T) {
 		workspace,
 		"test-model",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -342,7 +611,7 @@ func TestAgentLoopProcessMessageSlashNewClearsSession(t *testing.T) {
 		workspace,
 		"test-model",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -384,7 +653,7 @@ func TestAgentLoopProcessMessageMaxIterationFallback(t *testing.T) {
 		workspace,
 		"test-model",
 		2,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -412,7 +681,7 @@ func TestAgentLoopProcessMessageMaxIterationAutoModeUsesExpandedBudget(t *testin
 		workspace,
 		"test-model",
 		2,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -442,7 +711,7 @@ func TestAgentLoopAutoModeResumesPausedPlanWithoutContinue(t *testing.T) {
 		workspace,
 		"test-model",
 		2,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -482,7 +751,7 @@ func TestAgentLoopProcessMessageAutoConsolidatesWhenSessionLarge(t *testing.T) {
 		workspace,
 		"test-model",
 		2,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -511,6 +780,96 @@ func TestAgentLoopProcessMessageAutoConsolidatesWhenSessionLarge(t *testing.T) {
 	assert.Contains(t, string(body), "session: telegram:chat-42")
 }
 
+type fragmentedToolArgsProvider struct {
+	callCount int
+}
+
+func (p *fragmentedToolArgsProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *fragmentedToolArgsProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	defer func() { p.callCount++ }()
+	if p.callCount == 0 {
+		handler.OnToolCallStart("tool_1", "list_dir")
+		handler.OnToolCallDelta("tool_1", `{"pa`)
+		handler.OnToolCallDelta("tool_1", `th":`)
+		handler.OnToolCallDelta("tool_1", `"."}`)
+		handler.OnToolCallEnd("tool_1")
+		handler.OnComplete()
+		return nil
+	}
+
+	handler.OnContent("done")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *fragmentedToolArgsProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func (p *fragmentedToolArgsProvider) SupportsImageInput(model string) bool {
+	return false
+}
+
+func TestAgentLoopProcessDirectEventStreamEmitsToolCallArgumentDeltas(t *testing.T) {
+	workspace := t.TempDir()
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		&fragmentedToolArgsProvider{},
+		workspace,
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	var types []string
+	var argDeltas []StreamEvent
+	_, err := loop.ProcessDirectEventStream(
+		context.Background(),
+		"list files",
+		"desktop:args",
+		"desktop",
+		"chat-1",
+		func(event StreamEvent) {
+			types = append(types, event.Type)
+			if event.Type == "tool_call_delta" {
+				argDeltas = append(argDeltas, event)
+			}
+		},
+	)
+	require.NoError(t, err)
+
+	require.Len(t, argDeltas, 3)
+	var args strings.Builder
+	for _, event := range argDeltas {
+		assert.Equal(t, "tool_1", event.ToolID)
+		assert.Equal(t, "list_dir", event.ToolName)
+		assert.Equal(t, 1, event.Iteration)
+		args.WriteString(event.Delta)
+	}
+	assert.Equal(t, `{"path":"."}`, args.String())
+
+	firstDelta, toolStart := -1, -1
+	for i, typ := range types {
+		if typ == "tool_call_delta" && firstDelta < 0 {
+			firstDelta = i
+		}
+		if typ == "tool_start" && toolStart < 0 {
+			toolStart = i
+		}
+	}
+	require.GreaterOrEqual(t, toolStart, 0)
+	assert.Less(t, firstDelta, toolStart, "argument deltas should arrive before the tool runs")
+}
+
 func TestAgentLoopProcessDirectEventStreamEmitsStructuredEvents(t *testing.T) {
 	workspace := t.TempDir()
 	messageBus := bus.NewMessageBus(10)
@@ -522,7 +881,7 @@ func TestAgentLoopProcessDirectEventStreamEmitsStructuredEvents(t *testing.T) {
 		workspace,
 		"test-model",
 		3,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -610,7 +969,7 @@ func TestProcessDirectWithSkillsUsesOnlySelectedSkills(t *testing.T) {
 		workspace,
 		"test-model",
 		2,
-		"",
+		config.WebSearchConfig{},
 		tools.WebFetchOptions{},
 		config.ExecToolConfig{Timeout: 5},
 		false,
@@ -675,3 +1034,177 @@ func TestAgentLoop_PlanManagerIntegration(t *testing.T) {
 		t.Error("expected plan to not exist after delete")
 	}
 }
+
+func TestAgentLoopSpawnRunsNestedTurnAndReportsBack(t *testing.T) {
+	messageBus := bus.NewMessageBus(10)
+	loop := NewAgentLoop(
+		messageBus,
+		&staticProvider{},
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	result, err := loop.executeSpawnRequest(context.Background(), tools.SpawnRequest{
+		Task:             "check the build",
+		Label:            "build",
+		NotifyParent:     true,
+		Channel:          "telegram",
+		ChatID:           "chat-1",
+		ParentSessionKey: "telegram:chat-1",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result.Message)
+	assert.True(t, strings.HasPrefix(result.SessionKey, "telegram:chat-1:spawn:"))
+
+	started, ok := messageBus.TryConsumeOutbound()
+	require.True(t, ok)
+	assert.Contains(t, started.Content, "[Spawn] Started `build`")
+	completed, ok := messageBus.TryConsumeOutbound()
+	require.True(t, ok)
+	assert.Equal(t, "chat-1", completed.ChatID)
+	assert.Contains(t, completed.Content, "[Spawn] Completed `build`")
+}
+
+// imageToolProvider 第一次调用 tool（默认 read_image），之后直接回复，并记录每次请求的消息
+type imageToolProvider struct {
+	path     string
+	tool     string
+	requests [][]providers.Message
+}
+
+func (p *imageToolProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *imageToolProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	p.requests = append(p.requests, append([]providers.Message(nil), messages...))
+	if len(p.requests) == 1 {
+		tool := p.tool
+		if tool == "" {
+			tool = "read_image"
+		}
+		handler.OnToolCallStart("call_1", tool)
+		handler.OnToolCallDelta("call_1", fmt.Sprintf(`{"path":%q}`, p.path))
+		handler.OnToolCallEnd("call_1")
+	} else {
+		handler.OnContent("a red pixel")
+	}
+	handler.OnComplete()
+	return nil
+}
+
+func (p *imageToolProvider) GetDefaultModel() string          { return "test-model" }
+func (p *imageToolProvider) SupportsImageInput(m string) bool { return true }
+
+func TestAgentLoopAttachesReadImageResultAsImagePart(t *testing.T) {
+	workspace := t.TempDir()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	imagePath := filepath.Join(workspace, "pixel.png")
+	require.NoError(t, os.WriteFile(imagePath, buf.Bytes(), 0644))
+
+	provider := &imageToolProvider{path: imagePath}
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		workspace,
+		"test-model",
+		5,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "what is in pixel.png?"))
+	require.NoError(t, err)
+	assert.Equal(t, "a red pixel", resp.Content)
+
+	require.Len(t, provider.requests, 2)
+	messages := provider.requests[1]
+	toolMsg := messages[len(messages)-2]
+	assert.Equal(t, "tool", toolMsg.Role)
+	assert.Contains(t, toolMsg.Content, "Image loaded (image/png,")
+	assert.NotContains(t, toolMsg.Content, "base64")
+
+	imageMsg := messages[len(messages)-1]
+	assert.Equal(t, "user", imageMsg.Role)
+	require.Len(t, imageMsg.Parts, 2)
+	assert.Equal(t, "image_url", imageMsg.Parts[1].Type)
+	assert.Equal(t, "image/png", imageMsg.Parts[1].MimeType)
+	assert.True(t, strings.HasPrefix(imageMsg.Parts[1].ImageURL, "data:image/png;base64,"))
+}
+
+// dataURLTool 返回固定的图片 data URL，模拟恰好输出 data URL 的其他工具
+type dataURLTool struct{}
+
+func (t *dataURLTool) Name() string        { return "fetch_blob" }
+func (t *dataURLTool) Description() string { return "returns a data URL" }
+func (t *dataURLTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+func (t *dataURLTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	return "data:image/png;base64,aGVsbG8=", nil
+}
+
+func TestAgentLoopOnlyAttachesImagesFromReadImage(t *testing.T) {
+	provider := &imageToolProvider{tool: "fetch_blob"}
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		t.TempDir(),
+		"test-model",
+		5,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	require.NoError(t, loop.RegisterTool(&dataURLTool{}))
+
+	_, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "fetch it"))
+	require.NoError(t, err)
+
+	require.Len(t, provider.requests, 2)
+	messages := provider.requests[1]
+	toolMsg := messages[len(messages)-1]
+	assert.Equal(t, "tool", toolMsg.Role)
+	assert.Equal(t, "data:image/png;base64,aGVsbG8=", toolMsg.Content)
+}
+
+func TestAgentLoopDisablesExecOnInvalidDangerousPatterns(t *testing.T) {
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		&staticProvider{},
+		t.TempDir(),
+		"test-model",
+		5,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5, DangerousPatterns: []string{"rm -rf ("}, ReplaceDangerousDefaults: true},
+		false,
+		nil,
+		nil,
+		false,
+	)
+
+	// 非法的替换模式不能让 exec 回退到其他模式继续执行
+	_, err := loop.tools.Execute(context.Background(), "exec", map[string]interface{}{"command": "echo hi"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exec is disabled")
+	assert.Contains(t, err.Error(), "dangerousPatterns")
+}
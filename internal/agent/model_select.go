@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/Lichas/maxclaw/internal/providers"
+)
+
+// ModelProviderFactory 为非默认模型创建 provider（如频道覆盖的模型属于另一个提供商）
+type ModelProviderFactory func(model string) (providers.LLMProvider, error)
+
+// SetChannelModels 设置按频道覆盖的模型（key 为频道名）以及创建对应 provider 的工厂；
+// factory 为空时沿用默认 provider，只替换请求中的模型名。已缓存的 provider 会被丢弃
+func (a *AgentLoop) SetChannelModels(models map[string]string, factory ModelProviderFactory) {
+	cleaned := make(map[string]string, len(models))
+	for channel, model := range models {
+		if model = strings.TrimSpace(model); model != "" {
+			cleaned[channel] = model
+		}
+	}
+	a.runtimeMu.Lock()
+	defer a.runtimeMu.Unlock()
+	a.channelModels = cleaned
+	a.modelProviderFactory = factory
+	a.modelProviders = nil
+	a.modelProvidersGen++
+}
+
+// channelModel 返回频道配置的模型，未配置时为空
+func (a *AgentLoop) channelModel(channel string) string {
+	a.runtimeMu.RLock()
+	defer a.runtimeMu.RUnlock()
+	return a.channelModels[channel]
+}
+
+// providerForModel 返回调用 model 使用的 provider 与模型名；model 为空或与默认模型相同时使用运行时 provider，
+// 创建覆盖模型的 provider 失败时记录日志并回退到默认模型。工厂在锁外调用，避免创建耗时阻塞其他请求
+func (a *AgentLoop) providerForModel(model string) (providers.LLMProvider, string) {
+	a.runtimeMu.RLock()
+	defaultProvider, defaultModel := a.Provider, a.Model
+	factory, gen := a.modelProviderFactory, a.modelProvidersGen
+	cached, ok := a.modelProviders[model]
+	a.runtimeMu.RUnlock()
+
+	if model == "" || model == defaultModel {
+		return defaultProvider, defaultModel
+	}
+	if factory == nil {
+		return defaultProvider, model
+	}
+	if ok {
+		return cached, model
+	}
+
+	provider, err := factory(model)
+	if err != nil || provider == nil {
+		if lg := logging.Get(); lg != nil && lg.Session != nil {
+			lg.Session.Printf("model override %s unavailable, using default model %s: %v", model, defaultModel, err)
+		}
+		return defaultProvider, defaultModel
+	}
+
+	a.runtimeMu.Lock()
+	defer a.runtimeMu.Unlock()
+	// 并发请求可能已创建同一模型的 provider，优先复用缓存；配置在创建期间变更时不写回缓存
+	if existing, ok := a.modelProviders[model]; ok {
+		return existing, model
+	}
+	if gen == a.modelProvidersGen {
+		if a.modelProviders == nil {
+			a.modelProviders = make(map[string]providers.LLMProvider)
+		}
+		a.modelProviders[model] = provider
+	}
+	return provider, model
+}
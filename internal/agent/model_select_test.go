@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// modelRecordingProvider 直接回复，并记录每次请求的模型
+type modelRecordingProvider struct {
+	name   string
+	models []string
+}
+
+func (p *modelRecordingProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *modelRecordingProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	p.models = append(p.models, model)
+	handler.OnContent("reply from " + p.name)
+	handler.OnComplete()
+	return nil
+}
+
+func (p *modelRecordingProvider) GetDefaultModel() string          { return "test-model" }
+func (p *modelRecordingProvider) SupportsImageInput(m string) bool { return false }
+
+func TestAgentLoopUsesChannelModelOverride(t *testing.T) {
+	defaultProvider := &modelRecordingProvider{name: "default"}
+	loop := newRepeatTestLoop(t, defaultProvider, 5)
+	loop.SetChannelModels(map[string]string{"telegram": "fast-model", "slack": " "}, nil)
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "reply from default", resp.Content)
+
+	for _, channel := range []string{"discord", "slack"} {
+		_, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage(channel, "user-1", "chat-1", "hi"))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"fast-model", "test-model", "test-model"}, defaultProvider.models)
+}
+
+func TestAgentLoopCreatesProviderForChannelModel(t *testing.T) {
+	defaultProvider := &modelRecordingProvider{name: "default"}
+	loop := newRepeatTestLoop(t, defaultProvider, 5)
+
+	created := map[string]*modelRecordingProvider{}
+	loop.SetChannelModels(map[string]string{"telegram": "other/model", "slack": "broken/model"}, func(model string) (providers.LLMProvider, error) {
+		if model == "broken/model" {
+			return nil, errors.New("missing api key")
+		}
+		provider := &modelRecordingProvider{name: model}
+		created[model] = provider
+		return provider, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi"))
+		require.NoError(t, err)
+		assert.Equal(t, "reply from other/model", resp.Content)
+	}
+	require.Len(t, created, 1, "provider is created once and reused")
+	assert.Equal(t, []string{"other/model", "other/model"}, created["other/model"].models)
+
+	// 创建失败时回退到默认模型
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("slack", "user-1", "chat-1", "hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "reply from default", resp.Content)
+	assert.Equal(t, []string{"test-model"}, defaultProvider.models)
+
+	// 运行时模型变更后重新创建覆盖模型的 provider
+	loop.UpdateRuntimeModel(defaultProvider, "test-model")
+	_, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "hi"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"other/model"}, created["other/model"].models)
+}
+
+func TestAgentLoopReportsFallbackModelWhenOverrideUnavailable(t *testing.T) {
+	defaultProvider := &modelRecordingProvider{name: "default"}
+	loop := newRepeatTestLoop(t, defaultProvider, 5)
+	loop.SetChannelModels(map[string]string{"telegram": "broken/model"}, func(model string) (providers.LLMProvider, error) {
+		// 工厂在锁外调用，可以读取 AgentLoop 的运行时状态而不死锁
+		_, _, _ = loop.runtimeSnapshot()
+		return nil, errors.New("missing api key")
+	})
+
+	var statuses []string
+	resp, err := loop.ProcessDirectEventStream(context.Background(), "hi", "telegram:chat-1", "telegram", "chat-1", func(event StreamEvent) {
+		if event.Type == "status" {
+			statuses = append(statuses, event.Message)
+		}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "reply from default", resp)
+	assert.Contains(t, statuses, "Using model: test-model")
+	assert.NotContains(t, statuses, "Using model: broken/model")
+	assert.Equal(t, []string{"test-model"}, defaultProvider.models)
+}
+
+func TestProviderForModelDropsProviderBuiltForStaleConfig(t *testing.T) {
+	loop := newRepeatTestLoop(t, &modelRecordingProvider{name: "default"}, 5)
+	loop.SetChannelModels(map[string]string{"telegram": "other/model"}, func(model string) (providers.LLMProvider, error) {
+		// 创建期间配置被替换，旧 provider 不应写入缓存
+		loop.UpdateRuntimeModel(nil, "test-model")
+		return &modelRecordingProvider{name: model}, nil
+	})
+
+	provider, model := loop.providerForModel("other/model")
+	require.NotNil(t, provider)
+	assert.Equal(t, "other/model", model)
+	loop.runtimeMu.RLock()
+	defer loop.runtimeMu.RUnlock()
+	assert.Empty(t, loop.modelProviders)
+}
@@ -7,6 +7,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/Lichas/maxclaw/internal/skills"
+	"github.com/Lichas/maxclaw/pkg/tools"
 )
 
 const (
@@ -28,17 +29,22 @@ func truncateRunes(s string, limit int, suffix string) string {
 	return strings.TrimSpace(string(runes[:limit])) + suffix
 }
 
-func (b *ContextBuilder) buildSkillsSection(currentMessage string, explicitSkillRefs []string) string {
+// enabledSkills 返回工作区（及可选的全局目录）中未被禁用的技能
+func (b *ContextBuilder) enabledSkills() ([]skills.Entry, error) {
 	skillsDir := filepath.Join(b.workspace, "skills")
 	entries, err := skills.DiscoverAll(skillsDir, b.enableGlobalSkills)
 	if err != nil || len(entries) == 0 {
-		return ""
+		return nil, err
 	}
 
 	// Filter out disabled skills
 	stateMgr := skills.NewStateManager(filepath.Join(b.workspace, ".skills_state.json"))
-	entries = stateMgr.FilterEnabled(entries)
-	if len(entries) == 0 {
+	return stateMgr.FilterEnabled(entries), nil
+}
+
+func (b *ContextBuilder) buildSkillsSection(currentMessage string, explicitSkillRefs []string) string {
+	entries, err := b.enabledSkills()
+	if err != nil || len(entries) == 0 {
 		return ""
 	}
 
@@ -83,3 +89,68 @@ func skillRefsToSelectors(refs []string) []string {
 	}
 	return selectors
 }
+
+// skillsService 为 skills 工具提供技能列表，并把激活的技能保存在会话上
+type skillsService struct {
+	loop *AgentLoop
+}
+
+func (s skillsService) ListSkills() ([]tools.SkillInfo, error) {
+	entries, err := s.loop.context.enabledSkills()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]tools.SkillInfo, 0, len(entries))
+	for _, entry := range entries {
+		infos = append(infos, tools.SkillInfo{
+			Name:        entry.Name,
+			DisplayName: entry.DisplayName,
+			Description: entry.Description,
+			Source:      entry.Source,
+		})
+	}
+	return infos, nil
+}
+
+func (s skillsService) ActiveSkills(sessionKey string) []string {
+	return append([]string(nil), s.loop.sessions.GetOrCreate(sessionKey).ActiveSkills...)
+}
+
+func (s skillsService) SetActiveSkills(sessionKey string, names []string) ([]string, error) {
+	var active []string
+	if refs := normalizeSkillRefs(names); len(refs) > 0 {
+		entries, err := s.loop.context.enabledSkills()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load skills: %w", err)
+		}
+		available := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			available = append(available, entry.Name)
+		}
+		for _, ref := range refs {
+			matched := skills.FilterByMessage(entries, "@skill:"+ref)
+			if len(matched) == 0 || strings.EqualFold(ref, "all") || strings.EqualFold(ref, "none") {
+				return nil, fmt.Errorf("unknown skill %q (available: %s)", ref, strings.Join(available, ", "))
+			}
+			for _, entry := range matched {
+				active = appendUnique(active, entry.Name)
+			}
+		}
+	}
+
+	sess := s.loop.sessions.GetOrCreate(sessionKey)
+	sess.ActiveSkills = active
+	if err := s.loop.sessions.Save(sess); err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	return active, nil
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -1,10 +1,15 @@
 package agent
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -42,3 +47,123 @@ func TestBuildSkillsSectionSelectors(t *testing.T) {
 	assert.Contains(t, explicitAlpha, "### Alpha")
 	assert.NotContains(t, explicitAlpha, "### Beta")
 }
+
+// activateSkillsProvider 第一次调用时通过 skills 工具激活 alpha，之后记录每次请求的系统提示词
+type activateSkillsProvider struct {
+	callCount int
+	prompts   []string
+}
+
+func (p *activateSkillsProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *activateSkillsProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	p.callCount++
+	if p.callCount == 1 {
+		handler.OnToolCallStart("call_1", "skills")
+		handler.OnToolCallDelta("call_1", `{"action":"activate","names":["Alpha"]}`)
+		handler.OnToolCallEnd("call_1")
+		handler.OnComplete()
+		return nil
+	}
+	if len(messages) > 0 && messages[0].Role == "system" {
+		p.prompts = append(p.prompts, messages[0].Content)
+	}
+	handler.OnContent("ok")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *activateSkillsProvider) GetDefaultModel() string {
+	return "test-model"
+}
+
+func (p *activateSkillsProvider) SupportsImageInput(model string) bool {
+	return false
+}
+
+func TestActiveSkillsPersistAcrossTurns(t *testing.T) {
+	workspace := t.TempDir()
+	skillsDir := filepath.Join(workspace, "skills")
+	require.NoError(t, os.MkdirAll(skillsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(skillsDir, "alpha.md"), []byte("# Alpha\nAlpha content"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(skillsDir, "beta.md"), []byte("# Beta\nBeta content"), 0644))
+
+	provider := &activateSkillsProvider{}
+	newLoop := func() *AgentLoop {
+		return NewAgentLoop(
+			bus.NewMessageBus(10),
+			provider,
+			workspace,
+			"test-model",
+			3,
+			config.WebSearchConfig{},
+			tools.WebFetchOptions{},
+			config.ExecToolConfig{Timeout: 5},
+			false,
+			nil,
+			nil,
+			false,
+		)
+	}
+	loop := newLoop()
+	ctx := context.Background()
+
+	// 第一轮：工具激活 alpha
+	_, err := loop.ProcessDirect(ctx, "focus on alpha", "cli:skills", "cli", "direct")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alpha"}, loop.sessions.GetOrCreate("cli:skills").ActiveSkills)
+
+	// 第二轮：未写 @skill: 也只注入 alpha
+	_, err = loop.ProcessDirect(ctx, "next question", "cli:skills", "cli", "direct")
+	require.NoError(t, err)
+	last := provider.prompts[len(provider.prompts)-1]
+	assert.Contains(t, last, "### Alpha")
+	assert.NotContains(t, last, "### Beta")
+
+	// 消息中的显式选择优先
+	_, err = loop.ProcessDirect(ctx, "this time @skill:beta", "cli:skills", "cli", "direct")
+	require.NoError(t, err)
+	last = provider.prompts[len(provider.prompts)-1]
+	assert.NotContains(t, last, "### Alpha")
+	assert.Contains(t, last, "### Beta")
+
+	// 重启后激活状态仍然有效
+	loop = newLoop()
+	_, err = loop.ProcessDirect(ctx, "after restart", "cli:skills", "cli", "direct")
+	require.NoError(t, err)
+	last = provider.prompts[len(provider.prompts)-1]
+	assert.Contains(t, last, "### Alpha")
+	assert.NotContains(t, last, "### Beta")
+
+	// clear 之后恢复加载全部技能
+	toolCtx := tools.WithRuntimeContextWithSession(ctx, "cli", "direct", "cli:skills")
+	result, err := loop.tools.Execute(toolCtx, "skills", map[string]interface{}{"action": "clear"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "cleared")
+	_, err = loop.ProcessDirect(ctx, "anything", "cli:skills", "cli", "direct")
+	require.NoError(t, err)
+	last = provider.prompts[len(provider.prompts)-1]
+	assert.Contains(t, last, "### Alpha")
+	assert.Contains(t, last, "### Beta")
+}
+
+func TestSkillsToolRejectsUnknownSkill(t *testing.T) {
+	workspace := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(workspace, "skills"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "skills", "alpha.md"), []byte("# Alpha\nAlpha content"), 0644))
+
+	loop := NewAgentLoop(bus.NewMessageBus(10), &staticProvider{}, workspace, "test-model", 3,
+		config.WebSearchConfig{}, tools.WebFetchOptions{}, config.ExecToolConfig{Timeout: 5}, false, nil, nil, false)
+	ctx := tools.WithRuntimeContextWithSession(context.Background(), "cli", "direct", "cli:skills")
+
+	_, err := loop.tools.Execute(ctx, "skills", map[string]interface{}{"action": "activate", "names": []interface{}{"gamma"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown skill "gamma" (available: alpha)`)
+
+	listing, err := loop.tools.Execute(ctx, "skills", map[string]interface{}{"action": "list"})
+	require.NoError(t, err)
+	assert.Contains(t, listing, "alpha (Alpha)")
+	assert.Contains(t, listing, "all skills are loaded")
+}
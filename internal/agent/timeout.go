@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+)
+
+// withTurnTimeout 按 TurnTimeout 为单条消息的处理附加截止时间
+func withTurnTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// toolCancelGrace 工具超时后等待其响应 ctx 取消并返回的时长
+var toolCancelGrace = 2 * time.Second
+
+// maxAbandonedToolRuns 同一工具超时后仍未退出的调用上限，达到后拒绝再执行该工具
+const maxAbandonedToolRuns = 4
+
+// abandonedToolRuns 记录超时后未响应取消、仍在后台运行的工具调用数，按工具名统计
+type abandonedToolRuns struct {
+	mu      sync.Mutex
+	running map[string]int
+}
+
+func (r *abandonedToolRuns) count(name string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running[name]
+}
+
+func (r *abandonedToolRuns) add(name string, delta int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == nil {
+		r.running = make(map[string]int)
+	}
+	r.running[name] += delta
+	n := r.running[name]
+	if n <= 0 {
+		delete(r.running, name)
+	}
+	return n
+}
+
+// executeToolWithTimeout 在 ToolTimeout 内执行工具。超时后取消工具的 ctx，并在 toolCancelGrace 内等待其返回；
+// 仍未返回的调用（工具未响应 ctx）留在后台运行直到结束，这类调用按工具计数，
+// 同一工具积压达到 maxAbandonedToolRuns 后拒绝新的调用，避免 goroutine 与子资源无限累积
+func (a *AgentLoop) executeToolWithTimeout(ctx context.Context, name string, params map[string]interface{}) (string, error) {
+	timeout := a.runtimeLimits().ToolTimeout
+	if timeout <= 0 {
+		return a.tools.Execute(ctx, name, params)
+	}
+	if n := a.abandonedTools.count(name); n >= maxAbandonedToolRuns {
+		return "", fmt.Errorf("tool %s is unavailable: %d earlier calls timed out and are still running", name, n)
+	}
+
+	toolCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		result string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		result, err := a.tools.Execute(toolCtx, name, params)
+		done <- outcome{result: result, err: err}
+	}()
+
+	select {
+	case out := <-done:
+		if out.err != nil && ctx.Err() == nil && toolCtx.Err() == context.DeadlineExceeded {
+			return out.result, toolTimeoutError(name, timeout)
+		}
+		return out.result, out.err
+	case <-toolCtx.Done():
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(toolCancelGrace):
+		n := a.abandonedTools.add(name, 1)
+		if lg := logging.Get(); lg != nil && lg.Tools != nil {
+			lg.Tools.Printf("tool %s ignored cancellation after timeout, left running in background (running=%d)", name, n)
+		}
+		go func() {
+			<-done
+			a.abandonedTools.add(name, -1)
+		}()
+	}
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	return "", toolTimeoutError(name, timeout)
+}
+
+func toolTimeoutError(name string, timeout time.Duration) error {
+	return fmt.Errorf("tool %s timed out after %s", name, timeout)
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowToolProvider 首轮调用 slow 工具，拿到工具结果后原样回复
+type slowToolProvider struct{}
+
+func (p *slowToolProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *slowToolProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	if last := messages[len(messages)-1]; last.Role == "tool" {
+		handler.OnContent("tool said: " + last.Content)
+		handler.OnComplete()
+		return nil
+	}
+	handler.OnToolCallStart("call_slow", "slow")
+	handler.OnToolCallDelta("call_slow", `{}`)
+	handler.OnToolCallEnd("call_slow")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *slowToolProvider) GetDefaultModel() string              { return "test-model" }
+func (p *slowToolProvider) SupportsImageInput(model string) bool { return false }
+
+// blockingProvider 一直阻塞到 ctx 结束
+type blockingProvider struct{}
+
+func (p *blockingProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (p *blockingProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (p *blockingProvider) GetDefaultModel() string              { return "test-model" }
+func (p *blockingProvider) SupportsImageInput(model string) bool { return false }
+
+// hangingTool 一直阻塞到 ctx 结束或测试结束
+type hangingTool struct {
+	name    string
+	release chan struct{}
+}
+
+func (t *hangingTool) Name() string        { return t.name }
+func (t *hangingTool) Description() string { return "hang" }
+func (t *hangingTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (t *hangingTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-t.release:
+		return t.name + " done", nil
+	}
+}
+
+// stubbornTool 忽略 ctx，一直阻塞到 release 关闭
+type stubbornTool struct {
+	release chan struct{}
+}
+
+func (t *stubbornTool) Name() string        { return "stubborn" }
+func (t *stubbornTool) Description() string { return "ignores cancellation" }
+func (t *stubbornTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (t *stubbornTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	<-t.release
+	return "late", nil
+}
+
+func newTimeoutTestLoop(t *testing.T, provider providers.LLMProvider) *AgentLoop {
+	return NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+}
+
+func TestAgentLoopToolTimeoutRecordsResultAndContinues(t *testing.T) {
+	loop := newTimeoutTestLoop(t, &slowToolProvider{})
+	loop.ToolTimeout = 100 * time.Millisecond
+	release := make(chan struct{})
+	defer close(release)
+	require.NoError(t, loop.RegisterTool(&hangingTool{name: "slow", release: release}))
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "u", "c", "go"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "tool said: Error: tool slow timed out after 100ms", resp.Content)
+	assert.Zero(t, loop.abandonedTools.count("slow"), "tool honoring ctx should not be left running")
+}
+
+func TestExecuteToolWithTimeoutLimitsAbandonedRuns(t *testing.T) {
+	grace := toolCancelGrace
+	toolCancelGrace = 10 * time.Millisecond
+	t.Cleanup(func() { toolCancelGrace = grace })
+
+	loop := newTimeoutTestLoop(t, &slowToolProvider{})
+	loop.ToolTimeout = 20 * time.Millisecond
+	release := make(chan struct{})
+	require.NoError(t, loop.RegisterTool(&stubbornTool{release: release}))
+
+	for i := 0; i < maxAbandonedToolRuns; i++ {
+		_, err := loop.executeToolWithTimeout(context.Background(), "stubborn", map[string]interface{}{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "timed out")
+	}
+	assert.Equal(t, maxAbandonedToolRuns, loop.abandonedTools.count("stubborn"))
+
+	// 积压达到上限后不再启动新的调用
+	_, err := loop.executeToolWithTimeout(context.Background(), "stubborn", map[string]interface{}{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "still running")
+
+	// 后台调用结束后恢复可用
+	close(release)
+	assert.Eventually(t, func() bool { return loop.abandonedTools.count("stubborn") == 0 }, 2*time.Second, 10*time.Millisecond)
+	result, err := loop.executeToolWithTimeout(context.Background(), "stubborn", map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Equal(t, "late", result)
+}
+
+func TestAgentLoopTurnTimeoutAbortsHungProvider(t *testing.T) {
+	loop := newTimeoutTestLoop(t, &blockingProvider{})
+	loop.TurnTimeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "u", "c", "go"))
+	require.Error(t, err)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Contains(t, err.Error(), "turn timed out after 100ms")
+}
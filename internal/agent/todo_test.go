@@ -0,0 +1,120 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// todoProvider 第一次调用 todo 工具添加待办，之后直接回复，并记录每次请求的系统提示
+type todoProvider struct {
+	systemPrompts []string
+}
+
+func (p *todoProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *todoProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	p.systemPrompts = append(p.systemPrompts, messages[0].Content)
+	if len(p.systemPrompts) == 1 {
+		handler.OnToolCallStart("call_1", "todo")
+		handler.OnToolCallDelta("call_1", `{"action":"add","items":["collect logs","write summary"]}`)
+		handler.OnToolCallEnd("call_1")
+	} else {
+		handler.OnContent("done")
+	}
+	handler.OnComplete()
+	return nil
+}
+
+func (p *todoProvider) GetDefaultModel() string          { return "test-model" }
+func (p *todoProvider) SupportsImageInput(m string) bool { return false }
+
+func TestContextBuilderWithTodoList(t *testing.T) {
+	workspace := t.TempDir()
+	builder := NewContextBuilder(workspace)
+	tool := tools.NewTodoTool(workspace)
+	ctx := tools.WithRuntimeContextWithSession(context.Background(), "telegram", "123", "telegram:123")
+
+	messages := builder.WithTodoList(builder.BuildMessages(nil, "hello", nil, "telegram", "123"), "telegram:123")
+	assert.NotContains(t, messages[0].Content, "## Todo List")
+
+	_, err := tool.Execute(ctx, map[string]interface{}{"action": "add", "items": []interface{}{"step one", "step two"}})
+	require.NoError(t, err)
+	messages = builder.WithTodoList(messages, "telegram:123")
+	assert.Contains(t, messages[0].Content, "## Todo List")
+	assert.Contains(t, messages[0].Content, "- [ ] 1. step one")
+
+	// 再次注入时替换旧内容而不是重复追加
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "complete", "ids": []interface{}{float64(1)}})
+	require.NoError(t, err)
+	messages = builder.WithTodoList(messages, "telegram:123")
+	assert.Contains(t, messages[0].Content, "- [x] 1. step one")
+	assert.NotContains(t, messages[0].Content, "- [ ] 1. step one")
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "clear"})
+	require.NoError(t, err)
+	messages = builder.WithTodoList(messages, "telegram:123")
+	assert.NotContains(t, messages[0].Content, "## Todo List")
+}
+
+func TestContextBuilderWithTodoListKeepsUserSections(t *testing.T) {
+	workspace := t.TempDir()
+	// 工作区文件中的同名标题属于用户内容，刷新清单时不能被截掉
+	require.NoError(t, os.WriteFile(filepath.Join(workspace, "AGENTS.md"), []byte("## Todo List\nAlways run tests.\n"), 0644))
+	builder := NewContextBuilder(workspace)
+	tool := tools.NewTodoTool(workspace)
+	ctx := tools.WithRuntimeContextWithSession(context.Background(), "telegram", "123", "telegram:123")
+
+	messages := builder.WithTodoList(builder.BuildMessages(nil, "hello", nil, "telegram", "123"), "telegram:123")
+	require.Contains(t, messages[0].Content, "Always run tests.")
+	original := messages[0].Content
+
+	_, err := tool.Execute(ctx, map[string]interface{}{"action": "add", "items": []interface{}{"step one"}})
+	require.NoError(t, err)
+	messages = builder.WithTodoList(messages, "telegram:123")
+	messages = builder.WithTodoList(messages, "telegram:123")
+	assert.Contains(t, messages[0].Content, "Always run tests.")
+	assert.Equal(t, 1, strings.Count(messages[0].Content, "- [ ] 1. step one"))
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "clear"})
+	require.NoError(t, err)
+	messages = builder.WithTodoList(messages, "telegram:123")
+	assert.Equal(t, original, messages[0].Content)
+}
+
+func TestAgentLoopInjectsTodoListIntoNextIteration(t *testing.T) {
+	provider := &todoProvider{}
+	loop := newRepeatTestLoop(t, provider, 5)
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "investigate"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, "done", resp.Content)
+
+	require.Len(t, provider.systemPrompts, 2)
+	assert.NotContains(t, provider.systemPrompts[0], "## Todo List")
+	assert.Contains(t, provider.systemPrompts[1], "- [ ] 1. collect logs")
+	assert.Contains(t, provider.systemPrompts[1], "- [ ] 2. write summary")
+
+	// 新消息仍能看到之前的清单
+	_, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "continue"))
+	require.NoError(t, err)
+	assert.Contains(t, provider.systemPrompts[2], "- [ ] 2. write summary")
+
+	// /reset 清空待办清单
+	_, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "/reset"))
+	require.NoError(t, err)
+	items, err := tools.LoadTodoList(loop.Workspace, "telegram:chat-1")
+	require.NoError(t, err)
+	assert.Empty(t, items)
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"sync"
+
+	"github.com/Lichas/maxclaw/internal/providers"
+)
+
+// defaultMaxParallelTools MaxParallelTools 未配置时单轮内并发执行工具的上限
+const defaultMaxParallelTools = 4
+
+// toolExecResult 单个工具调用的执行结果
+type toolExecResult struct {
+	result string
+	err    error
+}
+
+// effectiveMaxParallelTools 返回生效的并发上限（<=0 使用默认值）
+func (a *AgentLoop) effectiveMaxParallelTools() int {
+	if limit := a.runtimeLimits().MaxParallelTools; limit > 0 {
+		return limit
+	}
+	return defaultMaxParallelTools
+}
+
+// planToolBatches 把一轮工具调用切分为按序执行的批次：连续的并发安全调用合为一批，
+// 其余调用各自单独成批，保证有状态的工具不会与其他工具同时运行
+func planToolBatches(calls []providers.ToolCall, safe func(name string) bool) [][]int {
+	var batches [][]int
+	var current []int
+	for i, tc := range calls {
+		if safe != nil && safe(tc.Function.Name) {
+			current = append(current, i)
+			continue
+		}
+		if len(current) > 0 {
+			batches = append(batches, current)
+			current = nil
+		}
+		batches = append(batches, []int{i})
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// runToolBatch 在 limit 并发上限内执行一批工具调用；单个调用或 limit <= 1 时直接顺序执行
+func runToolBatch(batch []int, limit int, run func(i int)) {
+	if len(batch) == 1 || limit <= 1 {
+		for _, i := range batch {
+			run(i)
+		}
+		return
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, i := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			run(i)
+		}(i)
+	}
+	wg.Wait()
+}
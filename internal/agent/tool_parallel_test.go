@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// barrierTool 只读测试工具：等待同一批的所有工具都开始执行后才返回，串行执行时超时报错
+type barrierTool struct {
+	name    string
+	arrived *sync.WaitGroup
+}
+
+func (t *barrierTool) Name() string        { return t.name }
+func (t *barrierTool) Description() string { return "barrier" }
+func (t *barrierTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object"}
+}
+func (t *barrierTool) ConcurrencySafe() bool { return true }
+func (t *barrierTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	t.arrived.Done()
+	all := make(chan struct{})
+	go func() {
+		t.arrived.Wait()
+		close(all)
+	}()
+	select {
+	case <-all:
+		return t.name + " done", nil
+	case <-time.After(5 * time.Second):
+		return "", fmt.Errorf("%s did not run concurrently with the other tools", t.name)
+	}
+}
+
+// parallelToolProvider 首轮同时调用两个工具，拿到工具结果后记录消息并结束
+type parallelToolProvider struct {
+	final []providers.Message
+}
+
+func (p *parallelToolProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *parallelToolProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	if len(messages) > 0 && messages[len(messages)-1].Role == "tool" {
+		p.final = messages
+		handler.OnContent("ok")
+		handler.OnComplete()
+		return nil
+	}
+	for _, id := range []string{"a", "b"} {
+		handler.OnToolCallStart("call_"+id, "sleep_"+id)
+		handler.OnToolCallDelta("call_"+id, `{}`)
+		handler.OnToolCallEnd("call_" + id)
+	}
+	handler.OnComplete()
+	return nil
+}
+
+func (p *parallelToolProvider) GetDefaultModel() string              { return "test-model" }
+func (p *parallelToolProvider) SupportsImageInput(model string) bool { return false }
+
+func TestAgentLoopExecutesConcurrencySafeToolsInParallel(t *testing.T) {
+	provider := &parallelToolProvider{}
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	arrived := &sync.WaitGroup{}
+	arrived.Add(2)
+	require.NoError(t, loop.RegisterTool(&barrierTool{name: "sleep_a", arrived: arrived}))
+	require.NoError(t, loop.RegisterTool(&barrierTool{name: "sleep_b", arrived: arrived}))
+
+	_, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "u", "c", "go"))
+	require.NoError(t, err)
+
+	var toolResults []string
+	for _, m := range provider.final {
+		if m.Role == "tool" {
+			toolResults = append(toolResults, m.ToolCallID+"="+m.Content)
+		}
+	}
+	assert.Equal(t, []string{"call_a=sleep_a done", "call_b=sleep_b done"}, toolResults)
+}
+
+func TestPlanToolBatchesIsolatesUnsafeTools(t *testing.T) {
+	call := func(name string) providers.ToolCall {
+		return providers.ToolCall{Function: providers.ToolCallFunction{Name: name}}
+	}
+	calls := []providers.ToolCall{call("read_file"), call("web_fetch"), call("write_file"), call("read_file"), call("exec")}
+	safe := func(name string) bool { return name == "read_file" || name == "web_fetch" }
+
+	assert.Equal(t, [][]int{{0, 1}, {2}, {3}, {4}}, planToolBatches(calls, safe))
+	assert.Equal(t, [][]int{{0}, {1}, {2}, {3}, {4}}, planToolBatches(calls, nil))
+}
@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// defaultRepeatedToolCallWindow RepeatedToolCallWindow 未配置时记住的最近工具调用数
+	defaultRepeatedToolCallWindow = 8
+	// maxRepeatedToolCallHits 同一重复调用被拦截的次数达到该值时结束本轮
+	maxRepeatedToolCallHits = 2
+	// repeatedToolCallResultMax 拦截时附带的上次结果最大字符数（完整结果已在上下文中）
+	repeatedToolCallResultMax = 2000
+)
+
+// repeatedCallDetector 记录本轮最近的工具调用（工具名 + 规范化参数）及结果，用于识别模型反复发起相同调用的死循环
+type repeatedCallDetector struct {
+	window int
+	recent []recentToolCall
+	hits   map[string]int
+}
+
+type recentToolCall struct {
+	key    string
+	result string
+}
+
+func newRepeatedCallDetector(window int) *repeatedCallDetector {
+	if window <= 0 {
+		window = defaultRepeatedToolCallWindow
+	}
+	return &repeatedCallDetector{window: window, hits: make(map[string]int)}
+}
+
+// effectiveRepeatedToolCallWindow 返回生效的检测窗口（<=0 使用默认值）
+func (a *AgentLoop) effectiveRepeatedToolCallWindow() int {
+	if window := a.runtimeLimits().RepeatedToolCallWindow; window > 0 {
+		return window
+	}
+	return defaultRepeatedToolCallWindow
+}
+
+// toolCallKey 参数经 JSON 重新编码（键有序），字段顺序或空白不同的相同调用得到相同的 key
+func toolCallKey(name string, args map[string]interface{}) string {
+	normalized, err := json.Marshal(args)
+	if err != nil {
+		normalized = []byte(fmt.Sprint(args))
+	}
+	return name + "\x00" + string(normalized)
+}
+
+// lookup 返回窗口内相同调用的上次结果
+func (d *repeatedCallDetector) lookup(key string) (string, bool) {
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		if d.recent[i].key == key {
+			return d.recent[i].result, true
+		}
+	}
+	return "", false
+}
+
+// record 记录一次实际执行的调用；stateful 为 true（工具可能修改了文件等状态）时先清空窗口，之后的相同只读调用可能得到新结果
+func (d *repeatedCallDetector) record(key, result string, stateful bool) {
+	if stateful {
+		d.recent = d.recent[:0]
+		d.hits = make(map[string]int)
+	}
+	d.recent = append(d.recent, recentToolCall{key: key, result: result})
+	if len(d.recent) > d.window {
+		d.recent = append(d.recent[:0], d.recent[len(d.recent)-d.window:]...)
+	}
+}
+
+// hit 记录一次被拦截的重复调用，返回该调用累计被拦截的次数
+func (d *repeatedCallDetector) hit(key string) int {
+	d.hits[key]++
+	return d.hits[key]
+}
+
+// repeatedToolCallResult 拦截重复调用时返回给模型的说明
+func repeatedToolCallResult(name, prior string) string {
+	return fmt.Sprintf("Skipped: %s was already called with identical arguments earlier in this turn, so it was not run again. Previous result:\n%s\n\nUse this result instead of repeating the call, or change the arguments if you need something different.",
+		name, truncateEventText(prior, repeatedToolCallResultMax))
+}
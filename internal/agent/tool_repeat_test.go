@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// repeatingToolProvider 每次都发起同一个工具调用，模拟陷入死循环的模型
+type repeatingToolProvider struct {
+	tool string
+	// args 按调用次数轮流使用
+	args         []string
+	calls        int
+	lastMessages []providers.Message
+}
+
+func (p *repeatingToolProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *repeatingToolProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	args := p.args[p.calls%len(p.args)]
+	p.calls++
+	p.lastMessages = messages
+	handler.OnToolCallStart("call_1", p.tool)
+	handler.OnToolCallDelta("call_1", args)
+	handler.OnToolCallEnd("call_1")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *repeatingToolProvider) GetDefaultModel() string          { return "test-model" }
+func (p *repeatingToolProvider) SupportsImageInput(m string) bool { return false }
+
+type pollingTool struct {
+	calls int
+}
+
+func (t *pollingTool) Name() string        { return "poll_status" }
+func (t *pollingTool) Description() string { return "poll a background job" }
+func (t *pollingTool) Parameters() map[string]interface{} {
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+func (t *pollingTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	t.calls++
+	return "still running", nil
+}
+func (t *pollingTool) Repeatable(params map[string]interface{}) bool { return true }
+
+func newRepeatTestLoop(t *testing.T, provider providers.LLMProvider, maxIterations int) *AgentLoop {
+	t.Helper()
+	return NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		t.TempDir(),
+		"test-model",
+		maxIterations,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+}
+
+func TestToolCallKeyNormalizesArguments(t *testing.T) {
+	a := toolCallKey("read_file", map[string]interface{}{"path": "a.txt", "limit": float64(10)})
+	b := toolCallKey("read_file", map[string]interface{}{"limit": float64(10), "path": "a.txt"})
+	assert.Equal(t, a, b)
+	assert.NotEqual(t, a, toolCallKey("read_file", map[string]interface{}{"path": "b.txt", "limit": float64(10)}))
+	assert.NotEqual(t, a, toolCallKey("stat", map[string]interface{}{"path": "a.txt", "limit": float64(10)}))
+}
+
+func TestRepeatedCallDetectorWindow(t *testing.T) {
+	d := newRepeatedCallDetector(2)
+	d.record("a", "result a", false)
+	d.record("b", "result b", false)
+
+	prior, ok := d.lookup("a")
+	require.True(t, ok)
+	assert.Equal(t, "result a", prior)
+
+	// 超出窗口的调用被淘汰
+	d.record("c", "result c", false)
+	_, ok = d.lookup("a")
+	assert.False(t, ok)
+
+	// 可能修改状态的调用会清空窗口
+	d.record("write", "ok", true)
+	_, ok = d.lookup("c")
+	assert.False(t, ok)
+	_, ok = d.lookup("write")
+	assert.True(t, ok)
+
+	assert.Equal(t, 1, d.hit("write"))
+	assert.Equal(t, 2, d.hit("write"))
+}
+
+func TestAgentLoopCutsOffRepeatedToolCall(t *testing.T) {
+	provider := &repeatingToolProvider{tool: "list_dir", args: []string{`{"path": "."}`}}
+	loop := newRepeatTestLoop(t, provider, 10)
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "list files"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	// 第一次执行，第二次被拦截并告知上次结果，第三次仍重复则结束本轮
+	assert.Equal(t, 3, provider.calls)
+	assert.Equal(t, "Stopped: the model kept repeating the same list_dir call with identical arguments.", resp.Content)
+
+	last := provider.lastMessages[len(provider.lastMessages)-1]
+	assert.Equal(t, "tool", last.Role)
+	assert.Contains(t, last.Content, "Skipped: list_dir was already called with identical arguments")
+	assert.Contains(t, last.Content, "Previous result:")
+}
+
+func TestAgentLoopAllowsRepeatableToolPolling(t *testing.T) {
+	provider := &repeatingToolProvider{tool: "poll_status", args: []string{`{}`}}
+	loop := newRepeatTestLoop(t, provider, 4)
+	tool := &pollingTool{}
+	require.NoError(t, loop.RegisterTool(tool))
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "wait for the job"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	assert.Equal(t, 4, tool.calls)
+	assert.Contains(t, resp.Content, "Reached 4 iterations without completion.")
+}
+
+func TestAgentLoopCutsOffRepeatedSpawn(t *testing.T) {
+	provider := &repeatingToolProvider{tool: "spawn", args: []string{`{"action": "spawn", "task": "crawl the site"}`}}
+	loop := newRepeatTestLoop(t, provider, 10)
+	var spawned int32
+	require.NoError(t, loop.RegisterTool(tools.NewSpawnTool(func(ctx context.Context, req tools.SpawnRequest) (tools.SpawnResult, error) {
+		atomic.AddInt32(&spawned, 1)
+		return tools.SpawnResult{SessionKey: "spawn:test"}, nil
+	})))
+
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "crawl the site"))
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	// spawn 的轮询操作可以重复，但重复启动同一任务仍按死循环拦截
+	assert.Equal(t, 3, provider.calls)
+	assert.Equal(t, "Stopped: the model kept repeating the same spawn call with identical arguments.", resp.Content)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&spawned) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spawned))
+}
+
+func TestAgentLoopRepeatedToolCallWindowConfigurable(t *testing.T) {
+	alternating := []string{`{"path": "."}`, `{"path": ".."}`}
+
+	// 默认窗口能记住隔一次出现的相同调用
+	provider := &repeatingToolProvider{tool: "list_dir", args: alternating}
+	loop := newRepeatTestLoop(t, provider, 6)
+	resp, err := loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "list"))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "Stopped:")
+	assert.Equal(t, 5, provider.calls)
+
+	// 窗口为 1 时只拦截紧挨着的重复调用
+	provider = &repeatingToolProvider{tool: "list_dir", args: alternating}
+	loop = newRepeatTestLoop(t, provider, 6)
+	loop.RepeatedToolCallWindow = 1
+	resp, err = loop.ProcessMessage(context.Background(), bus.NewInboundMessage("telegram", "user-1", "chat-1", "list"))
+	require.NoError(t, err)
+	assert.Contains(t, resp.Content, "Reached 6 iterations without completion.")
+	assert.Equal(t, 6, provider.calls)
+}
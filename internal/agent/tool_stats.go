@@ -0,0 +1,169 @@
+package agent
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+)
+
+const (
+	// defaultToolCallWarnThreshold 统计窗口内单会话工具调用超过该次数时记录告警
+	defaultToolCallWarnThreshold = 120
+	toolCallRateWindow           = 10 * time.Minute
+	// toolCallStatsIdleTTL 超过该时长没有活动的会话统计会被清理
+	toolCallStatsIdleTTL = 24 * time.Hour
+)
+
+// SessionToolStats 单个会话的工具调用统计
+type SessionToolStats struct {
+	SessionKey    string    `json:"sessionKey"`
+	Turns         int       `json:"turns"`
+	ToolCalls     int       `json:"toolCalls"`
+	LastTurnCalls int       `json:"lastTurnCalls"`
+	MaxTurnCalls  int       `json:"maxTurnCalls"`
+	AvgPerTurn    float64   `json:"avgPerTurn"`
+	RecentCalls   int       `json:"recentCalls"`
+	Warnings      int       `json:"warnings"`
+	LastActive    time.Time `json:"lastActive"`
+}
+
+type sessionToolUsage struct {
+	stats    SessionToolStats
+	recent   []time.Time
+	lastWarn time.Time
+}
+
+// toolCallTracker 按会话统计工具调用频率，窗口内调用过多时记录软告警（不打断执行）
+type toolCallTracker struct {
+	mu       sync.Mutex
+	window   time.Duration
+	now      func() time.Time
+	warn     func(format string, args ...interface{})
+	sessions map[string]*sessionToolUsage
+}
+
+func newToolCallTracker() *toolCallTracker {
+	return &toolCallTracker{
+		window:   toolCallRateWindow,
+		now:      time.Now,
+		warn:     logToolCallWarning,
+		sessions: make(map[string]*sessionToolUsage),
+	}
+}
+
+func logToolCallWarning(format string, args ...interface{}) {
+	if lg := logging.Get(); lg != nil && lg.Session != nil {
+		lg.Session.Printf(format, args...)
+	}
+}
+
+// record 记录一次迭代中执行的 n 个工具调用，返回是否触发了告警
+func (t *toolCallTracker) record(sessionKey string, n, threshold int) bool {
+	if n <= 0 {
+		return false
+	}
+	if threshold <= 0 {
+		threshold = defaultToolCallWarnThreshold
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	t.pruneIdleLocked(now)
+	usage := t.usageLocked(sessionKey)
+	for i := 0; i < n; i++ {
+		usage.recent = append(usage.recent, now)
+	}
+	usage.recent = trimBefore(usage.recent, now.Add(-t.window))
+	usage.stats.ToolCalls += n
+	usage.stats.LastActive = now
+
+	// 同一会话每个窗口最多告警一次，避免日志刷屏
+	if len(usage.recent) < threshold || (!usage.lastWarn.IsZero() && now.Sub(usage.lastWarn) < t.window) {
+		return false
+	}
+	usage.lastWarn = now
+	usage.stats.Warnings++
+	t.warn("tool call rate high session=%s calls=%d window=%s total=%d turns=%d",
+		sessionKey, len(usage.recent), t.window, usage.stats.ToolCalls, usage.stats.Turns)
+	return true
+}
+
+// finishTurn 记录一轮对话结束时本轮的工具调用次数
+func (t *toolCallTracker) finishTurn(sessionKey string, calls int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	usage := t.usageLocked(sessionKey)
+	usage.stats.Turns++
+	usage.stats.LastTurnCalls = calls
+	if calls > usage.stats.MaxTurnCalls {
+		usage.stats.MaxTurnCalls = calls
+	}
+	usage.stats.LastActive = t.now()
+}
+
+// snapshot 返回所有会话的统计，按窗口内调用次数降序
+func (t *toolCallTracker) snapshot() []SessionToolStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	result := make([]SessionToolStats, 0, len(t.sessions))
+	for _, usage := range t.sessions {
+		usage.recent = trimBefore(usage.recent, now.Add(-t.window))
+		stats := usage.stats
+		stats.RecentCalls = len(usage.recent)
+		if stats.Turns > 0 {
+			stats.AvgPerTurn = float64(stats.ToolCalls) / float64(stats.Turns)
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RecentCalls != result[j].RecentCalls {
+			return result[i].RecentCalls > result[j].RecentCalls
+		}
+		if result[i].ToolCalls != result[j].ToolCalls {
+			return result[i].ToolCalls > result[j].ToolCalls
+		}
+		return result[i].SessionKey < result[j].SessionKey
+	})
+	return result
+}
+
+func (t *toolCallTracker) usageLocked(sessionKey string) *sessionToolUsage {
+	usage, ok := t.sessions[sessionKey]
+	if !ok {
+		usage = &sessionToolUsage{stats: SessionToolStats{SessionKey: sessionKey}}
+		t.sessions[sessionKey] = usage
+	}
+	return usage
+}
+
+func (t *toolCallTracker) pruneIdleLocked(now time.Time) {
+	for key, usage := range t.sessions {
+		if now.Sub(usage.stats.LastActive) > toolCallStatsIdleTTL {
+			delete(t.sessions, key)
+		}
+	}
+}
+
+// trimBefore 丢弃早于 cutoff 的时间戳（输入按时间递增）
+func trimBefore(times []time.Time, cutoff time.Time) []time.Time {
+	idx := sort.Search(len(times), func(i int) bool { return times[i].After(cutoff) })
+	if idx == 0 {
+		return times
+	}
+	return append(times[:0], times[idx:]...)
+}
+
+// ToolCallStats 返回各会话的工具调用统计，用于状态页展示
+func (a *AgentLoop) ToolCallStats() []SessionToolStats {
+	if a.toolStats == nil {
+		return nil
+	}
+	return a.toolStats.snapshot()
+}
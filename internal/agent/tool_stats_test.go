@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolCallTrackerWarnsOncePerWindow(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	tracker := newToolCallTracker()
+	tracker.now = func() time.Time { return now }
+	var warnings []string
+	tracker.warn = func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	assert.False(t, tracker.record("s1", 3, 5))
+	assert.True(t, tracker.record("s1", 3, 5))
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "session=s1 calls=6")
+
+	// 同一窗口内不重复告警
+	now = now.Add(time.Minute)
+	assert.False(t, tracker.record("s1", 4, 5))
+	assert.Len(t, warnings, 1)
+
+	// 窗口滑过后旧调用不再计入
+	now = now.Add(toolCallRateWindow + time.Second)
+	assert.False(t, tracker.record("s1", 2, 5))
+	assert.True(t, tracker.record("s1", 3, 5))
+	assert.Len(t, warnings, 2)
+
+	tracker.finishTurn("s1", 5)
+	tracker.finishTurn("s1", 8)
+	tracker.record("s2", 1, 5)
+
+	stats := tracker.snapshot()
+	require.Len(t, stats, 2)
+	assert.Equal(t, "s1", stats[0].SessionKey)
+	assert.Equal(t, 15, stats[0].ToolCalls)
+	assert.Equal(t, 5, stats[0].RecentCalls)
+	assert.Equal(t, 2, stats[0].Turns)
+	assert.Equal(t, 8, stats[0].LastTurnCalls)
+	assert.Equal(t, 8, stats[0].MaxTurnCalls)
+	assert.Equal(t, 7.5, stats[0].AvgPerTurn)
+	assert.Equal(t, 2, stats[0].Warnings)
+	assert.Equal(t, 1, stats[1].ToolCalls)
+}
+
+func TestAgentLoopHighToolCallRateTriggersWarning(t *testing.T) {
+	workspace := t.TempDir()
+	loop := NewAgentLoop(
+		bus.NewMessageBus(10),
+		&endlessToolProvider{},
+		workspace,
+		"test-model",
+		5,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	loop.ToolCallWarnThreshold = 4
+	var warnings []string
+	loop.toolStats.warn = func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	msg := bus.NewInboundMessage("telegram", "user-1", "chat-42", "hello")
+	_, err := loop.ProcessMessage(context.Background(), msg)
+	require.NoError(t, err)
+
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "tool call rate high session=telegram:chat-42 calls=4")
+
+	stats := loop.ToolCallStats()
+	require.Len(t, stats, 1)
+	assert.Equal(t, 1, stats[0].Turns)
+	assert.Equal(t, 5, stats[0].ToolCalls)
+	assert.Equal(t, 5, stats[0].LastTurnCalls)
+	assert.Equal(t, 1, stats[0].Warnings)
+}
@@ -34,6 +34,11 @@ func BuildWebFetchOptions(cfg *config.Config) tools.WebFetchOptions {
 			HostUserDataDir:  cfg.Tools.Web.Fetch.Chrome.HostUserDataDir,
 			LaunchTimeoutMs:  cfg.Tools.Web.Fetch.Chrome.LaunchTimeoutMs,
 		},
+		MaxConcurrentBrowsers: cfg.Tools.Web.Fetch.MaxConcurrent,
+		HTTPFallback:          cfg.Tools.Web.Fetch.HTTPFallback,
+		IgnoreRobots:          cfg.Tools.Web.Fetch.IgnoreRobots,
+		AllowPrivateNetwork:   cfg.Tools.Web.Fetch.AllowPrivateNetwork,
+		AllowedHosts:          cfg.Tools.Web.Fetch.AllowedHosts,
 	}
 
 	if opts.ScriptPath == "" {
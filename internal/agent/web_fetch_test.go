@@ -21,6 +21,8 @@ func TestBuildWebFetchOptionsIncludesChromeConfig(t *testing.T) {
 	cfg.Tools.Web.Fetch.WaitForSelector = "#app"
 	cfg.Tools.Web.Fetch.WaitForText = "dashboard"
 	cfg.Tools.Web.Fetch.WaitForNoText = "loading"
+	cfg.Tools.Web.Fetch.IgnoreRobots = true
+	cfg.Tools.Web.Fetch.AllowedHosts = []string{"intranet.local"}
 	cfg.Tools.Web.Fetch.Chrome = config.WebFetchChromeConfig{
 		CDPEndpoint:      "http://127.0.0.1:9222",
 		ProfileName:      "host",
@@ -46,6 +48,9 @@ func TestBuildWebFetchOptionsIncludesChromeConfig(t *testing.T) {
 	assert.Equal(t, "#app", got.WaitForSelector)
 	assert.Equal(t, "dashboard", got.WaitForText)
 	assert.Equal(t, "loading", got.WaitForNoText)
+	assert.True(t, got.IgnoreRobots)
+	assert.False(t, got.AllowPrivateNetwork)
+	assert.Equal(t, []string{"intranet.local"}, got.AllowedHosts)
 	assert.Equal(t, "http://127.0.0.1:9222", got.Chrome.CDPEndpoint)
 	assert.Equal(t, "host", got.Chrome.ProfileName)
 	assert.Equal(t, "/tmp/chrome-profile", got.Chrome.UserDataDir)
@@ -2,6 +2,7 @@ package bus
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -152,3 +153,105 @@ func TestConcurrentAccess(t *testing.T) {
 
 	assert.Equal(t, 10, count)
 }
+
+func TestSessionKeyStrategiesForGroupMessage(t *testing.T) {
+	cases := []struct {
+		scope string
+		want  string
+	}{
+		{scope: "", want: "telegram:group-1"},
+		{scope: SessionScopeChat, want: "telegram:group-1"},
+		{scope: SessionScopeSender, want: "telegram:user:alice"},
+		{scope: SessionScopeChatSender, want: "telegram:group-1:alice"},
+		{scope: "unknown", want: "telegram:group-1"},
+	}
+	for _, tc := range cases {
+		msg := NewInboundMessage("telegram", "alice", "group-1", "hi")
+		msg.ApplySessionScope(tc.scope)
+		assert.Equal(t, tc.want, msg.SessionKey, "scope %q", tc.scope)
+	}
+
+	alice := SessionKeyFor(SessionScopeChatSender, "discord", "alice", "room")
+	bob := SessionKeyFor(SessionScopeChatSender, "discord", "bob", "room")
+	assert.NotEqual(t, alice, bob, "group members should not share a session")
+}
+
+func TestSessionKeyDirectMessages(t *testing.T) {
+	for _, scope := range []string{SessionScopeChat, SessionScopeSender, SessionScopeChatSender} {
+		assert.Equal(t, "telegram:42", SessionKeyFor(scope, "telegram", "", "42"))
+	}
+	assert.Equal(t, "telegram:42", SessionKeyFor(SessionScopeChatSender, "telegram", "42", "42"))
+	// sender 粒度下私聊与群聊共享同一会话
+	assert.Equal(t, SessionKeyFor(SessionScopeSender, "telegram", "42", "group-1"), SessionKeyFor(SessionScopeSender, "telegram", "42", "42"))
+}
+
+func TestInboundMiddlewareTransformsAndDrops(t *testing.T) {
+	bus := NewMessageBus(10)
+	defer bus.Close()
+
+	var order []string
+	bus.UseInbound(
+		func(msg *InboundMessage) *InboundMessage {
+			order = append(order, "filter")
+			if msg.SenderID == "spammer" {
+				return nil
+			}
+			return msg
+		},
+		func(msg *InboundMessage) *InboundMessage {
+			order = append(order, "upper")
+			msg.Content = strings.ToUpper(msg.Content)
+			return msg
+		},
+	)
+
+	require.NoError(t, bus.PublishInbound(NewInboundMessage("telegram", "spammer", "chat1", "buy now")))
+	assert.Equal(t, []string{"filter"}, order, "dropping stops the chain")
+	_, ok := bus.TryConsumeInbound()
+	assert.False(t, ok, "dropped message must not be queued")
+
+	require.NoError(t, bus.PublishInbound(NewInboundMessage("telegram", "alice", "chat1", "hello")))
+	msg, ok := bus.TryConsumeInbound()
+	require.True(t, ok)
+	assert.Equal(t, "HELLO", msg.Content)
+	assert.Equal(t, []string{"filter", "filter", "upper"}, order)
+}
+
+func TestOutboundMiddlewareCanReplaceMessage(t *testing.T) {
+	bus := NewMessageBus(10)
+	defer bus.Close()
+
+	bus.UseOutbound(func(msg *OutboundMessage) *OutboundMessage {
+		if msg.Content == "" {
+			return nil
+		}
+		return NewOutboundMessage(msg.Channel, msg.ChatID, msg.Content+" [via middleware]")
+	})
+
+	require.NoError(t, bus.PublishOutbound(NewOutboundMessage("discord", "room", "")))
+	_, ok := bus.TryConsumeOutbound()
+	assert.False(t, ok)
+
+	require.NoError(t, bus.PublishOutbound(NewOutboundMessage("discord", "room", "done")))
+	msg, ok := bus.TryConsumeOutbound()
+	require.True(t, ok)
+	assert.Equal(t, "done [via middleware]", msg.Content)
+}
+
+func TestMiddlewareMayPublishWithoutDeadlock(t *testing.T) {
+	bus := NewMessageBus(10)
+	defer bus.Close()
+
+	bus.UseInbound(func(msg *InboundMessage) *InboundMessage {
+		if msg.Content == "ping" {
+			_ = bus.PublishOutbound(NewOutboundMessage(msg.Channel, msg.ChatID, "pong"))
+			return nil
+		}
+		return msg
+	})
+
+	require.NoError(t, bus.PublishInbound(NewInboundMessage("cli", "user", "chat", "ping")))
+	out, ok := bus.TryConsumeOutbound()
+	require.True(t, ok)
+	assert.Equal(t, "pong", out.Content)
+}
@@ -18,9 +18,21 @@ type InboundMessage struct {
 	Content        string           `json:"content"`                  // 消息内容
 	SelectedSkills []string         `json:"selectedSkills,omitempty"` // optional explicit skill filters
 	Media          *MediaAttachment `json:"media,omitempty"`
-	SessionKey     string           `json:"sessionKey"` // channel:chatId
+	SessionKey     string           `json:"sessionKey"` // 默认 channel:chatId，见 SessionKeyFor
+	// MessageID 频道内的原始消息 ID（Discord 斜杠命令为交互 ID），回复时写入 OutboundMessage.ReplyTo
+	MessageID string `json:"messageId,omitempty"`
 }
 
+// 会话隔离粒度
+const (
+	// SessionScopeChat 同一聊天共享一个会话（默认）
+	SessionScopeChat = "chat"
+	// SessionScopeSender 同一发送者共享一个会话，跨聊天保持上下文
+	SessionScopeSender = "sender"
+	// SessionScopeChatSender 群聊内每个发送者各自一个会话
+	SessionScopeChatSender = "chat_sender"
+)
+
 // NewInboundMessage 创建入站消息
 func NewInboundMessage(channel, senderID, chatID, content string) *InboundMessage {
 	return &InboundMessage{
@@ -32,12 +44,43 @@ func NewInboundMessage(channel, senderID, chatID, content string) *InboundMessag
 	}
 }
 
+// SessionKeyFor 按隔离粒度生成会话 key：
+// chat → channel:chatId；sender → channel:user:senderId；chat_sender → channel:chatId:senderId。
+// 发送者为空时退回 channel:chatId；chat_sender 在私聊（senderId 与 chatId 相同）时也不追加发送者；
+// 未知粒度按 chat 处理
+func SessionKeyFor(scope, channel, senderID, chatID string) string {
+	chatKey := channel + ":" + chatID
+	if senderID == "" {
+		return chatKey
+	}
+	switch scope {
+	case SessionScopeSender:
+		return channel + ":user:" + senderID
+	case SessionScopeChatSender:
+		if senderID == chatID {
+			return chatKey
+		}
+		return chatKey + ":" + senderID
+	default:
+		return chatKey
+	}
+}
+
+// ApplySessionScope 按隔离粒度重新计算 SessionKey
+func (m *InboundMessage) ApplySessionScope(scope string) {
+	m.SessionKey = SessionKeyFor(scope, m.Channel, m.SenderID, m.ChatID)
+}
+
 // OutboundMessage 出站消息
 type OutboundMessage struct {
 	Channel string           `json:"channel"`
 	ChatID  string           `json:"chatId"`
 	Content string           `json:"content"`
 	Media   *MediaAttachment `json:"media,omitempty"`
+	// ReplyTo 所回复的入站消息 ID，实现 channels.ReplySender 的频道据此关联原始消息
+	ReplyTo string `json:"replyTo,omitempty"`
+	// Delivered 表示内容已在流式过程中送达用户，调用方不应再次发送
+	Delivered bool `json:"-"`
 }
 
 // NewOutboundMessage 创建出站消息
@@ -49,6 +92,13 @@ func NewOutboundMessage(channel, chatID, content string) *OutboundMessage {
 	}
 }
 
+// NewReply 创建回复 in 的出站消息，ReplyTo 指向 in 的原始消息
+func NewReply(in *InboundMessage, content string) *OutboundMessage {
+	out := NewOutboundMessage(in.Channel, in.ChatID, content)
+	out.ReplyTo = in.MessageID
+	return out
+}
+
 // NewOutboundMessageWithMedia 创建带媒体附件的出站消息
 func NewOutboundMessageWithMedia(channel, chatID, content string, media *MediaAttachment) *OutboundMessage {
 	return &OutboundMessage{
@@ -0,0 +1,61 @@
+package bus
+
+// InboundMiddleware 入站消息中间件。
+// 返回值替换原消息继续传递给下一个中间件；返回 nil 表示丢弃该消息，
+// 后续中间件不再执行，消息也不会进入队列（PublishInbound 返回 nil）。
+// 中间件可以直接修改并返回传入的消息。
+type InboundMiddleware func(msg *InboundMessage) *InboundMessage
+
+// OutboundMiddleware 出站消息中间件，约定同 InboundMiddleware。
+// 注意：开启 channels.streamResponses 时边生成边发送的分段不经过总线，也不会经过出站中间件。
+type OutboundMiddleware func(msg *OutboundMessage) *OutboundMessage
+
+// UseInbound 追加入站中间件，按注册顺序在 PublishInbound 入队前执行
+func (b *MessageBus) UseInbound(middleware ...InboundMiddleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, mw := range middleware {
+		if mw != nil {
+			b.inboundMiddleware = append(b.inboundMiddleware, mw)
+		}
+	}
+}
+
+// UseOutbound 追加出站中间件，按注册顺序在 PublishOutbound 入队前执行
+func (b *MessageBus) UseOutbound(middleware ...OutboundMiddleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, mw := range middleware {
+		if mw != nil {
+			b.outboundMiddleware = append(b.outboundMiddleware, mw)
+		}
+	}
+}
+
+// applyInbound 依次执行入站中间件；执行时不持有锁，中间件内可以再次发布消息
+func (b *MessageBus) applyInbound(msg *InboundMessage) *InboundMessage {
+	b.mu.RLock()
+	chain := b.inboundMiddleware
+	b.mu.RUnlock()
+
+	for _, mw := range chain {
+		if msg = mw(msg); msg == nil {
+			return nil
+		}
+	}
+	return msg
+}
+
+// applyOutbound 依次执行出站中间件；执行时不持有锁，中间件内可以再次发布消息
+func (b *MessageBus) applyOutbound(msg *OutboundMessage) *OutboundMessage {
+	b.mu.RLock()
+	chain := b.outboundMiddleware
+	b.mu.RUnlock()
+
+	for _, mw := range chain {
+		if msg = mw(msg); msg == nil {
+			return nil
+		}
+	}
+	return msg
+}
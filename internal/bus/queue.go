@@ -11,6 +11,9 @@ type MessageBus struct {
 	outbound chan *OutboundMessage
 	mu       sync.RWMutex
 	closed   bool
+
+	inboundMiddleware  []InboundMiddleware
+	outboundMiddleware []OutboundMiddleware
 }
 
 // NewMessageBus 创建消息总线
@@ -24,8 +27,12 @@ func NewMessageBus(bufferSize int) *MessageBus {
 	}
 }
 
-// PublishInbound 发布入站消息
+// PublishInbound 发布入站消息；消息先经过入站中间件，被丢弃时返回 nil
 func (b *MessageBus) PublishInbound(msg *InboundMessage) error {
+	if msg = b.applyInbound(msg); msg == nil {
+		return nil
+	}
+
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
@@ -41,8 +48,12 @@ func (b *MessageBus) PublishInbound(msg *InboundMessage) error {
 	}
 }
 
-// PublishOutbound 发布出站消息
+// PublishOutbound 发布出站消息；消息先经过出站中间件，被丢弃时返回 nil
 func (b *MessageBus) PublishOutbound(msg *OutboundMessage) error {
+	if msg = b.applyOutbound(msg); msg == nil {
+		return nil
+	}
+
 	b.mu.RLock()
 	defer b.mu.RUnlock()
 
@@ -2,6 +2,10 @@ package channels
 
 import (
 	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Lichas/maxclaw/internal/bus"
 )
@@ -14,7 +18,53 @@ type Message struct {
 	ChatID  string
 	Channel string
 	Media   *bus.MediaAttachment
-	Raw     interface{}
+	// Timestamp 平台侧的消息发送时间，未知时为零值
+	Timestamp time.Time
+	Raw       interface{}
+}
+
+// IsStaleMessage 判断消息是否早于 maxAge；maxAge <= 0 或消息没有时间戳时视为新消息
+func IsStaleMessage(msg *Message, maxAge time.Duration, now time.Time) bool {
+	if msg == nil || maxAge <= 0 || msg.Timestamp.IsZero() {
+		return false
+	}
+	return now.Sub(msg.Timestamp) > maxAge
+}
+
+// unixSecondsTime 将 Unix 秒转换为 time.Time，非正数返回零值
+func unixSecondsTime(sec int64) time.Time {
+	if sec <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// parseSlackTimestamp 解析 Slack 的 "1700000000.000100" 格式时间戳
+func parseSlackTimestamp(ts string) time.Time {
+	secPart, _, _ := strings.Cut(strings.TrimSpace(ts), ".")
+	sec, err := strconv.ParseInt(secPart, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return unixSecondsTime(sec)
+}
+
+// parseUnixMillisString 解析毫秒级 Unix 时间戳字符串（飞书 create_time）
+func parseUnixMillisString(ms string) time.Time {
+	v, err := strconv.ParseInt(strings.TrimSpace(ms), 10, 64)
+	if err != nil || v <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(v)
+}
+
+// parseQQTimestamp 解析 QQ 事件中的 RFC3339 时间戳
+func parseQQTimestamp(ts string) time.Time {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(ts))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
 }
 
 // Channel 频道接口
@@ -33,9 +83,23 @@ type Channel interface {
 	IsEnabled() bool
 }
 
-// Registry 频道注册表
+// StatusProvider 可选接口：能报告连接状态的频道实现它，返回值会原样序列化到 Web UI 的 /api/status
+type StatusProvider interface {
+	ChannelStatus() interface{}
+}
+
+// ReplySender 可选接口：能把回复关联到原始消息的频道实现它（如 Discord 用斜杠命令的延迟响应回复），
+// replyTo 为入站消息的 ID，出站消息带 ReplyTo 时网关优先调用 SendReply
+type ReplySender interface {
+	SendReply(chatID, replyTo, text string) error
+}
+
+// Registry 频道注册表（并发安全，配置热加载时会增删频道）
 type Registry struct {
+	mu       sync.RWMutex
 	channels map[string]Channel
+	// reconcileMu 串行化 Reconcile，避免两次热加载交错启停同一频道
+	reconcileMu sync.Mutex
 }
 
 // NewRegistry 创建频道注册表
@@ -47,17 +111,30 @@ func NewRegistry() *Registry {
 
 // Register 注册频道
 func (r *Registry) Register(channel Channel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.channels[channel.Name()] = channel
 }
 
+// Unregister 移除频道（不会停止频道）
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.channels, name)
+}
+
 // Get 获取频道
 func (r *Registry) Get(name string) (Channel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	ch, ok := r.channels[name]
 	return ch, ok
 }
 
 // GetAll 获取所有频道
 func (r *Registry) GetAll() []Channel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	channels := make([]Channel, 0, len(r.channels))
 	for _, ch := range r.channels {
 		channels = append(channels, ch)
@@ -67,6 +144,8 @@ func (r *Registry) GetAll() []Channel {
 
 // GetEnabled 获取启用的频道
 func (r *Registry) GetEnabled() []Channel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	channels := make([]Channel, 0)
 	for _, ch := range r.channels {
 		if ch.IsEnabled() {
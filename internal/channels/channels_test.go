@@ -2,6 +2,7 @@ package channels
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -392,3 +393,51 @@ func TestSendMessageNotEnabled(t *testing.T) {
 		assert.Contains(t, err.Error(), "not enabled")
 	})
 }
+
+func TestIsStaleMessage(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	stale := &Message{ID: "1", Timestamp: now.Add(-2 * time.Hour)}
+	fresh := &Message{ID: "2", Timestamp: now.Add(-30 * time.Second)}
+	unknown := &Message{ID: "3"}
+
+	assert.True(t, IsStaleMessage(stale, time.Hour, now))
+	assert.False(t, IsStaleMessage(fresh, time.Hour, now))
+	assert.False(t, IsStaleMessage(unknown, time.Hour, now))
+	assert.False(t, IsStaleMessage(stale, 0, now))
+}
+
+func TestInboundTimestampParsing(t *testing.T) {
+	assert.Equal(t, int64(1700000000), parseSlackTimestamp("1700000000.000100").Unix())
+	assert.Equal(t, int64(1700000000123), parseUnixMillisString("1700000000123").UnixMilli())
+	assert.Equal(t, int64(1699249038), parseQQTimestamp("2023-11-06T13:37:18+08:00").Unix())
+	assert.True(t, parseSlackTimestamp("").IsZero())
+	assert.True(t, unixSecondsTime(0).IsZero())
+}
+
+// 所有频道都实现 Channel；能报告连接状态的频道实现 StatusProvider
+var (
+	_ Channel = (*TelegramChannel)(nil)
+	_ Channel = (*DiscordChannel)(nil)
+	_ Channel = (*WhatsAppChannel)(nil)
+	_ Channel = (*WebSocketChannel)(nil)
+	_ Channel = (*SlackChannel)(nil)
+	_ Channel = (*EmailChannel)(nil)
+	_ Channel = (*QQChannel)(nil)
+	_ Channel = (*FeishuChannel)(nil)
+
+	_ StatusProvider = (*TelegramChannel)(nil)
+	_ StatusProvider = (*WhatsAppChannel)(nil)
+)
+
+func TestStatusProvidersReportTypedStatus(t *testing.T) {
+	tg := NewTelegramChannel(&TelegramConfig{Token: "test-token", Enabled: true})
+	tgStatus, ok := tg.ChannelStatus().(TelegramStatus)
+	require.True(t, ok)
+	assert.True(t, tgStatus.Enabled)
+
+	wa := NewWhatsAppChannel(&WhatsAppConfig{Enabled: true, BridgeURL: "ws://localhost:3001"})
+	waStatus, ok := wa.ChannelStatus().(WhatsAppStatus)
+	require.True(t, ok)
+	assert.True(t, waStatus.Enabled)
+}
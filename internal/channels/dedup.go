@@ -0,0 +1,182 @@
+package channels
+
+import (
+	"container/list"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+)
+
+// 已处理消息缓存的默认容量与保留时长
+const (
+	DefaultSeenMessageCapacity = 2000
+	DefaultSeenMessageTTL      = 24 * time.Hour
+)
+
+// SeenMessageCache 记录已分发过的入站消息（频道 + 会话 + 消息 ID），用于丢弃网关重启后
+// 平台重放的重复消息；按 LRU 与 TTL 淘汰，path 非空时持久化到磁盘
+type SeenMessageCache struct {
+	mu       sync.Mutex
+	path     string
+	capacity int
+	ttl      time.Duration
+	order    *list.List // 最近命中的在队尾
+	entries  map[string]*list.Element
+	now      func() time.Time
+}
+
+type seenMessageEntry struct {
+	Key    string    `json:"key"`
+	SeenAt time.Time `json:"seenAt"`
+}
+
+// NewSeenMessageCache 创建缓存并从 path 恢复；capacity/ttl <= 0 时使用默认值
+func NewSeenMessageCache(path string, capacity int, ttl time.Duration) *SeenMessageCache {
+	if capacity <= 0 {
+		capacity = DefaultSeenMessageCapacity
+	}
+	if ttl <= 0 {
+		ttl = DefaultSeenMessageTTL
+	}
+	c := &SeenMessageCache{
+		path:     strings.TrimSpace(path),
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+		now:      time.Now,
+	}
+	c.load()
+	return c
+}
+
+// Seen 判断消息是否已处理过；未处理过则记录下来并返回 false。没有消息 ID 的消息无法去重，始终返回 false
+func (c *SeenMessageCache) Seen(msg *Message) bool {
+	if c == nil || msg == nil || strings.TrimSpace(msg.ID) == "" {
+		return false
+	}
+	key := seenMessageKey(msg)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.evictExpired(now)
+	if elem, ok := c.entries[key]; ok {
+		c.order.MoveToBack(elem)
+		return true
+	}
+
+	c.entries[key] = c.order.PushBack(&seenMessageEntry{Key: key, SeenAt: now})
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Front())
+	}
+	c.persist()
+	return false
+}
+
+// Len 返回当前缓存的消息数
+func (c *SeenMessageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// seenMessageKey 部分平台（如 Telegram）的消息 ID 只在会话内唯一，因此同时带上 chatID
+func seenMessageKey(msg *Message) string {
+	return msg.Channel + "\x00" + msg.ChatID + "\x00" + strings.TrimSpace(msg.ID)
+}
+
+// evictExpired 淘汰超过 TTL 的记录；记录按首次出现时间判断，命中不会延长保留期
+func (c *SeenMessageCache) evictExpired(now time.Time) {
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if now.Sub(elem.Value.(*seenMessageEntry).SeenAt) > c.ttl {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *SeenMessageCache) removeElement(elem *list.Element) {
+	entry := c.order.Remove(elem).(*seenMessageEntry)
+	delete(c.entries, entry.Key)
+}
+
+func (c *SeenMessageCache) load() {
+	if c.path == "" {
+		return
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			if lg := logging.Get(); lg != nil && lg.Channels != nil {
+				lg.Channels.Printf("read seen message cache error: %v", err)
+			}
+		}
+		return
+	}
+
+	var saved []seenMessageEntry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("parse seen message cache error: %v", err)
+		}
+		return
+	}
+
+	now := c.now()
+	for i := range saved {
+		entry := saved[i]
+		if entry.Key == "" || now.Sub(entry.SeenAt) > c.ttl {
+			continue
+		}
+		if elem, ok := c.entries[entry.Key]; ok {
+			c.removeElement(elem)
+		}
+		c.entries[entry.Key] = c.order.PushBack(&entry)
+	}
+	for c.order.Len() > c.capacity {
+		c.removeElement(c.order.Front())
+	}
+}
+
+// persist 按 LRU 顺序写入 path（先写临时文件再 rename，避免半写）；调用方需持有锁
+func (c *SeenMessageCache) persist() {
+	if c.path == "" {
+		return
+	}
+
+	saved := make([]seenMessageEntry, 0, c.order.Len())
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		saved = append(saved, *elem.Value.(*seenMessageEntry))
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("create seen message cache dir error: %v", err)
+		}
+		return
+	}
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("write seen message cache error: %v", err)
+		}
+		return
+	}
+	if err := os.Rename(tmpPath, c.path); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("rename seen message cache error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package channels
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeenMessageCacheDetectsDuplicates(t *testing.T) {
+	c := NewSeenMessageCache("", 0, 0)
+	msg := &Message{ID: "1", Channel: "telegram", ChatID: "9"}
+
+	assert.False(t, c.Seen(msg))
+	assert.True(t, c.Seen(msg))
+	// Telegram 的 message_id 只在会话内唯一，不同会话的同一 ID 不是重复
+	assert.False(t, c.Seen(&Message{ID: "1", Channel: "telegram", ChatID: "10"}))
+	assert.False(t, c.Seen(&Message{ID: "1", Channel: "whatsapp", ChatID: "9"}))
+	// 没有 ID 的消息无法去重
+	assert.False(t, c.Seen(&Message{Channel: "telegram", ChatID: "9"}))
+	assert.False(t, c.Seen(&Message{Channel: "telegram", ChatID: "9"}))
+	assert.Equal(t, 3, c.Len())
+}
+
+func TestSeenMessageCacheEvictsLRUAndExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	c := NewSeenMessageCache("", 2, time.Hour)
+	c.now = func() time.Time { return now }
+
+	a := &Message{ID: "a", Channel: "slack", ChatID: "c"}
+	b := &Message{ID: "b", Channel: "slack", ChatID: "c"}
+	d := &Message{ID: "d", Channel: "slack", ChatID: "c"}
+	assert.False(t, c.Seen(a))
+	assert.False(t, c.Seen(b))
+	assert.True(t, c.Seen(a)) // a 变为最近使用
+	assert.False(t, c.Seen(d))
+	assert.Equal(t, 2, c.Len())
+	assert.True(t, c.Seen(a))
+	assert.False(t, c.Seen(b), "least recently used entry should be evicted")
+
+	now = now.Add(2 * time.Hour)
+	assert.False(t, c.Seen(a), "expired entry should be forgotten")
+}
+
+func TestSeenMessageCachePersistsAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "channels", "seen_messages.json")
+	msg := &Message{ID: "wamid.1", Channel: "whatsapp", ChatID: "123@s.whatsapp.net"}
+
+	first := NewSeenMessageCache(path, 0, 0)
+	assert.False(t, first.Seen(msg))
+	_, err := os.Stat(path)
+	require.NoError(t, err)
+
+	restarted := NewSeenMessageCache(path, 0, 0)
+	assert.Equal(t, 1, restarted.Len())
+	assert.True(t, restarted.Seen(msg))
+
+	expired := NewSeenMessageCache(path, 0, time.Nanosecond)
+	assert.Equal(t, 0, expired.Len())
+}
+
+func TestSeenMessageCacheIgnoresCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen_messages.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0644))
+
+	c := NewSeenMessageCache(path, 0, 0)
+	assert.Equal(t, 0, c.Len())
+	assert.False(t, c.Seen(&Message{ID: "1", Channel: "telegram", ChatID: "9"}))
+}
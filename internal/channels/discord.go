@@ -15,11 +15,22 @@ import (
 	"github.com/bwmarrin/discordgo"
 )
 
+const (
+	// DefaultDiscordSlashCommand 默认注册的斜杠命令名
+	DefaultDiscordSlashCommand = "ask"
+	// discordPromptOption 斜杠命令中携带提问内容的参数名
+	discordPromptOption = "prompt"
+	// discordInteractionTTL 交互 token 的有效期，过期后只能普通发消息
+	discordInteractionTTL = 15 * time.Minute
+)
+
 // DiscordConfig Discord 配置
 type DiscordConfig struct {
 	Token     string   `json:"token"`
 	Enabled   bool     `json:"enabled"`
 	AllowFrom []string `json:"allowFrom"`
+	// SlashCommand 斜杠命令名，为空时使用 DefaultDiscordSlashCommand，"off" 表示不注册
+	SlashCommand string `json:"slashCommand,omitempty"`
 }
 
 // DiscordChannel Discord 频道
@@ -32,6 +43,15 @@ type DiscordChannel struct {
 	enabled        bool
 
 	session *discordgo.Session
+
+	// pending 已延迟响应、等待回复的斜杠命令交互，按交互 ID（即入站消息 ID）索引
+	pendingMu sync.Mutex
+	pending   map[string]pendingInteraction
+}
+
+type pendingInteraction struct {
+	interaction *discordgo.Interaction
+	createdAt   time.Time
 }
 
 // NewDiscordChannel 创建 Discord 频道
@@ -43,6 +63,20 @@ func NewDiscordChannel(config *DiscordConfig) *DiscordChannel {
 		},
 		stopChan: make(chan struct{}),
 		enabled:  config.Enabled && config.Token != "",
+		pending:  make(map[string]pendingInteraction),
+	}
+}
+
+// slashCommandName 返回要注册的斜杠命令名，未启用时返回空串
+func (d *DiscordChannel) slashCommandName() string {
+	name := strings.ToLower(strings.TrimSpace(d.config.SlashCommand))
+	switch name {
+	case "":
+		return DefaultDiscordSlashCommand
+	case "off":
+		return ""
+	default:
+		return name
 	}
 }
 
@@ -77,12 +111,16 @@ func (d *DiscordChannel) Start(ctx context.Context) error {
 	dg.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
 		d.handleMessage(s, m)
 	})
+	dg.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		d.handleInteraction(s, i)
+	})
 
 	if err := dg.Open(); err != nil {
 		return err
 	}
 
 	d.session = dg
+	d.registerSlashCommand(dg)
 
 	d.wg.Add(1)
 	go func() {
@@ -128,6 +166,33 @@ func (d *DiscordChannel) SendMessage(channelID string, text string) error {
 	if d.session == nil {
 		return fmt.Errorf("discord session not started")
 	}
+	return d.send(channelID, text)
+}
+
+// SendReply 回复入站消息：replyTo 是尚未应答的斜杠命令交互时，第一条回复填入其延迟响应，
+// 其余（包括同一频道中其他会话的消息）照常发到频道
+func (d *DiscordChannel) SendReply(channelID, replyTo, text string) error {
+	if !d.enabled {
+		return fmt.Errorf("discord channel not enabled")
+	}
+	if d.session == nil {
+		return fmt.Errorf("discord session not started")
+	}
+	if interaction := d.popPendingInteraction(replyTo, time.Now()); interaction != nil {
+		content := text
+		if _, err := d.session.InteractionResponseEdit(interaction, &discordgo.WebhookEdit{Content: &content}); err == nil {
+			if lg := logging.Get(); lg != nil && lg.Channels != nil {
+				lg.Channels.Printf("discord interaction reply chat=%s text=%q", channelID, logging.Truncate(text, 300))
+			}
+			return nil
+		} else if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("discord interaction reply failed chat=%s err=%v, falling back to message", channelID, err)
+		}
+	}
+	return d.send(channelID, text)
+}
+
+func (d *DiscordChannel) send(channelID, text string) error {
 	_, err := d.session.ChannelMessageSend(channelID, text)
 	if err != nil {
 		if lg := logging.Get(); lg != nil && lg.Channels != nil {
@@ -141,6 +206,17 @@ func (d *DiscordChannel) SendMessage(channelID string, text string) error {
 	return nil
 }
 
+// SendTyping 在 Discord 频道显示“正在输入”，约 10 秒后或发出消息时自动消失
+func (d *DiscordChannel) SendTyping(channelID string) error {
+	if !d.enabled {
+		return fmt.Errorf("discord channel not enabled")
+	}
+	if d.session == nil {
+		return fmt.Errorf("discord session not started")
+	}
+	return d.session.ChannelTyping(channelID)
+}
+
 // SendWebhookMessage 通过 Webhook 发送消息
 func (d *DiscordChannel) SendWebhookMessage(webhookURL string, text string) error {
 	if webhookURL == "" {
@@ -210,12 +286,13 @@ func (d *DiscordChannel) handleMessage(_ *discordgo.Session, m *discordgo.Messag
 	}
 
 	msg := &Message{
-		ID:      m.ID,
-		Text:    m.Content,
-		Sender:  d.authorLabel(m.Author),
-		ChatID:  m.ChannelID,
-		Channel: "discord",
-		Raw:     m,
+		ID:        m.ID,
+		Text:      m.Content,
+		Sender:    d.authorLabel(m.Author),
+		ChatID:    m.ChannelID,
+		Channel:   "discord",
+		Timestamp: m.Timestamp,
+		Raw:       m,
 	}
 	if msg.Text == "" {
 		return
@@ -226,6 +303,128 @@ func (d *DiscordChannel) handleMessage(_ *discordgo.Session, m *discordgo.Messag
 	}
 }
 
+// registerSlashCommand 注册全局斜杠命令；失败只记录日志，普通消息仍可用
+func (d *DiscordChannel) registerSlashCommand(s *discordgo.Session) {
+	name := d.slashCommandName()
+	if name == "" || s.State == nil || s.State.User == nil {
+		return
+	}
+	_, err := s.ApplicationCommandCreate(s.State.User.ID, "", &discordgo.ApplicationCommand{
+		Name:        name,
+		Description: "Ask the assistant",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        discordPromptOption,
+				Description: "What do you want to ask?",
+				Required:    true,
+			},
+		},
+	})
+	if lg := logging.Get(); lg != nil && lg.Channels != nil {
+		if err != nil {
+			lg.Channels.Printf("discord register slash command /%s failed: %v", name, err)
+		} else {
+			lg.Channels.Printf("discord registered slash command /%s", name)
+		}
+	}
+}
+
+// handleInteraction 处理斜杠命令：先延迟响应（Discord 要求 3 秒内应答），再交给消息处理器
+func (d *DiscordChannel) handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if !d.enabled || d.messageHandler == nil || i == nil || i.Interaction == nil {
+		return
+	}
+	msg := d.interactionMessage(i.Interaction)
+	if msg == nil {
+		return
+	}
+
+	if err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	}); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("discord defer interaction failed chat=%s err=%v", msg.ChatID, err)
+		}
+	} else {
+		d.addPendingInteraction(i.Interaction, time.Now())
+	}
+
+	d.messageHandler(msg)
+	if lg := logging.Get(); lg != nil && lg.Channels != nil {
+		lg.Channels.Printf("discord slash inbound chat=%s sender=%s text=%q", msg.ChatID, msg.Sender, logging.Truncate(msg.Text, 300))
+	}
+}
+
+// interactionMessage 把斜杠命令交互转换为频道消息；不是本频道的命令、发送者不在白名单或内容为空时返回 nil
+func (d *DiscordChannel) interactionMessage(i *discordgo.Interaction) *Message {
+	name := d.slashCommandName()
+	if name == "" || i.Type != discordgo.InteractionApplicationCommand {
+		return nil
+	}
+	data, ok := i.Data.(discordgo.ApplicationCommandInteractionData)
+	if !ok || data.Name != name {
+		return nil
+	}
+
+	// 服务器内调用时填 Member，私聊时填 User
+	user := i.User
+	if i.Member != nil && i.Member.User != nil {
+		user = i.Member.User
+	}
+	if user == nil || user.Bot || !d.isAllowed(user) {
+		return nil
+	}
+
+	var text string
+	for _, opt := range data.Options {
+		if opt != nil && opt.Name == discordPromptOption && opt.Type == discordgo.ApplicationCommandOptionString {
+			text = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	if text == "" {
+		return nil
+	}
+
+	timestamp, _ := discordgo.SnowflakeTimestamp(i.ID)
+	return &Message{
+		ID:        i.ID,
+		Text:      text,
+		Sender:    d.authorLabel(user),
+		ChatID:    i.ChannelID,
+		Channel:   "discord",
+		Timestamp: timestamp,
+		Raw:       i,
+	}
+}
+
+// addPendingInteraction 记录待回复的交互，同时清理已过期（始终没有回复）的交互
+func (d *DiscordChannel) addPendingInteraction(i *discordgo.Interaction, now time.Time) {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+	for id, p := range d.pending {
+		if now.Sub(p.createdAt) >= discordInteractionTTL {
+			delete(d.pending, id)
+		}
+	}
+	d.pending[i.ID] = pendingInteraction{interaction: i, createdAt: now}
+}
+
+// popPendingInteraction 取出 ID 对应且未过期的待回复交互
+func (d *DiscordChannel) popPendingInteraction(id string, now time.Time) *discordgo.Interaction {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+	p, ok := d.pending[id]
+	if !ok {
+		return nil
+	}
+	delete(d.pending, id)
+	if now.Sub(p.createdAt) >= discordInteractionTTL {
+		return nil
+	}
+	return p.interaction
+}
+
 func (d *DiscordChannel) isAllowed(author *discordgo.User) bool {
 	if len(d.config.AllowFrom) == 0 {
 		return true
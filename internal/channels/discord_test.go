@@ -0,0 +1,100 @@
+package channels
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func discordCommandInteraction(name, prompt string) *discordgo.Interaction {
+	return &discordgo.Interaction{
+		// 雪花 ID 内含创建时间：175928847299117063 对应 2016-04-30T11:18:25.796Z
+		ID:        "175928847299117063",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "chan-1",
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: name,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: "prompt", Type: discordgo.ApplicationCommandOptionString, Value: prompt},
+			},
+		},
+	}
+}
+
+func TestDiscordInteractionMessageFromGuildMember(t *testing.T) {
+	ch := NewDiscordChannel(&DiscordConfig{Token: "token", Enabled: true})
+	i := discordCommandInteraction("ask", "  what's the weather?  ")
+	i.Member = &discordgo.Member{User: &discordgo.User{ID: "42", Username: "alice"}}
+
+	msg := ch.interactionMessage(i)
+	require.NotNil(t, msg)
+	assert.Equal(t, "what's the weather?", msg.Text)
+	assert.Equal(t, "alice", msg.Sender)
+	assert.Equal(t, "chan-1", msg.ChatID)
+	assert.Equal(t, "discord", msg.Channel)
+	assert.Equal(t, "175928847299117063", msg.ID)
+	assert.Equal(t, int64(1462015105), msg.Timestamp.Unix())
+}
+
+func TestDiscordInteractionMessageFromDirectMessageUser(t *testing.T) {
+	ch := NewDiscordChannel(&DiscordConfig{Token: "token", Enabled: true, SlashCommand: "Bot"})
+	i := discordCommandInteraction("bot", "hello")
+	i.User = &discordgo.User{ID: "7", Username: "bob", Discriminator: "1234"}
+
+	msg := ch.interactionMessage(i)
+	require.NotNil(t, msg)
+	assert.Equal(t, "hello", msg.Text)
+	assert.Equal(t, "bob#1234", msg.Sender)
+}
+
+func TestDiscordInteractionMessageRejectsOtherInteractions(t *testing.T) {
+	ch := NewDiscordChannel(&DiscordConfig{Token: "token", Enabled: true, AllowFrom: []string{"alice"}})
+	alice := &discordgo.User{ID: "42", Username: "alice"}
+
+	other := discordCommandInteraction("other", "hello")
+	other.User = alice
+	assert.Nil(t, ch.interactionMessage(other), "different command name")
+
+	empty := discordCommandInteraction("ask", "   ")
+	empty.User = alice
+	assert.Nil(t, ch.interactionMessage(empty), "empty prompt")
+
+	stranger := discordCommandInteraction("ask", "hello")
+	stranger.User = &discordgo.User{ID: "99", Username: "mallory"}
+	assert.Nil(t, ch.interactionMessage(stranger), "sender not in allowFrom")
+
+	bot := discordCommandInteraction("ask", "hello")
+	bot.User = &discordgo.User{ID: "42", Username: "alice", Bot: true}
+	assert.Nil(t, ch.interactionMessage(bot), "bot user")
+
+	button := &discordgo.Interaction{Type: discordgo.InteractionMessageComponent, User: alice}
+	assert.Nil(t, ch.interactionMessage(button), "non-command interaction")
+
+	disabled := NewDiscordChannel(&DiscordConfig{Token: "token", Enabled: true, SlashCommand: "off"})
+	ask := discordCommandInteraction("ask", "hello")
+	ask.User = alice
+	assert.Nil(t, disabled.interactionMessage(ask), "slash command disabled")
+}
+
+func TestDiscordPendingInteractionsMatchReplyAndExpire(t *testing.T) {
+	ch := NewDiscordChannel(&DiscordConfig{Token: "token", Enabled: true})
+	now := time.Now()
+	stale := &discordgo.Interaction{ID: "stale", ChannelID: "chan-1"}
+	first := &discordgo.Interaction{ID: "first", ChannelID: "chan-1"}
+	second := &discordgo.Interaction{ID: "second", ChannelID: "chan-1"}
+
+	ch.addPendingInteraction(stale, now.Add(-discordInteractionTTL-time.Second))
+	ch.addPendingInteraction(first, now)
+	ch.addPendingInteraction(second, now)
+	assert.NotContains(t, ch.pending, "stale", "expired interactions are pruned")
+
+	// 只有回复对应交互的消息才会取走它，同频道的其他消息不受影响
+	assert.Nil(t, ch.popPendingInteraction("chan-1", now))
+	assert.Same(t, second, ch.popPendingInteraction("second", now))
+	assert.Nil(t, ch.popPendingInteraction("second", now), "an interaction is answered once")
+	assert.Nil(t, ch.popPendingInteraction("first", now.Add(discordInteractionTTL)))
+	assert.Empty(t, ch.pending)
+}
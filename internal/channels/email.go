@@ -221,13 +221,19 @@ func (e *EmailChannel) fetchAndHandleOne(c *imapclient.Client, id uint32) error
 		return nil
 	}
 
+	var sentAt time.Time
+	if fetched.Envelope != nil {
+		sentAt = fetched.Envelope.Date
+	}
+
 	e.messageHandler(&Message{
-		ID:      "email-" + strconv.FormatUint(uint64(id), 10),
-		Text:    "Email received\nFrom: " + sender + "\nSubject: " + subject + "\n\n" + content,
-		Sender:  sender,
-		ChatID:  sender,
-		Channel: "email",
-		Raw:     fetched,
+		ID:        "email-" + strconv.FormatUint(uint64(id), 10),
+		Text:      "Email received\nFrom: " + sender + "\nSubject: " + subject + "\n\n" + content,
+		Sender:    sender,
+		ChatID:    sender,
+		Channel:   "email",
+		Timestamp: sentAt,
+		Raw:       fetched,
 	})
 
 	if e.config.MarkSeen {
@@ -0,0 +1,130 @@
+package channels
+
+import (
+	"path/filepath"
+
+	"github.com/Lichas/maxclaw/internal/config"
+)
+
+// BuildFromConfig 按配置创建所有启用的频道，统一设置消息处理器并注册到新的注册表。
+// gateway 等入口共用这一条构建路径，新增频道时只需在此处接入。
+func BuildFromConfig(cfg *config.Config, handler func(msg *Message)) *Registry {
+	registry := NewRegistry()
+	if cfg == nil {
+		return registry
+	}
+	register := func(ch Channel) {
+		if handler != nil {
+			ch.SetMessageHandler(handler)
+		}
+		registry.Register(ch)
+	}
+
+	// Telegram
+	if c := cfg.Channels.Telegram; c.Enabled {
+		register(NewTelegramChannel(&TelegramConfig{
+			Token:      c.Token,
+			Enabled:    c.Enabled,
+			AllowFrom:  c.AllowFrom,
+			Proxy:      c.Proxy,
+			OffsetFile: filepath.Join(config.GetDataDir(), "channels", "telegram_offset"),
+		}))
+	}
+
+	// Discord
+	if c := cfg.Channels.Discord; c.Enabled {
+		register(NewDiscordChannel(&DiscordConfig{
+			Token:        c.Token,
+			Enabled:      c.Enabled,
+			AllowFrom:    c.AllowFrom,
+			SlashCommand: c.SlashCommand,
+		}))
+	}
+
+	// WhatsApp (Bridge)
+	if c := cfg.Channels.WhatsApp; c.Enabled {
+		register(NewWhatsAppChannel(&WhatsAppConfig{
+			Enabled:     c.Enabled,
+			BridgeURL:   c.BridgeURL,
+			BridgeToken: c.BridgeToken,
+			AllowFrom:   c.AllowFrom,
+			AllowSelf:   c.AllowSelf,
+		}))
+	}
+
+	// WebSocket
+	if c := cfg.Channels.WebSocket; c.Enabled {
+		register(NewWebSocketChannel(&WebSocketConfig{
+			Enabled:             c.Enabled,
+			Host:                c.Host,
+			Port:                c.Port,
+			Path:                c.Path,
+			AllowOrigins:        c.AllowOrigins,
+			PingIntervalSeconds: c.PingIntervalSeconds,
+		}))
+	}
+
+	// Slack（Socket Mode）
+	if c := cfg.Channels.Slack; c.Enabled {
+		register(NewSlackChannel(&SlackConfig{
+			Enabled:   c.Enabled,
+			BotToken:  c.BotToken,
+			AppToken:  c.AppToken,
+			AllowFrom: c.AllowFrom,
+		}))
+	}
+
+	// Email（IMAP/SMTP）
+	if c := cfg.Channels.Email; c.Enabled {
+		register(NewEmailChannel(&EmailConfig{
+			Enabled:             c.Enabled,
+			ConsentGranted:      c.ConsentGranted,
+			IMAPHost:            c.IMAPHost,
+			IMAPPort:            c.IMAPPort,
+			IMAPUsername:        c.IMAPUsername,
+			IMAPPassword:        c.IMAPPassword,
+			IMAPMailbox:         c.IMAPMailbox,
+			IMAPUseSSL:          c.IMAPUseSSL,
+			SMTPHost:            c.SMTPHost,
+			SMTPPort:            c.SMTPPort,
+			SMTPUsername:        c.SMTPUsername,
+			SMTPPassword:        c.SMTPPassword,
+			SMTPUseTLS:          c.SMTPUseTLS,
+			SMTPUseSSL:          c.SMTPUseSSL,
+			FromAddress:         c.FromAddress,
+			AutoReplyEnabled:    c.AutoReplyEnabled,
+			PollIntervalSeconds: c.PollIntervalSeconds,
+			MarkSeen:            c.MarkSeen,
+			AllowFrom:           c.AllowFrom,
+		}))
+	}
+
+	// QQ（腾讯官方 QQBot）
+	if c := cfg.Channels.QQ; c.Enabled {
+		register(NewQQChannel(&QQConfig{
+			Enabled:     c.Enabled,
+			AppID:       c.AppID,
+			AppSecret:   c.AppSecret,
+			AccessToken: c.AccessToken,
+			ListenAddr:  c.ListenAddr,
+			WebhookPath: c.WebhookPath,
+			WSURL:       c.WSURL,
+			AllowFrom:   c.AllowFrom,
+		}))
+	}
+
+	// Feishu（Webhook + OpenAPI）
+	if c := cfg.Channels.Feishu; c.Enabled {
+		register(NewFeishuChannel(&FeishuConfig{
+			Enabled:           c.Enabled,
+			AppID:             c.AppID,
+			AppSecret:         c.AppSecret,
+			VerificationToken: c.VerificationToken,
+			ListenAddr:        c.ListenAddr,
+			WebhookPath:       c.WebhookPath,
+			AllowFrom:         c.AllowFrom,
+		}))
+	}
+
+	return registry
+}
@@ -0,0 +1,83 @@
+package channels
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func registryNames(r *Registry) []string {
+	names := make([]string, 0)
+	for _, ch := range r.GetAll() {
+		names = append(names, ch.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestBuildFromConfigRegistersOnlyEnabledChannels(t *testing.T) {
+	cfg := config.DefaultConfig()
+	assert.Empty(t, registryNames(BuildFromConfig(cfg, nil)))
+
+	cfg.Channels.Telegram.Enabled = true
+	cfg.Channels.Telegram.Token = "tg-token"
+	cfg.Channels.Slack.Enabled = true
+	cfg.Channels.Slack.BotToken = "xoxb-test"
+	cfg.Channels.Feishu.Enabled = true
+
+	registry := BuildFromConfig(cfg, nil)
+	assert.Equal(t, []string{"feishu", "slack", "telegram"}, registryNames(registry))
+
+	_, ok := registry.Get("discord")
+	assert.False(t, ok)
+}
+
+func TestBuildFromConfigWiresMessageHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.WebSocket.Enabled = true
+
+	var received []*Message
+	registry := BuildFromConfig(cfg, func(msg *Message) {
+		received = append(received, msg)
+	})
+
+	ch, ok := registry.Get("websocket")
+	require.True(t, ok)
+	ws, ok := ch.(*WebSocketChannel)
+	require.True(t, ok)
+	require.NotNil(t, ws.messageHandler)
+
+	ws.messageHandler(&Message{Channel: "websocket", Text: "hi"})
+	require.Len(t, received, 1)
+	assert.Equal(t, "hi", received[0].Text)
+}
+
+func TestBuildFromConfigNilConfig(t *testing.T) {
+	assert.Empty(t, BuildFromConfig(nil, nil).GetAll())
+}
+
+func TestChangedChannels(t *testing.T) {
+	prev := config.DefaultConfig()
+	prev.Channels.Telegram.Enabled = true
+	prev.Channels.Telegram.Token = "old"
+	prev.Channels.Slack.Enabled = true
+
+	next := config.DefaultConfig()
+	next.Channels.Telegram.Enabled = true
+	next.Channels.Telegram.Token = "new"
+	next.Channels.Slack.Enabled = true
+	next.Channels.Discord.Enabled = true
+
+	// 新启用的 discord 不算“变化”，由 Reconcile 直接启动
+	assert.Equal(t, []string{"telegram"}, ChangedChannels(prev, next))
+	assert.Empty(t, ChangedChannels(next, next))
+
+	// 只修改频道模型不重启频道
+	withModel := config.DefaultConfig()
+	withModel.Channels = next.Channels
+	withModel.Channels.Slack.Model = "anthropic/claude-haiku-4-5"
+	assert.Empty(t, ChangedChannels(next, withModel))
+}
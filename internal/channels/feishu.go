@@ -230,12 +230,13 @@ func (f *FeishuChannel) handleWebhook(w http.ResponseWriter, r *http.Request) {
 	text := strings.TrimSpace(parseFeishuText(evt.Event.Message.MessageType, evt.Event.Message.Content))
 	if text != "" && f.messageHandler != nil {
 		f.messageHandler(&Message{
-			ID:      evt.Event.Message.MessageID,
-			Text:    text,
-			Sender:  sender,
-			ChatID:  sender,
-			Channel: "feishu",
-			Raw:     evt,
+			ID:        evt.Event.Message.MessageID,
+			Text:      text,
+			Sender:    sender,
+			ChatID:    sender,
+			Channel:   "feishu",
+			Timestamp: parseUnixMillisString(evt.Event.Message.CreateTime),
+			Raw:       evt,
 		})
 	}
 
@@ -346,6 +347,7 @@ type feishuEventEnvelope struct {
 			MessageID   string `json:"message_id"`
 			MessageType string `json:"message_type"`
 			Content     string `json:"content"`
+			CreateTime  string `json:"create_time"`
 		} `json:"message"`
 	} `json:"event"`
 }
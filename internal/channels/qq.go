@@ -541,13 +541,14 @@ func (q *QQChannel) handleC2CMessage(event *qqC2CMessageEvent) {
 	q.mu.Unlock()
 
 	q.messageHandler(&Message{
-		ID:      strings.TrimSpace(event.ID),
-		Text:    text,
-		Sender:  sender,
-		ChatID:  sender,
-		Channel: "qq",
-		Media:   media,
-		Raw:     event,
+		ID:        strings.TrimSpace(event.ID),
+		Text:      text,
+		Sender:    sender,
+		ChatID:    sender,
+		Channel:   "qq",
+		Media:     media,
+		Timestamp: parseQQTimestamp(event.Timestamp),
+		Raw:       event,
 	})
 }
 
@@ -0,0 +1,102 @@
+package channels
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiterPruneSize 桶数量超过该值时清理已回满（长时间未发消息）的桶
+const rateLimiterPruneSize = 4096
+
+// RateLimiter 按频道 + 发送者的令牌桶限流器：每条入站消息消耗一个令牌，令牌按固定速率补充，最多累积 burst 个
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // 每秒补充的令牌数
+	burst   float64
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	// notified 本次被限流期间是否已提示过发送者，取得令牌后重置
+	notified bool
+}
+
+// NewRateLimiter 创建限流器；perMinute <= 0 时返回 nil（不限流），burst <= 0 时等于 perMinute
+func NewRateLimiter(perMinute, burst int) *RateLimiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	if burst <= 0 {
+		burst = perMinute
+	}
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow 判断消息是否放行；被限流时 notify 只在本轮限流的第一条消息上为 true，避免对刷屏的发送者重复提示
+func (l *RateLimiter) Allow(msg *Message) (allowed bool, notify bool) {
+	if l == nil || msg == nil {
+		return true, false
+	}
+	key := rateLimitKey(msg)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.buckets) >= rateLimiterPruneSize {
+		l.prune(now)
+	}
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = bucket
+	}
+	l.refill(bucket, now)
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		bucket.notified = false
+		return true, false
+	}
+	if bucket.notified {
+		return false, false
+	}
+	bucket.notified = true
+	return false, true
+}
+
+// rateLimitKey 发送者为空时退回到会话 ID
+func rateLimitKey(msg *Message) string {
+	sender := msg.Sender
+	if sender == "" {
+		sender = msg.ChatID
+	}
+	return msg.Channel + "\x00" + sender
+}
+
+func (l *RateLimiter) refill(bucket *tokenBucket, now time.Time) {
+	if elapsed := now.Sub(bucket.last).Seconds(); elapsed > 0 {
+		bucket.tokens += elapsed * l.rate
+		if bucket.tokens > l.burst {
+			bucket.tokens = l.burst
+		}
+	}
+	bucket.last = now
+}
+
+func (l *RateLimiter) prune(now time.Time) {
+	for key, bucket := range l.buckets {
+		l.refill(bucket, now)
+		if bucket.tokens >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package channels
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestRateLimiter(perMinute, burst int) (*RateLimiter, *time.Time) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	l := NewRateLimiter(perMinute, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestRateLimiterDisabled(t *testing.T) {
+	l := NewRateLimiter(0, 5)
+	assert.Nil(t, l)
+	allowed, notify := l.Allow(&Message{Channel: "telegram", Sender: "1"})
+	assert.True(t, allowed)
+	assert.False(t, notify)
+}
+
+func TestRateLimiterOverLimitNotifiesOnce(t *testing.T) {
+	l, _ := newTestRateLimiter(6, 2)
+	msg := &Message{Channel: "telegram", Sender: "7", ChatID: "9"}
+
+	for i := 0; i < 2; i++ {
+		allowed, _ := l.Allow(msg)
+		assert.True(t, allowed, "burst message %d", i)
+	}
+	allowed, notify := l.Allow(msg)
+	assert.False(t, allowed)
+	assert.True(t, notify)
+	allowed, notify = l.Allow(msg)
+	assert.False(t, allowed)
+	assert.False(t, notify, "notice should only be sent once per limited period")
+
+	// 其他发送者与其他频道各自计数
+	allowed, _ = l.Allow(&Message{Channel: "telegram", Sender: "8", ChatID: "9"})
+	assert.True(t, allowed)
+	allowed, _ = l.Allow(&Message{Channel: "discord", Sender: "7", ChatID: "9"})
+	assert.True(t, allowed)
+}
+
+func TestRateLimiterRefill(t *testing.T) {
+	l, now := newTestRateLimiter(6, 2) // 每 10 秒补充一个令牌
+	msg := &Message{Channel: "whatsapp", Sender: "a"}
+
+	l.Allow(msg)
+	l.Allow(msg)
+	allowed, _ := l.Allow(msg)
+	assert.False(t, allowed)
+
+	*now = now.Add(5 * time.Second)
+	allowed, _ = l.Allow(msg)
+	assert.False(t, allowed, "half a token is not enough")
+
+	*now = now.Add(5 * time.Second)
+	allowed, _ = l.Allow(msg)
+	assert.True(t, allowed)
+	allowed, notify := l.Allow(msg)
+	assert.False(t, allowed)
+	assert.True(t, notify, "a new limited period should notify again")
+
+	// 长时间空闲后最多恢复到 burst
+	*now = now.Add(time.Hour)
+	for i := 0; i < 2; i++ {
+		allowed, _ = l.Allow(msg)
+		assert.True(t, allowed)
+	}
+	allowed, _ = l.Allow(msg)
+	assert.False(t, allowed)
+}
+
+func TestRateLimiterDefaultsBurstAndFallsBackToChatID(t *testing.T) {
+	l, _ := newTestRateLimiter(3, 0)
+	msg := &Message{Channel: "websocket", ChatID: "ws-1"}
+	for i := 0; i < 3; i++ {
+		allowed, _ := l.Allow(msg)
+		assert.True(t, allowed)
+	}
+	allowed, _ := l.Allow(msg)
+	assert.False(t, allowed)
+	allowed, _ = l.Allow(&Message{Channel: "websocket", ChatID: "ws-2"})
+	assert.True(t, allowed)
+}
+
+func TestRateLimiterPrunesIdleBuckets(t *testing.T) {
+	l, now := newTestRateLimiter(60, 1)
+	for i := 0; i < rateLimiterPruneSize; i++ {
+		l.Allow(&Message{Channel: "telegram", Sender: fmt.Sprint(i)})
+	}
+	assert.Len(t, l.buckets, rateLimiterPruneSize)
+
+	*now = now.Add(time.Minute)
+	l.Allow(&Message{Channel: "telegram", Sender: "new"})
+	assert.Len(t, l.buckets, 1)
+}
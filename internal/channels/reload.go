@@ -0,0 +1,153 @@
+package channels
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/Lichas/maxclaw/internal/config"
+)
+
+// ReconcileResult 一次热加载对频道注册表的调整结果
+type ReconcileResult struct {
+	Started   []string
+	Stopped   []string
+	Restarted []string
+	Errors    []error
+	// StartErrors 本次启动或重启失败的频道及原因（同时计入 Errors）
+	StartErrors map[string]error
+}
+
+// Changed 是否有频道被启动、停止或重启
+func (r ReconcileResult) Changed() bool {
+	return len(r.Started)+len(r.Stopped)+len(r.Restarted) > 0
+}
+
+// enabledChannelConfigs 返回启用频道的配置快照，用于比较两次配置之间的差异
+func enabledChannelConfigs(cfg *config.Config) map[string]interface{} {
+	result := make(map[string]interface{})
+	if cfg == nil {
+		return result
+	}
+	c := cfg.Channels
+	candidates := map[string]struct {
+		enabled bool
+		value   interface{}
+	}{
+		"telegram":  {c.Telegram.Enabled, c.Telegram},
+		"discord":   {c.Discord.Enabled, c.Discord},
+		"whatsapp":  {c.WhatsApp.Enabled, c.WhatsApp},
+		"websocket": {c.WebSocket.Enabled, c.WebSocket},
+		"slack":     {c.Slack.Enabled, c.Slack},
+		"email":     {c.Email.Enabled, c.Email},
+		"qq":        {c.QQ.Enabled, c.QQ},
+		"feishu":    {c.Feishu.Enabled, c.Feishu},
+	}
+	for name, candidate := range candidates {
+		if candidate.enabled {
+			result[name] = withoutModel(candidate.value)
+		}
+	}
+	return result
+}
+
+// withoutModel 清空频道配置中的 Model：模型覆盖由 AgentLoop 热更新，修改时不需要重启频道
+func withoutModel(value interface{}) interface{} {
+	copied := reflect.New(reflect.TypeOf(value)).Elem()
+	copied.Set(reflect.ValueOf(value))
+	if field := copied.FieldByName("Model"); field.IsValid() && field.Kind() == reflect.String {
+		field.SetString("")
+	}
+	return copied.Interface()
+}
+
+// ChangedChannels 返回两次配置中都启用但配置发生变化、需要重启的频道
+func ChangedChannels(prev, next *config.Config) []string {
+	before := enabledChannelConfigs(prev)
+	after := enabledChannelConfigs(next)
+	changed := []string{}
+	for name, value := range after {
+		if old, ok := before[name]; ok && !reflect.DeepEqual(old, value) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Reconcile 让注册表与 desired（通常由 BuildFromConfig 按新配置构建）保持一致：
+// 停止并移除不再启用的频道，启动新启用的频道，restart 中的频道用新实例替换；
+// 其余频道保留正在运行的旧实例
+func (r *Registry) Reconcile(ctx context.Context, desired *Registry, restart []string) ReconcileResult {
+	r.reconcileMu.Lock()
+	defer r.reconcileMu.Unlock()
+
+	var result ReconcileResult
+	restartSet := make(map[string]bool, len(restart))
+	for _, name := range restart {
+		restartSet[name] = true
+	}
+
+	current := channelsByName(r.GetAll())
+	wanted := channelsByName(desired.GetAll())
+
+	for _, name := range sortedChannelNames(current) {
+		if _, ok := wanted[name]; ok {
+			continue
+		}
+		if err := current[name].Stop(); err != nil {
+			result.Errors = append(result.Errors, fmt.Errorf("stop %s: %w", name, err))
+		}
+		r.Unregister(name)
+		result.Stopped = append(result.Stopped, name)
+	}
+
+	for _, name := range sortedChannelNames(wanted) {
+		ch := wanted[name]
+		old, exists := current[name]
+		if exists && !restartSet[name] {
+			continue
+		}
+		if exists {
+			if err := old.Stop(); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("stop %s: %w", name, err))
+			}
+		}
+		// 与启动流程一致：启动失败的频道仍保留在注册表中，便于状态页展示
+		r.Register(ch)
+		if ch.IsEnabled() {
+			if err := ch.Start(ctx); err != nil {
+				result.Errors = append(result.Errors, fmt.Errorf("start %s: %w", name, err))
+				if result.StartErrors == nil {
+					result.StartErrors = make(map[string]error)
+				}
+				result.StartErrors[name] = err
+			}
+		}
+		if exists {
+			result.Restarted = append(result.Restarted, name)
+		} else {
+			result.Started = append(result.Started, name)
+		}
+	}
+
+	return result
+}
+
+func channelsByName(list []Channel) map[string]Channel {
+	result := make(map[string]Channel, len(list))
+	for _, ch := range list {
+		result[ch.Name()] = ch
+	}
+	return result
+}
+
+func sortedChannelNames(m map[string]Channel) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
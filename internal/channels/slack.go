@@ -167,12 +167,13 @@ func (s *SlackChannel) handleEvent(evt socketmode.Event) {
 	}
 
 	s.messageHandler(&Message{
-		ID:      msgEvt.EventTimeStamp,
-		Text:    text,
-		Sender:  msgEvt.User,
-		ChatID:  msgEvt.Channel,
-		Channel: "slack",
-		Raw:     msgEvt,
+		ID:        msgEvt.EventTimeStamp,
+		Text:      text,
+		Sender:    msgEvt.User,
+		ChatID:    msgEvt.Channel,
+		Channel:   "slack",
+		Timestamp: parseSlackTimestamp(msgEvt.TimeStamp),
+		Raw:       msgEvt,
 	})
 }
 
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/Lichas/maxclaw/internal/bus"
 	"github.com/Lichas/maxclaw/internal/logging"
@@ -27,6 +28,8 @@ type TelegramConfig struct {
 	Enabled   bool     `json:"enabled"`
 	AllowFrom []string `json:"allowFrom,omitempty"`
 	Proxy     string   `json:"proxy,omitempty"`
+	// OffsetFile 持久化 getUpdates offset 的文件路径，为空则不持久化
+	OffsetFile string `json:"offsetFile,omitempty"`
 }
 
 // TelegramChannel Telegram 频道
@@ -130,6 +133,7 @@ func (t *TelegramChannel) Start(ctx context.Context) error {
 		return nil
 	}
 
+	t.restoreOffset()
 	t.refreshBotInfo()
 
 	t.wg.Add(1)
@@ -222,6 +226,7 @@ func (t *TelegramChannel) fetchUpdates() {
 		t.setStatus("ready", st.Username, st.Name, "")
 	}
 
+	prevOffset := t.offset
 	for _, update := range result.Result {
 		if update.UpdateID > t.offset {
 			t.offset = update.UpdateID
@@ -243,6 +248,67 @@ func (t *TelegramChannel) fetchUpdates() {
 			}
 		}
 	}
+
+	if t.offset != prevOffset {
+		t.persistOffset()
+	}
+}
+
+// restoreOffset 从 OffsetFile 恢复上次处理到的 update_id
+func (t *TelegramChannel) restoreOffset() {
+	path := strings.TrimSpace(t.config.OffsetFile)
+	if path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			if lg := logging.Get(); lg != nil && lg.Channels != nil {
+				lg.Channels.Printf("telegram read offset file error: %v", err)
+			}
+		}
+		return
+	}
+
+	offset, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("telegram parse offset file error: %v", err)
+		}
+		return
+	}
+	if offset > t.offset {
+		t.offset = offset
+	}
+}
+
+// persistOffset 将当前 offset 写入 OffsetFile（先写临时文件再 rename，避免半写）
+func (t *TelegramChannel) persistOffset() {
+	path := strings.TrimSpace(t.config.OffsetFile)
+	if path == "" {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("telegram create offset dir error: %v", err)
+		}
+		return
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(strconv.FormatInt(t.offset, 10)), 0644); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("telegram write offset file error: %v", err)
+		}
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("telegram rename offset file error: %v", err)
+		}
+	}
 }
 
 func (t *TelegramChannel) buildInboundMessage(message telegramMessage) *Message {
@@ -269,6 +335,10 @@ func (t *TelegramChannel) buildInboundMessage(message telegramMessage) *Message
 	if text == "" {
 		return nil
 	}
+	text, ok := t.resolveBotCommand(text)
+	if !ok {
+		return nil
+	}
 
 	sender := message.From.Username
 	if strings.TrimSpace(sender) == "" {
@@ -276,15 +346,55 @@ func (t *TelegramChannel) buildInboundMessage(message telegramMessage) *Message
 	}
 
 	return &Message{
-		ID:      strconv.FormatInt(message.MessageID, 10),
-		Text:    text,
-		Sender:  sender,
-		ChatID:  strconv.FormatInt(message.Chat.ID, 10),
-		Channel: "telegram",
-		Media:   media,
+		ID:        strconv.FormatInt(message.MessageID, 10),
+		Text:      text,
+		Sender:    sender,
+		ChatID:    strconv.FormatInt(message.Chat.ID, 10),
+		Channel:   "telegram",
+		Media:     media,
+		Timestamp: unixSecondsTime(message.Date),
 	}
 }
 
+// resolveBotCommand 去掉群聊命令 "/cmd@bot" 中指向本机器人的 @bot 后缀；
+// 指向其他机器人的命令返回 false 忽略整条消息，用户名未知时原样保留
+func (t *TelegramChannel) resolveBotCommand(text string) (string, bool) {
+	if !strings.HasPrefix(text, "/") {
+		return text, true
+	}
+	head, rest := text, ""
+	if i := strings.IndexFunc(text, unicode.IsSpace); i >= 0 {
+		head, rest = text[:i], text[i:]
+	}
+	command, target, found := strings.Cut(head, "@")
+	if !found || !isTelegramCommandName(strings.TrimPrefix(command, "/")) {
+		return text, true
+	}
+	t.mu.RLock()
+	botUsername := t.botUsername
+	t.mu.RUnlock()
+	if botUsername == "" {
+		return text, true
+	}
+	if !strings.EqualFold(target, botUsername) {
+		return "", false
+	}
+	return command + rest, true
+}
+
+// isTelegramCommandName Telegram 命令名只含字母、数字和下划线；其他以 / 开头的文本（如路径）不当作命令
+func isTelegramCommandName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r != '_' && (r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
 func telegramInboundMedia(message telegramMessage) *bus.MediaAttachment {
 	if len(message.Photo) > 0 {
 		photo := message.Photo[len(message.Photo)-1]
@@ -370,6 +480,35 @@ func (t *TelegramChannel) SendMessage(chatID string, text string) error {
 	return nil
 }
 
+// SendTyping 发送“正在输入”状态（sendChatAction），约 5 秒后或发出消息时自动消失
+func (t *TelegramChannel) SendTyping(chatID string) error {
+	if !t.enabled {
+		return fmt.Errorf("telegram channel not enabled")
+	}
+
+	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/sendChatAction", t.config.Token)
+
+	params := url.Values{}
+	params.Set("chat_id", chatID)
+	params.Set("action", "typing")
+
+	resp, err := t.httpClient.Post(
+		apiURL,
+		"application/x-www-form-urlencoded",
+		strings.NewReader(params.Encode()),
+	)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("telegram API error: %s", string(body))
+	}
+	return nil
+}
+
 // SendPhoto 发送图片
 func (t *TelegramChannel) SendPhoto(chatID string, photoPath string, caption string) error {
 	if !t.enabled {
@@ -508,6 +647,11 @@ func (t *TelegramChannel) Status() TelegramStatus {
 	}
 }
 
+// ChannelStatus 实现 StatusProvider
+func (t *TelegramChannel) ChannelStatus() interface{} {
+	return t.Status()
+}
+
 func (t *TelegramChannel) refreshBotInfo() {
 	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/getMe", t.config.Token)
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTelegramChannelIsAllowed(t *testing.T) {
@@ -47,3 +48,33 @@ func TestTelegramChannelIsAllowed(t *testing.T) {
 		assert.False(t, ch.isAllowed(123456, ""))
 	})
 }
+
+func TestTelegramBuildInboundMessageResolvesBotCommands(t *testing.T) {
+	ch := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true})
+	ch.setStatus("ready", "maxclaw_bot", "maxclaw", "")
+
+	build := func(text string) *Message {
+		return ch.buildInboundMessage(telegramMessage{
+			MessageID: 1,
+			From:      telegramUser{ID: 42, Username: "alice"},
+			Chat:      telegramChat{ID: -1001},
+			Text:      text,
+		})
+	}
+
+	msg := build("/reset@MaxClaw_Bot")
+	require.NotNil(t, msg)
+	assert.Equal(t, "/reset", msg.Text)
+
+	msg = build("/model@maxclaw_bot gpt-4o")
+	require.NotNil(t, msg)
+	assert.Equal(t, "/model gpt-4o", msg.Text)
+
+	// 指向其他机器人的命令不处理
+	assert.Nil(t, build("/reset@someotherbot"))
+
+	// 不像命令名的 / 开头文本原样保留
+	msg = build("/home/alice@host is full")
+	require.NotNil(t, msg)
+	assert.Equal(t, "/home/alice@host is full", msg.Text)
+}
@@ -1,6 +1,12 @@
 package channels
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +18,7 @@ func TestTelegramBuildInboundMessageFromPhoto(t *testing.T) {
 
 	msg := ch.buildInboundMessage(telegramMessage{
 		MessageID: 101,
+		Date:      1700000000,
 		From: telegramUser{
 			ID:       42,
 			Username: "alice",
@@ -29,12 +36,43 @@ func TestTelegramBuildInboundMessageFromPhoto(t *testing.T) {
 	assert.Equal(t, "[Image]", msg.Text)
 	assert.Equal(t, "alice", msg.Sender)
 	assert.Equal(t, "1001", msg.ChatID)
+	assert.Equal(t, int64(1700000000), msg.Timestamp.Unix())
 	require.NotNil(t, msg.Media)
 	assert.Equal(t, "image", msg.Media.Type)
 	assert.Equal(t, "large", msg.Media.FileID)
 	assert.Equal(t, "image/jpeg", msg.Media.MimeType)
 }
 
+func TestTelegramBuildInboundMessageFromRawPhotoUpdate(t *testing.T) {
+	raw := `{
+		"update_id": 900,
+		"message": {
+			"message_id": 103,
+			"date": 1700000100,
+			"from": {"id": 42, "username": "alice"},
+			"chat": {"id": 1001, "type": "private"},
+			"caption": "what is this?",
+			"photo": [
+				{"file_id": "thumb", "file_unique_id": "a", "width": 90, "height": 60, "file_size": 1200},
+				{"file_id": "full", "file_unique_id": "b", "width": 1280, "height": 853, "file_size": 98765}
+			]
+		}
+	}`
+	var update telegramUpdate
+	require.NoError(t, json.Unmarshal([]byte(raw), &update))
+
+	ch := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true})
+	msg := ch.buildInboundMessage(update.Message)
+
+	require.NotNil(t, msg)
+	assert.Equal(t, "what is this?", msg.Text)
+	assert.Equal(t, "1001", msg.ChatID)
+	require.NotNil(t, msg.Media)
+	assert.Equal(t, "image", msg.Media.Type)
+	assert.Equal(t, "full", msg.Media.FileID)
+	assert.Equal(t, "image/jpeg", msg.Media.MimeType)
+}
+
 func TestTelegramBuildInboundMessageUsesCaptionAndDocumentMime(t *testing.T) {
 	ch := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true})
 
@@ -79,3 +117,38 @@ func TestTelegramBuildInboundMessageDropsEmptyNonMediaMessage(t *testing.T) {
 
 	assert.Nil(t, msg)
 }
+
+func TestTelegramRestoresPersistedOffsetForNextGetUpdates(t *testing.T) {
+	offsetFile := filepath.Join(t.TempDir(), "channels", "telegram_offset")
+	require.NoError(t, os.MkdirAll(filepath.Dir(offsetFile), 0755))
+	require.NoError(t, os.WriteFile(offsetFile, []byte("41"), 0644))
+
+	var gotOffset string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotOffset = r.URL.Query().Get("offset")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":[{"update_id":57,"message":{"message_id":1,"from":{"id":7},"chat":{"id":9},"text":"hi"}}]}`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ch := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true, OffsetFile: offsetFile})
+	ch.httpClient = &http.Client{
+		Transport: &rewriteHostTransport{target: http.DefaultTransport, base: serverURL},
+	}
+	ch.restoreOffset()
+	ch.fetchUpdates()
+
+	assert.Equal(t, "42", gotOffset)
+	assert.Equal(t, int64(57), ch.offset)
+
+	saved, err := os.ReadFile(offsetFile)
+	require.NoError(t, err)
+	assert.Equal(t, "57", string(saved))
+
+	restarted := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true, OffsetFile: offsetFile})
+	restarted.restoreOffset()
+	assert.Equal(t, int64(57), restarted.offset)
+}
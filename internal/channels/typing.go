@@ -0,0 +1,117 @@
+package channels
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+)
+
+const (
+	// DefaultTypingInterval 重发“正在输入”的间隔；Telegram 的 typing 状态约 5 秒后自动消失
+	DefaultTypingInterval = 4 * time.Second
+	// DefaultTypingMaxDuration 单条消息最多显示“正在输入”的时长，防止回复丢失时一直显示
+	DefaultTypingMaxDuration = 2 * time.Minute
+)
+
+// TypingSender 可选接口：支持“正在输入”提示的频道实现它
+type TypingSender interface {
+	SendTyping(chatID string) error
+}
+
+// TypingIndicator 在消息处理期间周期性发送“正在输入”，直到回复发出或超时
+type TypingIndicator struct {
+	interval    time.Duration
+	maxDuration time.Duration
+
+	mu     sync.Mutex
+	active map[string]chan struct{}
+}
+
+// NewTypingIndicator 创建输入提示管理器，interval / maxDuration <= 0 时使用默认值
+func NewTypingIndicator(interval, maxDuration time.Duration) *TypingIndicator {
+	if interval <= 0 {
+		interval = DefaultTypingInterval
+	}
+	if maxDuration <= 0 {
+		maxDuration = DefaultTypingMaxDuration
+	}
+	return &TypingIndicator{
+		interval:    interval,
+		maxDuration: maxDuration,
+		active:      make(map[string]chan struct{}),
+	}
+}
+
+// Start 开始向 chatID 发送输入提示；频道不支持时忽略，同一会话已在提示时不重复启动
+func (t *TypingIndicator) Start(ch Channel, chatID string) {
+	if t == nil {
+		return
+	}
+	sender, ok := ch.(TypingSender)
+	if !ok || chatID == "" {
+		return
+	}
+	key := typingKey(ch.Name(), chatID)
+
+	t.mu.Lock()
+	if _, running := t.active[key]; running {
+		t.mu.Unlock()
+		return
+	}
+	done := make(chan struct{})
+	t.active[key] = done
+	t.mu.Unlock()
+
+	go t.run(sender, ch.Name(), chatID, key, done)
+}
+
+// Stop 停止 chatID 的输入提示（回复发出时调用）
+func (t *TypingIndicator) Stop(channel, chatID string) {
+	if t == nil {
+		return
+	}
+	key := typingKey(channel, chatID)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if done, ok := t.active[key]; ok {
+		close(done)
+		delete(t.active, key)
+	}
+}
+
+func (t *TypingIndicator) run(sender TypingSender, channel, chatID, key string, done chan struct{}) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(t.maxDuration)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if err := sender.SendTyping(chatID); err != nil {
+			if lg := logging.Get(); lg != nil && lg.Channels != nil {
+				lg.Channels.Debug("typing failed", "channel", channel, "chat", chatID, "err", err)
+			}
+		}
+		select {
+		case <-done:
+			return
+		case <-deadline.C:
+			t.mu.Lock()
+			if t.active[key] == done {
+				delete(t.active, key)
+			}
+			t.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func typingKey(channel, chatID string) string {
+	return channel + ":" + chatID
+}
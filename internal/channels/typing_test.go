@@ -0,0 +1,132 @@
+package channels
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTelegramSendTypingCallsSendChatAction(t *testing.T) {
+	var gotPath string
+	var gotForm url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		require.NoError(t, r.ParseForm())
+		gotForm = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ch := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true})
+	ch.httpClient = &http.Client{
+		Transport: &rewriteHostTransport{target: http.DefaultTransport, base: serverURL},
+	}
+
+	require.NoError(t, ch.SendTyping("1001"))
+	assert.Equal(t, "/bottoken/sendChatAction", gotPath)
+	assert.Equal(t, "1001", gotForm.Get("chat_id"))
+	assert.Equal(t, "typing", gotForm.Get("action"))
+}
+
+func TestTelegramSendTypingReportsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"ok":false,"description":"chat not found"}`))
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	ch := NewTelegramChannel(&TelegramConfig{Token: "token", Enabled: true})
+	ch.httpClient = &http.Client{
+		Transport: &rewriteHostTransport{target: http.DefaultTransport, base: serverURL},
+	}
+
+	err = ch.SendTyping("404")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "chat not found")
+}
+
+func TestDiscordSendTypingRequiresSession(t *testing.T) {
+	ch := NewDiscordChannel(&DiscordConfig{Token: "token", Enabled: true})
+	assert.Error(t, ch.SendTyping("123"))
+}
+
+type typingRecorder struct {
+	mu    sync.Mutex
+	chats []string
+}
+
+func (r *typingRecorder) Name() string                                 { return "fake" }
+func (r *typingRecorder) Start(ctx context.Context) error              { return nil }
+func (r *typingRecorder) Stop() error                                  { return nil }
+func (r *typingRecorder) SendMessage(chatID string, text string) error { return nil }
+func (r *typingRecorder) SetMessageHandler(handler func(msg *Message)) {}
+func (r *typingRecorder) IsEnabled() bool                              { return true }
+
+func (r *typingRecorder) SendTyping(chatID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.chats = append(r.chats, chatID)
+	return nil
+}
+
+func (r *typingRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.chats)
+}
+
+func TestTypingIndicatorRepeatsUntilStopped(t *testing.T) {
+	rec := &typingRecorder{}
+	indicator := NewTypingIndicator(10*time.Millisecond, time.Minute)
+
+	indicator.Start(rec, "42")
+	indicator.Start(rec, "42") // 重复启动不会产生第二个循环
+	require.Eventually(t, func() bool { return rec.count() >= 3 }, time.Second, 5*time.Millisecond)
+
+	indicator.Stop("fake", "42")
+	stopped := rec.count()
+	time.Sleep(50 * time.Millisecond)
+	assert.LessOrEqual(t, rec.count(), stopped+1)
+}
+
+func TestTypingIndicatorStopsAtMaxDuration(t *testing.T) {
+	rec := &typingRecorder{}
+	indicator := NewTypingIndicator(5*time.Millisecond, 30*time.Millisecond)
+
+	indicator.Start(rec, "42")
+	require.Eventually(t, func() bool {
+		indicator.mu.Lock()
+		defer indicator.mu.Unlock()
+		return len(indicator.active) == 0
+	}, time.Second, 5*time.Millisecond)
+
+	// 超时退出后可以为下一条消息重新启动
+	before := rec.count()
+	indicator.Start(rec, "42")
+	require.Eventually(t, func() bool { return rec.count() > before }, time.Second, 5*time.Millisecond)
+	indicator.Stop("fake", "42")
+}
+
+func TestTypingIndicatorIgnoresChannelsWithoutTyping(t *testing.T) {
+	indicator := NewTypingIndicator(0, 0)
+	indicator.Start(NewWhatsAppChannel(&WhatsAppConfig{}), "42")
+	assert.Empty(t, indicator.active)
+
+	var nilIndicator *TypingIndicator
+	nilIndicator.Start(&typingRecorder{}, "42")
+	nilIndicator.Stop("fake", "42")
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -24,6 +25,31 @@ type WebSocketConfig struct {
 	Port         int      `json:"port"`
 	Path         string   `json:"path"`
 	AllowOrigins []string `json:"allowOrigins"`
+	// PingIntervalSeconds ping 间隔，<=0 使用默认值
+	PingIntervalSeconds int `json:"pingIntervalSeconds"`
+}
+
+const (
+	// DefaultWebSocketPingInterval 默认 ping 间隔
+	DefaultWebSocketPingInterval = 30 * time.Second
+	// wsWriteWait 单次写入（消息或 ping）的超时
+	wsWriteWait = 10 * time.Second
+	// BroadcastChatID 保留的 chatID，发往该 ID 的消息会推送给所有已连接的客户端
+	BroadcastChatID = "*"
+)
+
+// wsClient 单个客户端连接；gorilla/websocket 同一连接同时只允许一个写入方
+type wsClient struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+}
+
+// write 带写超时发送一条文本消息
+func (c *wsClient) write(data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return c.conn.WriteMessage(websocket.TextMessage, data)
 }
 
 // WebSocketChannel WebSocket 频道
@@ -38,8 +64,11 @@ type WebSocketChannel struct {
 	enabled        bool
 
 	mu       sync.RWMutex
-	clients  map[string]*websocket.Conn
+	clients  map[string]*wsClient
 	upgrader websocket.Upgrader
+
+	// pingInterval 发送 ping 的间隔；超过两个间隔未收到任何帧（含 pong）即视为连接已失效
+	pingInterval time.Duration
 }
 
 // NewWebSocketChannel 创建 WebSocket 频道
@@ -56,10 +85,14 @@ func NewWebSocketChannel(config *WebSocketConfig) *WebSocketChannel {
 	}
 
 	ch := &WebSocketChannel{
-		config:   &cfg,
-		stopChan: make(chan struct{}),
-		enabled:  cfg.Enabled,
-		clients:  make(map[string]*websocket.Conn),
+		config:       &cfg,
+		stopChan:     make(chan struct{}),
+		enabled:      cfg.Enabled,
+		clients:      make(map[string]*wsClient),
+		pingInterval: DefaultWebSocketPingInterval,
+	}
+	if cfg.PingIntervalSeconds > 0 {
+		ch.pingInterval = time.Duration(cfg.PingIntervalSeconds) * time.Second
 	}
 
 	ch.upgrader = websocket.Upgrader{
@@ -149,7 +182,7 @@ func (w *WebSocketChannel) Stop() error {
 	return nil
 }
 
-// SendMessage 发送消息
+// SendMessage 发送消息；chatID 为 BroadcastChatID 时推送给所有客户端
 func (w *WebSocketChannel) SendMessage(chatID string, text string) error {
 	if !w.enabled {
 		return fmt.Errorf("websocket channel not enabled")
@@ -157,14 +190,57 @@ func (w *WebSocketChannel) SendMessage(chatID string, text string) error {
 	if chatID == "" {
 		return fmt.Errorf("chatID is required")
 	}
+	if chatID == BroadcastChatID {
+		return w.Broadcast(text)
+	}
 
 	w.mu.RLock()
-	conn := w.clients[chatID]
+	client := w.clients[chatID]
 	w.mu.RUnlock()
-	if conn == nil {
+	if client == nil {
 		return fmt.Errorf("client not connected: %s", chatID)
 	}
+	if err := w.sendTo(chatID, client, text); err != nil {
+		return err
+	}
+	if lg := logging.Get(); lg != nil && lg.Channels != nil {
+		lg.Channels.Printf("websocket send chat=%s text=%q", chatID, logging.Truncate(text, 300))
+	}
+	return nil
+}
+
+// Broadcast 把消息推送给所有已连接的客户端；个别客户端发送失败不影响其他客户端，
+// 失败的连接会被移除，返回的错误汇总所有失败
+func (w *WebSocketChannel) Broadcast(text string) error {
+	if !w.enabled {
+		return fmt.Errorf("websocket channel not enabled")
+	}
+
+	// 读锁下取快照，逐个写入时不阻塞新连接的注册
+	w.mu.RLock()
+	targets := make(map[string]*wsClient, len(w.clients))
+	for chatID, client := range w.clients {
+		targets[chatID] = client
+	}
+	w.mu.RUnlock()
+
+	var errs []error
+	for chatID, client := range targets {
+		if err := w.sendTo(chatID, client, text); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", chatID, err))
+		}
+	}
+	if lg := logging.Get(); lg != nil && lg.Channels != nil {
+		lg.Channels.Printf("websocket broadcast clients=%d failed=%d text=%q", len(targets), len(errs), logging.Truncate(text, 300))
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("broadcast failed for %d of %d clients: %w", len(errs), len(targets), errors.Join(errs...))
+	}
+	return nil
+}
 
+// sendTo 向单个客户端发送 assistant 消息；写失败的连接不可再用，关闭后移除
+func (w *WebSocketChannel) sendTo(chatID string, client *wsClient, text string) error {
 	payload := map[string]interface{}{
 		"type":    "message",
 		"chatId":  chatID,
@@ -176,20 +252,14 @@ func (w *WebSocketChannel) SendMessage(chatID string, text string) error {
 		return err
 	}
 
-	w.mu.Lock()
-	defer w.mu.Unlock()
-	if conn == nil {
-		return fmt.Errorf("client not connected: %s", chatID)
-	}
-	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+	if err := client.write(data); err != nil {
 		if lg := logging.Get(); lg != nil && lg.Channels != nil {
 			lg.Channels.Printf("websocket send error chat=%s err=%v", chatID, err)
 		}
+		w.removeClient(chatID, client)
+		_ = client.conn.Close()
 		return err
 	}
-	if lg := logging.Get(); lg != nil && lg.Channels != nil {
-		lg.Channels.Printf("websocket send chat=%s text=%q", chatID, logging.Truncate(text, 300))
-	}
 	return nil
 }
 
@@ -203,25 +273,65 @@ func (w *WebSocketChannel) handleWebSocket(rw http.ResponseWriter, r *http.Reque
 	if chatID == "" {
 		chatID = r.URL.Query().Get("clientId")
 	}
-	if chatID == "" {
+	// 保留的广播 ID 不能被客户端占用，否则发给它的回复会推送给所有客户端
+	if chatID == "" || chatID == BroadcastChatID {
 		chatID = "ws-" + randomID(8)
 	}
 
-	w.addClient(chatID, conn)
-	_ = w.sendHello(conn, chatID)
+	// 任何入站帧（含 pong）都会延长读超时；客户端失联时 ReadMessage 超时返回，连接随之被清理
+	pongWait := 2 * w.pingInterval
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	client := &wsClient{conn: conn}
+	w.addClient(chatID, client)
+	_ = w.sendHello(client, chatID)
+
+	done := make(chan struct{})
+	go w.keepAlive(conn, chatID, done)
 
 	for {
 		_, data, err := conn.ReadMessage()
 		if err != nil {
+			if lg := logging.Get(); lg != nil && lg.Channels != nil {
+				lg.Channels.Debug("websocket client disconnected", "chat", chatID, "error", err)
+			}
 			break
 		}
+		_ = conn.SetReadDeadline(time.Now().Add(pongWait))
 		w.handleInbound(chatID, data)
 	}
 
-	w.removeClient(chatID)
+	close(done)
+	w.removeClient(chatID, client)
 	_ = conn.Close()
 }
 
+// keepAlive 定期发送 ping；发送失败时关闭连接，让读循环退出
+func (w *WebSocketChannel) keepAlive(conn *websocket.Conn, chatID string, done <-chan struct{}) {
+	ticker := time.NewTicker(w.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-w.stopChan:
+			return
+		case <-ticker.C:
+			// WriteControl 可与其他写操作并发调用
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+				if lg := logging.Get(); lg != nil && lg.Channels != nil {
+					lg.Channels.Debug("websocket ping failed", "chat", chatID, "error", err)
+				}
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
 func (w *WebSocketChannel) handleInbound(defaultChatID string, data []byte) {
 	var msg wsInboundMessage
 	if err := json.Unmarshal(data, &msg); err != nil {
@@ -237,7 +347,7 @@ func (w *WebSocketChannel) handleInbound(defaultChatID string, data []byte) {
 	}
 
 	chatID := msg.ChatID
-	if chatID == "" {
+	if chatID == "" || chatID == BroadcastChatID {
 		chatID = defaultChatID
 	}
 
@@ -261,7 +371,7 @@ func (w *WebSocketChannel) handleInbound(defaultChatID string, data []byte) {
 	}
 }
 
-func (w *WebSocketChannel) sendHello(conn *websocket.Conn, chatID string) error {
+func (w *WebSocketChannel) sendHello(client *wsClient, chatID string) error {
 	payload := map[string]interface{}{
 		"type":   "hello",
 		"chatId": chatID,
@@ -270,29 +380,32 @@ func (w *WebSocketChannel) sendHello(conn *websocket.Conn, chatID string) error
 	if err != nil {
 		return err
 	}
-	return conn.WriteMessage(websocket.TextMessage, data)
+	return client.write(data)
 }
 
-func (w *WebSocketChannel) addClient(chatID string, conn *websocket.Conn) {
+func (w *WebSocketChannel) addClient(chatID string, client *wsClient) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	if old := w.clients[chatID]; old != nil {
-		_ = old.Close()
+		_ = old.conn.Close()
 	}
-	w.clients[chatID] = conn
+	w.clients[chatID] = client
 }
 
-func (w *WebSocketChannel) removeClient(chatID string) {
+// removeClient 仅在 chatID 仍指向该连接时移除，避免误删同一 chatID 的新连接
+func (w *WebSocketChannel) removeClient(chatID string, client *wsClient) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	delete(w.clients, chatID)
+	if w.clients[chatID] == client {
+		delete(w.clients, chatID)
+	}
 }
 
 func (w *WebSocketChannel) closeAll() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	for id, conn := range w.clients {
-		_ = conn.Close()
+	for id, client := range w.clients {
+		_ = client.conn.Close()
 		delete(w.clients, id)
 	}
 }
@@ -0,0 +1,210 @@
+package channels
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWebSocketChannel(t *testing.T, pingInterval time.Duration) (*WebSocketChannel, string) {
+	t.Helper()
+	ch := NewWebSocketChannel(&WebSocketConfig{Enabled: true})
+	ch.pingInterval = pingInterval
+	server := httptest.NewServer(http.HandlerFunc(ch.handleWebSocket))
+	t.Cleanup(func() {
+		ch.closeAll()
+		server.Close()
+	})
+	return ch, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func (w *WebSocketChannel) hasClient(chatID string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.clients[chatID] != nil
+}
+
+func TestWebSocketPingIntervalFromConfig(t *testing.T) {
+	assert.Equal(t, DefaultWebSocketPingInterval, NewWebSocketChannel(&WebSocketConfig{}).pingInterval)
+	assert.Equal(t, 5*time.Second, NewWebSocketChannel(&WebSocketConfig{PingIntervalSeconds: 5}).pingInterval)
+}
+
+func TestWebSocketPrunesStaleConnection(t *testing.T) {
+	ch, url := newTestWebSocketChannel(t, 20*time.Millisecond)
+
+	// 只连接不读取：客户端不会回复 pong，模拟已失联的连接
+	stale, _, err := websocket.DefaultDialer.Dial(url+"?chatId=stale", nil)
+	require.NoError(t, err)
+	defer stale.Close()
+
+	require.Eventually(t, func() bool { return ch.hasClient("stale") }, time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool { return !ch.hasClient("stale") }, 2*time.Second, 10*time.Millisecond)
+
+	err = ch.SendMessage("stale", "hello?")
+	assert.ErrorContains(t, err, "client not connected")
+}
+
+func TestWebSocketKeepsResponsiveConnection(t *testing.T) {
+	ch, url := newTestWebSocketChannel(t, 20*time.Millisecond)
+
+	live, _, err := websocket.DefaultDialer.Dial(url+"?chatId=live", nil)
+	require.NoError(t, err)
+	defer live.Close()
+
+	var pings int
+	live.SetPingHandler(func(data string) error {
+		pings++
+		return live.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	// 持续读取以处理 ping 帧
+	messages := make(chan string, 8)
+	go func() {
+		for {
+			_, data, err := live.ReadMessage()
+			if err != nil {
+				close(messages)
+				return
+			}
+			messages <- string(data)
+		}
+	}()
+
+	assert.Contains(t, <-messages, `"hello"`)
+	time.Sleep(150 * time.Millisecond)
+	require.True(t, ch.hasClient("live"), "responsive client should stay connected")
+
+	require.NoError(t, ch.SendMessage("live", "still here"))
+	select {
+	case msg := <-messages:
+		assert.Contains(t, msg, "still here")
+	case <-time.After(time.Second):
+		t.Fatal("message not delivered")
+	}
+	assert.Greater(t, pings, 0)
+}
+
+func TestWebSocketReconnectKeepsNewConnection(t *testing.T) {
+	ch, url := newTestWebSocketChannel(t, time.Minute)
+
+	first, _, err := websocket.DefaultDialer.Dial(url+"?chatId=same", nil)
+	require.NoError(t, err)
+	defer first.Close()
+	require.Eventually(t, func() bool { return ch.hasClient("same") }, time.Second, 5*time.Millisecond)
+
+	second, _, err := websocket.DefaultDialer.Dial(url+"?chatId=same", nil)
+	require.NoError(t, err)
+	defer second.Close()
+	_, hello, err := second.ReadMessage()
+	require.NoError(t, err)
+	require.Contains(t, string(hello), `"hello"`)
+
+	// 旧连接被替换关闭后，其读循环退出时不能把新连接移除
+	time.Sleep(50 * time.Millisecond)
+	require.True(t, ch.hasClient("same"))
+	require.NoError(t, ch.SendMessage("same", "to the new one"))
+	_, data, err := second.ReadMessage()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "to the new one")
+}
+
+// closedWebSocketConn 返回一个已关闭的服务端连接，写入必然失败
+func closedWebSocketConn(t *testing.T) *websocket.Conn {
+	t.Helper()
+	conns := make(chan *websocket.Conn, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(rw, r, nil)
+		if err != nil {
+			return
+		}
+		conns <- conn
+	}))
+	t.Cleanup(server.Close)
+
+	peer, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = peer.Close() })
+
+	conn := <-conns
+	require.NoError(t, conn.Close())
+	return conn
+}
+
+func TestWebSocketBroadcastReachesAllClients(t *testing.T) {
+	ch, url := newTestWebSocketChannel(t, time.Minute)
+
+	var clients []*websocket.Conn
+	for _, id := range []string{"a", "b", "c"} {
+		conn, _, err := websocket.DefaultDialer.Dial(url+"?chatId="+id, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, hello, err := conn.ReadMessage()
+		require.NoError(t, err)
+		require.Contains(t, string(hello), `"hello"`)
+		clients = append(clients, conn)
+	}
+	// 一个写入必然失败的连接，不应影响其他客户端
+	ch.addClient("broken", &wsClient{conn: closedWebSocketConn(t)})
+
+	err := ch.SendMessage(BroadcastChatID, "maintenance at noon")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "broadcast failed for 1 of 4 clients")
+	assert.Contains(t, err.Error(), "broken")
+	assert.False(t, ch.hasClient("broken"), "failed client is pruned")
+
+	for i, id := range []string{"a", "b", "c"} {
+		_ = clients[i].SetReadDeadline(time.Now().Add(time.Second))
+		_, data, err := clients[i].ReadMessage()
+		require.NoError(t, err, "client %s", id)
+		assert.Contains(t, string(data), "maintenance at noon")
+		assert.Contains(t, string(data), `"chatId":"`+id+`"`)
+	}
+
+	// 失败的连接移除后，再次广播全部成功
+	require.NoError(t, ch.Broadcast("all clear"))
+	for _, conn := range clients {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "all clear")
+	}
+}
+
+func TestWebSocketBroadcastWithoutClients(t *testing.T) {
+	ch, _ := newTestWebSocketChannel(t, time.Minute)
+	assert.NoError(t, ch.Broadcast("nobody listening"))
+
+	disabled := NewWebSocketChannel(&WebSocketConfig{})
+	assert.Error(t, disabled.Broadcast("hi"))
+}
+
+func TestWebSocketClientCannotClaimBroadcastChatID(t *testing.T) {
+	ch, url := newTestWebSocketChannel(t, time.Minute)
+	inbound := make(chan *Message, 1)
+	ch.SetMessageHandler(func(msg *Message) { inbound <- msg })
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?chatId=*", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, hello, err := conn.ReadMessage()
+	require.NoError(t, err)
+	assert.NotContains(t, string(hello), `"chatId":"*"`)
+	assert.False(t, ch.hasClient(BroadcastChatID))
+
+	// 入站帧中的保留 ID 同样被替换为连接自己的 ID
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"message","chatId":"*","content":"hi"}`)))
+	select {
+	case msg := <-inbound:
+		assert.NotEqual(t, BroadcastChatID, msg.ChatID)
+		assert.True(t, strings.HasPrefix(msg.ChatID, "ws-"))
+		assert.True(t, ch.hasClient(msg.ChatID))
+	case <-time.After(2 * time.Second):
+		t.Fatal("inbound message not delivered")
+	}
+}
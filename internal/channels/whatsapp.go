@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"strings"
 	"sync"
 	"time"
@@ -41,6 +42,65 @@ type WhatsAppChannel struct {
 
 	outboundMu sync.Mutex
 	outbound   []outboundRecord
+
+	backoff *reconnectBackoff
+}
+
+// 桥接重连的退避参数：首次等待基础间隔，之后逐次翻倍直至上限
+const (
+	whatsappReconnectBase = time.Second
+	whatsappReconnectMax  = 60 * time.Second
+)
+
+// reconnectBackoff 指数退避 + 抖动；连接成功后 reset 回到基础间隔
+type reconnectBackoff struct {
+	base    time.Duration
+	max     time.Duration
+	current time.Duration
+	// jitter 由当前间隔得到实际等待时长，测试中可替换为确定值
+	jitter func(d time.Duration) time.Duration
+}
+
+func newReconnectBackoff(base, max time.Duration) *reconnectBackoff {
+	if base <= 0 {
+		base = time.Second
+	}
+	if max < base {
+		max = base
+	}
+	return &reconnectBackoff{
+		base:    base,
+		max:     max,
+		current: base,
+		jitter:  equalJitter,
+	}
+}
+
+// next 返回本次应等待的时长，并将下一次的间隔翻倍（不超过上限）
+func (b *reconnectBackoff) next() time.Duration {
+	d := b.current
+	b.current *= 2
+	if b.current > b.max {
+		b.current = b.max
+	}
+	if b.jitter != nil {
+		d = b.jitter(d)
+	}
+	return d
+}
+
+// reset 回到基础间隔
+func (b *reconnectBackoff) reset() {
+	b.current = b.base
+}
+
+// equalJitter 在 [d/2, d] 内随机取值，避免多个实例在桥接恢复时同时重连
+func equalJitter(d time.Duration) time.Duration {
+	half := d / 2
+	if half <= 0 {
+		return d
+	}
+	return half + time.Duration(rand.Int63n(int64(half)+1))
 }
 
 // NewWhatsAppChannel 创建 WhatsApp 频道
@@ -50,6 +110,7 @@ func NewWhatsAppChannel(config *WhatsAppConfig) *WhatsAppChannel {
 		config:   config,
 		stopChan: make(chan struct{}),
 		enabled:  enabled,
+		backoff:  newReconnectBackoff(whatsappReconnectBase, whatsappReconnectMax),
 	}
 }
 
@@ -151,7 +212,7 @@ func (w *WhatsAppChannel) connectLoop(ctx context.Context) {
 		conn, _, err := websocket.DefaultDialer.DialContext(ctx, w.config.BridgeURL, nil)
 		if err != nil {
 			w.setConnected(false, nil)
-			if !w.waitRetry(ctx, 5*time.Second) {
+			if !w.waitRetry(ctx, w.backoff.next()) {
 				return
 			}
 			continue
@@ -163,17 +224,18 @@ func (w *WhatsAppChannel) connectLoop(ctx context.Context) {
 			if lg := logging.Get(); lg != nil && lg.Channels != nil {
 				lg.Channels.Printf("whatsapp auth handshake failed: %v", err)
 			}
-			if !w.waitRetry(ctx, 5*time.Second) {
+			if !w.waitRetry(ctx, w.backoff.next()) {
 				return
 			}
 			continue
 		}
 
 		w.setConnected(true, conn)
+		w.backoff.reset()
 		w.readLoop(ctx, conn)
 		w.setConnected(false, nil)
 
-		if !w.waitRetry(ctx, 5*time.Second) {
+		if !w.waitRetry(ctx, w.backoff.next()) {
 			return
 		}
 	}
@@ -242,12 +304,13 @@ func (w *WhatsAppChannel) handleBridgeMessage(data []byte) {
 
 		if w.messageHandler != nil {
 			w.messageHandler(&Message{
-				ID:      msg.ID,
-				Text:    msg.Content,
-				Sender:  senderID,
-				ChatID:  chatID,
-				Channel: "whatsapp",
-				Raw:     msg,
+				ID:        msg.ID,
+				Text:      msg.Content,
+				Sender:    senderID,
+				ChatID:    chatID,
+				Channel:   "whatsapp",
+				Timestamp: unixSecondsTime(msg.Timestamp),
+				Raw:       msg,
 			})
 		}
 		if lg := logging.Get(); lg != nil && lg.Channels != nil {
@@ -341,6 +404,11 @@ func (w *WhatsAppChannel) Status() WhatsAppStatus {
 	}
 }
 
+// ChannelStatus 实现 StatusProvider
+func (w *WhatsAppChannel) ChannelStatus() interface{} {
+	return w.Status()
+}
+
 func (w *WhatsAppChannel) closeConn() {
 	w.mu.Lock()
 	defer w.mu.Unlock()
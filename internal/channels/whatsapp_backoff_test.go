@@ -0,0 +1,70 @@
+package channels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectBackoffProgressionAndReset(t *testing.T) {
+	b := newReconnectBackoff(time.Second, 10*time.Second)
+	b.jitter = func(d time.Duration) time.Duration { return d }
+
+	var got []time.Duration
+	for i := 0; i < 6; i++ {
+		got = append(got, b.next())
+	}
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second,
+	}, got)
+
+	b.reset()
+	assert.Equal(t, time.Second, b.next())
+	assert.Equal(t, 2*time.Second, b.next())
+}
+
+func TestReconnectBackoffNormalizesBounds(t *testing.T) {
+	b := newReconnectBackoff(0, 0)
+	b.jitter = nil
+	assert.Equal(t, time.Second, b.next())
+	assert.Equal(t, time.Second, b.next())
+}
+
+func TestEqualJitterStaysWithinRange(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := equalJitter(8 * time.Second)
+		assert.GreaterOrEqual(t, d, 4*time.Second)
+		assert.LessOrEqual(t, d, 8*time.Second)
+	}
+	assert.Equal(t, time.Duration(1), equalJitter(1))
+}
+
+func TestWhatsAppConnectLoopBacksOffUntilStopped(t *testing.T) {
+	ch := NewWhatsAppChannel(&WhatsAppConfig{Enabled: true, BridgeURL: "ws://127.0.0.1:1/ws"})
+	waits := make(chan time.Duration, 16)
+	ch.backoff = newReconnectBackoff(10*time.Millisecond, 40*time.Millisecond)
+	ch.backoff.jitter = func(d time.Duration) time.Duration {
+		waits <- d
+		return d
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.NoError(t, ch.Start(ctx))
+
+	var got []time.Duration
+	for len(got) < 4 {
+		select {
+		case d := <-waits:
+			got = append(got, d)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("connect loop did not retry, got %v", got)
+		}
+	}
+	assert.NoError(t, ch.Stop())
+	assert.Equal(t, []time.Duration{
+		10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond,
+	}, got)
+}
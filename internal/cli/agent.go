@@ -11,13 +11,14 @@ import (
 	"regexp"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/Lichas/maxclaw/internal/agent"
 	"github.com/Lichas/maxclaw/internal/bus"
 	"github.com/Lichas/maxclaw/internal/config"
 	"github.com/Lichas/maxclaw/internal/cron"
-	"github.com/Lichas/maxclaw/internal/logging"
 	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
 	"github.com/peterh/liner"
 	"github.com/spf13/cobra"
 )
@@ -29,6 +30,7 @@ var (
 	logsFlag       bool
 	noMarkdownFlag bool
 	noLogsFlag     bool
+	profileFlag    string
 )
 
 func normalizeInteractiveInput(input string) string {
@@ -78,6 +80,88 @@ func init() {
 	agentCmd.Flags().BoolVar(&logsFlag, "logs", false, "Show runtime log file paths")
 	agentCmd.Flags().BoolVar(&noMarkdownFlag, "no-markdown", false, "Disable markdown rendering")
 	agentCmd.Flags().BoolVar(&noLogsFlag, "no-logs", false, "Hide runtime log file paths")
+	agentCmd.Flags().StringVar(&profileFlag, "profile", "", "Agent profile from agents.profiles to overlay onto agents.defaults")
+}
+
+// loadConfigWithProfile 加载配置并叠加 --profile 指定的配置档
+func loadConfigWithProfile(profile string) (*config.Config, error) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := cfg.ApplyProfile(profile); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// newLocalAgentLoop 为本地一次性命令（agent / chat）创建 AgentLoop；Cron 服务只创建不启动
+func newLocalAgentLoop(cfg *config.Config, messageBus *bus.MessageBus) (*agent.AgentLoop, error) {
+	// 检查模型对应的提供商已配置 API key / base
+	if err := cfg.CheckModelProvider(""); err != nil {
+		return nil, err
+	}
+	apiKey := cfg.GetAPIKey("")
+	apiBase := cfg.GetAPIBase("")
+
+	// 创建 Provider
+	provider, err := providers.NewProvider(
+		apiKey,
+		apiBase,
+		cfg.GetAPIFormat(""),
+		cfg.ResolveModel(""),
+		cfg.Agents.Defaults.MaxTokens,
+		cfg.Agents.Defaults.Temperature,
+		cfg.SupportsImageInput,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create provider: %w", err)
+	}
+	provider = withFallbackModels(cfg, provider)
+
+	// 创建 Cron 服务（agent 模式下也需要，但不启动）
+	storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
+	cronService := cron.NewService(storePath)
+
+	agentLoop := agent.NewAgentLoop(
+		messageBus,
+		provider,
+		cfg.Agents.Defaults.Workspace,
+		cfg.ResolveModel(""),
+		cfg.Agents.Defaults.MaxToolIterations,
+		cfg.Tools.Web.Search,
+		agent.BuildWebFetchOptions(cfg),
+		cfg.Tools.Exec,
+		cfg.Tools.RestrictToWorkspace,
+		cronService,
+		cfg.Tools.MCPServers,
+		cfg.Agents.Defaults.EnableGlobalSkills,
+	)
+	applyAgentLoopDefaults(agentLoop, cfg)
+	providers.SetMaxConcurrentRequests(cfg.Agents.Defaults.MaxConcurrentRequests)
+	agentLoop.UpdateRuntimeExecutionMode(cfg.Agents.Defaults.ExecutionMode)
+	registerOptionalTools(agentLoop, cfg)
+	return agentLoop, nil
+}
+
+// applyAgentLoopDefaults 把 agents.defaults 中的运行参数（并发、超时、告警阈值、会话格式）、文件写入限制与频道模型覆盖应用到 AgentLoop
+func applyAgentLoopDefaults(agentLoop *agent.AgentLoop, cfg *config.Config) {
+	defaults := cfg.Agents.Defaults
+	agentLoop.UpdateRuntimeLimits(agent.RuntimeLimits{
+		MaxParallelTools:       defaults.MaxParallelTools,
+		ToolTimeout:            time.Duration(defaults.ToolTimeoutSeconds) * time.Second,
+		TurnTimeout:            time.Duration(defaults.TurnTimeoutSeconds) * time.Second,
+		ToolCallWarnThreshold:  defaults.ToolCallWarnThreshold,
+		RepeatedToolCallWindow: defaults.RepeatedToolCallWindow,
+	})
+	agentLoop.SetSessionFormat(defaults.SessionFormat)
+	agentLoop.SetFileExtensionPolicy(tools.ExtensionPolicy{
+		Allowed: cfg.Tools.Files.AllowedExtensions,
+		Denied:  cfg.Tools.Files.DeniedExtensions,
+	})
+	agentLoop.SetToolResultLimits(cfg.Tools.ResultLimits)
+	agentLoop.SetChannelModels(channelModelOverrides(cfg))
+	agentLoop.SetErrorMessages(cfg.Channels.ErrorMessages)
 }
 
 // agentCmd Agent 命令
@@ -92,61 +176,24 @@ var agentCmd = &cobra.Command{
 			logsFlag = false
 		}
 
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfigWithProfile(profileFlag)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
 		}
 
-		if _, err := logging.Init(config.GetDataDir()); err != nil {
-			fmt.Printf("⚠ logging init error: %v\n", err)
-		}
+		initLogging()
 		if logsFlag {
 			fmt.Printf("Logs: %s\n", config.GetLogsDir())
 		}
 
-		// 检查 API key
-		apiKey := cfg.GetAPIKey("")
-		apiBase := cfg.GetAPIBase("")
-		if apiKey == "" {
-			return fmt.Errorf("no API key configured. Set one in ~/.maxclaw/config.json")
+		if err := autoInitWorkspace(cfg); err != nil {
+			return err
 		}
 
-		// 创建 Provider
-		provider, err := providers.NewProvider(
-			apiKey,
-			apiBase,
-			cfg.GetAPIFormat(cfg.Agents.Defaults.Model),
-			cfg.Agents.Defaults.Model,
-			cfg.Agents.Defaults.MaxTokens,
-			cfg.Agents.Defaults.Temperature,
-			cfg.SupportsImageInput,
-		)
+		agentLoop, err := newLocalAgentLoop(cfg, bus.NewMessageBus(100))
 		if err != nil {
-			return fmt.Errorf("failed to create provider: %w", err)
+			return err
 		}
-
-		// 创建组件
-		messageBus := bus.NewMessageBus(100)
-
-		// 创建 Cron 服务（agent 模式下也需要，但不启动）
-		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
-		cronService := cron.NewService(storePath)
-
-		agentLoop := agent.NewAgentLoop(
-			messageBus,
-			provider,
-			cfg.Agents.Defaults.Workspace,
-			cfg.Agents.Defaults.Model,
-			cfg.Agents.Defaults.MaxToolIterations,
-			cfg.Tools.Web.Search.APIKey,
-			agent.BuildWebFetchOptions(cfg),
-			cfg.Tools.Exec,
-			cfg.Tools.RestrictToWorkspace,
-			cronService,
-			cfg.Tools.MCPServers,
-			cfg.Agents.Defaults.EnableGlobalSkills,
-		)
-		agentLoop.UpdateRuntimeExecutionMode(cfg.Agents.Defaults.ExecutionMode)
 		defer agentLoop.Close()
 
 		if messageFlag != "" {
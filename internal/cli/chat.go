@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Lichas/maxclaw/internal/agent"
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	chatChannelFlag string
+	chatSenderFlag  string
+	chatIDFlag      string
+	chatMessageFlag string
+	chatSessionFlag string
+)
+
+func init() {
+	chatCmd.Flags().StringVar(&chatChannelFlag, "channel", "telegram", "Channel to simulate (telegram, discord, slack, ...)")
+	chatCmd.Flags().StringVar(&chatSenderFlag, "sender", "user", "Simulated sender ID")
+	chatCmd.Flags().StringVar(&chatIDFlag, "chat", "direct", "Simulated chat ID")
+	chatCmd.Flags().StringVarP(&chatMessageFlag, "message", "m", "", "Message to send (required)")
+	chatCmd.Flags().StringVarP(&chatSessionFlag, "session", "s", "", "Session ID (default <channel>:<chat>)")
+	_ = chatCmd.MarkFlagRequired("message")
+}
+
+// chatCmd 模拟频道消息的一次性对话命令
+var chatCmd = &cobra.Command{
+	Use:   "chat",
+	Short: "Run one agent turn as if the message arrived on a channel",
+	Long: "Simulate an inbound channel message locally: the turn runs with the given channel/chat context " +
+		"and every message that would be sent back to the platform is printed instead.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		initLogging()
+		if err := autoInitWorkspace(cfg); err != nil {
+			return err
+		}
+
+		messageBus := bus.NewMessageBus(100)
+		agentLoop, err := newLocalAgentLoop(cfg, messageBus)
+		if err != nil {
+			return err
+		}
+		defer agentLoop.Close()
+
+		msg := bus.NewInboundMessage(chatChannelFlag, chatSenderFlag, chatIDFlag, chatMessageFlag)
+		if chatSessionFlag != "" {
+			msg.SessionKey = chatSessionFlag
+		}
+
+		fmt.Printf("%s Simulating %s message from %s in chat %s\n", logo, msg.Channel, msg.SenderID, msg.ChatID)
+		outbound, err := simulateChannelTurn(context.Background(), agentLoop, messageBus, msg)
+		if err != nil {
+			return err
+		}
+		printSimulatedOutbound(cmd.OutOrStdout(), outbound)
+		return nil
+	},
+}
+
+// simulateChannelTurn 以指定频道上下文处理一条入站消息，返回本轮会发往平台的全部消息
+// （工具经消息总线发送的消息在前，最终回复在后）
+func simulateChannelTurn(ctx context.Context, agentLoop *agent.AgentLoop, messageBus *bus.MessageBus, msg *bus.InboundMessage) ([]*bus.OutboundMessage, error) {
+	resp, err := agentLoop.ProcessMessage(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var outbound []*bus.OutboundMessage
+	for {
+		out, ok := messageBus.TryConsumeOutbound()
+		if !ok {
+			break
+		}
+		outbound = append(outbound, out)
+	}
+	if resp != nil && strings.TrimSpace(resp.Content) != "" {
+		outbound = append(outbound, resp)
+	}
+	return outbound, nil
+}
+
+func printSimulatedOutbound(w io.Writer, outbound []*bus.OutboundMessage) {
+	if len(outbound) == 0 {
+		fmt.Fprintln(w, "(nothing would be sent)")
+		return
+	}
+	for _, out := range outbound {
+		fmt.Fprintf(w, "\n→ %s:%s\n%s\n", out.Channel, out.ChatID, out.Content)
+		if out.Media != nil {
+			fmt.Fprintf(w, "  [media: %s %s]\n", out.Media.Type, firstNonEmpty(out.Media.LocalPath, out.Media.URL))
+		}
+	}
+}
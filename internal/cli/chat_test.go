@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/agent"
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/providers"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// channelEchoProvider 记录系统提示词，首轮调用 message 工具，拿到工具结果后结束
+type channelEchoProvider struct {
+	systemPrompt string
+}
+
+func (p *channelEchoProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return &providers.Response{Content: "ok"}, nil
+}
+
+func (p *channelEchoProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	if len(messages) > 0 && messages[0].Role == "system" {
+		p.systemPrompt = messages[0].Content
+	}
+	if messages[len(messages)-1].Role == "tool" {
+		handler.OnContent("done")
+		handler.OnComplete()
+		return nil
+	}
+	handler.OnToolCallStart("call_1", "message")
+	handler.OnToolCallDelta("call_1", `{"content":"working on it"}`)
+	handler.OnToolCallEnd("call_1")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *channelEchoProvider) GetDefaultModel() string              { return "test-model" }
+func (p *channelEchoProvider) SupportsImageInput(model string) bool { return false }
+
+func TestSimulateChannelTurnUsesChannelContext(t *testing.T) {
+	messageBus := bus.NewMessageBus(10)
+	provider := &channelEchoProvider{}
+	loop := agent.NewAgentLoop(
+		messageBus,
+		provider,
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	defer loop.Close()
+
+	msg := bus.NewInboundMessage("telegram", "bob", "123", "hello")
+	outbound, err := simulateChannelTurn(context.Background(), loop, messageBus, msg)
+	require.NoError(t, err)
+
+	assert.Contains(t, provider.systemPrompt, "telegram")
+	assert.Contains(t, provider.systemPrompt, "123")
+
+	require.Len(t, outbound, 2)
+	assert.Equal(t, "telegram", outbound[0].Channel)
+	assert.Equal(t, "123", outbound[0].ChatID)
+	assert.Equal(t, "working on it", outbound[0].Content)
+	assert.Equal(t, "telegram", outbound[1].Channel)
+	assert.Equal(t, "123", outbound[1].ChatID)
+	assert.Equal(t, "done", outbound[1].Content)
+
+	var out bytes.Buffer
+	printSimulatedOutbound(&out, outbound)
+	assert.Contains(t, out.String(), "→ telegram:123\nworking on it")
+}
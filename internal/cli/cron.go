@@ -14,7 +14,6 @@ import (
 	"github.com/Lichas/maxclaw/internal/bus"
 	"github.com/Lichas/maxclaw/internal/config"
 	"github.com/Lichas/maxclaw/internal/cron"
-	"github.com/Lichas/maxclaw/internal/logging"
 	"github.com/Lichas/maxclaw/internal/providers"
 	"github.com/spf13/cobra"
 )
@@ -71,37 +70,21 @@ var cronAddCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
-		if _, err := logging.Init(config.GetDataDir()); err != nil {
-			fmt.Printf("⚠ logging init error: %v\n", err)
-		}
+		initLogging()
 
 		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
 		service := cron.NewService(storePath)
 
 		// 构建 Schedule
-		schedule := cron.Schedule{}
-		switch cronType {
-		case "every":
-			schedule.Type = cron.ScheduleTypeEvery
-			schedule.EveryMs = cronEvery
-		case "cron":
-			if cronSchedule == "" {
-				return fmt.Errorf("--schedule is required for type=cron")
-			}
-			schedule.Type = cron.ScheduleTypeCron
-			schedule.Expr = cronSchedule
-		case "once":
-			if cronAt == "" {
-				return fmt.Errorf("--at is required for type=once")
-			}
-			schedule.Type = cron.ScheduleTypeOnce
-			t, err := time.Parse("2006-01-02 15:04:05", cronAt)
-			if err != nil {
-				return fmt.Errorf("invalid time format, use: 2006-01-02 15:04:05")
-			}
-			schedule.AtMs = t.UnixMilli()
-		default:
-			return fmt.Errorf("invalid type: %s, use: every, cron, or once", cronType)
+		if cronType == string(cron.ScheduleTypeCron) && cronSchedule == "" {
+			return fmt.Errorf("--schedule is required for type=cron")
+		}
+		if cronType == string(cron.ScheduleTypeOnce) && cronAt == "" {
+			return fmt.Errorf("--at is required for type=once")
+		}
+		schedule, err := cron.BuildSchedule(cron.ScheduleType(cronType), cronSchedule, cronEvery, cronAt)
+		if err != nil {
+			return err
 		}
 
 		// 构建 Payload
@@ -150,6 +133,11 @@ var cronListCmd = &cobra.Command{
 			return nil
 		}
 
+		missed := make(map[string]bool)
+		for _, job := range service.MissedOnceJobs() {
+			missed[job.ID] = true
+		}
+
 		fmt.Printf("%-20s %-15s %-10s %-10s %s\n", "ID", "NAME", "TYPE", "STATUS", "NEXT RUN")
 		fmt.Println(string(make([]byte, 80)))
 		for _, job := range jobs {
@@ -157,6 +145,9 @@ var cronListCmd = &cobra.Command{
 			if job.Enabled {
 				status = "enabled"
 			}
+			if missed[job.ID] {
+				status = "missed"
+			}
 			nextRun := "-"
 			if t, ok := job.GetNextRun(); ok {
 				nextRun = t.Format("01-02 15:04")
@@ -178,6 +169,7 @@ var cronRemoveCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		initLogging()
 
 		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
 		service := cron.NewService(storePath)
@@ -201,6 +193,7 @@ var cronEnableCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		initLogging()
 
 		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
 		service := cron.NewService(storePath)
@@ -224,6 +217,7 @@ var cronDisableCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		initLogging()
 
 		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
 		service := cron.NewService(storePath)
@@ -270,12 +264,13 @@ var cronRunCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		initLogging()
 
+		if err := cfg.CheckModelProvider(""); err != nil {
+			return err
+		}
 		apiKey := cfg.GetAPIKey("")
 		apiBase := cfg.GetAPIBase("")
-		if apiKey == "" {
-			return fmt.Errorf("no API key configured")
-		}
 
 		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
 		service := cron.NewService(storePath)
@@ -313,8 +308,8 @@ func executeCronJob(cfg *config.Config, apiKey, apiBase string, cronService *cro
 	provider, err := providers.NewProvider(
 		apiKey,
 		apiBase,
-		cfg.GetAPIFormat(cfg.Agents.Defaults.Model),
-		cfg.Agents.Defaults.Model,
+		cfg.GetAPIFormat(""),
+		cfg.ResolveModel(""),
 		cfg.Agents.Defaults.MaxTokens,
 		cfg.Agents.Defaults.Temperature,
 		cfg.SupportsImageInput,
@@ -322,6 +317,7 @@ func executeCronJob(cfg *config.Config, apiKey, apiBase string, cronService *cro
 	if err != nil {
 		return "", fmt.Errorf("failed to create provider: %w", err)
 	}
+	provider = withFallbackModels(cfg, provider)
 
 	// 创建消息总线
 	messageBus := bus.NewMessageBus(100)
@@ -331,9 +327,9 @@ func executeCronJob(cfg *config.Config, apiKey, apiBase string, cronService *cro
 		messageBus,
 		provider,
 		cfg.Agents.Defaults.Workspace,
-		cfg.Agents.Defaults.Model,
+		cfg.ResolveModel(""),
 		cfg.Agents.Defaults.MaxToolIterations,
-		cfg.Tools.Web.Search.APIKey,
+		cfg.Tools.Web.Search,
 		agent.BuildWebFetchOptions(cfg),
 		cfg.Tools.Exec,
 		cfg.Tools.RestrictToWorkspace,
@@ -341,6 +337,7 @@ func executeCronJob(cfg *config.Config, apiKey, apiBase string, cronService *cro
 		cfg.Tools.MCPServers,
 		cfg.Agents.Defaults.EnableGlobalSkills,
 	)
+	applyAgentLoopDefaults(agentLoop, cfg)
 	// Use job's execution mode (defaults to auto for cron jobs to avoid waiting for user confirmation)
 	executionMode := job.GetExecutionMode()
 	if executionMode == cron.ExecutionModeAsk || executionMode == "" {
@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
 	"github.com/Lichas/maxclaw/internal/cron"
+	"github.com/Lichas/maxclaw/internal/logging"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -102,3 +105,30 @@ func TestEnqueueCronJobBufferFull(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to enqueue cron job")
 }
+
+func TestCronAddAndDisableCommandsInitLogging(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("MAXCLAW_HOME", "")
+	t.Setenv("NANOBOT_HOME", "")
+
+	rootCmd.SetArgs([]string{"cron", "add", "--name", "hello", "--message", "say hi", "--type", "every", "--every", "60000"})
+	defer rootCmd.SetArgs(nil)
+	require.NoError(t, rootCmd.Execute())
+	// logging.Init 进程内只生效一次，这里只确认命令执行后日志已可用
+	assert.NotNil(t, logging.Get())
+
+	cfg, err := config.LoadConfig()
+	require.NoError(t, err)
+	service := cron.NewService(filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json"))
+	jobs := service.ListJobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "hello", jobs[0].Name)
+	assert.True(t, jobs[0].Enabled)
+
+	rootCmd.SetArgs([]string{"cron", "disable", jobs[0].ID})
+	require.NoError(t, rootCmd.Execute())
+	service = cron.NewService(filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json"))
+	require.Len(t, service.ListJobs(), 1)
+	assert.False(t, service.ListJobs()[0].Enabled)
+}
@@ -6,6 +6,9 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,13 +22,18 @@ import (
 	"github.com/Lichas/maxclaw/internal/memory"
 	"github.com/Lichas/maxclaw/internal/providers"
 	"github.com/Lichas/maxclaw/internal/webui"
+	"github.com/Lichas/maxclaw/pkg/tools"
 	"github.com/spf13/cobra"
 )
 
-var gatewayPort int
+var (
+	gatewayPort    int
+	gatewayProfile string
+)
 
 func init() {
 	gatewayCmd.Flags().IntVarP(&gatewayPort, "port", "p", 18890, "Gateway port")
+	gatewayCmd.Flags().StringVar(&gatewayProfile, "profile", "", "Agent profile from agents.profiles to overlay onto agents.defaults")
 }
 
 // gatewayCmd 网关命令
@@ -33,17 +41,19 @@ var gatewayCmd = &cobra.Command{
 	Use:   "gateway",
 	Short: "Start the maxclaw gateway",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cfg, err := config.LoadConfig()
+		cfg, err := loadConfigWithProfile(gatewayProfile)
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
 		}
 
-		if _, err := logging.Init(config.GetDataDir()); err != nil {
-			fmt.Printf("⚠ logging init error: %v\n", err)
+		initLogging()
+
+		if err := autoInitWorkspace(cfg); err != nil {
+			return err
 		}
 
 		if lg := logging.Get(); lg != nil && lg.Gateway != nil {
-			lg.Gateway.Printf("gateway starting port=%d model=%s workspace=%s", gatewayPort, cfg.Agents.Defaults.Model, cfg.Agents.Defaults.Workspace)
+			lg.Gateway.Printf("gateway starting port=%d profile=%q model=%s workspace=%s", gatewayPort, gatewayProfile, cfg.ResolveModel(""), cfg.Agents.Defaults.Workspace)
 		}
 
 		apiKey := cfg.GetAPIKey("")
@@ -60,28 +70,39 @@ var gatewayCmd = &cobra.Command{
 				lg.Gateway.Printf("startup warning: %s", bootWarning)
 			}
 		}
+		for _, warning := range cfg.Warnings() {
+			fmt.Printf("⚠ %s\n", warning)
+			if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+				lg.Gateway.Printf("config warning: %s", warning)
+			}
+		}
 
 		// 创建组件
 		messageBus := bus.NewMessageBus(100)
 
+		// 启动所有服务
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// 配置热加载：SIGHUP、Web UI 保存配置或配置文件变化时生效；
+		// 入站处理与定时任务按次读取 reloader 的当前配置，限流、消息时效、会话范围与模型密钥的修改无需重启
+		reloader := newGatewayReloader(ctx, cfg, func() (*config.Config, error) {
+			return loadConfigWithProfile(gatewayProfile)
+		})
+
 		// 创建 Cron 服务（需要先创建，传给 agent）
 		storePath := filepath.Join(cfg.Agents.Defaults.Workspace, ".cron", "jobs.json")
 		cronService := cron.NewService(storePath)
-		cronService.SetJobHandler(func(job *cron.Job) (string, error) {
-			// Deliverable jobs should go through the live gateway bus so they are sent to the real channel/chat.
-			if job != nil && job.Payload.Deliver && len(job.Payload.Channels) > 0 && job.Payload.To != "" {
-				return enqueueCronJob(messageBus, job)
-			}
-			return executeCronJob(cfg, apiKey, apiBase, cronService, job)
-		})
+		cronService.SetMissedOncePolicy(cfg.Cron.MissedOnceJobs)
+		cronService.SetJobHandler(newGatewayCronHandler(messageBus, cronService, reloader.currentConfig, executeCronJob))
 
 		agentLoop := agent.NewAgentLoop(
 			messageBus,
 			provider,
 			cfg.Agents.Defaults.Workspace,
-			cfg.Agents.Defaults.Model,
+			cfg.ResolveModel(""),
 			cfg.Agents.Defaults.MaxToolIterations,
-			cfg.Tools.Web.Search.APIKey,
+			cfg.Tools.Web.Search,
 			agent.BuildWebFetchOptions(cfg),
 			cfg.Tools.Exec,
 			cfg.Tools.RestrictToWorkspace,
@@ -89,160 +110,55 @@ var gatewayCmd = &cobra.Command{
 			cfg.Tools.MCPServers,
 			cfg.Agents.Defaults.EnableGlobalSkills,
 		)
+		applyAgentLoopDefaults(agentLoop, cfg)
+		// 全局模型请求并发上限，定时任务与子代理共享；热加载时由 reloader 更新
+		providers.SetMaxConcurrentRequests(cfg.Agents.Defaults.MaxConcurrentRequests)
 		agentLoop.UpdateRuntimeExecutionMode(cfg.Agents.Defaults.ExecutionMode)
+		agentLoop.StreamToChannels = cfg.Channels.StreamResponses
+		agentLoop.ToolNotices = cfg.Channels.ToolNotices
+		registerOptionalTools(agentLoop, cfg)
 		defer agentLoop.Close()
 
 		// 创建频道注册表
-		channelRegistry := channels.NewRegistry()
-		mediaManager := media.NewManager(filepath.Join(config.GetDataDir(), "media", "inbound"))
-
-		// 注册 Telegram
-		if cfg.Channels.Telegram.Enabled {
-			mediaManager.Register("telegram", media.NewTelegramResolver(filepath.Join(config.GetDataDir(), "media", "inbound"), cfg.Channels.Telegram.Token, cfg.Channels.Telegram.Proxy))
-			tgChannel := channels.NewTelegramChannel(&channels.TelegramConfig{
-				Token:     cfg.Channels.Telegram.Token,
-				Enabled:   cfg.Channels.Telegram.Enabled,
-				AllowFrom: cfg.Channels.Telegram.AllowFrom,
-				Proxy:     cfg.Channels.Telegram.Proxy,
-			})
-			tgChannel.SetMessageHandler(func(msg *channels.Message) {
-				// 转发到消息总线
-				inboundMsg := bus.NewInboundMessage("telegram", msg.Sender, msg.ChatID, msg.Text)
-				inboundMsg.Media = stageInboundMedia(mediaManager, "telegram", msg.Media)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(tgChannel)
-		}
-
-		// 注册 Discord
-		if cfg.Channels.Discord.Enabled {
-			dcChannel := channels.NewDiscordChannel(&channels.DiscordConfig{
-				Token:     cfg.Channels.Discord.Token,
-				Enabled:   cfg.Channels.Discord.Enabled,
-				AllowFrom: cfg.Channels.Discord.AllowFrom,
-			})
-			dcChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("discord", msg.Sender, msg.ChatID, msg.Text)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(dcChannel)
-		}
-
-		// 注册 WhatsApp (Bridge)
-		if cfg.Channels.WhatsApp.Enabled {
-			waChannel := channels.NewWhatsAppChannel(&channels.WhatsAppConfig{
-				Enabled:     cfg.Channels.WhatsApp.Enabled,
-				BridgeURL:   cfg.Channels.WhatsApp.BridgeURL,
-				BridgeToken: cfg.Channels.WhatsApp.BridgeToken,
-				AllowFrom:   cfg.Channels.WhatsApp.AllowFrom,
-				AllowSelf:   cfg.Channels.WhatsApp.AllowSelf,
-			})
-			waChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("whatsapp", msg.Sender, msg.ChatID, msg.Text)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(waChannel)
-		}
-
-		// 注册 WebSocket
-		if cfg.Channels.WebSocket.Enabled {
-			wsChannel := channels.NewWebSocketChannel(&channels.WebSocketConfig{
-				Enabled:      cfg.Channels.WebSocket.Enabled,
-				Host:         cfg.Channels.WebSocket.Host,
-				Port:         cfg.Channels.WebSocket.Port,
-				Path:         cfg.Channels.WebSocket.Path,
-				AllowOrigins: cfg.Channels.WebSocket.AllowOrigins,
-			})
-			wsChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("websocket", msg.Sender, msg.ChatID, msg.Text)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(wsChannel)
-		}
-
-		// 注册 Slack（Socket Mode）
-		if cfg.Channels.Slack.Enabled {
-			slackChannel := channels.NewSlackChannel(&channels.SlackConfig{
-				Enabled:   cfg.Channels.Slack.Enabled,
-				BotToken:  cfg.Channels.Slack.BotToken,
-				AppToken:  cfg.Channels.Slack.AppToken,
-				AllowFrom: cfg.Channels.Slack.AllowFrom,
-			})
-			slackChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("slack", msg.Sender, msg.ChatID, msg.Text)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(slackChannel)
-		}
-
-		// 注册 Email（IMAP/SMTP）
-		if cfg.Channels.Email.Enabled {
-			emailChannel := channels.NewEmailChannel(&channels.EmailConfig{
-				Enabled:             cfg.Channels.Email.Enabled,
-				ConsentGranted:      cfg.Channels.Email.ConsentGranted,
-				IMAPHost:            cfg.Channels.Email.IMAPHost,
-				IMAPPort:            cfg.Channels.Email.IMAPPort,
-				IMAPUsername:        cfg.Channels.Email.IMAPUsername,
-				IMAPPassword:        cfg.Channels.Email.IMAPPassword,
-				IMAPMailbox:         cfg.Channels.Email.IMAPMailbox,
-				IMAPUseSSL:          cfg.Channels.Email.IMAPUseSSL,
-				SMTPHost:            cfg.Channels.Email.SMTPHost,
-				SMTPPort:            cfg.Channels.Email.SMTPPort,
-				SMTPUsername:        cfg.Channels.Email.SMTPUsername,
-				SMTPPassword:        cfg.Channels.Email.SMTPPassword,
-				SMTPUseTLS:          cfg.Channels.Email.SMTPUseTLS,
-				SMTPUseSSL:          cfg.Channels.Email.SMTPUseSSL,
-				FromAddress:         cfg.Channels.Email.FromAddress,
-				AutoReplyEnabled:    cfg.Channels.Email.AutoReplyEnabled,
-				PollIntervalSeconds: cfg.Channels.Email.PollIntervalSeconds,
-				MarkSeen:            cfg.Channels.Email.MarkSeen,
-				AllowFrom:           cfg.Channels.Email.AllowFrom,
-			})
-			emailChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("email", msg.Sender, msg.ChatID, msg.Text)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(emailChannel)
-		}
-
-		// 注册 QQ（腾讯官方 QQBot）
-		if cfg.Channels.QQ.Enabled {
-			mediaManager.Register("qq", media.NewQQResolver(filepath.Join(config.GetDataDir(), "media", "inbound"), nil))
-			qqChannel := channels.NewQQChannel(&channels.QQConfig{
-				Enabled:     cfg.Channels.QQ.Enabled,
-				AppID:       cfg.Channels.QQ.AppID,
-				AppSecret:   cfg.Channels.QQ.AppSecret,
-				AccessToken: cfg.Channels.QQ.AccessToken,
-				ListenAddr:  cfg.Channels.QQ.ListenAddr,
-				WebhookPath: cfg.Channels.QQ.WebhookPath,
-				WSURL:       cfg.Channels.QQ.WSURL,
-				AllowFrom:   cfg.Channels.QQ.AllowFrom,
-			})
-			qqChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("qq", msg.Sender, msg.ChatID, msg.Text)
-				inboundMsg.Media = stageInboundMedia(mediaManager, "qq", msg.Media)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(qqChannel)
-		}
-
-		// 注册 Feishu（Webhook + OpenAPI）
-		if cfg.Channels.Feishu.Enabled {
-			feishuChannel := channels.NewFeishuChannel(&channels.FeishuConfig{
-				Enabled:           cfg.Channels.Feishu.Enabled,
-				AppID:             cfg.Channels.Feishu.AppID,
-				AppSecret:         cfg.Channels.Feishu.AppSecret,
-				VerificationToken: cfg.Channels.Feishu.VerificationToken,
-				ListenAddr:        cfg.Channels.Feishu.ListenAddr,
-				WebhookPath:       cfg.Channels.Feishu.WebhookPath,
-				AllowFrom:         cfg.Channels.Feishu.AllowFrom,
-			})
-			feishuChannel.SetMessageHandler(func(msg *channels.Message) {
-				inboundMsg := bus.NewInboundMessage("feishu", msg.Sender, msg.ChatID, msg.Text)
-				messageBus.PublishInbound(inboundMsg)
-			})
-			channelRegistry.Register(feishuChannel)
+		dropStale := newStaleMessageFilter(func() time.Duration {
+			return time.Duration(reloader.currentConfig().Channels.MaxMessageAgeSeconds) * time.Second
+		})
+		dropDuplicate := newDuplicateMessageFilter(channels.NewSeenMessageCache(
+			filepath.Join(config.GetDataDir(), "channels", "seen_messages.json"), 0, 0))
+		inboundDir := filepath.Join(config.GetDataDir(), "media", "inbound")
+		mediaManager := media.NewManager(inboundDir)
+		registerMediaResolvers(mediaManager, inboundDir, cfg)
+		typing := channels.NewTypingIndicator(0, 0)
+
+		var channelRegistry *channels.Registry
+		dropRateLimited := newRateLimitFilter(
+			func() config.RateLimitConfig { return reloader.currentConfig().Channels.RateLimit },
+			func(channel, chatID, text string) error {
+				ch, ok := channelRegistry.Get(channel)
+				if !ok {
+					return fmt.Errorf("channel not found: %s", channel)
+				}
+				return ch.SendMessage(chatID, text)
+			},
+		)
+		inboundHandler := func(msg *channels.Message) {
+			if dropStale(msg) || dropDuplicate(msg) || dropRateLimited(msg) {
+				return
+			}
+			// 转发到消息总线
+			inboundMsg := bus.NewInboundMessage(msg.Channel, msg.Sender, msg.ChatID, msg.Text)
+			inboundMsg.MessageID = msg.ID
+			inboundMsg.ApplySessionScope(reloader.currentConfig().Channels.SessionScopeFor(msg.Channel))
+			inboundMsg.Media = stageInboundMedia(mediaManager, msg.Channel, msg.Media)
+			if err := messageBus.PublishInbound(inboundMsg); err != nil {
+				return
+			}
+			// 处理期间显示“正在输入”，回复发出时停止
+			if ch, ok := channelRegistry.Get(msg.Channel); ok {
+				typing.Start(ch, msg.ChatID)
+			}
 		}
+		channelRegistry = channels.BuildFromConfig(cfg, inboundHandler)
 
 		// 检查启用的频道
 		enabledChannels := []string{}
@@ -269,12 +185,24 @@ var gatewayCmd = &cobra.Command{
 			lg.Gateway.Printf("cron jobs total=%v enabled=%v", cronStatus["totalJobs"], cronStatus["enabledJobs"])
 		}
 
-		// 启动所有服务
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
+		reloader.agentLoop = agentLoop
+		reloader.registry = channelRegistry
+		reloader.media = mediaManager
+		reloader.inboundDir = inboundDir
+		reloader.handler = inboundHandler
 
 		// 启动 Web UI/API 服务器
 		webServer := webui.NewServer(cfg, agentLoop, cronService, channelRegistry)
+		webServer.SetConfigReloader(func() error {
+			return reloader.reloadAndReport("web ui")
+		})
+		webServer.SetConfigLoader(func() (*config.Config, error) {
+			return loadConfigWithProfile(gatewayProfile)
+		})
+		reloader.onReload = func(cfg *config.Config, result channels.ReconcileResult) {
+			webServer.SetConfig(cfg)
+			webServer.UpdateChannelStatus(result)
+		}
 		go func() {
 			if err := webServer.Start(ctx, cfg.Gateway.Host, gatewayPort); err != nil && err != context.Canceled {
 				fmt.Printf("⚠ Web UI server error: %v\n", err)
@@ -293,14 +221,17 @@ var gatewayCmd = &cobra.Command{
 		fmt.Println("\nPress Ctrl+C to stop")
 
 		// 启动频道
+		channelErrors := map[string]error{}
 		for _, ch := range channelRegistry.GetEnabled() {
 			if err := ch.Start(ctx); err != nil {
+				channelErrors[ch.Name()] = err
 				fmt.Printf("⚠ Failed to start %s channel: %v\n", ch.Name(), err)
 				if lg := logging.Get(); lg != nil && lg.Channels != nil {
 					lg.Channels.Printf("start channel=%s error=%v", ch.Name(), err)
 				}
 			}
 		}
+		webServer.MarkChannelsStarted(channelErrors)
 
 		// 启动 Cron 服务
 		if err := cronService.Start(); err != nil {
@@ -315,15 +246,23 @@ var gatewayCmd = &cobra.Command{
 		go dailySummary.Start(ctx)
 
 		// 启动出站消息处理器
-		go handleOutboundMessages(ctx, messageBus, channelRegistry)
+		go handleOutboundMessages(ctx, messageBus, channelRegistry, typing)
+
+		go reloader.watch(ctx, configWatchInterval)
 
-		// 处理 Ctrl+C
+		// 处理 Ctrl+C；SIGHUP 重新加载配置
 		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 		go func() {
-			<-sigChan
-			fmt.Println("\nShutting down...")
-			cancel()
+			for sig := range sigChan {
+				if sig == syscall.SIGHUP {
+					_ = reloader.reloadAndReport("SIGHUP")
+					continue
+				}
+				fmt.Println("\nShutting down...")
+				cancel()
+				return
+			}
 		}()
 
 		// 运行 Agent
@@ -363,19 +302,21 @@ func stageInboundMedia(manager *media.Manager, channel string, attachment *bus.M
 	return staged
 }
 
+// buildGatewayProvider 创建网关使用的提供商；模型对应的提供商缺少 Key / Base 时不中止启动，
+// 而是进入仅配置模式（可在 Web UI 中补全配置后热加载），请求会返回点名缺失项的错误
 func buildGatewayProvider(cfg *config.Config, apiKey, apiBase string) (providers.LLMProvider, string, error) {
-	if apiKey == "" {
-		return &unavailableProvider{
-			model:  cfg.Agents.Defaults.Model,
-			reason: "no API key configured. Set one in ~/.maxclaw/config.json (or via Web UI settings) to enable model requests",
-		}, "No API key configured. Gateway started in configuration-only mode; model requests will fail until key is set.", nil
+	if err := cfg.CheckModelProvider(""); err != nil {
+		return withFallbackModels(cfg, &unavailableProvider{
+			model:  cfg.ResolveModel(""),
+			reason: err.Error(),
+		}), fmt.Sprintf("%v. Gateway started in configuration-only mode; model requests will fail until this is fixed.", err), nil
 	}
 
 	provider, err := providers.NewProvider(
 		apiKey,
 		apiBase,
-		cfg.GetAPIFormat(cfg.Agents.Defaults.Model),
-		cfg.Agents.Defaults.Model,
+		cfg.GetAPIFormat(""),
+		cfg.ResolveModel(""),
 		cfg.Agents.Defaults.MaxTokens,
 		cfg.Agents.Defaults.Temperature,
 		cfg.SupportsImageInput,
@@ -383,7 +324,59 @@ func buildGatewayProvider(cfg *config.Config, apiKey, apiBase string) (providers
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to create provider: %w", err)
 	}
-	return provider, "", nil
+	return withFallbackModels(cfg, provider), "", nil
+}
+
+// withFallbackModels 为 primary 加上 agents.defaults.fallbackModels 中的备用模型；
+// 缺少 Key / Base 或创建失败的备用模型会被跳过并记录日志
+func withFallbackModels(cfg *config.Config, primary providers.LLMProvider) providers.LLMProvider {
+	fallbacks := make([]providers.LLMProvider, 0, len(cfg.Agents.Defaults.FallbackModels))
+	for _, name := range cfg.Agents.Defaults.FallbackModels {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		model := cfg.ResolveModel(name)
+		provider, err := newModelProvider(cfg, model)
+		if err != nil {
+			if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+				lg.Gateway.Printf("skip fallback model %s: %v", model, err)
+			}
+			continue
+		}
+		fallbacks = append(fallbacks, provider)
+	}
+	return providers.NewFallbackProvider(primary, fallbacks...)
+}
+
+// newModelProvider 按配置为指定模型创建 provider
+func newModelProvider(cfg *config.Config, model string) (providers.LLMProvider, error) {
+	if err := cfg.CheckModelProvider(model); err != nil {
+		return nil, err
+	}
+	return providers.NewProvider(
+		cfg.GetAPIKey(model),
+		cfg.GetAPIBase(model),
+		cfg.GetAPIFormat(model),
+		model,
+		cfg.Agents.Defaults.MaxTokens,
+		cfg.Agents.Defaults.Temperature,
+		cfg.SupportsImageInput,
+	)
+}
+
+// channelModelOverrides 返回按频道覆盖的模型（已解析提供商简写）及为这些模型创建 provider 的工厂
+func channelModelOverrides(cfg *config.Config) (map[string]string, agent.ModelProviderFactory) {
+	models := cfg.Channels.Models()
+	for channel, model := range models {
+		models[channel] = cfg.ResolveModel(model)
+	}
+	return models, func(model string) (providers.LLMProvider, error) {
+		provider, err := newModelProvider(cfg, cfg.ResolveModel(model))
+		if err != nil {
+			return nil, err
+		}
+		return withFallbackModels(cfg, provider), nil
+	}
 }
 
 type unavailableProvider struct {
@@ -414,8 +407,8 @@ func (p *unavailableProvider) SupportsImageInput(model string) bool {
 	return false
 }
 
-// handleOutboundMessages 处理出站消息
-func handleOutboundMessages(ctx context.Context, bus *bus.MessageBus, registry *channels.Registry) {
+// handleOutboundMessages 处理出站消息；typing 不为 nil 时，发送前停止对应会话的“正在输入”提示
+func handleOutboundMessages(ctx context.Context, bus *bus.MessageBus, registry *channels.Registry, typing *channels.TypingIndicator) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -448,6 +441,7 @@ func handleOutboundMessages(ctx context.Context, bus *bus.MessageBus, registry *
 			}
 			continue
 		}
+		typing.Stop(msg.Channel, msg.ChatID)
 
 		// 检查是否有媒体附件
 		if msg.Media != nil && msg.Media.Type != "" {
@@ -459,7 +453,7 @@ func handleOutboundMessages(ctx context.Context, bus *bus.MessageBus, registry *
 			}
 		} else {
 			// 发送普通文本消息
-			if err := ch.SendMessage(msg.ChatID, msg.Content); err != nil {
+			if err := sendTextMessage(ch, msg); err != nil {
 				if lg := logging.Get(); lg != nil && lg.Channels != nil {
 					lg.Channels.Printf("send failed channel=%s chat=%s err=%v", msg.Channel, msg.ChatID, err)
 				}
@@ -468,6 +462,14 @@ func handleOutboundMessages(ctx context.Context, bus *bus.MessageBus, registry *
 	}
 }
 
+// sendTextMessage 发送文本消息；带 ReplyTo 且频道支持时关联原始消息回复
+func sendTextMessage(ch channels.Channel, msg *bus.OutboundMessage) error {
+	if replier, ok := ch.(channels.ReplySender); ok && msg.ReplyTo != "" {
+		return replier.SendReply(msg.ChatID, msg.ReplyTo, msg.Content)
+	}
+	return ch.SendMessage(msg.ChatID, msg.Content)
+}
+
 // sendMessageWithMedia 发送带附件的消息
 func sendMessageWithMedia(ch channels.Channel, msg *bus.OutboundMessage) error {
 	type photoSender interface {
@@ -501,3 +503,106 @@ func sendMessageWithMedia(ch channels.Channel, msg *bus.OutboundMessage) error {
 	}
 	return ch.SendMessage(msg.ChatID, content)
 }
+
+// newStaleMessageFilter 返回入站消息过滤器：消息早于 maxAge 时记录日志并返回 true（应丢弃）；
+// maxAge 每条消息读取一次，热加载后的时效设置立即生效
+func newStaleMessageFilter(maxAge func() time.Duration) func(msg *channels.Message) bool {
+	return func(msg *channels.Message) bool {
+		maxAge := maxAge()
+		if !channels.IsStaleMessage(msg, maxAge, time.Now()) {
+			return false
+		}
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("skip stale inbound channel=%s chat=%s id=%s sentAt=%s maxAge=%s",
+				msg.Channel, msg.ChatID, msg.ID, msg.Timestamp.Format(time.RFC3339), maxAge)
+		}
+		return true
+	}
+}
+
+// newGatewayCronHandler 返回网关的定时任务处理器：需投递的任务经消息总线发往真实频道，
+// 其余任务每次运行时读取当前配置并解析模型密钥与地址，热加载后的修改无需重启网关
+func newGatewayCronHandler(
+	messageBus *bus.MessageBus,
+	cronService *cron.Service,
+	currentConfig func() *config.Config,
+	execute func(cfg *config.Config, apiKey, apiBase string, cronService *cron.Service, job *cron.Job) (string, error),
+) cron.JobFunc {
+	return func(job *cron.Job) (string, error) {
+		if job != nil && job.Payload.Deliver && len(job.Payload.Channels) > 0 && job.Payload.To != "" {
+			return enqueueCronJob(messageBus, job)
+		}
+		cfg := currentConfig()
+		return execute(cfg, cfg.GetAPIKey(""), cfg.GetAPIBase(""), cronService, job)
+	}
+}
+
+// newDuplicateMessageFilter 返回入站消息过滤器：消息已处理过（如网关重启后平台重放）时记录日志并返回 true（应丢弃）
+func newDuplicateMessageFilter(seen *channels.SeenMessageCache) func(msg *channels.Message) bool {
+	return func(msg *channels.Message) bool {
+		if !seen.Seen(msg) {
+			return false
+		}
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("skip duplicate inbound channel=%s chat=%s id=%s", msg.Channel, msg.ChatID, msg.ID)
+		}
+		return true
+	}
+}
+
+// newRateLimitFilter 返回入站消息过滤器：发送者超出速率时记录日志并返回 true（应丢弃）；
+// settings 每条消息读取一次当前限流配置，Notice 非空时在开始限流的第一条消息上回复提示
+func newRateLimitFilter(settings func() config.RateLimitConfig, send func(channel, chatID, text string) error) func(msg *channels.Message) bool {
+	var (
+		mu      sync.Mutex
+		applied config.RateLimitConfig
+		limiter *channels.RateLimiter
+		built   bool
+	)
+	return func(msg *channels.Message) bool {
+		current := settings()
+		notice := strings.TrimSpace(current.Notice)
+		mu.Lock()
+		// 速率或容量变化后按新配置重建限流器（已有的令牌桶随之清空）
+		if !built || current.MessagesPerMinute != applied.MessagesPerMinute || current.Burst != applied.Burst {
+			limiter = channels.NewRateLimiter(current.MessagesPerMinute, current.Burst)
+			applied, built = current, true
+		}
+		mu.Unlock()
+		allowed, notify := limiter.Allow(msg)
+		if allowed {
+			return false
+		}
+		if lg := logging.Get(); lg != nil && lg.Channels != nil {
+			lg.Channels.Printf("skip rate limited inbound channel=%s chat=%s sender=%s id=%s", msg.Channel, msg.ChatID, msg.Sender, msg.ID)
+		}
+		if notify && notice != "" && send != nil {
+			if err := send(msg.Channel, msg.ChatID, notice); err != nil {
+				if lg := logging.Get(); lg != nil && lg.Channels != nil {
+					lg.Channels.Printf("rate limit notice failed channel=%s chat=%s err=%v", msg.Channel, msg.ChatID, err)
+				}
+			}
+		}
+		return true
+	}
+}
+
+// registerOptionalTools 注册默认关闭、需在配置中显式开启的工具
+func registerOptionalTools(agentLoop *agent.AgentLoop, cfg *config.Config) {
+	if cfg.Tools.ReadLogs.Enabled {
+		_ = agentLoop.RegisterTool(tools.NewReadLogsTool(config.GetLogsDir(), cfg.Tools.ReadLogs.AllowChannels))
+	}
+	if httpGet := cfg.Tools.HTTPGet; httpGet.Enabled && len(httpGet.Endpoints) > 0 {
+		names := make([]string, 0, len(httpGet.Endpoints))
+		for name := range httpGet.Endpoints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		endpoints := make([]tools.HTTPGetEndpoint, 0, len(names))
+		for _, name := range names {
+			ep := httpGet.Endpoints[name]
+			endpoints = append(endpoints, tools.HTTPGetEndpoint{Name: name, BaseURL: ep.BaseURL, Headers: ep.Headers})
+		}
+		_ = agentLoop.RegisterTool(tools.NewHTTPGetTool(endpoints, httpGet.TimeoutSeconds, httpGet.MaxChars))
+	}
+}
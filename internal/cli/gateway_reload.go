@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/agent"
+	"github.com/Lichas/maxclaw/internal/channels"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/Lichas/maxclaw/internal/media"
+	"github.com/Lichas/maxclaw/internal/providers"
+)
+
+// configWatchInterval 轮询配置文件修改时间的间隔
+const configWatchInterval = 2 * time.Second
+
+// gatewayReloader 在网关运行期间重新加载配置：重建 provider、更新模型参数，并增删/重启频道。
+// 触发方式：SIGHUP、Web UI 保存配置、配置文件修改时间变化
+type gatewayReloader struct {
+	mu sync.Mutex
+	// cfgMu 保护 cfg，入站处理等热路径通过 currentConfig 读取而不必等待整个 Reload
+	cfgMu sync.RWMutex
+
+	ctx        context.Context
+	cfg        *config.Config
+	load       func() (*config.Config, error)
+	configPath string
+	modTime    time.Time
+
+	agentLoop  *agent.AgentLoop
+	registry   *channels.Registry
+	media      *media.Manager
+	inboundDir string
+	handler    func(msg *channels.Message)
+	// onReload 配置重新加载成功后调用（如同步 Web UI 持有的配置与频道就绪状态）
+	onReload func(cfg *config.Config, result channels.ReconcileResult)
+}
+
+func newGatewayReloader(ctx context.Context, cfg *config.Config, load func() (*config.Config, error)) *gatewayReloader {
+	r := &gatewayReloader{
+		ctx:        ctx,
+		cfg:        cfg,
+		load:       load,
+		configPath: config.GetConfigPath(),
+	}
+	r.modTime = r.configModTime()
+	return r
+}
+
+// Reload 重新读取配置并应用到运行中的组件；配置无效时保留当前配置并返回错误
+func (r *gatewayReloader) Reload() (channels.ReconcileResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.modTime = r.configModTime()
+	cfg, err := r.load()
+	if err != nil {
+		return channels.ReconcileResult{}, fmt.Errorf("reload config: %w", err)
+	}
+
+	if r.agentLoop != nil {
+		provider, warning, err := buildGatewayProvider(cfg, cfg.GetAPIKey(""), cfg.GetAPIBase(""))
+		if err != nil {
+			return channels.ReconcileResult{}, fmt.Errorf("reload provider: %w", err)
+		}
+		if warning != "" {
+			if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+				lg.Gateway.Printf("reload warning: %s", warning)
+			}
+		}
+		r.agentLoop.UpdateRuntimeModel(provider, cfg.ResolveModel(""))
+		applyAgentLoopDefaults(r.agentLoop, cfg)
+		r.agentLoop.UpdateRuntimeMaxIterations(cfg.Agents.Defaults.MaxToolIterations)
+		r.agentLoop.UpdateRuntimeExecutionMode(cfg.Agents.Defaults.ExecutionMode)
+	}
+
+	providers.SetMaxConcurrentRequests(cfg.Agents.Defaults.MaxConcurrentRequests)
+
+	if r.media != nil {
+		registerMediaResolvers(r.media, r.inboundDir, cfg)
+	}
+	desired := channels.BuildFromConfig(cfg, r.handler)
+	result := r.registry.Reconcile(r.ctx, desired, channels.ChangedChannels(r.cfg, cfg))
+	r.cfgMu.Lock()
+	r.cfg = cfg
+	r.cfgMu.Unlock()
+	if r.onReload != nil {
+		r.onReload(cfg, result)
+	}
+
+	if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+		lg.Gateway.Printf("config reloaded model=%s started=%v stopped=%v restarted=%v errors=%v",
+			cfg.ResolveModel(""), result.Started, result.Stopped, result.Restarted, result.Errors)
+	}
+	return result, nil
+}
+
+// currentConfig 返回最近一次成功加载的配置
+func (r *gatewayReloader) currentConfig() *config.Config {
+	r.cfgMu.RLock()
+	defer r.cfgMu.RUnlock()
+	return r.cfg
+}
+
+// reloadAndReport 执行 Reload 并把结果打印到控制台与日志
+func (r *gatewayReloader) reloadAndReport(trigger string) error {
+	result, err := r.Reload()
+	if err != nil {
+		fmt.Printf("⚠ Config reload (%s) failed, keeping current config: %v\n", trigger, err)
+		if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+			lg.Gateway.Printf("config reload trigger=%s error=%v", trigger, err)
+		}
+		return err
+	}
+	fmt.Printf("✓ Config reloaded (%s)\n", trigger)
+	if result.Changed() {
+		fmt.Printf("  channels started=%v stopped=%v restarted=%v\n", result.Started, result.Stopped, result.Restarted)
+	}
+	for _, chErr := range result.Errors {
+		fmt.Printf("⚠ %v\n", chErr)
+	}
+	return nil
+}
+
+// watch 轮询配置文件修改时间，变化时自动重新加载
+func (r *gatewayReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.mu.Lock()
+			changed := !r.configModTime().Equal(r.modTime)
+			r.mu.Unlock()
+			if changed {
+				_ = r.reloadAndReport("config file changed")
+			}
+		}
+	}
+}
+
+func (r *gatewayReloader) configModTime() time.Time {
+	info, err := os.Stat(r.configPath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// registerMediaResolvers 按配置注册（或移除）需要下载入站媒体的频道解析器
+func registerMediaResolvers(manager *media.Manager, inboundDir string, cfg *config.Config) {
+	if tg := cfg.Channels.Telegram; tg.Enabled && tg.MediaDownloadEnabled() {
+		manager.Register("telegram", media.NewTelegramResolver(inboundDir, tg.Token, tg.Proxy, tg.MaxMediaBytes()))
+	} else {
+		manager.Unregister("telegram")
+	}
+	if cfg.Channels.QQ.Enabled {
+		manager.Register("qq", media.NewQQResolver(inboundDir, nil))
+	} else {
+		manager.Unregister("qq")
+	}
+}
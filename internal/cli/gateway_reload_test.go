@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/agent"
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/channels"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/cron"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func freeTCPPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestGatewayReloaderReconcilesChannels(t *testing.T) {
+	port := freeTCPPort(t)
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	websocketConfig := func(path string) *config.Config {
+		cfg := config.DefaultConfig()
+		cfg.Channels.WebSocket = config.WebSocketConfig{Enabled: true, Host: "127.0.0.1", Port: port, Path: path}
+		return cfg
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	initial := config.DefaultConfig()
+	next, loadErr := initial, error(nil)
+	registry := channels.BuildFromConfig(initial, nil)
+	reloader := newGatewayReloader(ctx, initial, func() (*config.Config, error) { return next, loadErr })
+	reloader.registry = registry
+	defer func() {
+		for _, ch := range registry.GetAll() {
+			ch.Stop()
+		}
+	}()
+
+	// 启用 websocket 后重新加载：频道被注册并开始监听
+	next = websocketConfig("/ws")
+	result, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"websocket"}, result.Started)
+	assert.Empty(t, result.Errors)
+	first, ok := registry.Get("websocket")
+	require.True(t, ok)
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	conn.Close()
+
+	// 配置未变化时保留正在运行的实例
+	result, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, result.Changed())
+	current, _ := registry.Get("websocket")
+	assert.Same(t, first, current)
+
+	// 频道配置变化时用新实例替换
+	next = websocketConfig("/chat")
+	result, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"websocket"}, result.Restarted)
+	assert.Empty(t, result.Errors)
+	current, _ = registry.Get("websocket")
+	assert.NotSame(t, first, current)
+
+	// 配置无效时保留当前状态
+	loadErr = errors.New("invalid config: gateway.port")
+	_, err = reloader.Reload()
+	require.Error(t, err)
+	_, ok = registry.Get("websocket")
+	assert.True(t, ok)
+
+	// 停用后频道被停止并移除
+	next, loadErr = config.DefaultConfig(), nil
+	result, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"websocket"}, result.Stopped)
+	_, ok = registry.Get("websocket")
+	assert.False(t, ok)
+	_, err = net.Dial("tcp", addr)
+	assert.Error(t, err)
+}
+
+func TestGatewayReloaderAppliesAgentDefaultsAndNotifies(t *testing.T) {
+	loop := agent.NewAgentLoop(
+		bus.NewMessageBus(10),
+		&channelEchoProvider{},
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	defer loop.Close()
+
+	next := config.DefaultConfig()
+	next.Agents.Defaults.Model = "mock"
+	next.Agents.Defaults.ToolTimeoutSeconds = 7
+	next.Agents.Defaults.TurnTimeoutSeconds = 90
+	next.Agents.Defaults.MaxParallelTools = 2
+	next.Agents.Defaults.RepeatedToolCallWindow = 3
+
+	reloader := newGatewayReloader(context.Background(), config.DefaultConfig(), func() (*config.Config, error) { return next, nil })
+	reloader.agentLoop = loop
+	reloader.registry = channels.NewRegistry()
+	var notified *config.Config
+	reloader.onReload = func(cfg *config.Config, _ channels.ReconcileResult) { notified = cfg }
+
+	_, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, 7*time.Second, loop.ToolTimeout)
+	assert.Equal(t, 90*time.Second, loop.TurnTimeout)
+	assert.Equal(t, 2, loop.MaxParallelTools)
+	assert.Equal(t, 3, loop.RepeatedToolCallWindow)
+	assert.Same(t, next, notified)
+	// 入站处理读取的会话范围、限流等配置随之更新
+	assert.Same(t, next, reloader.currentConfig())
+}
+
+func TestGatewayCronHandlerUsesReloadedProviderSettings(t *testing.T) {
+	initial := config.DefaultConfig()
+	initial.Agents.Defaults.Model = "openrouter/auto"
+	initial.Providers.OpenRouter = config.ProviderConfig{APIKey: "old-key", APIBase: "https://old.example/v1"}
+	next := config.DefaultConfig()
+	next.Agents.Defaults.Model = "openrouter/auto"
+	next.Providers.OpenRouter = config.ProviderConfig{APIKey: "new-key", APIBase: "https://new.example/v1"}
+
+	reloader := newGatewayReloader(context.Background(), initial, func() (*config.Config, error) { return next, nil })
+	reloader.registry = channels.NewRegistry()
+
+	var gotCfg *config.Config
+	var gotKey, gotBase string
+	handler := newGatewayCronHandler(bus.NewMessageBus(10), nil, reloader.currentConfig,
+		func(cfg *config.Config, apiKey, apiBase string, _ *cron.Service, _ *cron.Job) (string, error) {
+			gotCfg, gotKey, gotBase = cfg, apiKey, apiBase
+			return "ok", nil
+		})
+	job := &cron.Job{ID: "job-1"}
+
+	_, err := handler(job)
+	require.NoError(t, err)
+	assert.Same(t, initial, gotCfg)
+	assert.Equal(t, "old-key", gotKey)
+	assert.Equal(t, "https://old.example/v1", gotBase)
+
+	// 热加载轮换密钥后，下一次运行使用新配置
+	_, err = reloader.Reload()
+	require.NoError(t, err)
+	_, err = handler(job)
+	require.NoError(t, err)
+	assert.Same(t, next, gotCfg)
+	assert.Equal(t, "new-key", gotKey)
+	assert.Equal(t, "https://new.example/v1", gotBase)
+}
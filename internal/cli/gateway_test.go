@@ -3,6 +3,8 @@ package cli
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -69,7 +71,7 @@ func TestHandleOutboundMessagesSendSuccess(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go handleOutboundMessages(ctx, messageBus, registry)
+	go handleOutboundMessages(ctx, messageBus, registry, nil)
 
 	if err := messageBus.PublishOutbound(bus.NewOutboundMessage("telegram", "chat-42", "hello")); err != nil {
 		t.Fatalf("publish outbound: %v", err)
@@ -86,6 +88,40 @@ func TestHandleOutboundMessagesSendSuccess(t *testing.T) {
 	}
 }
 
+type replyMockChannel struct {
+	mockChannel
+	replies []string
+}
+
+func (m *replyMockChannel) SendReply(chatID, replyTo, text string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.replies = append(m.replies, replyTo+":"+text)
+	return nil
+}
+
+func TestSendTextMessageUsesReplySender(t *testing.T) {
+	ch := &replyMockChannel{mockChannel: mockChannel{name: "discord", enabled: true}}
+
+	reply := bus.NewOutboundMessage("discord", "chan-1", "answer")
+	reply.ReplyTo = "interaction-1"
+	if err := sendTextMessage(ch, reply); err != nil {
+		t.Fatalf("send reply: %v", err)
+	}
+	// 不关联入站消息的出站消息（如定时任务）走普通发送
+	if err := sendTextMessage(ch, bus.NewOutboundMessage("discord", "chan-1", "cron")); err != nil {
+		t.Fatalf("send message: %v", err)
+	}
+
+	calls, _, text := ch.snapshot()
+	if len(ch.replies) != 1 || ch.replies[0] != "interaction-1:answer" {
+		t.Fatalf("expected one reply for the interaction, got %v", ch.replies)
+	}
+	if calls != 1 || text != "cron" {
+		t.Fatalf("expected plain send for unrelated message, calls=%d text=%q", calls, text)
+	}
+}
+
 func TestHandleOutboundMessagesDropEmptyChat(t *testing.T) {
 	messageBus := bus.NewMessageBus(10)
 	registry := channels.NewRegistry()
@@ -94,7 +130,7 @@ func TestHandleOutboundMessagesDropEmptyChat(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go handleOutboundMessages(ctx, messageBus, registry)
+	go handleOutboundMessages(ctx, messageBus, registry, nil)
 
 	if err := messageBus.PublishOutbound(bus.NewOutboundMessage("telegram", "", "hello")); err != nil {
 		t.Fatalf("publish outbound: %v", err)
@@ -115,7 +151,7 @@ func TestHandleOutboundMessagesContinueAfterError(t *testing.T) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	go handleOutboundMessages(ctx, messageBus, registry)
+	go handleOutboundMessages(ctx, messageBus, registry, nil)
 
 	if err := messageBus.PublishOutbound(bus.NewOutboundMessage("telegram", "chat-1", "a")); err != nil {
 		t.Fatalf("publish outbound #1: %v", err)
@@ -155,6 +191,7 @@ func TestBuildGatewayProviderWithoutAPIKeyFallsBack(t *testing.T) {
 func TestBuildGatewayProviderWithAPIKeyUsesOpenAIProvider(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Agents.Defaults.Model = "gpt-4o-mini"
+	cfg.Providers.OpenAI.APIKey = "sk-test"
 
 	provider, warning, err := buildGatewayProvider(cfg, "sk-test", "https://example.com/v1")
 	if err != nil {
@@ -174,6 +211,7 @@ func TestBuildGatewayProviderWithAPIKeyUsesOpenAIProvider(t *testing.T) {
 func TestBuildGatewayProviderWithAnthropicModelUsesAnthropicProvider(t *testing.T) {
 	cfg := config.DefaultConfig()
 	cfg.Agents.Defaults.Model = "anthropic/claude-sonnet-4-5"
+	cfg.Providers.Anthropic.APIKey = "sk-ant"
 
 	provider, warning, err := buildGatewayProvider(cfg, "sk-ant", "https://api.anthropic.com")
 	if err != nil {
@@ -186,3 +224,167 @@ func TestBuildGatewayProviderWithAnthropicModelUsesAnthropicProvider(t *testing.
 		t.Fatalf("expected AnthropicProvider, got %T", provider)
 	}
 }
+
+func TestBuildGatewayProviderNamesProviderMissingKey(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Model = "deepseek-chat"
+	cfg.Providers.OpenAI.APIKey = "sk-openai"
+
+	provider, warning, err := buildGatewayProvider(cfg, cfg.GetAPIKey(""), cfg.GetAPIBase(""))
+	if err != nil {
+		t.Fatalf("buildGatewayProvider returned error: %v", err)
+	}
+	if !strings.Contains(warning, "providers.deepseek.apiKey") {
+		t.Fatalf("expected warning naming deepseek, got: %s", warning)
+	}
+	_, chatErr := provider.Chat(context.Background(), nil, nil, "")
+	if chatErr == nil || !strings.Contains(chatErr.Error(), `provider "deepseek"`) {
+		t.Fatalf("expected chat error naming deepseek, got: %v", chatErr)
+	}
+}
+
+func TestStaleMessageFilterSkipsOldMessages(t *testing.T) {
+	maxAge := 10 * time.Minute
+	dropStale := newStaleMessageFilter(func() time.Duration { return maxAge })
+
+	stale := &channels.Message{ID: "old", Channel: "telegram", ChatID: "1", Timestamp: time.Now().Add(-3 * time.Hour)}
+	fresh := &channels.Message{ID: "new", Channel: "telegram", ChatID: "1", Timestamp: time.Now().Add(-time.Minute)}
+	if !dropStale(stale) {
+		t.Fatalf("expected stale message to be skipped")
+	}
+	if dropStale(fresh) {
+		t.Fatalf("expected fresh message to be processed")
+	}
+
+	// 热加载把时效改为 0 后，同一个过滤器不再丢弃旧消息
+	maxAge = 0
+	if dropStale(stale) {
+		t.Fatalf("expected filter to be disabled when max age is 0")
+	}
+}
+
+func TestDuplicateMessageFilterSkipsReplayedMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "seen_messages.json")
+	msg := &channels.Message{ID: "100", Channel: "telegram", ChatID: "1", Text: "hi"}
+
+	dropDuplicate := newDuplicateMessageFilter(channels.NewSeenMessageCache(path, 0, 0))
+	if dropDuplicate(msg) {
+		t.Fatalf("expected first delivery to be processed")
+	}
+	if !dropDuplicate(msg) {
+		t.Fatalf("expected redelivered message to be skipped")
+	}
+
+	// 网关重启后平台重放同一条消息
+	restarted := newDuplicateMessageFilter(channels.NewSeenMessageCache(path, 0, 0))
+	if !restarted(msg) {
+		t.Fatalf("expected message replayed after restart to be skipped")
+	}
+}
+
+func TestRateLimitFilterDropsAndNotifiesOnce(t *testing.T) {
+	var notices []string
+	send := func(channel, chatID, text string) error {
+		notices = append(notices, channel+"/"+chatID+": "+text)
+		return nil
+	}
+	settings := config.RateLimitConfig{MessagesPerMinute: 1, Burst: 1, Notice: "Slow down"}
+	dropRateLimited := newRateLimitFilter(func() config.RateLimitConfig { return settings }, send)
+
+	msg := &channels.Message{ID: "1", Channel: "telegram", Sender: "7", ChatID: "9"}
+	if dropRateLimited(msg) {
+		t.Fatalf("expected first message to pass")
+	}
+	for i := 0; i < 3; i++ {
+		if !dropRateLimited(msg) {
+			t.Fatalf("expected message %d over the limit to be dropped", i)
+		}
+	}
+	if len(notices) != 1 || notices[0] != "telegram/9: Slow down" {
+		t.Fatalf("expected a single slow-down notice, got %v", notices)
+	}
+
+	// 热加载关闭限流后立即生效
+	settings.MessagesPerMinute = 0
+	for i := 0; i < 10; i++ {
+		if dropRateLimited(msg) {
+			t.Fatalf("expected no rate limiting when disabled")
+		}
+	}
+
+	// 重新开启时按新配置限流
+	settings = config.RateLimitConfig{MessagesPerMinute: 1, Burst: 2}
+	for i := 0; i < 2; i++ {
+		if dropRateLimited(msg) {
+			t.Fatalf("expected message %d within the new burst to pass", i)
+		}
+	}
+	if !dropRateLimited(msg) {
+		t.Fatalf("expected message over the new burst to be dropped")
+	}
+}
+
+func TestWithFallbackModelsSkipsUnconfiguredModels(t *testing.T) {
+	cfg := config.DefaultConfig()
+	primary := providers.NewMockProvider("mock")
+
+	if got := withFallbackModels(cfg, primary); got != providers.LLMProvider(primary) {
+		t.Fatalf("expected primary unchanged without fallback models, got %T", got)
+	}
+
+	cfg.Agents.Defaults.FallbackModels = []string{"deepseek-chat", " ", "mock/backup"}
+	got := withFallbackModels(cfg, primary)
+	if _, ok := got.(*providers.FallbackProvider); !ok {
+		t.Fatalf("expected fallback provider, got %T", got)
+	}
+	if got.GetDefaultModel() != "mock" {
+		t.Fatalf("expected primary default model, got %q", got.GetDefaultModel())
+	}
+
+	cfg.Agents.Defaults.FallbackModels = []string{"deepseek-chat"}
+	if got := withFallbackModels(cfg, primary); got != providers.LLMProvider(primary) {
+		t.Fatalf("expected fallback without API key to be skipped, got %T", got)
+	}
+}
+
+func TestAutoInitWorkspaceRespectsConfigFlag(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = filepath.Join(t.TempDir(), "ws")
+	cfg.Agents.Defaults.AutoInitWorkspace = false
+
+	if err := autoInitWorkspace(cfg); err != nil {
+		t.Fatalf("autoInitWorkspace (disabled): %v", err)
+	}
+	if _, err := os.Stat(cfg.Agents.Defaults.Workspace); !os.IsNotExist(err) {
+		t.Fatalf("expected workspace to stay absent when auto init is disabled, err=%v", err)
+	}
+
+	cfg.Agents.Defaults.AutoInitWorkspace = true
+	if err := autoInitWorkspace(cfg); err != nil {
+		t.Fatalf("autoInitWorkspace: %v", err)
+	}
+	for _, rel := range []string{"AGENTS.md", "SOUL.md", filepath.Join("memory", "MEMORY.md")} {
+		if _, err := os.Stat(filepath.Join(cfg.Agents.Defaults.Workspace, rel)); err != nil {
+			t.Fatalf("expected %s to be created: %v", rel, err)
+		}
+	}
+}
+
+func TestChannelModelOverrides(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Channels.Telegram.Model = "mock/fast"
+	cfg.Channels.Slack.Model = "deepseek-chat"
+
+	models, factory := channelModelOverrides(cfg)
+	if len(models) != 2 || models["telegram"] != "mock/fast" || models["slack"] != "deepseek-chat" {
+		t.Fatalf("unexpected channel models: %v", models)
+	}
+
+	provider, err := factory(models["telegram"])
+	if err != nil || provider == nil {
+		t.Fatalf("expected provider for mock model, got %v, %v", provider, err)
+	}
+	if _, err := factory(models["slack"]); err == nil {
+		t.Fatalf("expected error for model without API key")
+	}
+}
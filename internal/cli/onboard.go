@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/logging"
 	"github.com/Lichas/maxclaw/internal/skills"
 	"github.com/spf13/cobra"
 )
@@ -19,6 +20,10 @@ var onboardCmd = &cobra.Command{
 		// 检查配置文件是否已存在
 		if _, err := os.Stat(configPath); err == nil {
 			fmt.Printf("Config already exists at %s\n", configPath)
+			if _, err := config.LoadConfig(); err != nil {
+				fmt.Println("⚠ Existing config has problems:")
+				printConfigProblems(err)
+			}
 			fmt.Print("Overwrite? (y/N): ")
 			var response string
 			fmt.Scanln(&response)
@@ -29,6 +34,9 @@ var onboardCmd = &cobra.Command{
 
 		// 创建默认配置
 		cfg := config.DefaultConfig()
+		if err := cfg.Validate(); err != nil {
+			return fmt.Errorf("default config is invalid: %w", err)
+		}
 		if err := config.SaveConfig(cfg); err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
 		}
@@ -89,3 +97,28 @@ var onboardCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// autoInitWorkspace 启动时若工作空间不存在则自动创建目录与模板（可通过 agents.defaults.autoInitWorkspace 关闭）
+func autoInitWorkspace(cfg *config.Config) error {
+	if cfg == nil || !cfg.Agents.Defaults.AutoInitWorkspace {
+		return nil
+	}
+
+	workspace := cfg.Agents.Defaults.Workspace
+	created, err := config.InitWorkspace(workspace)
+	if err != nil {
+		return fmt.Errorf("failed to initialize workspace %s: %w", workspace, err)
+	}
+	if len(created) == 0 {
+		return nil
+	}
+
+	fmt.Printf("✓ Initialized workspace at %s\n", workspace)
+	for _, path := range created {
+		fmt.Printf("  Created %s\n", path)
+	}
+	if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+		lg.Gateway.Printf("workspace initialized path=%s created=%v", workspace, created)
+	}
+	return nil
+}
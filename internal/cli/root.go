@@ -3,12 +3,14 @@ package cli
 import (
 	"fmt"
 
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/Lichas/maxclaw/internal/version"
 	"github.com/spf13/cobra"
 )
 
 var (
-	version = "0.1.0"
-	logo    = `🤖`
+	logo = `🤖`
 )
 
 // rootCmd 根命令
@@ -32,20 +34,35 @@ func ExecuteGateway() error {
 func init() {
 	rootCmd.AddCommand(onboardCmd)
 	rootCmd.AddCommand(agentCmd)
+	rootCmd.AddCommand(chatCmd)
 	rootCmd.AddCommand(browserCmd)
 	rootCmd.AddCommand(gatewayCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(spawnsCmd)
 	rootCmd.AddCommand(whatsappCmd)
 	rootCmd.AddCommand(telegramCmd)
 	rootCmd.AddCommand(skillsCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
+// initLogging 初始化 ~/.maxclaw/logs 下的日志文件，失败时只打印警告、不中断命令。
+// 运行 agent 或修改状态的命令（agent、chat、gateway、cron add/remove/enable/disable/run）需要调用；
+// 只读命令（status、cron list/status 等）不写日志
+func initLogging() {
+	if _, err := logging.Init(config.GetDataDir()); err != nil {
+		fmt.Printf("⚠ logging init error: %v\n", err)
+	}
+}
+
 // versionCmd 版本命令
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print version information",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("%s maxclaw v%s\n", logo, version)
+		info := version.Get()
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "%s maxclaw %s\n", logo, info.Version)
+		fmt.Fprintf(out, "Commit: %s\n", info.Commit)
+		fmt.Fprintf(out, "Built: %s\n", info.BuildDate)
 	},
 }
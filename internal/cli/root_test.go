@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionCommandPrintsInjectedBuildInfo(t *testing.T) {
+	origVersion, origCommit, origDate := version.Version, version.Commit, version.BuildDate
+	defer func() {
+		version.Version, version.Commit, version.BuildDate = origVersion, origCommit, origDate
+	}()
+	// 模拟 -ldflags "-X .../internal/version.Version=..." 注入的值
+	version.Version = "v1.2.3"
+	version.Commit = "abc1234"
+	version.BuildDate = "2026-01-02T03:04:05Z"
+
+	var out bytes.Buffer
+	rootCmd.SetOut(&out)
+	rootCmd.SetArgs([]string{"version"})
+	defer func() {
+		rootCmd.SetOut(nil)
+		rootCmd.SetArgs(nil)
+	}()
+	require.NoError(t, rootCmd.Execute())
+
+	assert.Contains(t, out.String(), "maxclaw v1.2.3")
+	assert.Contains(t, out.String(), "Commit: abc1234")
+	assert.Contains(t, out.String(), "Built: 2026-01-02T03:04:05Z")
+	assert.Equal(t, "v1.2.3 (commit abc1234, built 2026-01-02T03:04:05Z)", version.Get().String())
+}
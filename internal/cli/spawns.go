@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+// spawnsCmd 查看网关中正在运行的后台子任务
+var spawnsCmd = &cobra.Command{
+	Use:   "spawns",
+	Short: "List running spawn subagent tasks in the gateway",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+
+		tasks, err := fetchSpawnTasks(cfg)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			fmt.Println("No running spawn tasks")
+			return nil
+		}
+
+		fmt.Printf("%-26s %-20s %-10s %-10s %s\n", "ID", "LABEL", "STATUS", "ELAPSED", "SESSION")
+		for _, task := range tasks {
+			elapsed := time.Since(task.StartTime).Truncate(time.Second)
+			fmt.Printf("%-26s %-20s %-10s %-10s %s\n", task.ID, task.Label, task.Status, elapsed, task.SessionKey)
+		}
+		return nil
+	},
+}
+
+// gatewayBaseURL 返回本机访问网关的地址（监听 0.0.0.0 时改用 127.0.0.1）
+func gatewayBaseURL(cfg *config.Config) string {
+	host := strings.TrimSpace(cfg.Gateway.Host)
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(cfg.Gateway.Port))
+}
+
+func fetchSpawnTasks(cfg *config.Config) ([]tools.SpawnTask, error) {
+	req, err := http.NewRequest(http.MethodGet, gatewayBaseURL(cfg)+"/api/spawns", nil)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Gateway.AuthToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Gateway.AuthToken)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach gateway (is `maxclaw gateway` running?): %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("gateway returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Tasks []tools.SpawnTask `json:"tasks"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode spawn tasks: %w", err)
+	}
+	return body.Tasks, nil
+}
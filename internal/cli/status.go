@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/version"
 	"github.com/spf13/cobra"
 )
 
@@ -15,17 +18,25 @@ var statusCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		configPath := config.GetConfigPath()
 		cfg, err := config.LoadConfig()
-		if err != nil {
+		if err != nil && !config.IsValidationError(err) {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		validationErr := err
 
 		fmt.Printf("%s maxclaw Status\n\n", logo)
+		fmt.Printf("Version: %s\n", version.Get())
 
 		// 配置文件状态
-		if _, err := os.Stat(configPath); err == nil {
-			fmt.Printf("Config: %s ✓\n", configPath)
-		} else {
+		if _, err := os.Stat(configPath); err != nil {
 			fmt.Printf("Config: %s ✗ (not found)\n", configPath)
+		} else if validationErr != nil {
+			fmt.Printf("Config: %s ✗ (invalid)\n", configPath)
+			printConfigProblems(validationErr)
+		} else {
+			fmt.Printf("Config: %s ✓\n", configPath)
+		}
+		for _, warning := range cfg.Warnings() {
+			fmt.Printf("  ⚠ %s\n", warning)
 		}
 
 		// 工作空间状态
@@ -37,29 +48,18 @@ var statusCmd = &cobra.Command{
 		}
 
 		// 模型配置
-		fmt.Printf("Model: %s\n", cfg.Agents.Defaults.Model)
-		fmt.Printf("Execution Mode: %s\n", cfg.Agents.Defaults.ExecutionMode)
-
-		// API Key 状态
-		fmt.Printf("OpenRouter API: ")
-		if cfg.Providers.OpenRouter.APIKey != "" {
-			fmt.Println("✓")
+		if resolved := cfg.ResolveModel(""); resolved != cfg.Agents.Defaults.Model {
+			fmt.Printf("Model: %s (→ %s)\n", cfg.Agents.Defaults.Model, resolved)
 		} else {
-			fmt.Println("✗ (not set)")
-		}
-
-		fmt.Printf("Anthropic API: ")
-		if cfg.Providers.Anthropic.APIKey != "" {
-			fmt.Println("✓")
-		} else {
-			fmt.Println("✗ (not set)")
+			fmt.Printf("Model: %s\n", resolved)
 		}
+		fmt.Printf("Execution Mode: %s\n", cfg.Agents.Defaults.ExecutionMode)
 
-		fmt.Printf("OpenAI API: ")
-		if cfg.Providers.OpenAI.APIKey != "" {
-			fmt.Println("✓")
+		// API Key 状态
+		if configured := cfg.ConfiguredProviders(); len(configured) > 0 {
+			fmt.Printf("Providers: %s ✓\n", strings.Join(configured, ", "))
 		} else {
-			fmt.Println("✗ (not set)")
+			fmt.Println("Providers: ✗ (no API key set)")
 		}
 
 		// 频道状态
@@ -108,3 +108,15 @@ var statusCmd = &cobra.Command{
 		return nil
 	},
 }
+
+// printConfigProblems 逐条列出配置校验问题
+func printConfigProblems(err error) {
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) {
+		fmt.Printf("  %v\n", err)
+		return
+	}
+	for _, problem := range verr.Problems {
+		fmt.Printf("  - %s\n", problem)
+	}
+}
@@ -3,7 +3,9 @@ package cli
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -14,14 +16,35 @@ import (
 )
 
 var (
-	whatsappBridgeFlag string
-	whatsappTimeoutSec int
+	whatsappBridgeFlag    string
+	whatsappTimeoutSec    int
+	whatsappRetryInterval time.Duration
+
+	whatsappStatusBridge     string
+	whatsappStatusTimeoutSec int
+)
+
+const (
+	// whatsappMaxRetryInterval 连接 bridge 重试退避的上限
+	whatsappMaxRetryInterval = 15 * time.Second
+	// whatsappProgressInterval 等待期间输出进度提示的间隔
+	whatsappProgressInterval = 15 * time.Second
+	// whatsappStatusSettle 收到状态帧后继续等待紧随其后的 QR 帧的时间
+	whatsappStatusSettle = 300 * time.Millisecond
 )
 
+// errBridgeUnreachable 在超时内始终无法连上 bridge
+var errBridgeUnreachable = errors.New("bridge unreachable")
+
 func init() {
 	whatsappCmd.AddCommand(whatsappBindCmd)
 	whatsappBindCmd.Flags().StringVar(&whatsappBridgeFlag, "bridge", "", "Bridge WebSocket URL (default from config)")
 	whatsappBindCmd.Flags().IntVar(&whatsappTimeoutSec, "timeout", 180, "Timeout seconds to wait for QR/connection")
+	whatsappBindCmd.Flags().DurationVar(&whatsappRetryInterval, "retry-interval", 2*time.Second, "Initial delay between bridge connection retries (doubles up to 15s)")
+
+	whatsappCmd.AddCommand(whatsappStatusCmd)
+	whatsappStatusCmd.Flags().StringVar(&whatsappStatusBridge, "bridge", "", "Bridge WebSocket URL (default from config)")
+	whatsappStatusCmd.Flags().IntVar(&whatsappStatusTimeoutSec, "timeout", 10, "Timeout seconds to wait for the bridge status")
 }
 
 var whatsappCmd = &cobra.Command{
@@ -38,38 +61,26 @@ var whatsappBindCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		bridgeURL := whatsappBridgeFlag
-		if bridgeURL == "" {
-			bridgeURL = cfg.Channels.WhatsApp.BridgeURL
-		}
-		if bridgeURL == "" {
-			bridgeURL = "ws://localhost:3001"
-		}
+		bridgeURL := resolveBridgeURL(whatsappBridgeFlag, cfg)
 
 		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(whatsappTimeoutSec)*time.Second)
 		defer cancel()
 
 		fmt.Printf("%s WhatsApp bridge: %s\n", logo, bridgeURL)
-		fmt.Println("Waiting for QR code...")
+		fmt.Println("Connecting to bridge...")
 
-		conn, _, err := websocket.DefaultDialer.DialContext(ctx, bridgeURL, nil)
+		conn, err := dialBridgeWithRetry(ctx, bridgeURL, whatsappRetryInterval, whatsappMaxRetryInterval, cmd.OutOrStdout())
 		if err != nil {
-			return fmt.Errorf("failed to connect to bridge: %w", err)
+			if errors.Is(err, errBridgeUnreachable) {
+				return fmt.Errorf("%w (is the bridge running? start it with `make bridge-run` or check channels.whatsapp.bridgeUrl)", err)
+			}
+			return err
 		}
 		defer conn.Close()
+		fmt.Println("Waiting for QR code...")
 
-		if token := strings.TrimSpace(cfg.Channels.WhatsApp.BridgeToken); token != "" {
-			authPayload := map[string]string{
-				"type":  "auth",
-				"token": token,
-			}
-			authData, err := json.Marshal(authPayload)
-			if err != nil {
-				return fmt.Errorf("failed to encode bridge auth payload: %w", err)
-			}
-			if err := conn.WriteMessage(websocket.TextMessage, authData); err != nil {
-				return fmt.Errorf("failed to send bridge auth: %w", err)
-			}
+		if err := sendBridgeAuth(conn, cfg.Channels.WhatsApp.BridgeToken); err != nil {
+			return err
 		}
 
 		msgCh := make(chan bridgeEvent)
@@ -82,19 +93,33 @@ var whatsappBindCmd = &cobra.Command{
 					errCh <- err
 					return
 				}
-				var msg bridgeEvent
-				if err := json.Unmarshal(data, &msg); err != nil {
+				msg, err := parseBridgeEvent(data)
+				if err != nil {
 					continue
 				}
 				msgCh <- msg
 			}
 		}()
 
+		started := time.Now()
+		progress := time.NewTicker(whatsappProgressInterval)
+		defer progress.Stop()
+
 		lastQR := ""
 		for {
 			select {
 			case <-ctx.Done():
-				return fmt.Errorf("timeout waiting for QR/connection")
+				if lastQR == "" {
+					return fmt.Errorf("timed out after %ds: connected to bridge but no QR code arrived (the bridge may be stuck or already linked)", whatsappTimeoutSec)
+				}
+				return fmt.Errorf("timed out after %ds waiting for the QR code to be scanned", whatsappTimeoutSec)
+			case <-progress.C:
+				elapsed := time.Since(started).Round(time.Second)
+				if lastQR == "" {
+					fmt.Printf("Still waiting for QR code... (%s elapsed)\n", elapsed)
+				} else {
+					fmt.Printf("Still waiting for scan... (%s elapsed)\n", elapsed)
+				}
 			case err := <-errCh:
 				return fmt.Errorf("bridge connection error: %w", err)
 			case msg := <-msgCh:
@@ -129,9 +154,240 @@ var whatsappBindCmd = &cobra.Command{
 	},
 }
 
+var whatsappStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the WhatsApp bridge is connected",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		bridgeURL := resolveBridgeURL(whatsappStatusBridge, cfg)
+
+		timeout := time.Duration(whatsappStatusTimeoutSec) * time.Second
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Bridge: %s\n", bridgeURL)
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, bridgeURL, nil)
+		if err != nil {
+			fmt.Fprintln(cmd.OutOrStdout(), "WhatsApp: unknown (bridge unreachable)")
+			return fmt.Errorf("%w at %s: %v (is the bridge running? start it with `make bridge-run`)", errBridgeUnreachable, bridgeURL, err)
+		}
+		defer conn.Close()
+
+		if err := sendBridgeAuth(conn, cfg.Channels.WhatsApp.BridgeToken); err != nil {
+			return err
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"status"}`)); err != nil {
+			return fmt.Errorf("failed to request bridge status: %w", err)
+		}
+
+		status, err := readBridgeStatus(ctx, conn, whatsappStatusSettle)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(cmd.OutOrStdout(), status.format(time.Now()))
+		return nil
+	},
+}
+
+func resolveBridgeURL(flag string, cfg *config.Config) string {
+	if bridgeURL := strings.TrimSpace(flag); bridgeURL != "" {
+		return bridgeURL
+	}
+	if bridgeURL := strings.TrimSpace(cfg.Channels.WhatsApp.BridgeURL); bridgeURL != "" {
+		return bridgeURL
+	}
+	return "ws://localhost:3001"
+}
+
+// sendBridgeAuth 配置了 bridgeToken 时发送鉴权握手（必须是连接后的第一帧）
+func sendBridgeAuth(conn *websocket.Conn, token string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil
+	}
+	authData, err := json.Marshal(map[string]string{
+		"type":  "auth",
+		"token": token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode bridge auth payload: %w", err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, authData); err != nil {
+		return fmt.Errorf("failed to send bridge auth: %w", err)
+	}
+	return nil
+}
+
+// readBridgeStatus 读取 bridge 帧直到拿到状态帧；之后再等待 settle，收集紧随其后的 QR 帧
+func readBridgeStatus(ctx context.Context, conn *websocket.Conn, settle time.Duration) (bridgeStatus, error) {
+	var status bridgeStatus
+	deadline, _ := ctx.Deadline()
+	for {
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return status, err
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if status.Status != "" {
+				// 状态帧已收到，settle 到期属于正常结束
+				return status, nil
+			}
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) && (closeErr.Code == 4001 || closeErr.Code == 4003) {
+				return status, fmt.Errorf("bridge rejected the connection (%s); check channels.whatsapp.bridgeToken", closeErr.Text)
+			}
+			if ctx.Err() != nil || isTimeout(err) {
+				return status, fmt.Errorf("timed out waiting for bridge status (the bridge has not reported a WhatsApp status yet)")
+			}
+			return status, fmt.Errorf("bridge connection error: %w", err)
+		}
+		event, err := parseBridgeEvent(data)
+		if err != nil {
+			continue
+		}
+		hadStatus := status.Status != ""
+		status.apply(event)
+		if !hadStatus && status.Status != "" {
+			if settleDeadline := time.Now().Add(settle); deadline.IsZero() || settleDeadline.Before(deadline) {
+				deadline = settleDeadline
+			}
+		}
+	}
+}
+
+func isTimeout(err error) bool {
+	var netErr interface{ Timeout() bool }
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// dialBridgeWithRetry 在 ctx 超时前按指数退避反复连接 bridge
+func dialBridgeWithRetry(ctx context.Context, bridgeURL string, initial, max time.Duration, out io.Writer) (*websocket.Conn, error) {
+	if initial <= 0 {
+		initial = time.Second
+	}
+	if max < initial {
+		max = initial
+	}
+
+	backoff := initial
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, bridgeURL, nil)
+		if err == nil {
+			if attempt > 1 {
+				fmt.Fprintf(out, "Connected to bridge after %d attempts.\n", attempt)
+			}
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w at %s after %d attempts: %v", errBridgeUnreachable, bridgeURL, attempt, lastErr)
+		}
+
+		fmt.Fprintf(out, "Bridge not reachable (attempt %d): %v; retrying in %s...\n", attempt, err, backoff)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w at %s after %d attempts: %v", errBridgeUnreachable, bridgeURL, attempt, lastErr)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > max {
+			backoff = max
+		}
+	}
+}
+
 type bridgeEvent struct {
 	Type   string `json:"type"`
 	Status string `json:"status"`
 	QR     string `json:"qr"`
 	Error  string `json:"error"`
+	// At 事件发生时间（Unix 毫秒），旧版 bridge 不提供
+	At int64 `json:"at,omitempty"`
+	// QRAt 状态快照中最近一次 QR 的时间（Unix 毫秒）
+	QRAt int64 `json:"qrAt,omitempty"`
+}
+
+func parseBridgeEvent(data []byte) (bridgeEvent, error) {
+	var event bridgeEvent
+	if err := json.Unmarshal(data, &event); err != nil {
+		return bridgeEvent{}, err
+	}
+	return event, nil
+}
+
+// bridgeStatus whatsapp status 汇总的 bridge 状态
+type bridgeStatus struct {
+	Status   string
+	StatusAt time.Time
+	HasQR    bool
+	QRAt     time.Time
+}
+
+// apply 合并一个 bridge 帧；时间字段缺失时保留零值（输出为未知）
+func (s *bridgeStatus) apply(event bridgeEvent) {
+	switch event.Type {
+	case "status":
+		s.Status = event.Status
+		s.StatusAt = unixMillisTime(event.At)
+		if event.QRAt > 0 {
+			s.HasQR = true
+			s.QRAt = unixMillisTime(event.QRAt)
+		}
+	case "qr":
+		if event.QR != "" || event.At > 0 {
+			s.HasQR = true
+			if at := unixMillisTime(event.At); !at.IsZero() {
+				s.QRAt = at
+			}
+		}
+	}
+}
+
+func (s bridgeStatus) connected() bool {
+	return s.Status == "connected"
+}
+
+func (s bridgeStatus) format(now time.Time) string {
+	var b strings.Builder
+	state := "disconnected"
+	if s.connected() {
+		state = "connected"
+	}
+	if s.Status != "" && s.Status != state {
+		state += " (" + s.Status + ")"
+	}
+	fmt.Fprintf(&b, "WhatsApp: %s", state)
+	if !s.StatusAt.IsZero() {
+		fmt.Fprintf(&b, ", since %s ago", now.Sub(s.StatusAt).Round(time.Second))
+	}
+	b.WriteString("\n")
+	switch {
+	case !s.HasQR:
+		b.WriteString("Last QR: none\n")
+	case s.QRAt.IsZero():
+		b.WriteString("Last QR: received (age unknown)\n")
+	default:
+		fmt.Fprintf(&b, "Last QR: %s ago\n", now.Sub(s.QRAt).Round(time.Second))
+	}
+	if !s.connected() {
+		b.WriteString("Run `maxclaw whatsapp bind` to link a device.\n")
+	}
+	return b.String()
+}
+
+func unixMillisTime(ms int64) time.Time {
+	if ms <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
 }
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDialBridgeWithRetryRecoversAfterFailures(t *testing.T) {
+	var attempts int32
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			http.Error(w, "bridge starting", http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"qr","qr":"abc"}`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var out bytes.Buffer
+	conn, err := dialBridgeWithRetry(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), 10*time.Millisecond, 20*time.Millisecond, &out)
+	if err != nil {
+		t.Fatalf("dialBridgeWithRetry failed: %v", err)
+	}
+	defer conn.Close()
+
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+	if !strings.Contains(out.String(), "attempt 2") || !strings.Contains(out.String(), "after 3 attempts") {
+		t.Fatalf("unexpected retry output: %q", out.String())
+	}
+	if _, data, err := conn.ReadMessage(); err != nil || !strings.Contains(string(data), `"qr"`) {
+		t.Fatalf("unexpected bridge message %q: %v", data, err)
+	}
+}
+
+func TestDialBridgeWithRetryReportsUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var out bytes.Buffer
+	_, err := dialBridgeWithRetry(ctx, url, 10*time.Millisecond, 20*time.Millisecond, &out)
+	if !errors.Is(err, errBridgeUnreachable) {
+		t.Fatalf("expected errBridgeUnreachable, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Bridge not reachable (attempt 1)") {
+		t.Fatalf("expected retry output, got %q", out.String())
+	}
+}
+
+func TestBridgeStatusAppliesStatusAndQRFrames(t *testing.T) {
+	now := time.UnixMilli(1700000300000)
+	var status bridgeStatus
+	for _, frame := range []string{
+		`{"type":"status","status":"disconnected","at":1700000000000}`,
+		`{"type":"qr","qr":"2@abc","at":1700000240000}`,
+		`{"type":"message","id":"m1"}`,
+		`{"type":"status","status":"connected","at":1700000270000}`,
+	} {
+		event, err := parseBridgeEvent([]byte(frame))
+		if err != nil {
+			t.Fatalf("parseBridgeEvent(%s): %v", frame, err)
+		}
+		status.apply(event)
+	}
+	if _, err := parseBridgeEvent([]byte("not json")); err == nil {
+		t.Fatal("expected error for malformed frame")
+	}
+
+	if !status.connected() {
+		t.Fatalf("expected connected status, got %+v", status)
+	}
+	got := status.format(now)
+	for _, want := range []string{"WhatsApp: connected, since 30s ago", "Last QR: 1m0s ago"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "whatsapp bind") {
+		t.Fatalf("connected status should not suggest binding:\n%s", got)
+	}
+}
+
+func TestBridgeStatusSnapshotAndLegacyFrames(t *testing.T) {
+	now := time.UnixMilli(1700000300000)
+
+	var snapshot bridgeStatus
+	event, err := parseBridgeEvent([]byte(`{"type":"status","status":"disconnected","at":1700000290000,"qrAt":1700000000000}`))
+	if err != nil {
+		t.Fatalf("parseBridgeEvent: %v", err)
+	}
+	snapshot.apply(event)
+	got := snapshot.format(now)
+	for _, want := range []string{"WhatsApp: disconnected, since 10s ago", "Last QR: 5m0s ago", "maxclaw whatsapp bind"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output:\n%s", want, got)
+		}
+	}
+
+	// 旧版 bridge 的帧没有时间戳
+	var legacy bridgeStatus
+	for _, frame := range []string{`{"type":"status","status":"connecting"}`, `{"type":"qr","qr":"2@abc"}`} {
+		event, _ := parseBridgeEvent([]byte(frame))
+		legacy.apply(event)
+	}
+	got = legacy.format(now)
+	for _, want := range []string{"WhatsApp: disconnected (connecting)\n", "Last QR: received (age unknown)"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("expected %q in output:\n%s", want, got)
+		}
+	}
+
+	if got := (bridgeStatus{Status: "unknown"}).format(now); !strings.Contains(got, "Last QR: none") {
+		t.Fatalf("expected no QR in output:\n%s", got)
+	}
+}
+
+func TestReadBridgeStatusFromBridge(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, req, err := conn.ReadMessage()
+		if err != nil || !strings.Contains(string(req), `"status"`) {
+			return
+		}
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"status","status":"connected","at":1700000000000}`))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"qr","qr":"2@abc","at":1699999000000}`))
+		// 保持连接，直到客户端读取超时后关闭
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"status"}`)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	started := time.Now()
+	status, err := readBridgeStatus(ctx, conn, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("readBridgeStatus: %v", err)
+	}
+	if !status.connected() || !status.HasQR || status.QRAt.UnixMilli() != 1699999000000 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Fatalf("expected to return after the settle window, took %s", elapsed)
+	}
+}
+
+func TestReadBridgeStatusTimesOutWithoutStatus(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _, _ = conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = readBridgeStatus(ctx, conn, 50*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
@@ -19,6 +19,7 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, 0.7, cfg.Agents.Defaults.Temperature)
 	assert.Equal(t, 200, cfg.Agents.Defaults.MaxToolIterations)
 	assert.Equal(t, ExecutionModeAsk, cfg.Agents.Defaults.ExecutionMode)
+	assert.True(t, cfg.Agents.Defaults.AutoInitWorkspace)
 
 	assert.Equal(t, "0.0.0.0", cfg.Gateway.Host)
 	assert.Equal(t, 18890, cfg.Gateway.Port)
@@ -29,12 +30,14 @@ func TestDefaultConfig(t *testing.T) {
 	assert.Equal(t, 600, cfg.Tools.Web.Fetch.RenderWaitMs)
 	assert.Equal(t, 4000, cfg.Tools.Web.Fetch.SmartWaitMs)
 	assert.Equal(t, 500, cfg.Tools.Web.Fetch.StableWaitMs)
+	assert.False(t, cfg.Tools.Web.Fetch.HTTPFallback)
 	assert.Equal(t, "chrome", cfg.Tools.Web.Fetch.Chrome.ProfileName)
 	assert.Equal(t, "chrome", cfg.Tools.Web.Fetch.Chrome.Channel)
 	assert.True(t, cfg.Tools.Web.Fetch.Chrome.Headless)
 	assert.True(t, cfg.Tools.Web.Fetch.Chrome.AutoStartCDP)
 	assert.Equal(t, 15000, cfg.Tools.Web.Fetch.Chrome.LaunchTimeoutMs)
 	assert.Equal(t, 60, cfg.Tools.Exec.Timeout)
+	assert.Equal(t, 300, cfg.Tools.Exec.MaxTimeout)
 	assert.Empty(t, cfg.Tools.MCPServers)
 
 	assert.False(t, cfg.Channels.Slack.Enabled)
@@ -81,6 +84,52 @@ func TestGetAPIKeyNoKeys(t *testing.T) {
 	assert.Empty(t, cfg.GetAPIKey("any-model"))
 }
 
+func TestConfiguredProviders(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Empty(t, cfg.ConfiguredProviders())
+
+	cfg.Providers.OpenAI.APIKey = "openai-key"
+	cfg.Providers.DeepSeek.APIKey = "deepseek-key"
+	cfg.Providers.Groq.APIKey = "   "
+	cfg.Providers.VLLM.APIBase = "http://localhost:8000/v1"
+	assert.Equal(t, []string{"openai", "deepseek"}, cfg.ConfiguredProviders())
+
+	cfg.Providers.OpenRouter.APIKey = "openrouter-key"
+	cfg.Providers.OpenAI.APIKey = ""
+	assert.Equal(t, []string{"openrouter", "deepseek"}, cfg.ConfiguredProviders())
+}
+
+func TestResolveModelUsesProviderDefault(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Providers.Anthropic.APIKey = "anthropic-key"
+	cfg.Providers.DeepSeek.APIKey = "deepseek-key"
+	cfg.Providers.DeepSeek.DefaultModel = "deepseek-reasoner"
+	cfg.Providers.Groq.APIKey = "groq-key"
+	cfg.Providers.Groq.DefaultModel = "llama-3.3-70b-versatile"
+
+	// 完整模型名保持不变
+	assert.Equal(t, "anthropic/claude-opus-4-1", cfg.ResolveModel("anthropic/claude-opus-4-1"))
+
+	// 只给出提供商时使用配置的 defaultModel，否则使用内置默认
+	assert.Equal(t, "deepseek-reasoner", cfg.ResolveModel("deepseek"))
+	assert.Equal(t, "claude-sonnet-4-5", cfg.ResolveModel("Anthropic"))
+	assert.Equal(t, "anthropic-key", cfg.GetAPIKey("anthropic"))
+	assert.Equal(t, "anthropic", cfg.GetAPIFormat("anthropic"))
+
+	// 无法识别提供商的默认模型会加上前缀以便路由
+	assert.Equal(t, "groq/llama-3.3-70b-versatile", cfg.ResolveModel("groq"))
+	assert.Equal(t, "groq-key", cfg.GetAPIKey("groq"))
+
+	// 没有默认模型的提供商原样返回
+	assert.Equal(t, "vllm", cfg.ResolveModel("vllm"))
+
+	// 空值解析 agents.defaults.model
+	cfg.Agents.Defaults.Model = "deepseek"
+	assert.Equal(t, "deepseek-reasoner", cfg.ResolveModel(""))
+	assert.Equal(t, "deepseek-key", cfg.GetAPIKey(""))
+	assert.Equal(t, "https://api.deepseek.com/v1", cfg.GetAPIBase(""))
+}
+
 func TestGetAPIBase(t *testing.T) {
 	cfg := DefaultConfig()
 	cfg.Providers.VLLM.APIBase = "http://localhost:8000/v1"
@@ -168,6 +217,7 @@ func TestGetAPIFormat(t *testing.T) {
 	assert.Equal(t, "anthropic", cfg.GetAPIFormat("anthropic/claude-sonnet-4-5"))
 	assert.Equal(t, "openai", cfg.GetAPIFormat("gpt-5.1"))
 	assert.Equal(t, "openai", cfg.GetAPIFormat("openrouter/auto"))
+	assert.Equal(t, "gemini", cfg.GetAPIFormat("gemini-2.5-flash"))
 }
 
 func TestWorkspacePath(t *testing.T) {
@@ -256,6 +306,29 @@ func TestLoadConfigNormalizesExecutionMode(t *testing.T) {
 	assert.Equal(t, ExecutionModeAsk, loaded.Agents.Defaults.ExecutionMode)
 }
 
+func TestLoadConfigNormalizesToolNotices(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	configDir := GetConfigDir()
+	require.NoError(t, os.MkdirAll(configDir, 0755))
+
+	raw := `{"channels":{"toolNotices":"Verbose"}}`
+	require.NoError(t, os.WriteFile(GetConfigPath(), []byte(raw), 0600))
+
+	loaded, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, ToolNoticesVerbose, loaded.Channels.ToolNotices)
+
+	raw = `{"channels":{"toolNotices":"loud"}}`
+	require.NoError(t, os.WriteFile(GetConfigPath(), []byte(raw), 0600))
+	loaded, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, ToolNoticesOff, loaded.Channels.ToolNotices)
+}
+
 func TestLoadConfigMCPServersCompatibility(t *testing.T) {
 	tmpDir := t.TempDir()
 	origHome := os.Getenv("HOME")
@@ -342,3 +415,227 @@ func TestCreateWorkspaceTemplates(t *testing.T) {
 	assert.FileExists(t, filepath.Join(workspace, "memory", "HISTORY.md"))
 	assert.FileExists(t, filepath.Join(workspace, "memory", "heartbeat.md"))
 }
+
+func TestInitWorkspaceCreatesTemplatesWhenMissing(t *testing.T) {
+	workspace := filepath.Join(t.TempDir(), "fresh-workspace")
+
+	created, err := InitWorkspace(workspace)
+	require.NoError(t, err)
+
+	for _, rel := range []string{
+		"AGENTS.md",
+		"SOUL.md",
+		"USER.md",
+		"skills/README.md",
+		"skills/example/SKILL.md",
+		"memory/MEMORY.md",
+		"memory/HISTORY.md",
+		"memory/heartbeat.md",
+	} {
+		assert.FileExists(t, filepath.Join(workspace, filepath.FromSlash(rel)))
+		assert.Contains(t, created, rel)
+	}
+
+	// 已存在的工作空间不会被再次初始化或覆盖
+	agentsPath := filepath.Join(workspace, "AGENTS.md")
+	require.NoError(t, os.WriteFile(agentsPath, []byte("custom"), 0644))
+	created, err = InitWorkspace(workspace)
+	require.NoError(t, err)
+	assert.Empty(t, created)
+	data, err := os.ReadFile(agentsPath)
+	require.NoError(t, err)
+	assert.Equal(t, "custom", string(data))
+}
+
+func TestApplyProfileOverlaysNamedProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	require.NoError(t, os.MkdirAll(GetConfigDir(), 0755))
+	raw := `{
+		"agents": {
+			"defaults": {"model": "anthropic/claude-opus-4-5", "workspace": "~/main", "maxTokens": 4096},
+			"profiles": {
+				"coder": {"model": "deepseek-chat", "workspace": "~/code", "executionMode": "AUTO"},
+				"research": {"temperature": 0.2}
+			}
+		},
+		"providers": {
+			"anthropic": {"apiKey": "sk-ant"},
+			"deepseek": {"apiKey": "sk-ds", "apiBase": "https://ds.example/v1"}
+		}
+	}`
+	require.NoError(t, os.WriteFile(GetConfigPath(), []byte(raw), 0600))
+
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"coder", "research"}, cfg.Agents.ProfileNames())
+
+	require.NoError(t, cfg.ApplyProfile("coder"))
+	assert.Equal(t, "deepseek-chat", cfg.Agents.Defaults.Model)
+	assert.Equal(t, filepath.Join(tmpDir, "code"), cfg.Agents.Defaults.Workspace)
+	assert.Equal(t, ExecutionModeAuto, cfg.Agents.Defaults.ExecutionMode)
+	// 配置档未设置的字段沿用 defaults
+	assert.Equal(t, 4096, cfg.Agents.Defaults.MaxTokens)
+	assert.Equal(t, 200, cfg.Agents.Defaults.MaxToolIterations)
+	assert.Equal(t, "sk-ds", cfg.GetAPIKey(""))
+	assert.Equal(t, "https://ds.example/v1", cfg.GetAPIBase(""))
+}
+
+func TestApplyProfileFallsBackToDefaults(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Agents.Defaults.Model = "anthropic/claude-opus-4-5"
+	cfg.Providers.Anthropic.APIKey = "sk-ant"
+	cfg.Agents.Profiles = map[string]AgentDefaults{"research": {Temperature: 0.2}}
+	before := cfg.Agents.Defaults
+
+	require.NoError(t, cfg.ApplyProfile(""))
+	assert.Equal(t, before, cfg.Agents.Defaults)
+
+	require.NoError(t, cfg.ApplyProfile("research"))
+	assert.Equal(t, 0.2, cfg.Agents.Defaults.Temperature)
+	assert.Equal(t, before.Model, cfg.Agents.Defaults.Model)
+	assert.Equal(t, "sk-ant", cfg.GetAPIKey(""))
+
+	err := cfg.ApplyProfile("missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "available: research")
+	assert.Equal(t, 0.2, cfg.Agents.Defaults.Temperature, "failed lookup must not modify defaults")
+}
+
+func TestLoadConfigEnvOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	require.NoError(t, os.MkdirAll(GetConfigDir(), 0755))
+	raw := `{"providers":{"openrouter":{"apiKey":"file-key"},"openai":{"apiKey":"file-openai"}},` +
+		`"channels":{"telegram":{"token":"file-token"}},"tools":{"web":{"search":{"apiKey":"file-brave"}}}}`
+	require.NoError(t, os.WriteFile(GetConfigPath(), []byte(raw), 0600))
+
+	// 没有环境变量时保持文件中的值
+	loaded, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "file-key", loaded.Providers.OpenRouter.APIKey)
+	assert.Empty(t, loaded.EnvOverrides())
+
+	t.Setenv("MAXCLAW_OPENROUTER_APIKEY", "env-key")
+	t.Setenv("NANOBOT_OPENROUTER_APIKEY", "legacy-key")
+	t.Setenv("NANOBOT_TELEGRAM_TOKEN", "env-token")
+	t.Setenv("MAXCLAW_BRAVE_APIKEY", " env-brave ")
+	t.Setenv("MAXCLAW_DEEPSEEK_APIKEY", "env-deepseek")
+
+	loaded, err = LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", loaded.Providers.OpenRouter.APIKey, "MAXCLAW_ prefix wins over NANOBOT_")
+	assert.Equal(t, "file-openai", loaded.Providers.OpenAI.APIKey)
+	assert.Equal(t, "env-deepseek", loaded.Providers.DeepSeek.APIKey)
+	assert.Equal(t, "env-token", loaded.Channels.Telegram.Token)
+	assert.Equal(t, "env-brave", loaded.Tools.Web.Search.APIKey)
+	assert.ElementsMatch(t, []string{"BRAVE_APIKEY", "TELEGRAM_TOKEN", "OPENROUTER_APIKEY", "DEEPSEEK_APIKEY"}, loaded.EnvOverrides())
+
+	// 保存时不把环境变量中的密钥写进文件，但保留显式修改
+	loaded.Channels.Telegram.Token = "new-file-token"
+	require.NoError(t, SaveConfig(loaded))
+	data, err := os.ReadFile(GetConfigPath())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"file-key"`)
+	assert.Contains(t, string(data), `"new-file-token"`)
+	assert.NotContains(t, string(data), "env-key")
+	assert.NotContains(t, string(data), "env-deepseek")
+	assert.Equal(t, "env-key", loaded.Providers.OpenRouter.APIKey)
+}
+
+func TestLoadConfigEnvOverridesWithoutConfigFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	t.Setenv("MAXCLAW_ANTHROPIC_APIKEY", "env-anthropic")
+	cfg, err := LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "env-anthropic", cfg.Providers.Anthropic.APIKey)
+	assert.Equal(t, []string{"anthropic"}, cfg.ConfiguredProviders())
+}
+
+func TestTelegramMediaDownloadDefaults(t *testing.T) {
+	cfg := TelegramConfig{}
+	assert.True(t, cfg.MediaDownloadEnabled())
+	assert.Equal(t, int64(0), cfg.MaxMediaBytes())
+
+	disabled := false
+	cfg.DownloadMedia = &disabled
+	cfg.MaxMediaMB = 5
+	assert.False(t, cfg.MediaDownloadEnabled())
+	assert.Equal(t, int64(5*1024*1024), cfg.MaxMediaBytes())
+}
+
+func TestChannelsConfigModels(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Channels.Telegram.Model = " deepseek-chat "
+	cfg.Channels.Slack.Model = "anthropic/claude-haiku-4-5"
+
+	assert.Equal(t, map[string]string{"telegram": "deepseek-chat", "slack": "anthropic/claude-haiku-4-5"}, cfg.Channels.Models())
+}
+
+func TestCheckModelProvider(t *testing.T) {
+	t.Run("provider key missing while another key is set", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Agents.Defaults.Model = "deepseek-chat"
+		cfg.Providers.OpenAI.APIKey = "sk-openai"
+
+		err := cfg.CheckModelProvider("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `provider "deepseek"`)
+		assert.Contains(t, err.Error(), "providers.deepseek.apiKey")
+		assert.Contains(t, err.Error(), "(openai)")
+	})
+
+	t.Run("matched provider with key", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Providers.DeepSeek.APIKey = "sk-deepseek"
+		assert.NoError(t, cfg.CheckModelProvider("deepseek-chat"))
+	})
+
+	t.Run("any matched provider with key is enough", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Providers.OpenRouter.APIKey = "sk-or"
+		assert.NoError(t, cfg.CheckModelProvider("openrouter/anthropic/claude-sonnet-4-5"))
+	})
+
+	t.Run("provider without default base needs apiBase", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Providers.Groq.APIKey = "gsk"
+		err := cfg.CheckModelProvider("groq/llama-3.3-70b")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "providers.groq.apiBase")
+
+		cfg.Providers.Groq.APIBase = "https://api.groq.com/openai/v1"
+		assert.NoError(t, cfg.CheckModelProvider("groq/llama-3.3-70b"))
+	})
+
+	t.Run("gemini uses native default base", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Providers.Gemini.APIKey = "gemini-key"
+		assert.NoError(t, cfg.CheckModelProvider("gemini-2.5-flash"))
+	})
+
+	t.Run("unrecognized model falls back to any key", func(t *testing.T) {
+		cfg := DefaultConfig()
+		err := cfg.CheckModelProvider("o3-mini")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no API key configured")
+
+		cfg.Providers.OpenAI.APIKey = "sk-openai"
+		assert.NoError(t, cfg.CheckModelProvider("o3-mini"))
+	})
+
+	t.Run("mock model needs no key", func(t *testing.T) {
+		cfg := DefaultConfig()
+		assert.NoError(t, cfg.CheckModelProvider("mock"))
+	})
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// 敏感字段的环境变量前缀：MAXCLAW_ 优先，兼容旧的 NANOBOT_
+var envOverridePrefixes = []string{"MAXCLAW_", "NANOBOT_"}
+
+// secretEnvField 环境变量后缀与对应配置字段
+type secretEnvField struct {
+	suffix string
+	field  func(c *Config) *string
+}
+
+var secretEnvFields = []secretEnvField{
+	{"BRAVE_APIKEY", func(c *Config) *string { return &c.Tools.Web.Search.APIKey }},
+	{"GATEWAY_AUTHTOKEN", func(c *Config) *string { return &c.Gateway.AuthToken }},
+	{"TELEGRAM_TOKEN", func(c *Config) *string { return &c.Channels.Telegram.Token }},
+	{"DISCORD_TOKEN", func(c *Config) *string { return &c.Channels.Discord.Token }},
+	{"WHATSAPP_BRIDGETOKEN", func(c *Config) *string { return &c.Channels.WhatsApp.BridgeToken }},
+	{"SLACK_BOTTOKEN", func(c *Config) *string { return &c.Channels.Slack.BotToken }},
+	{"SLACK_APPTOKEN", func(c *Config) *string { return &c.Channels.Slack.AppToken }},
+	{"EMAIL_IMAPPASSWORD", func(c *Config) *string { return &c.Channels.Email.IMAPPassword }},
+	{"EMAIL_SMTPPASSWORD", func(c *Config) *string { return &c.Channels.Email.SMTPPassword }},
+	{"QQ_APPSECRET", func(c *Config) *string { return &c.Channels.QQ.AppSecret }},
+	{"QQ_ACCESSTOKEN", func(c *Config) *string { return &c.Channels.QQ.AccessToken }},
+	{"FEISHU_APPSECRET", func(c *Config) *string { return &c.Channels.Feishu.AppSecret }},
+	{"FEISHU_VERIFICATIONTOKEN", func(c *Config) *string { return &c.Channels.Feishu.VerificationToken }},
+	{"OPENROUTER_APIKEY", func(c *Config) *string { return &c.Providers.OpenRouter.APIKey }},
+	{"ANTHROPIC_APIKEY", func(c *Config) *string { return &c.Providers.Anthropic.APIKey }},
+	{"OPENAI_APIKEY", func(c *Config) *string { return &c.Providers.OpenAI.APIKey }},
+	{"DEEPSEEK_APIKEY", func(c *Config) *string { return &c.Providers.DeepSeek.APIKey }},
+	{"ZHIPU_APIKEY", func(c *Config) *string { return &c.Providers.Zhipu.APIKey }},
+	{"GROQ_APIKEY", func(c *Config) *string { return &c.Providers.Groq.APIKey }},
+	{"GEMINI_APIKEY", func(c *Config) *string { return &c.Providers.Gemini.APIKey }},
+	{"DASHSCOPE_APIKEY", func(c *Config) *string { return &c.Providers.DashScope.APIKey }},
+	{"MOONSHOT_APIKEY", func(c *Config) *string { return &c.Providers.Moonshot.APIKey }},
+	{"MINIMAX_APIKEY", func(c *Config) *string { return &c.Providers.MiniMax.APIKey }},
+	{"VLLM_APIKEY", func(c *Config) *string { return &c.Providers.VLLM.APIKey }},
+}
+
+// envOverride 记录一次环境变量覆盖，保存时据此还原文件中的原值
+type envOverride struct {
+	suffix   string
+	value    string
+	original string
+}
+
+// ApplyEnvOverrides 用 MAXCLAW_<NAME>（或旧的 NANOBOT_<NAME>）环境变量覆盖 API Key、渠道 token 等敏感字段，
+// 环境变量优先于配置文件；返回实际生效的环境变量名
+func (c *Config) ApplyEnvOverrides() []string {
+	var applied []string
+	for _, f := range secretEnvFields {
+		name, value, ok := lookupSecretEnv(f.suffix)
+		if !ok {
+			continue
+		}
+		target := f.field(c)
+		c.envOverrides = append(c.envOverrides, envOverride{suffix: f.suffix, value: value, original: *target})
+		*target = value
+		applied = append(applied, name)
+	}
+	sort.Strings(applied)
+	return applied
+}
+
+// EnvOverrides 返回当前配置中由环境变量提供的字段对应的变量后缀（如 OPENROUTER_APIKEY）
+func (c *Config) EnvOverrides() []string {
+	suffixes := make([]string, 0, len(c.envOverrides))
+	for _, o := range c.envOverrides {
+		suffixes = append(suffixes, o.suffix)
+	}
+	return suffixes
+}
+
+// withoutEnvOverrides 返回用于写盘的副本：仍等于环境变量值的字段还原为文件原值，避免把密钥写进配置文件
+func (c *Config) withoutEnvOverrides() *Config {
+	if len(c.envOverrides) == 0 {
+		return c
+	}
+	clone := *c
+	for _, o := range c.envOverrides {
+		for _, f := range secretEnvFields {
+			if f.suffix != o.suffix {
+				continue
+			}
+			if target := f.field(&clone); *target == o.value {
+				*target = o.original
+			}
+		}
+	}
+	return &clone
+}
+
+func lookupSecretEnv(suffix string) (string, string, bool) {
+	for _, prefix := range envOverridePrefixes {
+		name := prefix + suffix
+		if value := strings.TrimSpace(os.Getenv(name)); value != "" {
+			return name, value, true
+		}
+	}
+	return "", "", false
+}
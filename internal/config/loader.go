@@ -68,7 +68,9 @@ func LoadConfig() (*Config, error) {
 
 	// 如果配置文件不存在，返回默认配置
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return DefaultConfig(), nil
+		config := DefaultConfig()
+		config.ApplyEnvOverrides()
+		return config, nil
 	}
 
 	data, err := os.ReadFile(configPath)
@@ -100,7 +102,14 @@ func LoadConfig() (*Config, error) {
 	// Expand workspace path (supports ~ and $HOME)
 	config.Agents.Defaults.Workspace = expandPath(config.Agents.Defaults.Workspace)
 	config.Agents.Defaults.ExecutionMode = NormalizeExecutionMode(config.Agents.Defaults.ExecutionMode)
+	config.Channels.ToolNotices = NormalizeToolNotices(config.Channels.ToolNotices)
+	// 环境变量中的密钥优先于配置文件（适合 CI / 容器部署）
+	config.ApplyEnvOverrides()
 
+	if err := config.Validate(); err != nil {
+		// 校验失败时仍返回解析结果，status / Web UI 可据此展示并修复配置
+		return config, fmt.Errorf("%s: %w", configPath, err)
+	}
 	return config, nil
 }
 
@@ -139,7 +148,7 @@ func SaveConfig(config *Config) error {
 	}
 
 	configPath := GetConfigPath()
-	data, err := json.MarshalIndent(config, "", "  ")
+	data, err := json.MarshalIndent(config.withoutEnvOverrides(), "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -151,6 +160,24 @@ func SaveConfig(config *Config) error {
 	return nil
 }
 
+// InitWorkspace 工作空间目录不存在时创建目录与模板文件，返回新建文件的相对路径；目录已存在时不做任何修改
+func InitWorkspace(workspace string) ([]string, error) {
+	if workspace == "" || dirExists(workspace) {
+		return nil, nil
+	}
+	if err := os.MkdirAll(workspace, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return CreateWorkspaceTemplatesAt(workspace)
+}
+
+func relWorkspacePath(workspace, path string) string {
+	if rel, err := filepath.Rel(workspace, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
 // EnsureWorkspace 确保工作空间目录存在
 func EnsureWorkspace() error {
 	workspace := GetWorkspacePath()
@@ -162,7 +189,13 @@ func EnsureWorkspace() error {
 
 // CreateWorkspaceTemplates 创建工作空间模板文件
 func CreateWorkspaceTemplates() error {
-	workspace := GetWorkspacePath()
+	_, err := CreateWorkspaceTemplatesAt(GetWorkspacePath())
+	return err
+}
+
+// CreateWorkspaceTemplatesAt 在指定工作空间创建缺失的模板文件，返回新建文件的相对路径（幂等）
+func CreateWorkspaceTemplatesAt(workspace string) ([]string, error) {
+	var created []string
 
 	templates := map[string]string{
 		"AGENTS.md": `# Agent Instructions
@@ -208,15 +241,16 @@ Information about the user goes here.
 		filePath := filepath.Join(workspace, filename)
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
 			if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-				return fmt.Errorf("failed to create %s: %w", filename, err)
+				return created, fmt.Errorf("failed to create %s: %w", filename, err)
 			}
+			created = append(created, relWorkspacePath(workspace, filePath))
 		}
 	}
 
 	// 创建 skills 目录与示例
 	skillsDir := filepath.Join(workspace, "skills")
 	if err := os.MkdirAll(skillsDir, 0755); err != nil {
-		return fmt.Errorf("failed to create skills directory: %w", err)
+		return created, fmt.Errorf("failed to create skills directory: %w", err)
 	}
 
 	skillsReadme := filepath.Join(skillsDir, "README.md")
@@ -235,13 +269,14 @@ Skills 是一组可复用的本地指令文件。将技能放在本目录下的
 - @skill:none / $none 禁用技能加载
 `
 		if err := os.WriteFile(skillsReadme, []byte(skillsContent), 0644); err != nil {
-			return fmt.Errorf("failed to create skills README: %w", err)
+			return created, fmt.Errorf("failed to create skills README: %w", err)
 		}
+		created = append(created, relWorkspacePath(workspace, skillsReadme))
 	}
 
 	exampleDir := filepath.Join(skillsDir, "example")
 	if err := os.MkdirAll(exampleDir, 0755); err != nil {
-		return fmt.Errorf("failed to create example skill directory: %w", err)
+		return created, fmt.Errorf("failed to create example skill directory: %w", err)
 	}
 
 	exampleSkill := filepath.Join(exampleDir, "SKILL.md")
@@ -254,14 +289,15 @@ When writing responses:
 - Call tools when needed
 `
 		if err := os.WriteFile(exampleSkill, []byte(exampleContent), 0644); err != nil {
-			return fmt.Errorf("failed to create example skill: %w", err)
+			return created, fmt.Errorf("failed to create example skill: %w", err)
 		}
+		created = append(created, relWorkspacePath(workspace, exampleSkill))
 	}
 
 	// 创建 memory 目录
 	memoryDir := filepath.Join(workspace, "memory")
 	if err := os.MkdirAll(memoryDir, 0755); err != nil {
-		return fmt.Errorf("failed to create memory directory: %w", err)
+		return created, fmt.Errorf("failed to create memory directory: %w", err)
 	}
 
 	// 创建 MEMORY.md
@@ -284,8 +320,9 @@ This file stores important information that should persist across sessions.
 (Things to remember)
 `
 		if err := os.WriteFile(memoryPath, []byte(memoryContent), 0644); err != nil {
-			return fmt.Errorf("failed to create MEMORY.md: %w", err)
+			return created, fmt.Errorf("failed to create MEMORY.md: %w", err)
 		}
+		created = append(created, relWorkspacePath(workspace, memoryPath))
 	}
 
 	// 创建 HISTORY.md（两层内存系统：grep 可检索历史日志）
@@ -296,8 +333,9 @@ This file stores important information that should persist across sessions.
 Append-only summaries for grep-based recall.
 `
 		if err := os.WriteFile(historyPath, []byte(historyContent), 0644); err != nil {
-			return fmt.Errorf("failed to create HISTORY.md: %w", err)
+			return created, fmt.Errorf("failed to create HISTORY.md: %w", err)
 		}
+		created = append(created, relWorkspacePath(workspace, historyPath))
 	}
 
 	// 创建 heartbeat.md（短周期工作状态）
@@ -320,9 +358,10 @@ Last Updated: (fill automatically or manually)
 - What should happen next
 `
 		if err := os.WriteFile(heartbeatPath, []byte(heartbeatContent), 0644); err != nil {
-			return fmt.Errorf("failed to create heartbeat.md: %w", err)
+			return created, fmt.Errorf("failed to create heartbeat.md: %w", err)
 		}
+		created = append(created, relWorkspacePath(workspace, heartbeatPath))
 	}
 
-	return nil
+	return created, nil
 }
@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const maskedSecretShort = "****"
+
+// MaskSecret 将敏感值替换为 "sk-...abcd" 形式的占位符，空值保持为空
+func MaskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	if len(secret) < 12 {
+		return maskedSecretShort
+	}
+	return secret[:3] + "..." + secret[len(secret)-4:]
+}
+
+// maskURLCredentials 隐藏 URL 中 userinfo 的密码与 token 类查询参数
+func maskURLCredentials(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+	changed := false
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok && pass != "" {
+			u.User = url.UserPassword(u.User.Username(), MaskSecret(pass))
+			changed = true
+		}
+	}
+	query := u.Query()
+	for key, values := range query {
+		if !isSecretQueryKey(key) {
+			continue
+		}
+		for i, v := range values {
+			values[i] = MaskSecret(v)
+		}
+		changed = true
+	}
+	if !changed {
+		return raw
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+func isSecretQueryKey(key string) bool {
+	key = strings.ToLower(key)
+	return strings.Contains(key, "token") || strings.Contains(key, "key") || strings.Contains(key, "secret") || strings.Contains(key, "password")
+}
+
+// secretField 配置中的一个敏感字段
+type secretField struct {
+	value *string
+	mask  func(string) string
+}
+
+// secretFields 列出配置中所有敏感字段，key 为稳定的字段路径
+func (c *Config) secretFields() map[string]secretField {
+	fields := map[string]secretField{}
+	add := func(key string, value *string) {
+		fields[key] = secretField{value: value, mask: MaskSecret}
+	}
+
+	providers := reflect.ValueOf(&c.Providers).Elem()
+	providerType := providers.Type()
+	for i := 0; i < providers.NumField(); i++ {
+		name := strings.Split(providerType.Field(i).Tag.Get("json"), ",")[0]
+		if pc, ok := providers.Field(i).Addr().Interface().(*ProviderConfig); ok {
+			add("providers."+name+".apiKey", &pc.APIKey)
+		}
+	}
+
+	ch := &c.Channels
+	add("channels.telegram.token", &ch.Telegram.Token)
+	add("channels.discord.token", &ch.Discord.Token)
+	add("channels.whatsapp.bridgeToken", &ch.WhatsApp.BridgeToken)
+	fields["channels.whatsapp.bridgeUrl"] = secretField{value: &ch.WhatsApp.BridgeURL, mask: maskURLCredentials}
+	add("channels.slack.botToken", &ch.Slack.BotToken)
+	add("channels.slack.appToken", &ch.Slack.AppToken)
+	add("channels.email.imapPassword", &ch.Email.IMAPPassword)
+	add("channels.email.smtpPassword", &ch.Email.SMTPPassword)
+	add("channels.qq.appSecret", &ch.QQ.AppSecret)
+	add("channels.qq.accessToken", &ch.QQ.AccessToken)
+	add("channels.feishu.appSecret", &ch.Feishu.AppSecret)
+	add("channels.feishu.verificationToken", &ch.Feishu.VerificationToken)
+
+	add("tools.web.search.apiKey", &c.Tools.Web.Search.APIKey)
+	add("gateway.authToken", &c.Gateway.AuthToken)
+
+	return fields
+}
+
+// secretMapValues MCP 服务器的 env/headers 与 http_get 的 headers 值通常包含凭据，同样视为敏感
+func (c *Config) secretMapValues(fn func(key string, m map[string]string, k string)) {
+	names := make([]string, 0, len(c.Tools.MCPServers))
+	for name := range c.Tools.MCPServers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		server := c.Tools.MCPServers[name]
+		for k := range server.Env {
+			fn("tools.mcpServers."+name+".env."+k, server.Env, k)
+		}
+		for k := range server.Headers {
+			fn("tools.mcpServers."+name+".headers."+k, server.Headers, k)
+		}
+	}
+
+	endpoints := make([]string, 0, len(c.Tools.HTTPGet.Endpoints))
+	for name := range c.Tools.HTTPGet.Endpoints {
+		endpoints = append(endpoints, name)
+	}
+	sort.Strings(endpoints)
+	for _, name := range endpoints {
+		headers := c.Tools.HTTPGet.Endpoints[name].Headers
+		for k := range headers {
+			fn("tools.httpGet.endpoints."+name+".headers."+k, headers, k)
+		}
+	}
+}
+
+// Redacted 返回敏感字段已脱敏的配置副本，原配置不受影响
+func (c *Config) Redacted() *Config {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return DefaultConfig()
+	}
+	copied := &Config{}
+	if err := json.Unmarshal(data, copied); err != nil {
+		return DefaultConfig()
+	}
+
+	for _, field := range copied.secretFields() {
+		*field.value = field.mask(*field.value)
+	}
+	copied.secretMapValues(func(_ string, m map[string]string, k string) {
+		m[k] = MaskSecret(m[k])
+	})
+	return copied
+}
+
+// RestoreMaskedSecrets 将仍为脱敏占位符的字段恢复为 prev 中的原值，
+// 使 GET → PUT 的往返不会把真实凭据覆盖成占位符
+func (c *Config) RestoreMaskedSecrets(prev *Config) {
+	if prev == nil {
+		return
+	}
+
+	prevFields := prev.secretFields()
+	for key, field := range c.secretFields() {
+		old, ok := prevFields[key]
+		if !ok || *old.value == "" || *field.value == "" {
+			continue
+		}
+		if *field.value == field.mask(*old.value) {
+			*field.value = *old.value
+		}
+	}
+
+	prevValues := map[string]string{}
+	prev.secretMapValues(func(key string, m map[string]string, k string) {
+		prevValues[key] = m[k]
+	})
+	c.secretMapValues(func(key string, m map[string]string, k string) {
+		if old, ok := prevValues[key]; ok && old != "" && m[k] == MaskSecret(old) {
+			m[k] = old
+		}
+	})
+}
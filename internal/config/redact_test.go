@@ -0,0 +1,72 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func secretTestConfig() *Config {
+	cfg := DefaultConfig()
+	cfg.Providers.OpenAI.APIKey = "sk-openai-1234567890abcd"
+	cfg.Providers.Anthropic.APIKey = "short"
+	cfg.Channels.Telegram.Token = "123456:telegram-bot-token-wxyz"
+	cfg.Channels.WhatsApp.BridgeURL = "ws://bot:bridge-password-1234@localhost:3001/?token=query-secret-9876"
+	cfg.Tools.Web.Search.APIKey = "brave-search-key-0000"
+	cfg.Tools.MCPServers = map[string]MCPServerConfig{
+		"github": {Command: "npx", Env: map[string]string{"GITHUB_TOKEN": "ghp_abcdefghijklmnop"}},
+	}
+	cfg.Tools.HTTPGet.Endpoints = map[string]HTTPGetEndpointConfig{
+		"weather": {BaseURL: "https://api.weather.example/v1/", Headers: map[string]string{"X-API-Key": "weather-key-12345678"}},
+	}
+	return cfg
+}
+
+func TestMaskSecret(t *testing.T) {
+	assert.Equal(t, "", MaskSecret(""))
+	assert.Equal(t, "****", MaskSecret("short"))
+	assert.Equal(t, "sk-...abcd", MaskSecret("sk-openai-1234567890abcd"))
+}
+
+func TestRedactedMasksSecretsWithoutMutatingOriginal(t *testing.T) {
+	cfg := secretTestConfig()
+	redacted := cfg.Redacted()
+
+	assert.Equal(t, "sk-...abcd", redacted.Providers.OpenAI.APIKey)
+	assert.Equal(t, "****", redacted.Providers.Anthropic.APIKey)
+	assert.Equal(t, "", redacted.Providers.Groq.APIKey)
+	assert.Equal(t, "123...wxyz", redacted.Channels.Telegram.Token)
+	assert.Equal(t, "bra...0000", redacted.Tools.Web.Search.APIKey)
+	assert.Equal(t, "ghp...mnop", redacted.Tools.MCPServers["github"].Env["GITHUB_TOKEN"])
+	assert.Equal(t, "wea...5678", redacted.Tools.HTTPGet.Endpoints["weather"].Headers["X-API-Key"])
+	assert.NotContains(t, redacted.Channels.WhatsApp.BridgeURL, "bridge-password-1234")
+	assert.NotContains(t, redacted.Channels.WhatsApp.BridgeURL, "query-secret-9876")
+	assert.Contains(t, redacted.Channels.WhatsApp.BridgeURL, "localhost:3001")
+
+	data, err := json.Marshal(redacted)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-openai-1234567890abcd")
+
+	assert.Equal(t, "sk-openai-1234567890abcd", cfg.Providers.OpenAI.APIKey)
+	assert.Equal(t, "ghp_abcdefghijklmnop", cfg.Tools.MCPServers["github"].Env["GITHUB_TOKEN"])
+}
+
+func TestRestoreMaskedSecretsKeepsOriginalsAndAcceptsNewValues(t *testing.T) {
+	prev := secretTestConfig()
+	updated := prev.Redacted()
+	updated.Channels.Discord.Token = "brand-new-discord-token"
+	updated.Tools.Web.Search.APIKey = ""
+
+	updated.RestoreMaskedSecrets(prev)
+
+	assert.Equal(t, "sk-openai-1234567890abcd", updated.Providers.OpenAI.APIKey)
+	assert.Equal(t, "short", updated.Providers.Anthropic.APIKey)
+	assert.Equal(t, "123456:telegram-bot-token-wxyz", updated.Channels.Telegram.Token)
+	assert.Equal(t, prev.Channels.WhatsApp.BridgeURL, updated.Channels.WhatsApp.BridgeURL)
+	assert.Equal(t, "ghp_abcdefghijklmnop", updated.Tools.MCPServers["github"].Env["GITHUB_TOKEN"])
+	assert.Equal(t, "weather-key-12345678", updated.Tools.HTTPGet.Endpoints["weather"].Headers["X-API-Key"])
+	assert.Equal(t, "brand-new-discord-token", updated.Channels.Discord.Token)
+	assert.Equal(t, "", updated.Tools.Web.Search.APIKey, "explicitly cleared secrets stay cleared")
+}
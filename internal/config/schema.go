@@ -1,7 +1,10 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/Lichas/maxclaw/internal/providers"
@@ -13,6 +16,8 @@ type ProviderConfig struct {
 	APIBase   string                `json:"apiBase,omitempty" mapstructure:"apiBase"`
 	APIFormat string                `json:"apiFormat,omitempty" mapstructure:"apiFormat"`
 	Models    []ProviderModelConfig `json:"models,omitempty" mapstructure:"models"`
+	// DefaultModel 只指定提供商名称（如 "anthropic"）作为模型时使用的模型
+	DefaultModel string `json:"defaultModel,omitempty" mapstructure:"defaultModel"`
 }
 
 type ProviderModelConfig struct {
@@ -33,6 +38,69 @@ type ChannelsConfig struct {
 	Email     EmailConfig     `json:"email" mapstructure:"email"`
 	QQ        QQConfig        `json:"qq" mapstructure:"qq"`
 	Feishu    FeishuConfig    `json:"feishu" mapstructure:"feishu"`
+	// MaxMessageAgeSeconds 入站消息最大年龄（秒），超过则忽略；0 表示不限制
+	MaxMessageAgeSeconds int `json:"maxMessageAgeSeconds,omitempty" mapstructure:"maxMessageAgeSeconds"`
+	// StreamResponses 为 true 时按段落边生成边发送回复，而不是等待完整回复
+	StreamResponses bool `json:"streamResponses,omitempty" mapstructure:"streamResponses"`
+	// ToolNotices 工具执行后是否向聊天频道发送提示：off（默认）/ brief / verbose
+	ToolNotices string `json:"toolNotices,omitempty" mapstructure:"toolNotices"`
+	// SessionScope 按频道设置会话隔离粒度：chat（默认）/ sender / chat_sender，key 为频道名
+	SessionScope map[string]string `json:"sessionScope,omitempty" mapstructure:"sessionScope"`
+	// ErrorMessages 处理失败时按错误类别回复给用户的提示，原始错误只写入日志
+	ErrorMessages ErrorMessagesConfig `json:"errorMessages" mapstructure:"errorMessages"`
+	// RateLimit 按频道 + 发送者限制入站消息速率，超出的消息直接丢弃
+	RateLimit RateLimitConfig `json:"rateLimit" mapstructure:"rateLimit"`
+}
+
+// RateLimitConfig 入站消息限流（令牌桶）
+type RateLimitConfig struct {
+	// MessagesPerMinute 每个发送者每分钟允许的消息数（令牌补充速率），0 表示不限流
+	MessagesPerMinute int `json:"messagesPerMinute,omitempty" mapstructure:"messagesPerMinute"`
+	// Burst 允许连续发送的条数（令牌桶容量），0 时等于 MessagesPerMinute
+	Burst int `json:"burst,omitempty" mapstructure:"burst"`
+	// Notice 开始被限流时回复给发送者的提示，留空则静默丢弃
+	Notice string `json:"notice,omitempty" mapstructure:"notice"`
+}
+
+// ErrorMessagesConfig 各错误类别的用户提示，留空使用内置文案
+type ErrorMessagesConfig struct {
+	// Auth 模型服务鉴权失败（API Key 无效、未配置等）
+	Auth string `json:"auth,omitempty" mapstructure:"auth"`
+	// RateLimit 模型服务限流或额度不足
+	RateLimit string `json:"rateLimit,omitempty" mapstructure:"rateLimit"`
+	// Timeout 本轮处理或模型请求超时
+	Timeout string `json:"timeout,omitempty" mapstructure:"timeout"`
+	// Tool 工具执行失败
+	Tool string `json:"tool,omitempty" mapstructure:"tool"`
+	// AllProvidersFailed 主模型与所有备用模型均调用失败
+	AllProvidersFailed string `json:"allProvidersFailed,omitempty" mapstructure:"allProvidersFailed"`
+	// Default 其他错误
+	Default string `json:"default,omitempty" mapstructure:"default"`
+}
+
+// Models 返回配置了模型覆盖的频道，key 为频道名
+func (c ChannelsConfig) Models() map[string]string {
+	models := make(map[string]string)
+	for channel, model := range map[string]string{
+		"telegram":  c.Telegram.Model,
+		"discord":   c.Discord.Model,
+		"whatsapp":  c.WhatsApp.Model,
+		"websocket": c.WebSocket.Model,
+		"slack":     c.Slack.Model,
+		"email":     c.Email.Model,
+		"qq":        c.QQ.Model,
+		"feishu":    c.Feishu.Model,
+	} {
+		if model = strings.TrimSpace(model); model != "" {
+			models[channel] = model
+		}
+	}
+	return models
+}
+
+// SessionScopeFor 返回频道的会话隔离粒度，未配置时为空（按 chat 处理）
+func (c ChannelsConfig) SessionScopeFor(channel string) string {
+	return strings.ToLower(strings.TrimSpace(c.SessionScope[channel]))
 }
 
 // TelegramConfig Telegram 配置
@@ -41,6 +109,25 @@ type TelegramConfig struct {
 	Token     string   `json:"token" mapstructure:"token"`
 	AllowFrom []string `json:"allowFrom" mapstructure:"allowFrom"`
 	Proxy     string   `json:"proxy,omitempty" mapstructure:"proxy"`
+	// DownloadMedia 是否下载入站图片/文件供模型读取，未设置时默认开启
+	DownloadMedia *bool `json:"downloadMedia,omitempty" mapstructure:"downloadMedia"`
+	// MaxMediaMB 单个入站媒体的大小上限（MB），<= 0 时使用默认 20MB
+	MaxMediaMB int `json:"maxMediaMB,omitempty" mapstructure:"maxMediaMB"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
+}
+
+// MediaDownloadEnabled 是否下载入站媒体
+func (c TelegramConfig) MediaDownloadEnabled() bool {
+	return c.DownloadMedia == nil || *c.DownloadMedia
+}
+
+// MaxMediaBytes 入站媒体大小上限（字节），0 表示使用下载器默认值
+func (c TelegramConfig) MaxMediaBytes() int64 {
+	if c.MaxMediaMB <= 0 {
+		return 0
+	}
+	return int64(c.MaxMediaMB) * 1024 * 1024
 }
 
 // DiscordConfig Discord 配置
@@ -48,6 +135,10 @@ type DiscordConfig struct {
 	Enabled   bool     `json:"enabled" mapstructure:"enabled"`
 	Token     string   `json:"token" mapstructure:"token"`
 	AllowFrom []string `json:"allowFrom" mapstructure:"allowFrom"`
+	// SlashCommand 注册的斜杠命令名（默认 ask），设为 off 则不注册
+	SlashCommand string `json:"slashCommand,omitempty" mapstructure:"slashCommand"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // WhatsAppConfig WhatsApp 配置
@@ -57,6 +148,8 @@ type WhatsAppConfig struct {
 	BridgeToken string   `json:"bridgeToken,omitempty" mapstructure:"bridgeToken"`
 	AllowFrom   []string `json:"allowFrom" mapstructure:"allowFrom"`
 	AllowSelf   bool     `json:"allowSelf,omitempty" mapstructure:"allowSelf"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // WebSocketConfig WebSocket 频道配置
@@ -66,6 +159,10 @@ type WebSocketConfig struct {
 	Port         int      `json:"port,omitempty" mapstructure:"port"`
 	Path         string   `json:"path,omitempty" mapstructure:"path"`
 	AllowOrigins []string `json:"allowOrigins,omitempty" mapstructure:"allowOrigins"`
+	// PingIntervalSeconds 向客户端发送 ping 的间隔（默认 30），两个间隔内无响应的连接会被断开
+	PingIntervalSeconds int `json:"pingIntervalSeconds,omitempty" mapstructure:"pingIntervalSeconds"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // SlackConfig Slack Socket Mode 配置
@@ -74,6 +171,8 @@ type SlackConfig struct {
 	BotToken  string   `json:"botToken,omitempty" mapstructure:"botToken"`
 	AppToken  string   `json:"appToken,omitempty" mapstructure:"appToken"`
 	AllowFrom []string `json:"allowFrom" mapstructure:"allowFrom"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // EmailConfig Email(IMAP/SMTP) 配置
@@ -97,6 +196,8 @@ type EmailConfig struct {
 	PollIntervalSeconds int      `json:"pollIntervalSeconds,omitempty" mapstructure:"pollIntervalSeconds"`
 	MarkSeen            bool     `json:"markSeen,omitempty" mapstructure:"markSeen"`
 	AllowFrom           []string `json:"allowFrom" mapstructure:"allowFrom"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // QQConfig QQ 机器人配置（腾讯官方 QQBot）
@@ -109,6 +210,8 @@ type QQConfig struct {
 	ListenAddr  string   `json:"listenAddr,omitempty" mapstructure:"listenAddr"`
 	WebhookPath string   `json:"webhookPath,omitempty" mapstructure:"webhookPath"`
 	AllowFrom   []string `json:"allowFrom" mapstructure:"allowFrom"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // FeishuConfig Feishu/Lark 配置
@@ -120,6 +223,8 @@ type FeishuConfig struct {
 	ListenAddr        string   `json:"listenAddr,omitempty" mapstructure:"listenAddr"`
 	WebhookPath       string   `json:"webhookPath,omitempty" mapstructure:"webhookPath"`
 	AllowFrom         []string `json:"allowFrom" mapstructure:"allowFrom"`
+	// Model 该频道消息使用的模型，留空使用 agents.defaults.model
+	Model string `json:"model,omitempty" mapstructure:"model"`
 }
 
 // AgentDefaults 默认代理配置
@@ -132,11 +237,78 @@ type AgentDefaults struct {
 	ExecutionMode      string   `json:"executionMode,omitempty" mapstructure:"executionMode"`
 	EnableGlobalSkills bool     `json:"enableGlobalSkills" mapstructure:"enableGlobalSkills"`
 	GlobalSkillsPaths  []string `json:"globalSkillsPaths,omitempty" mapstructure:"globalSkillsPaths"`
+	AutoInitWorkspace  bool     `json:"autoInitWorkspace" mapstructure:"autoInitWorkspace"` // 启动时工作空间不存在则自动初始化
+	// FallbackModels 主模型调用失败时依次尝试的备用模型
+	FallbackModels []string `json:"fallbackModels,omitempty" mapstructure:"fallbackModels"`
+	// MaxParallelTools 单轮内并发执行只读工具的上限，0 使用默认值，1 表示顺序执行
+	MaxParallelTools int `json:"maxParallelTools,omitempty" mapstructure:"maxParallelTools"`
+	// ToolTimeoutSeconds 单次工具调用的超时秒数，0 表示不限制；超时后记录错误结果并继续本轮
+	ToolTimeoutSeconds int `json:"toolTimeoutSeconds,omitempty" mapstructure:"toolTimeoutSeconds"`
+	// TurnTimeoutSeconds 处理单条消息的总超时秒数，0 表示不限制
+	TurnTimeoutSeconds int `json:"turnTimeoutSeconds,omitempty" mapstructure:"turnTimeoutSeconds"`
+	// ToolCallWarnThreshold 单会话 10 分钟内工具调用超过该次数时写入告警日志，0 使用默认值
+	ToolCallWarnThreshold int `json:"toolCallWarnThreshold,omitempty" mapstructure:"toolCallWarnThreshold"`
+	// RepeatedToolCallWindow 单轮内记住的最近工具调用数，与其中某次完全相同的调用不再执行而是返回上次结果，0 使用默认值（8）
+	RepeatedToolCallWindow int `json:"repeatedToolCallWindow,omitempty" mapstructure:"repeatedToolCallWindow"`
+	// MaxConcurrentRequests 进程内同时进行的模型请求上限（所有会话、子代理与定时任务共享），超出时排队；0 表示不限制
+	MaxConcurrentRequests int `json:"maxConcurrentRequests,omitempty" mapstructure:"maxConcurrentRequests"`
+	// SessionFormat 会话持久化格式：json（默认，每次整体重写）或 jsonl（逐条追加，定期压缩）
+	SessionFormat string `json:"sessionFormat,omitempty" mapstructure:"sessionFormat"`
 }
 
 // AgentsConfig 代理配置
 type AgentsConfig struct {
 	Defaults AgentDefaults `json:"defaults" mapstructure:"defaults"`
+	// Profiles 命名配置档，通过 --profile 叠加到 Defaults 上（只覆盖非零值字段）
+	Profiles map[string]AgentDefaults `json:"profiles,omitempty" mapstructure:"profiles"`
+}
+
+// ProfileNames 返回已定义的配置档名称（按字母排序）
+func (a AgentsConfig) ProfileNames() []string {
+	names := make([]string, 0, len(a.Profiles))
+	for name := range a.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EffectiveDefaults 返回叠加指定配置档后的 agent 参数；name 为空时直接返回 Defaults
+func (a AgentsConfig) EffectiveDefaults(name string) (AgentDefaults, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return a.Defaults, nil
+	}
+	profile, ok := a.Profiles[name]
+	if !ok {
+		if len(a.Profiles) == 0 {
+			return AgentDefaults{}, fmt.Errorf("unknown agent profile %q (no profiles defined in agents.profiles)", name)
+		}
+		return AgentDefaults{}, fmt.Errorf("unknown agent profile %q (available: %s)", name, strings.Join(a.ProfileNames(), ", "))
+	}
+
+	merged := a.Defaults
+	dst := reflect.ValueOf(&merged).Elem()
+	src := reflect.ValueOf(profile)
+	for i := 0; i < src.NumField(); i++ {
+		if field := src.Field(i); !field.IsZero() {
+			dst.Field(i).Set(field)
+		}
+	}
+	merged.Workspace = expandPath(merged.Workspace)
+	merged.ExecutionMode = NormalizeExecutionMode(merged.ExecutionMode)
+	return merged, nil
+}
+
+// ApplyProfile 把命名配置档叠加到 agents.defaults，之后 ResolveModel / GetAPIKey / GetAPIBase
+// 等都基于叠加后的模型解析；name 为空时不做任何修改
+func (c *Config) ApplyProfile(name string) error {
+	effective, err := c.Agents.EffectiveDefaults(name)
+	if err != nil {
+		return err
+	}
+	c.Agents.Defaults = effective
+	return nil
 }
 
 // WebSearchConfig 网页搜索配置
@@ -160,6 +332,16 @@ type WebFetchConfig struct {
 	WaitForText     string               `json:"waitForText,omitempty" mapstructure:"waitForText"`
 	WaitForNoText   string               `json:"waitForNoText,omitempty" mapstructure:"waitForNoText"`
 	Chrome          WebFetchChromeConfig `json:"chrome,omitempty" mapstructure:"chrome"`
+	// MaxConcurrent 同时运行的 browser/chrome 抓取子进程上限，0 使用默认值 2
+	MaxConcurrent int `json:"maxConcurrent,omitempty" mapstructure:"maxConcurrent"`
+	// HTTPFallback browser/chrome 模式失败（Node 缺失、Playwright 报错等）时退回普通 HTTP 抓取（默认关闭）
+	HTTPFallback bool `json:"httpFallback,omitempty" mapstructure:"httpFallback"`
+	// IgnoreRobots 不检查目标站点的 robots.txt（默认检查并跳过禁止抓取的路径）
+	IgnoreRobots bool `json:"ignoreRobots,omitempty" mapstructure:"ignoreRobots"`
+	// AllowPrivateNetwork 允许抓取回环、私有、链路本地等内部地址（默认阻止，防止 SSRF）
+	AllowPrivateNetwork bool `json:"allowPrivateNetwork,omitempty" mapstructure:"allowPrivateNetwork"`
+	// AllowedHosts 阻止内部地址时仍允许抓取的主机名或 IP/CIDR
+	AllowedHosts []string `json:"allowedHosts,omitempty" mapstructure:"allowedHosts"`
 }
 
 // WebFetchChromeConfig Chrome 抓取配置
@@ -193,20 +375,76 @@ type MCPServerConfig struct {
 // ExecToolConfig Shell 执行配置
 type ExecToolConfig struct {
 	Timeout int `json:"timeout" mapstructure:"timeout"`
+	// MaxTimeout 单次调用 timeout 参数允许的上限（秒），默认 300，不低于 Timeout
+	MaxTimeout int `json:"maxTimeout,omitempty" mapstructure:"maxTimeout"`
+	// AllowedCommands 非空时只允许执行列表中的程序（每个管道段都会校验）
+	AllowedCommands []string `json:"allowedCommands,omitempty" mapstructure:"allowedCommands"`
+	// DangerousPatterns 额外的危险命令正则（匹配小写后的命令）；为空时只使用内置默认模式
+	DangerousPatterns []string `json:"dangerousPatterns,omitempty" mapstructure:"dangerousPatterns"`
+	// ReplaceDangerousDefaults 为 true 且 DangerousPatterns 非空时替换内置默认模式
+	ReplaceDangerousDefaults bool `json:"replaceDangerousDefaults,omitempty" mapstructure:"replaceDangerousDefaults"`
+	// StreamOutput 为 true 时长命令的输出会实时推送到流式事件 / CLI
+	StreamOutput bool `json:"streamOutput,omitempty" mapstructure:"streamOutput"`
+	// RestrictedPrompt 开启 restrictToWorkspace 时追加到 exec 工具描述末尾的自定义说明
+	RestrictedPrompt string `json:"restrictedPrompt,omitempty" mapstructure:"restrictedPrompt"`
+}
+
+// ReadLogsToolConfig read_logs 工具配置（默认关闭，日志可能包含敏感信息）
+type ReadLogsToolConfig struct {
+	Enabled       bool     `json:"enabled" mapstructure:"enabled"`
+	AllowChannels []string `json:"allowChannels,omitempty" mapstructure:"allowChannels"` // 为空时仅允许 cli/webui/desktop
+}
+
+// HTTPGetToolConfig http_get 工具配置（默认关闭，只允许访问 endpoints 中列出的 base URL）
+type HTTPGetToolConfig struct {
+	Enabled   bool                             `json:"enabled" mapstructure:"enabled"`
+	Endpoints map[string]HTTPGetEndpointConfig `json:"endpoints,omitempty" mapstructure:"endpoints"`
+	// TimeoutSeconds 单次请求超时，默认 15
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty" mapstructure:"timeoutSeconds"`
+	// MaxChars 返回响应体的最大字符数，默认 8000
+	MaxChars int `json:"maxChars,omitempty" mapstructure:"maxChars"`
+}
+
+// HTTPGetEndpointConfig 允许 http_get 访问的 API；Headers（如 API Key）只会发送给该 base URL
+type HTTPGetEndpointConfig struct {
+	BaseURL string            `json:"baseUrl" mapstructure:"baseUrl"`
+	Headers map[string]string `json:"headers,omitempty" mapstructure:"headers"`
+}
+
+// FileToolsConfig write_file / edit_file 配置
+type FileToolsConfig struct {
+	// AllowedExtensions 非空时只允许写入这些扩展名（如 ".md"、"txt"，"." 表示无扩展名）
+	AllowedExtensions []string `json:"allowedExtensions,omitempty" mapstructure:"allowedExtensions"`
+	// DeniedExtensions 禁止写入的扩展名，优先于 AllowedExtensions
+	DeniedExtensions []string `json:"deniedExtensions,omitempty" mapstructure:"deniedExtensions"`
 }
 
 // ToolsConfig 工具配置
 type ToolsConfig struct {
 	Web                 WebToolsConfig             `json:"web" mapstructure:"web"`
 	Exec                ExecToolConfig             `json:"exec" mapstructure:"exec"`
+	ReadLogs            ReadLogsToolConfig         `json:"readLogs,omitempty" mapstructure:"readLogs"`
+	Files               FileToolsConfig            `json:"files,omitempty" mapstructure:"files"`
+	HTTPGet             HTTPGetToolConfig          `json:"httpGet,omitempty" mapstructure:"httpGet"`
 	RestrictToWorkspace bool                       `json:"restrictToWorkspace" mapstructure:"restrictToWorkspace"`
 	MCPServers          map[string]MCPServerConfig `json:"mcpServers,omitempty" mapstructure:"mcpServers"`
+	// ResultLimits 按工具名设置返回给模型的结果最大字节数（如 {"read_file": 200000, "web_fetch": 8000}），
+	// 超出部分截断；工具支持 max_bytes / max_length / max_chars 参数时同时作为其默认值
+	ResultLimits map[string]int `json:"resultLimits,omitempty" mapstructure:"resultLimits"`
 }
 
 // GatewayConfig 网关配置
 type GatewayConfig struct {
 	Host string `json:"host" mapstructure:"host"`
 	Port int    `json:"port" mapstructure:"port"`
+	// AuthToken 非空时 /api/* 需要携带 Authorization: Bearer <token>
+	AuthToken string `json:"authToken,omitempty" mapstructure:"authToken"`
+}
+
+// CronConfig 定时任务配置
+type CronConfig struct {
+	// MissedOnceJobs 网关启动时对停机期间错过的一次性任务的处理：skip（默认，仅记录日志）/ run（立即补执行）/ notify（发送通知）
+	MissedOnceJobs string `json:"missedOnceJobs,omitempty" mapstructure:"missedOnceJobs"`
 }
 
 // ProvidersConfig 所有 LLM 提供商配置
@@ -265,6 +503,10 @@ type Config struct {
 	Providers ProvidersConfig `json:"providers" mapstructure:"providers"`
 	Gateway   GatewayConfig   `json:"gateway" mapstructure:"gateway"`
 	Tools     ToolsConfig     `json:"tools" mapstructure:"tools"`
+	Cron      CronConfig      `json:"cron,omitempty" mapstructure:"cron"`
+
+	// envOverrides 记录 LoadConfig 时由环境变量覆盖的字段，SaveConfig 不会把这些值写回文件
+	envOverrides []envOverride
 }
 
 // DefaultConfig 返回默认配置
@@ -281,6 +523,8 @@ func DefaultConfig() *Config {
 				MaxToolIterations:  200,
 				ExecutionMode:      ExecutionModeAsk,
 				EnableGlobalSkills: true, // 默认启用 ~/.agents/skills/
+				AutoInitWorkspace:  true,
+				MaxParallelTools:   4,
 			},
 		},
 		Channels: ChannelsConfig{
@@ -368,7 +612,8 @@ func DefaultConfig() *Config {
 				},
 			},
 			Exec: ExecToolConfig{
-				Timeout: 60,
+				Timeout:    60,
+				MaxTimeout: 300,
 			},
 			RestrictToWorkspace: false,
 			MCPServers:          map[string]MCPServerConfig{},
@@ -376,12 +621,38 @@ func DefaultConfig() *Config {
 	}
 }
 
-// GetAPIKey 根据模型名称获取 API Key
-func (c *Config) GetAPIKey(model string) string {
+// ResolveModel 解析实际使用的模型：空值取 agents.defaults.model；
+// 只给出提供商名称时使用该提供商的 defaultModel（未配置则用内置默认）
+func (c *Config) ResolveModel(model string) string {
+	model = strings.TrimSpace(model)
 	if model == "" {
-		model = c.Agents.Defaults.Model
+		model = strings.TrimSpace(c.Agents.Defaults.Model)
+	}
+
+	name := strings.ToLower(strings.TrimSuffix(model, "/"))
+	cfg, ok := c.providerConfigMap()[name]
+	if !ok {
+		return model
 	}
-	model = strings.ToLower(model)
+	spec, _ := providers.FindProviderSpec(name)
+
+	defaultModel := strings.TrimSpace(cfg.DefaultModel)
+	if defaultModel == "" {
+		defaultModel = spec.DefaultModel
+	}
+	if defaultModel == "" {
+		return model
+	}
+	// 默认模型名无法识别出提供商时加上前缀，保证 API Key / Base 路由到该提供商
+	if !spec.MatchesModel(defaultModel) {
+		return name + "/" + defaultModel
+	}
+	return defaultModel
+}
+
+// GetAPIKey 根据模型名称获取 API Key
+func (c *Config) GetAPIKey(model string) string {
+	model = strings.ToLower(c.ResolveModel(model))
 
 	providerMap := c.providerConfigMap()
 
@@ -403,12 +674,50 @@ func (c *Config) GetAPIKey(model string) string {
 	return ""
 }
 
+// CheckModelProvider 检查模型能否路由到可用的提供商：按模型名匹配到的提供商中至少一个配置了 API Key，
+// 且能确定 API Base。否则返回点名缺失配置项的错误，避免借用其他提供商的 Key 在运行时得到 401。
+// 无法从模型名识别提供商时沿用 GetAPIKey 的回退逻辑，只要求存在任意 Key；mock 模型始终可用
+func (c *Config) CheckModelProvider(model string) error {
+	resolved := c.ResolveModel(model)
+	if providers.IsMockModel(resolved) {
+		return nil
+	}
+
+	providerMap := c.providerConfigMap()
+	var matched []string
+	for _, spec := range providers.ProviderSpecs {
+		if !spec.MatchesModel(strings.ToLower(resolved)) {
+			continue
+		}
+		matched = append(matched, spec.Name)
+		if strings.TrimSpace(providerMap[spec.Name].APIKey) == "" {
+			continue
+		}
+		// Gemini 原生接口自带默认地址，其余提供商缺少 API Base 时会误用 OpenAI 默认地址
+		if spec.Name != "gemini" && c.GetAPIBase(resolved) == "" {
+			return fmt.Errorf("no API base configured for provider %q (model %q). Set providers.%s.apiBase in ~/.maxclaw/config.json", spec.Name, resolved, spec.Name)
+		}
+		return nil
+	}
+
+	configured := c.ConfiguredProviders()
+	if len(matched) == 0 {
+		if len(configured) == 0 {
+			return fmt.Errorf("no API key configured. Set one in ~/.maxclaw/config.json")
+		}
+		return nil
+	}
+
+	msg := fmt.Sprintf("no API key configured for provider %q (model %q). Set providers.%s.apiKey in ~/.maxclaw/config.json", matched[0], resolved, matched[0])
+	if len(configured) > 0 {
+		msg += fmt.Sprintf(" or switch to a model of a configured provider (%s)", strings.Join(configured, ", "))
+	}
+	return errors.New(msg)
+}
+
 // GetAPIBase 根据模型名称获取 API Base URL
 func (c *Config) GetAPIBase(model string) string {
-	if model == "" {
-		model = c.Agents.Defaults.Model
-	}
-	model = strings.ToLower(model)
+	model = strings.ToLower(c.ResolveModel(model))
 
 	providerMap := c.providerConfigMap()
 	matchedProvider := false
@@ -439,10 +748,7 @@ func (c *Config) GetAPIBase(model string) string {
 
 // GetAPIFormat returns the wire format configured for the target provider.
 func (c *Config) GetAPIFormat(model string) string {
-	if model == "" {
-		model = c.Agents.Defaults.Model
-	}
-	model = strings.ToLower(model)
+	model = strings.ToLower(c.ResolveModel(model))
 
 	providerMap := c.providerConfigMap()
 	for _, spec := range providers.ProviderSpecs {
@@ -452,8 +758,8 @@ func (c *Config) GetAPIFormat(model string) string {
 		if cfg, ok := providerMap[spec.Name]; ok && strings.TrimSpace(cfg.APIFormat) != "" {
 			return strings.ToLower(strings.TrimSpace(cfg.APIFormat))
 		}
-		if spec.Name == "anthropic" {
-			return "anthropic"
+		if spec.Name == "anthropic" || spec.Name == "gemini" {
+			return spec.Name
 		}
 		return "openai"
 	}
@@ -498,6 +804,14 @@ func zhipuVisionModel(model string) bool {
 
 func (c *Config) providerConfigMap() map[string]ProviderConfig {
 	out := make(map[string]ProviderConfig)
+	c.forEachProviderConfig(func(name string, cfg ProviderConfig) {
+		out[name] = cfg
+	})
+	return out
+}
+
+// forEachProviderConfig 按 ProvidersConfig 字段声明顺序遍历提供商配置
+func (c *Config) forEachProviderConfig(fn func(name string, cfg ProviderConfig)) {
 	val := reflect.ValueOf(c.Providers)
 	typ := reflect.TypeOf(c.Providers)
 	for i := 0; i < typ.NumField(); i++ {
@@ -511,9 +825,19 @@ func (c *Config) providerConfigMap() map[string]ProviderConfig {
 		if !ok {
 			continue
 		}
-		out[name] = cfg
+		fn(name, cfg)
 	}
-	return out
+}
+
+// ConfiguredProviders 返回已配置 API Key 的提供商名称（按配置字段顺序）
+func (c *Config) ConfiguredProviders() []string {
+	configured := []string{}
+	c.forEachProviderConfig(func(name string, cfg ProviderConfig) {
+		if strings.TrimSpace(cfg.APIKey) != "" {
+			configured = append(configured, name)
+		}
+	})
+	return configured
 }
 
 func looksLikeRawModelID(model string) bool {
@@ -538,9 +862,7 @@ func looksLikeRawModelID(model string) bool {
 // SupportsImageInput reports whether the target model should receive multimodal image parts.
 // Explicit per-model config wins; otherwise we fall back to provider heuristics.
 func (c *Config) SupportsImageInput(model string) bool {
-	if model == "" {
-		model = c.Agents.Defaults.Model
-	}
+	model = c.ResolveModel(model)
 	if model == "" {
 		return false
 	}
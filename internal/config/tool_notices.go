@@ -0,0 +1,22 @@
+package config
+
+import "strings"
+
+const (
+	ToolNoticesOff     = "off"
+	ToolNoticesBrief   = "brief"
+	ToolNoticesVerbose = "verbose"
+)
+
+// NormalizeToolNotices normalizes the channel tool notice verbosity.
+// Any unknown value falls back to off.
+func NormalizeToolNotices(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case ToolNoticesBrief:
+		return ToolNoticesBrief
+	case ToolNoticesVerbose:
+		return ToolNoticesVerbose
+	default:
+		return ToolNoticesOff
+	}
+}
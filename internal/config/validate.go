@@ -0,0 +1,254 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	validWebFetchModes = []string{"http", "auto", "browser", "chrome"}
+	validWaitUntil     = []string{"load", "domcontentloaded", "networkidle", "commit"}
+	validAPIFormats    = []string{"openai", "anthropic", "gemini"}
+	validSessionFormat = []string{"json", "jsonl"}
+	validSessionScopes = []string{"chat", "sender", "chat_sender"}
+	validMissedOnce    = []string{"skip", "run", "notify"}
+)
+
+// ValidationError 汇总配置中的全部问题，每条都带字段路径与修复提示
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid config: " + e.Problems[0]
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid config (%d problems):", len(e.Problems))
+	for _, p := range e.Problems {
+		b.WriteString("\n  - " + p)
+	}
+	return b.String()
+}
+
+// IsValidationError 判断错误是否来自 Validate（此时 LoadConfig 仍返回了解析出的配置）
+func IsValidationError(err error) bool {
+	var verr *ValidationError
+	return errors.As(err, &verr)
+}
+
+// isDiscordCommandName 检查（已转小写的）Discord 斜杠命令名：1-32 个小写字母、数字、- 或 _
+func isDiscordCommandName(name string) bool {
+	if len(name) == 0 || len(name) > 32 {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+type configValidator struct {
+	problems []string
+}
+
+func (v *configValidator) addf(field, format string, args ...interface{}) {
+	v.problems = append(v.problems, field+": "+fmt.Sprintf(format, args...))
+}
+
+func (v *configValidator) nonNegative(field string, value int) {
+	if value < 0 {
+		v.addf(field, "must be >= 0, got %d (use 0 for the default)", value)
+	}
+}
+
+func (v *configValidator) port(field string, value int, allowZero bool) {
+	if value == 0 && allowZero {
+		return
+	}
+	if value < 1 || value > 65535 {
+		v.addf(field, "must be a port between 1 and 65535, got %d", value)
+	}
+}
+
+func (v *configValidator) oneOf(field, value string, allowed []string) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return
+		}
+	}
+	v.addf(field, "unsupported value %q (expected one of: %s)", value, strings.Join(allowed, ", "))
+}
+
+// Validate 检查枚举值、数值范围与必需的组合，返回 *ValidationError 汇总全部问题
+func (c *Config) Validate() error {
+	v := &configValidator{}
+
+	v.validateAgentDefaults("agents.defaults", c.Agents.Defaults)
+	for _, name := range c.Agents.ProfileNames() {
+		v.validateAgentDefaults("agents.profiles."+name, c.Agents.Profiles[name])
+	}
+
+	v.validateWebFetch(c.Tools.Web.Fetch)
+	v.nonNegative("tools.web.search.maxResults", c.Tools.Web.Search.MaxResults)
+	v.nonNegative("tools.exec.timeout", c.Tools.Exec.Timeout)
+	v.nonNegative("tools.exec.maxTimeout", c.Tools.Exec.MaxTimeout)
+	for i, pattern := range c.Tools.Exec.DangerousPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			v.addf(fmt.Sprintf("tools.exec.dangerousPatterns[%d]", i), "invalid regular expression %q: %v", pattern, err)
+		}
+	}
+	mcpNames := make([]string, 0, len(c.Tools.MCPServers))
+	for name := range c.Tools.MCPServers {
+		mcpNames = append(mcpNames, name)
+	}
+	sort.Strings(mcpNames)
+	for _, name := range mcpNames {
+		server := c.Tools.MCPServers[name]
+		field := "tools.mcpServers." + name
+		hasCommand := strings.TrimSpace(server.Command) != ""
+		hasURL := strings.TrimSpace(server.URL) != ""
+		switch {
+		case !hasCommand && !hasURL:
+			v.addf(field, "set either command (stdio server) or url (HTTP server)")
+		case hasCommand && hasURL:
+			v.addf(field, "set only one of command or url, not both")
+		case hasURL:
+			if u, err := url.Parse(server.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				v.addf(field+".url", "must be an http(s) URL, got %q", server.URL)
+			}
+		}
+	}
+
+	v.nonNegative("tools.httpGet.timeoutSeconds", c.Tools.HTTPGet.TimeoutSeconds)
+	v.nonNegative("tools.httpGet.maxChars", c.Tools.HTTPGet.MaxChars)
+	endpointNames := make([]string, 0, len(c.Tools.HTTPGet.Endpoints))
+	for name := range c.Tools.HTTPGet.Endpoints {
+		endpointNames = append(endpointNames, name)
+	}
+	sort.Strings(endpointNames)
+	for _, name := range endpointNames {
+		base := strings.TrimSpace(c.Tools.HTTPGet.Endpoints[name].BaseURL)
+		if u, err := url.Parse(base); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.addf("tools.httpGet.endpoints."+name+".baseUrl", "must be an http(s) URL such as https://api.example.com/v1/, got %q", base)
+		}
+	}
+
+	v.port("gateway.port", c.Gateway.Port, false)
+	v.oneOf("cron.missedOnceJobs", c.Cron.MissedOnceJobs, validMissedOnce)
+
+	v.nonNegative("channels.maxMessageAgeSeconds", c.Channels.MaxMessageAgeSeconds)
+	v.nonNegative("channels.telegram.maxMediaMB", c.Channels.Telegram.MaxMediaMB)
+	v.nonNegative("channels.rateLimit.messagesPerMinute", c.Channels.RateLimit.MessagesPerMinute)
+	v.nonNegative("channels.rateLimit.burst", c.Channels.RateLimit.Burst)
+	scopeChannels := make([]string, 0, len(c.Channels.SessionScope))
+	for name := range c.Channels.SessionScope {
+		scopeChannels = append(scopeChannels, name)
+	}
+	sort.Strings(scopeChannels)
+	for _, name := range scopeChannels {
+		v.oneOf("channels.sessionScope."+name, c.Channels.SessionScope[name], validSessionScopes)
+	}
+	if name := strings.ToLower(strings.TrimSpace(c.Channels.Discord.SlashCommand)); name != "" && name != "off" && !isDiscordCommandName(name) {
+		v.addf("channels.discord.slashCommand", "must be 1-32 letters, digits, '-' or '_' (or \"off\"), got %q", c.Channels.Discord.SlashCommand)
+	}
+	limitTools := make([]string, 0, len(c.Tools.ResultLimits))
+	for name := range c.Tools.ResultLimits {
+		limitTools = append(limitTools, name)
+	}
+	sort.Strings(limitTools)
+	for _, name := range limitTools {
+		v.nonNegative("tools.resultLimits."+name, c.Tools.ResultLimits[name])
+	}
+	if ws := c.Channels.WebSocket; ws.Enabled {
+		v.port("channels.websocket.port", ws.Port, true)
+		if ws.Path != "" && !strings.HasPrefix(ws.Path, "/") {
+			v.addf("channels.websocket.path", "must start with \"/\", got %q", ws.Path)
+		}
+		v.nonNegative("channels.websocket.pingIntervalSeconds", ws.PingIntervalSeconds)
+	}
+	if email := c.Channels.Email; email.Enabled {
+		v.port("channels.email.imapPort", email.IMAPPort, true)
+		v.port("channels.email.smtpPort", email.SMTPPort, true)
+		v.nonNegative("channels.email.pollIntervalSeconds", email.PollIntervalSeconds)
+	}
+
+	c.forEachProviderConfig(func(name string, cfg ProviderConfig) {
+		v.oneOf("providers."+name+".apiFormat", cfg.APIFormat, validAPIFormats)
+		if base := strings.TrimSpace(cfg.APIBase); base != "" {
+			if u, err := url.Parse(base); err != nil || u.Scheme == "" || u.Host == "" {
+				v.addf("providers."+name+".apiBase", "must be an absolute URL such as https://api.example.com/v1, got %q", base)
+			}
+		}
+	})
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+func (v *configValidator) validateAgentDefaults(prefix string, d AgentDefaults) {
+	v.nonNegative(prefix+".maxTokens", d.MaxTokens)
+	v.nonNegative(prefix+".maxToolIterations", d.MaxToolIterations)
+	v.nonNegative(prefix+".maxParallelTools", d.MaxParallelTools)
+	v.nonNegative(prefix+".toolTimeoutSeconds", d.ToolTimeoutSeconds)
+	v.nonNegative(prefix+".turnTimeoutSeconds", d.TurnTimeoutSeconds)
+	v.nonNegative(prefix+".toolCallWarnThreshold", d.ToolCallWarnThreshold)
+	v.nonNegative(prefix+".repeatedToolCallWindow", d.RepeatedToolCallWindow)
+	v.nonNegative(prefix+".maxConcurrentRequests", d.MaxConcurrentRequests)
+	v.oneOf(prefix+".sessionFormat", d.SessionFormat, validSessionFormat)
+	if d.Temperature < 0 || d.Temperature > 2 {
+		v.addf(prefix+".temperature", "must be between 0 and 2, got %g", d.Temperature)
+	}
+}
+
+func (v *configValidator) validateWebFetch(f WebFetchConfig) {
+	const prefix = "tools.web.fetch"
+	v.oneOf(prefix+".mode", f.Mode, validWebFetchModes)
+	v.oneOf(prefix+".waitUntil", f.WaitUntil, validWaitUntil)
+	v.nonNegative(prefix+".timeout", f.Timeout)
+	v.nonNegative(prefix+".renderWaitMs", f.RenderWaitMs)
+	v.nonNegative(prefix+".smartWaitMs", f.SmartWaitMs)
+	v.nonNegative(prefix+".stableWaitMs", f.StableWaitMs)
+	v.nonNegative(prefix+".maxConcurrent", f.MaxConcurrent)
+	v.nonNegative(prefix+".chrome.launchTimeoutMs", f.Chrome.LaunchTimeoutMs)
+
+	mode := strings.ToLower(strings.TrimSpace(f.Mode))
+	if mode != "browser" && mode != "chrome" && mode != "auto" {
+		return
+	}
+	if cdp := strings.TrimSpace(f.Chrome.CDPEndpoint); cdp != "" {
+		if u, err := url.Parse(cdp); err != nil || u.Scheme == "" || u.Host == "" {
+			v.addf(prefix+".chrome.cdpEndpoint", "must be a URL such as http://127.0.0.1:9222, got %q", cdp)
+		}
+	}
+}
+
+// Warnings 返回依赖本机环境、不影响加载的配置提示（如显式配置的 webfetcher 脚本不存在）；
+// 这类检查不放进 Validate，避免配置能否加载取决于当前机器的文件系统
+func (c *Config) Warnings() []string {
+	var warnings []string
+	f := c.Tools.Web.Fetch
+	mode := strings.ToLower(strings.TrimSpace(f.Mode))
+	if mode == "browser" || mode == "chrome" || mode == "auto" {
+		// scriptPath 为空时运行期会自动查找 webfetcher/fetch.mjs
+		if script := strings.TrimSpace(f.ScriptPath); script != "" {
+			if info, err := os.Stat(expandPath(script)); err != nil || info.IsDir() {
+				warnings = append(warnings, fmt.Sprintf("tools.web.fetch.scriptPath: %s mode needs the webfetcher script, but %q does not exist (run `make webfetch-install` or fix the path)", mode, script))
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultConfigIsValid(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Validate())
+}
+
+func TestValidateRejectsInvalidConfigs(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(cfg *Config)
+		want   []string
+	}{
+		{
+			name:   "negative timeouts",
+			mutate: func(cfg *Config) { cfg.Tools.Exec.Timeout = -1; cfg.Agents.Defaults.ToolTimeoutSeconds = -5 },
+			want:   []string{"tools.exec.timeout: must be >= 0, got -1", "agents.defaults.toolTimeoutSeconds: must be >= 0, got -5"},
+		},
+		{
+			name:   "bad fetch mode",
+			mutate: func(cfg *Config) { cfg.Tools.Web.Fetch.Mode = "headless" },
+			want:   []string{`tools.web.fetch.mode: unsupported value "headless" (expected one of: http, auto, browser, chrome)`},
+		},
+		{
+			name:   "bad waitUntil",
+			mutate: func(cfg *Config) { cfg.Tools.Web.Fetch.WaitUntil = "idle" },
+			want:   []string{`tools.web.fetch.waitUntil: unsupported value "idle"`},
+		},
+		{
+			name:   "gateway port zero",
+			mutate: func(cfg *Config) { cfg.Gateway.Port = 0 },
+			want:   []string{"gateway.port: must be a port between 1 and 65535, got 0"},
+		},
+		{
+			name:   "bad dangerous pattern",
+			mutate: func(cfg *Config) { cfg.Tools.Exec.DangerousPatterns = []string{"rm -rf (", "ok"} },
+			want:   []string{"tools.exec.dangerousPatterns[0]: invalid regular expression"},
+		},
+		{
+			name: "mcp server without command or url",
+			mutate: func(cfg *Config) {
+				cfg.Tools.MCPServers = map[string]MCPServerConfig{"empty": {}, "both": {Command: "npx", URL: "http://x"}}
+			},
+			want: []string{"tools.mcpServers.both: set only one of command or url", "tools.mcpServers.empty: set either command"},
+		},
+		{
+			name:   "temperature and profile ranges",
+			mutate: func(cfg *Config) { cfg.Agents.Profiles = map[string]AgentDefaults{"hot": {Temperature: 3}} },
+			want:   []string{"agents.profiles.hot.temperature: must be between 0 and 2, got 3"},
+		},
+		{
+			name:   "bad session format",
+			mutate: func(cfg *Config) { cfg.Agents.Defaults.SessionFormat = "sqlite" },
+			want:   []string{`agents.defaults.sessionFormat: unsupported value "sqlite" (expected one of: json, jsonl)`},
+		},
+		{
+			name: "bad session scope",
+			mutate: func(cfg *Config) {
+				cfg.Channels.SessionScope = map[string]string{"telegram": "chat_sender", "discord": "user"}
+			},
+			want: []string{`channels.sessionScope.discord: unsupported value "user" (expected one of: chat, sender, chat_sender)`},
+		},
+		{
+			name:   "bad discord slash command",
+			mutate: func(cfg *Config) { cfg.Channels.Discord.SlashCommand = "ask me" },
+			want:   []string{`channels.discord.slashCommand: must be 1-32 letters, digits, '-' or '_' (or "off"), got "ask me"`},
+		},
+		{
+			name: "negative websocket ping interval",
+			mutate: func(cfg *Config) {
+				cfg.Channels.WebSocket.Enabled = true
+				cfg.Channels.WebSocket.PingIntervalSeconds = -1
+			},
+			want: []string{`channels.websocket.pingIntervalSeconds: must be >= 0, got -1 (use 0 for the default)`},
+		},
+		{
+			name:   "negative rate limit",
+			mutate: func(cfg *Config) { cfg.Channels.RateLimit.MessagesPerMinute = -1 },
+			want:   []string{`channels.rateLimit.messagesPerMinute: must be >= 0, got -1 (use 0 for the default)`},
+		},
+		{
+			name:   "negative repeated tool call window",
+			mutate: func(cfg *Config) { cfg.Agents.Defaults.RepeatedToolCallWindow = -2 },
+			want:   []string{`agents.defaults.repeatedToolCallWindow: must be >= 0, got -2 (use 0 for the default)`},
+		},
+		{
+			name:   "negative tool result limit",
+			mutate: func(cfg *Config) { cfg.Tools.ResultLimits = map[string]int{"web_fetch": -5, "read_file": 1000} },
+			want:   []string{`tools.resultLimits.web_fetch: must be >= 0, got -5 (use 0 for the default)`},
+		},
+		{
+			name:   "bad missed once-job policy",
+			mutate: func(cfg *Config) { cfg.Cron.MissedOnceJobs = "retry" },
+			want:   []string{`cron.missedOnceJobs: unsupported value "retry" (expected one of: skip, run, notify)`},
+		},
+		{
+			name: "http_get endpoint base url",
+			mutate: func(cfg *Config) {
+				cfg.Tools.HTTPGet.Endpoints = map[string]HTTPGetEndpointConfig{"weather": {BaseURL: "api.weather.example"}}
+			},
+			want: []string{`tools.httpGet.endpoints.weather.baseUrl: must be an http(s) URL`},
+		},
+		{
+			name: "provider api format and base",
+			mutate: func(cfg *Config) {
+				cfg.Providers.OpenAI.APIFormat = "grpc"
+				cfg.Providers.DeepSeek.APIBase = "api.deepseek.com"
+			},
+			want: []string{`providers.openai.apiFormat: unsupported value "grpc"`, `providers.deepseek.apiBase: must be an absolute URL`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.mutate(cfg)
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.True(t, IsValidationError(err))
+			verr := err.(*ValidationError)
+			assert.Len(t, verr.Problems, len(tt.want), "problems: %v", verr.Problems)
+			for _, want := range tt.want {
+				assert.Contains(t, err.Error(), want)
+			}
+		})
+	}
+}
+
+func TestWarningsReportMissingWebFetchScriptWithoutFailingValidation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tools.Web.Fetch.Mode = "chrome"
+	assert.NoError(t, cfg.Validate())
+	assert.Empty(t, cfg.Warnings())
+
+	script := filepath.Join(t.TempDir(), "fetch.mjs")
+	require.NoError(t, os.WriteFile(script, []byte("// stub"), 0644))
+	cfg.Tools.Web.Fetch.ScriptPath = script
+	assert.NoError(t, cfg.Validate())
+	assert.Empty(t, cfg.Warnings())
+
+	// 脚本是否存在取决于本机，只作为提示，不阻止加载
+	cfg.Tools.Web.Fetch.Mode = "browser"
+	cfg.Tools.Web.Fetch.ScriptPath = filepath.Join(t.TempDir(), "missing", "fetch.mjs")
+	assert.NoError(t, cfg.Validate())
+	warnings := cfg.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "tools.web.fetch.scriptPath: browser mode needs the webfetcher script")
+}
+
+func TestLoadConfigReportsAllValidationProblems(t *testing.T) {
+	tmpDir := t.TempDir()
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", tmpDir)
+	defer os.Setenv("HOME", origHome)
+
+	require.NoError(t, os.MkdirAll(GetConfigDir(), 0755))
+	raw := `{"gateway":{"port":0},"tools":{"web":{"fetch":{"mode":"turbo","timeout":-3}}}}`
+	require.NoError(t, os.WriteFile(GetConfigPath(), []byte(raw), 0600))
+
+	cfg, err := LoadConfig()
+	require.Error(t, err)
+	assert.True(t, IsValidationError(err))
+	assert.Contains(t, err.Error(), "invalid config (3 problems)")
+	assert.Contains(t, err.Error(), GetConfigPath())
+	// 校验失败时仍返回解析出的配置，供 status / Web UI 展示
+	require.NotNil(t, cfg)
+	assert.Equal(t, "turbo", cfg.Tools.Web.Fetch.Mode)
+}
@@ -2,6 +2,7 @@ package cron
 
 import (
 	"bytes"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -292,6 +293,11 @@ func TestServiceWithEmptyStorePath(t *testing.T) {
 	service2 := NewService("")
 	// 不应该加载之前的任务
 	assert.Len(t, service2.ListJobs(), 0)
+
+	// 执行历史也不写入当前目录
+	service.executeJob(job, "every")
+	_, err = os.Stat("cron_history.json")
+	assert.True(t, os.IsNotExist(err), "in-memory service must not write cron_history.json")
 }
 
 func TestLoadCorruptedFile(t *testing.T) {
@@ -317,7 +323,7 @@ func TestExecuteJobLogsAttemptAndCompletion(t *testing.T) {
 	var buf bytes.Buffer
 	lg.Cron.SetOutput(&buf)
 
-	service := NewService("")
+	service := NewService(filepath.Join(t.TempDir(), "jobs.json"))
 	service.SetJobHandler(func(job *Job) (string, error) {
 		return "ok", nil
 	})
@@ -341,7 +347,7 @@ func TestExecuteJobLogsSkipReasons(t *testing.T) {
 	var buf bytes.Buffer
 	lg.Cron.SetOutput(&buf)
 
-	service := NewService("")
+	service := NewService(filepath.Join(t.TempDir(), "jobs.json"))
 
 	disabledJob := NewJob("disabled", Schedule{Type: ScheduleTypeEvery, EveryMs: 1000}, Payload{})
 	disabledJob.Enabled = false
@@ -355,3 +361,122 @@ func TestExecuteJobLogsSkipReasons(t *testing.T) {
 	assert.True(t, strings.Contains(logText, "reason=disabled"))
 	assert.True(t, strings.Contains(logText, "reason=no_handler"))
 }
+
+func TestBuildScheduleFromFields(t *testing.T) {
+	schedule, err := BuildScheduleFromFields("", "60000", "")
+	require.NoError(t, err)
+	assert.Equal(t, ScheduleTypeEvery, schedule.Type)
+	assert.Equal(t, int64(60000), schedule.EveryMs)
+
+	schedule, err = BuildScheduleFromFields("*/5 * * * *", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, ScheduleTypeCron, schedule.Type)
+	assert.Equal(t, "*/5 * * * *", schedule.Expr)
+
+	want := time.Date(2026, 3, 1, 8, 30, 0, 0, time.UTC).UnixMilli()
+	for _, at := range []string{"2026-03-01T08:30:00Z", "2026-03-01T08:30:00", "2026-03-01 08:30:00"} {
+		schedule, err = BuildScheduleFromFields("", "", at)
+		require.NoError(t, err, at)
+		assert.Equal(t, ScheduleTypeOnce, schedule.Type)
+		assert.Equal(t, want, schedule.AtMs, at)
+	}
+
+	_, err = BuildScheduleFromFields("", "", "")
+	assert.Error(t, err)
+	_, err = BuildScheduleFromFields("", "-1", "")
+	assert.Error(t, err)
+	_, err = BuildSchedule(ScheduleType("hourly"), "", 0, "")
+	assert.Error(t, err)
+}
+
+func writeMissedOnceJob(t *testing.T) (string, *Job) {
+	t.Helper()
+	storePath := filepath.Join(t.TempDir(), "jobs.json")
+	job := NewJob("standup reminder", Schedule{Type: ScheduleTypeOnce, AtMs: time.Now().Add(-10 * time.Minute).UnixMilli()}, Payload{Message: "standup"})
+	data, err := json.Marshal(map[string]*Job{job.ID: job})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(storePath, data, 0644))
+	return storePath, job
+}
+
+func TestMissedOnceJobFiresOnStartWhenEnabled(t *testing.T) {
+	storePath, job := writeMissedOnceJob(t)
+
+	service := NewService(storePath)
+	service.SetMissedOncePolicy("run")
+	fired := make(chan string, 1)
+	service.SetJobHandler(func(j *Job) (string, error) {
+		fired <- j.ID
+		return "ok", nil
+	})
+
+	missed := service.MissedOnceJobs()
+	require.Len(t, missed, 1)
+	assert.Equal(t, job.ID, missed[0].ID)
+
+	require.NoError(t, service.Start())
+	select {
+	case id := <-fired:
+		assert.Equal(t, job.ID, id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("missed once job was not fired on start")
+	}
+	service.Stop()
+
+	// 补执行后持久化了执行时间，再次启动不会重复触发
+	restarted := NewService(storePath)
+	assert.Empty(t, restarted.MissedOnceJobs())
+}
+
+func TestMissedOnceJobSkippedByDefault(t *testing.T) {
+	storePath, _ := writeMissedOnceJob(t)
+
+	service := NewService(storePath)
+	fired := make(chan string, 1)
+	service.SetJobHandler(func(j *Job) (string, error) {
+		fired <- j.ID
+		return "ok", nil
+	})
+	require.NoError(t, service.Start())
+	defer service.Stop()
+
+	select {
+	case <-fired:
+		t.Fatal("missed once job should not fire without the run policy")
+	case <-time.After(200 * time.Millisecond):
+	}
+	assert.Len(t, service.MissedOnceJobs(), 1)
+}
+
+func TestMissedOnceJobNotifyPolicy(t *testing.T) {
+	storePath, job := writeMissedOnceJob(t)
+
+	service := NewService(storePath)
+	service.SetMissedOncePolicy("notify")
+	service.SetJobHandler(func(j *Job) (string, error) {
+		t.Errorf("job should not run under notify policy")
+		return "", nil
+	})
+	var notified map[string]interface{}
+	service.SetNotificationHandler(func(title, body string, data map[string]interface{}) {
+		// 回调在释放锁后调用，可以查询服务
+		_, ok := service.GetJob(job.ID)
+		assert.True(t, ok)
+		notified = data
+	})
+	require.NoError(t, service.Start())
+	service.Stop()
+
+	require.NotNil(t, notified)
+	assert.Equal(t, job.ID, notified["jobId"])
+	assert.Equal(t, "missed", notified["status"])
+
+	// 提醒状态已持久化，再次启动不会重复提醒
+	restarted := NewService(storePath)
+	restarted.SetMissedOncePolicy("notify")
+	restarted.SetNotificationHandler(func(title, body string, data map[string]interface{}) {
+		t.Errorf("missed job should only be notified once")
+	})
+	require.NoError(t, restarted.Start())
+	restarted.Stop()
+}
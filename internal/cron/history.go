@@ -17,7 +17,7 @@ type HistoryStore struct {
 	maxSize   int
 }
 
-// NewHistoryStore 创建历史存储
+// NewHistoryStore 创建历史存储；storePath 为空时只保存在内存中
 func NewHistoryStore(storePath string) *HistoryStore {
 	h := &HistoryStore{
 		storePath: storePath,
@@ -93,6 +93,9 @@ func (h *HistoryStore) GetRecord(id string) (*ExecutionRecord, bool) {
 }
 
 func (h *HistoryStore) load() {
+	if h.storePath == "" {
+		return
+	}
 	data, err := os.ReadFile(h.storePath)
 	if err != nil {
 		return
@@ -101,6 +104,9 @@ func (h *HistoryStore) load() {
 }
 
 func (h *HistoryStore) save() error {
+	if h.storePath == "" {
+		return nil
+	}
 	dir := filepath.Dir(h.storePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
@@ -0,0 +1,72 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scheduleAtLayouts 一次性任务支持的时间格式（无时区的格式按 UTC 解析，与历史行为一致）
+var scheduleAtLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+}
+
+// BuildSchedule 按调度类型构建 Schedule，CLI 与 Web API 共用
+func BuildSchedule(scheduleType ScheduleType, expr string, everyMs int64, at string) (Schedule, error) {
+	switch scheduleType {
+	case ScheduleTypeEvery:
+		if everyMs <= 0 {
+			return Schedule{}, fmt.Errorf("every must be a positive number of milliseconds")
+		}
+		return Schedule{Type: ScheduleTypeEvery, EveryMs: everyMs}, nil
+	case ScheduleTypeCron:
+		expr = strings.TrimSpace(expr)
+		if expr == "" {
+			return Schedule{}, fmt.Errorf("cron expression is required")
+		}
+		return Schedule{Type: ScheduleTypeCron, Expr: expr}, nil
+	case ScheduleTypeOnce:
+		runAt, err := ParseScheduleAt(at)
+		if err != nil {
+			return Schedule{}, err
+		}
+		return Schedule{Type: ScheduleTypeOnce, AtMs: runAt.UnixMilli()}, nil
+	default:
+		return Schedule{}, fmt.Errorf("invalid schedule type: %s, use: every, cron, or once", scheduleType)
+	}
+}
+
+// BuildScheduleFromFields 根据 cron/every/at 中第一个非空字段构建 Schedule（every 为毫秒字符串）
+func BuildScheduleFromFields(expr, every, at string) (Schedule, error) {
+	switch {
+	case strings.TrimSpace(expr) != "":
+		return BuildSchedule(ScheduleTypeCron, expr, 0, "")
+	case strings.TrimSpace(every) != "":
+		everyMs, err := strconv.ParseInt(strings.TrimSpace(every), 10, 64)
+		if err != nil {
+			return Schedule{}, fmt.Errorf("invalid every format: %v", err)
+		}
+		return BuildSchedule(ScheduleTypeEvery, "", everyMs, "")
+	case strings.TrimSpace(at) != "":
+		return BuildSchedule(ScheduleTypeOnce, "", 0, at)
+	default:
+		return Schedule{}, fmt.Errorf("schedule is required (cron, every, or at)")
+	}
+}
+
+// ParseScheduleAt 解析一次性任务的执行时间
+func ParseScheduleAt(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("at is required for once schedule")
+	}
+	for _, layout := range scheduleAtLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid at format %q, use RFC3339 or 2006-01-02 15:04:05", raw)
+}
@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -32,8 +34,19 @@ type Service struct {
 	onNotify     NotificationFunc
 	cron         *cron.Cron
 	historyStore *HistoryStore
+	missedOnce   string
 }
 
+// 启动时对错过的一次性任务（停机期间到点、从未执行）的处理策略
+const (
+	// MissedOnceSkip 只记录日志（默认）
+	MissedOnceSkip = "skip"
+	// MissedOnceRun 启动后立即补执行
+	MissedOnceRun = "run"
+	// MissedOnceNotify 发送通知提醒用户，不执行
+	MissedOnceNotify = "notify"
+)
+
 // NewService 创建定时任务服务
 func NewService(storePath string) *Service {
 	s := &Service{
@@ -45,7 +58,11 @@ func NewService(storePath string) *Service {
 	}
 	s.load()
 
-	historyPath := filepath.Join(filepath.Dir(storePath), "cron_history.json")
+	// 没有 storePath 时历史同样只保存在内存中
+	historyPath := ""
+	if storePath != "" {
+		historyPath = filepath.Join(filepath.Dir(storePath), "cron_history.json")
+	}
 	s.historyStore = NewHistoryStore(historyPath)
 
 	return s
@@ -61,6 +78,45 @@ func (s *Service) SetNotificationHandler(handler NotificationFunc) {
 	s.onNotify = handler
 }
 
+// SetMissedOncePolicy 设置启动时错过的一次性任务的处理策略：skip（默认）/ run / notify
+func (s *Service) SetMissedOncePolicy(policy string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	switch policy {
+	case MissedOnceRun, MissedOnceNotify:
+		s.missedOnce = policy
+	default:
+		s.missedOnce = MissedOnceSkip
+	}
+}
+
+// MissedOnceJobs 返回已启用、执行时间已过但从未执行过的一次性任务
+func (s *Service) MissedOnceJobs() []*Job {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.missedOnceJobsLocked(time.Now())
+}
+
+func (s *Service) missedOnceJobsLocked(now time.Time) []*Job {
+	var missed []*Job
+	for _, job := range s.jobs {
+		if !job.Enabled || job.Schedule.Type != ScheduleTypeOnce || job.Schedule.AtMs <= 0 {
+			continue
+		}
+		if job.Schedule.AtMs > now.UnixMilli() || job.LastRunAtMs >= job.Schedule.AtMs {
+			continue
+		}
+		// 旧版本执行过的任务没有 LastRunAtMs，以执行历史为准
+		if s.historyStore != nil && len(s.historyStore.GetRecords(job.ID, 1)) > 0 {
+			continue
+		}
+		missed = append(missed, job)
+	}
+	sort.Slice(missed, func(i, j int) bool { return missed[i].Schedule.AtMs < missed[j].Schedule.AtMs })
+	return missed
+}
+
 // AddJob 添加任务
 func (s *Service) AddJob(name string, schedule Schedule, payload Payload) (*Job, error) {
 	return s.AddJobWithOptions(name, schedule, payload, "")
@@ -189,9 +245,9 @@ func (s *Service) EnableJob(id string, enabled bool) (*Job, bool) {
 // Start 启动服务
 func (s *Service) Start() error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	if s.running {
+		s.mu.Unlock()
 		return fmt.Errorf("service already running")
 	}
 
@@ -210,23 +266,75 @@ func (s *Service) Start() error {
 			s.scheduleJob(job)
 		}
 	}
+	notifications := s.handleMissedOnceJobsLocked()
 
 	s.cron.Start()
+	s.mu.Unlock()
 
+	// 通知回调可能回调 Service（如查询任务），在释放 s.mu 后调用
+	for _, notify := range notifications {
+		notify()
+	}
 	return nil
 }
 
-// Stop 停止服务
+// handleMissedOnceJobsLocked 按策略处理停机期间错过的一次性任务。调用方必须持有 s.mu；
+// notify 策略下返回待发送的通知，由调用方释放锁后执行。已提醒过的任务会被记录并持久化，不会重复提醒
+func (s *Service) handleMissedOnceJobsLocked() []func() {
+	policy := s.missedOnce
+	if policy == "" {
+		policy = MissedOnceSkip
+	}
+	var notifications []func()
+	for _, job := range s.missedOnceJobsLocked(time.Now()) {
+		if policy == MissedOnceNotify && job.MissedNotifiedAtMs >= job.Schedule.AtMs {
+			continue
+		}
+		at := time.UnixMilli(job.Schedule.AtMs)
+		s.logCronf("cron missed once job job=%s job_id=%s at=%s policy=%s", job.Name, job.ID, at.Format(time.RFC3339), policy)
+		switch policy {
+		case MissedOnceRun:
+			s.wg.Add(1)
+			go func(job *Job) {
+				defer s.wg.Done()
+				s.executeJob(job, "missed")
+			}(job)
+		case MissedOnceNotify:
+			if s.onNotify == nil {
+				continue
+			}
+			job.MissedNotifiedAtMs = job.Schedule.AtMs
+			onNotify := s.onNotify
+			body := fmt.Sprintf("任务 \"%s\" 原定于 %s 执行，因服务未运行而错过", job.Name, at.Format("2006-01-02 15:04"))
+			data := map[string]interface{}{
+				"type":    "scheduled_task",
+				"jobId":   job.ID,
+				"jobName": job.Name,
+				"status":  "missed",
+			}
+			notifications = append(notifications, func() { onNotify("定时任务已错过", body, data) })
+		}
+	}
+	if len(notifications) > 0 {
+		if err := s.save(); err != nil {
+			s.logCronf("cron save failed after missed notifications err=%v", err)
+		}
+	}
+	return notifications
+}
+
+// Stop 停止服务。等待调度 goroutine 退出时不持有 s.mu，
+// 避免与正在执行、需要写回任务状态的一次性任务互相等待
 func (s *Service) Stop() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.running {
+		s.mu.Unlock()
 		return
 	}
-
 	s.running = false
 	close(s.stopChan)
+	s.mu.Unlock()
+
 	s.cron.Stop()
 	s.wg.Wait()
 }
@@ -367,6 +475,10 @@ func (s *Service) executeJob(job *Job, trigger string) {
 		return
 	}
 
+	if job.Schedule.Type == ScheduleTypeOnce {
+		s.markOnceJobRun(job)
+	}
+
 	// Create execution record
 	record := ExecutionRecord{
 		ID:        fmt.Sprintf("exec_%d", time.Now().UnixNano()),
@@ -429,6 +541,16 @@ func (s *Service) executeJob(job *Job, trigger string) {
 	}
 }
 
+// markOnceJobRun 记录一次性任务的执行时间并持久化，重启后不会被当作错过的任务
+func (s *Service) markOnceJobRun(job *Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job.LastRunAtMs = time.Now().UnixMilli()
+	if err := s.save(); err != nil {
+		s.logCronf("cron save failed job_id=%s err=%v", job.ID, err)
+	}
+}
+
 // GetHistoryStore 获取历史存储
 func (s *Service) GetHistoryStore() *HistoryStore {
 	return s.historyStore
@@ -63,6 +63,10 @@ type Job struct {
 	Enabled       bool     `json:"enabled"`
 	Created       int64    `json:"created"`
 	ExecutionMode string   `json:"executionMode,omitempty"` // safe, ask, auto
+	// LastRunAtMs 最近一次执行开始时间（仅一次性任务记录），用于启动时识别错过的任务
+	LastRunAtMs int64 `json:"lastRunAtMs,omitempty"`
+	// MissedNotifiedAtMs 已发送错过提醒的计划执行时间（仅一次性任务），重启后不再重复提醒
+	MissedNotifiedAtMs int64 `json:"missedNotifiedAtMs,omitempty"`
 }
 
 // GetExecutionMode 获取任务的执行模式，默认为 ask
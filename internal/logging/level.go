@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Level 日志级别，数值越大输出越详细
+type Level int32
+
+const (
+	LevelError Level = iota
+	LevelWarn
+	LevelInfo
+	LevelDebug
+)
+
+// EnvLevel 未显式指定 Options.Level 时读取的环境变量
+const EnvLevel = "MAXCLAW_LOG_LEVEL"
+
+var currentLevel = int32(LevelInfo)
+
+// String 返回级别名称
+func (l Level) String() string {
+	switch l {
+	case LevelError:
+		return "error"
+	case LevelWarn:
+		return "warn"
+	case LevelDebug:
+		return "debug"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel 解析 error / warn / info / debug（忽略大小写，warning 视为 warn）
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "error":
+		return LevelError, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "", "info":
+		return LevelInfo, nil
+	case "debug":
+		return LevelDebug, nil
+	default:
+		return LevelInfo, fmt.Errorf("unknown log level %q (expected error, warn, info or debug)", value)
+	}
+}
+
+// SetLevel 设置全局日志级别，对所有日志器立即生效
+func SetLevel(level Level) {
+	atomic.StoreInt32(&currentLevel, int32(level))
+}
+
+// GetLevel 返回当前全局日志级别
+func GetLevel() Level {
+	return Level(atomic.LoadInt32(&currentLevel))
+}
+
+// Enabled 判断指定级别的日志是否会输出，可用于跳过昂贵的日志参数构造
+func Enabled(level Level) bool {
+	return level <= GetLevel()
+}
+
+func levelFromEnv() string {
+	return os.Getenv(EnvLevel)
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func withLevel(t *testing.T, level Level) {
+	t.Helper()
+	previous := GetLevel()
+	SetLevel(level)
+	t.Cleanup(func() { SetLevel(previous) })
+}
+
+func TestInfoLevelSuppressesDebugLines(t *testing.T) {
+	withLevel(t, LevelInfo)
+
+	var buf bytes.Buffer
+	lg := NewLogger(&buf, "session", FormatText)
+	lg.Debugf("request dump %s", "payload")
+	lg.Debug("finish reason", "reason", "stop")
+	lg.Printf("message handled")
+	lg.Warn("slow response", "ms", 1200)
+
+	out := buf.String()
+	assert.NotContains(t, out, "request dump")
+	assert.NotContains(t, out, "finish reason")
+	assert.Contains(t, out, "message handled")
+	assert.Contains(t, out, "WARN slow response ms=1200")
+}
+
+func TestDebugLevelEmitsDebugLines(t *testing.T) {
+	withLevel(t, LevelDebug)
+
+	var buf bytes.Buffer
+	lg := NewLogger(&buf, "session", FormatJSON)
+	lg.Debugf("request dump %s", "payload")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(buf.String())), &entry))
+	assert.Equal(t, "debug", entry["level"])
+	assert.Equal(t, "request dump payload", entry["msg"])
+}
+
+func TestErrorLevelSuppressesInfoAndWarn(t *testing.T) {
+	withLevel(t, LevelError)
+
+	var buf bytes.Buffer
+	lg := NewLogger(&buf, "gateway", FormatText)
+	lg.Printf("started")
+	lg.Warn("retrying")
+	lg.Error("provider failed", "err", "timeout")
+
+	out := buf.String()
+	assert.NotContains(t, out, "started")
+	assert.NotContains(t, out, "retrying")
+	assert.Contains(t, out, "ERROR provider failed err=timeout")
+}
+
+func TestParseLevel(t *testing.T) {
+	for input, want := range map[string]Level{"": LevelInfo, "DEBUG": LevelDebug, "warning": LevelWarn, " error ": LevelError} {
+		got, err := ParseLevel(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	got, err := ParseLevel("verbose")
+	assert.Error(t, err)
+	assert.Equal(t, LevelInfo, got)
+}
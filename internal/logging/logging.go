@@ -2,22 +2,22 @@ package logging
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
 type Loggers struct {
-	Gateway  *log.Logger
-	Session  *log.Logger
-	Tools    *log.Logger
-	Channels *log.Logger
-	Cron     *log.Logger
-	Web      *log.Logger
-
-	files []*os.File
+	Gateway  *Logger
+	Session  *Logger
+	Tools    *Logger
+	Channels *Logger
+	Cron     *Logger
+	Web      *Logger
+
+	writers []*rotatingWriter
 }
 
 var (
@@ -27,7 +27,15 @@ var (
 )
 
 // Init sets up ~/.maxclaw/logs files. Safe to call multiple times.
+// Each file rotates by size (see MAXCLAW_LOG_MAX_SIZE_MB / MAXCLAW_LOG_MAX_BACKUPS);
+// the output format comes from MAXCLAW_LOG_FORMAT (text or json) and the level
+// from MAXCLAW_LOG_LEVEL (error, warn, info or debug).
 func Init(baseDir string) (*Loggers, error) {
+	return InitWithOptions(baseDir, optionsFromEnv())
+}
+
+// InitWithOptions is Init with an explicit format and level. Only the first call takes effect.
+func InitWithOptions(baseDir string, opts Options) (*Loggers, error) {
 	once.Do(func() {
 		if baseDir == "" {
 			initErr = fmt.Errorf("log base dir is empty")
@@ -39,66 +47,71 @@ func Init(baseDir string) (*Loggers, error) {
 			return
 		}
 
-		open := func(name string) (*log.Logger, *os.File, error) {
-			path := filepath.Join(logDir, name)
-			f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		level, levelErr := ParseLevel(opts.Level)
+		SetLevel(level)
+
+		maxBytes, backups := rotationFromEnv()
+		open := func(name string) (*Logger, *rotatingWriter, error) {
+			w, err := newRotatingWriter(filepath.Join(logDir, name), maxBytes, backups)
 			if err != nil {
 				return nil, nil, err
 			}
-			l := log.New(f, "", log.LstdFlags|log.Lmicroseconds)
-			return l, f, nil
+			return NewLogger(w, strings.TrimSuffix(name, ".log"), opts.Format), w, nil
 		}
 
 		l := &Loggers{}
-		var files []*os.File
+		var writers []*rotatingWriter
 
 		var err error
-		l.Gateway, files, err = attach(open, files, "gateway.log")
+		l.Gateway, writers, err = attach(open, writers, "gateway.log")
 		if err != nil {
 			initErr = err
 			return
 		}
-		l.Session, files, err = attach(open, files, "session.log")
+		l.Session, writers, err = attach(open, writers, "session.log")
 		if err != nil {
 			initErr = err
 			return
 		}
-		l.Tools, files, err = attach(open, files, "tools.log")
+		l.Tools, writers, err = attach(open, writers, "tools.log")
 		if err != nil {
 			initErr = err
 			return
 		}
-		l.Channels, files, err = attach(open, files, "channels.log")
+		l.Channels, writers, err = attach(open, writers, "channels.log")
 		if err != nil {
 			initErr = err
 			return
 		}
-		l.Cron, files, err = attach(open, files, "cron.log")
+		l.Cron, writers, err = attach(open, writers, "cron.log")
 		if err != nil {
 			initErr = err
 			return
 		}
-		l.Web, files, err = attach(open, files, "webui.log")
+		l.Web, writers, err = attach(open, writers, "webui.log")
 		if err != nil {
 			initErr = err
 			return
 		}
 
-		l.files = files
+		l.writers = writers
 		loggers = l
 
 		l.Gateway.Printf("logging initialized at %s", logDir)
+		if levelErr != nil {
+			l.Gateway.Warn("falling back to info log level", "error", levelErr)
+		}
 	})
 
 	return loggers, initErr
 }
 
-func attach(open func(string) (*log.Logger, *os.File, error), files []*os.File, name string) (*log.Logger, []*os.File, error) {
+func attach(open func(string) (*Logger, *rotatingWriter, error), writers []*rotatingWriter, name string) (*Logger, []*rotatingWriter, error) {
 	l, f, err := open(name)
 	if err != nil {
-		return nil, files, fmt.Errorf("open %s: %w", name, err)
+		return nil, writers, fmt.Errorf("open %s: %w", name, err)
 	}
-	return l, append(files, f), nil
+	return l, append(writers, f), nil
 }
 
 // Get returns initialized loggers (may be nil if Init failed or not called).
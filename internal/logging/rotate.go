@@ -0,0 +1,137 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultMaxSizeMB 单个日志文件的默认轮转阈值
+	DefaultMaxSizeMB = 10
+	// DefaultMaxBackups 默认保留的历史日志文件数
+	DefaultMaxBackups = 3
+
+	// EnvMaxSizeMB 覆盖轮转阈值（MB），设为 0 关闭轮转
+	EnvMaxSizeMB = "MAXCLAW_LOG_MAX_SIZE_MB"
+	// EnvMaxBackups 覆盖保留的历史文件数
+	EnvMaxBackups = "MAXCLAW_LOG_MAX_BACKUPS"
+)
+
+// rotatingWriter 按大小轮转的日志文件：写入将超过 maxBytes 时，
+// 把 name 依次重命名为 name.1 … name.N（丢弃最旧的）并重新打开 name
+type rotatingWriter struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64 // <= 0 表示不轮转
+	backups  int
+	file     *os.File
+	size     int64
+}
+
+func newRotatingWriter(path string, maxBytes int64, backups int) (*rotatingWriter, error) {
+	if backups < 0 {
+		backups = 0
+	}
+	w := &rotatingWriter{path: path, maxBytes: maxBytes, backups: backups}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *rotatingWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	w.file = f
+	w.size = info.Size()
+	return nil
+}
+
+// Write 实现 io.Writer；多个 logger 或 goroutine 并发写入同一 writer 时由 mu 串行化
+func (w *rotatingWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+	if w.maxBytes > 0 && w.size > 0 && w.size+int64(len(p)) > w.maxBytes {
+		if err := w.rotate(); err != nil {
+			return 0, fmt.Errorf("rotate %s: %w", w.path, err)
+		}
+	}
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *rotatingWriter) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	if w.backups == 0 {
+		if err := os.Remove(w.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return w.open()
+	}
+	for i := w.backups - 1; i >= 1; i-- {
+		src := backupName(w.path, i)
+		if err := os.Rename(src, backupName(w.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(w.path, backupName(w.path, 1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return w.open()
+}
+
+// Close 关闭底层文件
+func (w *rotatingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+func backupName(path string, index int) string {
+	return fmt.Sprintf("%s.%d", path, index)
+}
+
+// rotationFromEnv 读取轮转配置，未设置或非法时使用默认值
+func rotationFromEnv() (maxBytes int64, backups int) {
+	sizeMB := envInt(EnvMaxSizeMB, DefaultMaxSizeMB)
+	backups = envInt(EnvMaxBackups, DefaultMaxBackups)
+	return int64(sizeMB) * 1024 * 1024, backups
+}
+
+func envInt(key string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil || v < 0 {
+		return fallback
+	}
+	return v
+}
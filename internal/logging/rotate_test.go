@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingWriterRollsPastThreshold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.log")
+	w, err := newRotatingWriter(path, 100, 2)
+	require.NoError(t, err)
+	defer w.Close()
+
+	line := strings.Repeat("x", 59) + "\n"
+	for i := 0; i < 5; i++ {
+		_, err := w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, line, string(current))
+
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, line, string(rotated))
+	assert.FileExists(t, path+".2")
+	assert.NoFileExists(t, path+".3", "only maxBackups rotated files are kept")
+}
+
+func TestRotatingWriterResumesExistingSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cron.log")
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("a", 90)), 0644))
+
+	w, err := newRotatingWriter(path, 100, 1)
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte(strings.Repeat("b", 20)))
+	require.NoError(t, err)
+
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("a", 90), string(rotated))
+}
+
+func TestRotatingWriterConcurrentLoggers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tools.log")
+	w, err := newRotatingWriter(path, 2048, 50)
+	require.NoError(t, err)
+	defer w.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l := log.New(w, "", 0)
+			for j := 0; j < 100; j++ {
+				l.Print(strings.Repeat("z", 30))
+			}
+		}()
+	}
+	wg.Wait()
+
+	matches, err := filepath.Glob(path + "*")
+	require.NoError(t, err)
+	assert.Greater(t, len(matches), 1)
+
+	lines := 0
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), int64(2048))
+		data, err := os.ReadFile(m)
+		require.NoError(t, err)
+		for _, ln := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			assert.Equal(t, strings.Repeat("z", 30), ln)
+			lines++
+		}
+	}
+	assert.Equal(t, 400, lines)
+}
+
+func TestRotationFromEnv(t *testing.T) {
+	t.Setenv(EnvMaxSizeMB, "")
+	t.Setenv(EnvMaxBackups, "")
+	maxBytes, backups := rotationFromEnv()
+	assert.Equal(t, int64(DefaultMaxSizeMB)*1024*1024, maxBytes)
+	assert.Equal(t, DefaultMaxBackups, backups)
+
+	t.Setenv(EnvMaxSizeMB, "0")
+	t.Setenv(EnvMaxBackups, "7")
+	maxBytes, backups = rotationFromEnv()
+	assert.Equal(t, int64(0), maxBytes)
+	assert.Equal(t, 7, backups)
+
+	t.Setenv(EnvMaxSizeMB, "abc")
+	maxBytes, _ = rotationFromEnv()
+	assert.Equal(t, int64(DefaultMaxSizeMB)*1024*1024, maxBytes)
+}
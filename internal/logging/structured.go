@@ -0,0 +1,211 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// FormatText 默认的单行文本格式
+	FormatText = "text"
+	// FormatJSON 每条日志一个 JSON 对象，便于日志采集系统解析
+	FormatJSON = "json"
+
+	// EnvFormat 未显式指定 Options.Format 时读取的环境变量
+	EnvFormat = "MAXCLAW_LOG_FORMAT"
+)
+
+// Options 日志初始化选项
+type Options struct {
+	// Format 输出格式：text（默认）/ json
+	Format string
+	// Level 日志级别：error / warn / info（默认）/ debug，非法值按 info 处理
+	Level string
+}
+
+// NormalizeFormat 归一化日志格式，未知值按 text 处理
+func NormalizeFormat(format string) string {
+	if strings.EqualFold(strings.TrimSpace(format), FormatJSON) {
+		return FormatJSON
+	}
+	return FormatText
+}
+
+// Logger 在 *log.Logger 之上增加键值对日志；JSON 格式下 Printf 等输出同样被编码为 JSON
+type Logger struct {
+	*log.Logger
+	name string
+	json bool
+}
+
+// NewLogger 创建写入 w 的日志器，name 会作为 JSON 条目的 logger 字段
+func NewLogger(w io.Writer, name, format string) *Logger {
+	if NormalizeFormat(format) == FormatJSON {
+		return &Logger{Logger: log.New(w, "", 0), name: name, json: true}
+	}
+	return &Logger{Logger: log.New(w, "", log.LstdFlags|log.Lmicroseconds), name: name}
+}
+
+// Printf 兼容 log.Logger.Printf，按 info 级别输出
+func (l *Logger) Printf(format string, v ...interface{}) {
+	if l.enabled(LevelInfo) {
+		l.emit(LevelInfo, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Print 兼容 log.Logger.Print，按 info 级别输出
+func (l *Logger) Print(v ...interface{}) {
+	if l.enabled(LevelInfo) {
+		l.emit(LevelInfo, fmt.Sprint(v...), nil)
+	}
+}
+
+// Println 兼容 log.Logger.Println，按 info 级别输出
+func (l *Logger) Println(v ...interface{}) {
+	if l.enabled(LevelInfo) {
+		l.emit(LevelInfo, strings.TrimSuffix(fmt.Sprintln(v...), "\n"), nil)
+	}
+}
+
+// Debugf 按 debug 级别输出格式化日志
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	if l.enabled(LevelDebug) {
+		l.emit(LevelDebug, fmt.Sprintf(format, v...), nil)
+	}
+}
+
+// Info 记录一条带键值对的日志，kv 按 key, value 交替传入，例如
+// lg.Session.Info("message", "session", key, "channel", ch)。
+// 文本格式输出为 "message session=... channel=..."；JSON 格式下键值对成为对象字段
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.log(LevelInfo, msg, kv)
+}
+
+// Debug 同 Info，按 debug 级别输出
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.log(LevelDebug, msg, kv)
+}
+
+// Warn 同 Info，按 warn 级别输出
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.log(LevelWarn, msg, kv)
+}
+
+// Error 同 Info，按 error 级别输出
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.log(LevelError, msg, kv)
+}
+
+func (l *Logger) enabled(level Level) bool {
+	return l != nil && l.Logger != nil && Enabled(level)
+}
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	if l.enabled(level) {
+		l.emit(level, msg, kv)
+	}
+}
+
+// emit 输出一条日志；文本格式下 info 级别不加前缀，保持原有行格式
+func (l *Logger) emit(level Level, msg string, kv []interface{}) {
+	if l.json {
+		l.Logger.Print(encodeJSONEntry(time.Now(), l.name, level, msg, kv))
+		return
+	}
+	if level != LevelInfo {
+		msg = strings.ToUpper(level.String()) + " " + msg
+	}
+	if len(kv) == 0 {
+		l.Logger.Print(msg)
+		return
+	}
+	l.Logger.Print(msg + " " + formatKV(kv))
+}
+
+// encodeJSONEntry 编码单条日志；time / level / logger / msg 为保留字段，同名的键值对会被忽略
+func encodeJSONEntry(ts time.Time, name string, level Level, msg string, kv []interface{}) string {
+	entry := make(map[string]interface{}, len(kv)/2+4)
+	for key, value := range kvFields(kv) {
+		entry[key] = value
+	}
+	entry["time"] = ts.Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["logger"] = name
+	entry["msg"] = msg
+	data, err := json.Marshal(entry)
+	if err != nil {
+		fallback, _ := json.Marshal(map[string]string{
+			"time":   ts.Format(time.RFC3339Nano),
+			"level":  level.String(),
+			"logger": name,
+			"msg":    msg,
+			"error":  err.Error(),
+		})
+		return string(fallback)
+	}
+	return string(data)
+}
+
+func kvFields(kv []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		if i+1 >= len(kv) {
+			fields[key] = "(MISSING)"
+			break
+		}
+		fields[key] = fieldValue(kv[i+1])
+	}
+	return fields
+}
+
+func fieldValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil:
+		return nil
+	case error:
+		return value.Error()
+	case time.Duration:
+		return value.String()
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return value
+	}
+}
+
+func formatKV(kv []interface{}) string {
+	fields := kvFields(kv)
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	// 保持调用方传入的顺序，便于阅读
+	for i := 0; i < len(kv); i += 2 {
+		key := fmt.Sprint(kv[i])
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+formatTextValue(fields[key]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTextValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+func optionsFromEnv() Options {
+	return Options{Format: os.Getenv(EnvFormat), Level: levelFromEnv()}
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeJSONLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &entry), "line %q", line)
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestJSONLoggerEmitsParsableEntries(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewLogger(&buf, "session", FormatJSON)
+
+	lg.Info("message", "session", "telegram:1", "count", 3, "err", errors.New("boom"), "elapsed", 1500*time.Millisecond)
+	lg.Printf("legacy line id=%d", 7)
+
+	entries := decodeJSONLines(t, &buf)
+	require.Len(t, entries, 2)
+
+	first := entries[0]
+	assert.Equal(t, "session", first["logger"])
+	assert.Equal(t, "message", first["msg"])
+	assert.Equal(t, "telegram:1", first["session"])
+	assert.Equal(t, float64(3), first["count"])
+	assert.Equal(t, "boom", first["err"])
+	assert.Equal(t, "1.5s", first["elapsed"])
+	_, err := time.Parse(time.RFC3339Nano, first["time"].(string))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "legacy line id=7", entries[1]["msg"])
+	assert.Equal(t, "session", entries[1]["logger"])
+}
+
+func TestJSONLoggerReservedKeysAndOddPairs(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewLogger(&buf, "tools", "JSON")
+
+	lg.Info("call", "msg", "override", "logger", "x", "dangling")
+
+	entries := decodeJSONLines(t, &buf)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "call", entries[0]["msg"])
+	assert.Equal(t, "tools", entries[0]["logger"])
+	assert.Equal(t, "(MISSING)", entries[0]["dangling"])
+}
+
+func TestTextLoggerFormatsKeyValues(t *testing.T) {
+	var buf bytes.Buffer
+	lg := NewLogger(&buf, "cron", "")
+
+	lg.Info("cron completed", "job", "abc", "reason", "has space", "empty", "")
+
+	line := strings.TrimSpace(buf.String())
+	assert.True(t, strings.HasSuffix(line, `cron completed job=abc reason="has space" empty=""`), line)
+	assert.False(t, strings.HasPrefix(line, "{"))
+}
+
+func TestNormalizeFormat(t *testing.T) {
+	assert.Equal(t, FormatJSON, NormalizeFormat(" Json "))
+	assert.Equal(t, FormatText, NormalizeFormat(""))
+	assert.Equal(t, FormatText, NormalizeFormat("xml"))
+}
+
+func TestNilLoggerIsNoop(t *testing.T) {
+	var lg *Logger
+	assert.NotPanics(t, func() {
+		lg.Info("ignored", "k", "v")
+		lg.Printf("ignored %d", 1)
+	})
+}
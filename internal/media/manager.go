@@ -1,6 +1,7 @@
 package media
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -13,11 +14,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Lichas/maxclaw/internal/bus"
 )
 
+// DefaultMaxBytes 入站媒体默认大小上限（与 Telegram Bot API 的下载上限一致）
+const DefaultMaxBytes int64 = 20 * 1024 * 1024
+
+// sniffBytes 用于内容类型探测的字节数
+const sniffBytes = 512
+
 type ResolvedMedia struct {
 	LocalPath string
 	Filename  string
@@ -29,6 +37,7 @@ type Resolver interface {
 }
 
 type Manager struct {
+	mu        sync.RWMutex
 	rootDir   string
 	resolvers map[string]Resolver
 }
@@ -44,15 +53,26 @@ func (m *Manager) Register(channel string, resolver Resolver) {
 	if resolver == nil {
 		return
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.resolvers[strings.TrimSpace(channel)] = resolver
 }
 
+// Unregister 移除频道的媒体解析器（频道被热加载停用时调用）
+func (m *Manager) Unregister(channel string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.resolvers, strings.TrimSpace(channel))
+}
+
 func (m *Manager) StageInbound(ctx context.Context, channel string, attachment *bus.MediaAttachment) (*bus.MediaAttachment, error) {
 	if attachment == nil {
 		return nil, nil
 	}
 
+	m.mu.RLock()
 	resolver, ok := m.resolvers[strings.TrimSpace(channel)]
+	m.mu.RUnlock()
 	if !ok {
 		return attachment, nil
 	}
@@ -82,6 +102,7 @@ type URLResolver struct {
 	rootDir    string
 	channel    string
 	httpClient *http.Client
+	maxBytes   int64
 }
 
 func NewQQResolver(rootDir string, client *http.Client) *URLResolver {
@@ -92,6 +113,7 @@ func NewQQResolver(rootDir string, client *http.Client) *URLResolver {
 		rootDir:    rootDir,
 		channel:    "qq",
 		httpClient: client,
+		maxBytes:   DefaultMaxBytes,
 	}
 }
 
@@ -100,7 +122,7 @@ func (r *URLResolver) Stage(ctx context.Context, attachment *bus.MediaAttachment
 	if sourceURL == "" {
 		return nil, fmt.Errorf("%s media URL is empty", r.channel)
 	}
-	return stageRemoteMedia(ctx, r.rootDir, r.channel, sourceURL, attachment.Filename, attachment.MimeType, r.httpClient)
+	return stageRemoteMedia(ctx, r.rootDir, r.channel, sourceURL, attachment.Filename, attachment.MimeType, r.httpClient, r.maxBytes)
 }
 
 type TelegramResolver struct {
@@ -108,9 +130,14 @@ type TelegramResolver struct {
 	token      string
 	apiBaseURL string
 	httpClient *http.Client
+	maxBytes   int64
 }
 
-func NewTelegramResolver(rootDir, token, proxy string) *TelegramResolver {
+// NewTelegramResolver 创建 Telegram 媒体下载器；maxBytes <= 0 时使用 DefaultMaxBytes
+func NewTelegramResolver(rootDir, token, proxy string, maxBytes int64) *TelegramResolver {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
 	transport := &http.Transport{Proxy: http.ProxyFromEnvironment}
 	if proxy != "" {
 		if parsed, err := url.Parse(strings.TrimSpace(proxy)); err == nil {
@@ -125,6 +152,7 @@ func NewTelegramResolver(rootDir, token, proxy string) *TelegramResolver {
 			Transport: transport,
 			Timeout:   30 * time.Second,
 		},
+		maxBytes: maxBytes,
 	}
 }
 
@@ -133,55 +161,62 @@ func (r *TelegramResolver) Stage(ctx context.Context, attachment *bus.MediaAttac
 		if strings.TrimSpace(attachment.URL) == "" {
 			return nil, fmt.Errorf("telegram media file_id is empty")
 		}
-		return stageRemoteMedia(ctx, r.rootDir, "telegram", attachment.URL, attachment.Filename, attachment.MimeType, r.httpClient)
+		return stageRemoteMedia(ctx, r.rootDir, "telegram", attachment.URL, attachment.Filename, attachment.MimeType, r.httpClient, r.maxBytes)
 	}
 
-	filePath, err := r.getFilePath(ctx, attachment.FileID)
+	filePath, fileSize, err := r.getFile(ctx, attachment.FileID)
 	if err != nil {
 		return nil, err
 	}
+	if r.maxBytes > 0 && fileSize > r.maxBytes {
+		return nil, fmt.Errorf("telegram media too large: %d bytes (limit %d)", fileSize, r.maxBytes)
+	}
 
 	sourceURL := strings.TrimRight(r.apiBaseURL, "/") + "/file/bot" + r.token + "/" + strings.TrimLeft(filePath, "/")
 	filename := strings.TrimSpace(attachment.Filename)
 	if filename == "" {
 		filename = filepath.Base(filePath)
 	}
-	return stageRemoteMedia(ctx, r.rootDir, "telegram", sourceURL, filename, attachment.MimeType, r.httpClient)
+	return stageRemoteMedia(ctx, r.rootDir, "telegram", sourceURL, filename, attachment.MimeType, r.httpClient, r.maxBytes)
 }
 
-func (r *TelegramResolver) getFilePath(ctx context.Context, fileID string) (string, error) {
+// getFile 调用 getFile 获取文件路径与大小（大小未知时为 0）
+func (r *TelegramResolver) getFile(ctx context.Context, fileID string) (string, int64, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(r.apiBaseURL, "/")+"/bot"+r.token+"/getFile?file_id="+url.QueryEscape(fileID), nil)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("telegram getFile failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return "", 0, fmt.Errorf("telegram getFile failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
 
 	var payload struct {
 		OK     bool `json:"ok"`
 		Result struct {
 			FilePath string `json:"file_path"`
+			FileSize int64  `json:"file_size"`
 		} `json:"result"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
-		return "", err
+		return "", 0, err
 	}
 	if !payload.OK || strings.TrimSpace(payload.Result.FilePath) == "" {
-		return "", fmt.Errorf("telegram getFile returned empty file path")
+		return "", 0, fmt.Errorf("telegram getFile returned empty file path")
 	}
-	return payload.Result.FilePath, nil
+	return payload.Result.FilePath, payload.Result.FileSize, nil
 }
 
-func stageRemoteMedia(ctx context.Context, rootDir, channel, sourceURL, filenameHint, mimeType string, client *http.Client) (*ResolvedMedia, error) {
+// stageRemoteMedia 下载媒体到 rootDir/<channel>/<日期>/；超过 maxBytes（> 0 时生效）的文件会被拒绝并删除，
+// 未提供或只有通用 MIME 时根据文件头探测内容类型
+func stageRemoteMedia(ctx context.Context, rootDir, channel, sourceURL, filenameHint, mimeType string, client *http.Client, maxBytes int64) (*ResolvedMedia, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
 	if err != nil {
 		return nil, err
@@ -197,11 +232,24 @@ func stageRemoteMedia(ctx context.Context, rootDir, channel, sourceURL, filename
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("download media failed: status=%d body=%s", resp.StatusCode, strings.TrimSpace(string(body)))
 	}
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return nil, fmt.Errorf("media too large: %d bytes (limit %d)", resp.ContentLength, maxBytes)
+	}
+
+	head := make([]byte, sniffBytes)
+	n, err := io.ReadFull(resp.Body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	head = head[:n]
 
 	resolvedMime := strings.TrimSpace(mimeType)
 	if resolvedMime == "" {
 		resolvedMime = strings.TrimSpace(resp.Header.Get("Content-Type"))
 	}
+	if isGenericMime(resolvedMime) && len(head) > 0 {
+		resolvedMime = http.DetectContentType(head)
+	}
 
 	dir := filepath.Join(rootDir, channel, time.Now().Format("20060102"))
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -220,7 +268,17 @@ func stageRemoteMedia(ctx context.Context, rootDir, channel, sourceURL, filename
 	}
 	defer file.Close()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(body, maxBytes+1)
+	}
+	written, err := io.Copy(file, body)
+	if err == nil && maxBytes > 0 && written > maxBytes {
+		err = fmt.Errorf("media too large: exceeds limit %d bytes", maxBytes)
+	}
+	if err != nil {
+		file.Close()
+		os.Remove(targetPath)
 		return nil, err
 	}
 
@@ -236,6 +294,12 @@ func stageRemoteMedia(ctx context.Context, rootDir, channel, sourceURL, filename
 	}, nil
 }
 
+// isGenericMime 判断 MIME 是否缺失或过于笼统，需要根据内容探测
+func isGenericMime(mimeType string) bool {
+	base := strings.ToLower(strings.TrimSpace(strings.Split(mimeType, ";")[0]))
+	return base == "" || base == "application/octet-stream" || base == "binary/octet-stream"
+}
+
 func guessExtension(filenameHint, mimeType, sourceURL string) string {
 	if ext := strings.TrimSpace(filepath.Ext(filenameHint)); ext != "" {
 		return ext
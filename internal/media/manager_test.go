@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -99,3 +101,79 @@ func TestManagerStageInboundAddsLocalPath(t *testing.T) {
 	assert.FileExists(t, staged.LocalPath)
 	assert.Equal(t, "image.png", staged.Filename)
 }
+
+func TestTelegramResolverRejectsOversizedFileBeforeDownload(t *testing.T) {
+	downloaded := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/getFile"):
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+				"ok": true,
+				"result": map[string]interface{}{
+					"file_path": "documents/big.pdf",
+					"file_size": 2048,
+				},
+			}))
+		default:
+			downloaded = true
+			_, _ = w.Write([]byte("unexpected"))
+		}
+	}))
+	defer server.Close()
+
+	resolver := &TelegramResolver{
+		rootDir:    t.TempDir(),
+		token:      "token",
+		apiBaseURL: server.URL,
+		httpClient: server.Client(),
+		maxBytes:   1024,
+	}
+
+	_, err := resolver.Stage(context.Background(), &bus.MediaAttachment{Type: "document", FileID: "big"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too large")
+	assert.False(t, downloaded)
+}
+
+func TestStageRemoteMediaRejectsBodyOverLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 分块传输，没有 Content-Length，只能边读边限制
+		flusher := w.(http.Flusher)
+		for i := 0; i < 4; i++ {
+			_, _ = w.Write([]byte(strings.Repeat("x", 512)))
+			flusher.Flush()
+		}
+	}))
+	defer server.Close()
+
+	rootDir := t.TempDir()
+	_, err := stageRemoteMedia(context.Background(), rootDir, "telegram", server.URL+"/big.bin", "big.bin", "", server.Client(), 1024)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too large")
+
+	var files []string
+	require.NoError(t, filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	}))
+	assert.Empty(t, files, "partial download should be removed")
+}
+
+func TestStageRemoteMediaSniffsGenericMimeType(t *testing.T) {
+	pngHeader := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		_, _ = w.Write(pngHeader)
+	}))
+	defer server.Close()
+
+	staged, err := stageRemoteMedia(context.Background(), t.TempDir(), "telegram", server.URL+"/file_2", "", "", server.Client(), DefaultMaxBytes)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", staged.MimeType)
+
+	data, err := os.ReadFile(staged.LocalPath)
+	require.NoError(t, err)
+	assert.Equal(t, pngHeader, data)
+}
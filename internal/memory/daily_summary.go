@@ -3,7 +3,6 @@ package memory
 import (
 	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -200,20 +199,15 @@ func collectSummaryFromSessionFiles(workspace string, day time.Time, data *summa
 		if walkErr != nil {
 			return nil
 		}
-		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+		if d.IsDir() || !session.IsSessionFile(d.Name()) {
 			return nil
 		}
 
-		content, err := os.ReadFile(path)
+		sess, err := session.LoadFile(path)
 		if err != nil {
 			return nil
 		}
 
-		var sess session.Session
-		if err := json.Unmarshal(content, &sess); err != nil {
-			return nil
-		}
-
 		dayKey := day.In(day.Location()).Format("2006-01-02")
 		for _, msg := range sess.Messages {
 			if msg.Timestamp.IsZero() {
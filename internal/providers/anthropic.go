@@ -61,6 +61,7 @@ func newAnthropicProvider(apiKey, apiBase, defaultModel string, maxTokens int, t
 
 func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
 	params := p.buildMessageParams(messages, tools, model)
+	params.StopSequences = ChatOptionsFrom(ctx).stopSequences()
 
 	resp, err := p.client.Messages.New(ctx, params)
 	if err != nil {
@@ -94,6 +95,7 @@ func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message, tools
 
 func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
 	params := p.buildMessageParams(messages, tools, model)
+	params.StopSequences = ChatOptionsFrom(ctx).stopSequences()
 
 	stream := p.client.Messages.NewStreaming(ctx, params)
 	defer stream.Close()
@@ -52,3 +52,30 @@ func TestAnthropicProviderUsesSDKEndpointAndNormalizesModel(t *testing.T) {
 		t.Fatalf("expected normalized model, got %q", model)
 	}
 }
+
+func TestAnthropicProviderSendsStopSequences(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"msg_123","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"ok"}],"stop_reason":"stop_sequence","stop_sequence":"END","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	provider, err := newAnthropicProvider("sk-anthropic", server.URL+"/v1", "claude-sonnet-4-5", 64, 0.2, nil, server.Client())
+	if err != nil {
+		t.Fatalf("newAnthropicProvider failed: %v", err)
+	}
+
+	ctx := WithChatOptions(context.Background(), ChatOptions{Stop: []string{"END"}})
+	if _, err := provider.Chat(ctx, []Message{{Role: "user", Content: "ping"}}, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	stop, _ := body["stop_sequences"].([]any)
+	if len(stop) != 1 || stop[0] != "END" {
+		t.Fatalf("unexpected stop_sequences: %v", body["stop_sequences"])
+	}
+}
@@ -51,6 +51,22 @@ type StreamHandler interface {
 	OnError(err error)                // 错误处理
 }
 
+// ReasoningHandler 可选扩展：StreamHandler 实现该接口即可接收推理模型（如 DeepSeek-R1）
+// 单独返回的思考内容；未实现时推理内容被忽略，且始终不计入最终回答
+type ReasoningHandler interface {
+	OnReasoning(token string)
+}
+
+// emitReasoning 在 handler 支持时转发推理内容
+func emitReasoning(handler StreamHandler, token string) {
+	if token == "" {
+		return
+	}
+	if rh, ok := handler.(ReasoningHandler); ok {
+		rh.OnReasoning(token)
+	}
+}
+
 // LLMProvider LLM 提供商接口
 type LLMProvider interface {
 	Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error)
@@ -6,14 +6,20 @@ const (
 	providerKindCompatOpenAI = "compat-openai"
 	providerKindOpenAI       = "openai"
 	providerKindAnthropic    = "anthropic"
+	providerKindGemini       = "gemini"
+	providerKindMock         = "mock"
 )
 
 // NewProvider creates the appropriate runtime provider implementation for the
 // configured model/provider pair.
 func NewProvider(apiKey, apiBase, apiFormat, defaultModel string, maxTokens int, temperature float64, supportsImageInput func(model string) bool) (LLMProvider, error) {
 	switch ResolveProviderKind(defaultModel, apiBase, apiFormat) {
+	case providerKindMock:
+		return NewMockProvider(defaultModel), nil
 	case providerKindAnthropic:
 		return NewAnthropicProvider(apiKey, apiBase, defaultModel, maxTokens, temperature, supportsImageInput)
+	case providerKindGemini:
+		return NewGeminiProvider(apiKey, apiBase, defaultModel, maxTokens, temperature, supportsImageInput)
 	case providerKindOpenAI:
 		return NewOpenAIOfficialProvider(apiKey, apiBase, defaultModel, maxTokens, temperature, supportsImageInput)
 	default:
@@ -24,6 +30,10 @@ func NewProvider(apiKey, apiBase, apiFormat, defaultModel string, maxTokens int,
 // ResolveProviderKind returns the concrete provider implementation kind to use
 // at runtime.
 func ResolveProviderKind(model, apiBase, apiFormat string) string {
+	if IsMockModel(model) {
+		return providerKindMock
+	}
+
 	providerName := DetectProviderName(model)
 	if providerName == "unknown" {
 		providerName = DetectProviderNameFromAPIBase(apiBase)
@@ -38,6 +48,13 @@ func ResolveProviderKind(model, apiBase, apiFormat string) string {
 		if strings.EqualFold(strings.TrimSpace(apiFormat), "openai") || apiFormat == "" {
 			return providerKindOpenAI
 		}
+	case "gemini":
+		// 经由 OpenRouter 等聚合网关访问 Gemini 模型时仍走 OpenAI 兼容协议
+		baseProvider := DetectProviderNameFromAPIBase(apiBase)
+		if (strings.EqualFold(strings.TrimSpace(apiFormat), "gemini") || apiFormat == "") &&
+			(baseProvider == "gemini" || strings.TrimSpace(apiBase) == "") {
+			return providerKindGemini
+		}
 	}
 
 	if strings.EqualFold(strings.TrimSpace(apiFormat), "anthropic") && DetectProviderNameFromAPIBase(apiBase) == "anthropic" {
@@ -46,6 +63,9 @@ func ResolveProviderKind(model, apiBase, apiFormat string) string {
 	if strings.EqualFold(strings.TrimSpace(apiFormat), "openai") && DetectProviderNameFromAPIBase(apiBase) == "openai" {
 		return providerKindOpenAI
 	}
+	if strings.EqualFold(strings.TrimSpace(apiFormat), "gemini") && DetectProviderNameFromAPIBase(apiBase) == "gemini" {
+		return providerKindGemini
+	}
 
 	return providerKindCompatOpenAI
 }
@@ -14,6 +14,10 @@ func TestResolveProviderKind(t *testing.T) {
 		{name: "openai model uses official provider", model: "openai/gpt-5.1", apiFormat: "openai", expected: providerKindOpenAI},
 		{name: "openrouter stays compat", model: "openrouter/auto", apiBase: "https://openrouter.ai/api/v1", apiFormat: "openai", expected: providerKindCompatOpenAI},
 		{name: "anthropic api base can recover official provider", model: "custom", apiBase: "https://api.anthropic.com", apiFormat: "anthropic", expected: providerKindAnthropic},
+		{name: "gemini model uses native provider", model: "gemini/gemini-2.5-flash", apiFormat: "gemini", expected: providerKindGemini},
+		{name: "gemini openai-compat base still native", model: "gemini-2.5-pro", apiBase: "https://generativelanguage.googleapis.com/v1beta/openai", expected: providerKindGemini},
+		{name: "gemini via openrouter stays compat", model: "google/gemini-2.5-flash", apiBase: "https://openrouter.ai/api/v1", apiFormat: "openai", expected: providerKindCompatOpenAI},
+		{name: "gemini with explicit openai format stays compat", model: "gemini-2.5-flash", apiBase: "https://generativelanguage.googleapis.com/v1beta/openai", apiFormat: "openai", expected: providerKindCompatOpenAI},
 	}
 
 	for _, tt := range tests {
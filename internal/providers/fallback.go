@@ -0,0 +1,171 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+)
+
+// AllProvidersFailedError 主模型与所有备用模型均调用失败；Errors 与 Models 按尝试顺序一一对应
+type AllProvidersFailedError struct {
+	Models []string
+	Errors []error
+}
+
+func (e *AllProvidersFailedError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for i, err := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %v", e.Models[i], err))
+	}
+	return fmt.Sprintf("all %d providers failed: %s", len(e.Errors), strings.Join(parts, "; "))
+}
+
+// Unwrap 返回各模型的错误，便于 errors.Is / errors.As 检查
+func (e *AllProvidersFailedError) Unwrap() []error {
+	return e.Errors
+}
+
+// FallbackProvider 主模型失败时按顺序尝试备用模型；备用模型使用各自的默认模型名
+type FallbackProvider struct {
+	providers []LLMProvider
+}
+
+// NewFallbackProvider 创建带备用模型的 provider；没有备用模型时原样返回 primary
+func NewFallbackProvider(primary LLMProvider, fallbacks ...LLMProvider) LLMProvider {
+	chain := []LLMProvider{primary}
+	for _, p := range fallbacks {
+		if p != nil {
+			chain = append(chain, p)
+		}
+	}
+	if primary == nil || len(chain) == 1 {
+		return primary
+	}
+	return &FallbackProvider{providers: chain}
+}
+
+// Chat 依次尝试各模型，返回第一个成功的结果
+func (p *FallbackProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	failed := &AllProvidersFailedError{}
+	for i, provider := range p.providers {
+		m := p.modelFor(i, model)
+		resp, err := provider.Chat(ctx, messages, tools, m)
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		p.recordFailure(failed, m, err)
+	}
+	return nil, p.allFailed(failed)
+}
+
+// ChatStream 依次尝试各模型；某个模型已经输出内容后失败时无法无缝切换，直接返回该错误
+func (p *FallbackProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
+	failed := &AllProvidersFailedError{}
+	for i, provider := range p.providers {
+		m := p.modelFor(i, model)
+		guard := &fallbackStreamHandler{handler: handler}
+		err := provider.ChatStream(ctx, messages, tools, m, guard)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || guard.emitted {
+			if handler != nil {
+				handler.OnError(err)
+			}
+			return err
+		}
+		p.recordFailure(failed, m, err)
+	}
+
+	err := p.allFailed(failed)
+	if handler != nil {
+		handler.OnError(err)
+	}
+	return err
+}
+
+// GetDefaultModel 返回主模型
+func (p *FallbackProvider) GetDefaultModel() string {
+	return p.providers[0].GetDefaultModel()
+}
+
+// SupportsImageInput 以主模型为准
+func (p *FallbackProvider) SupportsImageInput(model string) bool {
+	return p.providers[0].SupportsImageInput(model)
+}
+
+// modelFor 主模型使用调用方指定的模型名，备用模型使用各自的默认模型
+func (p *FallbackProvider) modelFor(i int, model string) string {
+	if i == 0 && strings.TrimSpace(model) != "" {
+		return model
+	}
+	return p.providers[i].GetDefaultModel()
+}
+
+func (p *FallbackProvider) recordFailure(failed *AllProvidersFailedError, model string, err error) {
+	failed.Models = append(failed.Models, model)
+	failed.Errors = append(failed.Errors, err)
+	if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+		lg.Gateway.Warn("provider failed, trying next model", "model", model, "error", err)
+	}
+}
+
+func (p *FallbackProvider) allFailed(failed *AllProvidersFailedError) error {
+	if lg := logging.Get(); lg != nil && lg.Gateway != nil {
+		lg.Gateway.Error("all providers failed", "models", strings.Join(failed.Models, ","), "error", failed)
+	}
+	return failed
+}
+
+// fallbackStreamHandler 拦截 OnError（由 FallbackProvider 决定是否切换），并记录是否已经向下游输出内容
+type fallbackStreamHandler struct {
+	handler StreamHandler
+	emitted bool
+}
+
+func (h *fallbackStreamHandler) OnContent(token string) {
+	h.emitted = true
+	if h.handler != nil {
+		h.handler.OnContent(token)
+	}
+}
+
+func (h *fallbackStreamHandler) OnReasoning(token string) {
+	h.emitted = true
+	if h.handler != nil {
+		emitReasoning(h.handler, token)
+	}
+}
+
+func (h *fallbackStreamHandler) OnToolCallStart(id, name string) {
+	h.emitted = true
+	if h.handler != nil {
+		h.handler.OnToolCallStart(id, name)
+	}
+}
+
+func (h *fallbackStreamHandler) OnToolCallDelta(id, delta string) {
+	h.emitted = true
+	if h.handler != nil {
+		h.handler.OnToolCallDelta(id, delta)
+	}
+}
+
+func (h *fallbackStreamHandler) OnToolCallEnd(id string) {
+	if h.handler != nil {
+		h.handler.OnToolCallEnd(id)
+	}
+}
+
+func (h *fallbackStreamHandler) OnComplete() {
+	if h.handler != nil {
+		h.handler.OnComplete()
+	}
+}
+
+func (h *fallbackStreamHandler) OnError(err error) {}
@@ -0,0 +1,155 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type scriptedProvider struct {
+	model   string
+	err     error
+	content string
+	calls   []string
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	p.calls = append(p.calls, model)
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &Response{Content: p.content}, nil
+}
+
+func (p *scriptedProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
+	p.calls = append(p.calls, model)
+	if p.content != "" {
+		handler.OnContent(p.content)
+	}
+	if p.err != nil {
+		handler.OnError(p.err)
+		return p.err
+	}
+	handler.OnComplete()
+	return nil
+}
+
+func (p *scriptedProvider) GetDefaultModel() string        { return p.model }
+func (p *scriptedProvider) SupportsImageInput(string) bool { return false }
+
+type fallbackTestHandler struct {
+	content  strings.Builder
+	errs     []error
+	complete bool
+}
+
+func (h *fallbackTestHandler) OnContent(token string)           { h.content.WriteString(token) }
+func (h *fallbackTestHandler) OnToolCallStart(id, name string)  {}
+func (h *fallbackTestHandler) OnToolCallDelta(id, delta string) {}
+func (h *fallbackTestHandler) OnToolCallEnd(id string)          {}
+func (h *fallbackTestHandler) OnComplete()                      { h.complete = true }
+func (h *fallbackTestHandler) OnError(err error)                { h.errs = append(h.errs, err) }
+
+func TestNewFallbackProviderWithoutFallbacksReturnsPrimary(t *testing.T) {
+	primary := &scriptedProvider{model: "a"}
+	if got := NewFallbackProvider(primary); got != LLMProvider(primary) {
+		t.Fatalf("expected primary to be returned unchanged, got %T", got)
+	}
+	if got := NewFallbackProvider(primary, nil); got != LLMProvider(primary) {
+		t.Fatalf("expected nil fallbacks to be ignored, got %T", got)
+	}
+}
+
+func TestFallbackProviderChatUsesNextModel(t *testing.T) {
+	primary := &scriptedProvider{model: "gpt-4o", err: errors.New("status 500")}
+	backup := &scriptedProvider{model: "deepseek-chat", content: "hello"}
+	p := NewFallbackProvider(primary, backup)
+
+	resp, err := p.Chat(context.Background(), nil, nil, "gpt-4o-mini")
+	if err != nil {
+		t.Fatalf("Chat returned error: %v", err)
+	}
+	if resp.Content != "hello" {
+		t.Fatalf("expected backup response, got %q", resp.Content)
+	}
+	if len(primary.calls) != 1 || primary.calls[0] != "gpt-4o-mini" {
+		t.Fatalf("expected primary to use the requested model, got %v", primary.calls)
+	}
+	if len(backup.calls) != 1 || backup.calls[0] != "deepseek-chat" {
+		t.Fatalf("expected backup to use its default model, got %v", backup.calls)
+	}
+	if p.GetDefaultModel() != "gpt-4o" {
+		t.Fatalf("expected primary default model, got %q", p.GetDefaultModel())
+	}
+}
+
+func TestFallbackProviderAllFailed(t *testing.T) {
+	p := NewFallbackProvider(
+		&scriptedProvider{model: "a", err: errors.New("status 401")},
+		&scriptedProvider{model: "b", err: errors.New("status 503")},
+	)
+
+	_, err := p.Chat(context.Background(), nil, nil, "")
+	var allFailed *AllProvidersFailedError
+	if !errors.As(err, &allFailed) {
+		t.Fatalf("expected AllProvidersFailedError, got %v", err)
+	}
+	if len(allFailed.Errors) != 2 || allFailed.Models[0] != "a" || allFailed.Models[1] != "b" {
+		t.Fatalf("unexpected aggregate: %+v", allFailed)
+	}
+	if want := "all 2 providers failed: a: status 401; b: status 503"; err.Error() != want {
+		t.Fatalf("expected %q, got %q", want, err.Error())
+	}
+
+	handler := &fallbackTestHandler{}
+	err = p.ChatStream(context.Background(), nil, nil, "", handler)
+	if !errors.As(err, &allFailed) {
+		t.Fatalf("expected AllProvidersFailedError from stream, got %v", err)
+	}
+	if len(handler.errs) != 1 || handler.errs[0] != err {
+		t.Fatalf("expected a single aggregate OnError, got %v", handler.errs)
+	}
+}
+
+func TestFallbackProviderStreamFallsBackOnlyBeforeOutput(t *testing.T) {
+	backup := &scriptedProvider{model: "b", content: "from backup"}
+	p := NewFallbackProvider(&scriptedProvider{model: "a", err: errors.New("status 500")}, backup)
+
+	handler := &fallbackTestHandler{}
+	if err := p.ChatStream(context.Background(), nil, nil, "", handler); err != nil {
+		t.Fatalf("ChatStream returned error: %v", err)
+	}
+	if handler.content.String() != "from backup" || !handler.complete || len(handler.errs) != 0 {
+		t.Fatalf("unexpected stream result: content=%q complete=%v errs=%v", handler.content.String(), handler.complete, handler.errs)
+	}
+
+	// 主模型已经输出内容后失败，不能再切换到备用模型
+	backup.calls = nil
+	partial := NewFallbackProvider(&scriptedProvider{model: "a", content: "par", err: errors.New("stream reset")}, backup)
+	handler = &fallbackTestHandler{}
+	err := partial.ChatStream(context.Background(), nil, nil, "", handler)
+	if err == nil || err.Error() != "stream reset" {
+		t.Fatalf("expected original stream error, got %v", err)
+	}
+	if len(backup.calls) != 0 {
+		t.Fatalf("expected backup not to be called after partial output")
+	}
+	if handler.content.String() != "par" || len(handler.errs) != 1 {
+		t.Fatalf("unexpected partial stream result: content=%q errs=%v", handler.content.String(), handler.errs)
+	}
+}
+
+func TestFallbackProviderStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	backup := &scriptedProvider{model: "b", content: "x"}
+	p := NewFallbackProvider(&scriptedProvider{model: "a", err: context.Canceled}, backup)
+
+	if _, err := p.Chat(ctx, nil, nil, ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(backup.calls) != 0 {
+		t.Fatalf("expected backup not to be called after cancellation")
+	}
+}
@@ -0,0 +1,494 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultGeminiAPIBase = "https://generativelanguage.googleapis.com/v1beta"
+
+// GeminiProvider 使用 Gemini 原生 generateContent API（contents/parts/functionCall）
+type GeminiProvider struct {
+	apiKey             string
+	apiBase            string
+	defaultModel       string
+	maxTokens          int
+	temperature        float64
+	httpClient         *http.Client
+	streamClient       *http.Client
+	supportsImageInput func(model string) bool
+}
+
+// NewGeminiProvider 创建 Gemini 原生提供商
+func NewGeminiProvider(apiKey, apiBase, defaultModel string, maxTokens int, temperature float64, supportsImageInput func(model string) bool) (*GeminiProvider, error) {
+	if strings.TrimSpace(apiKey) == "" {
+		return nil, fmt.Errorf("API key is required")
+	}
+	if strings.TrimSpace(defaultModel) == "" {
+		defaultModel = "gemini-2.5-flash"
+	}
+	if maxTokens <= 0 {
+		maxTokens = 1
+	}
+
+	return &GeminiProvider{
+		apiKey:       apiKey,
+		apiBase:      normalizeGeminiBaseURL(apiBase),
+		defaultModel: defaultModel,
+		maxTokens:    maxTokens,
+		temperature:  temperature,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		streamClient:       &http.Client{},
+		supportsImageInput: supportsImageInput,
+	}, nil
+}
+
+// Chat 发送 generateContent 请求
+func (p *GeminiProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	if strings.TrimSpace(model) == "" {
+		model = p.defaultModel
+	}
+
+	req := p.buildRequest(messages, tools, model)
+	req.GenerationConfig.StopSequences = ChatOptionsFrom(ctx).stopSequences()
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	resp, err := p.doRequest(ctx, p.httpClient, p.endpoint(model, "generateContent"), payload)
+	if err != nil {
+		return nil, p.wrapModelRequestError("chat request failed", model, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, p.wrapModelRequestError("chat request failed", model, err)
+	}
+
+	var parsed geminiResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(parsed.Candidates) == 0 {
+		return nil, fmt.Errorf("no response from model")
+	}
+
+	return convertFromGeminiContent(parsed.Candidates[0].Content), nil
+}
+
+// ChatStream 通过 streamGenerateContent（SSE）流式请求
+func (p *GeminiProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
+	if strings.TrimSpace(model) == "" {
+		model = p.defaultModel
+	}
+
+	req := p.buildRequest(messages, tools, model)
+	req.GenerationConfig.StopSequences = ChatOptionsFrom(ctx).stopSequences()
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	resp, err := p.doRequest(ctx, p.streamClient, p.endpoint(model, "streamGenerateContent")+"?alt=sse", payload)
+	if err != nil {
+		wrappedErr := p.wrapModelRequestError("stream request failed", model, err)
+		handler.OnError(wrappedErr)
+		return wrappedErr
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "" {
+			continue
+		}
+
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			decodeErr := fmt.Errorf("stream decode error: %w", err)
+			handler.OnError(decodeErr)
+			return decodeErr
+		}
+		if len(chunk.Candidates) == 0 {
+			continue
+		}
+
+		// Gemini 在单个分片中返回完整的 functionCall，因此开始/参数/结束一次性发出
+		partial := convertFromGeminiContent(chunk.Candidates[0].Content)
+		if partial.Content != "" {
+			handler.OnContent(partial.Content)
+		}
+		for _, call := range partial.ToolCalls {
+			handler.OnToolCallStart(call.ID, call.Function.Name)
+			handler.OnToolCallDelta(call.ID, call.Function.Arguments)
+			handler.OnToolCallEnd(call.ID)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		modelErr := p.wrapModelRequestError("stream read failed", model, fmt.Errorf("stream error: %w", err))
+		handler.OnError(modelErr)
+		return modelErr
+	}
+
+	handler.OnComplete()
+	return nil
+}
+
+// GetDefaultModel 获取默认模型
+func (p *GeminiProvider) GetDefaultModel() string {
+	return p.defaultModel
+}
+
+func (p *GeminiProvider) SupportsImageInput(model string) bool {
+	if strings.TrimSpace(model) == "" {
+		model = p.defaultModel
+	}
+	if p.supportsImageInput != nil {
+		return p.supportsImageInput(model)
+	}
+	return SupportsImageInput("gemini", model)
+}
+
+func (p *GeminiProvider) buildRequest(messages []Message, tools []map[string]interface{}, model string) geminiRequest {
+	system, contents := convertToGeminiContents(messages, p.SupportsImageInput(model))
+	req := geminiRequest{
+		Contents:          contents,
+		SystemInstruction: system,
+		GenerationConfig: &geminiGenerationConfig{
+			MaxOutputTokens: p.maxTokens,
+			Temperature:     p.temperature,
+		},
+	}
+	if declarations := convertToGeminiTools(tools); len(declarations) > 0 {
+		req.Tools = []geminiTool{{FunctionDeclarations: declarations}}
+	}
+	return req
+}
+
+func (p *GeminiProvider) endpoint(model, method string) string {
+	model = normalizeModelForProvider("gemini", model)
+	if !strings.HasPrefix(model, "models/") {
+		model = "models/" + model
+	}
+	return p.apiBase + "/" + model + ":" + method
+}
+
+func (p *GeminiProvider) doRequest(ctx context.Context, client *http.Client, endpoint string, payload []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", p.apiKey)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s", formatGeminiError(body, resp.StatusCode))
+	}
+	return resp, nil
+}
+
+func (p *GeminiProvider) wrapModelRequestError(prefix, model string, err error) error {
+	return fmt.Errorf("%s provider=gemini model=%s api_base=%s: %w", prefix, model, p.apiBase, err)
+}
+
+// convertToGeminiContents 转换消息：system 进入 system_instruction，assistant 映射为 model，
+// tool 结果转为 functionResponse（Gemini 按函数名而不是调用 ID 关联）
+func convertToGeminiContents(messages []Message, allowImageInput bool) (*geminiContent, []geminiContent) {
+	var systemParts []geminiPart
+	contents := make([]geminiContent, 0, len(messages))
+	toolNames := make(map[string]string)
+
+	appendParts := func(role string, parts []geminiPart) {
+		if len(parts) == 0 {
+			return
+		}
+		// Gemini 要求角色交替，连续同角色的消息合并为一轮
+		if n := len(contents); n > 0 && contents[n-1].Role == role {
+			contents[n-1].Parts = append(contents[n-1].Parts, parts...)
+			return
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: parts})
+	}
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system":
+			if text := strings.TrimSpace(flattenContentParts(msg)); text != "" {
+				systemParts = append(systemParts, geminiPart{Text: text})
+			}
+		case "assistant":
+			var parts []geminiPart
+			if text := strings.TrimSpace(flattenContentParts(msg)); text != "" {
+				parts = append(parts, geminiPart{Text: text})
+			}
+			for _, call := range msg.ToolCalls {
+				toolNames[call.ID] = call.Function.Name
+				parts = append(parts, geminiPart{FunctionCall: &geminiFunctionCall{
+					Name: call.Function.Name,
+					Args: decodeToolArguments(call.Function.Arguments),
+				}})
+			}
+			appendParts("model", parts)
+		case "tool":
+			appendParts("user", []geminiPart{{FunctionResponse: &geminiFunctionResponse{
+				Name:     toolNames[msg.ToolCallID],
+				Response: map[string]interface{}{"result": flattenContentParts(msg)},
+			}}})
+		default:
+			appendParts("user", geminiPartsForUser(msg, allowImageInput))
+		}
+	}
+
+	var system *geminiContent
+	if len(systemParts) > 0 {
+		system = &geminiContent{Parts: systemParts}
+	}
+	return system, contents
+}
+
+func geminiPartsForUser(msg Message, allowImageInput bool) []geminiPart {
+	if !allowImageInput || len(msg.Parts) == 0 {
+		return []geminiPart{{Text: flattenContentParts(msg)}}
+	}
+
+	parts := make([]geminiPart, 0, len(msg.Parts))
+	for _, part := range msg.Parts {
+		switch part.Type {
+		case "image_url":
+			// 原生 API 只接受内联数据（远程 URL 需先上传到 File API）
+			if mediaType, data, ok := parseImageDataURL(buildProviderImageURL(part)); ok {
+				parts = append(parts, geminiPart{InlineData: &geminiInlineData{MimeType: mediaType, Data: data}})
+			}
+		default:
+			parts = append(parts, geminiPart{Text: part.Text})
+		}
+	}
+	if len(parts) == 0 {
+		parts = append(parts, geminiPart{Text: flattenContentParts(msg)})
+	}
+	return parts
+}
+
+// convertFromGeminiContent 把模型返回的 parts 转为文本和工具调用
+func convertFromGeminiContent(content geminiContent) *Response {
+	result := &Response{}
+	for _, part := range content.Parts {
+		if part.Thought {
+			continue
+		}
+		if part.FunctionCall != nil {
+			args := part.FunctionCall.Args
+			if args == nil {
+				args = map[string]interface{}{}
+			}
+			arguments, _ := json.Marshal(args)
+			id := part.FunctionCall.ID
+			if id == "" {
+				id = newGeminiToolCallID()
+			}
+			result.ToolCalls = append(result.ToolCalls, ToolCall{
+				ID:   id,
+				Type: "function",
+				Function: ToolCallFunction{
+					Name:      part.FunctionCall.Name,
+					Arguments: string(arguments),
+				},
+			})
+			continue
+		}
+		result.Content += part.Text
+	}
+	result.HasToolCalls = len(result.ToolCalls) > 0
+	return result
+}
+
+// convertToGeminiTools 把 OpenAI 风格的工具定义转为 function_declarations
+func convertToGeminiTools(tools []map[string]interface{}) []geminiFunctionDeclaration {
+	result := make([]geminiFunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		function, _ := tool["function"].(map[string]interface{})
+		name, _ := function["name"].(string)
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		description, _ := function["description"].(string)
+		declaration := geminiFunctionDeclaration{Name: name, Description: description}
+		if parameters, ok := function["parameters"].(map[string]interface{}); ok {
+			if properties, ok := parameters["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+				declaration.Parameters = sanitizeGeminiSchema(parameters)
+			}
+		}
+		result = append(result, declaration)
+	}
+	return result
+}
+
+// geminiSchemaKeys Gemini 支持的 OpenAPI schema 子集，其余字段（如 additionalProperties）会被拒绝
+var geminiSchemaKeys = map[string]bool{
+	"type": true, "format": true, "description": true, "nullable": true, "enum": true,
+	"properties": true, "required": true, "items": true, "minItems": true, "maxItems": true,
+	"minimum": true, "maximum": true, "minLength": true, "maxLength": true, "pattern": true,
+	"anyOf": true, "title": true, "propertyOrdering": true,
+}
+
+func sanitizeGeminiSchema(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			if !geminiSchemaKeys[key] {
+				continue
+			}
+			switch key {
+			case "properties":
+				props, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				cleaned := make(map[string]interface{}, len(props))
+				for name, schema := range props {
+					cleaned[name] = sanitizeGeminiSchema(schema)
+				}
+				out[key] = cleaned
+			case "items":
+				out[key] = sanitizeGeminiSchema(item)
+			case "anyOf":
+				if list, ok := item.([]interface{}); ok {
+					cleaned := make([]interface{}, 0, len(list))
+					for _, schema := range list {
+						cleaned = append(cleaned, sanitizeGeminiSchema(schema))
+					}
+					out[key] = cleaned
+				}
+			default:
+				out[key] = item
+			}
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+func newGeminiToolCallID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("call_%d", time.Now().UnixNano())
+	}
+	return "call_" + hex.EncodeToString(buf)
+}
+
+func normalizeGeminiBaseURL(apiBase string) string {
+	trimmed := strings.TrimRight(strings.TrimSpace(apiBase), "/")
+	if trimmed == "" {
+		return defaultGeminiAPIBase
+	}
+	// 兼容之前配置的 OpenAI 兼容端点（.../v1beta/openai）
+	return strings.TrimSuffix(trimmed, "/openai")
+}
+
+func formatGeminiError(body []byte, status int) string {
+	var apiErr geminiErrorResponse
+	if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Error.Message != "" {
+		return fmt.Sprintf("status %d: %s", status, apiErr.Error.Message)
+	}
+	return fmt.Sprintf("status %d: %s", status, strings.TrimSpace(string(body)))
+}
+
+// ---- Gemini generateContent request/response structs ----
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"system_instruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	InlineData       *geminiInlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFunctionCall struct {
+	ID   string      `json:"id,omitempty"`
+	Name string      `json:"name"`
+	Args interface{} `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string                 `json:"name"`
+	Response map[string]interface{} `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"function_declarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	Temperature     float64  `json:"temperature"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason,omitempty"`
+	} `json:"candidates"`
+}
+
+type geminiErrorResponse struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
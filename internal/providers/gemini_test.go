@@ -0,0 +1,200 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertToGeminiContentsToolCallRoundTrip(t *testing.T) {
+	messages := []Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "list files"},
+		{Role: "assistant", Content: "Checking.", ToolCalls: []ToolCall{{
+			ID:       "call_1",
+			Type:     "function",
+			Function: ToolCallFunction{Name: "list_dir", Arguments: `{"path":"."}`},
+		}}},
+		{Role: "tool", ToolCallID: "call_1", Content: "[FILE] a.txt"},
+	}
+
+	system, contents := convertToGeminiContents(messages, false)
+	if system == nil || len(system.Parts) != 1 || system.Parts[0].Text != "You are helpful." {
+		t.Fatalf("unexpected system instruction: %+v", system)
+	}
+	if len(contents) != 3 {
+		t.Fatalf("expected 3 contents, got %d: %+v", len(contents), contents)
+	}
+	if contents[0].Role != "user" || contents[0].Parts[0].Text != "list files" {
+		t.Fatalf("unexpected user content: %+v", contents[0])
+	}
+
+	model := contents[1]
+	if model.Role != "model" || len(model.Parts) != 2 || model.Parts[0].Text != "Checking." {
+		t.Fatalf("unexpected model content: %+v", model)
+	}
+	call := model.Parts[1].FunctionCall
+	if call == nil || call.Name != "list_dir" {
+		t.Fatalf("expected list_dir functionCall, got %+v", model.Parts[1])
+	}
+	if args, _ := call.Args.(map[string]interface{}); args["path"] != "." {
+		t.Fatalf("unexpected functionCall args: %#v", call.Args)
+	}
+
+	toolTurn := contents[2]
+	response := toolTurn.Parts[0].FunctionResponse
+	if toolTurn.Role != "user" || response == nil {
+		t.Fatalf("expected functionResponse turn, got %+v", toolTurn)
+	}
+	if response.Name != "list_dir" || response.Response["result"] != "[FILE] a.txt" {
+		t.Fatalf("unexpected functionResponse: %+v", response)
+	}
+
+	parsed := convertFromGeminiContent(geminiContent{Role: "model", Parts: []geminiPart{
+		{Text: "Done"},
+		{FunctionCall: &geminiFunctionCall{Name: "read_file", Args: map[string]interface{}{"path": "a.txt"}}},
+	}})
+	if parsed.Content != "Done" || !parsed.HasToolCalls || len(parsed.ToolCalls) != 1 {
+		t.Fatalf("unexpected parsed response: %+v", parsed)
+	}
+	tc := parsed.ToolCalls[0]
+	if tc.ID == "" || tc.Function.Name != "read_file" || tc.Function.Arguments != `{"path":"a.txt"}` {
+		t.Fatalf("unexpected parsed tool call: %+v", tc)
+	}
+}
+
+func TestConvertToGeminiToolsDropsUnsupportedSchemaKeys(t *testing.T) {
+	declarations := convertToGeminiTools([]map[string]interface{}{{
+		"type": "function",
+		"function": map[string]interface{}{
+			"name":        "exec",
+			"description": "run",
+			"parameters": map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"env": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+					},
+				},
+				"required": []string{"command"},
+			},
+		},
+	}})
+	if len(declarations) != 1 || declarations[0].Name != "exec" {
+		t.Fatalf("unexpected declarations: %+v", declarations)
+	}
+	data, _ := json.Marshal(declarations[0].Parameters)
+	if strings.Contains(string(data), "additionalProperties") || !strings.Contains(string(data), `"required":["command"]`) {
+		t.Fatalf("unexpected sanitized schema: %s", data)
+	}
+}
+
+func TestGeminiProviderChatAndStream(t *testing.T) {
+	var (
+		paths  []string
+		apiKey string
+		body   map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path+"?"+r.URL.RawQuery)
+		apiKey = r.Header.Get("x-goog-api-key")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		if strings.HasSuffix(r.URL.Path, ":streamGenerateContent") {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"Hel\"}]}}]}\n\n"))
+			_, _ = w.Write([]byte("data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"lo\"},{\"functionCall\":{\"name\":\"list_dir\",\"args\":{\"path\":\".\"}}}]}}]}\n\n"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewGeminiProvider("gemini-key", server.URL+"/v1beta/openai/", "gemini/gemini-2.5-flash", 64, 0.1, nil)
+	if err != nil {
+		t.Fatalf("NewGeminiProvider failed: %v", err)
+	}
+
+	resp, err := provider.Chat(context.Background(), []Message{
+		{Role: "system", Content: "sys"},
+		{Role: "user", Content: "ping"},
+	}, nil, "")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "ok" {
+		t.Fatalf("unexpected content: %q", resp.Content)
+	}
+	if paths[0] != "/v1beta/models/gemini-2.5-flash:generateContent?" {
+		t.Fatalf("unexpected request path: %q", paths[0])
+	}
+	if apiKey != "gemini-key" {
+		t.Fatalf("unexpected api key header: %q", apiKey)
+	}
+	if _, ok := body["system_instruction"]; !ok {
+		t.Fatalf("expected system_instruction in request: %v", body)
+	}
+
+	handler := &recordingStreamHandler{}
+	if err := provider.ChatStream(context.Background(), []Message{{Role: "user", Content: "ping"}}, nil, "", handler); err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if paths[1] != "/v1beta/models/gemini-2.5-flash:streamGenerateContent?alt=sse" {
+		t.Fatalf("unexpected stream path: %q", paths[1])
+	}
+	if handler.content != "Hello" || !handler.completed {
+		t.Fatalf("unexpected stream content: %+v", handler)
+	}
+	if len(handler.toolNames) != 1 || handler.toolNames[0] != "list_dir" || handler.toolArgs != `{"path":"."}` || handler.toolEnds != 1 {
+		t.Fatalf("unexpected stream tool calls: %+v", handler)
+	}
+}
+
+type recordingStreamHandler struct {
+	content   string
+	reasoning string
+	toolNames []string
+	toolArgs  string
+	toolEnds  int
+	completed bool
+}
+
+func (h *recordingStreamHandler) OnContent(token string)   { h.content += token }
+func (h *recordingStreamHandler) OnReasoning(token string) { h.reasoning += token }
+func (h *recordingStreamHandler) OnToolCallStart(id, name string) {
+	h.toolNames = append(h.toolNames, name)
+}
+func (h *recordingStreamHandler) OnToolCallDelta(id, delta string) { h.toolArgs += delta }
+func (h *recordingStreamHandler) OnToolCallEnd(id string)          { h.toolEnds++ }
+func (h *recordingStreamHandler) OnComplete()                      { h.completed = true }
+func (h *recordingStreamHandler) OnError(err error)                {}
+
+func TestGeminiProviderSendsStopSequences(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]},"finishReason":"STOP"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewGeminiProvider("gemini-key", server.URL+"/v1beta", "gemini-2.5-flash", 64, 0.1, nil)
+	if err != nil {
+		t.Fatalf("NewGeminiProvider failed: %v", err)
+	}
+
+	ctx := WithChatOptions(context.Background(), ChatOptions{Stop: []string{"END"}})
+	if _, err := provider.Chat(ctx, []Message{{Role: "user", Content: "ping"}}, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	config, _ := body["generationConfig"].(map[string]interface{})
+	stop, _ := config["stopSequences"].([]interface{})
+	if len(stop) != 1 || stop[0] != "END" {
+		t.Fatalf("unexpected generationConfig: %v", body["generationConfig"])
+	}
+}
@@ -0,0 +1,111 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// RequestLimiter 限制同时进行的 provider 请求数，超出上限的请求排队等待空位；
+// 多个 provider 实例共用同一个限制器时即为全局上限
+type RequestLimiter struct {
+	mu    sync.Mutex
+	slots chan struct{} // nil 表示不限制
+}
+
+// NewRequestLimiter 创建请求限制器；max <= 0 表示不限制
+func NewRequestLimiter(max int) *RequestLimiter {
+	l := &RequestLimiter{}
+	l.SetLimit(max)
+	return l
+}
+
+// DefaultRequestLimiter 进程内所有 agent 共享的 provider 请求限制器
+var DefaultRequestLimiter = NewRequestLimiter(0)
+
+// SetMaxConcurrentRequests 设置全局 provider 并发请求上限，0 表示不限制
+func SetMaxConcurrentRequests(max int) {
+	DefaultRequestLimiter.SetLimit(max)
+}
+
+// SetLimit 调整并发上限；已在进行中的请求按旧上限释放，不受影响
+func (l *RequestLimiter) SetLimit(max int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if max <= 0 {
+		l.slots = nil
+		return
+	}
+	if l.slots != nil && cap(l.slots) == max {
+		return
+	}
+	l.slots = make(chan struct{}, max)
+}
+
+// Limit 返回当前并发上限，0 表示不限制
+func (l *RequestLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return cap(l.slots)
+}
+
+// Acquire 占用一个请求名额，没有空位时阻塞直到有请求结束或 ctx 结束；
+// 成功时返回的 release 必须调用一次
+func (l *RequestLimiter) Acquire(ctx context.Context) (func(), error) {
+	l.mu.Lock()
+	slots := l.slots
+	l.mu.Unlock()
+	if slots == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+	default:
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("waiting for provider request slot (limit %d): %w", cap(slots), ctx.Err())
+		}
+	}
+	var once sync.Once
+	return func() { once.Do(func() { <-slots }) }, nil
+}
+
+// limitedProvider 在 Chat / ChatStream 外层加上并发限制
+type limitedProvider struct {
+	LLMProvider
+	limiter *RequestLimiter
+}
+
+// WithRequestLimit 用限制器包装 provider；limiter 为 nil 时原样返回
+func WithRequestLimit(provider LLMProvider, limiter *RequestLimiter) LLMProvider {
+	if provider == nil || limiter == nil {
+		return provider
+	}
+	if existing, ok := provider.(*limitedProvider); ok && existing.limiter == limiter {
+		return provider
+	}
+	return &limitedProvider{LLMProvider: provider, limiter: limiter}
+}
+
+func (p *limitedProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	release, err := p.limiter.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.LLMProvider.Chat(ctx, messages, tools, model)
+}
+
+func (p *limitedProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
+	release, err := p.limiter.Acquire(ctx)
+	if err != nil {
+		if handler != nil {
+			handler.OnError(err)
+		}
+		return err
+	}
+	defer release()
+	return p.LLMProvider.ChatStream(ctx, messages, tools, model, handler)
+}
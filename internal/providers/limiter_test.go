@@ -0,0 +1,126 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingProvider 记录同时进行的请求数，每个请求保持一小段时间
+type blockingProvider struct {
+	active  int32
+	maxSeen int32
+	hold    time.Duration
+}
+
+func (p *blockingProvider) enter() {
+	n := atomic.AddInt32(&p.active, 1)
+	for {
+		seen := atomic.LoadInt32(&p.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(&p.maxSeen, seen, n) {
+			break
+		}
+	}
+	time.Sleep(p.hold)
+	atomic.AddInt32(&p.active, -1)
+}
+
+func (p *blockingProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	p.enter()
+	return &Response{Content: "ok"}, nil
+}
+
+func (p *blockingProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
+	p.enter()
+	return nil
+}
+
+func (p *blockingProvider) GetDefaultModel() string { return "test-model" }
+
+func (p *blockingProvider) SupportsImageInput(model string) bool { return false }
+
+func TestRequestLimiterSerializesBeyondLimit(t *testing.T) {
+	inner := &blockingProvider{hold: 30 * time.Millisecond}
+	limiter := NewRequestLimiter(2)
+	// 分别包装的实例共享同一个限制器
+	first := WithRequestLimit(inner, limiter)
+	second := WithRequestLimit(inner, limiter)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		provider := first
+		if i%2 == 1 {
+			provider = second
+		}
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var err error
+			if i%3 == 0 {
+				_, err = provider.Chat(context.Background(), nil, nil, "m")
+			} else {
+				err = provider.ChatStream(context.Background(), nil, nil, "m", nil)
+			}
+			if err != nil {
+				t.Errorf("request %d failed: %v", i, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&inner.maxSeen); got != 2 {
+		t.Fatalf("max concurrent requests = %d, want 2", got)
+	}
+
+	// 调整为 1 后完全串行
+	limiter.SetLimit(1)
+	atomic.StoreInt32(&inner.maxSeen, 0)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = first.ChatStream(context.Background(), nil, nil, "m", nil)
+		}()
+	}
+	wg.Wait()
+	if got := atomic.LoadInt32(&inner.maxSeen); got != 1 {
+		t.Fatalf("max concurrent requests after SetLimit(1) = %d, want 1", got)
+	}
+}
+
+func TestRequestLimiterHonorsContextDeadlineWhileQueued(t *testing.T) {
+	limiter := NewRequestLimiter(1)
+	release, err := limiter.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	defer release()
+
+	provider := WithRequestLimit(&blockingProvider{}, limiter)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = provider.ChatStream(ctx, nil, nil, "m", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded while queued, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("queued request did not honor deadline, waited %v", elapsed)
+	}
+}
+
+func TestRequestLimiterUnlimitedByDefault(t *testing.T) {
+	limiter := NewRequestLimiter(0)
+	if limiter.Limit() != 0 {
+		t.Fatalf("expected unlimited limiter, got limit %d", limiter.Limit())
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := limiter.Acquire(context.Background()); err != nil {
+			t.Fatalf("Acquire %d failed: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MockModel 选择内置 mock 提供商的模型名（也接受 mock/<任意>）
+	MockModel = "mock"
+	// mockToolPrefix 用户消息以此开头时返回脚本化的工具调用：tool:<name> <json 参数>
+	mockToolPrefix = "tool:"
+)
+
+// MockProvider 不访问网络的确定性提供商，用于演示、CI 与端到端测试：
+// 普通消息原样回显；"tool:<name> {json}" 触发一次工具调用；工具结果返回后回显结果
+type MockProvider struct {
+	defaultModel string
+}
+
+// NewMockProvider 创建 mock 提供商
+func NewMockProvider(defaultModel string) *MockProvider {
+	if strings.TrimSpace(defaultModel) == "" {
+		defaultModel = MockModel
+	}
+	return &MockProvider{defaultModel: defaultModel}
+}
+
+// IsMockModel 判断模型名是否选择 mock 提供商
+func IsMockModel(model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	return model == MockModel || strings.HasPrefix(model, MockModel+"/")
+}
+
+// Chat 返回确定性响应
+func (p *MockProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	resp := mockRespond(messages)
+	resp.Content = truncateAtStop(resp.Content, ChatOptionsFrom(ctx).stopSequences())
+	return resp, nil
+}
+
+// ChatStream 按空白切分内容逐个 token 推送，工具调用一次性发出
+func (p *MockProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
+	if err := ctx.Err(); err != nil {
+		handler.OnError(err)
+		return err
+	}
+
+	resp := mockRespond(messages)
+	resp.Content = truncateAtStop(resp.Content, ChatOptionsFrom(ctx).stopSequences())
+	for _, token := range strings.SplitAfter(resp.Content, " ") {
+		if token != "" {
+			handler.OnContent(token)
+		}
+	}
+	for _, call := range resp.ToolCalls {
+		handler.OnToolCallStart(call.ID, call.Function.Name)
+		handler.OnToolCallDelta(call.ID, call.Function.Arguments)
+		handler.OnToolCallEnd(call.ID)
+	}
+	handler.OnComplete()
+	return nil
+}
+
+// GetDefaultModel 获取默认模型
+func (p *MockProvider) GetDefaultModel() string {
+	return p.defaultModel
+}
+
+func (p *MockProvider) SupportsImageInput(model string) bool {
+	return false
+}
+
+// truncateAtStop 模拟服务端停止序列：在最早出现的停止序列处截断（不含序列本身）
+func truncateAtStop(content string, stop []string) string {
+	cut := len(content)
+	for _, seq := range stop {
+		if idx := strings.Index(content, seq); idx >= 0 && idx < cut {
+			cut = idx
+		}
+	}
+	return content[:cut]
+}
+
+func mockRespond(messages []Message) *Response {
+	if len(messages) == 0 {
+		return &Response{Content: "echo: "}
+	}
+
+	last := messages[len(messages)-1]
+	if last.Role == "tool" {
+		return &Response{Content: "tool result: " + flattenContentParts(last)}
+	}
+
+	text := strings.TrimSpace(flattenContentParts(last))
+	if strings.HasPrefix(text, mockToolPrefix) {
+		spec := strings.TrimSpace(strings.TrimPrefix(text, mockToolPrefix))
+		name, args, _ := strings.Cut(spec, " ")
+		args = strings.TrimSpace(args)
+		if args == "" {
+			args = "{}"
+		}
+		if name != "" {
+			return &Response{
+				ToolCalls: []ToolCall{{
+					// 以消息数生成 ID，保证同一会话内唯一且可复现
+					ID:   fmt.Sprintf("mock_call_%d", len(messages)),
+					Type: "function",
+					Function: ToolCallFunction{
+						Name:      name,
+						Arguments: args,
+					},
+				}},
+				HasToolCalls: true,
+			}
+		}
+	}
+
+	return &Response{Content: "echo: " + text}
+}
@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMockProviderEchoesAndScriptsToolCalls(t *testing.T) {
+	provider, err := NewProvider("", "", "", "mock", 0, 0, nil)
+	if err != nil {
+		t.Fatalf("NewProvider mock failed: %v", err)
+	}
+	if _, ok := provider.(*MockProvider); !ok {
+		t.Fatalf("expected MockProvider, got %T", provider)
+	}
+
+	resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hello world"}}, nil, "")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "echo: hello world" || resp.HasToolCalls {
+		t.Fatalf("unexpected echo response: %+v", resp)
+	}
+
+	resp, err = provider.Chat(context.Background(), []Message{{Role: "user", Content: `tool:list_dir {"path":"."}`}}, nil, "")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if !resp.HasToolCalls || resp.ToolCalls[0].Function.Name != "list_dir" || resp.ToolCalls[0].Function.Arguments != `{"path":"."}` {
+		t.Fatalf("unexpected scripted tool call: %+v", resp)
+	}
+
+	handler := &recordingStreamHandler{}
+	err = provider.ChatStream(context.Background(), []Message{
+		{Role: "user", Content: "tool:exec"},
+		{Role: "assistant", ToolCalls: resp.ToolCalls},
+		{Role: "tool", ToolCallID: resp.ToolCalls[0].ID, Content: "done"},
+	}, nil, "", handler)
+	if err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if handler.content != "tool result: done" || !handler.completed || len(handler.toolNames) != 0 {
+		t.Fatalf("unexpected stream: %+v", handler)
+	}
+
+	if !IsMockModel("Mock/demo") || IsMockModel("mockingbird") {
+		t.Fatalf("unexpected IsMockModel results")
+	}
+}
+
+func TestMockProviderHonorsStopSequences(t *testing.T) {
+	provider := NewMockProvider("")
+	ctx := WithChatOptions(context.Background(), ChatOptions{Stop: []string{"|", " c"}})
+	messages := []Message{{Role: "user", Content: "a b c|d"}}
+
+	resp, err := provider.Chat(ctx, messages, nil, "")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "echo: a b" {
+		t.Fatalf("expected content cut at first stop sequence, got %q", resp.Content)
+	}
+
+	handler := &recordingStreamHandler{}
+	if err := provider.ChatStream(ctx, messages, nil, "", handler); err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if got := handler.content; got != "echo: a b" {
+		t.Fatalf("expected streamed content cut at stop sequence, got %q", got)
+	}
+}
@@ -14,13 +14,20 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
 )
 
 const maxInlineImageBytes = 8 * 1024 * 1024
 
-// debug flag - set to false in production
-const debug = false
+// debugf 以 debug 级别写入 session 日志（MAXCLAW_LOG_LEVEL=debug 时可见）
+func debugf(format string, args ...interface{}) {
+	if lg := logging.Get(); lg != nil && lg.Session != nil {
+		lg.Session.Debugf(format, args...)
+	}
+}
 
 // OpenAIProvider OpenAI 提供商实现
 // 使用 OpenAI 兼容 API (string content) 以支持 DeepSeek 等提供商
@@ -33,6 +40,8 @@ type OpenAIProvider struct {
 	httpClient         *http.Client
 	streamClient       *http.Client
 	supportsImageInput func(model string) bool
+	// streamOnlyUntil 网关明确拒绝非流式请求且流式回退成功后，在此时间（UnixNano）之前 Chat 直接走流式，过期后重新探测
+	streamOnlyUntil atomic.Int64
 }
 
 // NewOpenAIProvider 创建 OpenAI 提供商
@@ -73,21 +82,43 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, tools []m
 	if model == "" {
 		model = p.defaultModel
 	}
+	if time.Now().UnixNano() < p.streamOnlyUntil.Load() {
+		return p.chatViaStream(ctx, messages, tools, model)
+	}
 
 	reqBody := buildChatRequest(messages, tools, model, p.SupportsImageInput(model), false, p.maxTokens, p.temperature)
+	if err := reqBody.applyOptions(ChatOptionsFrom(ctx)); err != nil {
+		return nil, err
+	}
 	payload, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode request: %w", err)
 	}
 
-	if debug {
-		fmt.Printf("[OpenAIProvider] Request:\n%s\n", string(payload))
+	if logging.Enabled(logging.LevelDebug) {
+		debugf("[OpenAIProvider] Request:\n%s", string(payload))
 	}
 
 	respBody, err := p.doRequest(ctx, payload, false, model)
 	if err != nil {
+		if isStreamRequiredError(err) {
+			// 网关禁用了非流式请求：流式回退成功后一段时间内直接走流式
+			resp, streamErr := p.chatViaStream(ctx, messages, tools, model)
+			if streamErr == nil {
+				p.streamOnlyUntil.Store(time.Now().Add(streamOnlyRecheckInterval).UnixNano())
+			}
+			return resp, streamErr
+		}
 		return nil, p.wrapModelRequestError("chat request failed", model, err)
 	}
+	if isEventStream(respBody) {
+		// 网关忽略 stream=false 仍返回 SSE
+		collector := newResponseCollector()
+		if err := p.readStream(ctx, bytes.NewReader(respBody), model, collector); err != nil {
+			return nil, p.wrapModelRequestError("chat request failed", model, err)
+		}
+		return collector.response()
+	}
 
 	var resp chatResponse
 	if err := json.Unmarshal(respBody, &resp); err != nil {
@@ -122,6 +153,15 @@ func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message, tools []m
 	return result, nil
 }
 
+// chatViaStream 通过流式接口完成一次 Chat，把内容和工具调用拼装为完整的 Response
+func (p *OpenAIProvider) chatViaStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
+	collector := newResponseCollector()
+	if err := p.ChatStream(ctx, messages, tools, model, collector); err != nil {
+		return nil, err
+	}
+	return collector.response()
+}
+
 // GetDefaultModel 获取默认模型
 func (p *OpenAIProvider) GetDefaultModel() string {
 	return p.defaultModel
@@ -144,13 +184,16 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []Message, too
 	}
 
 	reqBody := buildChatRequest(messages, tools, model, p.SupportsImageInput(model), true, p.maxTokens, p.temperature)
+	if err := reqBody.applyOptions(ChatOptionsFrom(ctx)); err != nil {
+		return err
+	}
 	payload, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("failed to encode request: %w", err)
 	}
 
-	if debug {
-		fmt.Printf("[OpenAIProvider] Stream Request:\n%s\n", string(payload))
+	if logging.Enabled(logging.LevelDebug) {
+		debugf("[OpenAIProvider] Stream Request:\n%s", string(payload))
 	}
 
 	stream, err := p.doStreamRequest(ctx, payload, model)
@@ -160,7 +203,11 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []Message, too
 		return wrappedErr
 	}
 	defer stream.Close()
+	return p.readStream(ctx, stream, model, handler)
+}
 
+// readStream 解析 SSE 响应并回调 handler，直到流结束或收到 [DONE]
+func (p *OpenAIProvider) readStream(ctx context.Context, stream io.Reader, model string, handler StreamHandler) error {
 	buildersByIndex := make(map[int]*toolCallBuilder)
 
 	// Use a goroutine to read from stream so we can respond to context cancellation
@@ -225,9 +272,10 @@ func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []Message, too
 			delta := choice.Delta
 
 			if choice.FinishReason != "" {
-				fmt.Printf("[DEBUG] FinishReason: %s\n", choice.FinishReason)
+				debugf("[OpenAIProvider] FinishReason: %s", choice.FinishReason)
 			}
 
+			emitReasoning(handler, delta.ReasoningContent)
 			if delta.Content != "" {
 				handler.OnContent(delta.Content)
 			}
@@ -661,13 +709,27 @@ func formatAPIError(body []byte, status int) string {
 // ---- OpenAI-compatible request/response structs ----
 
 type chatRequest struct {
-	Model       string                   `json:"model"`
-	Messages    []chatMessage            `json:"messages"`
-	Tools       []map[string]interface{} `json:"tools,omitempty"`
-	ToolChoice  interface{}              `json:"tool_choice,omitempty"`
-	Stream      bool                     `json:"stream,omitempty"`
-	MaxTokens   int                      `json:"max_tokens"`
-	Temperature float64                  `json:"temperature"`
+	Model      string                   `json:"model"`
+	Messages   []chatMessage            `json:"messages"`
+	Tools      []map[string]interface{} `json:"tools,omitempty"`
+	ToolChoice interface{}              `json:"tool_choice,omitempty"`
+	// ResponseFormat 结构化输出（json_object / json_schema）
+	ResponseFormat interface{} `json:"response_format,omitempty"`
+	// Stop 停止序列
+	Stop        []string `json:"stop,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+	MaxTokens   int      `json:"max_tokens"`
+	Temperature float64  `json:"temperature"`
+}
+
+// applyOptions 写入单次请求选项（结构化输出、停止序列）
+func (r *chatRequest) applyOptions(opts ChatOptions) error {
+	if err := opts.ResponseFormat.validate(); err != nil {
+		return err
+	}
+	r.ResponseFormat = opts.ResponseFormat.chatPayload()
+	r.Stop = opts.stopSequences()
+	return nil
 }
 
 type chatMessage struct {
@@ -710,8 +772,10 @@ type chatResponse struct {
 type chatStreamChunk struct {
 	Choices []struct {
 		Delta struct {
-			Content   string              `json:"content,omitempty"`
-			ToolCalls []chatToolCallDelta `json:"tool_calls,omitempty"`
+			Content string `json:"content,omitempty"`
+			// ReasoningContent DeepSeek-R1 等推理模型单独返回的思考内容
+			ReasoningContent string              `json:"reasoning_content,omitempty"`
+			ToolCalls        []chatToolCallDelta `json:"tool_calls,omitempty"`
 		} `json:"delta"`
 		FinishReason string `json:"finish_reason,omitempty"`
 	} `json:"choices"`
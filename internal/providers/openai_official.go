@@ -63,6 +63,9 @@ func newOpenAIOfficialProvider(apiKey, apiBase, defaultModel string, maxTokens i
 
 func (p *OpenAIOfficialProvider) Chat(ctx context.Context, messages []Message, tools []map[string]interface{}, model string) (*Response, error) {
 	params := p.buildChatParams(messages, tools, model)
+	if err := applyOfficialChatOptions(&params, ChatOptionsFrom(ctx)); err != nil {
+		return nil, err
+	}
 
 	resp, err := p.client.Chat.Completions.New(ctx, params)
 	if err != nil {
@@ -96,6 +99,9 @@ func (p *OpenAIOfficialProvider) Chat(ctx context.Context, messages []Message, t
 
 func (p *OpenAIOfficialProvider) ChatStream(ctx context.Context, messages []Message, tools []map[string]interface{}, model string, handler StreamHandler) error {
 	params := p.buildChatParams(messages, tools, model)
+	if err := applyOfficialChatOptions(&params, ChatOptionsFrom(ctx)); err != nil {
+		return err
+	}
 
 	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
 	defer stream.Close()
@@ -192,6 +198,39 @@ func (p *OpenAIOfficialProvider) buildChatParams(messages []Message, tools []map
 	return params
 }
 
+// applyOfficialChatOptions 写入单次请求选项（结构化输出、停止序列）
+func applyOfficialChatOptions(params *openai.ChatCompletionNewParams, opts ChatOptions) error {
+	if err := opts.ResponseFormat.validate(); err != nil {
+		return err
+	}
+	applyOfficialResponseFormat(params, opts.ResponseFormat)
+	if stop := opts.stopSequences(); len(stop) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: stop}
+	}
+	return nil
+}
+
+// applyOfficialResponseFormat 设置结构化输出格式
+func applyOfficialResponseFormat(params *openai.ChatCompletionNewParams, format *ResponseFormat) {
+	switch format.kind() {
+	case ResponseFormatJSONSchema:
+		schema := shared.ResponseFormatJSONSchemaJSONSchemaParam{
+			Name:   format.schemaName(),
+			Schema: format.Schema,
+		}
+		if format.Strict {
+			schema.Strict = openai.Bool(true)
+		}
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{JSONSchema: schema},
+		}
+	case ResponseFormatJSONObject:
+		params.ResponseFormat = openai.ChatCompletionNewParamsResponseFormatUnion{
+			OfJSONObject: &shared.ResponseFormatJSONObjectParam{},
+		}
+	}
+}
+
 func convertToOfficialOpenAIMessages(messages []Message, allowImageInput bool) []openai.ChatCompletionMessageParamUnion {
 	result := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages))
 	for _, msg := range messages {
@@ -52,3 +52,71 @@ func TestOpenAIOfficialProviderUsesSDKEndpointAndNormalizesModel(t *testing.T) {
 		t.Fatalf("expected normalized model, got %q", model)
 	}
 }
+
+func TestOpenAIOfficialProviderSendsResponseFormat(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl_123","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"{}"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := newOpenAIOfficialProvider("sk-openai", server.URL+"/v1", "gpt-5.1", 64, 0, nil, server.Client())
+	if err != nil {
+		t.Fatalf("newOpenAIOfficialProvider failed: %v", err)
+	}
+
+	ctx := WithChatOptions(context.Background(), ChatOptions{ResponseFormat: &ResponseFormat{
+		Type:   ResponseFormatJSONSchema,
+		Name:   "result",
+		Schema: map[string]interface{}{"type": "object"},
+	}})
+	if _, err := provider.Chat(ctx, []Message{{Role: "user", Content: "ping"}}, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	format, _ := body["response_format"].(map[string]any)
+	jsonSchema, _ := format["json_schema"].(map[string]any)
+	if format["type"] != "json_schema" || jsonSchema["name"] != "result" {
+		t.Fatalf("unexpected response_format: %v", body["response_format"])
+	}
+
+	body = nil
+	ctx = WithChatOptions(context.Background(), ChatOptions{ResponseFormat: &ResponseFormat{Type: "text"}})
+	if _, err := provider.Chat(ctx, []Message{{Role: "user", Content: "ping"}}, nil, ""); err == nil {
+		t.Fatal("expected unknown response format type to be rejected")
+	}
+	if body != nil {
+		t.Fatalf("unknown response format should not be sent: %v", body)
+	}
+}
+
+func TestOpenAIOfficialProviderSendsStopSequences(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"chatcmpl_123","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := newOpenAIOfficialProvider("sk-openai", server.URL+"/v1", "gpt-5.1", 64, 0, nil, server.Client())
+	if err != nil {
+		t.Fatalf("newOpenAIOfficialProvider failed: %v", err)
+	}
+
+	ctx := WithChatOptions(context.Background(), ChatOptions{Stop: []string{"</answer>"}})
+	if _, err := provider.Chat(ctx, []Message{{Role: "user", Content: "ping"}}, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+
+	stop, _ := body["stop"].([]any)
+	if len(stop) != 1 || stop[0] != "</answer>" {
+		t.Fatalf("unexpected stop: %v", body["stop"])
+	}
+}
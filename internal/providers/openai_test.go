@@ -3,6 +3,7 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -260,3 +261,283 @@ func TestOpenAIProviderMiniMaxRequestUsesBearerAuthorization(t *testing.T) {
 		t.Fatalf("expected bearer auth header, got %q", authHeader)
 	}
 }
+
+func TestOpenAIProviderChatSendsResponseFormat(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"{\"ok\":true}"}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("sk-test", server.URL, "deepseek-chat", 64, 0, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	messages := []Message{{Role: "user", Content: "ping"}}
+
+	if _, err := provider.Chat(context.Background(), messages, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, ok := bodies[0]["response_format"]; ok {
+		t.Fatalf("response_format should be omitted without options: %v", bodies[0])
+	}
+
+	ctx := WithChatOptions(context.Background(), ChatOptions{ResponseFormat: &ResponseFormat{Type: ResponseFormatJSONObject}})
+	if _, err := provider.Chat(ctx, messages, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	format, _ := bodies[1]["response_format"].(map[string]interface{})
+	if format["type"] != "json_object" {
+		t.Fatalf("expected json_object response_format, got %v", bodies[1]["response_format"])
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"ok": map[string]interface{}{"type": "boolean"}},
+	}
+	ctx = WithChatOptions(context.Background(), ChatOptions{ResponseFormat: &ResponseFormat{Name: "status", Schema: schema, Strict: true}})
+	if _, err := provider.Chat(ctx, messages, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	format, _ = bodies[2]["response_format"].(map[string]interface{})
+	jsonSchema, _ := format["json_schema"].(map[string]interface{})
+	if format["type"] != "json_schema" || jsonSchema["name"] != "status" || jsonSchema["strict"] != true || jsonSchema["schema"] == nil {
+		t.Fatalf("unexpected json_schema response_format: %v", bodies[2]["response_format"])
+	}
+
+	// 未知类型直接报错，不发送请求
+	ctx = WithChatOptions(context.Background(), ChatOptions{ResponseFormat: &ResponseFormat{Type: "xml"}})
+	if _, err := provider.Chat(ctx, messages, nil, ""); err == nil || !strings.Contains(err.Error(), `unsupported response format type "xml"`) {
+		t.Fatalf("expected unsupported response format error, got %v", err)
+	}
+	if err := provider.ChatStream(ctx, messages, nil, "", nil); err == nil {
+		t.Fatal("expected ChatStream to reject unknown response format")
+	}
+	if len(bodies) != 3 {
+		t.Fatalf("unknown response format should not be sent, got %d requests", len(bodies))
+	}
+}
+
+func TestChatStreamChunkDecodesReasoningContent(t *testing.T) {
+	var chunk chatStreamChunk
+	if err := json.Unmarshal([]byte(`{"choices":[{"delta":{"reasoning_content":"thinking","content":""}}]}`), &chunk); err != nil {
+		t.Fatalf("decode chunk: %v", err)
+	}
+	if len(chunk.Choices) != 1 || chunk.Choices[0].Delta.ReasoningContent != "thinking" || chunk.Choices[0].Delta.Content != "" {
+		t.Fatalf("unexpected decoded chunk: %+v", chunk)
+	}
+}
+
+func TestOpenAIProviderChatStreamRoutesReasoningSeparately(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"Let me \"}}]}\n\n"))
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"reasoning_content\":\"think.\"}}]}\n\n"))
+		_, _ = w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"Answer\"}}]}\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("sk-test", server.URL, "deepseek-reasoner", 64, 0, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	handler := &recordingStreamHandler{}
+	if err := provider.ChatStream(context.Background(), []Message{{Role: "user", Content: "ping"}}, nil, "", handler); err != nil {
+		t.Fatalf("ChatStream failed: %v", err)
+	}
+	if handler.reasoning != "Let me think." {
+		t.Fatalf("unexpected reasoning: %q", handler.reasoning)
+	}
+	if handler.content != "Answer" || !handler.completed {
+		t.Fatalf("reasoning should stay out of content: %+v", handler)
+	}
+}
+
+func TestOpenAIProviderChatSendsStopSequences(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		bodies = append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("sk-test", server.URL, "deepseek-chat", 64, 0, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	messages := []Message{{Role: "user", Content: "ping"}}
+
+	if _, err := provider.Chat(context.Background(), messages, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if _, ok := bodies[0]["stop"]; ok {
+		t.Fatalf("stop should be omitted without options: %v", bodies[0])
+	}
+
+	ctx := WithChatOptions(context.Background(), ChatOptions{Stop: []string{"END", "", "END", "\n\n", "###", "---", "extra"}})
+	if _, err := provider.Chat(ctx, messages, nil, ""); err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	stop, _ := bodies[1]["stop"].([]interface{})
+	want := []interface{}{"END", "\n\n", "###", "---"}
+	if len(stop) != len(want) {
+		t.Fatalf("expected %v stop sequences, got %v", want, bodies[1]["stop"])
+	}
+	for i := range want {
+		if stop[i] != want[i] {
+			t.Fatalf("expected %v stop sequences, got %v", want, stop)
+		}
+	}
+}
+
+// sseChatResponse 一段包含文本和分片工具调用参数的 SSE 响应
+const sseChatResponse = `data: {"choices":[{"delta":{"content":"Hello, "}}]}
+
+data: {"choices":[{"delta":{"content":"world"}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_file","arguments":"{\"path\":"}}]}}]}
+
+data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"a.txt\"}"}}]}}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+
+func TestOpenAIProviderChatFallsBackToStreamWhenNonStreamingIsDisabled(t *testing.T) {
+	var nonStreamRequests, streamRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if body["stream"] != true {
+			nonStreamRequests++
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"stream must be set to true"}}`))
+			return
+		}
+		streamRequests++
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(sseChatResponse))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("sk-test", server.URL, "gpt-4o", 64, 0, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "")
+		if err != nil {
+			t.Fatalf("Chat failed: %v", err)
+		}
+		if resp.Content != "Hello, world" {
+			t.Fatalf("expected assembled content, got %q", resp.Content)
+		}
+		if !resp.HasToolCalls || len(resp.ToolCalls) != 1 {
+			t.Fatalf("expected one tool call, got %+v", resp.ToolCalls)
+		}
+		call := resp.ToolCalls[0]
+		if call.ID != "call_1" || call.Function.Name != "read_file" || call.Function.Arguments != `{"path":"a.txt"}` {
+			t.Fatalf("unexpected tool call: %+v", call)
+		}
+	}
+	// 检测到只支持流式后，后续 Chat 不再先发非流式请求
+	if nonStreamRequests != 1 || streamRequests != 2 {
+		t.Fatalf("expected 1 non-stream and 2 stream requests, got %d and %d", nonStreamRequests, streamRequests)
+	}
+}
+
+func TestOpenAIProviderChatDoesNotStickToStreamWhenFallbackFails(t *testing.T) {
+	var nonStreamRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if body["stream"] != true {
+			nonStreamRequests++
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"message":"stream must be set to true"}}`))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("sk-test", server.URL, "gpt-4o", 64, 0, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, ""); err == nil {
+			t.Fatal("expected error when the stream fallback fails too")
+		}
+	}
+	// 流式回退失败时不记住“只支持流式”，下一次仍先发非流式请求
+	if nonStreamRequests != 2 {
+		t.Fatalf("expected 2 non-stream requests, got %d", nonStreamRequests)
+	}
+}
+
+func TestOpenAIProviderChatAssemblesSSEBodyForNonStreamingRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 网关忽略 stream=false，始终返回 SSE
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(sseChatResponse))
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("sk-test", server.URL, "gpt-4o", 64, 0, nil)
+	if err != nil {
+		t.Fatalf("NewOpenAIProvider failed: %v", err)
+	}
+	resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil, "")
+	if err != nil {
+		t.Fatalf("Chat failed: %v", err)
+	}
+	if resp.Content != "Hello, world" || len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Function.Arguments != `{"path":"a.txt"}` {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+}
+
+func TestIsStreamRequiredError(t *testing.T) {
+	for _, msg := range []string{
+		"chat completion failed: status 400: stream must be set to true",
+		"chat completion failed: status 400: Only streaming requests are supported",
+		"chat completion failed: status 400: non-stream mode is disabled",
+		`chat completion failed: status 400: "stream" must be true`,
+		"chat completion failed: status 400: streaming is required for this model",
+	} {
+		if !isStreamRequiredError(errors.New(msg)) {
+			t.Fatalf("expected %q to be detected", msg)
+		}
+	}
+	for _, msg := range []string{
+		"chat completion failed: status 401: invalid api key",
+		"chat completion failed: status 400: model does not support tools",
+		"chat completion failed: status 400: model does not support streaming",
+		"chat completion failed: status 400: upstream request failed: max_tokens must be positive",
+		"chat completion failed: status 400: upstream provider only supports json mode",
+		"chat completion failed after 3 attempts: chat completion failed: status 502: upstream connect error or disconnect/reset before headers",
+		"chat completion failed: status 503: upstream service unavailable, streaming required",
+		"chat completion failed: status 403: non-stream mode is disabled",
+	} {
+		if isStreamRequiredError(errors.New(msg)) {
+			t.Fatalf("expected %q not to be detected", msg)
+		}
+	}
+}
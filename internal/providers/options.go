@@ -0,0 +1,122 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	ResponseFormatJSONObject = "json_object"
+	ResponseFormatJSONSchema = "json_schema"
+)
+
+// ResponseFormat 结构化输出格式（对应 OpenAI 的 response_format）
+type ResponseFormat struct {
+	// Type 为 json_object 或 json_schema；为空时有 Schema 则视为 json_schema
+	Type   string
+	Name   string
+	Schema map[string]interface{}
+	Strict bool
+}
+
+// ChatOptions 单次请求的可选参数；通过 context 传入 Chat/ChatStream，
+// 不改变 LLMProvider 的方法签名
+type ChatOptions struct {
+	ResponseFormat *ResponseFormat
+	// Stop 生成遇到任一序列即停止（结果不含该序列）；空串会被忽略，最多保留 MaxStopSequences 个
+	Stop []string
+}
+
+// MaxStopSequences 单次请求的停止序列上限（OpenAI 兼容接口最多接受 4 个）
+const MaxStopSequences = 4
+
+type chatOptionsKey struct{}
+
+// WithChatOptions 把请求选项附加到 context
+func WithChatOptions(ctx context.Context, opts ChatOptions) context.Context {
+	return context.WithValue(ctx, chatOptionsKey{}, opts)
+}
+
+// ChatOptionsFrom 读取 context 中的请求选项
+func ChatOptionsFrom(ctx context.Context) ChatOptions {
+	if ctx == nil {
+		return ChatOptions{}
+	}
+	opts, _ := ctx.Value(chatOptionsKey{}).(ChatOptions)
+	return opts
+}
+
+// stopSequences 返回去空、去重并截断到 MaxStopSequences 的停止序列；没有时返回 nil
+func (o ChatOptions) stopSequences() []string {
+	var out []string
+	seen := make(map[string]bool, len(o.Stop))
+	for _, seq := range o.Stop {
+		if seq == "" || seen[seq] {
+			continue
+		}
+		seen[seq] = true
+		out = append(out, seq)
+		if len(out) == MaxStopSequences {
+			break
+		}
+	}
+	return out
+}
+
+// kind 返回规范化后的格式类型；未知类型返回空，由 validate 报错
+func (f *ResponseFormat) kind() string {
+	if f == nil {
+		return ""
+	}
+	switch strings.ToLower(strings.TrimSpace(f.Type)) {
+	case ResponseFormatJSONSchema:
+		return ResponseFormatJSONSchema
+	case ResponseFormatJSONObject:
+		return ResponseFormatJSONObject
+	case "":
+		if f.Schema != nil {
+			return ResponseFormatJSONSchema
+		}
+		return ResponseFormatJSONObject
+	default:
+		return ""
+	}
+}
+
+// validate 拒绝未知的格式类型，避免请求被悄悄改成 json_object
+func (f *ResponseFormat) validate() error {
+	if f != nil && f.kind() == "" {
+		return fmt.Errorf("unsupported response format type %q (expected %s or %s)", f.Type, ResponseFormatJSONObject, ResponseFormatJSONSchema)
+	}
+	return nil
+}
+
+func (f *ResponseFormat) schemaName() string {
+	if name := strings.TrimSpace(f.Name); name != "" {
+		return name
+	}
+	return "response"
+}
+
+// chatPayload 生成 OpenAI 兼容接口的 response_format 字段
+func (f *ResponseFormat) chatPayload() interface{} {
+	switch f.kind() {
+	case ResponseFormatJSONSchema:
+		schema := map[string]interface{}{
+			"name":   f.schemaName(),
+			"schema": f.Schema,
+		}
+		if f.Strict {
+			schema["strict"] = true
+		}
+		return map[string]interface{}{
+			"type":        ResponseFormatJSONSchema,
+			"json_schema": schema,
+		}
+	case ResponseFormatJSONObject:
+		return map[string]interface{}{"type": ResponseFormatJSONObject}
+	default:
+		return nil
+	}
+}
@@ -6,6 +6,8 @@ type ProviderSpec struct {
 	Name           string
 	Keywords       []string
 	DefaultAPIBase string
+	// DefaultModel 只选择提供商（未给出完整模型名）时使用的内置默认模型
+	DefaultModel string
 }
 
 func (s ProviderSpec) MatchesModel(model string) bool {
@@ -23,15 +25,26 @@ func (s ProviderSpec) MatchesModel(model string) bool {
 // 1) add ProvidersConfig field in config/schema.go
 // 2) append one ProviderSpec here
 var ProviderSpecs = []ProviderSpec{
-	{Name: "openrouter", Keywords: []string{"openrouter"}, DefaultAPIBase: "https://openrouter.ai/api/v1"},
-	{Name: "deepseek", Keywords: []string{"deepseek"}, DefaultAPIBase: "https://api.deepseek.com/v1"},
-	{Name: "zhipu", Keywords: []string{"zhipu", "glm", "zai"}, DefaultAPIBase: "https://open.bigmodel.cn/api/coding/paas/v4"},
-	{Name: "anthropic", Keywords: []string{"anthropic", "claude"}, DefaultAPIBase: "https://api.anthropic.com"},
-	{Name: "openai", Keywords: []string{"openai", "gpt"}, DefaultAPIBase: "https://api.openai.com/v1"},
-	{Name: "gemini", Keywords: []string{"gemini"}},
-	{Name: "dashscope", Keywords: []string{"dashscope", "qwen"}, DefaultAPIBase: "https://dashscope.aliyuncs.com/compatible-mode/v1"},
+	{Name: "openrouter", Keywords: []string{"openrouter"}, DefaultAPIBase: "https://openrouter.ai/api/v1", DefaultModel: "openrouter/auto"},
+	{Name: "deepseek", Keywords: []string{"deepseek"}, DefaultAPIBase: "https://api.deepseek.com/v1", DefaultModel: "deepseek-chat"},
+	{Name: "zhipu", Keywords: []string{"zhipu", "glm", "zai"}, DefaultAPIBase: "https://open.bigmodel.cn/api/coding/paas/v4", DefaultModel: "glm-4.6"},
+	{Name: "anthropic", Keywords: []string{"anthropic", "claude"}, DefaultAPIBase: "https://api.anthropic.com", DefaultModel: "claude-sonnet-4-5"},
+	{Name: "openai", Keywords: []string{"openai", "gpt"}, DefaultAPIBase: "https://api.openai.com/v1", DefaultModel: "gpt-5.1"},
+	{Name: "gemini", Keywords: []string{"gemini"}, DefaultModel: "gemini-2.5-flash"},
+	{Name: "dashscope", Keywords: []string{"dashscope", "qwen"}, DefaultAPIBase: "https://dashscope.aliyuncs.com/compatible-mode/v1", DefaultModel: "qwen-max"},
 	{Name: "groq", Keywords: []string{"groq"}},
-	{Name: "moonshot", Keywords: []string{"moonshot", "kimi"}, DefaultAPIBase: "https://api.moonshot.ai/v1"},
-	{Name: "minimax", Keywords: []string{"minimax"}, DefaultAPIBase: "https://api.minimax.io/v1"},
+	{Name: "moonshot", Keywords: []string{"moonshot", "kimi"}, DefaultAPIBase: "https://api.moonshot.ai/v1", DefaultModel: "kimi-k2-turbo-preview"},
+	{Name: "minimax", Keywords: []string{"minimax"}, DefaultAPIBase: "https://api.minimax.io/v1", DefaultModel: "MiniMax-M2"},
 	{Name: "vllm", Keywords: []string{"vllm"}},
 }
+
+// FindProviderSpec 按名称查找提供商定义
+func FindProviderSpec(name string) (ProviderSpec, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, spec := range ProviderSpecs {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return ProviderSpec{}, false
+}
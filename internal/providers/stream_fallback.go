@@ -0,0 +1,97 @@
+package providers
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// streamOnlyRecheckInterval 认定网关只支持流式后，经过该时长重新尝试非流式请求
+const streamOnlyRecheckInterval = 10 * time.Minute
+
+// streamRequiredPatterns 网关拒绝非流式请求时的典型报错，只匹配明确针对 stream 参数的说法
+var streamRequiredPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bstream"?\s+(?:must|should)\s+be\s+(?:set\s+to\s+)?true\b`),
+	regexp.MustCompile(`\bonly\s+stream(?:ing)?\s+(?:requests?\s+|mode\s+|responses?\s+)?(?:is\s+|are\s+)?(?:supported|allowed)\b`),
+	regexp.MustCompile(`\bstream(?:ing)?\s+(?:parameter\s+|mode\s+)?(?:is\s+)?required\b`),
+	regexp.MustCompile(`\bnon[- ]?stream(?:ing)?\s+(?:requests?\s+|mode\s+)?(?:is\s+|are\s+)?(?:not\s+supported|not\s+allowed|disabled)\b`),
+}
+
+// isStreamRequiredError 判断非流式请求是否因网关只支持流式而被拒绝：必须是 HTTP 400 且报错明确针对 stream 参数
+// （如 "stream must be true"、"only streaming is supported"）；"upstream ..."、"model does not support streaming" 等不算
+func isStreamRequiredError(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	if !strings.Contains(msg, "status 400:") {
+		return false
+	}
+	for _, pattern := range streamRequiredPatterns {
+		if pattern.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEventStream 判断响应体是否为 SSE（以 data: / event: 行开头）
+func isEventStream(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return bytes.HasPrefix(trimmed, []byte("data:")) || bytes.HasPrefix(trimmed, []byte("event:")) || bytes.HasPrefix(trimmed, []byte(":"))
+}
+
+// responseCollector 把流式回调拼装为完整的 Response
+type responseCollector struct {
+	content   strings.Builder
+	toolCalls []ToolCall
+	byID      map[string]int
+	err       error
+}
+
+func newResponseCollector() *responseCollector {
+	return &responseCollector{byID: make(map[string]int)}
+}
+
+func (c *responseCollector) OnContent(token string) {
+	c.content.WriteString(token)
+}
+
+func (c *responseCollector) OnToolCallStart(id, name string) {
+	if _, ok := c.byID[id]; ok {
+		return
+	}
+	c.byID[id] = len(c.toolCalls)
+	c.toolCalls = append(c.toolCalls, ToolCall{
+		ID:       id,
+		Type:     "function",
+		Function: ToolCallFunction{Name: name},
+	})
+}
+
+func (c *responseCollector) OnToolCallDelta(id, delta string) {
+	if idx, ok := c.byID[id]; ok {
+		c.toolCalls[idx].Function.Arguments += delta
+	}
+}
+
+func (c *responseCollector) OnToolCallEnd(id string) {}
+
+func (c *responseCollector) OnComplete() {}
+
+func (c *responseCollector) OnError(err error) {
+	c.err = err
+}
+
+// response 返回拼装结果，流中途出错时返回该错误
+func (c *responseCollector) response() (*Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &Response{
+		Content:      c.content.String(),
+		ToolCalls:    c.toolCalls,
+		HasToolCalls: len(c.toolCalls) > 0,
+	}, nil
+}
@@ -0,0 +1,249 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// jsonlCompactSlack 过期行（被覆盖的元信息）超过该数量且超过消息数一半时自动压缩
+const jsonlCompactSlack = 32
+
+// jsonlRecord JSONL 会话文件中的一行：元信息（最后一行生效）或一条消息
+type jsonlRecord struct {
+	Meta    *sessionMeta `json:"meta,omitempty"`
+	Message *Message     `json:"message,omitempty"`
+}
+
+// sessionMeta 会话中除消息外的字段
+type sessionMeta struct {
+	Key              string    `json:"key"`
+	Title            string    `json:"title,omitempty"`
+	TitleSource      string    `json:"titleSource,omitempty"`
+	TitleState       string    `json:"titleState,omitempty"`
+	TitleUpdatedAt   time.Time `json:"titleUpdatedAt,omitempty"`
+	LastConsolidated int       `json:"lastConsolidated,omitempty"`
+	ActiveSkills     []string  `json:"activeSkills,omitempty"`
+}
+
+// jsonlState 记录已写入文件的内容，用于判断保存时只需追加哪些行
+type jsonlState struct {
+	messages int
+	lines    int
+	meta     string
+	// broken 文件中存在无法解析的行（如写了一半的末行），下次保存必须整体重写
+	broken bool
+	// last 已写入的最后一条消息，用于发现 Clear 等非追加修改
+	last *Message
+}
+
+func metaOf(session *Session) sessionMeta {
+	return sessionMeta{
+		Key:              session.Key,
+		Title:            session.Title,
+		TitleSource:      session.TitleSource,
+		TitleState:       session.TitleState,
+		TitleUpdatedAt:   session.TitleUpdatedAt,
+		LastConsolidated: session.LastConsolidated,
+		ActiveSkills:     session.ActiveSkills,
+	}
+}
+
+func (meta sessionMeta) applyTo(session *Session) {
+	session.Key = meta.Key
+	session.Title = meta.Title
+	session.TitleSource = meta.TitleSource
+	session.TitleState = meta.TitleState
+	session.TitleUpdatedAt = meta.TitleUpdatedAt
+	session.LastConsolidated = meta.LastConsolidated
+	session.ActiveSkills = meta.ActiveSkills
+}
+
+func encodeRecord(buf *bytes.Buffer, record jsonlRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session record: %w", err)
+	}
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return nil
+}
+
+func sameMessage(a, b *Message) bool {
+	return a != nil && b != nil && a.Role == b.Role && a.Content == b.Content &&
+		len(a.Timeline) == len(b.Timeline) && a.Timestamp.Equal(b.Timestamp)
+}
+
+// readJSONLFile 逐行读取 JSONL 会话文件；无法解析的行（如崩溃时写了一半的末行）会被跳过
+func readJSONLFile(path string) (*Session, *jsonlState, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	session := &Session{Messages: make([]Message, 0)}
+	state := &jsonlState{}
+	hasMeta := false
+
+	reader := bufio.NewReader(file)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			state.lines++
+			var record jsonlRecord
+			if err := json.Unmarshal(line, &record); err == nil {
+				if record.Meta != nil {
+					record.Meta.applyTo(session)
+					hasMeta = true
+				}
+				if record.Message != nil {
+					session.Messages = append(session.Messages, *record.Message)
+				}
+			} else {
+				state.broken = true
+			}
+		}
+		if readErr != nil {
+			// 末行缺少换行符时直接追加会与其拼成一行
+			if len(line) > 0 {
+				state.broken = true
+			}
+			break
+		}
+	}
+	if !hasMeta {
+		return nil, nil, fmt.Errorf("session file %s has no metadata", path)
+	}
+
+	meta, _ := json.Marshal(metaOf(session))
+	state.meta = string(meta)
+	state.messages = len(session.Messages)
+	if n := len(session.Messages); n > 0 {
+		last := session.Messages[n-1]
+		state.last = &last
+	}
+	return session, state, nil
+}
+
+// saveJSONL 只追加新消息与变化的元信息；无法安全追加（首次保存、Clear 后等）时整体压缩重写
+func (m *Manager) saveJSONL(session *Session) error {
+	path := m.sessionFilePath(session.Key, FormatJSONL)
+	state := m.jsonl[session.Key]
+	if state == nil || state.broken || !fileExists(path) || len(session.Messages) < state.messages ||
+		(state.messages > 0 && !sameMessage(&session.Messages[state.messages-1], state.last)) {
+		return m.compactJSONL(session)
+	}
+
+	meta := metaOf(session)
+	metaJSON, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	var buf bytes.Buffer
+	lines := 0
+	if string(metaJSON) != state.meta {
+		if err := encodeRecord(&buf, jsonlRecord{Meta: &meta}); err != nil {
+			return err
+		}
+		lines++
+	}
+	for i := state.messages; i < len(session.Messages); i++ {
+		if err := encodeRecord(&buf, jsonlRecord{Message: &session.Messages[i]}); err != nil {
+			return err
+		}
+		lines++
+	}
+	if lines == 0 {
+		return nil
+	}
+
+	stale := state.lines + lines - len(session.Messages) - 1
+	if stale > jsonlCompactSlack && stale > len(session.Messages)/2 {
+		return m.compactJSONL(session)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open session file: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append session file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to append session file: %w", err)
+	}
+
+	state.lines += lines
+	state.meta = string(metaJSON)
+	state.messages = len(session.Messages)
+	if n := len(session.Messages); n > 0 {
+		last := session.Messages[n-1]
+		state.last = &last
+	}
+	return nil
+}
+
+// compactJSONL 把会话完整写入临时文件后原子替换，只保留一行元信息与全部消息
+func (m *Manager) compactJSONL(session *Session) error {
+	path := m.sessionFilePath(session.Key, FormatJSONL)
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	meta := metaOf(session)
+	var buf bytes.Buffer
+	if err := encodeRecord(&buf, jsonlRecord{Meta: &meta}); err != nil {
+		return err
+	}
+	for i := range session.Messages {
+		if err := encodeRecord(&buf, jsonlRecord{Message: &session.Messages[i]}); err != nil {
+			return err
+		}
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create session file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(buf.Bytes()); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+
+	// 从 JSON 迁移到 JSONL 后移除旧文件
+	_ = os.Remove(m.sessionFilePath(session.Key, FormatJSON))
+
+	metaJSON, _ := json.Marshal(meta)
+	state := &jsonlState{
+		messages: len(session.Messages),
+		lines:    len(session.Messages) + 1,
+		meta:     string(metaJSON),
+	}
+	if n := len(session.Messages); n > 0 {
+		last := session.Messages[n-1]
+		state.last = &last
+	}
+	m.jsonl[session.Key] = state
+	return nil
+}
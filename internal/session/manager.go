@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -38,13 +39,26 @@ type Session struct {
 	TitleUpdatedAt   time.Time `json:"titleUpdatedAt,omitempty"`
 	Messages         []Message `json:"messages"`
 	LastConsolidated int       `json:"lastConsolidated,omitempty"`
+	// ActiveSkills 通过 skills 工具激活的技能，后续每轮未显式选择技能时注入提示词，清空会话时一并清除
+	ActiveSkills []string `json:"activeSkills,omitempty"`
 }
 
+// 会话文件格式
+const (
+	// FormatJSON 每个会话一个 JSON 文件，每次保存整体重写（默认）
+	FormatJSON = "json"
+	// FormatJSONL 每条消息一行，保存时只追加新消息，定期压缩
+	FormatJSONL = "jsonl"
+)
+
 // Manager 会话管理器
 type Manager struct {
 	workspace string
-	sessions  map[string]*Session
-	mu        sync.RWMutex
+	// format 为空时沿用会话已有文件的格式（新会话使用 JSON），非空时保存会迁移到该格式
+	format   string
+	sessions map[string]*Session
+	jsonl    map[string]*jsonlState
+	mu       sync.RWMutex
 }
 
 // NewManager 创建会话管理器
@@ -52,9 +66,29 @@ func NewManager(workspace string) *Manager {
 	return &Manager{
 		workspace: workspace,
 		sessions:  make(map[string]*Session),
+		jsonl:     make(map[string]*jsonlState),
+	}
+}
+
+// NormalizeFormat 规范化会话文件格式，无法识别时返回空字符串
+func NormalizeFormat(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case FormatJSON:
+		return FormatJSON
+	case FormatJSONL:
+		return FormatJSONL
+	default:
+		return ""
 	}
 }
 
+// SetFormat 设置会话持久化格式（json / jsonl）；为空时沿用已有文件的格式
+func (m *Manager) SetFormat(format string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.format = NormalizeFormat(format)
+}
+
 // GetOrCreate 获取或创建会话
 func (m *Manager) GetOrCreate(key string) *Session {
 	m.mu.Lock()
@@ -84,9 +118,31 @@ func (m *Manager) Save(session *Session) error {
 
 	RefreshTitle(session)
 	m.sessions[session.Key] = session
+	if m.saveFormat(session.Key) == FormatJSONL {
+		return m.saveJSONL(session)
+	}
 	return m.saveToFile(session)
 }
 
+// Compact 把 JSONL 会话文件重写为最新状态（去掉过期的元信息行）；JSON 格式的会话无需压缩
+func (m *Manager) Compact(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[key]
+	if !ok {
+		session = m.loadFromFile(key)
+		if session == nil {
+			return fmt.Errorf("session not found: %s", key)
+		}
+		m.sessions[key] = session
+	}
+	if m.saveFormat(key) != FormatJSONL {
+		return nil
+	}
+	return m.compactJSONL(session)
+}
+
 // AddMessage 添加消息到会话
 func (s *Session) AddMessage(role, content string) {
 	s.AddMessageWithTimeline(role, content, nil)
@@ -122,29 +178,83 @@ func (s *Session) GetHistory(maxMessages ...int) []Message {
 func (s *Session) Clear() {
 	s.Messages = make([]Message, 0)
 	s.LastConsolidated = 0
+	s.ActiveSkills = nil
 }
 
 // getSessionFilePath 获取会话文件路径
 func (m *Manager) getSessionFilePath(key string) string {
+	return m.sessionFilePath(key, FormatJSON)
+}
+
+func (m *Manager) sessionFilePath(key, format string) string {
 	// 将 key 中的特殊字符替换为安全字符
 	safeKey := sanitizeFilename(key)
-	return filepath.Join(m.workspace, ".sessions", safeKey+".json")
+	return filepath.Join(m.workspace, ".sessions", safeKey+"."+format)
+}
+
+// saveFormat 决定保存格式：显式配置优先，否则沿用已有文件格式
+func (m *Manager) saveFormat(key string) string {
+	if m.format != "" {
+		return m.format
+	}
+	if _, ok := m.jsonl[key]; ok {
+		return FormatJSONL
+	}
+	if fileExists(m.sessionFilePath(key, FormatJSONL)) && !fileExists(m.sessionFilePath(key, FormatJSON)) {
+		return FormatJSONL
+	}
+	return FormatJSON
 }
 
-// loadFromFile 从文件加载会话
+// loadFromFile 从文件加载会话（两种格式都支持，优先配置的格式）
 func (m *Manager) loadFromFile(key string) *Session {
-	filePath := m.getSessionFilePath(key)
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil
+	formats := []string{FormatJSON, FormatJSONL}
+	if m.format == FormatJSONL {
+		formats = []string{FormatJSONL, FormatJSON}
+	}
+	for _, format := range formats {
+		filePath := m.sessionFilePath(key, format)
+		if format == FormatJSONL {
+			session, state, err := readJSONLFile(filePath)
+			if err != nil {
+				continue
+			}
+			m.jsonl[key] = state
+			return session
+		}
+		session, err := readJSONFile(filePath)
+		if err != nil {
+			continue
+		}
+		return session
 	}
+	return nil
+}
 
+func readJSONFile(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
 	var session Session
 	if err := json.Unmarshal(data, &session); err != nil {
-		return nil
+		return nil, err
+	}
+	return &session, nil
+}
+
+// LoadFile 读取单个会话文件（.json 或 .jsonl），供会话列表、每日汇总等直接扫描目录的场景使用
+func LoadFile(path string) (*Session, error) {
+	if strings.HasSuffix(path, "."+FormatJSONL) {
+		session, _, err := readJSONLFile(path)
+		return session, err
 	}
+	return readJSONFile(path)
+}
 
-	return &session
+// IsSessionFile 判断文件名是否为会话文件
+func IsSessionFile(name string) bool {
+	return strings.HasSuffix(name, "."+FormatJSON) || strings.HasSuffix(name, "."+FormatJSONL)
 }
 
 // Delete 删除会话
@@ -154,11 +264,14 @@ func (m *Manager) Delete(key string) error {
 
 	// Remove from memory
 	delete(m.sessions, key)
+	delete(m.jsonl, key)
 
 	// Remove from disk
-	filePath := m.getSessionFilePath(key)
-	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("failed to delete session file: %w", err)
+	for _, format := range []string{FormatJSON, FormatJSONL} {
+		filePath := m.sessionFilePath(key, format)
+		if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete session file: %w", err)
+		}
 	}
 
 	return nil
@@ -181,9 +294,20 @@ func (m *Manager) saveToFile(session *Session) error {
 		return fmt.Errorf("failed to write session file: %w", err)
 	}
 
+	// 从 JSONL 迁移回 JSON 时移除旧文件，避免两份数据不一致
+	if _, ok := m.jsonl[session.Key]; ok || fileExists(m.sessionFilePath(session.Key, FormatJSONL)) {
+		delete(m.jsonl, session.Key)
+		_ = os.Remove(m.sessionFilePath(session.Key, FormatJSONL))
+	}
+
 	return nil
 }
 
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
 // sanitizeFilename 清理文件名
 func sanitizeFilename(name string) string {
 	// 简单的清理，替换不安全的字符
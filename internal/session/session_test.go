@@ -3,6 +3,7 @@ package session
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -211,3 +212,157 @@ func TestSessionFileCreation(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, files, 1)
 }
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Count(string(data), "\n")
+}
+
+func TestJSONLSaveAndLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetFormat(FormatJSONL)
+
+	session := manager.GetOrCreate("cli:jsonl")
+	session.AddMessage("user", "Hello")
+	session.AddMessageWithTimeline("assistant", "Hi!", []TimelineEntry{{Kind: "text", Text: "Hi!"}})
+	session.LastConsolidated = 1
+	require.NoError(t, manager.Save(session))
+
+	path := filepath.Join(tmpDir, ".sessions", "cli_jsonl.jsonl")
+	assert.FileExists(t, path)
+	assert.NoFileExists(t, filepath.Join(tmpDir, ".sessions", "cli_jsonl.json"))
+	assert.Equal(t, 3, countLines(t, path))
+
+	// 未配置格式的管理器也能识别已有的 JSONL 文件
+	loaded := NewManager(tmpDir).GetOrCreate("cli:jsonl")
+	require.Len(t, loaded.Messages, 2)
+	assert.Equal(t, "Hello", loaded.Messages[0].Content)
+	assert.Equal(t, "Hi!", loaded.Messages[1].Timeline[0].Text)
+	assert.Equal(t, 1, loaded.LastConsolidated)
+	assert.Equal(t, "Hello", loaded.Title)
+}
+
+func TestJSONLSaveAppendsOnlyNewMessages(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetFormat(FormatJSONL)
+
+	session := manager.GetOrCreate("cli:append")
+	session.Title = "Fixed"
+	session.TitleSource = TitleSourceUser
+	session.AddMessage("user", "one")
+	require.NoError(t, manager.Save(session))
+	path := filepath.Join(tmpDir, ".sessions", "cli_append.jsonl")
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	session.AddMessage("assistant", "two")
+	session.AddMessage("user", "three")
+	require.NoError(t, manager.Save(session))
+
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(after), string(before)), "existing lines must not be rewritten")
+	assert.Equal(t, 4, countLines(t, path))
+
+	// 没有变化时不写入
+	require.NoError(t, manager.Save(session))
+	assert.Equal(t, 4, countLines(t, path))
+
+	// Clear 之后无法追加，整体重写
+	session.Clear()
+	session.AddMessage("user", "fresh")
+	require.NoError(t, manager.Save(session))
+	loaded := NewManager(tmpDir).GetOrCreate("cli:append")
+	require.Len(t, loaded.Messages, 1)
+	assert.Equal(t, "fresh", loaded.Messages[0].Content)
+}
+
+func TestJSONLToleratesTruncatedLastLine(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetFormat(FormatJSONL)
+
+	session := manager.GetOrCreate("cli:crash")
+	session.AddMessage("user", "kept")
+	require.NoError(t, manager.Save(session))
+
+	// 模拟写入一半时崩溃
+	path := filepath.Join(tmpDir, ".sessions", "cli_crash.jsonl")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"message":{"role":"assistant","cont`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	manager2 := NewManager(tmpDir)
+	manager2.SetFormat(FormatJSONL)
+	loaded := manager2.GetOrCreate("cli:crash")
+	require.Len(t, loaded.Messages, 1)
+	assert.Equal(t, "kept", loaded.Messages[0].Content)
+
+	// 再次保存时重写文件，去掉残缺行
+	loaded.AddMessage("assistant", "recovered")
+	require.NoError(t, manager2.Save(loaded))
+	reloaded := NewManager(tmpDir).GetOrCreate("cli:crash")
+	require.Len(t, reloaded.Messages, 2)
+	assert.Equal(t, "recovered", reloaded.Messages[1].Content)
+}
+
+func TestJSONLCompact(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	manager.SetFormat(FormatJSONL)
+
+	session := manager.GetOrCreate("cli:compact")
+	session.AddMessage("user", "Hello")
+	require.NoError(t, manager.Save(session))
+	for i := 0; i < 5; i++ {
+		session.LastConsolidated = i + 1
+		session.AddMessage("assistant", "reply")
+		require.NoError(t, manager.Save(session))
+	}
+
+	path := filepath.Join(tmpDir, ".sessions", "cli_compact.jsonl")
+	assert.Equal(t, 12, countLines(t, path))
+
+	require.NoError(t, manager.Compact("cli:compact"))
+	assert.Equal(t, 7, countLines(t, path))
+
+	loaded := NewManager(tmpDir).GetOrCreate("cli:compact")
+	assert.Len(t, loaded.Messages, 6)
+	assert.Equal(t, 5, loaded.LastConsolidated)
+
+	// 压缩后继续追加
+	session.AddMessage("user", "after")
+	require.NoError(t, manager.Save(session))
+	assert.Equal(t, 8, countLines(t, path))
+	assert.Error(t, manager.Compact("cli:missing"))
+}
+
+func TestJSONLMigratesFromJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	manager := NewManager(tmpDir)
+	session := manager.GetOrCreate("cli:migrate")
+	session.AddMessage("user", "legacy")
+	require.NoError(t, manager.Save(session))
+	jsonPath := filepath.Join(tmpDir, ".sessions", "cli_migrate.json")
+	assert.FileExists(t, jsonPath)
+
+	manager2 := NewManager(tmpDir)
+	manager2.SetFormat("JSONL")
+	loaded := manager2.GetOrCreate("cli:migrate")
+	require.Len(t, loaded.Messages, 1)
+	loaded.AddMessage("assistant", "migrated")
+	require.NoError(t, manager2.Save(loaded))
+
+	assert.NoFileExists(t, jsonPath)
+	sess, err := LoadFile(filepath.Join(tmpDir, ".sessions", "cli_migrate.jsonl"))
+	require.NoError(t, err)
+	assert.Len(t, sess.Messages, 2)
+	assert.True(t, IsSessionFile("cli_migrate.jsonl"))
+	assert.False(t, IsSessionFile("cli_migrate.jsonl.tmp"))
+}
@@ -288,6 +288,11 @@ func extractSkillMetadata(content string) (string, string, string) {
 	return title, "", body
 }
 
+// HasRefs reports whether message contains any skill selector (including all/none).
+func HasRefs(message string) bool {
+	return len(extractRefs(message)) > 0
+}
+
 // extractRefs 从用户消息中提取技能引用。
 // 输入: message 用户消息字符串（包含 @skill:<name> 或 $<name> 的引用）。
 // 输出: refs 已标准化为小写的技能名切片；若无引用则返回空切片。
@@ -0,0 +1,34 @@
+// Package version 保存构建时通过 -ldflags 注入的版本信息，例如：
+//
+//	go build -ldflags "-X github.com/Lichas/maxclaw/internal/version.Version=v0.2.0 \
+//	  -X github.com/Lichas/maxclaw/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X github.com/Lichas/maxclaw/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package version
+
+import "fmt"
+
+var (
+	// Version 发布版本号，本地未注入时为 dev
+	Version = "dev"
+	// Commit 构建所用的 git 提交
+	Commit = "unknown"
+	// BuildDate 构建时间（UTC，RFC3339）
+	BuildDate = "unknown"
+)
+
+// Info 版本信息快照，用于 status 输出与 /api/status
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"buildDate"`
+}
+
+// Get 返回当前构建的版本信息
+func Get() Info {
+	return Info{Version: Version, Commit: Commit, BuildDate: BuildDate}
+}
+
+// String 返回单行版本描述，如 "v0.2.0 (commit abc1234, built 2026-01-02T03:04:05Z)"
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s)", i.Version, i.Commit, i.BuildDate)
+}
@@ -0,0 +1,180 @@
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/gorilla/websocket"
+)
+
+// 管理通道支持的命令
+const (
+	AdminCommandPing         = "ping"
+	AdminCommandListSessions = "list_sessions"
+	AdminCommandReloadConfig = "reload_config"
+	AdminCommandCancelTurn   = "cancel_turn"
+	AdminCommandRunCron      = "run_cron"
+)
+
+const adminWriteWait = 10 * time.Second
+
+// adminRequest 管理通道的请求：{"id":"1","command":"run_cron","args":{"jobId":"..."}}
+type adminRequest struct {
+	ID      string          `json:"id,omitempty"`
+	Command string          `json:"command"`
+	Args    json.RawMessage `json:"args,omitempty"`
+}
+
+// adminResponse 管理通道的响应，id 与请求一致
+type adminResponse struct {
+	ID     string      `json:"id,omitempty"`
+	OK     bool        `json:"ok"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type adminRunCronArgs struct {
+	JobID string `json:"jobId"`
+}
+
+type adminCancelTurnArgs struct {
+	SessionKey string `json:"sessionKey"`
+}
+
+// adminUpgrader 管理通道拒绝跨站页面发起的连接
+var adminUpgrader = websocket.Upgrader{CheckOrigin: sameOriginOrNone}
+
+// handleAdminWebSocket 运维用的管理通道；位于 /api/ 下由 Bearer token 鉴权，未配置 gateway.authToken 时拒绝连接
+func (s *Server) handleAdminWebSocket(w http.ResponseWriter, r *http.Request) {
+	if s.authToken() == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"error": "admin channel requires gateway.authToken",
+		})
+		return
+	}
+
+	conn, err := adminUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		if lg := logging.Get(); lg != nil && lg.Web != nil {
+			lg.Web.Printf("admin websocket upgrade failed: %v", err)
+		}
+		return
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(64 * 1024)
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var req adminRequest
+		var resp adminResponse
+		if err := json.Unmarshal(data, &req); err != nil {
+			resp = adminResponse{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else {
+			resp = s.handleAdminCommand(req)
+		}
+
+		if lg := logging.Get(); lg != nil && lg.Web != nil {
+			lg.Web.Printf("admin command=%s id=%s ok=%t err=%q", req.Command, req.ID, resp.OK, resp.Error)
+		}
+
+		_ = conn.SetWriteDeadline(time.Now().Add(adminWriteWait))
+		if err := conn.WriteJSON(resp); err != nil {
+			return
+		}
+	}
+}
+
+// handleAdminCommand 执行单条管理命令，复用 HTTP 接口背后的同一套能力
+func (s *Server) handleAdminCommand(req adminRequest) adminResponse {
+	resp := adminResponse{ID: req.ID}
+	result, err := s.runAdminCommand(strings.TrimSpace(req.Command), req.Args)
+	if err != nil {
+		resp.Error = err.Error()
+		return resp
+	}
+	resp.OK = true
+	resp.Result = result
+	return resp
+}
+
+func (s *Server) runAdminCommand(command string, args json.RawMessage) (interface{}, error) {
+	switch command {
+	case AdminCommandPing:
+		return map[string]interface{}{"pong": true, "version": s.version}, nil
+
+	case AdminCommandListSessions:
+		list, err := listSessions(s.currentConfig().Agents.Defaults.Workspace)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"sessions": list}, nil
+
+	case AdminCommandReloadConfig:
+		// 与 Web UI 保存配置走同一条路径：网关下交给热加载器，否则按运行时加载器（叠加 --profile）重新加载
+		if s.configReloader != nil {
+			if err := s.configReloader(); err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{"reloaded": true}, nil
+		}
+		updated, err := s.loadRuntimeConfig()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.applyUpdatedConfig(updated); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"reloaded": true}, nil
+
+	case AdminCommandCancelTurn:
+		if s.agentLoop == nil {
+			return nil, fmt.Errorf("agent loop not available")
+		}
+		var parsed adminCancelTurnArgs
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &parsed); err != nil {
+				return nil, fmt.Errorf("invalid args: %w", err)
+			}
+		}
+		sessionKey := strings.TrimSpace(parsed.SessionKey)
+		if sessionKey == "" {
+			return nil, fmt.Errorf("sessionKey is required")
+		}
+		return map[string]interface{}{"cancelled": s.agentLoop.CancelSessionTurn(sessionKey)}, nil
+
+	case AdminCommandRunCron:
+		if s.cronService == nil {
+			return nil, fmt.Errorf("cron service not available")
+		}
+		var parsed adminRunCronArgs
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &parsed); err != nil {
+				return nil, fmt.Errorf("invalid args: %w", err)
+			}
+		}
+		jobID := strings.TrimSpace(parsed.JobID)
+		if jobID == "" {
+			return nil, fmt.Errorf("jobId is required")
+		}
+		if err := s.cronService.RunJob(jobID); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"triggered": jobID}, nil
+
+	case "":
+		return nil, fmt.Errorf("command is required")
+
+	default:
+		return nil, fmt.Errorf("unknown command: %s", command)
+	}
+}
@@ -0,0 +1,189 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/agent"
+	"github.com/Lichas/maxclaw/internal/bus"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/cron"
+	"github.com/Lichas/maxclaw/internal/session"
+	"github.com/Lichas/maxclaw/pkg/tools"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAdminTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/admin/ws", s.handleAdminWebSocket)
+	ts := httptest.NewServer(requireBearerToken(s.authToken, mux))
+	t.Cleanup(ts.Close)
+	return "ws" + strings.TrimPrefix(ts.URL, "http") + "/api/admin/ws"
+}
+
+func dialAdmin(t *testing.T, url, token string) *websocket.Conn {
+	t.Helper()
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	conn, _, err := websocket.DefaultDialer.Dial(url, header)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func adminCall(t *testing.T, conn *websocket.Conn, req map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(req))
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var resp map[string]interface{}
+	require.NoError(t, conn.ReadJSON(&resp))
+	return resp
+}
+
+func TestAdminWebSocketRequiresToken(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Gateway.AuthToken = "s3cret"
+	url := newAdminTestServer(t, &Server{cfg: cfg})
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer nope")
+	_, resp, err = websocket.DefaultDialer.Dial(url, header)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAdminWebSocketDisabledWithoutAuthToken(t *testing.T) {
+	url := newAdminTestServer(t, &Server{cfg: config.DefaultConfig()})
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAdminWebSocketListSessions(t *testing.T) {
+	workspace := t.TempDir()
+	sess := session.NewManager(workspace).GetOrCreate("telegram:42")
+	sess.AddMessage("user", "hello")
+	require.NoError(t, session.NewManager(workspace).Save(sess))
+
+	cfg := config.DefaultConfig()
+	cfg.Gateway.AuthToken = "s3cret"
+	cfg.Agents.Defaults.Workspace = workspace
+	conn := dialAdmin(t, newAdminTestServer(t, &Server{cfg: cfg}), "s3cret")
+
+	resp := adminCall(t, conn, map[string]interface{}{"id": "1", "command": "list_sessions"})
+	assert.Equal(t, "1", resp["id"])
+	assert.Equal(t, true, resp["ok"], resp["error"])
+	result := resp["result"].(map[string]interface{})
+	sessions := result["sessions"].([]interface{})
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "telegram:42", sessions[0].(map[string]interface{})["key"])
+}
+
+func TestAdminWebSocketRunCron(t *testing.T) {
+	svc := cron.NewService(filepath.Join(t.TempDir(), "jobs.json"))
+	ran := make(chan string, 1)
+	svc.SetJobHandler(func(job *cron.Job) (string, error) {
+		ran <- job.ID
+		return "done", nil
+	})
+	job, err := svc.AddJob("daily", cron.Schedule{Type: cron.ScheduleTypeCron, Expr: "0 9 * * *"}, cron.Payload{Message: "hi"})
+	require.NoError(t, err)
+
+	cfg := config.DefaultConfig()
+	cfg.Gateway.AuthToken = "s3cret"
+	conn := dialAdmin(t, newAdminTestServer(t, &Server{cfg: cfg, cronService: svc}), "s3cret")
+
+	resp := adminCall(t, conn, map[string]interface{}{"id": "a", "command": "run_cron", "args": map[string]string{"jobId": job.ID}})
+	assert.Equal(t, true, resp["ok"], resp["error"])
+	select {
+	case id := <-ran:
+		assert.Equal(t, job.ID, id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("cron job was not triggered")
+	}
+
+	resp = adminCall(t, conn, map[string]interface{}{"id": "b", "command": "run_cron", "args": map[string]string{"jobId": "missing"}})
+	assert.Equal(t, false, resp["ok"])
+	assert.Equal(t, "job not found", resp["error"])
+
+	resp = adminCall(t, conn, map[string]interface{}{"id": "c", "command": "explode"})
+	assert.Equal(t, false, resp["ok"])
+	assert.Contains(t, resp["error"], "unknown command")
+}
+
+func TestAdminWebSocketRejectsCrossOrigin(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Gateway.AuthToken = "s3cret"
+	url := newAdminTestServer(t, &Server{cfg: cfg})
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer s3cret")
+	header.Set("Origin", "https://evil.example")
+	_, resp, err := websocket.DefaultDialer.Dial(url, header)
+	require.Error(t, err)
+	require.NotNil(t, resp)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestAdminWebSocketCancelTurnRequiresSessionKey(t *testing.T) {
+	provider := &blockingProvider{release: make(chan struct{})}
+	defer close(provider.release)
+	loop := agent.NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		t.TempDir(),
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	defer loop.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Gateway.AuthToken = "s3cret"
+	conn := dialAdmin(t, newAdminTestServer(t, &Server{cfg: cfg, agentLoop: loop}), "s3cret")
+
+	resp := adminCall(t, conn, map[string]interface{}{"id": "1", "command": "cancel_turn"})
+	assert.Equal(t, false, resp["ok"])
+	assert.Equal(t, "sessionKey is required", resp["error"])
+
+	resp = adminCall(t, conn, map[string]interface{}{"id": "2", "command": "cancel_turn", "args": map[string]string{"sessionKey": "telegram:42"}})
+	assert.Equal(t, true, resp["ok"], resp["error"])
+	assert.Equal(t, map[string]interface{}{"cancelled": false}, resp["result"])
+}
+
+func TestAdminWebSocketReloadConfigUsesRuntimeLoader(t *testing.T) {
+	t.Setenv("MAXCLAW_HOME", t.TempDir())
+	cfg := config.DefaultConfig()
+	cfg.Gateway.AuthToken = "s3cret"
+	s := &Server{cfg: cfg}
+
+	next := config.DefaultConfig()
+	next.Gateway.AuthToken = "s3cret"
+	next.Agents.Defaults.Workspace = t.TempDir()
+	s.SetConfigLoader(func() (*config.Config, error) { return next, nil })
+	conn := dialAdmin(t, newAdminTestServer(t, s), "s3cret")
+
+	resp := adminCall(t, conn, map[string]interface{}{"id": "1", "command": "reload_config"})
+	assert.Equal(t, true, resp["ok"], resp["error"])
+	assert.Same(t, next, s.currentConfig())
+}
@@ -0,0 +1,64 @@
+package webui
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// publicAPIPaths 不需要鉴权的接口（容器编排的健康检查无法携带 token）
+var publicAPIPaths = map[string]bool{
+	"/api/health": true,
+	"/api/ready":  true,
+}
+
+// requireBearerToken 为 /api/* 请求校验 Authorization: Bearer <token>；token 每次请求时读取（配置热加载后立即生效），
+// 为空时不启用鉴权，静态资源与 publicAPIPaths 始终公开
+func requireBearerToken(tokenFn func() string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimSpace(tokenFn())
+		if token == "" || (r.URL.Path != "/api" && !strings.HasPrefix(r.URL.Path, "/api/")) || publicAPIPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !tokenMatches(r, token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="maxclaw"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"unauthorized"}` + "\n"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tokenMatches 请求是否携带与 token 一致的 Bearer token
+func tokenMatches(r *http.Request, token string) bool {
+	provided, ok := bearerToken(r.Header.Get("Authorization"))
+	return ok && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// sameOriginOrNone 允许不带 Origin 的客户端（CLI、脚本），浏览器发起的连接必须与服务同源
+func sameOriginOrNone(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+func bearerToken(header string) (string, bool) {
+	scheme, value, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	value = strings.TrimSpace(value)
+	return value, value != ""
+}
@@ -0,0 +1,76 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireBearerToken(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := requireBearerToken(func() string { return "s3cret" }, next)
+
+	cases := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{name: "missing token", path: "/api/config", want: http.StatusUnauthorized},
+		{name: "wrong token", path: "/api/config", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "wrong scheme", path: "/api/config", header: "Basic s3cret", want: http.StatusUnauthorized},
+		{name: "correct token", path: "/api/config", header: "Bearer s3cret", want: http.StatusOK},
+		{name: "static files stay public", path: "/index.html", want: http.StatusOK},
+		{name: "api-like prefix is not api", path: "/apidocs", want: http.StatusOK},
+		{name: "health stays public", path: "/api/health", want: http.StatusOK},
+		{name: "ready stays public", path: "/api/ready", want: http.StatusOK},
+		{name: "health subpath needs token", path: "/api/health/x", want: http.StatusUnauthorized},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tc.want, rec.Code)
+		})
+	}
+}
+
+func TestRequireBearerTokenDisabledWhenEmpty(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	requireBearerToken(func() string { return "  " }, next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequireBearerTokenFollowsTokenChanges(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	s := &Server{cfg: config.DefaultConfig()}
+	handler := requireBearerToken(s.authToken, next)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// 热加载设置 token 后立即要求鉴权
+	updated := config.DefaultConfig()
+	updated.Gateway.AuthToken = "s3cret"
+	s.SetConfig(updated)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
@@ -0,0 +1,59 @@
+package webui
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+)
+
+// uiFallbackHTML 找不到 webui/dist 时返回的内置页面：说明 API 仍可用并给出构建方法
+const uiFallbackHTML = `<!doctype html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>maxclaw</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 40rem; margin: 4rem auto; padding: 0 1rem; color: #222; line-height: 1.5; }
+code, pre { background: #f4f4f4; border-radius: 4px; }
+code { padding: 0.1rem 0.3rem; }
+pre { padding: 0.75rem 1rem; overflow-x: auto; }
+</style>
+</head>
+<body>
+<h1>maxclaw is running</h1>
+<p>The web UI has not been built, so only the HTTP API is available.
+Check <a href="/api/health">/api/health</a> and <a href="/api/status">/api/status</a>.</p>
+<h2>Build the web UI</h2>
+<pre>make webui-install
+make webui-build</pre>
+<p>Then restart the gateway. The UI is loaded from <code>webui/dist</code> next to the binary or in the working directory.</p>
+</body>
+</html>
+`
+
+var uiMissingOnce sync.Once
+
+// warnUIMissing 启动时提示一次 Web UI 未构建
+func warnUIMissing() {
+	uiMissingOnce.Do(func() {
+		if lg := logging.Get(); lg != nil && lg.Web != nil {
+			lg.Web.Printf("web UI not built (webui/dist not found); serving fallback page, API is still available. Run `make webui-build` to build it")
+		}
+	})
+}
+
+// uiFallbackHandler 非 /api/ 路径返回内置说明页，/api/ 下未注册的路径仍返回 404
+func uiFallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write([]byte(uiFallbackHTML))
+	})
+}
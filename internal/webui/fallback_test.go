@@ -0,0 +1,58 @@
+package webui
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerServesFallbackPageWithoutUIDir(t *testing.T) {
+	s := &Server{cfg: config.DefaultConfig(), startedAt: time.Now(), version: "test"}
+	ts := httptest.NewServer(s.handler())
+	t.Cleanup(ts.Close)
+
+	for _, path := range []string{"/", "/sessions/abc"} {
+		resp, err := http.Get(ts.URL + path)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode, path)
+		assert.Contains(t, resp.Header.Get("Content-Type"), "text/html")
+		assert.Contains(t, string(body), "make webui-build")
+		assert.Contains(t, string(body), `href="/api/health"`)
+	}
+
+	resp, err := http.Get(ts.URL + "/api/health")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var health map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+	assert.Equal(t, "ok", health["status"])
+
+	missing, err := http.Get(ts.URL + "/api/does-not-exist")
+	require.NoError(t, err)
+	_ = missing.Body.Close()
+	assert.Equal(t, http.StatusNotFound, missing.StatusCode)
+}
+
+func TestWarnUIMissingLogsOnce(t *testing.T) {
+	lg, err := logging.Init(t.TempDir())
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	lg.Web.SetOutput(&buf)
+
+	warnUIMissing()
+	warnUIMissing()
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("web UI not built")))
+}
@@ -0,0 +1,140 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/channels"
+	"github.com/Lichas/maxclaw/internal/providers"
+)
+
+// readinessState 网关启动及热加载时上报的频道就绪信息
+type readinessState struct {
+	mu              sync.Mutex
+	channelsStarted bool
+	channelErrors   map[string]string
+}
+
+// SetVersion 覆盖 /api/health 返回的版本号（默认为构建注入的 version.Version）
+func (s *Server) SetVersion(version string) {
+	s.version = version
+}
+
+// MarkChannelsStarted 记录频道启动完成；errs 为启动失败的频道及原因
+func (s *Server) MarkChannelsStarted(errs map[string]error) {
+	s.readiness.mu.Lock()
+	defer s.readiness.mu.Unlock()
+	s.readiness.channelsStarted = true
+	s.readiness.channelErrors = make(map[string]string, len(errs))
+	for name, err := range errs {
+		if err != nil {
+			s.readiness.channelErrors[name] = err.Error()
+		}
+	}
+}
+
+// UpdateChannelStatus 按热加载的调整结果更新频道就绪信息：被启动、重启或停止的频道先清除旧的失败记录，
+// 再记录本次启动失败的频道
+func (s *Server) UpdateChannelStatus(result channels.ReconcileResult) {
+	s.readiness.mu.Lock()
+	defer s.readiness.mu.Unlock()
+	if s.readiness.channelErrors == nil {
+		s.readiness.channelErrors = make(map[string]string)
+	}
+	for _, names := range [][]string{result.Started, result.Restarted, result.Stopped} {
+		for _, name := range names {
+			delete(s.readiness.channelErrors, name)
+		}
+	}
+	for name, err := range result.StartErrors {
+		if err != nil {
+			s.readiness.channelErrors[name] = err.Error()
+		}
+	}
+}
+
+// handleHealth 存活检查：不读取配置与会话，只要进程能响应就返回 200
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	uptime := 0.0
+	if !s.startedAt.IsZero() {
+		uptime = time.Since(s.startedAt).Seconds()
+	}
+	writeJSON(w, map[string]interface{}{
+		"status":        "ok",
+		"version":       s.version,
+		"uptimeSeconds": int64(uptime),
+	})
+}
+
+// handleReady 就绪检查：provider 已配置且频道已全部启动时返回 200，否则返回 503。
+// 该接口免鉴权，启用 authToken 时未携带 token 的请求只返回 ready，模型名与频道错误不外泄
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	model := ""
+	providerConfigured := false
+	if cfg := s.currentConfig(); cfg != nil {
+		model = cfg.ResolveModel("")
+		providerConfigured = model != "" && (providers.IsMockModel(model) || cfg.GetAPIKey(model) != "")
+	}
+
+	enabled := []string{}
+	if s.channelRegistry != nil {
+		for _, ch := range s.channelRegistry.GetEnabled() {
+			enabled = append(enabled, ch.Name())
+		}
+		sort.Strings(enabled)
+	}
+
+	s.readiness.mu.Lock()
+	channelsStarted := s.readiness.channelsStarted
+	failed := make(map[string]string, len(s.readiness.channelErrors))
+	for name, msg := range s.readiness.channelErrors {
+		failed[name] = msg
+	}
+	s.readiness.mu.Unlock()
+	// 已从注册表移除的频道不再影响就绪状态
+	if s.channelRegistry != nil {
+		for name := range failed {
+			if _, ok := s.channelRegistry.Get(name); !ok {
+				delete(failed, name)
+			}
+		}
+	}
+
+	ready := providerConfigured && channelsStarted && len(failed) == 0
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if token := s.authToken(); token != "" && !tokenMatches(r, token) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"ready": ready})
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"ready": ready,
+		"provider": map[string]interface{}{
+			"configured": providerConfigured,
+			"model":      model,
+		},
+		"channels": map[string]interface{}{
+			"started": channelsStarted,
+			"enabled": enabled,
+			"failed":  failed,
+		},
+	})
+}
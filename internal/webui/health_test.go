@@ -0,0 +1,175 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Lichas/maxclaw/internal/channels"
+	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleHealth(t *testing.T) {
+	s := &Server{startedAt: time.Now().Add(-90 * time.Second), version: "1.2.3"}
+
+	rec := httptest.NewRecorder()
+	s.handleHealth(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "ok", body["status"])
+	assert.Equal(t, "1.2.3", body["version"])
+	assert.GreaterOrEqual(t, body["uptimeSeconds"], float64(90))
+
+	rec = httptest.NewRecorder()
+	s.handleHealth(rec, httptest.NewRequest(http.MethodPost, "/api/health", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+type readyResponse struct {
+	Ready    bool `json:"ready"`
+	Provider struct {
+		Configured bool   `json:"configured"`
+		Model      string `json:"model"`
+	} `json:"provider"`
+	Channels struct {
+		Started bool              `json:"started"`
+		Enabled []string          `json:"enabled"`
+		Failed  map[string]string `json:"failed"`
+	} `json:"channels"`
+}
+
+func getReady(t *testing.T, s *Server) (int, readyResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.handleReady(rec, httptest.NewRequest(http.MethodGet, "/api/ready", nil))
+	var body readyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec.Code, body
+}
+
+func TestHandleReady(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Model = "openai/gpt-4o"
+	cfg.Channels.WebSocket.Enabled = true
+	s := &Server{cfg: cfg, channelRegistry: channels.BuildFromConfig(cfg, nil)}
+
+	// 没有 API Key、频道未启动
+	code, body := getReady(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.False(t, body.Ready)
+	assert.False(t, body.Provider.Configured)
+	assert.Equal(t, "openai/gpt-4o", body.Provider.Model)
+	assert.False(t, body.Channels.Started)
+	assert.Equal(t, []string{"websocket"}, body.Channels.Enabled)
+
+	cfg.Providers.OpenAI.APIKey = "sk-test"
+	s.MarkChannelsStarted(map[string]error{"websocket": errors.New("address in use")})
+	code, body = getReady(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.True(t, body.Provider.Configured)
+	assert.True(t, body.Channels.Started)
+	assert.Equal(t, map[string]string{"websocket": "address in use"}, body.Channels.Failed)
+
+	s.MarkChannelsStarted(nil)
+	code, body = getReady(t, s)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, body.Ready)
+	assert.Empty(t, body.Channels.Failed)
+}
+
+type statusFakeChannel struct {
+	name   string
+	status interface{}
+}
+
+func (c *statusFakeChannel) Name() string                                  { return c.name }
+func (c *statusFakeChannel) Start(ctx context.Context) error               { return nil }
+func (c *statusFakeChannel) Stop() error                                   { return nil }
+func (c *statusFakeChannel) SendMessage(chatID string, text string) error  { return nil }
+func (c *statusFakeChannel) SetMessageHandler(func(msg *channels.Message)) {}
+func (c *statusFakeChannel) IsEnabled() bool                               { return true }
+
+type statusProviderFakeChannel struct{ statusFakeChannel }
+
+func (c *statusProviderFakeChannel) ChannelStatus() interface{} { return c.status }
+
+func TestHandleStatusIncludesStatusProviders(t *testing.T) {
+	registry := channels.NewRegistry()
+	registry.Register(&statusProviderFakeChannel{statusFakeChannel{name: "whatsapp", status: map[string]interface{}{"connected": true}}})
+	registry.Register(&statusFakeChannel{name: "discord"})
+	s := &Server{cfg: config.DefaultConfig(), channelRegistry: registry}
+
+	rec := httptest.NewRecorder()
+	s.handleStatus(rec, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, map[string]interface{}{"connected": true}, body["whatsapp"])
+	assert.NotContains(t, body, "discord")
+	assert.ElementsMatch(t, []interface{}{"whatsapp", "discord"}, body["channels"])
+
+	rec = httptest.NewRecorder()
+	s.handleWhatsAppStatus(rec, httptest.NewRequest(http.MethodGet, "/api/whatsapp/status", nil))
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, true, body["connected"])
+}
+
+func TestHandleReadyFollowsChannelReloads(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Model = "openai/gpt-4o"
+	cfg.Providers.OpenAI.APIKey = "sk-test"
+	registry := channels.NewRegistry()
+	registry.Register(&statusFakeChannel{name: "discord"})
+	registry.Register(&statusFakeChannel{name: "slack"})
+	s := &Server{cfg: cfg, channelRegistry: registry}
+
+	s.MarkChannelsStarted(map[string]error{"discord": errors.New("invalid token")})
+	code, _ := getReady(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+
+	// 热加载重启了 discord 并成功，slack 重启失败
+	s.UpdateChannelStatus(channels.ReconcileResult{
+		Restarted:   []string{"discord", "slack"},
+		StartErrors: map[string]error{"slack": errors.New("rate limited")},
+	})
+	code, body := getReady(t, s)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, map[string]string{"slack": "rate limited"}, body.Channels.Failed)
+
+	// 失败的频道被停用并移出注册表后恢复就绪
+	registry.Unregister("slack")
+	code, body = getReady(t, s)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, body.Ready)
+}
+
+func TestHandleReadyHidesDetailsWithoutToken(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Model = "openai/gpt-4o"
+	cfg.Gateway.AuthToken = "secret"
+	s := &Server{cfg: cfg, channelRegistry: channels.NewRegistry()}
+	s.MarkChannelsStarted(map[string]error{"discord": errors.New("invalid token abc")})
+
+	rec := httptest.NewRecorder()
+	s.handleReady(rec, httptest.NewRequest(http.MethodGet, "/api/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"ready":false}`, rec.Body.String())
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ready", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	s.handleReady(rec, req)
+	var body readyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "openai/gpt-4o", body.Provider.Model)
+}
@@ -16,6 +16,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -28,6 +29,7 @@ import (
 	"github.com/Lichas/maxclaw/internal/providers"
 	"github.com/Lichas/maxclaw/internal/session"
 	workspaceSkills "github.com/Lichas/maxclaw/internal/skills"
+	"github.com/Lichas/maxclaw/internal/version"
 	"github.com/Lichas/maxclaw/pkg/tools"
 	"github.com/anthropics/anthropic-sdk-go"
 	anthropicoption "github.com/anthropics/anthropic-sdk-go/option"
@@ -37,7 +39,9 @@ import (
 )
 
 type Server struct {
+	// cfg 当前生效的配置，经 currentConfig / SetConfig 在 cfgMu 保护下读写
 	cfg               *config.Config
+	cfgMu             sync.RWMutex
 	agentLoop         *agent.AgentLoop
 	cronService       *cron.Service
 	channelRegistry   *channels.Registry
@@ -46,6 +50,13 @@ type Server struct {
 	skillsStateMgr    *workspaceSkills.StateManager
 	notificationStore *NotificationStore
 	wsHub             *WebSocketHub
+	// configReloader 非空时保存配置后由网关统一热加载（provider 与频道）
+	configReloader func() error
+	// configLoader 加载运行时生效的配置（网关会叠加 --profile），为空时使用 config.LoadConfig
+	configLoader func() (*config.Config, error)
+	startedAt    time.Time
+	version      string
+	readiness    readinessState
 }
 
 type channelSenderStat struct {
@@ -92,6 +103,8 @@ func NewServer(cfg *config.Config, agentLoop *agent.AgentLoop, cronService *cron
 		skillsStateMgr:    workspaceSkills.NewStateManager(filepath.Join(cfg.Agents.Defaults.Workspace, ".skills_state.json")),
 		notificationStore: NewNotificationStore(),
 		wsHub:             NewWebSocketHub(),
+		startedAt:         time.Now(),
+		version:           version.Version,
 	}
 
 	// Start WebSocket hub
@@ -102,8 +115,33 @@ func NewServer(cfg *config.Config, agentLoop *agent.AgentLoop, cronService *cron
 
 func (s *Server) Start(ctx context.Context, host string, port int) error {
 	addr := fmt.Sprintf("%s:%d", host, port)
+	if s.uiDir == "" {
+		warnUIMissing()
+	}
+
+	s.server = &http.Server{
+		Addr:              addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = s.Stop(context.Background())
+	}()
+
+	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// handler 注册全部路由并套上鉴权中间件
+func (s *Server) handler() http.Handler {
 	mux := http.NewServeMux()
 
+	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/api/ready", s.handleReady)
 	mux.HandleFunc("/api/status", s.handleStatus)
 	mux.HandleFunc("/api/sessions", s.handleSessions)
 	mux.HandleFunc("/api/sessions/", s.handleSessionByKey)
@@ -112,6 +150,7 @@ func (s *Server) Start(ctx context.Context, host string, port int) error {
 	mux.HandleFunc("/api/skills/", s.handleSkillsPath)
 	mux.HandleFunc("/api/skills/install", s.handleSkillsInstall)
 	mux.HandleFunc("/api/message", s.handleMessage)
+	mux.HandleFunc("/api/message/stream", s.handleMessageStreamEndpoint)
 	mux.HandleFunc("/api/browser/action", s.handleBrowserAction)
 	mux.HandleFunc("/api/config", s.handleConfig)
 	mux.HandleFunc("/api/workspace-file/", s.handleWorkspaceFile)
@@ -128,27 +167,15 @@ func (s *Server) Start(ctx context.Context, host string, port int) error {
 	mux.HandleFunc("/api/channels/senders", s.handleChannelSenders)
 	mux.HandleFunc("/api/channels/", s.handleTestChannel)
 	mux.HandleFunc("/api/channels/whatsapp/status", s.handleWhatsAppStatus)
+	mux.HandleFunc("/api/spawns", s.handleSpawns)
 	mux.HandleFunc("/api/mcp", s.handleMCP)
 	mux.HandleFunc("/api/mcp/", s.handleMCPByName)
+	mux.HandleFunc("/api/admin/ws", s.handleAdminWebSocket)
 	mux.HandleFunc("/ws", s.handleWebSocket)
 
 	mux.Handle("/", spaHandler(s.uiDir))
 
-	s.server = &http.Server{
-		Addr:              addr,
-		Handler:           mux,
-		ReadHeaderTimeout: 10 * time.Second,
-	}
-
-	go func() {
-		<-ctx.Done()
-		_ = s.Stop(context.Background())
-	}()
-
-	if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return err
-	}
-	return nil
+	return requireBearerToken(s.authToken, mux)
 }
 
 func (s *Server) handleChannelSenders(w http.ResponseWriter, r *http.Request) {
@@ -195,11 +222,14 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	cfg := s.currentConfig()
 	status := map[string]interface{}{
-		"workspace":           s.cfg.Agents.Defaults.Workspace,
-		"model":               s.cfg.Agents.Defaults.Model,
-		"executionMode":       s.cfg.Agents.Defaults.ExecutionMode,
-		"restrictToWorkspace": s.cfg.Tools.RestrictToWorkspace,
+		"workspace":           cfg.Agents.Defaults.Workspace,
+		"model":               cfg.Agents.Defaults.Model,
+		"executionMode":       cfg.Agents.Defaults.ExecutionMode,
+		"restrictToWorkspace": cfg.Tools.RestrictToWorkspace,
+		"providers":           cfg.ConfiguredProviders(),
+		"build":               version.Get(),
 	}
 
 	if s.channelRegistry != nil {
@@ -209,15 +239,10 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		}
 		status["channels"] = enabled
 
-		if wa, ok := s.channelRegistry.Get("whatsapp"); ok {
-			if waChannel, ok := wa.(*channels.WhatsAppChannel); ok {
-				status["whatsapp"] = waChannel.Status()
-			}
-		}
-
-		if tg, ok := s.channelRegistry.Get("telegram"); ok {
-			if tgChannel, ok := tg.(*channels.TelegramChannel); ok {
-				status["telegram"] = tgChannel.Status()
+		// 能报告连接状态的频道（如 whatsapp、telegram）按频道名附加详细状态
+		for _, ch := range s.channelRegistry.GetAll() {
+			if provider, ok := ch.(channels.StatusProvider); ok {
+				status[ch.Name()] = provider.ChannelStatus()
 			}
 		}
 	}
@@ -226,6 +251,10 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		status["cron"] = s.cronService.Status()
 	}
 
+	if s.agentLoop != nil {
+		status["toolUsage"] = s.agentLoop.ToolCallStats()
+	}
+
 	writeJSON(w, status)
 }
 
@@ -235,7 +264,7 @@ func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	list, err := listSessions(s.cfg.Agents.Defaults.Workspace)
+	list, err := listSessions(s.currentConfig().Agents.Defaults.Workspace)
 	if err != nil {
 		writeError(w, err)
 		return
@@ -264,7 +293,7 @@ func (s *Server) handleSessionGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mgr := session.NewManager(s.cfg.Agents.Defaults.Workspace)
+	mgr := session.NewManager(s.currentConfig().Agents.Defaults.Workspace)
 	sess := mgr.GetOrCreate(key)
 	writeJSON(w, sess)
 }
@@ -294,7 +323,7 @@ func (s *Server) handleSessionPost(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		mgr := session.NewManager(s.cfg.Agents.Defaults.Workspace)
+		mgr := session.NewManager(s.currentConfig().Agents.Defaults.Workspace)
 		sess := mgr.GetOrCreate(key)
 		sess.Title = req.Title
 		sess.TitleSource = session.TitleSourceUser
@@ -324,7 +353,7 @@ func (s *Server) handleSessionDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	mgr := session.NewManager(s.cfg.Agents.Defaults.Workspace)
+	mgr := session.NewManager(s.currentConfig().Agents.Defaults.Workspace)
 	if err := mgr.Delete(key); err != nil {
 		writeError(w, err)
 		return
@@ -342,28 +371,12 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var payload messagePayload
-
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+	payload, err := decodeMessagePayload(r)
+	if err != nil {
 		writeError(w, err)
 		return
 	}
 
-	if payload.Content == "" {
-		writeError(w, fmt.Errorf("content is required"))
-		return
-	}
-
-	if payload.SessionKey == "" {
-		payload.SessionKey = "webui:default"
-	}
-	if payload.Channel == "" {
-		payload.Channel = "webui"
-	}
-	if payload.ChatID == "" {
-		payload.ChatID = payload.SessionKey
-	}
-
 	if wantsStreamResponse(r, payload) {
 		s.handleMessageStream(w, r, payload)
 		return
@@ -397,6 +410,45 @@ func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// handleMessageStreamEndpoint 始终以 SSE 返回的消息接口（/api/message/stream）
+func (s *Server) handleMessageStreamEndpoint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	payload, err := decodeMessagePayload(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	s.handleMessageStream(w, r, payload)
+}
+
+// decodeMessagePayload 解析消息请求并填充默认会话/频道
+func decodeMessagePayload(r *http.Request) (messagePayload, error) {
+	var payload messagePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return payload, err
+	}
+
+	if payload.Content == "" {
+		return payload, fmt.Errorf("content is required")
+	}
+
+	if payload.SessionKey == "" {
+		payload.SessionKey = "webui:default"
+	}
+	if payload.Channel == "" {
+		payload.Channel = "webui"
+	}
+	if payload.ChatID == "" {
+		payload.ChatID = payload.SessionKey
+	}
+	return payload, nil
+}
+
 func (s *Server) handleBrowserAction(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -563,7 +615,7 @@ func (s *Server) enrichContentWithAttachments(content string, attachments []mess
 
 	items := make([]attachmentInfo, 0, len(attachments))
 	seen := make(map[string]struct{}, len(attachments))
-	uploadsDir := filepath.Join(s.cfg.Agents.Defaults.Workspace, ".uploads")
+	uploadsDir := filepath.Join(s.currentConfig().Agents.Defaults.Workspace, ".uploads")
 
 	for _, att := range attachments {
 		p := strings.TrimSpace(att.Path)
@@ -576,7 +628,7 @@ func (s *Server) enrichContentWithAttachments(content string, attachments []mess
 			continue
 		}
 		if !filepath.IsAbs(p) {
-			p = filepath.Join(s.cfg.Agents.Defaults.Workspace, p)
+			p = filepath.Join(s.currentConfig().Agents.Defaults.Workspace, p)
 		}
 		p = filepath.Clean(p)
 		if _, ok := seen[p]; ok {
@@ -616,7 +668,7 @@ func (s *Server) extractImageAttachment(attachments []messageAttachment) *bus.Me
 			continue
 		}
 		if !filepath.IsAbs(path) {
-			path = filepath.Join(s.cfg.Agents.Defaults.Workspace, path)
+			path = filepath.Join(s.currentConfig().Agents.Defaults.Workspace, path)
 		}
 		path = filepath.Clean(path)
 
@@ -650,9 +702,10 @@ func (s *Server) handleSkills(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	defaults := s.currentConfig().Agents.Defaults
 	entries, err := workspaceSkills.DiscoverAll(
-		filepath.Join(s.cfg.Agents.Defaults.Workspace, "skills"),
-		s.cfg.Agents.Defaults.EnableGlobalSkills,
+		filepath.Join(defaults.Workspace, "skills"),
+		defaults.EnableGlobalSkills,
 	)
 	if err != nil {
 		writeError(w, err)
@@ -757,7 +810,7 @@ func (s *Server) handleSkillsInstall(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	skillsDir := filepath.Join(s.cfg.Agents.Defaults.Workspace, "skills")
+	skillsDir := filepath.Join(s.currentConfig().Agents.Defaults.Workspace, "skills")
 	if err := os.MkdirAll(skillsDir, 0755); err != nil {
 		writeError(w, fmt.Errorf("failed to create skills directory: %w", err))
 		return
@@ -794,7 +847,7 @@ func (s *Server) installSkillFromClawHub(source string) (map[string]interface{},
 		return nil, err
 	}
 
-	installer := workspaceSkills.NewInstaller(s.cfg.Agents.Defaults.Workspace)
+	installer := workspaceSkills.NewInstaller(s.currentConfig().Agents.Defaults.Workspace)
 	result, err := installer.InstallFromClawHub(clawHubSource)
 	if err != nil {
 		return nil, err
@@ -1153,12 +1206,13 @@ type configUpdateRequest struct {
 func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
+		// 配置校验失败时仍返回内容，让用户可以在界面上修复
 		cfg, err := config.LoadConfig()
-		if err != nil {
+		if err != nil && !config.IsValidationError(err) {
 			writeError(w, err)
 			return
 		}
-		writeJSON(w, cfg)
+		writeJSON(w, cfg.Redacted())
 	case http.MethodPut:
 		var req configUpdateRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1168,7 +1222,12 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 
 		// Load existing config
 		cfg, err := config.LoadConfig()
-		if err != nil {
+		if err != nil && !config.IsValidationError(err) {
+			writeError(w, err)
+			return
+		}
+		prev, err := config.LoadConfig()
+		if err != nil && !config.IsValidationError(err) {
 			writeError(w, err)
 			return
 		}
@@ -1189,23 +1248,34 @@ func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
 		if req.Tools != nil {
 			cfg.Tools = *req.Tools
 		}
+		// GET 返回的是脱敏值，原样回传的占位符视为“未修改”
+		cfg.RestoreMaskedSecrets(prev)
+		if err := cfg.Validate(); err != nil {
+			writeError(w, err)
+			return
+		}
 
 		if err := config.SaveConfig(cfg); err != nil {
 			writeError(w, err)
 			return
 		}
-		updated, err := config.LoadConfig()
+		saved, err := config.LoadConfig()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		// 运行时配置按网关的方式加载（叠加 --profile），返回给界面的仍是文件中的配置
+		updated, err := s.loadRuntimeConfig()
 		if err != nil {
 			writeError(w, err)
 			return
 		}
-		s.cfg = updated
-		if err := s.applyRuntimeModelConfig(updated); err != nil {
+		if err := s.applyUpdatedConfig(updated); err != nil {
 			if lg := logging.Get(); lg != nil && lg.Web != nil {
-				lg.Web.Printf("apply runtime model config failed: %v", err)
+				lg.Web.Printf("config reload failed: %v", err)
 			}
 		}
-		writeJSON(w, updated)
+		writeJSON(w, saved.Redacted())
 	default:
 		w.WriteHeader(http.StatusMethodNotAllowed)
 	}
@@ -1263,7 +1333,7 @@ func (s *Server) handleWorkspaceFile(w http.ResponseWriter, r *http.Request) {
 
 // readWorkspaceFile 读取 workspace 文件内容
 func (s *Server) readWorkspaceFile(filename string) (string, error) {
-	workspace := s.cfg.Agents.Defaults.Workspace
+	workspace := s.currentConfig().Agents.Defaults.Workspace
 	if workspace == "" {
 		workspace = "~/.maxclaw/workspace"
 	}
@@ -1286,7 +1356,7 @@ func (s *Server) readWorkspaceFile(filename string) (string, error) {
 
 // writeWorkspaceFile 写入 workspace 文件
 func (s *Server) writeWorkspaceFile(filename string, content string) error {
-	workspace := s.cfg.Agents.Defaults.Workspace
+	workspace := s.currentConfig().Agents.Defaults.Workspace
 	if workspace == "" {
 		workspace = "~/.maxclaw/workspace"
 	}
@@ -1308,6 +1378,74 @@ func (s *Server) writeWorkspaceFile(filename string, content string) error {
 	return os.WriteFile(filePath, []byte(content), 0644)
 }
 
+// SetConfigReloader 设置保存配置后的热加载回调（网关用它重建 provider 并调整频道）
+func (s *Server) SetConfigReloader(fn func() error) {
+	s.configReloader = fn
+}
+
+// SetConfig 替换当前生效的配置（网关热加载后调用）
+func (s *Server) SetConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	s.cfgMu.Lock()
+	defer s.cfgMu.Unlock()
+	s.cfg = cfg
+}
+
+// authToken 返回当前配置的 gateway.authToken，鉴权中间件与管理通道共用
+func (s *Server) authToken() string {
+	cfg := s.currentConfig()
+	if cfg == nil {
+		return ""
+	}
+	return strings.TrimSpace(cfg.Gateway.AuthToken)
+}
+
+// currentConfig 返回当前生效的配置
+func (s *Server) currentConfig() *config.Config {
+	s.cfgMu.RLock()
+	defer s.cfgMu.RUnlock()
+	return s.cfg
+}
+
+// SetConfigLoader 设置运行时配置的加载函数（网关传入叠加 --profile 的加载器）
+func (s *Server) SetConfigLoader(fn func() (*config.Config, error)) {
+	s.configLoader = fn
+}
+
+// loadRuntimeConfig 加载运行时生效的配置
+func (s *Server) loadRuntimeConfig() (*config.Config, error) {
+	if s.configLoader != nil {
+		return s.configLoader()
+	}
+	return config.LoadConfig()
+}
+
+// refreshRuntimeConfig 配置文件变更后重新加载运行时配置，加载失败时保留当前配置
+func (s *Server) refreshRuntimeConfig() {
+	updated, err := s.loadRuntimeConfig()
+	if err != nil {
+		if lg := logging.Get(); lg != nil && lg.Web != nil {
+			lg.Web.Printf("config refresh failed: %v", err)
+		}
+		return
+	}
+	s.SetConfig(updated)
+}
+
+// applyUpdatedConfig 替换当前配置并热加载：设置了 configReloader 时交给网关，否则只更新运行时模型参数
+func (s *Server) applyUpdatedConfig(updated *config.Config) error {
+	s.SetConfig(updated)
+	if s.configReloader != nil {
+		return s.configReloader()
+	}
+	if err := s.applyRuntimeModelConfig(updated); err != nil {
+		return fmt.Errorf("apply runtime model config: %w", err)
+	}
+	return nil
+}
+
 func (s *Server) applyRuntimeModelConfig(cfg *config.Config) error {
 	if s.agentLoop == nil || cfg == nil {
 		return nil
@@ -1315,13 +1453,13 @@ func (s *Server) applyRuntimeModelConfig(cfg *config.Config) error {
 	s.agentLoop.UpdateRuntimeMaxIterations(cfg.Agents.Defaults.MaxToolIterations)
 	s.agentLoop.UpdateRuntimeExecutionMode(cfg.Agents.Defaults.ExecutionMode)
 
-	model := cfg.Agents.Defaults.Model
+	model := cfg.ResolveModel("")
 	if model == "" {
 		return nil
 	}
 
 	apiKey := cfg.GetAPIKey(model)
-	if apiKey == "" {
+	if apiKey == "" && !providers.IsMockModel(model) {
 		return fmt.Errorf("no API key configured for model %s", model)
 	}
 	apiBase := cfg.GetAPIBase(model)
@@ -1446,42 +1584,9 @@ func (s *Server) handleCronCreate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 根据请求字段确定调度类型
-	var schedule cron.Schedule
-	switch {
-	case req.Cron != "":
-		schedule = cron.Schedule{
-			Type: cron.ScheduleTypeCron,
-			Expr: req.Cron,
-		}
-	case req.Every != "":
-		// 尝试解析毫秒数
-		var everyMs int64
-		if _, err := fmt.Sscanf(req.Every, "%d", &everyMs); err != nil {
-			writeError(w, fmt.Errorf("invalid every format: %v", err))
-			return
-		}
-		schedule = cron.Schedule{
-			Type:    cron.ScheduleTypeEvery,
-			EveryMs: everyMs,
-		}
-	case req.At != "":
-		// 尝试解析 ISO8601 时间
-		at, err := time.Parse(time.RFC3339, req.At)
-		if err != nil {
-			// 尝试其他格式
-			at, err = time.Parse("2006-01-02T15:04:05", req.At)
-			if err != nil {
-				writeError(w, fmt.Errorf("invalid at format: %v", err))
-				return
-			}
-		}
-		schedule = cron.Schedule{
-			Type: cron.ScheduleTypeOnce,
-			AtMs: at.UnixMilli(),
-		}
-	default:
-		writeError(w, fmt.Errorf("schedule is required (cron, every, or at)"))
+	schedule, err := cron.BuildScheduleFromFields(req.Cron, req.Every, req.At)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -1542,6 +1647,8 @@ func (s *Server) handleCronByID(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPut:
 		s.handleCronUpdate(w, r, jobID)
+	case http.MethodPatch:
+		s.handleCronPatch(w, r, jobID)
 	case http.MethodDelete:
 		s.handleCronDelete(w, r, jobID)
 	default:
@@ -1564,6 +1671,29 @@ func (s *Server) handleCronEnable(w http.ResponseWriter, r *http.Request, jobID
 	writeJSON(w, s.toCronJobResponse(job))
 }
 
+// handleCronPatch 通过 {"enabled": bool} 启用/禁用任务
+func (s *Server) handleCronPatch(w http.ResponseWriter, r *http.Request, jobID string) {
+	var req struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, fmt.Errorf("invalid request: %v", err))
+		return
+	}
+	if req.Enabled == nil {
+		writeError(w, fmt.Errorf("enabled is required"))
+		return
+	}
+
+	job, ok := s.cronService.EnableJob(jobID, *req.Enabled)
+	if !ok {
+		writeError(w, fmt.Errorf("job not found"))
+		return
+	}
+
+	writeJSON(w, s.toCronJobResponse(job))
+}
+
 func (s *Server) handleCronRun(w http.ResponseWriter, r *http.Request, jobID string) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -1604,40 +1734,9 @@ func (s *Server) handleCronUpdate(w http.ResponseWriter, r *http.Request, jobID
 		return
 	}
 
-	// 解析调度配置
-	var schedule cron.Schedule
-	switch {
-	case req.Cron != "":
-		schedule = cron.Schedule{
-			Type: cron.ScheduleTypeCron,
-			Expr: req.Cron,
-		}
-	case req.Every != "":
-		// 尝试解析毫秒数
-		var everyMs int64
-		if _, err := fmt.Sscanf(req.Every, "%d", &everyMs); err != nil {
-			writeError(w, fmt.Errorf("invalid every format: %v", err))
-			return
-		}
-		schedule = cron.Schedule{
-			Type:    cron.ScheduleTypeEvery,
-			EveryMs: everyMs,
-		}
-	case req.At != "":
-		at, err := time.Parse(time.RFC3339, req.At)
-		if err != nil {
-			at, err = time.Parse("2006-01-02T15:04:05", req.At)
-			if err != nil {
-				writeError(w, fmt.Errorf("invalid at format: %v", err))
-				return
-			}
-		}
-		schedule = cron.Schedule{
-			Type: cron.ScheduleTypeOnce,
-			AtMs: at.UnixMilli(),
-		}
-	default:
-		writeError(w, fmt.Errorf("schedule is required (cron, every, or at)"))
+	schedule, err := cron.BuildScheduleFromFields(req.Cron, req.Every, req.At)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -1750,20 +1849,15 @@ func listSessions(workspace string) ([]sessionSummary, error) {
 	mgr := session.NewManager(workspace)
 	var results []sessionSummary
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+		if entry.IsDir() || !session.IsSessionFile(entry.Name()) {
 			continue
 		}
-		path := filepath.Join(dir, entry.Name())
-		data, err := os.ReadFile(path)
+		sess, err := session.LoadFile(filepath.Join(dir, entry.Name()))
 		if err != nil {
 			continue
 		}
-		var sess session.Session
-		if err := json.Unmarshal(data, &sess); err != nil {
-			continue
-		}
-		if session.RefreshTitle(&sess) {
-			_ = mgr.Save(&sess)
+		if session.RefreshTitle(sess) {
+			_ = mgr.Save(sess)
 		}
 		summary := sessionSummary{
 			Key:          sess.Key,
@@ -1823,6 +1917,19 @@ type providerProbePayload struct {
 	Temperature float64 `json:"temperature"`
 }
 
+// unmaskProviderAPIKey 前端回传的是 /api/config 中的脱敏 key 时，替换为已保存的真实 key
+func (s *Server) unmaskProviderAPIKey(name, apiKey string) string {
+	cfg := s.currentConfig()
+	if cfg == nil || apiKey == "" {
+		return apiKey
+	}
+	saved, ok := cfg.Providers.ToMap()[strings.ToLower(strings.TrimSpace(name))]
+	if ok && saved.APIKey != "" && apiKey == config.MaskSecret(saved.APIKey) {
+		return saved.APIKey
+	}
+	return apiKey
+}
+
 func (s *Server) handleTestProvider(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		w.WriteHeader(http.StatusMethodNotAllowed)
@@ -1835,6 +1942,7 @@ func (s *Server) handleTestProvider(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	req.APIKey = s.unmaskProviderAPIKey(req.Name, req.APIKey)
 	if req.APIKey == "" {
 		http.Error(w, "API key is required", http.StatusBadRequest)
 		return
@@ -1876,6 +1984,11 @@ func (s *Server) handleTestProvider(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+	case "gemini":
+		if err := testGeminiProvider(ctx, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 	default:
 		if err := s.testCompatibleProvider(ctx, req); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -1886,6 +1999,30 @@ func (s *Server) handleTestProvider(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, map[string]bool{"ok": true})
 }
 
+// testGeminiProvider 用原生 API 的模型列表接口校验 Gemini API Key
+func testGeminiProvider(ctx context.Context, req ProviderTestRequest) error {
+	baseURL := strings.TrimSuffix(strings.TrimRight(strings.TrimSpace(req.BaseURL), "/"), "/openai")
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/models", nil)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("x-goog-api-key", req.APIKey)
+
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
 func (s *Server) testCompatibleProvider(ctx context.Context, req ProviderTestRequest) error {
 	client := &http.Client{Timeout: 10 * time.Second}
 
@@ -2390,10 +2527,7 @@ func writeError(w http.ResponseWriter, err error) {
 
 func spaHandler(uiDir string) http.Handler {
 	if uiDir == "" {
-		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusNotFound)
-			_, _ = w.Write([]byte("Web UI not built"))
-		})
+		return uiFallbackHandler()
 	}
 
 	fs := http.Dir(uiDir)
@@ -2505,12 +2639,8 @@ func (s *Server) handleWhatsAppStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Type assert to WhatsAppChannel to get status
-	if wc, ok := ch.(interface {
-		Status() channels.WhatsAppStatus
-	}); ok {
-		status := wc.Status()
-		writeJSON(w, status)
+	if provider, ok := ch.(channels.StatusProvider); ok {
+		writeJSON(w, provider.ChannelStatus())
 		return
 	}
 
@@ -2523,6 +2653,24 @@ func (s *Server) handleWhatsAppStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 // MCP-related handlers
+func (s *Server) handleSpawns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	tasks := []*tools.SpawnTask{}
+	if s.agentLoop != nil {
+		if running := s.agentLoop.ListSpawnTasks(); running != nil {
+			tasks = running
+		}
+	}
+	writeJSON(w, map[string]interface{}{
+		"tasks": tasks,
+		"count": len(tasks),
+	})
+}
+
 func (s *Server) handleMCP(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
@@ -2572,7 +2720,7 @@ func (s *Server) handleMCPByName(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleMCPList(w http.ResponseWriter, r *http.Request) {
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	if err != nil && !config.IsValidationError(err) {
 		writeError(w, err)
 		return
 	}
@@ -2625,7 +2773,7 @@ func (s *Server) handleMCPAdd(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	if err != nil && !config.IsValidationError(err) {
 		writeError(w, err)
 		return
 	}
@@ -2655,7 +2803,7 @@ func (s *Server) handleMCPAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.cfg = cfg
+	s.refreshRuntimeConfig()
 	writeJSON(w, map[string]interface{}{
 		"ok":   true,
 		"name": req.Name,
@@ -2679,7 +2827,7 @@ func (s *Server) handleMCPUpdate(w http.ResponseWriter, r *http.Request, name st
 	}
 
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	if err != nil && !config.IsValidationError(err) {
 		writeError(w, err)
 		return
 	}
@@ -2704,7 +2852,7 @@ func (s *Server) handleMCPUpdate(w http.ResponseWriter, r *http.Request, name st
 		return
 	}
 
-	s.cfg = cfg
+	s.refreshRuntimeConfig()
 	writeJSON(w, map[string]interface{}{
 		"ok":   true,
 		"name": name,
@@ -2713,7 +2861,7 @@ func (s *Server) handleMCPUpdate(w http.ResponseWriter, r *http.Request, name st
 
 func (s *Server) handleMCPDelete(w http.ResponseWriter, r *http.Request, name string) {
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	if err != nil && !config.IsValidationError(err) {
 		writeError(w, err)
 		return
 	}
@@ -2730,7 +2878,7 @@ func (s *Server) handleMCPDelete(w http.ResponseWriter, r *http.Request, name st
 		return
 	}
 
-	s.cfg = cfg
+	s.refreshRuntimeConfig()
 	writeJSON(w, map[string]interface{}{
 		"ok":   true,
 		"name": name,
@@ -2744,7 +2892,7 @@ func (s *Server) handleMCPEnable(w http.ResponseWriter, r *http.Request, name st
 	}
 
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	if err != nil && !config.IsValidationError(err) {
 		writeError(w, err)
 		return
 	}
@@ -2775,7 +2923,7 @@ func (s *Server) handleMCPTest(w http.ResponseWriter, r *http.Request, name stri
 	}
 
 	cfg, err := config.LoadConfig()
-	if err != nil {
+	if err != nil && !config.IsValidationError(err) {
 		writeError(w, err)
 		return
 	}
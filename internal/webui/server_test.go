@@ -1,7 +1,9 @@
 package webui
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -11,9 +13,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/Lichas/maxclaw/internal/agent"
 	"github.com/Lichas/maxclaw/internal/bus"
 	"github.com/Lichas/maxclaw/internal/config"
+	"github.com/Lichas/maxclaw/internal/cron"
+	"github.com/Lichas/maxclaw/internal/providers"
 	"github.com/Lichas/maxclaw/internal/session"
+	"github.com/Lichas/maxclaw/pkg/tools"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -337,3 +343,328 @@ func TestRenameSessionStoresDedicatedTitle(t *testing.T) {
 	require.Len(t, updated.Messages, 2)
 	assert.Equal(t, "好的", updated.Messages[1].Content)
 }
+
+func TestHandleCronAddListPatchDelete(t *testing.T) {
+	s := &Server{
+		cfg:         config.DefaultConfig(),
+		cronService: cron.NewService(filepath.Join(t.TempDir(), "jobs.json")),
+	}
+
+	body := `{"title":"daily","prompt":"summarize news","cron":"0 9 * * *"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/cron", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.handleCron(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var created cronJobResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	require.NotEmpty(t, created.ID)
+	assert.Equal(t, "cron", created.ScheduleType)
+	assert.True(t, created.Enabled)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/cron", nil)
+	rec = httptest.NewRecorder()
+	s.handleCron(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var listed struct {
+		Jobs []cronJobResponse `json:"jobs"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(t, listed.Jobs, 1)
+	assert.Equal(t, created.ID, listed.Jobs[0].ID)
+
+	req = httptest.NewRequest(http.MethodPatch, "/api/cron/"+created.ID, strings.NewReader(`{"enabled":false}`))
+	rec = httptest.NewRecorder()
+	s.handleCronByID(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	var patched cronJobResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &patched))
+	assert.False(t, patched.Enabled)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/cron/"+created.ID, nil)
+	rec = httptest.NewRecorder()
+	s.handleCronByID(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Empty(t, s.cronService.ListJobs())
+}
+
+func TestHandleCronCreateRejectsInvalidSchedule(t *testing.T) {
+	s := &Server{
+		cfg:         config.DefaultConfig(),
+		cronService: cron.NewService(filepath.Join(t.TempDir(), "jobs.json")),
+	}
+
+	for _, body := range []string{
+		`{"title":"t","prompt":"p"}`,
+		`{"title":"t","prompt":"p","every":"soon"}`,
+		`{"title":"t","prompt":"p","every":"0"}`,
+		`{"title":"t","prompt":"p","at":"tomorrow"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/cron", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		s.handleCron(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+	assert.Empty(t, s.cronService.ListJobs())
+}
+
+type tokenStreamProvider struct {
+	tokens []string
+}
+
+func (p *tokenStreamProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *tokenStreamProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	for _, token := range p.tokens {
+		handler.OnContent(token)
+	}
+	handler.OnComplete()
+	return nil
+}
+
+func (p *tokenStreamProvider) GetDefaultModel() string { return "test-model" }
+
+func (p *tokenStreamProvider) SupportsImageInput(model string) bool { return false }
+
+func TestHandleMessageStreamEndpointEmitsOrderedDeltasThenFinal(t *testing.T) {
+	workspace := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = workspace
+
+	loop := agent.NewAgentLoop(
+		bus.NewMessageBus(10),
+		&tokenStreamProvider{tokens: []string{"Hel", "lo", " world"}},
+		workspace,
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	defer loop.Close()
+
+	s := &Server{cfg: cfg, agentLoop: loop}
+	ts := httptest.NewServer(http.HandlerFunc(s.handleMessageStreamEndpoint))
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL, "application/json", strings.NewReader(`{"content":"hi","sessionKey":"webui:sse-test"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	var deltas []string
+	var final map[string]interface{}
+	sawDone := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		data := strings.TrimPrefix(line, "data: ")
+		if data == "[DONE]" {
+			sawDone = true
+			break
+		}
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(data), &event))
+		switch event["type"] {
+		case "content_delta":
+			require.Nil(t, final, "delta after final event")
+			deltas = append(deltas, event["delta"].(string))
+		case "final":
+			final = event
+		}
+	}
+	require.NoError(t, scanner.Err())
+
+	assert.Equal(t, []string{"Hel", "lo", " world"}, deltas)
+	require.NotNil(t, final)
+	assert.Equal(t, "Hello world", final["response"])
+	assert.Equal(t, true, final["done"])
+	assert.True(t, sawDone)
+}
+
+func TestHandleConfigRedactsSecretsAndPreservesMaskedValuesOnPut(t *testing.T) {
+	t.Setenv("MAXCLAW_HOME", t.TempDir())
+
+	saved := config.DefaultConfig()
+	saved.Providers.OpenAI.APIKey = "sk-openai-1234567890abcd"
+	saved.Channels.Telegram.Token = "123456:telegram-bot-token-wxyz"
+	require.NoError(t, config.SaveConfig(saved))
+
+	s := &Server{cfg: saved}
+
+	rec := httptest.NewRecorder()
+	s.handleConfig(rec, httptest.NewRequest(http.MethodGet, "/api/config", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "sk-openai-1234567890abcd")
+	assert.NotContains(t, rec.Body.String(), "telegram-bot-token")
+
+	var got config.Config
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, "sk-...abcd", got.Providers.OpenAI.APIKey)
+
+	// 把 GET 的结果原样 PUT 回去，只修改模型
+	got.Agents.Defaults.Model = "openai/gpt-4o"
+	putBody, err := json.Marshal(map[string]interface{}{
+		"agents":    got.Agents,
+		"channels":  got.Channels,
+		"providers": got.Providers.ToMap(),
+	})
+	require.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	s.handleConfig(rec, httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewReader(putBody)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), "sk-openai-1234567890abcd")
+
+	reloaded, err := config.LoadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "openai/gpt-4o", reloaded.Agents.Defaults.Model)
+	assert.Equal(t, "sk-openai-1234567890abcd", reloaded.Providers.OpenAI.APIKey)
+	assert.Equal(t, "123456:telegram-bot-token-wxyz", reloaded.Channels.Telegram.Token)
+}
+
+func TestHandleConfigPutKeepsProfileOverlay(t *testing.T) {
+	t.Setenv("MAXCLAW_HOME", t.TempDir())
+
+	saved := config.DefaultConfig()
+	saved.Agents.Defaults.Workspace = filepath.Join(t.TempDir(), "default")
+	profileWorkspace := filepath.Join(t.TempDir(), "work")
+	saved.Agents.Profiles = map[string]config.AgentDefaults{"work": {Workspace: profileWorkspace}}
+	require.NoError(t, config.SaveConfig(saved))
+
+	runtime, err := config.LoadConfig()
+	require.NoError(t, err)
+	require.NoError(t, runtime.ApplyProfile("work"))
+	s := &Server{cfg: runtime}
+	s.SetConfigLoader(func() (*config.Config, error) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return nil, err
+		}
+		return cfg, cfg.ApplyProfile("work")
+	})
+
+	putBody, err := json.Marshal(map[string]interface{}{
+		"agents": map[string]interface{}{
+			"defaults": map[string]interface{}{"workspace": saved.Agents.Defaults.Workspace, "model": "openai/gpt-4o"},
+			"profiles": saved.Agents.Profiles,
+		},
+	})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	s.handleConfig(rec, httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewReader(putBody)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// 运行时配置仍叠加 --profile，界面拿到的是文件中的 defaults
+	assert.Equal(t, profileWorkspace, s.cfg.Agents.Defaults.Workspace)
+	assert.Equal(t, "openai/gpt-4o", s.cfg.Agents.Defaults.Model)
+	var got config.Config
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, saved.Agents.Defaults.Workspace, got.Agents.Defaults.Workspace)
+}
+
+func TestHandleMCPListToleratesInvalidConfig(t *testing.T) {
+	t.Setenv("MAXCLAW_HOME", t.TempDir())
+
+	saved := config.DefaultConfig()
+	saved.Tools.MCPServers = map[string]config.MCPServerConfig{"files": {Command: "npx"}}
+	saved.Tools.Exec.Timeout = -1
+	require.NoError(t, config.SaveConfig(saved))
+
+	// 配置中其他字段校验失败时，MCP 列表仍然可用，方便在界面上修复
+	s := &Server{cfg: saved}
+	rec := httptest.NewRecorder()
+	s.handleMCP(rec, httptest.NewRequest(http.MethodGet, "/api/mcp", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(t, rec.Body.String(), "files")
+}
+
+type blockingProvider struct {
+	release chan struct{}
+}
+
+func (p *blockingProvider) Chat(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string) (*providers.Response, error) {
+	return nil, nil
+}
+
+func (p *blockingProvider) ChatStream(ctx context.Context, messages []providers.Message, defs []map[string]interface{}, model string, handler providers.StreamHandler) error {
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	handler.OnContent("done")
+	handler.OnComplete()
+	return nil
+}
+
+func (p *blockingProvider) GetDefaultModel() string { return "test-model" }
+
+func (p *blockingProvider) SupportsImageInput(model string) bool { return false }
+
+func TestHandleSpawnsListsRunningTasks(t *testing.T) {
+	workspace := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.Agents.Defaults.Workspace = workspace
+
+	provider := &blockingProvider{release: make(chan struct{})}
+	loop := agent.NewAgentLoop(
+		bus.NewMessageBus(10),
+		provider,
+		workspace,
+		"test-model",
+		3,
+		config.WebSearchConfig{},
+		tools.WebFetchOptions{},
+		config.ExecToolConfig{Timeout: 5},
+		false,
+		nil,
+		nil,
+		false,
+	)
+	defer loop.Close()
+	defer close(provider.release)
+
+	s := &Server{cfg: cfg, agentLoop: loop}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/spawns", nil)
+	rec := httptest.NewRecorder()
+	s.handleSpawns(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"tasks":[],"count":0}`, rec.Body.String())
+
+	_, err := loop.ExecuteToolWithSession(context.Background(), "spawn", map[string]interface{}{
+		"task":  "crawl the docs",
+		"label": "docs-crawl",
+	}, "webui:spawn-test", "webui", "spawn-test")
+	require.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	s.handleSpawns(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Tasks []tools.SpawnTask `json:"tasks"`
+		Count int               `json:"count"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, 1, body.Count)
+	require.Len(t, body.Tasks, 1)
+	assert.Equal(t, "docs-crawl", body.Tasks[0].Label)
+	assert.Equal(t, "crawl the docs", body.Tasks[0].Task)
+	assert.Equal(t, "running", body.Tasks[0].Status)
+
+	rec = httptest.NewRecorder()
+	s.handleSpawns(rec, httptest.NewRequest(http.MethodPost, "/api/spawns", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
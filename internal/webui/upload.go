@@ -42,7 +42,7 @@ func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
 	defer file.Close()
 
 	// Create uploads directory
-	uploadsDir := filepath.Join(s.cfg.Agents.Defaults.Workspace, ".uploads")
+	uploadsDir := filepath.Join(s.currentConfig().Agents.Defaults.Workspace, ".uploads")
 	if err := os.MkdirAll(uploadsDir, 0755); err != nil {
 		http.Error(w, "Failed to create uploads directory", http.StatusInternalServerError)
 		return
@@ -94,7 +94,7 @@ func (s *Server) handleGetUpload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filename := filepath.Base(r.URL.Path)
-	uploadsDir := filepath.Join(s.cfg.Agents.Defaults.Workspace, ".uploads")
+	uploadsDir := filepath.Join(s.currentConfig().Agents.Defaults.Workspace, ".uploads")
 	filePath := filepath.Join(uploadsDir, filename)
 
 	// Security check: ensure file is within uploads directory
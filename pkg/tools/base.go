@@ -5,9 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Lichas/maxclaw/internal/logging"
 )
 
 // Tool 工具接口
+//
+// Execute 必须响应 ctx 取消：本轮对话被取消或工具超时后应尽快返回（通常返回 ctx.Err() 或包装它的错误），
+// 网络请求使用 http.NewRequestWithContext，子进程使用 exec.CommandContext，耗时循环在每次迭代前检查 ctx.Err()。
 type Tool interface {
 	Name() string
 	Description() string
@@ -49,24 +58,59 @@ func (t *BaseTool) ToOpenAISchema() map[string]interface{} {
 	}
 }
 
-// ValidateParams 验证参数
+// ValidationError 参数校验发现的全部问题，按 required 声明顺序、参数名字典序、数组下标排列
+type ValidationError struct {
+	Errors []error
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		parts = append(parts, err.Error())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap 返回各条校验错误
+func (e *ValidationError) Unwrap() []error {
+	return e.Errors
+}
+
+// ValidateParams 验证参数，只返回第一个问题
 func ValidateParams(schema, params map[string]interface{}) error {
+	if errs := validateObject(schema, params); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateParamsAll 验证参数并一次返回全部问题（*ValidationError），便于模型一次改正
+func ValidateParamsAll(schema, params map[string]interface{}) error {
+	if errs := validateObject(schema, params); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validateObject 校验对象参数，返回顺序稳定的全部问题
+func validateObject(schema, params map[string]interface{}) []error {
 	if schema == nil {
 		return nil
 	}
 
 	schemaType, _ := schema["type"].(string)
 	if schemaType != "object" {
-		return fmt.Errorf("schema type must be 'object', got '%s'", schemaType)
+		return []error{fmt.Errorf("schema type must be 'object', got '%s'", schemaType)}
 	}
 
 	properties, _ := schema["properties"].(map[string]interface{})
+	var errs []error
 
 	// 检查必填字段 (支持 []string 和 []interface{})
 	if required, ok := schema["required"].([]string); ok {
 		for _, reqStr := range required {
 			if _, exists := params[reqStr]; !exists {
-				return fmt.Errorf("missing required parameter: %s", reqStr)
+				errs = append(errs, fmt.Errorf("missing required parameter: %s", reqStr))
 			}
 		}
 	} else if required, ok := schema["required"].([]interface{}); ok {
@@ -76,13 +120,18 @@ func ValidateParams(schema, params map[string]interface{}) error {
 				continue
 			}
 			if _, exists := params[reqStr]; !exists {
-				return fmt.Errorf("missing required parameter: %s", reqStr)
+				errs = append(errs, fmt.Errorf("missing required parameter: %s", reqStr))
 			}
 		}
 	}
 
-	// 验证每个参数的类型
-	for key, value := range params {
+	// 按参数名排序后验证每个参数，保证错误顺序稳定
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
 		propSchema, exists := properties[key]
 		if !exists {
 			continue // 忽略未知字段
@@ -92,80 +141,87 @@ func ValidateParams(schema, params map[string]interface{}) error {
 			continue
 		}
 
-		if err := validateValue(value, propMap, key); err != nil {
-			return err
-		}
+		errs = append(errs, validateValue(params[key], propMap, key)...)
 	}
 
-	return nil
+	return errs
 }
 
-// validateValue 验证单个值
-func validateValue(value interface{}, schema map[string]interface{}, path string) error {
+// validateValue 验证单个值；标量每个值最多报告一个问题，数组元素与嵌套对象的问题全部收集
+func validateValue(value interface{}, schema map[string]interface{}, path string) []error {
 	propType, _ := schema["type"].(string)
 
 	switch propType {
 	case "string":
 		if _, ok := value.(string); !ok {
-			return fmt.Errorf("%s should be string", path)
+			return []error{fmt.Errorf("%s should be string", path)}
 		}
 		str := value.(string)
-		if minLen, ok := schema["minLength"].(float64); ok && float64(len(str)) < minLen {
-			return fmt.Errorf("%s must be at least %d chars", path, int(minLen))
+		if minLen, ok := schemaNumber(schema, "minLength"); ok && float64(len(str)) < minLen {
+			return []error{fmt.Errorf("%s must be at least %d chars", path, int(minLen))}
 		}
-		if maxLen, ok := schema["maxLength"].(float64); ok && float64(len(str)) > maxLen {
-			return fmt.Errorf("%s must be at most %d chars", path, int(maxLen))
+		if maxLen, ok := schemaNumber(schema, "maxLength"); ok && float64(len(str)) > maxLen {
+			return []error{fmt.Errorf("%s must be at most %d chars", path, int(maxLen))}
+		}
+		if pattern, ok := schema["pattern"].(string); ok && pattern != "" {
+			if re := compileSchemaPattern(pattern); re != nil && !re.MatchString(str) {
+				return []error{fmt.Errorf("%s must match pattern %q", path, pattern)}
+			}
 		}
 
 	case "integer":
 		if !isInteger(value) {
-			return fmt.Errorf("%s should be integer", path)
-		}
-		num := toFloat64(value)
-		if minimum, ok := schema["minimum"].(float64); ok && num < minimum {
-			return fmt.Errorf("%s must be >= %v", path, minimum)
+			return []error{fmt.Errorf("%s should be integer", path)}
 		}
-		if maximum, ok := schema["maximum"].(float64); ok && num > maximum {
-			return fmt.Errorf("%s must be <= %v", path, maximum)
+		if err := validateNumberBounds(toFloat64(value), schema, path); err != nil {
+			return []error{err}
 		}
 
 	case "number":
 		if !isNumber(value) {
-			return fmt.Errorf("%s should be number", path)
+			return []error{fmt.Errorf("%s should be number", path)}
 		}
-		num := toFloat64(value)
-		if minimum, ok := schema["minimum"].(float64); ok && num < minimum {
-			return fmt.Errorf("%s must be >= %v", path, minimum)
-		}
-		if maximum, ok := schema["maximum"].(float64); ok && num > maximum {
-			return fmt.Errorf("%s must be <= %v", path, maximum)
+		if err := validateNumberBounds(toFloat64(value), schema, path); err != nil {
+			return []error{err}
 		}
 
 	case "boolean":
 		if _, ok := value.(bool); !ok {
-			return fmt.Errorf("%s should be boolean", path)
+			return []error{fmt.Errorf("%s should be boolean", path)}
 		}
 
 	case "array":
 		arr, ok := value.([]interface{})
 		if !ok {
-			return fmt.Errorf("%s should be array", path)
+			return []error{fmt.Errorf("%s should be array", path)}
+		}
+		if minItems, ok := schemaNumber(schema, "minItems"); ok && float64(len(arr)) < minItems {
+			return []error{fmt.Errorf("%s must have at least %d items", path, int(minItems))}
+		}
+		if maxItems, ok := schemaNumber(schema, "maxItems"); ok && float64(len(arr)) > maxItems {
+			return []error{fmt.Errorf("%s must have at most %d items", path, int(maxItems))}
 		}
 		if itemsSchema, ok := schema["items"].(map[string]interface{}); ok {
+			var errs []error
 			for i, item := range arr {
-				if err := validateValue(item, itemsSchema, fmt.Sprintf("%s[%d]", path, i)); err != nil {
-					return err
-				}
+				errs = append(errs, validateValue(item, itemsSchema, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+			if len(errs) > 0 {
+				return errs
 			}
 		}
 
 	case "object":
 		obj, ok := value.(map[string]interface{})
 		if !ok {
-			return fmt.Errorf("%s should be object", path)
+			return []error{fmt.Errorf("%s should be object", path)}
 		}
-		if err := ValidateParams(schema, obj); err != nil {
-			return fmt.Errorf("%s.%s", path, err.Error())
+		if nested := validateObject(schema, obj); len(nested) > 0 {
+			errs := make([]error, 0, len(nested))
+			for _, err := range nested {
+				errs = append(errs, fmt.Errorf("%s.%s", path, err.Error()))
+			}
+			return errs
 		}
 	}
 
@@ -179,13 +235,60 @@ func validateValue(value interface{}, schema map[string]interface{}, path string
 			}
 		}
 		if !found {
-			return fmt.Errorf("%s must be one of %v", path, enum)
+			return []error{fmt.Errorf("%s must be one of %v", path, enum)}
 		}
 	}
 
 	return nil
 }
 
+// validateNumberBounds 检查 minimum/maximum 与 exclusiveMinimum/exclusiveMaximum（数值形式）
+func validateNumberBounds(num float64, schema map[string]interface{}, path string) error {
+	if minimum, ok := schemaNumber(schema, "minimum"); ok && num < minimum {
+		return fmt.Errorf("%s must be >= %v", path, minimum)
+	}
+	if maximum, ok := schemaNumber(schema, "maximum"); ok && num > maximum {
+		return fmt.Errorf("%s must be <= %v", path, maximum)
+	}
+	if minimum, ok := schemaNumber(schema, "exclusiveMinimum"); ok && num <= minimum {
+		return fmt.Errorf("%s must be > %v", path, minimum)
+	}
+	if maximum, ok := schemaNumber(schema, "exclusiveMaximum"); ok && num >= maximum {
+		return fmt.Errorf("%s must be < %v", path, maximum)
+	}
+	return nil
+}
+
+// schemaNumber 读取 schema 中的数值约束，接受 Go 代码中直接写的 int 字面量与 JSON 解码得到的 float64
+func schemaNumber(schema map[string]interface{}, key string) (float64, bool) {
+	value, ok := schema[key]
+	if !ok || !isNumber(value) {
+		return 0, false
+	}
+	return toFloat64(value), true
+}
+
+// schemaPatterns 已编译的 pattern 缓存；无法编译的 pattern 记为 nil
+var schemaPatterns sync.Map
+
+// compileSchemaPattern 返回 pattern 编译后的正则。JSON Schema 的 pattern 是 ECMA 262 正则，
+// 外部（如 MCP）工具常用 Go RE2 不支持的写法（前瞻、反向引用等），无法编译时跳过该约束并只记录一次日志
+func compileSchemaPattern(pattern string) *regexp.Regexp {
+	if cached, ok := schemaPatterns.Load(pattern); ok {
+		return cached.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		re = nil
+	}
+	if _, loaded := schemaPatterns.LoadOrStore(pattern, re); !loaded && err != nil {
+		if lg := logging.Get(); lg != nil && lg.Tools != nil {
+			lg.Tools.Printf("skip unsupported schema pattern %q: %v", pattern, err)
+		}
+	}
+	return re
+}
+
 // isInteger 检查是否为整数类型
 func isInteger(v interface{}) bool {
 	switch v.(type) {
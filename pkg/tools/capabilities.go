@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// CapabilitiesTool 一次返回已注册工具、可用技能与当前会话定时任务的概览
+type CapabilitiesTool struct {
+	BaseTool
+	registry *Registry
+	skills   SkillsService
+	cron     CronService
+}
+
+// capabilitiesSummary capabilities 工具的返回结构
+type capabilitiesSummary struct {
+	Tools    []capabilityTool  `json:"tools"`
+	Skills   []capabilitySkill `json:"skills"`
+	CronJobs []capabilityJob   `json:"cronJobs"`
+}
+
+type capabilityTool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type capabilitySkill struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Source      string `json:"source,omitempty"`
+	Active      bool   `json:"active,omitempty"`
+}
+
+type capabilityJob struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+}
+
+// NewCapabilitiesTool 创建能力概览工具；skills / cron 为 nil 时对应部分为空
+func NewCapabilitiesTool(registry *Registry, skills SkillsService, cron CronService) *CapabilitiesTool {
+	return &CapabilitiesTool{
+		BaseTool: BaseTool{
+			name:        "capabilities",
+			description: "Summarize what you can do in this conversation: registered tools, available skills (active ones flagged) and the enabled cron jobs that deliver to this chat. Use it to answer \"what can you do?\" accurately instead of guessing.",
+			parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		registry: registry,
+		skills:   skills,
+		cron:     cron,
+	}
+}
+
+// Execute 返回 JSON 格式的能力概览
+func (t *CapabilitiesTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	summary := capabilitiesSummary{
+		Tools:    t.listTools(),
+		Skills:   t.listSkills(ctx),
+		CronJobs: t.listCronJobs(ctx),
+	}
+	payload, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
+func (t *CapabilitiesTool) listTools() []capabilityTool {
+	result := []capabilityTool{}
+	if t.registry == nil {
+		return result
+	}
+	names := t.registry.List()
+	sort.Strings(names)
+	for _, name := range names {
+		tool, ok := t.registry.Get(name)
+		if !ok {
+			continue
+		}
+		result = append(result, capabilityTool{Name: name, Description: firstSentence(tool.Description())})
+	}
+	return result
+}
+
+// listSkills 技能目录读取失败时返回空列表，不影响其余部分
+func (t *CapabilitiesTool) listSkills(ctx context.Context) []capabilitySkill {
+	result := []capabilitySkill{}
+	if t.skills == nil {
+		return result
+	}
+	entries, err := t.skills.ListSkills()
+	if err != nil {
+		return result
+	}
+
+	active := make(map[string]bool)
+	if sessionKey := RuntimeSessionKeyFrom(ctx); sessionKey != "" {
+		for _, name := range t.skills.ActiveSkills(sessionKey) {
+			active[name] = true
+		}
+	}
+	for _, entry := range entries {
+		result = append(result, capabilitySkill{
+			Name:        entry.Name,
+			Description: entry.Description,
+			Source:      entry.Source,
+			Active:      active[entry.Name],
+		})
+	}
+	return result
+}
+
+// listCronJobs 只列出已启用的任务；有会话上下文时只保留投递到当前会话的任务
+func (t *CapabilitiesTool) listCronJobs(ctx context.Context) []capabilityJob {
+	result := []capabilityJob{}
+	if t.cron == nil {
+		return result
+	}
+	channel, chatID := RuntimeContextFrom(ctx)
+	for _, job := range t.cron.ListJobs() {
+		if job == nil || !job.Enabled {
+			continue
+		}
+		if channel != "" && chatID != "" {
+			if job.Payload.To != chatID || !containsString(job.Payload.Channels, channel) {
+				continue
+			}
+		}
+		result = append(result, capabilityJob{ID: job.ID, Name: job.Name, Schedule: describeSchedule(job.Schedule)})
+	}
+	return result
+}
+
+// firstSentence 工具描述只保留第一句，避免概览过长
+func firstSentence(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.Index(text, ". "); i >= 0 {
+		return text[:i+1]
+	}
+	return text
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
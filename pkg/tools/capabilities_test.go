@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Lichas/maxclaw/internal/cron"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSkillsService struct {
+	skills []SkillInfo
+	active []string
+}
+
+func (s stubSkillsService) ListSkills() ([]SkillInfo, error) { return s.skills, nil }
+
+func (s stubSkillsService) ActiveSkills(sessionKey string) []string { return s.active }
+
+func (s stubSkillsService) SetActiveSkills(sessionKey string, names []string) ([]string, error) {
+	return names, nil
+}
+
+func executeCapabilities(t *testing.T, tool *CapabilitiesTool, ctx context.Context) capabilitiesSummary {
+	t.Helper()
+	result, err := tool.Execute(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	var summary capabilitiesSummary
+	require.NoError(t, json.Unmarshal([]byte(result), &summary))
+	return summary
+}
+
+func TestCapabilitiesToolEnumeratesToolsSkillsAndJobs(t *testing.T) {
+	cronService := NewMockCronService()
+	daily, err := cronService.AddJob("daily report", cron.Schedule{Type: cron.ScheduleTypeCron, Expr: "0 9 * * *"},
+		cron.Payload{Message: "report", Channels: []string{"telegram"}, To: "42", Deliver: true})
+	require.NoError(t, err)
+	_, err = cronService.AddJob("other chat", cron.Schedule{Type: cron.ScheduleTypeEvery, EveryMs: 60000},
+		cron.Payload{Message: "ping", Channels: []string{"telegram"}, To: "99", Deliver: true})
+	require.NoError(t, err)
+	paused, err := cronService.AddJob("paused", cron.Schedule{Type: cron.ScheduleTypeEvery, EveryMs: 60000},
+		cron.Payload{Message: "ping", Channels: []string{"telegram"}, To: "42", Deliver: true})
+	require.NoError(t, err)
+	paused.Enabled = false
+
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(NewCronTool(cronService)))
+	require.NoError(t, registry.Register(NewListDirTool()))
+	skills := stubSkillsService{
+		skills: []SkillInfo{{Name: "pdf", Description: "Work with PDFs", Source: "workspace"}, {Name: "git"}},
+		active: []string{"pdf"},
+	}
+	tool := NewCapabilitiesTool(registry, skills, cronService)
+	require.NoError(t, registry.Register(tool))
+
+	ctx := WithRuntimeContextWithSession(context.Background(), "telegram", "42", "telegram:42")
+	summary := executeCapabilities(t, tool, ctx)
+
+	names := make([]string, 0, len(summary.Tools))
+	for _, entry := range summary.Tools {
+		names = append(names, entry.Name)
+	}
+	assert.Equal(t, []string{"capabilities", "cron", "list_dir"}, names)
+	assert.Equal(t, "Schedule reminders and recurring tasks.", summary.Tools[1].Description)
+
+	require.Len(t, summary.Skills, 2)
+	assert.True(t, summary.Skills[0].Active)
+	assert.False(t, summary.Skills[1].Active)
+
+	require.Len(t, summary.CronJobs, 1)
+	assert.Equal(t, daily.ID, summary.CronJobs[0].ID)
+	assert.Equal(t, "cron: 0 9 * * *", summary.CronJobs[0].Schedule)
+
+	// 没有会话上下文时列出全部已启用任务
+	summary = executeCapabilities(t, tool, context.Background())
+	assert.Len(t, summary.CronJobs, 2)
+}
+
+func TestCapabilitiesToolWithoutOptionalServices(t *testing.T) {
+	registry := NewRegistry()
+	tool := NewCapabilitiesTool(registry, nil, nil)
+	require.NoError(t, registry.Register(tool))
+
+	summary := executeCapabilities(t, tool, context.Background())
+	require.Len(t, summary.Tools, 1)
+	assert.Empty(t, summary.Skills)
+	assert.Empty(t, summary.CronJobs)
+}
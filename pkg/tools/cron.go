@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/Lichas/maxclaw/internal/cron"
@@ -22,9 +21,8 @@ type CronService interface {
 type CronTool struct {
 	BaseTool
 	service CronService
-	mu      sync.RWMutex
-	channel string
-	chatID  string
+	// fallback SetContext 设置的默认 channel/chat
+	fallback runtimeContextFallback
 }
 
 // NewCronTool 创建定时任务工具
@@ -56,7 +54,7 @@ func NewCronTool(service CronService) *CronTool {
 					},
 					"at": map[string]interface{}{
 						"type":        "string",
-						"description": "One-time execution time. Supports RFC3339, 'YYYY-MM-DD HH:MM[:SS]', or local time-only 'HH:MM[:SS]' (next occurrence).",
+						"description": "One-time execution time. Supports RFC3339, 'YYYY-MM-DD HH:MM[:SS]', local time-only 'HH:MM[:SS]' (next occurrence), or natural phrases such as 'in 30 minutes', 'tomorrow at 9am', 'next friday 14:30', '明天下午3点'. Ambiguous phrases are rejected; ask the user to clarify instead of guessing.",
 					},
 					"job_id": map[string]interface{}{
 						"type":        "string",
@@ -70,12 +68,9 @@ func NewCronTool(service CronService) *CronTool {
 	}
 }
 
-// SetContext 设置当前上下文
+// SetContext 设置默认的 channel/chat；本次调用的 context 中带有 channel/chat 时以 context 为准
 func (t *CronTool) SetContext(channel, chatID string) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.channel = channel
-	t.chatID = chatID
+	t.fallback.set(channel, chatID)
 }
 
 // Execute 执行定时任务操作
@@ -104,20 +99,8 @@ func (t *CronTool) addJob(ctx context.Context, params map[string]interface{}) (s
 		return "", fmt.Errorf("message is required for add action")
 	}
 
-	t.mu.RLock()
-	channel := t.channel
-	chatID := t.chatID
-	t.mu.RUnlock()
-
-	// 优先使用当前请求上下文，避免并发请求时的上下文串线
-	if ctxChannel, ctxChatID := RuntimeContextFrom(ctx); ctxChannel != "" || ctxChatID != "" {
-		if channel == "" {
-			channel = ctxChannel
-		}
-		if chatID == "" {
-			chatID = ctxChatID
-		}
-	}
+	// 频道/会话来自本次调用的 context，缺失时才使用 SetContext 设置的默认值
+	channel, chatID := t.fallback.resolve(ctx)
 
 	if channel == "" || chatID == "" {
 		return "", fmt.Errorf("no session context (channel/chat_id)")
@@ -176,7 +159,11 @@ func (t *CronTool) addJob(ctx context.Context, params map[string]interface{}) (s
 		}
 		runAt, err := parseCronAt(raw)
 		if err != nil {
-			return "", fmt.Errorf("invalid at format")
+			// 固定格式解析失败时按自然语言短语解析（"in 30 minutes"、"明天下午3点"）
+			runAt, err = parseNaturalTime(raw, time.Now())
+			if err != nil {
+				return "", fmt.Errorf("invalid at: %w", err)
+			}
 		}
 		if !runAt.After(time.Now()) {
 			return "", fmt.Errorf("at must be in the future")
@@ -230,20 +217,24 @@ func (t *CronTool) listJobs() (string, error) {
 		if !job.Enabled {
 			status = "disabled"
 		}
-		schedule := ""
-		switch job.Schedule.Type {
-		case cron.ScheduleTypeEvery:
-			schedule = fmt.Sprintf("every %d seconds", job.Schedule.EveryMs/1000)
-		case cron.ScheduleTypeCron:
-			schedule = fmt.Sprintf("cron: %s", job.Schedule.Expr)
-		case cron.ScheduleTypeOnce:
-			schedule = fmt.Sprintf("at: %s", time.UnixMilli(job.Schedule.AtMs).Format(time.RFC3339))
-		}
-		result += fmt.Sprintf("%d. %s (id: %s, %s, %s)\n", i+1, job.Name, job.ID, schedule, status)
+		result += fmt.Sprintf("%d. %s (id: %s, %s, %s)\n", i+1, job.Name, job.ID, describeSchedule(job.Schedule), status)
 	}
 	return result, nil
 }
 
+// describeSchedule 调度配置的简短描述
+func describeSchedule(schedule cron.Schedule) string {
+	switch schedule.Type {
+	case cron.ScheduleTypeEvery:
+		return fmt.Sprintf("every %d seconds", schedule.EveryMs/1000)
+	case cron.ScheduleTypeCron:
+		return fmt.Sprintf("cron: %s", schedule.Expr)
+	case cron.ScheduleTypeOnce:
+		return fmt.Sprintf("at: %s", time.UnixMilli(schedule.AtMs).Format(time.RFC3339))
+	}
+	return ""
+}
+
 func parseCronAt(raw string) (time.Time, error) {
 	raw = strings.TrimSpace(raw)
 	if raw == "" {
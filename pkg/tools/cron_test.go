@@ -50,8 +50,7 @@ func (m *MockCronService) ListJobs() []*cron.Job {
 func TestCronToolAdd(t *testing.T) {
 	mockService := NewMockCronService()
 	tool := NewCronTool(mockService)
-	tool.SetContext("telegram", "123456")
-	ctx := context.Background()
+	ctx := WithRuntimeContext(context.Background(), "telegram", "123456")
 
 	t.Run("add with every_seconds", func(t *testing.T) {
 		result, err := tool.Execute(ctx, map[string]interface{}{
@@ -115,6 +114,30 @@ func TestCronToolAdd(t *testing.T) {
 		assert.Contains(t, err.Error(), "invalid at")
 	})
 
+	t.Run("add with natural language at", func(t *testing.T) {
+		before := time.Now()
+		result, err := tool.Execute(ctx, map[string]interface{}{
+			"action":  "add",
+			"message": "Stretch",
+			"at":      "in 30 minutes",
+		})
+		require.NoError(t, err)
+		assert.Contains(t, result, "Created job")
+		require.NotNil(t, mockService.lastAdded)
+		assert.Equal(t, cron.ScheduleTypeOnce, mockService.lastAdded.Schedule.Type)
+		assert.InDelta(t, before.Add(30*time.Minute).UnixMilli(), mockService.lastAdded.Schedule.AtMs, float64(5*time.Second/time.Millisecond))
+	})
+
+	t.Run("ambiguous natural language at asks to clarify", func(t *testing.T) {
+		_, err := tool.Execute(ctx, map[string]interface{}{
+			"action":  "add",
+			"message": "Call mom",
+			"at":      "at 3",
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "am or pm")
+	})
+
 	t.Run("at in the past", func(t *testing.T) {
 		pastAt := time.Now().Add(-2 * time.Hour).Format("2006-01-02 15:04:05")
 		_, err := tool.Execute(ctx, map[string]interface{}{
@@ -146,7 +169,7 @@ func TestCronToolAdd(t *testing.T) {
 
 	t.Run("no context", func(t *testing.T) {
 		toolNoContext := NewCronTool(mockService)
-		_, err := toolNoContext.Execute(ctx, map[string]interface{}{
+		_, err := toolNoContext.Execute(context.Background(), map[string]interface{}{
 			"action":        "add",
 			"message":       "Test",
 			"every_seconds": 3600,
@@ -155,6 +178,27 @@ func TestCronToolAdd(t *testing.T) {
 		assert.Contains(t, err.Error(), "no session context")
 	})
 
+	t.Run("set context is only a fallback", func(t *testing.T) {
+		fallbackTool := NewCronTool(mockService)
+		fallbackTool.SetContext("discord", "fallback-chat")
+		_, err := fallbackTool.Execute(context.Background(), map[string]interface{}{
+			"action":        "add",
+			"message":       "Fallback add",
+			"every_seconds": 1800,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "fallback-chat", mockService.lastAdded.Payload.To)
+
+		_, err = fallbackTool.Execute(WithRuntimeContext(context.Background(), "whatsapp", "998877"), map[string]interface{}{
+			"action":        "add",
+			"message":       "Context add",
+			"every_seconds": 1800,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "998877", mockService.lastAdded.Payload.To)
+		assert.Equal(t, []string{"whatsapp"}, mockService.lastAdded.Payload.Channels)
+	})
+
 	t.Run("use runtime context", func(t *testing.T) {
 		toolNoContext := NewCronTool(mockService)
 		runtimeCtx := WithRuntimeContext(ctx, "whatsapp", "998877")
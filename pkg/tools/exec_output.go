@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// execOutputTTL 被截断的完整输出保留时长
+	execOutputTTL = 10 * time.Minute
+	// maxExecOutputEntries 每个会话同时保留的输出缓冲数量，超出时淘汰该会话最早的
+	maxExecOutputEntries = 32
+	// maxExecOutputTotalEntries 所有会话合计保留的输出缓冲数量上限
+	maxExecOutputTotalEntries = 128
+	// maxExecRetainedSize 每个输出流最多保留的字节数
+	maxExecRetainedSize = 1024 * 1024
+)
+
+// retainedOutput 一次命令某个输出流的完整内容
+type retainedOutput struct {
+	// owner 执行命令的会话，只有同一会话能续读
+	owner   string
+	data    string
+	total   int
+	expires time.Time
+}
+
+// execOutputStore 短时保存被截断的 exec 输出，供 exec_output 分页读取；ID 随机生成且按会话隔离
+type execOutputStore struct {
+	mu      sync.Mutex
+	entries map[string]*retainedOutput
+	order   []string
+	now     func() time.Time
+}
+
+func newExecOutputStore() *execOutputStore {
+	return &execOutputStore{
+		entries: make(map[string]*retainedOutput),
+		now:     time.Now,
+	}
+}
+
+// save 保存 owner 会话的输出（超过 maxExecRetainedSize 的部分丢弃）并返回其 ID
+func (s *execOutputStore) save(owner, output string) (string, *retainedOutput) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.pruneLocked(now)
+	for s.countLocked(owner) >= maxExecOutputEntries {
+		s.evictOldestLocked(owner)
+	}
+	for len(s.order) >= maxExecOutputTotalEntries {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+
+	id := newExecOutputID()
+	entry := &retainedOutput{
+		owner:   owner,
+		data:    output[:runeBoundary(output, maxExecRetainedSize)],
+		total:   len(output),
+		expires: now.Add(execOutputTTL),
+	}
+	s.entries[id] = entry
+	s.order = append(s.order, id)
+	return id, entry
+}
+
+// get 返回 owner 会话保存的输出，其他会话的 ID 视为不存在
+func (s *execOutputStore) get(owner, id string) (*retainedOutput, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(s.now())
+	entry, ok := s.entries[id]
+	if !ok || entry.owner != owner {
+		return nil, false
+	}
+	return entry, true
+}
+
+func (s *execOutputStore) countLocked(owner string) int {
+	n := 0
+	for _, id := range s.order {
+		if s.entries[id].owner == owner {
+			n++
+		}
+	}
+	return n
+}
+
+func (s *execOutputStore) evictOldestLocked(owner string) {
+	for i, id := range s.order {
+		if s.entries[id].owner == owner {
+			delete(s.entries, id)
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			return
+		}
+	}
+}
+
+// newExecOutputID 生成不可猜测的输出 ID
+func newExecOutputID() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("out%x", time.Now().UnixNano())
+	}
+	return "out" + hex.EncodeToString(buf)
+}
+
+func (s *execOutputStore) pruneLocked(now time.Time) {
+	kept := s.order[:0]
+	for _, id := range s.order {
+		if now.After(s.entries[id].expires) {
+			delete(s.entries, id)
+			continue
+		}
+		kept = append(kept, id)
+	}
+	s.order = kept
+}
+
+// page 返回从 offset 开始的一页输出，并在还有剩余时附上续读提示
+func (s *execOutputStore) page(id string, entry *retainedOutput, offset int) string {
+	end := offset + runeBoundary(entry.data[offset:], maxExecStreamSize)
+	var sb strings.Builder
+	sb.WriteString(entry.data[offset:end])
+	switch {
+	case end < len(entry.data):
+		fmt.Fprintf(&sb, "\n... (output truncated: showing bytes %d-%d of %d; call exec_output with token %q to read more)",
+			offset, end, entry.total, execOutputToken(id, end))
+	case entry.total > len(entry.data):
+		fmt.Fprintf(&sb, "\n... (end of retained output: the remaining %d bytes were not kept)", entry.total-len(entry.data))
+	}
+	return sb.String()
+}
+
+// pageExecStream 输出超过单页大小时为 owner 会话保存完整内容，返回第一页与续读 token；否则原样返回
+func (s *execOutputStore) pageExecStream(owner, output string) string {
+	output = strings.TrimRight(output, "\n")
+	if len(output) <= maxExecStreamSize {
+		return output
+	}
+	id, entry := s.save(owner, output)
+	return s.page(id, entry, 0)
+}
+
+func execOutputToken(id string, offset int) string {
+	return id + "@" + strconv.Itoa(offset)
+}
+
+func parseExecOutputToken(token string) (string, int, error) {
+	id, rawOffset, ok := strings.Cut(strings.TrimSpace(token), "@")
+	offset, err := strconv.Atoi(rawOffset)
+	if !ok || id == "" || err != nil || offset < 0 {
+		return "", 0, fmt.Errorf("invalid token %q: expected the value from an exec truncation notice, like \"out3f2a9c1d5e7b8a6c@10240\"", token)
+	}
+	return id, offset, nil
+}
+
+// runeBoundary 返回不超过 max 且不切断 UTF-8 字符的截断位置
+func runeBoundary(s string, max int) int {
+	if len(s) <= max {
+		return len(s)
+	}
+	end := max
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	if end == 0 {
+		return max
+	}
+	return end
+}
+
+// ExecOutputTool 分页读取 exec 被截断的输出
+type ExecOutputTool struct {
+	BaseTool
+	store *execOutputStore
+}
+
+// NewExecOutputTool 创建 exec 输出分页工具，与 exec 共享输出缓冲
+func NewExecOutputTool(exec *ExecTool) *ExecOutputTool {
+	return &ExecOutputTool{
+		BaseTool: BaseTool{
+			name: "exec_output",
+			description: fmt.Sprintf("Read the next chunk of a previous exec command's output that was truncated. "+
+				"Pass the token from the truncation notice; each call returns up to %d bytes and a new token while more remains. "+
+				"Output is kept for %d minutes, after which the command must be rerun.", maxExecStreamSize, int(execOutputTTL/time.Minute)),
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "Continuation token from the exec truncation notice, e.g. \"out3f2a9c1d5e7b8a6c@10240\"",
+					},
+				},
+				"required": []string{"token"},
+			},
+		},
+		store: exec.outputs,
+	}
+}
+
+// ConcurrencySafe 只读取已保存的输出，可与其他只读工具并发执行
+func (t *ExecOutputTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Repeatable 后台命令仍在输出时，同一 token 的结果会变化
+func (t *ExecOutputTool) Repeatable(params map[string]interface{}) bool {
+	return true
+}
+
+// Execute 返回 token 指向位置开始的一页输出
+func (t *ExecOutputTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	token, _ := params["token"].(string)
+	id, offset, err := parseExecOutputToken(token)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := t.store.get(RuntimeSessionKeyFrom(ctx), id)
+	if !ok {
+		return "", fmt.Errorf("output %s has expired or does not exist; rerun the command", id)
+	}
+	if offset >= len(entry.data) {
+		return "", fmt.Errorf("offset %d is past the end of the retained output (%d bytes)", offset, len(entry.data))
+	}
+	return t.store.page(id, entry, offset), nil
+}
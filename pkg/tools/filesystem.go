@@ -1,11 +1,17 @@
 package tools
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // allowedDir 全局允许的目录（用于沙箱）
@@ -102,6 +108,11 @@ func resolvePath(ctx context.Context, path string) (string, error) {
 			}
 			return absPath, nil
 		}
+
+		// 没有会话上下文时，相对路径基于工作区而不是进程当前目录
+		if root := strings.TrimSpace(workspaceDir); root != "" {
+			path = filepath.Join(root, path)
+		}
 	}
 
 	absPath, err := filepath.Abs(path)
@@ -181,6 +192,15 @@ func sanitizePathSegment(input string) string {
 	return b.String()
 }
 
+const (
+	// defaultReadFileMaxBytes read_file 默认最多读取的字节数
+	defaultReadFileMaxBytes = 256 * 1024
+	// maxReadFileMaxBytes max_bytes 参数允许的上限
+	maxReadFileMaxBytes = 4 * 1024 * 1024
+	// readTailChunkSize tail 模式从文件末尾每次向前读取的块大小
+	readTailChunkSize = 8 * 1024
+)
+
 // ReadFileTool 读取文件工具
 type ReadFileTool struct {
 	BaseTool
@@ -191,7 +211,7 @@ func NewReadFileTool() *ReadFileTool {
 	return &ReadFileTool{
 		BaseTool: BaseTool{
 			name:        "read_file",
-			description: "Read the contents of a file. Use for viewing code, logs, or any text file.",
+			description: "Read the contents of a file. Use for viewing code, logs, or any text file. Large files are truncated to max_bytes; use offset/limit for a line range or tail for the last lines.",
 			parameters: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -210,6 +230,18 @@ func NewReadFileTool() *ReadFileTool {
 						"description": "Line offset to start reading from (0-indexed, optional)",
 						"minimum":     0,
 					},
+					"tail": map[string]interface{}{
+						"type":        "integer",
+						"description": "Read only the last N lines, reading from the end of the file (optional, cannot be combined with offset/limit)",
+						"minimum":     1,
+						"maximum":     1000,
+					},
+					"max_bytes": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum number of bytes to return (optional, default %d)", defaultReadFileMaxBytes),
+						"minimum":     1,
+						"maximum":     maxReadFileMaxBytes,
+					},
 				},
 				"required": []string{"path"},
 			},
@@ -217,6 +249,11 @@ func NewReadFileTool() *ReadFileTool {
 	}
 }
 
+// ConcurrencySafe 只读取文件内容，不修改工作区，可以并发执行
+func (t *ReadFileTool) ConcurrencySafe() bool {
+	return true
+}
+
 // Execute 执行读取文件
 func (t *ReadFileTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
 	path, _ := params["path"].(string)
@@ -229,47 +266,385 @@ func (t *ReadFileTool) Execute(ctx context.Context, params map[string]interface{
 		return "", err
 	}
 
-	content, err := os.ReadFile(resolvedPath)
+	// 处理 offset / limit / tail / max_bytes (支持 float64 和 int)
+	offset, _ := intParam(params["offset"])
+	limit, _ := intParam(params["limit"])
+	tail, _ := intParam(params["tail"])
+	maxBytes := defaultReadFileMaxBytes
+	if v, ok := intParam(params["max_bytes"]); ok && v > 0 {
+		maxBytes = v
+	}
+	if maxBytes > maxReadFileMaxBytes {
+		maxBytes = maxReadFileMaxBytes
+	}
+	if tail > 0 && (offset > 0 || limit > 0) {
+		return "", fmt.Errorf("tail cannot be combined with offset or limit")
+	}
+
+	f, err := os.Open(resolvedPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file: %w", err)
 	}
+	defer f.Close()
 
-	// 处理 offset 和 limit (支持 float64 和 int)
-	offset := 0
-	if v, ok := params["offset"].(float64); ok {
-		offset = int(v)
-	} else if v, ok := params["offset"].(int); ok {
-		offset = v
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("failed to read file: %s is a directory", path)
 	}
 
-	limit := 0
-	if v, ok := params["limit"].(float64); ok {
-		limit = int(v)
-	} else if v, ok := params["limit"].(int); ok {
-		limit = v
+	switch {
+	case tail > 0:
+		return readFileTail(f, info.Size(), tail, maxBytes)
+	case offset > 0 || limit > 0:
+		return readFileLines(f, offset, limit, maxBytes)
+	default:
+		return readFileHead(f, info.Size(), maxBytes)
 	}
+}
 
-	lines := strings.Split(string(content), "\n")
+// readFileHead 读取整个文件；超过 maxBytes 时只返回开头部分并附带截断提示
+func readFileHead(f *os.File, size int64, maxBytes int) (string, error) {
+	content, err := io.ReadAll(io.LimitReader(f, int64(maxBytes)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if size <= int64(maxBytes) {
+		return string(content), nil
+	}
+	return fmt.Sprintf("%s\n\n... (file truncated: showing first %d of %d bytes; use offset/limit or tail to read more)", content, len(content), size), nil
+}
 
-	// 应用 offset
-	if offset > 0 {
-		if offset >= len(lines) {
-			return "", fmt.Errorf("offset exceeds file length")
+// readFileLines 按行流式读取 [offset, offset+limit)，不把整个文件读入内存
+func readFileLines(f *os.File, offset, limit, maxBytes int) (string, error) {
+	reader := bufio.NewReader(f)
+	var lines []string
+	size := 0
+	truncated := false
+
+	for lineNo := 0; ; lineNo++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		if lineNo >= offset {
+			line = strings.TrimSuffix(line, "\n")
+			remaining := maxBytes - size
+			if remaining <= 0 {
+				truncated = true
+				break
+			}
+			if len(line) > remaining {
+				// 在字符边界截断，不切断多字节字符
+				end := remaining
+				for end > 0 && !utf8.RuneStart(line[end]) {
+					end--
+				}
+				lines = append(lines, line[:end])
+				truncated = true
+				break
+			}
+			lines = append(lines, line)
+			size += len(line) + 1
+			if limit > 0 && len(lines) >= limit {
+				break
+			}
+		}
+		if err == io.EOF {
+			break
 		}
-		lines = lines[offset:]
 	}
 
-	// 应用 limit
-	if limit > 0 && limit < len(lines) {
-		lines = lines[:limit]
+	if offset > 0 && len(lines) == 0 {
+		return "", fmt.Errorf("offset exceeds file length")
+	}
+	result := strings.Join(lines, "\n")
+	if truncated {
+		result += fmt.Sprintf("\n\n... (output truncated at %d bytes)", maxBytes)
 	}
+	return result, nil
+}
+
+// readFileTail 从文件末尾向前分块读取，返回最后 n 行
+func readFileTail(f *os.File, size int64, n, maxBytes int) (string, error) {
+	var data []byte
+	pos := size
+	truncated := false
 
-	return strings.Join(lines, "\n"), nil
+	for pos > 0 {
+		chunk := int64(readTailChunkSize)
+		if chunk > pos {
+			chunk = pos
+		}
+		if remaining := int64(maxBytes - len(data)); chunk > remaining {
+			chunk = remaining
+		}
+		if chunk <= 0 {
+			truncated = true
+			break
+		}
+		pos -= chunk
+		buf := make([]byte, chunk)
+		if _, err := f.ReadAt(buf, pos); err != nil && err != io.EOF {
+			return "", fmt.Errorf("failed to read file: %w", err)
+		}
+		data = append(buf, data...)
+		// 末尾换行不算作一行，需要 n 个分隔符才能确定完整的 n 行
+		if bytes.Count(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")) >= n {
+			break
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if truncated && pos > 0 && len(lines) > 1 {
+		// 第一行可能被截断
+		lines = lines[1:]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	result := strings.Join(lines, "\n")
+	if truncated && len(lines) < n {
+		result = fmt.Sprintf("... (output truncated: showing last %d bytes)\n\n%s", len(data), result)
+	}
+	return result, nil
+}
+
+const (
+	// maxReadManyFiles 单次批量读取的最大文件数
+	maxReadManyFiles = 50
+	// maxReadManyBytes 批量读取结果的总输出上限
+	maxReadManyBytes = 100 * 1024
+)
+
+// ReadManyFilesTool 批量读取文件工具
+type ReadManyFilesTool struct {
+	BaseTool
+}
+
+// NewReadManyFilesTool 创建批量读取文件工具
+func NewReadManyFilesTool() *ReadManyFilesTool {
+	return &ReadManyFilesTool{
+		BaseTool: BaseTool{
+			name:        "read_many_files",
+			description: "Read several text files in one call, by explicit paths and/or a glob pattern. Each file is returned under a '=== path ===' header; unreadable or binary files are skipped with a note.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"paths": map[string]interface{}{
+						"type":        "array",
+						"description": "File paths to read (relative paths resolve to the current session directory)",
+						"items": map[string]interface{}{
+							"type": "string",
+						},
+					},
+					"glob": map[string]interface{}{
+						"type":        "string",
+						"description": "Glob pattern to match files, e.g. 'src/*.go' (optional)",
+					},
+					"include_ignored": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Keep glob matches covered by .gitignore/.nanobotignore (default: false)",
+					},
+				},
+			},
+		},
+	}
+}
+
+// ConcurrencySafe 逐个读取文件内容，不修改工作区，可以并发执行
+func (t *ReadManyFilesTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Execute 执行批量读取
+func (t *ReadManyFilesTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	paths := toStringSlice(params["paths"])
+	pattern, _ := params["glob"].(string)
+	pattern = strings.TrimSpace(pattern)
+	if len(paths) == 0 && pattern == "" {
+		return "", fmt.Errorf("paths or glob is required")
+	}
+
+	if pattern != "" {
+		resolvedPattern, err := resolvePath(ctx, pattern)
+		if err != nil {
+			return "", err
+		}
+		matches, err := filepath.Glob(resolvedPattern)
+		if err != nil {
+			return "", fmt.Errorf("invalid glob: %w", err)
+		}
+		if includeIgnored, _ := params["include_ignored"].(bool); !includeIgnored {
+			matches = filterIgnoredMatches(globBaseDir(resolvedPattern), matches)
+		}
+		paths = append(paths, matches...)
+	}
+	if len(paths) == 0 {
+		return "No files matched", nil
+	}
+
+	var result strings.Builder
+	seen := make(map[string]struct{}, len(paths))
+	for i, path := range paths {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		if i >= maxReadManyFiles {
+			fmt.Fprintf(&result, "... (%d more files not read, limit is %d)\n", len(paths)-i, maxReadManyFiles)
+			break
+		}
+
+		resolvedPath, err := resolvePath(ctx, path)
+		if err != nil {
+			fmt.Fprintf(&result, "=== %s ===\n[skipped: %v]\n\n", path, err)
+			continue
+		}
+		if _, dup := seen[resolvedPath]; dup {
+			continue
+		}
+		seen[resolvedPath] = struct{}{}
+
+		content, err := readTextFile(resolvedPath)
+		if err != nil {
+			fmt.Fprintf(&result, "=== %s ===\n[skipped: %v]\n\n", path, err)
+			continue
+		}
+
+		remaining := maxReadManyBytes - result.Len()
+		if remaining <= 0 {
+			fmt.Fprintf(&result, "... (output limit reached, %d files not read)\n", len(paths)-i)
+			break
+		}
+		fmt.Fprintf(&result, "=== %s ===\n", path)
+		if len(content) > remaining {
+			result.WriteString(content[:remaining])
+			result.WriteString("\n... (output truncated)\n")
+			break
+		}
+		result.WriteString(content)
+		result.WriteString("\n\n")
+	}
+
+	return strings.TrimRight(result.String(), "\n"), nil
+}
+
+// filterIgnoredMatches 去掉被 baseDir 下忽略规则覆盖的 glob 结果
+func filterIgnoredMatches(baseDir string, matches []string) []string {
+	ignore := loadIgnoreMatcher(baseDir)
+	kept := matches[:0]
+	for _, match := range matches {
+		rel, err := filepath.Rel(baseDir, match)
+		if err != nil {
+			kept = append(kept, match)
+			continue
+		}
+		info, err := os.Stat(match)
+		if ignore.MatchPath(rel, err == nil && info.IsDir()) {
+			continue
+		}
+		kept = append(kept, match)
+	}
+	return kept
+}
+
+// readTextFile 读取文本文件，目录和二进制文件返回错误
+func readTextFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("is a directory")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	sniff := content
+	if len(sniff) > 8000 {
+		sniff = sniff[:8000]
+	}
+	if bytes.IndexByte(sniff, 0) >= 0 || !utf8.Valid(content) {
+		return "", fmt.Errorf("binary file")
+	}
+	return string(content), nil
+}
+
+// ExtensionPolicy 限制写入类工具可写的文件扩展名；黑名单优先，白名单为空表示全部允许。
+// 条目不区分大小写，可省略前导点，支持 ".tar.gz" 这类多段后缀，"." 表示无扩展名的文件
+type ExtensionPolicy struct {
+	Allowed []string
+	Denied  []string
+}
+
+// Check 校验路径的扩展名是否允许写入
+func (p ExtensionPolicy) Check(path string) error {
+	if len(p.Allowed) == 0 && len(p.Denied) == 0 {
+		return nil
+	}
+	name := strings.ToLower(filepath.Base(path))
+	ext := filepath.Ext(name)
+	display := ext
+	if display == "" {
+		display = "(none)"
+	}
+
+	if entry, ok := matchExtension(name, ext, p.Denied); ok {
+		return fmt.Errorf("writing %q is not allowed: extension %s is denied (tools.files.deniedExtensions contains %q)", filepath.Base(path), display, entry)
+	}
+	if len(p.Allowed) > 0 {
+		if _, ok := matchExtension(name, ext, p.Allowed); !ok {
+			return fmt.Errorf("writing %q is not allowed: extension %s is not in tools.files.allowedExtensions (%s)", filepath.Base(path), display, strings.Join(p.Allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func matchExtension(name, ext string, entries []string) (string, bool) {
+	for _, entry := range entries {
+		normalized := strings.ToLower(strings.TrimSpace(entry))
+		if normalized == "" {
+			continue
+		}
+		if normalized == "." {
+			if ext == "" {
+				return entry, true
+			}
+			continue
+		}
+		if !strings.HasPrefix(normalized, ".") {
+			normalized = "." + normalized
+		}
+		if strings.HasSuffix(name, normalized) {
+			return entry, true
+		}
+	}
+	return "", false
 }
 
 // WriteFileTool 写入文件工具
 type WriteFileTool struct {
 	BaseTool
+	// Extensions 限制可写入的扩展名，零值表示不限制；运行期间通过 SetExtensions 更新
+	Extensions ExtensionPolicy
+	extMu      sync.RWMutex
+}
+
+// SetExtensions 更新扩展名策略，可在工具执行期间调用
+func (t *WriteFileTool) SetExtensions(policy ExtensionPolicy) {
+	t.extMu.Lock()
+	defer t.extMu.Unlock()
+	t.Extensions = policy
+}
+
+func (t *WriteFileTool) extensions() ExtensionPolicy {
+	t.extMu.RLock()
+	defer t.extMu.RUnlock()
+	return t.Extensions
 }
 
 // NewWriteFileTool 创建写入文件工具
@@ -309,6 +684,9 @@ func (t *WriteFileTool) Execute(ctx context.Context, params map[string]interface
 	if err != nil {
 		return "", err
 	}
+	if err := t.extensions().Check(resolvedPath); err != nil {
+		return "", err
+	}
 
 	// 确保目录存在
 	dir := filepath.Dir(resolvedPath)
@@ -326,6 +704,22 @@ func (t *WriteFileTool) Execute(ctx context.Context, params map[string]interface
 // EditFileTool 编辑文件工具（替换文本）
 type EditFileTool struct {
 	BaseTool
+	// Extensions 限制可修改的扩展名，零值表示不限制；运行期间通过 SetExtensions 更新
+	Extensions ExtensionPolicy
+	extMu      sync.RWMutex
+}
+
+// SetExtensions 更新扩展名策略，可在工具执行期间调用
+func (t *EditFileTool) SetExtensions(policy ExtensionPolicy) {
+	t.extMu.Lock()
+	defer t.extMu.Unlock()
+	t.Extensions = policy
+}
+
+func (t *EditFileTool) extensions() ExtensionPolicy {
+	t.extMu.RLock()
+	defer t.extMu.RUnlock()
+	return t.Extensions
 }
 
 // NewEditFileTool 创建编辑文件工具
@@ -370,6 +764,9 @@ func (t *EditFileTool) Execute(ctx context.Context, params map[string]interface{
 	if err != nil {
 		return "", err
 	}
+	if err := t.extensions().Check(resolvedPath); err != nil {
+		return "", err
+	}
 
 	content, err := os.ReadFile(resolvedPath)
 	if err != nil {
@@ -399,7 +796,7 @@ func NewListDirTool() *ListDirTool {
 	return &ListDirTool{
 		BaseTool: BaseTool{
 			name:        "list_dir",
-			description: "List files and directories in a given path. Use to explore directory structure.",
+			description: "List files and directories in a given path. Use to explore directory structure. Entries matched by .gitignore/.nanobotignore in that path are skipped unless include_ignored is true.",
 			parameters: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -411,6 +808,10 @@ func NewListDirTool() *ListDirTool {
 						"type":        "boolean",
 						"description": "Whether to list recursively (default: false)",
 					},
+					"include_ignored": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Also list entries matched by .gitignore/.nanobotignore and .git (default: false)",
+					},
 				},
 				"required": []string{"path"},
 			},
@@ -418,6 +819,11 @@ func NewListDirTool() *ListDirTool {
 	}
 }
 
+// ConcurrencySafe 只列出目录项，不修改工作区，可以并发执行
+func (t *ListDirTool) ConcurrencySafe() bool {
+	return true
+}
+
 // Execute 执行列出目录
 func (t *ListDirTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
 	path, _ := params["path"].(string)
@@ -451,8 +857,13 @@ func (t *ListDirTool) Execute(ctx context.Context, params map[string]interface{}
 		return "", fmt.Errorf("path is not a directory: %s", resolvedPath)
 	}
 
+	var ignore *ignoreMatcher
+	if includeIgnored, _ := params["include_ignored"].(bool); !includeIgnored {
+		ignore = loadIgnoreMatcher(resolvedPath)
+	}
+
 	var result strings.Builder
-	err = listDirRecursive(resolvedPath, "", recursive, &result)
+	err = listDirRecursive(ctx, resolvedPath, "", "", recursive, ignore, &result)
 	if err != nil {
 		return "", err
 	}
@@ -460,8 +871,11 @@ func (t *ListDirTool) Execute(ctx context.Context, params map[string]interface{}
 	return result.String(), nil
 }
 
-// listDirRecursive 递归列出目录
-func listDirRecursive(basePath, prefix string, recursive bool, result *strings.Builder) error {
+// listDirRecursive 递归列出目录；relDir 为相对列出根目录的路径，用于匹配忽略规则
+func listDirRecursive(ctx context.Context, basePath, relDir, prefix string, recursive bool, ignore *ignoreMatcher, result *strings.Builder) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	entries, err := os.ReadDir(basePath)
 	if err != nil {
 		return fmt.Errorf("failed to read directory: %w", err)
@@ -469,11 +883,17 @@ func listDirRecursive(basePath, prefix string, recursive bool, result *strings.B
 
 	for _, entry := range entries {
 		name := entry.Name()
+		relPath := filepath.Join(relDir, name)
+		if ignore.Match(relPath, entry.IsDir()) {
+			continue
+		}
 		if entry.IsDir() {
 			result.WriteString(fmt.Sprintf("%s[DIR]  %s/\n", prefix, name))
 			if recursive {
 				subPath := filepath.Join(basePath, name)
-				listDirRecursive(subPath, prefix+"  ", recursive, result)
+				if err := listDirRecursive(ctx, subPath, relPath, prefix+"  ", recursive, ignore, result); err != nil && ctx.Err() != nil {
+					return err
+				}
 			}
 		} else {
 			info, _ := entry.Info()
@@ -487,3 +907,97 @@ func listDirRecursive(basePath, prefix string, recursive bool, result *strings.B
 
 	return nil
 }
+
+// StatTool 文件元信息工具
+type StatTool struct {
+	BaseTool
+}
+
+// NewStatTool 创建文件元信息工具
+func NewStatTool() *StatTool {
+	return &StatTool{
+		BaseTool: BaseTool{
+			name:        "stat",
+			description: "Show metadata for a path (type file/dir/symlink, size, mode, modification time) without reading it. Symlinks report both the link and its target.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to inspect (relative paths resolve to the current session directory)",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+	}
+}
+
+// ConcurrencySafe 只查询文件元数据，可以并发执行
+func (t *StatTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Execute 执行 stat
+func (t *StatTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	path, _ := params["path"].(string)
+	resolvedPath, err := resolvePath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(resolvedPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("path not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to stat path: %w", err)
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "path: %s\n", resolvedPath)
+	writeStatInfo(&result, "", info)
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(resolvedPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read symlink: %w", err)
+		}
+		fmt.Fprintf(&result, "target: %s\n", target)
+
+		absTarget := target
+		if !filepath.IsAbs(absTarget) {
+			absTarget = filepath.Join(filepath.Dir(resolvedPath), target)
+		}
+		if err := isPathAllowed(absTarget); err != nil {
+			result.WriteString("target_status: outside allowed directory\n")
+		} else if targetInfo, err := os.Stat(resolvedPath); err != nil {
+			result.WriteString("target_status: broken link\n")
+		} else {
+			writeStatInfo(&result, "target_", targetInfo)
+		}
+	}
+
+	return strings.TrimRight(result.String(), "\n"), nil
+}
+
+func writeStatInfo(result *strings.Builder, prefix string, info os.FileInfo) {
+	fmt.Fprintf(result, "%stype: %s\n", prefix, statType(info))
+	fmt.Fprintf(result, "%ssize: %d bytes\n", prefix, info.Size())
+	fmt.Fprintf(result, "%smode: %s\n", prefix, info.Mode().String())
+	fmt.Fprintf(result, "%smodified: %s\n", prefix, info.ModTime().Format(time.RFC3339))
+}
+
+func statType(info os.FileInfo) string {
+	mode := info.Mode()
+	switch {
+	case mode&os.ModeSymlink != 0:
+		return "symlink"
+	case mode.IsDir():
+		return "dir"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHTTPGetTimeoutSec = 15
+	defaultHTTPGetMaxChars   = 8000
+	// httpGetMaxBodyBytes 最多读取的响应体字节数，超出部分直接丢弃
+	httpGetMaxBodyBytes = 1 << 20
+	httpGetMaxRedirects = 5
+)
+
+// HTTPGetEndpoint http_get 允许访问的 API
+type HTTPGetEndpoint struct {
+	Name    string
+	BaseURL string
+	// Headers 只会附加到发往该 BaseURL 的请求，通常用于 API Key
+	Headers map[string]string
+}
+
+type httpGetTarget struct {
+	HTTPGetEndpoint
+	scheme string
+	host   string
+	path   string
+}
+
+// HTTPGetTool 对白名单内的 API 发起 GET 请求，返回状态码与截断后的响应体
+type HTTPGetTool struct {
+	BaseTool
+	targets  []httpGetTarget
+	maxChars int
+	client   *http.Client
+}
+
+// NewHTTPGetTool 创建 http_get 工具；无效的 BaseURL 会被忽略（配置校验会提前报告）
+func NewHTTPGetTool(endpoints []HTTPGetEndpoint, timeoutSec, maxChars int) *HTTPGetTool {
+	if timeoutSec <= 0 {
+		timeoutSec = defaultHTTPGetTimeoutSec
+	}
+	if maxChars <= 0 {
+		maxChars = defaultHTTPGetMaxChars
+	}
+
+	var targets []httpGetTarget
+	for _, ep := range endpoints {
+		u, err := url.Parse(strings.TrimSpace(ep.BaseURL))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			continue
+		}
+		targets = append(targets, httpGetTarget{
+			HTTPGetEndpoint: ep,
+			scheme:          u.Scheme,
+			host:            strings.ToLower(u.Host),
+			path:            strings.TrimSuffix(cleanURLPath(u.Path), "/"),
+		})
+	}
+	// 优先匹配路径更长（更具体）的 base URL
+	sort.SliceStable(targets, func(i, j int) bool { return len(targets[i].path) > len(targets[j].path) })
+
+	allowed := make([]string, 0, len(targets))
+	for _, target := range targets {
+		allowed = append(allowed, fmt.Sprintf("%s (%s)", target.BaseURL, target.Name))
+	}
+
+	t := &HTTPGetTool{
+		BaseTool: BaseTool{
+			name: "http_get",
+			description: "Send an HTTP GET request to a configured API and return the status and (truncated) response body. " +
+				"Only URLs under these base URLs are allowed: " + strings.Join(allowed, ", ") + ".",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "Absolute URL under one of the allowed base URLs",
+					},
+					"params": map[string]interface{}{
+						"type":                 "object",
+						"description":          "Query parameters to add to the URL",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum body length to return (default: %d)", maxChars),
+						"minimum":     100,
+						"maximum":     50000,
+					},
+				},
+				"required": []string{"url"},
+			},
+		},
+		targets:  targets,
+		maxChars: maxChars,
+	}
+	t.client = &http.Client{
+		Timeout: time.Duration(timeoutSec) * time.Second,
+		// 重定向也必须留在同一个 endpoint 内，避免把凭据头带到白名单之外
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= httpGetMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", httpGetMaxRedirects)
+			}
+			target, ok := t.match(req.URL)
+			if !ok || target.Name != via[0].Context().Value(httpGetEndpointKey{}) {
+				return fmt.Errorf("redirect to %s is outside the allowed endpoint", req.URL.Redacted())
+			}
+			return nil
+		},
+	}
+	return t
+}
+
+type httpGetEndpointKey struct{}
+
+// ConcurrencySafe GET 请求只读，可与其他只读工具并发执行
+func (t *HTTPGetTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Execute 校验 URL 后发起 GET 请求
+func (t *HTTPGetTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	rawURL, _ := params["url"].(string)
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", fmt.Errorf("url is required")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+	if u.User != nil {
+		return "", fmt.Errorf("urls with credentials are not allowed")
+	}
+	target, ok := t.match(u)
+	if !ok {
+		return "", fmt.Errorf("url %s is not under an allowed base URL", u.Redacted())
+	}
+
+	if extra, ok := params["params"].(map[string]interface{}); ok && len(extra) > 0 {
+		query := u.Query()
+		for key, value := range extra {
+			query.Set(key, fmt.Sprint(value))
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	maxChars := t.maxChars
+	if v, ok := params["max_length"].(float64); ok && int(v) >= 100 && int(v) <= 50000 {
+		maxChars = int(v)
+	}
+
+	reqCtx := context.WithValue(ctx, httpGetEndpointKey{}, target.Name)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "maxclaw-http-get/1.0")
+	for key, value := range target.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, httpGetMaxBodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Status: %s\n", resp.Status)
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		fmt.Fprintf(&sb, "Content-Type: %s\n", contentType)
+	}
+	sb.WriteString("\n")
+	sb.WriteString(truncateText(string(body), maxChars))
+	return sb.String(), nil
+}
+
+// match 返回 URL 所属的 endpoint：scheme 与 host 完全一致，且清理后的路径位于 base 路径之下
+func (t *HTTPGetTool) match(u *url.URL) (httpGetTarget, bool) {
+	if u == nil {
+		return httpGetTarget{}, false
+	}
+	reqPath := cleanURLPath(u.Path)
+	for _, target := range t.targets {
+		if u.Scheme != target.scheme || strings.ToLower(u.Host) != target.host {
+			continue
+		}
+		if target.path == "" || reqPath == target.path || strings.HasPrefix(reqPath, target.path+"/") {
+			return target, true
+		}
+	}
+	return httpGetTarget{}, false
+}
+
+// cleanURLPath 归一化 URL 路径，消除 ".." 等片段，防止绕过 base 路径前缀
+func cleanURLPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
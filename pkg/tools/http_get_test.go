@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHTTPGetTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/weather", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			http.Error(w, "missing key", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"city":"` + r.URL.Query().Get("city") + `","temp":21}`))
+	})
+	mux.HandleFunc("/api/long", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 500)))
+	})
+	mux.HandleFunc("/api/escape", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/admin", http.StatusFound)
+	})
+	mux.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("admin key=" + r.Header.Get("X-API-Key")))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHTTPGetAllowedEndpoint(t *testing.T) {
+	server := newHTTPGetTestServer(t)
+	tool := NewHTTPGetTool([]HTTPGetEndpoint{{
+		Name:    "weather",
+		BaseURL: server.URL + "/api/",
+		Headers: map[string]string{"X-API-Key": "secret"},
+	}}, 5, 0)
+	assert.True(t, tool.ConcurrencySafe())
+	assert.Contains(t, tool.Description(), server.URL+"/api/")
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"url":    server.URL + "/api/weather",
+		"params": map[string]interface{}{"city": "Paris"},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Status: 200 OK")
+	assert.Contains(t, result, "Content-Type: application/json")
+	assert.Contains(t, result, `{"city":"Paris","temp":21}`)
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{
+		"url":        server.URL + "/api/long",
+		"max_length": float64(100),
+	})
+	require.NoError(t, err)
+	assert.NotContains(t, result, strings.Repeat("x", 101))
+	assert.Contains(t, result, "content truncated")
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/api/missing"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Status: 404")
+}
+
+func TestHTTPGetBlocksHostsOutsideAllowlist(t *testing.T) {
+	server := newHTTPGetTestServer(t)
+	other := newHTTPGetTestServer(t)
+	tool := NewHTTPGetTool([]HTTPGetEndpoint{{
+		Name:    "weather",
+		BaseURL: server.URL + "/api",
+		Headers: map[string]string{"X-API-Key": "secret"},
+	}}, 5, 0)
+
+	blocked := []string{
+		other.URL + "/api/weather",
+		server.URL + "/admin",
+		server.URL + "/api/../admin",
+		server.URL + "/apiv2/weather",
+		strings.Replace(server.URL, "http://", "http://user:pass@", 1) + "/api/weather",
+		"file:///etc/passwd",
+	}
+	for _, target := range blocked {
+		_, err := tool.Execute(context.Background(), map[string]interface{}{"url": target})
+		assert.Error(t, err, target)
+	}
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/api/escape"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside the allowed endpoint")
+}
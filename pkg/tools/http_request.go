@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+)
+
+// HTTPRequestTool 发送 GET 以外的 HTTP 请求（调用需要请求体或鉴权头的 API），
+// 复用 web_fetch 的内网防护、超时、重定向上限与结果截断
+type HTTPRequestTool struct {
+	BaseTool
+	fetch *WebFetchTool
+}
+
+// NewHTTPRequestTool 创建 HTTP 请求工具
+func NewHTTPRequestTool(fetch *WebFetchTool) *HTTPRequestTool {
+	return &HTTPRequestTool{
+		BaseTool: BaseTool{
+			name:        "http_request",
+			description: "Send an HTTP request with a method, headers and body, e.g. to call a JSON API. Returns the response body of any 2xx response. Use web_fetch to read web pages.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"url": map[string]interface{}{
+						"type":        "string",
+						"description": "URL to send the request to",
+					},
+					"method": map[string]interface{}{
+						"type":        "string",
+						"enum":        webFetchMethods,
+						"description": "HTTP method",
+					},
+					"headers": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Extra request headers, e.g. {\"Authorization\": \"Bearer ...\"}; User-Agent overrides the configured one",
+					},
+					"body": map[string]interface{}{
+						"type":        "string",
+						"description": "Request body for POST/PUT/PATCH/DELETE. Content-Type defaults to application/json when the body is valid JSON.",
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": "Maximum response length to return (default: 10000)",
+						"minimum":     100,
+						"maximum":     50000,
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Request timeout in seconds (default from config)",
+						"minimum":     1,
+						"maximum":     180,
+					},
+				},
+				"required": []string{"url", "method"},
+			},
+		},
+		fetch: fetch,
+	}
+}
+
+// ConcurrencySafe POST/PUT/DELETE 等请求会修改远端状态，必须按模型给出的顺序逐个执行
+func (t *HTTPRequestTool) ConcurrencySafe() bool {
+	return false
+}
+
+// Repeatable 相同参数的请求可能是有意重复（如连续创建两条记录），不按死循环拦截
+func (t *HTTPRequestTool) Repeatable(params map[string]interface{}) bool {
+	return true
+}
+
+// Execute 发送请求并返回截断后的响应体
+func (t *HTTPRequestTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	request, err := parseWebFetchRequest(params)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := params["method"].(string); !ok {
+		return "", fmt.Errorf("method is required")
+	}
+	request.plain = true
+	return t.fetch.fetch(ctx, params, request)
+}
@@ -0,0 +1,167 @@
+package tools
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreFileNames 按顺序读取的忽略文件，后读取的规则优先级更高
+var ignoreFileNames = []string{".gitignore", ".nanobotignore"}
+
+// defaultIgnorePatterns 没有忽略文件时也跳过的目录
+var defaultIgnorePatterns = []string{".git/"}
+
+// ignoreRule 一条 gitignore 风格的规则
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// ignoreMatcher 基于 baseDir 下忽略文件的 gitignore 风格匹配器
+type ignoreMatcher struct {
+	rules []ignoreRule
+}
+
+// loadIgnoreMatcher 读取 baseDir 下的 .gitignore / .nanobotignore
+func loadIgnoreMatcher(baseDir string) *ignoreMatcher {
+	m := &ignoreMatcher{}
+	for _, pattern := range defaultIgnorePatterns {
+		m.addPattern(pattern)
+	}
+	for _, name := range ignoreFileNames {
+		f, err := os.Open(filepath.Join(baseDir, name))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			m.addPattern(scanner.Text())
+		}
+		f.Close()
+	}
+	return m
+}
+
+func (m *ignoreMatcher) addPattern(line string) {
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+
+	rule := ignoreRule{}
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return
+	}
+
+	// 含有 / 的模式相对 baseDir 锚定，否则匹配任意层级
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return
+	}
+	rule.re = re
+	m.rules = append(m.rules, rule)
+}
+
+// Match 判断相对 baseDir 的路径是否被忽略（最后一条匹配的规则生效）
+func (m *ignoreMatcher) Match(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	ignored := false
+	for _, rule := range m.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(relPath) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp 把 gitignore glob 转成正则（支持 *、?、**、[...]）
+func globToRegexp(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// **/ 匹配零或多级目录
+					i++
+					b.WriteString("(?:.*/)?")
+				} else {
+					b.WriteString(".*")
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				b.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// MatchPath 判断路径本身或其任一上级目录是否被忽略
+func (m *ignoreMatcher) MatchPath(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for i := 1; i < len(parts); i++ {
+		if m.Match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.Match(relPath, isDir)
+}
+
+// globBaseDir 返回 glob 模式中不含通配符的目录前缀
+func globBaseDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// defaultReadImageMaxBytes read_image 默认允许的最大图片大小（多数视觉模型的单图上限）
+const defaultReadImageMaxBytes = 5 * 1024 * 1024
+
+// ReadImageTool 读取工作区内的图片并返回 base64 data URL，供视觉模型通过多模态消息查看
+type ReadImageTool struct {
+	BaseTool
+	// MaxBytes 允许读取的最大文件字节数，<=0 使用默认值
+	MaxBytes int64
+}
+
+// NewReadImageTool 创建读取图片工具
+func NewReadImageTool() *ReadImageTool {
+	return &ReadImageTool{
+		BaseTool: BaseTool{
+			name:        "read_image",
+			description: fmt.Sprintf("Load an image file (PNG, JPEG, GIF, WebP, BMP) so you can look at it. The image is attached to the conversation for vision-capable models. Use read_file for text files. Files larger than %d bytes are rejected.", defaultReadImageMaxBytes),
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Relative path to the image (e.g., 'screenshot.png'). Automatically resolves to the current session directory.",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+	}
+}
+
+// ConcurrencySafe 读取图片并编码为 data URL，不写文件，可以并发执行
+func (t *ReadImageTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Execute 读取图片，按文件内容（而非扩展名）识别 MIME 类型
+func (t *ReadImageTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	path, _ := params["path"].(string)
+	resolvedPath, err := resolvePath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	maxBytes := t.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultReadImageMaxBytes
+	}
+
+	f, err := os.Open(resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("failed to read image: %s is a directory", path)
+	}
+	if info.Size() > maxBytes {
+		return "", fmt.Errorf("image %s is %d bytes, exceeding the %d byte limit", path, info.Size(), maxBytes)
+	}
+
+	// 多读 1 字节，防止文件在 Stat 之后变大
+	data, err := io.ReadAll(io.LimitReader(f, maxBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > maxBytes {
+		return "", fmt.Errorf("image %s exceeds the %d byte limit", path, maxBytes)
+	}
+
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("%s is not a supported image (detected %s)", path, mimeType)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ParseImageDataURL 判断工具结果是否为 read_image 返回的图片 data URL，返回 MIME 类型与解码后的字节数
+func ParseImageDataURL(value string) (mimeType string, size int, ok bool) {
+	if !strings.HasPrefix(value, "data:image/") {
+		return "", 0, false
+	}
+	meta, payload, found := strings.Cut(strings.TrimPrefix(value, "data:"), ",")
+	if !found || !strings.HasSuffix(meta, ";base64") {
+		return "", 0, false
+	}
+	// 被结果上限截断的 data URL 末尾带有截断提示，不是合法的 base64
+	if !isBase64Payload(payload) {
+		return "", 0, false
+	}
+	padding := len(payload) - len(strings.TrimRight(payload, "="))
+	return strings.TrimSuffix(meta, ";base64"), len(payload)/4*3 - padding, true
+}
+
+// isBase64Payload 判断 payload 是否为完整的标准 base64（只含字母表字符，长度为 4 的倍数，= 只出现在末尾）
+func isBase64Payload(payload string) bool {
+	if payload == "" || len(payload)%4 != 0 {
+		return false
+	}
+	data := strings.TrimRight(payload, "=")
+	if len(payload)-len(data) > 2 {
+		return false
+	}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if !(c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestPNG(t *testing.T, path string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0644))
+	return buf.Bytes()
+}
+
+func TestReadImageTool(t *testing.T) {
+	tmpDir := t.TempDir()
+	SetAllowedDir(tmpDir)
+	SetWorkspaceDir(tmpDir)
+	t.Cleanup(func() {
+		SetAllowedDir("")
+		SetWorkspaceDir("")
+	})
+
+	// 扩展名不影响识别，按内容判断
+	data := writeTestPNG(t, filepath.Join(tmpDir, "pixel.bin"))
+	tool := NewReadImageTool()
+	ctx := context.Background()
+
+	t.Run("small png", func(t *testing.T) {
+		result, err := tool.Execute(ctx, map[string]interface{}{"path": "pixel.bin"})
+		require.NoError(t, err)
+		assert.Equal(t, "data:image/png;base64,"+base64.StdEncoding.EncodeToString(data), result)
+
+		mimeType, size, ok := ParseImageDataURL(result)
+		require.True(t, ok)
+		assert.Equal(t, "image/png", mimeType)
+		assert.Equal(t, len(data), size)
+	})
+
+	t.Run("oversized file", func(t *testing.T) {
+		limited := NewReadImageTool()
+		limited.MaxBytes = int64(len(data) - 1)
+		_, err := limited.Execute(ctx, map[string]interface{}{"path": "pixel.bin"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "exceeding the")
+	})
+
+	t.Run("not an image", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "fake.png"), []byte("just some text"), 0644))
+		_, err := tool.Execute(ctx, map[string]interface{}{"path": "fake.png"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not a supported image")
+	})
+
+	t.Run("outside sandbox", func(t *testing.T) {
+		outside := filepath.Join(t.TempDir(), "outside.png")
+		writeTestPNG(t, outside)
+		_, err := tool.Execute(ctx, map[string]interface{}{"path": outside})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "outside of allowed directory")
+	})
+}
+
+func TestParseImageDataURLRejectsOtherResults(t *testing.T) {
+	for _, value := range []string{
+		"plain text",
+		"data:text/plain;base64,aGk=",
+		"data:image/png,raw",
+		strings.Repeat("x", 10),
+		// 被结果上限截断的图片
+		truncateText("data:image/png;base64,"+strings.Repeat("iVBORw0K", 20), 60),
+		"data:image/png;base64,aGk",
+	} {
+		_, _, ok := ParseImageDataURL(value)
+		assert.False(t, ok, value)
+	}
+}
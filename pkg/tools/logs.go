@@ -0,0 +1,201 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultReadLogsLines = 100
+	maxReadLogsLines     = 1000
+	// readLogsTailBytes 只读取文件末尾这么多字节来取尾部行，避免大日志整体读入内存
+	readLogsTailBytes = 512 * 1024
+)
+
+// defaultReadLogsChannels 未配置时只允许本地管理入口调用
+var defaultReadLogsChannels = []string{"cli", "webui", "desktop"}
+
+// logSecretPatterns 返回日志前先隐藏常见凭据
+var logSecretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}[REDACTED]"},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{8,}`), "sk-[REDACTED]"},
+	{regexp.MustCompile(`\bxox[abpr]-[A-Za-z0-9-]+`), "xox-[REDACTED]"},
+	{regexp.MustCompile(`\b\d{6,}:[A-Za-z0-9_-]{30,}`), "[REDACTED_BOT_TOKEN]"},
+	{regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?token|token|secret|password)["']?\s*[:=]\s*["']?)[^\s"'&,}]+`), "${1}[REDACTED]"},
+}
+
+// ReadLogsTool 读取数据目录 logs/ 下日志的尾部（需显式开启，且只允许指定频道调用）
+type ReadLogsTool struct {
+	BaseTool
+	logsDir       string
+	allowChannels map[string]struct{}
+}
+
+// NewReadLogsTool 创建日志读取工具；allowChannels 为空时只允许 cli/webui/desktop
+func NewReadLogsTool(logsDir string, allowChannels []string) *ReadLogsTool {
+	if len(allowChannels) == 0 {
+		allowChannels = defaultReadLogsChannels
+	}
+	allowed := make(map[string]struct{}, len(allowChannels))
+	for _, ch := range allowChannels {
+		if ch = strings.TrimSpace(ch); ch != "" {
+			allowed[ch] = struct{}{}
+		}
+	}
+
+	return &ReadLogsTool{
+		BaseTool: BaseTool{
+			name:        "read_logs",
+			description: "Read the tail of maxclaw's own log files for self-diagnosis. Call without name to list available logs. Secrets are redacted.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name": map[string]interface{}{
+						"type":        "string",
+						"description": "Log name, e.g. gateway, session, tools, channels, cron, webui (omit to list logs)",
+					},
+					"lines": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Number of trailing lines to return (default %d)", defaultReadLogsLines),
+						"minimum":     1,
+						"maximum":     maxReadLogsLines,
+					},
+				},
+			},
+		},
+		logsDir:       logsDir,
+		allowChannels: allowed,
+	}
+}
+
+// ConcurrencySafe 只读取日志文件尾部，不修改日志，可以并发执行
+func (t *ReadLogsTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Repeatable 日志持续追加，重复读取用于观察新内容
+func (t *ReadLogsTool) Repeatable(params map[string]interface{}) bool {
+	return true
+}
+
+// Execute 读取日志尾部
+func (t *ReadLogsTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	channel, _ := RuntimeContextFrom(ctx)
+	if _, ok := t.allowChannels[channel]; !ok {
+		return "", fmt.Errorf("read_logs is not allowed from channel %q", channel)
+	}
+
+	name, _ := params["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return t.listLogs()
+	}
+
+	fileName := strings.TrimSuffix(name, ".log") + ".log"
+	if filepath.Base(fileName) != fileName || strings.HasPrefix(fileName, ".") {
+		return "", fmt.Errorf("invalid log name: %s", name)
+	}
+
+	lines := defaultReadLogsLines
+	if v, ok := params["lines"].(float64); ok && int(v) > 0 {
+		lines = int(v)
+	}
+	if lines > maxReadLogsLines {
+		lines = maxReadLogsLines
+	}
+
+	tail, err := tailFileLines(filepath.Join(t.logsDir, fileName), lines)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("log not found: %s", name)
+		}
+		return "", fmt.Errorf("failed to read log: %w", err)
+	}
+	if len(tail) == 0 {
+		return fmt.Sprintf("%s is empty", fileName), nil
+	}
+
+	return redactLogText(strings.Join(tail, "\n")), nil
+}
+
+func (t *ReadLogsTool) listLogs() (string, error) {
+	entries, err := os.ReadDir(t.logsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "No logs found", nil
+		}
+		return "", fmt.Errorf("failed to list logs: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		names = append(names, fmt.Sprintf("%s (%d bytes)", strings.TrimSuffix(entry.Name(), ".log"), info.Size()))
+	}
+	if len(names) == 0 {
+		return "No logs found", nil
+	}
+	sort.Strings(names)
+	return "Available logs:\n" + strings.Join(names, "\n"), nil
+}
+
+// tailFileLines 返回文件最后 n 行
+func tailFileLines(path string, n int) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	offset := info.Size() - readLogsTailBytes
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if offset > 0 && len(lines) > 0 {
+		// 第一行可能被截断
+		lines = lines[1:]
+	}
+	if len(lines) == 1 && lines[0] == "" {
+		return nil, nil
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+func redactLogText(text string) string {
+	for _, p := range logSecretPatterns {
+		text = p.re.ReplaceAllString(text, p.repl)
+	}
+	return text
+}
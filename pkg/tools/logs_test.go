@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestLog(t *testing.T, dir, name string, lines int) {
+	t.Helper()
+	var b strings.Builder
+	for i := 1; i <= lines; i++ {
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(b.String()), 0644))
+}
+
+func TestReadLogsToolReturnsTail(t *testing.T) {
+	dir := t.TempDir()
+	writeTestLog(t, dir, "gateway.log", 50)
+	tool := NewReadLogsTool(dir, nil)
+	ctx := WithRuntimeContext(context.Background(), "cli", "direct")
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"name": "gateway", "lines": float64(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "line 48\nline 49\nline 50", result)
+
+	// 默认行数大于文件行数时返回全部内容
+	result, err = tool.Execute(ctx, map[string]interface{}{"name": "gateway.log"})
+	require.NoError(t, err)
+	assert.Equal(t, 50, strings.Count(result, "\n")+1)
+	assert.True(t, strings.HasPrefix(result, "line 1\n"))
+
+	listing, err := tool.Execute(ctx, map[string]interface{}{})
+	require.NoError(t, err)
+	assert.Contains(t, listing, "gateway (")
+}
+
+func TestReadLogsToolRedactsSecrets(t *testing.T) {
+	dir := t.TempDir()
+	content := "request Authorization: Bearer abc.def.ghi\nprovider key sk-live-1234567890abcdef\nconfig apiKey=supersecret value\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tools.log"), []byte(content), 0644))
+	tool := NewReadLogsTool(dir, nil)
+
+	result, err := tool.Execute(WithRuntimeContext(context.Background(), "webui", "x"), map[string]interface{}{"name": "tools"})
+	require.NoError(t, err)
+	assert.NotContains(t, result, "abc.def.ghi")
+	assert.NotContains(t, result, "sk-live-1234567890abcdef")
+	assert.NotContains(t, result, "supersecret")
+	assert.Contains(t, result, "[REDACTED]")
+}
+
+func TestReadLogsToolRejectsUnauthorizedChannelsAndPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestLog(t, dir, "gateway.log", 5)
+	tool := NewReadLogsTool(dir, nil)
+
+	_, err := tool.Execute(WithRuntimeContext(context.Background(), "telegram", "123"), map[string]interface{}{"name": "gateway"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not allowed")
+
+	ctx := WithRuntimeContext(context.Background(), "cli", "direct")
+	_, err = tool.Execute(ctx, map[string]interface{}{"name": "../config.json"})
+	assert.Error(t, err)
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"name": "missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	custom := NewReadLogsTool(dir, []string{"telegram"})
+	_, err = custom.Execute(WithRuntimeContext(context.Background(), "telegram", "123"), map[string]interface{}{"name": "gateway"})
+	assert.NoError(t, err)
+}
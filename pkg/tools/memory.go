@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMemorySection  = "Important Notes"
+	memoryFileName        = "MEMORY.md"
+	maxMemorySearchResult = 50
+	// memoryFileTemplate 与 memory.Store 的默认模板保持一致
+	memoryFileTemplate = "# Long-term Memory\n\nThis file stores important information that should persist across sessions.\n"
+)
+
+// memoryTimeNow 便于测试替换
+var memoryTimeNow = time.Now
+
+// MemoryTool 读写工作区 memory/ 下的长期记忆（只能访问该目录内的 .md 文件）
+type MemoryTool struct {
+	BaseTool
+	memoryDir string
+	mu        sync.Mutex
+}
+
+// NewMemoryTool 创建长期记忆工具
+func NewMemoryTool(workspace string) *MemoryTool {
+	return &MemoryTool{
+		BaseTool: BaseTool{
+			name:        "memory",
+			description: "Manage long-term memory in memory/MEMORY.md. Use action=append to save a durable fact under a section (timestamped), action=list to show sections and entries, action=search to find entries across memory/*.md.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"append", "list", "search"},
+						"description": "Operation to perform",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "Entry text to append (for append)",
+					},
+					"section": map[string]interface{}{
+						"type":        "string",
+						"description": fmt.Sprintf("Section heading to append to or list (default %q for append)", defaultMemorySection),
+					},
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Case-insensitive text to search for (for search)",
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		memoryDir: filepath.Join(workspace, "memory"),
+	}
+}
+
+// Execute 执行记忆操作
+func (t *MemoryTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	action, _ := params["action"].(string)
+	section, _ := params["section"].(string)
+	section = normalizeMemorySection(section)
+
+	switch strings.TrimSpace(action) {
+	case "append":
+		content, _ := params["content"].(string)
+		return t.appendEntry(section, content)
+	case "list":
+		return t.list(section)
+	case "search":
+		query, _ := params["query"].(string)
+		return t.search(query)
+	default:
+		return "", fmt.Errorf("unknown action %q (expected append, list or search)", action)
+	}
+}
+
+func (t *MemoryTool) memoryPath() string {
+	return filepath.Join(t.memoryDir, memoryFileName)
+}
+
+// appendEntry 在指定段落末尾追加带时间戳的条目，文件或段落不存在时自动创建
+func (t *MemoryTool) appendEntry(section, content string) (string, error) {
+	content = strings.Join(strings.Fields(content), " ")
+	if content == "" {
+		return "", fmt.Errorf("content is required for append")
+	}
+	if section == "" {
+		section = defaultMemorySection
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(t.memoryDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	data, err := os.ReadFile(t.memoryPath())
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", fmt.Errorf("failed to read memory: %w", err)
+		}
+		data = []byte(memoryFileTemplate)
+	}
+
+	entry := fmt.Sprintf("- [%s] %s", memoryTimeNow().Format("2006-01-02 15:04"), content)
+	updated := insertMemoryEntry(string(data), section, entry)
+	if err := os.WriteFile(t.memoryPath(), []byte(updated), 0644); err != nil {
+		return "", fmt.Errorf("failed to write memory: %w", err)
+	}
+	return fmt.Sprintf("Appended to %s under ## %s: %s", memoryFileName, section, entry), nil
+}
+
+// insertMemoryEntry 把条目插入到段落最后一个非空行之后
+func insertMemoryEntry(doc, section, entry string) string {
+	lines := strings.Split(strings.TrimRight(doc, "\n"), "\n")
+	start := -1
+	for i, line := range lines {
+		if heading, ok := memoryHeading(line); ok && strings.EqualFold(heading, section) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		lines = append(lines, "", "## "+section, "", entry)
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	end := len(lines)
+	for i := start + 1; i < len(lines); i++ {
+		if isMemorySectionBoundary(lines[i]) {
+			end = i
+			break
+		}
+	}
+	insertAt := end
+	for insertAt > start+1 && strings.TrimSpace(lines[insertAt-1]) == "" {
+		insertAt--
+	}
+
+	block := []string{entry}
+	if insertAt == start+1 {
+		// 空段落：标题与条目之间保留一个空行
+		block = []string{"", entry}
+	}
+	if insertAt < len(lines) && strings.TrimSpace(lines[insertAt]) != "" {
+		block = append(block, "")
+	}
+
+	out := make([]string, 0, len(lines)+len(block))
+	out = append(out, lines[:insertAt]...)
+	out = append(out, block...)
+	out = append(out, lines[insertAt:]...)
+	return strings.Join(out, "\n") + "\n"
+}
+
+// list 列出段落及条目；指定 section 时只输出该段落内容
+func (t *MemoryTool) list(section string) (string, error) {
+	data, err := os.ReadFile(t.memoryPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "Memory is empty (memory/MEMORY.md does not exist yet).", nil
+		}
+		return "", fmt.Errorf("failed to read memory: %w", err)
+	}
+
+	var (
+		b       strings.Builder
+		current string
+		found   bool
+	)
+	for _, line := range strings.Split(string(data), "\n") {
+		if heading, ok := memoryHeading(line); ok {
+			current = heading
+			if section == "" || strings.EqualFold(heading, section) {
+				found = true
+				b.WriteString("## " + heading + "\n")
+			}
+			continue
+		}
+		if isMemorySectionBoundary(line) {
+			current = ""
+			continue
+		}
+		if current == "" || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if section == "" || strings.EqualFold(current, section) {
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if section != "" && !found {
+		return fmt.Sprintf("Section %q not found in %s", section, memoryFileName), nil
+	}
+	if b.Len() == 0 {
+		return fmt.Sprintf("No sections in %s", memoryFileName), nil
+	}
+	return strings.TrimRight(b.String(), "\n"), nil
+}
+
+// search 在 memory/*.md 中按行做不区分大小写的匹配
+func (t *MemoryTool) search(query string) (string, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", fmt.Errorf("query is required for search")
+	}
+
+	files, err := filepath.Glob(filepath.Join(t.memoryDir, "*.md"))
+	if err != nil {
+		return "", fmt.Errorf("failed to list memory files: %w", err)
+	}
+	sort.Strings(files)
+
+	needle := strings.ToLower(query)
+	var matches []string
+	total := 0
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		name := filepath.Base(path)
+		section := ""
+		for i, line := range strings.Split(string(data), "\n") {
+			if heading, ok := memoryHeading(line); ok {
+				section = heading
+			} else if isMemorySectionBoundary(line) {
+				section = ""
+			}
+			if !strings.Contains(strings.ToLower(line), needle) {
+				continue
+			}
+			total++
+			if len(matches) >= maxMemorySearchResult {
+				continue
+			}
+			location := fmt.Sprintf("%s:%d", name, i+1)
+			if section != "" {
+				location += " [" + section + "]"
+			}
+			matches = append(matches, location+": "+strings.TrimSpace(line))
+		}
+	}
+
+	if total == 0 {
+		return fmt.Sprintf("No memory entries match %q", query), nil
+	}
+	result := strings.Join(matches, "\n")
+	if total > len(matches) {
+		result += fmt.Sprintf("\n... (%d more matches omitted)", total-len(matches))
+	}
+	return result, nil
+}
+
+// memoryHeading 识别二级标题 "## xxx"
+func memoryHeading(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "## ") {
+		return "", false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(trimmed, "## ")), true
+}
+
+// isMemorySectionBoundary 一级或二级标题都会结束当前段落
+func isMemorySectionBoundary(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "# ") || strings.HasPrefix(trimmed, "## ")
+}
+
+func normalizeMemorySection(section string) string {
+	section = strings.TrimSpace(section)
+	section = strings.TrimLeft(section, "#")
+	return strings.Join(strings.Fields(section), " ")
+}
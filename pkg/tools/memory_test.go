@@ -0,0 +1,89 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryToolAppendCreatesFileAndSections(t *testing.T) {
+	origNow := memoryTimeNow
+	memoryTimeNow = func() time.Time { return time.Date(2026, 3, 4, 9, 30, 0, 0, time.Local) }
+	defer func() { memoryTimeNow = origNow }()
+
+	workspace := t.TempDir()
+	tool := NewMemoryTool(workspace)
+	ctx := context.Background()
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"action": "append", "content": "User prefers  concise\nanswers"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "## Important Notes")
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "append", "section": "## Preferences", "content": "Timezone is UTC+8"})
+	require.NoError(t, err)
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "append", "section": "important notes", "content": "Project codename is falcon"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(workspace, "memory", "MEMORY.md"))
+	require.NoError(t, err)
+	assert.Equal(t, "# Long-term Memory\n\n"+
+		"This file stores important information that should persist across sessions.\n\n"+
+		"## Important Notes\n\n"+
+		"- [2026-03-04 09:30] User prefers concise answers\n"+
+		"- [2026-03-04 09:30] Project codename is falcon\n\n"+
+		"## Preferences\n\n"+
+		"- [2026-03-04 09:30] Timezone is UTC+8\n", string(data))
+
+	listed, err := tool.Execute(ctx, map[string]interface{}{"action": "list", "section": "Preferences"})
+	require.NoError(t, err)
+	assert.Equal(t, "## Preferences\n- [2026-03-04 09:30] Timezone is UTC+8", listed)
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "append", "content": "   "})
+	assert.Error(t, err)
+}
+
+func TestMemoryToolAppendKeepsExistingLayout(t *testing.T) {
+	workspace := t.TempDir()
+	memoryDir := filepath.Join(workspace, "memory")
+	require.NoError(t, os.MkdirAll(memoryDir, 0755))
+	original := "# Long-term Memory\n\n## User Information\n\n(Important facts about the user)\n\n## Preferences\n\n- likes tea\n"
+	require.NoError(t, os.WriteFile(filepath.Join(memoryDir, "MEMORY.md"), []byte(original), 0644))
+
+	tool := NewMemoryTool(workspace)
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"action": "append", "section": "User Information", "content": "Name is Ada"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(memoryDir, "MEMORY.md"))
+	require.NoError(t, err)
+	assert.Regexp(t, `\(Important facts about the user\)\n- \[[0-9: -]+\] Name is Ada\n\n## Preferences\n\n- likes tea\n$`, string(data))
+}
+
+func TestMemoryToolSearch(t *testing.T) {
+	workspace := t.TempDir()
+	memoryDir := filepath.Join(workspace, "memory")
+	require.NoError(t, os.MkdirAll(memoryDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(memoryDir, "HISTORY.md"), []byte("# History\n\n- discussed Falcon launch\n"), 0644))
+
+	tool := NewMemoryTool(workspace)
+	ctx := context.Background()
+	_, err := tool.Execute(ctx, map[string]interface{}{"action": "append", "section": "Projects", "content": "falcon ships in May"})
+	require.NoError(t, err)
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"action": "search", "query": "FALCON"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "HISTORY.md:3: - discussed Falcon launch")
+	assert.Contains(t, result, "[Projects]")
+	assert.Contains(t, result, "falcon ships in May")
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"action": "search", "query": "nothing here"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "No memory entries match")
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "search"})
+	assert.Error(t, err)
+}
@@ -3,7 +3,6 @@ package tools
 import (
 	"context"
 	"fmt"
-	"sync"
 )
 
 // MessageCallback 消息发送回调函数类型
@@ -13,9 +12,8 @@ type MessageCallback func(channel, chatID, content string) error
 type MessageTool struct {
 	BaseTool
 	callback MessageCallback
-	mu       sync.RWMutex
-	channel  string
-	chatID   string
+	// fallback SetContext 设置的默认 channel/chat
+	fallback runtimeContextFallback
 }
 
 // NewMessageTool 创建消息发送工具
@@ -48,12 +46,9 @@ func NewMessageTool(callback MessageCallback) *MessageTool {
 	}
 }
 
-// SetContext 设置当前上下文
+// SetContext 设置默认的 channel/chat；本次调用的 context 中带有 channel/chat 时以 context 为准
 func (t *MessageTool) SetContext(channel, chatID string) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.channel = channel
-	t.chatID = chatID
+	t.fallback.set(channel, chatID)
 }
 
 // Execute 执行消息发送
@@ -63,20 +58,8 @@ func (t *MessageTool) Execute(ctx context.Context, params map[string]interface{}
 		return "", fmt.Errorf("content is required")
 	}
 
-	t.mu.RLock()
-	channel := t.channel
-	chatID := t.chatID
-	t.mu.RUnlock()
-
-	// 优先使用当前请求上下文，避免并发请求时的上下文串线
-	if ctxChannel, ctxChatID := RuntimeContextFrom(ctx); ctxChannel != "" || ctxChatID != "" {
-		if channel == "" {
-			channel = ctxChannel
-		}
-		if chatID == "" {
-			chatID = ctxChatID
-		}
-	}
+	// 频道/会话来自本次调用的 context，缺失时才使用 SetContext 设置的默认值
+	channel, chatID := t.fallback.resolve(ctx)
 
 	// 允许通过参数覆盖
 	if v, ok := params["channel"].(string); ok && v != "" {
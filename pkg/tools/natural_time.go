@@ -0,0 +1,404 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 自然语言时间解析：把 "in 30 minutes"、"tomorrow at 9am"、"明天下午3点" 之类的短语解析为一次性执行时间。
+// 只覆盖常见句式；含义不明确时（如没有上午/下午的 "at 3"、只有日期没有时间）返回错误，让模型向用户确认而不是猜测。
+
+// timeMarker 时间段标记，用于把 12 小时制的钟点换算为 24 小时制
+type timeMarker int
+
+const (
+	markerNone timeMarker = iota
+	markerAM
+	markerPM
+	markerNoon
+)
+
+// earliestUnmarkedHour 指明日期但没有上午/下午时，不小于该值的钟点按字面理解（"tomorrow at 9" 即 09:00）
+const earliestUnmarkedHour = 7
+
+var (
+	enRelativeInPattern    = regexp.MustCompile(`^in\s+(.+)$`)
+	enRelativeLaterPattern = regexp.MustCompile(`^(.+?)\s+(?:from\s+now|later)$`)
+	// 数字后可紧跟单位（30m），单词数量后必须有空格，避免把 "and" 误读为 "an d"
+	enDurationPartPattern = regexp.MustCompile(`(?:(\d+(?:\.\d+)?)\s*|\b(an?|one|half\s+an?)\s+)(seconds?|secs?|minutes?|mins?|m|hours?|hrs?|h|days?|d|weeks?|w)\b`)
+	enDayPattern          = regexp.MustCompile(`\b(?:the\s+)?day\s+after\s+tomorrow\b|\btoday\b|\btonight\b|\btomorrow\b|\b(?:(next|this)\s+)?(monday|tuesday|wednesday|thursday|friday|saturday|sunday)\b`)
+	enPeriodPattern       = regexp.MustCompile(`\b(?:in\s+the\s+|this\s+|at\s+)?(morning|afternoon|evening|night)\b`)
+	enClockPattern        = regexp.MustCompile(`^(?:at\s+)?(?:(\d{1,2})(?::(\d{2}))?\s*(am|pm)?|(noon|midday))$`)
+
+	zhRelativePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?|半|一)个?(秒钟?|分钟|分|小时|钟头|天|周|星期)(?:后|以后|之后)$`)
+	zhDayPattern      = regexp.MustCompile(`大后天|后天|明天|明日|今天|今日|今晚|今早|明早|明晚|(下)?(?:周|星期|礼拜)([一二三四五六日天])`)
+	zhPeriodPattern   = regexp.MustCompile(`凌晨|早上|早晨|上午|中午|下午|傍晚|晚上|夜里`)
+	zhClockPattern    = regexp.MustCompile(`^(\d{1,2})(?:[:：](\d{2})|[点时](?:钟)?(?:(\d{1,2})分?|(半))?)$`)
+)
+
+var enWeekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+var zhWeekdays = map[string]time.Weekday{
+	"一": time.Monday, "二": time.Tuesday, "三": time.Wednesday, "四": time.Thursday,
+	"五": time.Friday, "六": time.Saturday, "日": time.Sunday, "天": time.Sunday,
+}
+
+var zhPeriodMarkers = map[string]timeMarker{
+	"凌晨": markerAM, "早上": markerAM, "早晨": markerAM, "上午": markerAM,
+	"中午": markerNoon,
+	"下午": markerPM, "傍晚": markerPM, "晚上": markerPM, "夜里": markerPM,
+}
+
+// naturalDay 短语中的日期部分
+type naturalDay struct {
+	set        bool
+	offset     int // 相对今天的天数（未指定星期几时使用）
+	weekday    time.Weekday
+	hasWeekday bool
+	// nextWeek 为 true 时取下一个自然周（中文“下周一”）；nextOccurrence 为 true 时跳过今天（英文 "next monday"）
+	nextWeek       bool
+	nextOccurrence bool
+	label          string
+}
+
+// naturalClock 短语中的钟点部分
+type naturalClock struct {
+	set       bool
+	hour      int
+	minute    int
+	hasMinute bool
+	marker    timeMarker
+	label     string
+}
+
+// parseNaturalTime 解析自然语言时间短语，返回 now 之后的执行时间
+func parseNaturalTime(raw string, now time.Time) (time.Time, error) {
+	phrase := normalizeTimePhrase(raw)
+	if phrase == "" {
+		return time.Time{}, fmt.Errorf("empty time phrase")
+	}
+
+	if containsHan(phrase) {
+		compact := strings.Join(strings.Fields(phrase), "")
+		if m := zhRelativePattern.FindStringSubmatch(compact); m != nil {
+			return relativeTime(now, m[1], m[2])
+		}
+		return parseZhAbsoluteTime(compact, raw, now)
+	}
+
+	// "in the evening at 8" 也以 in 开头，时长解析失败时继续按具体时间解析
+	for _, pattern := range []*regexp.Regexp{enRelativeInPattern, enRelativeLaterPattern} {
+		if m := pattern.FindStringSubmatch(phrase); m != nil {
+			if runAt, err := parseEnDuration(now, m[1], raw); err == nil {
+				return runAt, nil
+			}
+		}
+	}
+	return parseEnAbsoluteTime(phrase, raw, now)
+}
+
+func normalizeTimePhrase(raw string) string {
+	phrase := strings.ToLower(strings.TrimSpace(raw))
+	phrase = strings.NewReplacer("a.m.", "am", "p.m.", "pm", "o'clock", "", ",", " ").Replace(phrase)
+	phrase = strings.TrimRight(phrase, ".!?。！？")
+	return strings.Join(strings.Fields(phrase), " ")
+}
+
+func containsHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEnDuration 解析 "2 hours 30 minutes"、"half an hour" 之类的时长
+func parseEnDuration(now time.Time, text, raw string) (time.Time, error) {
+	matches := enDurationPartPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return time.Time{}, unrecognizedTimeError(raw)
+	}
+
+	var total time.Duration
+	rest := text
+	for i := len(matches) - 1; i >= 0; i-- {
+		m := matches[i]
+		var amount string
+		if m[2] >= 0 {
+			amount = text[m[2]:m[3]]
+		} else {
+			amount = text[m[4]:m[5]]
+		}
+		d, err := durationOf(amount, text[m[6]:m[7]])
+		if err != nil {
+			return time.Time{}, err
+		}
+		total += d
+		rest = rest[:m[0]] + " " + rest[m[1]:]
+	}
+	// 时长之间只允许 and / 空白
+	if leftover := strings.TrimSpace(strings.ReplaceAll(" "+rest+" ", " and ", " ")); leftover != "" {
+		return time.Time{}, unrecognizedTimeError(raw)
+	}
+	if total <= 0 {
+		return time.Time{}, fmt.Errorf("%q resolves to a time that is not in the future", raw)
+	}
+	return now.Add(total), nil
+}
+
+func relativeTime(now time.Time, amount, unit string) (time.Time, error) {
+	d, err := durationOf(amount, unit)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("relative time must be positive")
+	}
+	return now.Add(d), nil
+}
+
+func durationOf(amount, unit string) (time.Duration, error) {
+	var n float64
+	switch {
+	case amount == "a" || amount == "an" || amount == "one" || amount == "一":
+		n = 1
+	case strings.HasPrefix(amount, "half") || amount == "半":
+		n = 0.5
+	default:
+		parsed, err := strconv.ParseFloat(amount, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid amount %q", amount)
+		}
+		n = parsed
+	}
+
+	var base time.Duration
+	switch {
+	case strings.HasPrefix(unit, "s") || strings.HasPrefix(unit, "秒"):
+		base = time.Second
+	case strings.HasPrefix(unit, "m") || unit == "分钟" || unit == "分":
+		base = time.Minute
+	case strings.HasPrefix(unit, "h") || unit == "小时" || unit == "钟头":
+		base = time.Hour
+	case strings.HasPrefix(unit, "d") || unit == "天":
+		base = 24 * time.Hour
+	case strings.HasPrefix(unit, "w") || unit == "周" || unit == "星期":
+		base = 7 * 24 * time.Hour
+	default:
+		return 0, fmt.Errorf("unsupported time unit %q", unit)
+	}
+	return time.Duration(math.Round(n * float64(base))), nil
+}
+
+// parseEnAbsoluteTime 解析 "tomorrow at 9am"、"next friday 14:30"、"tonight at 8" 等
+func parseEnAbsoluteTime(phrase, raw string, now time.Time) (time.Time, error) {
+	var day naturalDay
+	if m := enDayPattern.FindStringSubmatchIndex(phrase); m != nil {
+		word := phrase[m[0]:m[1]]
+		day = naturalDay{set: true, label: word}
+		switch {
+		case strings.Contains(word, "after"):
+			day.offset = 2
+		case word == "tomorrow":
+			day.offset = 1
+		case word == "today" || word == "tonight":
+		default:
+			day.hasWeekday = true
+			day.weekday = enWeekdays[phrase[m[4]:m[5]]]
+			day.nextOccurrence = m[2] >= 0 && phrase[m[2]:m[3]] == "next"
+		}
+		phrase = phrase[:m[0]] + " " + phrase[m[1]:]
+		if word == "tonight" {
+			phrase += " evening"
+		}
+	}
+
+	marker := markerNone
+	for _, m := range enPeriodPattern.FindAllStringSubmatchIndex(phrase, -1) {
+		if phrase[m[2]:m[3]] == "morning" {
+			marker = markerAM
+		} else {
+			marker = markerPM
+		}
+	}
+	phrase = strings.TrimSpace(enPeriodPattern.ReplaceAllString(phrase, " "))
+	phrase = strings.TrimSpace(strings.TrimPrefix(" "+phrase+" ", " on "))
+	phrase = strings.Join(strings.Fields(phrase), " ")
+
+	var clock naturalClock
+	if phrase != "" {
+		m := enClockPattern.FindStringSubmatch(phrase)
+		if m == nil {
+			return time.Time{}, unrecognizedTimeError(raw)
+		}
+		clock = naturalClock{set: true, marker: marker, label: strings.TrimPrefix(phrase, "at ")}
+		if m[4] != "" {
+			clock.hour, clock.hasMinute, clock.marker = 12, true, markerNoon
+		} else {
+			clock.hour, _ = strconv.Atoi(m[1])
+			if m[2] != "" {
+				clock.minute, _ = strconv.Atoi(m[2])
+				clock.hasMinute = true
+			}
+			switch m[3] {
+			case "am":
+				clock.marker = markerAM
+			case "pm":
+				clock.marker = markerPM
+			}
+		}
+	}
+	return resolveNaturalTime(now, day, clock, raw)
+}
+
+// parseZhAbsoluteTime 解析 "明天下午3点"、"明早9点半"、"下周一10:00" 等
+func parseZhAbsoluteTime(phrase, raw string, now time.Time) (time.Time, error) {
+	var day naturalDay
+	marker := markerNone
+	if m := zhDayPattern.FindStringSubmatchIndex(phrase); m != nil {
+		word := phrase[m[0]:m[1]]
+		day = naturalDay{set: true, label: word}
+		switch word {
+		case "今天", "今日":
+		case "今早":
+			marker = markerAM
+		case "今晚":
+			marker = markerPM
+		case "明天", "明日":
+			day.offset = 1
+		case "明早":
+			day.offset, marker = 1, markerAM
+		case "明晚":
+			day.offset, marker = 1, markerPM
+		case "后天":
+			day.offset = 2
+		case "大后天":
+			day.offset = 3
+		default:
+			day.hasWeekday = true
+			day.weekday = zhWeekdays[phrase[m[4]:m[5]]]
+			day.nextWeek = m[2] >= 0
+		}
+		phrase = phrase[:m[0]] + phrase[m[1]:]
+	}
+	if m := zhPeriodPattern.FindStringIndex(phrase); m != nil {
+		marker = zhPeriodMarkers[phrase[m[0]:m[1]]]
+		phrase = phrase[:m[0]] + phrase[m[1]:]
+	}
+	phrase = strings.TrimPrefix(phrase, "的")
+
+	var clock naturalClock
+	if phrase != "" {
+		m := zhClockPattern.FindStringSubmatch(phrase)
+		if m == nil {
+			return time.Time{}, unrecognizedTimeError(raw)
+		}
+		clock = naturalClock{set: true, marker: marker, label: phrase}
+		clock.hour, _ = strconv.Atoi(m[1])
+		switch {
+		case m[2] != "":
+			clock.minute, _ = strconv.Atoi(m[2])
+			clock.hasMinute = true
+		case m[3] != "":
+			clock.minute, _ = strconv.Atoi(m[3])
+			clock.hasMinute = true
+		case m[4] != "":
+			clock.minute = 30
+		}
+	}
+	return resolveNaturalTime(now, day, clock, raw)
+}
+
+// resolveNaturalTime 把日期与钟点组合为具体时间，并在含义不明确时要求澄清
+func resolveNaturalTime(now time.Time, day naturalDay, clock naturalClock, raw string) (time.Time, error) {
+	if !clock.set {
+		if day.set {
+			return time.Time{}, fmt.Errorf("%q has no time of day; ask the user what time they mean (e.g. \"%s at 9am\")", raw, day.label)
+		}
+		return time.Time{}, unrecognizedTimeError(raw)
+	}
+
+	hour, err := clockHour(clock, day.set)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is ambiguous: %v", raw, err)
+	}
+	if clock.minute > 59 {
+		return time.Time{}, fmt.Errorf("invalid minute in %q", raw)
+	}
+
+	loc := now.Location()
+	date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	switch {
+	case day.hasWeekday && day.nextWeek:
+		// 下周X：下一个自然周（周一为一周的开始）中的那一天
+		sinceMonday := (int(date.Weekday()) + 6) % 7
+		target := (int(day.weekday) + 6) % 7
+		date = date.AddDate(0, 0, 7-sinceMonday+target)
+	case day.hasWeekday:
+		diff := (int(day.weekday) - int(date.Weekday()) + 7) % 7
+		if diff == 0 && day.nextOccurrence {
+			diff = 7
+		}
+		date = date.AddDate(0, 0, diff)
+	default:
+		date = date.AddDate(0, 0, day.offset)
+	}
+
+	runAt := time.Date(date.Year(), date.Month(), date.Day(), hour, clock.minute, 0, 0, loc)
+	if !runAt.After(now) {
+		switch {
+		case !day.set:
+			// 只给出钟点时取下一次出现的时间
+			runAt = runAt.AddDate(0, 0, 1)
+		case day.hasWeekday && !day.nextWeek:
+			runAt = runAt.AddDate(0, 0, 7)
+		default:
+			return time.Time{}, fmt.Errorf("%q resolves to %s, which has already passed", raw, runAt.Format(time.RFC3339))
+		}
+	}
+	return runAt, nil
+}
+
+// clockHour 按时间段标记换算 24 小时制钟点；没有标记的 1-12 点在无法确定上午/下午时返回错误
+func clockHour(clock naturalClock, daySet bool) (int, error) {
+	hour := clock.hour
+	if hour > 23 {
+		return 0, fmt.Errorf("hour %d is out of range", hour)
+	}
+	switch clock.marker {
+	case markerAM:
+		if hour > 12 {
+			return 0, fmt.Errorf("%d o'clock cannot be in the morning", hour)
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	case markerPM:
+		if hour < 12 {
+			hour += 12
+		}
+	case markerNoon:
+		if hour <= 2 {
+			hour += 12
+		}
+	default:
+		// HH:MM 视为 24 小时制；只有整点数字时无法区分上午/下午
+		if !clock.hasMinute && hour >= 1 && hour <= 12 && !(daySet && hour >= earliestUnmarkedHour) {
+			return 0, fmt.Errorf("%q could mean %02d:00 or %02d:00; ask the user whether they mean am or pm", clock.label, hour%12, hour%12+12)
+		}
+	}
+	return hour, nil
+}
+
+func unrecognizedTimeError(raw string) error {
+	return fmt.Errorf("could not understand the time %q; ask the user to clarify, or use a form like \"in 30 minutes\", \"tomorrow at 9am\" or \"YYYY-MM-DD HH:MM\"", raw)
+}
@@ -0,0 +1,99 @@
+package tools
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNaturalTimeRelative(t *testing.T) {
+	// 2026-03-04 是周三
+	now := time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC)
+
+	tests := map[string]time.Duration{
+		"in 30 minutes":         30 * time.Minute,
+		"In 2 hours.":           2 * time.Hour,
+		"in an hour":            time.Hour,
+		"in half an hour":       30 * time.Minute,
+		"in 1 hour and 15 mins": 75 * time.Minute,
+		"in 3 days":             72 * time.Hour,
+		"45 minutes from now":   45 * time.Minute,
+		"30分钟后":                 30 * time.Minute,
+		"2 小时后":                 2 * time.Hour,
+		"半小时后":                  30 * time.Minute,
+		"3天以后":                  72 * time.Hour,
+	}
+	for phrase, want := range tests {
+		t.Run(phrase, func(t *testing.T) {
+			got, err := parseNaturalTime(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, now.Add(want), got)
+		})
+	}
+}
+
+func TestParseNaturalTimeAbsolute(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := map[string]time.Time{
+		"tomorrow at 9":               at(5, 9, 0),
+		"tomorrow at 9am":             at(5, 9, 0),
+		"tomorrow 3pm":                at(5, 15, 0),
+		"tomorrow morning at 8:30":    at(5, 8, 30),
+		"at 5pm":                      at(4, 17, 0),
+		"at 9 p.m.":                   at(4, 21, 0),
+		"9am":                         at(5, 9, 0), // 今天 9 点已过，取明天
+		"tonight at 8":                at(4, 20, 0),
+		"this evening at 7:45":        at(4, 19, 45),
+		"today at noon":               at(4, 12, 0),
+		"the day after tomorrow 10am": at(6, 10, 0),
+		"friday at 14:30":             at(6, 14, 30),
+		"next wednesday at 9am":       at(11, 9, 0),
+		"wednesday at 9am":            at(11, 9, 0), // 今天同一时刻已过
+		"on monday at 10":             at(9, 10, 0),
+		"明天9点":                        at(5, 9, 0),
+		"明天下午3点半":                     at(5, 15, 30),
+		"明早 8 点 20 分":                 at(5, 8, 20),
+		"今晚8点":                        at(4, 20, 0),
+		"后天上午10:00":                   at(6, 10, 0),
+		"下周一上午9点":                     at(9, 9, 0),
+		"周五中午12点":                     at(6, 12, 0),
+		"下午2点":                        at(4, 14, 0),
+	}
+	for phrase, want := range tests {
+		t.Run(phrase, func(t *testing.T) {
+			got, err := parseNaturalTime(phrase, now)
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestParseNaturalTimeRejectsAmbiguousPhrases(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 15, 0, 0, time.UTC)
+
+	tests := map[string]string{
+		"at 9":             "am or pm",
+		"tomorrow at 3":    "am or pm",
+		"明天3点":             "am or pm",
+		"tomorrow":         "no time of day",
+		"tomorrow morning": "no time of day",
+		"明天":               "no time of day",
+		"later":            "could not understand",
+		"sometime soon":    "could not understand",
+		"today at 8am":     "already passed",
+		"at 25:00":         "out of range",
+	}
+	for phrase, want := range tests {
+		t.Run(phrase, func(t *testing.T) {
+			_, err := parseNaturalTime(phrase, now)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), want)
+		})
+	}
+}
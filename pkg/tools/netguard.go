@@ -0,0 +1,179 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// blockedNetworks net.IP 方法未覆盖、但同样不应被访问的地址段
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "本网络"
+	"100.64.0.0/10", // 运营商级 NAT，部分云的元数据服务在此段
+	"192.0.0.0/24",  // IETF 协议分配
+	"198.18.0.0/15", // 基准测试
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+// isBlockedIP 回环、私有、链路本地（含 169.254.169.254 元数据地址）、未指定等内部地址
+func isBlockedIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, n := range blockedNetworks {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostGuard 阻止 web_fetch 访问内网地址（SSRF 防护）；allowedHosts 中的主机名或 IP/CIDR 放行
+type hostGuard struct {
+	hosts map[string]bool
+	nets  []*net.IPNet
+	// proxy 选择代理，默认读取 HTTP(S)_PROXY 等环境变量
+	proxy func(*http.Request) (*url.URL, error)
+	// proxyAddrs 记录已选用的代理地址，拨号到这些地址时不做内网检查
+	proxyAddrs sync.Map
+}
+
+func newHostGuard(allowed []string) *hostGuard {
+	g := &hostGuard{hosts: make(map[string]bool), proxy: http.ProxyFromEnvironment}
+	for _, entry := range allowed {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if _, n, err := net.ParseCIDR(entry); err == nil {
+			g.nets = append(g.nets, n)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			g.nets = append(g.nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		g.hosts[entry] = true
+	}
+	return g
+}
+
+func (g *hostGuard) hostAllowed(host string) bool {
+	return g.hosts[strings.ToLower(strings.TrimSuffix(host, "."))]
+}
+
+func (g *hostGuard) ipAllowed(ip net.IP) bool {
+	if !isBlockedIP(ip) {
+		return true
+	}
+	for _, n := range g.nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL 在发出任何请求前解析目标主机并检查所有地址；这只是预检，
+// 重定向和 DNS 重绑定由 transport 在建立连接时拦截
+func (g *hostGuard) checkURL(ctx context.Context, target *url.URL) error {
+	host := target.Hostname()
+	if host == "" || g.hostAllowed(host) {
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		// 解析失败交给实际请求报错
+		return nil
+	}
+	for _, addr := range ips {
+		if !g.ipAllowed(addr.IP) {
+			return &blockedHostError{host: host, ip: addr.IP}
+		}
+	}
+	return nil
+}
+
+// transport 返回在建立连接时再次校验地址的 Transport，覆盖重定向和 DNS 重绑定。
+// 经代理的请求（含每一跳重定向）在交给代理前解析并检查目标主机，代理本身由运维配置，拨号时放行
+func (g *hostGuard) transport() *http.Transport {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.Proxy = func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := g.proxy(req)
+		if err != nil || proxyURL == nil {
+			return proxyURL, err
+		}
+		if err := g.checkURL(req.Context(), req.URL); err != nil {
+			return nil, err
+		}
+		g.proxyAddrs.Store(proxyDialAddr(proxyURL), true)
+		return proxyURL, nil
+	}
+	base.DialContext = g.dialContext
+	return base
+}
+
+// dialContext 建立连接时校验实际拨号的地址；已选用的代理和 allowedHosts 中的主机名直接放行
+func (g *hostGuard) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if _, ok := g.proxyAddrs.Load(addr); ok {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil && g.hostAllowed(host) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	dialer.Control = func(network, address string, _ syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip != nil && !g.ipAllowed(ip) {
+			return &blockedHostError{host: host, ip: ip}
+		}
+		return nil
+	}
+	return dialer.DialContext(ctx, network, addr)
+}
+
+// proxyDialAddr 返回 Transport 连接代理时使用的 host:port（缺省端口按 scheme 补齐）
+func proxyDialAddr(proxyURL *url.URL) string {
+	if port := proxyURL.Port(); port != "" {
+		return net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	port := "80"
+	switch strings.ToLower(proxyURL.Scheme) {
+	case "https":
+		port = "443"
+	case "socks5", "socks5h":
+		port = "1080"
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), port)
+}
+
+// blockedHostError 目标地址被 SSRF 防护拦截；auto 模式据此不再回退到浏览器
+type blockedHostError struct {
+	host string
+	ip   net.IP
+}
+
+func (e *blockedHostError) Error() string {
+	return fmt.Sprintf("blocked request to %s: %s is a private, loopback or link-local address (add the host to tools.web.fetch.allowedHosts to permit it)", e.host, e.ip)
+}
@@ -0,0 +1,245 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hopHeaders 代理转发时不应透传的逐跳头
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// guardProxy 浏览器抓取时使用的本地 HTTP 代理：浏览器的每个连接（重定向、子资源、DNS 重绑定）
+// 都经 hostGuard 在拨号时校验，弥补浏览器在独立进程中联网、Go 侧无法拦截的问题
+type guardProxy struct {
+	guard     *hostGuard
+	transport *http.Transport
+	listener  net.Listener
+	server    *http.Server
+	done      chan struct{}
+
+	mu      sync.Mutex
+	blocked *blockedHostError
+	closed  bool
+}
+
+// startProxy 在回环地址上启动一次性代理，用完由调用方 Close
+func (g *hostGuard) startProxy() (*guardProxy, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	p := &guardProxy{
+		guard:     g,
+		transport: g.transport(),
+		listener:  listener,
+		done:      make(chan struct{}),
+	}
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: 30 * time.Second}
+	go func() { _ = p.server.Serve(listener) }()
+	return p, nil
+}
+
+// URL 返回交给浏览器的代理地址
+func (p *guardProxy) URL() string {
+	return "http://" + p.listener.Addr().String()
+}
+
+// Blocked 返回第一个被拦截的目标；浏览器只会看到连接失败，由此还原出拦截原因
+func (p *guardProxy) Blocked() *blockedHostError {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.blocked
+}
+
+// Close 关闭代理及仍在转发的隧道
+func (p *guardProxy) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	p.mu.Unlock()
+	p.transport.CloseIdleConnections()
+	return p.server.Close()
+}
+
+func (p *guardProxy) record(err error) bool {
+	var blocked *blockedHostError
+	if !errors.As(err, &blocked) {
+		return false
+	}
+	p.mu.Lock()
+	if p.blocked == nil {
+		p.blocked = blocked
+	}
+	p.mu.Unlock()
+	return true
+}
+
+func (p *guardProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.tunnel(w, r)
+		return
+	}
+	if !r.URL.IsAbs() {
+		http.Error(w, "proxy requests must use an absolute URL", http.StatusBadRequest)
+		return
+	}
+
+	out := r.Clone(r.Context())
+	out.RequestURI = ""
+	removeHopHeaders(out.Header)
+	resp, err := p.transport.RoundTrip(out)
+	if err != nil {
+		if p.record(err) {
+			// 直接断开而不是返回错误页：浏览器会把错误页当作正常页面渲染，断开后导航失败
+			if hijacker, ok := w.(http.Hijacker); ok {
+				if conn, _, err := hijacker.Hijack(); err == nil {
+					conn.Close()
+					return
+				}
+			}
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+
+	removeHopHeaders(resp.Header)
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
+
+// tunnel 处理 HTTPS 的 CONNECT：目标在拨号时校验，随后原样转发字节
+func (p *guardProxy) tunnel(w http.ResponseWriter, r *http.Request) {
+	upstream, err := p.dialTunnel(r.Context(), r.Host)
+	if err != nil {
+		if p.record(err) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		upstream.Close()
+		http.Error(w, "tunneling not supported", http.StatusInternalServerError)
+		return
+	}
+	client, _, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := io.WriteString(client, "HTTP/1.1 200 Connection Established\r\n\r\n"); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+
+	finished := make(chan struct{}, 2)
+	pipe := func(dst, src net.Conn) {
+		_, _ = io.Copy(dst, src)
+		finished <- struct{}{}
+	}
+	go pipe(upstream, client)
+	go pipe(client, upstream)
+	select {
+	case <-finished:
+	case <-p.done:
+	}
+	client.Close()
+	upstream.Close()
+}
+
+// dialTunnel 连接 CONNECT 目标；配置了环境代理时先检查目标主机，再经上游代理建立隧道
+func (p *guardProxy) dialTunnel(ctx context.Context, hostport string) (net.Conn, error) {
+	target := &url.URL{Scheme: "https", Host: hostport}
+	proxyURL, err := p.guard.proxy(&http.Request{URL: target, Header: make(http.Header)})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL == nil {
+		return p.guard.dialContext(ctx, "tcp", hostport)
+	}
+	if !strings.EqualFold(proxyURL.Scheme, "http") {
+		return nil, fmt.Errorf("browser fetch cannot tunnel through %s proxy %s", proxyURL.Scheme, proxyURL.Host)
+	}
+	if err := p.guard.checkURL(ctx, target); err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", proxyDialAddr(proxyURL))
+	if err != nil {
+		return nil, err
+	}
+	connect := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: hostport},
+		Host:   hostport,
+		Header: make(http.Header),
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		connect.SetBasicAuth(user.Username(), password)
+		connect.Header.Set("Proxy-Authorization", connect.Header.Get("Authorization"))
+		connect.Header.Del("Authorization")
+	}
+	if err := connect.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connect)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("upstream proxy refused CONNECT %s: %s", hostport, resp.Status)
+	}
+	return conn, nil
+}
+
+func removeHopHeaders(header http.Header) {
+	for _, key := range header.Values("Connection") {
+		for _, name := range strings.Split(key, ",") {
+			header.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, key := range hopHeaders {
+		header.Del(key)
+	}
+}
@@ -0,0 +1,283 @@
+package tools
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsBlockedIP(t *testing.T) {
+	for _, addr := range []string{"169.254.169.254", "127.0.0.1", "10.1.2.3", "172.16.0.5", "192.168.1.1", "100.100.100.200", "0.0.0.0", "::1", "fe80::1", "fd00::1"} {
+		assert.True(t, isBlockedIP(net.ParseIP(addr)), addr)
+	}
+	for _, addr := range []string{"93.184.216.34", "8.8.8.8", "2606:4700:4700::1111"} {
+		assert.False(t, isBlockedIP(net.ParseIP(addr)), addr)
+	}
+}
+
+func TestHostGuardAllowsPublicAndListedHosts(t *testing.T) {
+	guard := newHostGuard([]string{"intranet.local", "10.0.0.0/8", "192.168.1.20"})
+	ctx := context.Background()
+
+	public, _ := url.Parse("http://93.184.216.34/page")
+	assert.NoError(t, guard.checkURL(ctx, public))
+
+	// allowedHosts 中的主机名不做地址检查
+	named, _ := url.Parse("http://intranet.local/wiki")
+	assert.NoError(t, guard.checkURL(ctx, named))
+
+	assert.True(t, guard.ipAllowed(net.ParseIP("10.2.3.4")))
+	assert.True(t, guard.ipAllowed(net.ParseIP("192.168.1.20")))
+	assert.False(t, guard.ipAllowed(net.ParseIP("192.168.1.21")))
+
+	metadata, _ := url.Parse("http://169.254.169.254/latest/meta-data/")
+	err := guard.checkURL(ctx, metadata)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "allowedHosts")
+}
+
+func TestWebFetchBlocksMetadataIPInHTTPAndBrowserModes(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "launched")
+	script := filepath.Join(t.TempDir(), "fetch.sh")
+	require.NoError(t, os.WriteFile(script, []byte("touch "+marker+"\necho '{\"ok\":true,\"text\":\"page\"}'\n"), 0755))
+
+	for _, options := range []WebFetchOptions{
+		{Mode: "http"},
+		{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script},
+	} {
+		_, err := NewWebFetchTool(options).Execute(context.Background(), map[string]interface{}{"url": "http://169.254.169.254/latest/meta-data/"})
+		require.Error(t, err, options.Mode)
+		assert.Contains(t, err.Error(), "blocked request to 169.254.169.254", options.Mode)
+	}
+	_, err := os.Stat(marker)
+	assert.True(t, os.IsNotExist(err), "browser must not be launched for a blocked host")
+}
+
+func TestWebFetchGuardAllowListAndRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(w, r)
+		case "/redirect":
+			// 重定向到未放行的地址：连接时再次校验
+			_, port, _ := net.SplitHostPort(r.Host)
+			http.Redirect(w, r, "http://127.0.0.1:"+port+"/secret", http.StatusFound)
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<p>internal page</p>"))
+		}
+	}))
+	defer server.Close()
+
+	_, err := NewWebFetchTool(WebFetchOptions{Mode: "http"}).Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "blocked request")
+
+	allowed := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowedHosts: []string{"127.0.0.1"}})
+	result, err := allowed.Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	require.NoError(t, err)
+	assert.Contains(t, result, "internal page")
+
+	byName := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowedHosts: []string{"localhost"}})
+	localURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	result, err = byName.Execute(context.Background(), map[string]interface{}{"url": localURL})
+	require.NoError(t, err)
+	assert.Contains(t, result, "internal page")
+
+	_, err = byName.Execute(context.Background(), map[string]interface{}{"url": localURL + "/redirect"})
+	require.Error(t, err)
+	var blocked *blockedHostError
+	assert.ErrorAs(t, err, &blocked, "a blocked redirect must not fall back to the browser")
+}
+
+func TestWebFetchGuardAllowsBrowserForPermittedHosts(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "launched")
+	script := filepath.Join(t.TempDir(), "fetch.sh")
+	require.NoError(t, os.WriteFile(script, []byte("touch "+marker+"\necho '{\"ok\":true,\"proxied\":true,\"text\":\"rendered page\"}'\n"), 0755))
+
+	// 防护开启时 browser 模式照常工作，只拦截内网目标
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script, IgnoreRobots: true})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": "http://93.184.216.34/page"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "rendered page")
+	_, err = os.Stat(marker)
+	require.NoError(t, err)
+}
+
+func TestWebFetchAutoModeChecksHostBeforeBrowserFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<p>Please enable JavaScript to continue</p>"))
+	}))
+	defer server.Close()
+
+	script := filepath.Join(t.TempDir(), "fetch.sh")
+	require.NoError(t, os.WriteFile(script, []byte("echo '{\"ok\":true,\"proxied\":true,\"text\":\"rendered page\"}'\n"), 0755))
+
+	// 放行的主机在 auto 模式下仍可回退到浏览器
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "auto", NodePath: "/bin/sh", ScriptPath: script, IgnoreRobots: true, AllowedHosts: []string{"127.0.0.1"}})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	require.NoError(t, err)
+	assert.Contains(t, result, "rendered page")
+
+	// 浏览器回退前同样检查目标主机
+	tool = NewWebFetchTool(WebFetchOptions{Mode: "auto", NodePath: "/bin/sh", ScriptPath: script, IgnoreRobots: true})
+	_, err = tool.executeBrowserFetch(context.Background(), "http://169.254.169.254/", 1000, "browser", map[string]interface{}{})
+	var blocked *blockedHostError
+	assert.ErrorAs(t, err, &blocked)
+}
+
+func TestHostGuardTransportUsesProxyAfterCheckingTarget(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	// 代理地址本身在回环段，拨号时放行；目标地址在交给代理前检查
+	guard := newHostGuard(nil)
+	guard.proxy = http.ProxyURL(proxyURL)
+	client := &http.Client{Transport: guard.transport()}
+
+	resp, err := client.Get("http://93.184.216.34/page")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, []string{"http://93.184.216.34/page"}, proxied)
+
+	_, err = client.Get("http://169.254.169.254/latest/meta-data/")
+	var blocked *blockedHostError
+	assert.ErrorAs(t, err, &blocked)
+	assert.Len(t, proxied, 1, "blocked targets must not reach the proxy")
+}
+
+// newRedirectToPrivateServer 模拟公网页面重定向到内网地址：经 localhost 访问的 /redirect 跳到 127.0.0.1 的 /secret
+func newRedirectToPrivateServer(t *testing.T, secretHits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			_, port, _ := net.SplitHostPort(r.Host)
+			http.Redirect(w, r, "http://127.0.0.1:"+port+"/secret", http.StatusFound)
+		case "/secret":
+			atomic.AddInt32(secretHits, 1)
+			_, _ = w.Write([]byte("metadata secret"))
+		default:
+			_, _ = w.Write([]byte("public page"))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGuardProxyBlocksRedirectToPrivateAddress(t *testing.T) {
+	var secretHits int32
+	server := newRedirectToPrivateServer(t, &secretHits)
+	publicURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	// 只放行主机名 localhost，127.0.0.1 仍视为内网地址
+	proxy, err := newHostGuard([]string{"localhost"}).startProxy()
+	require.NoError(t, err)
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL())
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get(publicURL + "/page")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "public page", string(body))
+	assert.Nil(t, proxy.Blocked())
+
+	_, err = client.Get(publicURL + "/redirect")
+	require.Error(t, err, "the redirected hop must be cut off by the proxy")
+	require.NotNil(t, proxy.Blocked())
+	assert.Equal(t, "127.0.0.1", proxy.Blocked().host)
+	assert.Zero(t, atomic.LoadInt32(&secretHits))
+}
+
+func TestGuardProxyBlocksConnectToPrivateAddress(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("tls page"))
+	}))
+	defer server.Close()
+
+	newClient := func(proxy *guardProxy) *http.Client {
+		proxyURL, _ := url.Parse(proxy.URL())
+		transport := server.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = http.ProxyURL(proxyURL)
+		return &http.Client{Transport: transport}
+	}
+
+	blocked, err := newHostGuard(nil).startProxy()
+	require.NoError(t, err)
+	defer blocked.Close()
+	_, err = newClient(blocked).Get(server.URL)
+	require.Error(t, err)
+	assert.NotNil(t, blocked.Blocked())
+
+	allowed, err := newHostGuard([]string{"127.0.0.1"}).startProxy()
+	require.NoError(t, err)
+	defer allowed.Close()
+	resp, err := newClient(allowed).Get(server.URL)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "tls page", string(body))
+}
+
+func TestWebFetchBrowserModeBlocksRedirectToPrivateAddress(t *testing.T) {
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is required to emulate the browser")
+	}
+	var secretHits int32
+	server := newRedirectToPrivateServer(t, &secretHits)
+	publicURL := strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/redirect"
+
+	// 用 curl 模拟浏览器：经请求中的 proxy 抓取并跟随重定向
+	script := filepath.Join(t.TempDir(), "fetch.sh")
+	body := "req=$(cat)\n" +
+		"proxy=$(printf '%s' \"$req\" | sed -n 's/.*\"proxy\":\"\\([^\"]*\\)\".*/\\1/p')\n" +
+		"if text=$(curl -sf -L -x \"$proxy\" '" + publicURL + "'); then\n" +
+		"  echo \"{\\\"ok\\\":true,\\\"proxied\\\":true,\\\"text\\\":\\\"$text\\\"}\"\n" +
+		"else\n" +
+		"  echo '{\"ok\":false,\"error\":\"net::ERR_EMPTY_RESPONSE\"}'\n" +
+		"fi\n"
+	require.NoError(t, os.WriteFile(script, []byte(body), 0755))
+
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script, IgnoreRobots: true, AllowedHosts: []string{"localhost"}})
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"url": publicURL})
+	var blocked *blockedHostError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, "127.0.0.1", blocked.host)
+	assert.Zero(t, atomic.LoadInt32(&secretHits))
+}
+
+func TestWebFetchRejectsBrowserScriptWithoutGuardProxy(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "fetch.sh")
+	require.NoError(t, os.WriteFile(script, []byte("echo '{\"ok\":true,\"text\":\"page\"}'\n"), 0755))
+
+	// 旧脚本不使用防护代理，防护开启时不能采信其结果
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script, IgnoreRobots: true})
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"url": "http://93.184.216.34/"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "update webfetcher/fetch.mjs")
+
+	tool = NewWebFetchTool(WebFetchOptions{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script, AllowPrivateNetwork: true})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": "http://93.184.216.34/"})
+	require.NoError(t, err)
+	assert.Equal(t, "page", result)
+}
@@ -0,0 +1,157 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+const (
+	// maxPDFBytes 解析 PDF 时允许的最大文件大小（web_fetch 下载和 read_pdf 共用）
+	maxPDFBytes = 20 * 1024 * 1024
+	// defaultReadPDFMaxLength read_pdf 默认返回的最大字节数
+	defaultReadPDFMaxLength = 50000
+	// maxReadPDFMaxLength read_pdf 的 max_length 参数上限
+	maxReadPDFMaxLength = 500000
+)
+
+// isPDFResponse 根据 Content-Type 或 URL 扩展名判断响应是否为 PDF
+func isPDFResponse(contentType, fetchURL string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/pdf" {
+		return true
+	}
+	if u, err := url.Parse(fetchURL); err == nil {
+		return strings.EqualFold(path.Ext(u.Path), ".pdf")
+	}
+	return false
+}
+
+// extractPDFText 按页提取 PDF 纯文本，页之间以空行分隔；PDF 库在遇到损坏文件时可能 panic，这里转换为错误
+func extractPDFText(data []byte) (text string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("failed to parse PDF: %v", r)
+		}
+	}()
+
+	reader, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse PDF: %w", err)
+	}
+
+	fonts := make(map[string]*pdf.Font)
+	pages := make([]string, 0, reader.NumPage())
+	for i := 1; i <= reader.NumPage(); i++ {
+		page := reader.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		for _, name := range page.Fonts() {
+			if _, ok := fonts[name]; !ok {
+				font := page.Font(name)
+				fonts[name] = &font
+			}
+		}
+		pageText, err := page.GetPlainText(fonts)
+		if err != nil {
+			return "", fmt.Errorf("failed to extract text from PDF page %d: %w", i, err)
+		}
+		if pageText = strings.TrimSpace(pageText); pageText != "" {
+			pages = append(pages, pageText)
+		}
+	}
+	if len(pages) == 0 {
+		return "", fmt.Errorf("PDF contains no extractable text (it may be scanned images)")
+	}
+	return strings.Join(pages, "\n\n"), nil
+}
+
+// readPDFBody 读取响应体中的 PDF 并提取文本，超过 maxPDFBytes 时报错
+func readPDFBody(body io.Reader) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxPDFBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read body: %w", err)
+	}
+	if len(data) > maxPDFBytes {
+		return "", fmt.Errorf("PDF exceeds the %d byte limit", maxPDFBytes)
+	}
+	return extractPDFText(data)
+}
+
+// ReadPDFTool 提取工作区内 PDF 文件的文本
+type ReadPDFTool struct {
+	BaseTool
+}
+
+// NewReadPDFTool 创建读取 PDF 工具
+func NewReadPDFTool() *ReadPDFTool {
+	return &ReadPDFTool{
+		BaseTool: BaseTool{
+			name:        "read_pdf",
+			description: "Extract the text of a PDF file in the workspace. Pages are separated by blank lines; scanned PDFs without a text layer return an error. Use web_fetch for PDF URLs.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Relative path to the PDF (e.g., 'docs/report.pdf'). Automatically resolves to the current session directory.",
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum number of bytes of text to return (optional, default %d)", defaultReadPDFMaxLength),
+						"minimum":     100,
+						"maximum":     maxReadPDFMaxLength,
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+	}
+}
+
+// ConcurrencySafe 只解析 PDF 文本，不修改文件，可以并发执行
+func (t *ReadPDFTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Execute 提取 PDF 文本并按 max_length 截断
+func (t *ReadPDFTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	filePath, _ := params["path"].(string)
+	resolvedPath, err := resolvePath(ctx, filePath)
+	if err != nil {
+		return "", err
+	}
+
+	maxLength := defaultReadPDFMaxLength
+	if v, ok := intParam(params["max_length"]); ok && v > 0 {
+		maxLength = v
+	}
+
+	f, err := os.Open(resolvedPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("failed to read PDF: %s is a directory", filePath)
+	}
+
+	text, err := readPDFBody(f)
+	if err != nil {
+		return "", err
+	}
+	return truncateText(text, maxLength), nil
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildTestPDF 生成每页一行 Helvetica 文本的最小 PDF
+func buildTestPDF(pages ...string) []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"", // Pages，页对象编号确定后再填
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+	}
+	kids := make([]string, 0, len(pages))
+	for _, text := range pages {
+		content := fmt.Sprintf("BT /F1 12 Tf 72 720 Td (%s) Tj ET", text)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+		contentID := len(objects)
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", contentID))
+		kids = append(kids, fmt.Sprintf("%d 0 R", len(objects)))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return buf.Bytes()
+}
+
+func TestExtractPDFText(t *testing.T) {
+	text, err := extractPDFText(buildTestPDF("Quarterly revenue grew", "Second page summary"))
+	require.NoError(t, err)
+	assert.Contains(t, text, "Quarterly revenue grew")
+	assert.Contains(t, text, "Second page summary")
+	assert.Less(t, strings.Index(text, "Quarterly"), strings.Index(text, "Second page"))
+
+	_, err = extractPDFText([]byte("not a pdf"))
+	require.Error(t, err)
+}
+
+func TestIsPDFResponse(t *testing.T) {
+	assert.True(t, isPDFResponse("application/pdf", "https://example.com/download"))
+	assert.True(t, isPDFResponse("application/pdf; charset=binary", "https://example.com/"))
+	assert.True(t, isPDFResponse("application/octet-stream", "https://example.com/paper.PDF?x=1"))
+	assert.False(t, isPDFResponse("text/html", "https://example.com/pdf"))
+	assert.False(t, isPDFResponse("", "https://example.com/index.html"))
+}
+
+func TestWebFetchExtractsPDFText(t *testing.T) {
+	doc := buildTestPDF("Quarterly revenue grew", "Second page summary")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/report.pdf" {
+			// 服务端未声明类型时按扩展名识别
+			w.Header().Set("Content-Type", "application/octet-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/pdf")
+		}
+		_, _ = w.Write(doc)
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true})
+	for _, path := range []string{"/download", "/report.pdf"} {
+		result, err := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL + path})
+		require.NoError(t, err, path)
+		assert.Contains(t, result, "Quarterly revenue grew", path)
+		assert.Contains(t, result, "Second page summary", path)
+		assert.NotContains(t, result, "%PDF", path)
+	}
+
+	long := buildTestPDF(strings.Repeat("word ", 60))
+	longServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+		_, _ = w.Write(long)
+	}))
+	defer longServer.Close()
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": longServer.URL, "max_length": float64(100)})
+	require.NoError(t, err)
+	assert.True(t, strings.HasSuffix(result, "... (content truncated)"))
+}
+
+func TestReadPDFTool(t *testing.T) {
+	tmpDir := t.TempDir()
+	SetAllowedDir(tmpDir)
+	SetWorkspaceDir(tmpDir)
+	t.Cleanup(func() {
+		SetAllowedDir("")
+		SetWorkspaceDir("")
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "report.pdf"), buildTestPDF("Hello from the PDF", "Page two"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "notes.pdf"), []byte("plain text"), 0644))
+
+	tool := NewReadPDFTool()
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"path": "report.pdf"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Hello from the PDF")
+	assert.Contains(t, result, "Page two")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"path": "notes.pdf"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse PDF")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"path": filepath.Join(t.TempDir(), "x.pdf")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "outside of allowed directory")
+}
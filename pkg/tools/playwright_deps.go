@@ -48,6 +48,7 @@ func runNodeScriptWithPlaywrightRetry(ctx context.Context, nodePath, scriptPath
 
 func runNodeScript(ctx context.Context, nodePath, scriptPath string, payload []byte) ([]byte, string, error) {
 	cmd := exec.CommandContext(ctx, nodePath, scriptPath)
+	cmd.WaitDelay = execWaitDelay
 	cmd.Stdin = bytes.NewReader(payload)
 	cmd.Env = append(os.Environ(), "PLAYWRIGHT_BROWSERS_PATH=0")
 
@@ -10,8 +10,14 @@ import (
 type Registry struct {
 	tools map[string]Tool
 	mu    sync.RWMutex
+
+	// resultLimits 按工具名配置的结果最大字节数，优先于工具内置的默认上限
+	resultLimits map[string]int
 }
 
+// resultSizeParams 工具用来控制输出大小的参数名；配置了结果上限且调用未指定时用上限作为默认值
+var resultSizeParams = []string{"max_bytes", "max_length", "max_chars"}
+
 // NewRegistry 创建工具注册表
 func NewRegistry() *Registry {
 	return &Registry{
@@ -46,19 +52,184 @@ func (r *Registry) Get(name string) (Tool, bool) {
 	return tool, exists
 }
 
-// Execute 执行工具
+// SetResultLimits 设置各工具的结果大小上限（字节），<=0 的条目忽略
+func (r *Registry) SetResultLimits(limits map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resultLimits = make(map[string]int, len(limits))
+	for name, limit := range limits {
+		if limit > 0 {
+			r.resultLimits[name] = limit
+		}
+	}
+}
+
+func (r *Registry) resultLimit(name string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resultLimits[name]
+}
+
+// Execute 执行工具；ctx 已取消时不再启动工具
 func (r *Registry) Execute(ctx context.Context, name string, params map[string]interface{}) (string, error) {
 	tool, exists := r.Get(name)
 	if !exists {
 		return "", fmt.Errorf("tool not found: %s", name)
 	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("tool %s not started: %w", name, err)
+	}
+
+	limit := r.resultLimit(name)
+	if limit > 0 {
+		params = withResultSizeDefault(tool.Parameters(), params, limit)
+	}
+	params = withSchemaDefaults(tool.Parameters(), params)
 
-	// 验证参数
-	if err := ValidateParams(tool.Parameters(), params); err != nil {
+	// 验证参数，一次报告全部问题
+	if err := ValidateParamsAll(tool.Parameters(), params); err != nil {
 		return fmt.Sprintf("Invalid parameters: %s", err.Error()), nil
 	}
 
-	return tool.Execute(ctx, params)
+	result, err := tool.Execute(ctx, params)
+	if limit > 0 {
+		result = truncateText(result, limit)
+	}
+	return result, err
+}
+
+// withResultSizeDefault 工具支持大小参数且本次调用未指定时，以配置的上限（限制在 schema 的 minimum/maximum 内）作为参数值，
+// 使工具内置的默认上限被配置覆盖；返回新的参数 map，不修改调用方的参数
+func withResultSizeDefault(schema, params map[string]interface{}, limit int) map[string]interface{} {
+	props, _ := schema["properties"].(map[string]interface{})
+	for _, key := range resultSizeParams {
+		prop, ok := props[key].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if _, set := params[key]; set {
+			return params
+		}
+		value := float64(limit)
+		if upper, ok := prop["maximum"]; ok && isNumber(upper) && value > toFloat64(upper) {
+			value = toFloat64(upper)
+		}
+		if lower, ok := prop["minimum"]; ok && isNumber(lower) && value < toFloat64(lower) {
+			value = toFloat64(lower)
+		}
+
+		merged := make(map[string]interface{}, len(params)+1)
+		for k, v := range params {
+			merged[k] = v
+		}
+		merged[key] = value
+		return merged
+	}
+	return params
+}
+
+// withSchemaDefaults 用 schema 中声明的 default 填充调用未提供的参数（含已提供的嵌套对象），已提供的值不覆盖；
+// 有需要填充的参数时返回新的 map，不修改调用方的参数
+func withSchemaDefaults(schema, params map[string]interface{}) map[string]interface{} {
+	filled, _ := applySchemaDefaults(schema, params)
+	return filled
+}
+
+// applySchemaDefaults 返回填充后的参数以及是否有改动
+func applySchemaDefaults(schema, params map[string]interface{}) (map[string]interface{}, bool) {
+	props, _ := schema["properties"].(map[string]interface{})
+	if len(props) == 0 {
+		return params, false
+	}
+
+	var merged map[string]interface{}
+	set := func(key string, value interface{}) {
+		if merged == nil {
+			merged = make(map[string]interface{}, len(params)+1)
+			for k, v := range params {
+				merged[k] = v
+			}
+		}
+		merged[key] = value
+	}
+
+	for key, rawProp := range props {
+		prop, ok := rawProp.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, exists := params[key]
+		if !exists {
+			if def, ok := prop["default"]; ok {
+				set(key, cloneDefault(def))
+			}
+			continue
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			if filled, changed := applySchemaDefaults(prop, nested); changed {
+				set(key, filled)
+			}
+		}
+	}
+
+	if merged == nil {
+		return params, false
+	}
+	return merged, true
+}
+
+// cloneDefault 复制 map / slice 类型的默认值，避免工具修改参数时改动 schema 本身
+func cloneDefault(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		cloned := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			cloned[key] = cloneDefault(item)
+		}
+		return cloned
+	case []interface{}:
+		cloned := make([]interface{}, len(v))
+		for i, item := range v {
+			cloned[i] = cloneDefault(item)
+		}
+		return cloned
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return value
+	}
+}
+
+// ConcurrencySafeTool 可选接口：返回 true 的工具不修改共享状态，可在同一轮内与其他此类工具并发执行
+type ConcurrencySafeTool interface {
+	ConcurrencySafe() bool
+}
+
+// IsConcurrencySafe 判断工具是否声明可并发执行；未注册或未实现该接口的工具视为不安全
+func (r *Registry) IsConcurrencySafe(name string) bool {
+	tool, exists := r.Get(name)
+	if !exists {
+		return false
+	}
+	cs, ok := tool.(ConcurrencySafeTool)
+	return ok && cs.ConcurrencySafe()
+}
+
+// RepeatableTool 可选接口：按调用参数判断结果是否会随时间变化（如轮询后台任务或日志），
+// 返回 true 的调用以相同参数重复时不视为死循环
+type RepeatableTool interface {
+	Repeatable(params map[string]interface{}) bool
+}
+
+// IsRepeatable 判断这次调用是否允许以相同参数重复；未注册或未实现该接口的工具视为不允许
+func (r *Registry) IsRepeatable(name string, params map[string]interface{}) bool {
+	tool, exists := r.Get(name)
+	if !exists {
+		return false
+	}
+	rt, ok := tool.(RepeatableTool)
+	return ok && rt.Repeatable(params)
 }
 
 // schemaTool 能够生成 OpenAI Schema 的工具接口
@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// robotsCacheTTL 同一站点的 robots.txt 规则缓存时长
+	robotsCacheTTL = time.Hour
+	// robotsFetchTimeout 获取 robots.txt 的超时时间
+	robotsFetchTimeout = 10 * time.Second
+	// maxRobotsBytes robots.txt 最多读取的字节数（RFC 9309 要求至少解析 500 KiB）
+	maxRobotsBytes = 512 * 1024
+)
+
+// robotsRule robots.txt 中的一条 Allow/Disallow 规则
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+type robotsGroup struct {
+	agents []string
+	rules  []robotsRule
+}
+
+type robotsEntry struct {
+	rules     []robotsRule
+	fetchedAt time.Time
+}
+
+// robotsChecker 按站点缓存 robots.txt，判断 URL 是否允许当前 User-Agent 抓取
+type robotsChecker struct {
+	userAgent string
+	client    *http.Client
+
+	mu      sync.Mutex
+	entries map[string]robotsEntry
+}
+
+func newRobotsChecker(userAgent string, transport http.RoundTripper) *robotsChecker {
+	return &robotsChecker{
+		userAgent: userAgent,
+		client:    &http.Client{Transport: transport, Timeout: robotsFetchTimeout},
+		entries:   make(map[string]robotsEntry),
+	}
+}
+
+// Allowed 返回 URL 是否允许抓取；robots.txt 不存在（4xx）或无法获取时视为允许
+func (c *robotsChecker) Allowed(ctx context.Context, target *url.URL) bool {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return true
+	}
+	path := target.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	if target.RawQuery != "" {
+		path += "?" + target.RawQuery
+	}
+
+	rules, ok := c.rulesFor(ctx, target)
+	if !ok {
+		return true
+	}
+	return robotsPathAllowed(rules, path)
+}
+
+func (c *robotsChecker) rulesFor(ctx context.Context, target *url.URL) ([]robotsRule, bool) {
+	site := target.Scheme + "://" + target.Host
+	c.mu.Lock()
+	entry, cached := c.entries[site]
+	c.mu.Unlock()
+	if cached && time.Since(entry.fetchedAt) < robotsCacheTTL {
+		return entry.rules, true
+	}
+
+	rules, err := c.fetch(ctx, site)
+	if err != nil {
+		// 网络错误不缓存，下次重试
+		return nil, false
+	}
+	c.mu.Lock()
+	c.entries[site] = robotsEntry{rules: rules, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return rules, true
+}
+
+func (c *robotsChecker) fetch(ctx context.Context, site string) ([]robotsRule, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, site+"/robots.txt", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, maxRobotsBytes), c.userAgent), nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		// 没有 robots.txt：全部允许
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("robots.txt returned HTTP %d", resp.StatusCode)
+	}
+}
+
+// parseRobots 解析 robots.txt，返回适用于 userAgent 的规则：优先匹配最具体（最长）的 User-agent 名称，没有时使用 "*" 组
+func parseRobots(r io.Reader, userAgent string) []robotsRule {
+	var groups []*robotsGroup
+	var current *robotsGroup
+	lastWasAgent := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// 连续的 User-agent 行属于同一组
+			if current == nil || !lastWasAgent {
+				current = &robotsGroup{}
+				groups = append(groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			lastWasAgent = true
+		case "allow", "disallow":
+			lastWasAgent = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, robotsRule{allow: key == "allow", pattern: value})
+		}
+	}
+
+	ua := strings.ToLower(userAgent)
+	best := -1
+	var rules []robotsRule
+	for _, group := range groups {
+		score := -1
+		for _, agent := range group.agents {
+			switch {
+			case agent == "*":
+				score = max(score, 0)
+			case agent != "" && strings.Contains(ua, agent):
+				score = max(score, len(agent))
+			}
+		}
+		switch {
+		case score > best:
+			best = score
+			rules = append([]robotsRule(nil), group.rules...)
+		case score == best && score >= 0:
+			// 同名的多个组合并
+			rules = append(rules, group.rules...)
+		}
+	}
+	return rules
+}
+
+// robotsPathAllowed 最长匹配的规则生效，长度相同时 Allow 优先；没有匹配的规则时允许
+func robotsPathAllowed(rules []robotsRule, path string) bool {
+	allowed := true
+	longest := -1
+	for _, rule := range rules {
+		if !robotsPatternMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || (n == longest && rule.allow) {
+			longest = n
+			allowed = rule.allow
+		}
+	}
+	return allowed
+}
+
+// robotsPatternMatch 前缀匹配，支持 "*" 通配符和结尾的 "$" 锚点
+func robotsPatternMatch(pattern, path string) bool {
+	if !strings.ContainsAny(pattern, "*$") {
+		return strings.HasPrefix(path, pattern)
+	}
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
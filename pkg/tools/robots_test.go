@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const robotsFixture = `# sample robots.txt
+User-agent: *
+Disallow: /private
+Allow: /private/open
+Disallow: /*.json$
+
+User-agent: maxclaw-bot
+User-agent: other-bot
+Disallow: /
+Allow: /public
+`
+
+func TestParseRobotsSelectsMatchingGroup(t *testing.T) {
+	generic := parseRobots(strings.NewReader(robotsFixture), "Mozilla/5.0 Chrome/120.0")
+	specific := parseRobots(strings.NewReader(robotsFixture), "Maxclaw-Bot/1.0")
+
+	cases := []struct {
+		rules []robotsRule
+		path  string
+		want  bool
+	}{
+		{generic, "/", true},
+		{generic, "/docs/index.html", true},
+		{generic, "/private/keys", false},
+		{generic, "/private/open/readme", true},
+		{generic, "/data/feed.json", false},
+		{generic, "/data/feed.json?page=2", true},
+		{specific, "/docs", false},
+		{specific, "/public/page", true},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.want, robotsPathAllowed(tc.rules, tc.path), tc.path)
+	}
+
+	// 空 Disallow 表示全部允许
+	assert.True(t, robotsPathAllowed(parseRobots(strings.NewReader("User-agent: *\nDisallow:\n"), "any"), "/x"))
+}
+
+func newRobotsTestServer(t *testing.T, robots string, robotsHits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			atomic.AddInt32(robotsHits, 1)
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(robots))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body><p>page " + r.URL.Path + "</p></body></html>"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWebFetchHonorsRobotsTxt(t *testing.T) {
+	var hits int32
+	server := newRobotsTestServer(t, robotsFixture, &hits)
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true})
+	ctx := context.Background()
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"url": server.URL + "/docs"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "page /docs")
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"url": server.URL + "/private/keys"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "robots.txt")
+	assert.Contains(t, err.Error(), "ignoreRobots")
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"url": server.URL + "/private/open"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "page /private/open")
+
+	// 同一站点的 robots.txt 只获取一次
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestWebFetchIgnoreRobotsAndMissingRobots(t *testing.T) {
+	var hits int32
+	server := newRobotsTestServer(t, robotsFixture, &hits)
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true, IgnoreRobots: true})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/private/keys"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "page /private/keys")
+	assert.Zero(t, atomic.LoadInt32(&hits))
+
+	var missingHits int32
+	missing := newRobotsTestServer(t, "", &missingHits)
+	tool = NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true})
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"url": missing.URL + "/private/keys"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "page /private/keys")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&missingHits))
+}
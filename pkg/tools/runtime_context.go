@@ -1,6 +1,9 @@
 package tools
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 type runtimeContextKey string
 
@@ -8,8 +11,12 @@ const (
 	runtimeChannelKey runtimeContextKey = "channel"
 	runtimeChatIDKey  runtimeContextKey = "chat_id"
 	runtimeSessionKey runtimeContextKey = "session_key"
+	runtimeOutputKey  runtimeContextKey = "output"
 )
 
+// OutputFunc receives incremental tool output chunks while a tool is still running.
+type OutputFunc func(chunk string)
+
 // WithRuntimeContext injects channel/chat metadata for tools in the current request.
 func WithRuntimeContext(ctx context.Context, channel, chatID string) context.Context {
 	return WithRuntimeContextWithSession(ctx, channel, chatID, "")
@@ -38,6 +45,32 @@ func RuntimeContextFrom(ctx context.Context) (channel, chatID string) {
 	return channel, chatID
 }
 
+// runtimeContextFallback 保存通过 SetContext 设置的 channel/chat；
+// 只有本次调用的 context 中没有 channel/chat 时才使用，context 中的值优先
+type runtimeContextFallback struct {
+	mu      sync.RWMutex
+	channel string
+	chatID  string
+}
+
+func (f *runtimeContextFallback) set(channel, chatID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.channel = channel
+	f.chatID = chatID
+}
+
+// resolve 返回 context 中的 channel/chat，两者都缺失时回退到 SetContext 设置的值
+func (f *runtimeContextFallback) resolve(ctx context.Context) (channel, chatID string) {
+	channel, chatID = RuntimeContextFrom(ctx)
+	if channel != "" || chatID != "" {
+		return channel, chatID
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.channel, f.chatID
+}
+
 // RuntimeSessionKeyFrom extracts session key metadata from context.
 func RuntimeSessionKeyFrom(ctx context.Context) string {
 	if ctx == nil {
@@ -49,3 +82,20 @@ func RuntimeSessionKeyFrom(ctx context.Context) string {
 	}
 	return ""
 }
+
+// WithOutputHandler injects a callback for incremental tool output (e.g. streaming exec).
+func WithOutputHandler(ctx context.Context, fn OutputFunc) context.Context {
+	return context.WithValue(ctx, runtimeOutputKey, fn)
+}
+
+// OutputHandlerFrom extracts the incremental output callback from context.
+func OutputHandlerFrom(ctx context.Context) OutputFunc {
+	if ctx == nil {
+		return nil
+	}
+
+	if fn, ok := ctx.Value(runtimeOutputKey).(OutputFunc); ok {
+		return fn
+	}
+	return nil
+}
@@ -1,12 +1,18 @@
 package tools
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode"
 )
@@ -23,17 +29,40 @@ var dangerousPatterns = []string{
 	`chmod\s+-R\s+000\s+/`, // 递归修改根目录权限
 }
 
-// isDangerousCommand 检查命令是否危险
-func isDangerousCommand(command string) error {
-	lowerCmd := strings.ToLower(command)
+// defaultDangerousRegexps 预编译的默认危险命令模式
+var defaultDangerousRegexps = mustCompilePatterns(dangerousPatterns)
 
-	for _, pattern := range dangerousPatterns {
-		matched, err := regexp.MatchString(pattern, lowerCmd)
-		if err != nil {
+func mustCompilePatterns(patterns []string) []*regexp.Regexp {
+	compiled, err := compileDangerousPatterns(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+// compileDangerousPatterns 编译危险命令正则，任一模式非法即返回错误
+func compileDangerousPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if strings.TrimSpace(pattern) == "" {
 			continue
 		}
-		if matched {
-			return fmt.Errorf("dangerous command detected: pattern '%s' matched", pattern)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dangerous pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// isDangerousCommand 检查命令是否匹配任一危险模式
+func isDangerousCommand(command string, patterns []*regexp.Regexp) error {
+	lowerCmd := strings.ToLower(command)
+
+	for _, re := range patterns {
+		if re.MatchString(lowerCmd) {
+			return fmt.Errorf("dangerous command detected: pattern '%s' matched", re.String())
 		}
 	}
 
@@ -43,21 +72,57 @@ func isDangerousCommand(command string) error {
 // ExecTool Shell 执行工具
 type ExecTool struct {
 	BaseTool
-	WorkingDir          string
-	Timeout             time.Duration
+	WorkingDir string
+	Timeout    time.Duration
+	// MaxTimeout 单次调用 timeout 参数允许的上限
+	MaxTimeout          time.Duration
 	RestrictToWorkspace bool
+	// AllowedCommands 非空时，命令中每个管道/分隔段的程序名都必须在列表中
+	AllowedCommands []string
+	// StreamOutput 为 true 且 context 中有输出回调时，边执行边推送输出，返回结果只保留末尾 10KB
+	StreamOutput bool
+
+	dangerousRegexps []*regexp.Regexp
+	// disabledErr 非空时拒绝执行任何命令（如配置的危险命令模式非法）
+	disabledErr error
+	// outputs 保存被截断的完整输出，供 exec_output 分页读取
+	outputs *execOutputStore
 }
 
-// NewExecTool 创建 Shell 执行工具
+// NewExecTool 创建 Shell 执行工具（使用默认危险命令模式）
 func NewExecTool(workingDir string, timeout int, restrictToWorkspace bool) *ExecTool {
+	tool, _ := NewExecToolWithPatterns(workingDir, timeout, restrictToWorkspace, nil, false)
+	return tool
+}
+
+// NewDisabledExecTool 创建拒绝执行任何命令的 exec 工具，reason 会返回给模型；
+// 用于危险命令配置非法时失败关闭，而不是悄悄换成另一套模式
+func NewDisabledExecTool(workingDir string, timeout int, restrictToWorkspace bool, reason error) *ExecTool {
+	tool := NewExecTool(workingDir, timeout, restrictToWorkspace)
+	tool.disabledErr = reason
+	return tool
+}
+
+// NewExecToolWithPatterns 创建 Shell 执行工具并追加自定义危险命令模式；
+// replaceDefaults 为 true 且 patterns 非空时替换默认模式，patterns 为空时始终使用默认模式
+func NewExecToolWithPatterns(workingDir string, timeout int, restrictToWorkspace bool, patterns []string, replaceDefaults bool) (*ExecTool, error) {
+	custom, err := compileDangerousPatterns(patterns)
+	if err != nil {
+		return nil, err
+	}
+	dangerous := custom
+	if !replaceDefaults || len(custom) == 0 {
+		dangerous = append(append([]*regexp.Regexp{}, defaultDangerousRegexps...), custom...)
+	}
+
 	if timeout <= 0 {
-		timeout = 60
+		timeout = defaultExecTimeout
 	}
 
 	tool := &ExecTool{
 		BaseTool: BaseTool{
 			name:        "exec",
-			description: "Execute shell commands. Use for running code, managing files, or system operations. Command timeout is enforced.",
+			description: execToolDescription(restrictToWorkspace, ""),
 			parameters: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -66,10 +131,13 @@ func NewExecTool(workingDir string, timeout int, restrictToWorkspace bool) *Exec
 						"description": "The shell command to execute",
 					},
 					"timeout": map[string]interface{}{
-						"type":        "integer",
-						"description": "Timeout in seconds (optional, default: 60)",
-						"minimum":     1,
-						"maximum":     300,
+						"type":    "integer",
+						"minimum": 1,
+					},
+					"env": map[string]interface{}{
+						"type":                 "object",
+						"description":          "Extra environment variables for this command (optional), merged onto the current environment. Variables that change how programs are found or loaded (PATH, LD_*, DYLD_*, BASH_ENV, ENV, IFS, ...) cannot be set",
+						"additionalProperties": map[string]interface{}{"type": "string"},
 					},
 				},
 				"required": []string{"command"},
@@ -78,20 +146,81 @@ func NewExecTool(workingDir string, timeout int, restrictToWorkspace bool) *Exec
 		WorkingDir:          workingDir,
 		Timeout:             time.Duration(timeout) * time.Second,
 		RestrictToWorkspace: restrictToWorkspace,
+		dangerousRegexps:    dangerous,
+		outputs:             newExecOutputStore(),
 	}
+	tool.SetMaxTimeout(defaultExecMaxTimeout)
 
-	return tool
+	return tool, nil
+}
+
+const (
+	defaultExecTimeout    = 60
+	defaultExecMaxTimeout = 300
+	// execWaitDelay 命令被取消后等待输出管道关闭的最长时间
+	execWaitDelay = 2 * time.Second
+)
+
+const (
+	execBaseDescription = "Execute shell commands. Use for running code, managing files, or system operations. Command timeout is enforced. Returns exit_code followed by separate stdout and stderr sections."
+	// execRestrictedNote 受限模式下的约束说明，避免模型反复尝试会被拒绝的命令
+	execRestrictedNote = "RESTRICTED MODE: commands run inside the workspace directory and are rejected (not executed) when they break these rules: " +
+		"use relative paths only - absolute paths outside the workspace and '..' escapes are blocked; " +
+		"do not use '~', $HOME, ${HOME} or $USERPROFILE; " +
+		"do not use command substitution ($(...) or backticks). " +
+		"If a command is rejected, rewrite it within these limits instead of retrying it unchanged."
+)
+
+// execToolDescription 生成 exec 工具描述；受限模式追加约束说明与可选的自定义提示
+func execToolDescription(restricted bool, extra string) string {
+	if !restricted {
+		return execBaseDescription
+	}
+	desc := execBaseDescription + "\n\n" + execRestrictedNote
+	if extra = strings.TrimSpace(extra); extra != "" {
+		desc += "\n" + extra
+	}
+	return desc
+}
+
+// SetRestrictedPrompt 在受限模式的工具描述后追加自定义说明（非受限模式下忽略）
+func (t *ExecTool) SetRestrictedPrompt(prompt string) {
+	t.description = execToolDescription(t.RestrictToWorkspace, prompt)
+}
+
+// SetMaxTimeout 设置单次调用 timeout 参数的上限（秒），不低于默认超时，并同步到参数 schema
+func (t *ExecTool) SetMaxTimeout(seconds int) {
+	if seconds <= 0 {
+		seconds = defaultExecMaxTimeout
+	}
+	if floor := int(t.Timeout / time.Second); seconds < floor {
+		seconds = floor
+	}
+	t.MaxTimeout = time.Duration(seconds) * time.Second
+
+	props := t.parameters["properties"].(map[string]interface{})
+	timeoutSchema := props["timeout"].(map[string]interface{})
+	timeoutSchema["maximum"] = seconds
+	timeoutSchema["description"] = fmt.Sprintf("Timeout in seconds (optional, default: %d, max: %d)", int(t.Timeout/time.Second), seconds)
 }
 
 // Execute 执行 Shell 命令
 func (t *ExecTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	if t.disabledErr != nil {
+		return "", fmt.Errorf("exec is disabled: %w", t.disabledErr)
+	}
 	command, _ := params["command"].(string)
 	if command == "" {
 		return "", fmt.Errorf("command is required")
 	}
 
+	// 检查命令白名单
+	if err := checkAllowedCommands(command, t.AllowedCommands); err != nil {
+		return "", err
+	}
+
 	// 检查危险命令
-	if err := isDangerousCommand(command); err != nil {
+	if err := isDangerousCommand(command, t.dangerousRegexps); err != nil {
 		return "", err
 	}
 
@@ -111,12 +240,22 @@ func (t *ExecTool) Execute(ctx context.Context, params map[string]interface{}) (
 		return "", fmt.Errorf("restrictToWorkspace enabled but working directory is empty")
 	}
 
+	env, err := parseExecEnv(params["env"])
+	if err != nil {
+		return "", err
+	}
+	if t.RestrictToWorkspace {
+		if err := validateEnvInWorkspace(env, workDir); err != nil {
+			return "", err
+		}
+	}
+
 	// 确定超时时间
 	timeout := t.Timeout
-	if v, ok := params["timeout"].(float64); ok {
-		t := int(v)
-		if t > 0 {
-			timeout = time.Duration(t) * time.Second
+	if seconds, ok := intParam(params["timeout"]); ok && seconds > 0 {
+		timeout = time.Duration(seconds) * time.Second
+		if t.MaxTimeout > 0 && timeout > t.MaxTimeout {
+			return "", fmt.Errorf("timeout %ds exceeds maximum of %ds", seconds, int(t.MaxTimeout/time.Second))
 		}
 	}
 
@@ -126,27 +265,340 @@ func (t *ExecTool) Execute(ctx context.Context, params map[string]interface{}) (
 
 	// 执行命令
 	cmd := exec.CommandContext(execCtx, "sh", "-c", command)
+	// sh 被杀死后，仍持有输出管道的子进程（如管道中的 sleep）不会阻塞返回
+	cmd.WaitDelay = execWaitDelay
 	if workDir != "" {
 		cmd.Dir = workDir
 	}
+	if len(env) > 0 {
+		cmd.Env = mergeExecEnv(os.Environ(), env)
+	}
 
-	output, err := cmd.CombinedOutput()
+	var stdout, stderr execOutput
+	if onOutput := OutputHandlerFrom(ctx); t.StreamOutput && onOutput != nil {
+		var mu sync.Mutex
+		emit := func(chunk string) {
+			mu.Lock()
+			defer mu.Unlock()
+			onOutput(chunk)
+		}
+		// 保留与非流式相同的上限，超长输出仍可通过 exec_output 分页读取
+		stdout = &streamingOutput{tail: tailBuffer{max: maxExecRetainedSize}, emit: emit}
+		stderr = &streamingOutput{tail: tailBuffer{max: maxExecRetainedSize}, emit: emit}
+	} else {
+		stdout = &bytesOutput{}
+		stderr = &bytesOutput{}
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	err = cmd.Run()
 
-	// 截断输出（限制 10KB）
-	maxOutputSize := 10 * 1024
-	outputStr := string(output)
-	if len(outputStr) > maxOutputSize {
-		outputStr = outputStr[:maxOutputSize] + "\n... (output truncated)"
+	exitCode := 0
+	if err != nil {
+		exitCode = -1
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode()
+		}
 	}
 
+	result := formatExecStreams(exitCode, t.pageStream(ctx, stdout.String()), t.pageStream(ctx, stderr.String()))
 	if err != nil {
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("command canceled: %w", ctx.Err())
+		}
 		if execCtx.Err() == context.DeadlineExceeded {
-			return fmt.Sprintf("Command timed out after %v\nPartial output:\n%s", timeout, outputStr), nil
+			return fmt.Sprintf("Command timed out after %v\n%s", timeout, result), nil
+		}
+		if exitCode == -1 {
+			return fmt.Sprintf("Command failed: %v\n%s", err, result), nil
 		}
-		return fmt.Sprintf("Command failed: %v\nOutput:\n%s", err, outputStr), nil
 	}
 
-	return outputStr, nil
+	return result, nil
+}
+
+func intParam(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int(n), true
+	case int:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// maxExecStreamSize 每个输出流单次返回给模型的最大字节数（超出部分经 exec_output 分页）
+const maxExecStreamSize = 10 * 1024
+
+// execOutput 收集单个输出流
+type execOutput interface {
+	io.Writer
+	String() string
+}
+
+type bytesOutput struct {
+	bytes.Buffer
+}
+
+// streamingOutput 把输出实时推送给回调，同时只保留末尾部分用于最终结果
+type streamingOutput struct {
+	tail tailBuffer
+	emit func(string)
+}
+
+func (o *streamingOutput) Write(p []byte) (int, error) {
+	o.emit(string(p))
+	return o.tail.Write(p)
+}
+
+func (o *streamingOutput) String() string {
+	return o.tail.String()
+}
+
+const execTailTruncatedMarker = "... (earlier output truncated)\n"
+
+// tailBuffer 只保留最近写入的 max 字节；缓冲超过 2*max 时才整理，避免每次写入都复制
+type tailBuffer struct {
+	max       int
+	buf       []byte
+	truncated bool
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > 2*b.max {
+		b.buf = append([]byte(nil), b.buf[len(b.buf)-b.max:]...)
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	keep := b.buf
+	if len(keep) > b.max {
+		keep = keep[len(keep)-b.max:]
+	} else if !b.truncated {
+		return string(keep)
+	}
+	if limit := b.max - len(execTailTruncatedMarker); limit > 0 && len(keep) > limit {
+		keep = keep[len(keep)-limit:]
+	}
+	return execTailTruncatedMarker + string(keep)
+}
+
+// formatExecStreams 拼接已截断处理的 stdout/stderr
+func formatExecStreams(exitCode int, stdout, stderr string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "exit_code: %d\n", exitCode)
+	sb.WriteString("--- stdout ---\n")
+	sb.WriteString(stdout)
+	sb.WriteString("\n--- stderr ---\n")
+	sb.WriteString(stderr)
+	return sb.String()
+}
+
+// pageStream 超长输出只返回第一页，完整内容留给 exec_output 续读
+func (t *ExecTool) pageStream(ctx context.Context, output string) string {
+	if t.outputs == nil {
+		return truncateExecStream(output)
+	}
+	return t.outputs.pageExecStream(RuntimeSessionKeyFrom(ctx), output)
+}
+
+func truncateExecStream(output string) string {
+	output = strings.TrimRight(output, "\n")
+	if len(output) > maxExecStreamSize {
+		output = output[:maxExecStreamSize] + "\n... (output truncated)"
+	}
+	return output
+}
+
+// deniedExecEnv 模型不能通过 env 参数覆盖的变量：它们决定命令查找、动态库加载或 shell 启动行为，
+// 可让无害的命令（如 ls）执行工作区外的任意程序，从而绕过 dangerousPatterns
+var deniedExecEnv = map[string]bool{
+	"PATH":           true,
+	"IFS":            true,
+	"ENV":            true,
+	"BASH_ENV":       true,
+	"SHELLOPTS":      true,
+	"BASHOPTS":       true,
+	"PS4":            true,
+	"PROMPT_COMMAND": true,
+	"CDPATH":         true,
+	"GLOBIGNORE":     true,
+}
+
+// deniedExecEnvPrefixes 按前缀拒绝的变量（动态链接器与导出的 bash 函数）
+var deniedExecEnvPrefixes = []string{"LD_", "DYLD_", "BASH_FUNC_"}
+
+func isDeniedExecEnv(name string) bool {
+	name = strings.ToUpper(name)
+	if deniedExecEnv[name] {
+		return true
+	}
+	for _, prefix := range deniedExecEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExecEnv 解析 env 参数（字符串到字符串的映射），拒绝 deniedExecEnv 中的变量
+func parseExecEnv(raw interface{}) (map[string]string, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	obj, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("env must be an object of string values")
+	}
+
+	env := make(map[string]string, len(obj))
+	for key, value := range obj {
+		if key == "" || strings.ContainsAny(key, "= \t\n") {
+			return nil, fmt.Errorf("invalid env name: %q", key)
+		}
+		if isDeniedExecEnv(key) {
+			return nil, fmt.Errorf("env %s cannot be overridden", key)
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("env %s must be a string", key)
+		}
+		env[key] = str
+	}
+	return env, nil
+}
+
+// validateEnvInWorkspace 受限模式下，看起来像路径的 env 值（含 PATH 风格列表）不能逃出工作区
+func validateEnvInWorkspace(env map[string]string, workspace string) error {
+	for key, value := range env {
+		for _, part := range filepath.SplitList(value) {
+			if !looksLikePath(part) {
+				continue
+			}
+			if err := ensurePathWithinWorkspace(part, workspace); err != nil {
+				return fmt.Errorf("env %s: %w", key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeExecEnv 将 overrides 合并到 base 上，同名变量以 overrides 为准
+func mergeExecEnv(base []string, overrides map[string]string) []string {
+	merged := make([]string, 0, len(base)+len(overrides))
+	for _, kv := range base {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, ok := overrides[name]; ok {
+			continue
+		}
+		merged = append(merged, kv)
+	}
+
+	keys := make([]string, 0, len(overrides))
+	for key := range overrides {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		merged = append(merged, key+"="+overrides[key])
+	}
+	return merged
+}
+
+// checkAllowedCommands 校验命令每一段的程序名都在白名单中；allowed 为空时不限制
+func checkAllowedCommands(command string, allowed []string) error {
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	// 命令替换可以执行任意程序，白名单模式下直接拒绝
+	if strings.Contains(command, "$(") || strings.Contains(command, "`") {
+		return fmt.Errorf("command substitution is not allowed when exec allow-list is enabled")
+	}
+
+	allowSet := make(map[string]struct{}, len(allowed))
+	for _, name := range allowed {
+		if name = strings.TrimSpace(name); name != "" {
+			allowSet[name] = struct{}{}
+		}
+	}
+
+	for _, segment := range splitShellSegments(command) {
+		program := segmentProgram(splitShellWords(segment))
+		if program == "" {
+			continue
+		}
+		if _, ok := allowSet[program]; !ok {
+			return fmt.Errorf("command '%s' is not in the exec allow-list (allowed: %s)", program, strings.Join(allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// splitShellSegments 按引号外的 | & ; 换行和括号切分命令
+func splitShellSegments(command string) []string {
+	var segments []string
+	var buf strings.Builder
+	inSingle := false
+	inDouble := false
+	escape := false
+
+	flush := func() {
+		if seg := strings.TrimSpace(buf.String()); seg != "" {
+			segments = append(segments, seg)
+		}
+		buf.Reset()
+	}
+
+	runes := []rune(command)
+	for i, r := range runes {
+		if escape {
+			buf.WriteRune(r)
+			escape = false
+			continue
+		}
+		// 2>&1、&>file 属于重定向而不是后台/分隔符
+		isRedirectAmp := r == '&' && ((i > 0 && runes[i-1] == '>') || (i+1 < len(runes) && runes[i+1] == '>'))
+		switch {
+		case isRedirectAmp:
+			buf.WriteRune(r)
+		case r == '\\' && !inSingle:
+			escape = true
+			buf.WriteRune(r)
+		case r == '\'' && !inDouble:
+			inSingle = !inSingle
+			buf.WriteRune(r)
+		case r == '"' && !inSingle:
+			inDouble = !inDouble
+			buf.WriteRune(r)
+		case !inSingle && !inDouble && strings.ContainsRune("|&;\n()", r):
+			flush()
+		default:
+			buf.WriteRune(r)
+		}
+	}
+
+	flush()
+	return segments
+}
+
+// segmentProgram 返回一段命令中实际执行的程序，跳过前导的环境变量赋值与重定向
+func segmentProgram(words []string) string {
+	for _, word := range words {
+		if strings.HasPrefix(word, ">") || strings.HasPrefix(word, "<") {
+			continue
+		}
+		if name, _, ok := strings.Cut(word, "="); ok && name != "" && !strings.ContainsAny(name, "/\\") {
+			continue
+		}
+		return word
+	}
+	return ""
 }
 
 func validateCommandInWorkspace(command, workspace string) error {
@@ -0,0 +1,137 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SkillInfo 技能的基本信息
+type SkillInfo struct {
+	Name        string
+	DisplayName string
+	Description string
+	Source      string
+}
+
+// SkillsService 技能目录与会话激活状态接口
+type SkillsService interface {
+	ListSkills() ([]SkillInfo, error)
+	ActiveSkills(sessionKey string) []string
+	// SetActiveSkills 设置会话的激活技能并返回解析后的技能名；names 为空表示清除
+	SetActiveSkills(sessionKey string, names []string) ([]string, error)
+}
+
+// SkillsTool 列出可用技能并设置当前会话的激活技能
+type SkillsTool struct {
+	BaseTool
+	service SkillsService
+}
+
+// NewSkillsTool 创建技能工具
+func NewSkillsTool(service SkillsService) *SkillsTool {
+	return &SkillsTool{
+		BaseTool: BaseTool{
+			name:        "skills",
+			description: "Manage the active skill set of this conversation. Use action=list to see available skills (active ones are marked), action=activate with names to make only those skills apply to all following turns (no need to repeat @skill: in every message), and action=clear to go back to loading all skills. A message that contains @skill: selectors still overrides the active set for that turn.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"list", "activate", "clear"},
+						"description": "Action to perform",
+					},
+					"names": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Skill names to activate, replacing the current active set (required for activate)",
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		service: service,
+	}
+}
+
+// Execute 执行技能操作
+func (t *SkillsTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	if t.service == nil {
+		return "", fmt.Errorf("skills service not available")
+	}
+	action, _ := params["action"].(string)
+
+	switch strings.TrimSpace(action) {
+	case "list":
+		return t.list(ctx)
+	case "activate":
+		names := toStringSlice(params["names"])
+		if len(names) == 0 {
+			return "", fmt.Errorf("names is required for activate")
+		}
+		return t.setActive(ctx, names)
+	case "clear":
+		return t.setActive(ctx, nil)
+	default:
+		return "", fmt.Errorf("unknown action %q (expected list, activate or clear)", action)
+	}
+}
+
+func (t *SkillsTool) list(ctx context.Context) (string, error) {
+	entries, err := t.service.ListSkills()
+	if err != nil {
+		return "", fmt.Errorf("failed to list skills: %w", err)
+	}
+	if len(entries) == 0 {
+		return "No skills available.", nil
+	}
+
+	active := make(map[string]bool)
+	if sessionKey := RuntimeSessionKeyFrom(ctx); sessionKey != "" {
+		for _, name := range t.service.ActiveSkills(sessionKey) {
+			active[name] = true
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Available skills (%d):\n", len(entries))
+	for _, entry := range entries {
+		marker := " "
+		if active[entry.Name] {
+			marker = "*"
+		}
+		line := fmt.Sprintf("%s %s", marker, entry.Name)
+		if entry.DisplayName != "" && entry.DisplayName != entry.Name {
+			line += fmt.Sprintf(" (%s)", entry.DisplayName)
+		}
+		if entry.Source != "" {
+			line += fmt.Sprintf(" [%s]", entry.Source)
+		}
+		if entry.Description != "" {
+			line += ": " + entry.Description
+		}
+		sb.WriteString(line + "\n")
+	}
+	if len(active) > 0 {
+		sb.WriteString("(* = active in this conversation)")
+	} else {
+		sb.WriteString("No active skill set; all skills are loaded.")
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func (t *SkillsTool) setActive(ctx context.Context, names []string) (string, error) {
+	sessionKey := RuntimeSessionKeyFrom(ctx)
+	if sessionKey == "" {
+		return "", fmt.Errorf("no session context")
+	}
+	active, err := t.service.SetActiveSkills(sessionKey, names)
+	if err != nil {
+		return "", err
+	}
+	if len(active) == 0 {
+		return "Active skills cleared; all skills are loaded from the next turn.", nil
+	}
+	return fmt.Sprintf("Active skills set to: %s (applies from the next turn until cleared).", strings.Join(active, ", ")), nil
+}
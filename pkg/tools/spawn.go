@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -32,30 +33,47 @@ type SpawnResult struct {
 // SpawnCallback 子代理回调函数类型
 type SpawnCallback func(ctx context.Context, request SpawnRequest) (SpawnResult, error)
 
+const (
+	// maxFinishedSpawnTasks 保留供查询结果的已结束任务数量上限
+	maxFinishedSpawnTasks = 50
+	// defaultSpawnResultTTL 已结束任务结果的保留时长
+	defaultSpawnResultTTL = 30 * time.Minute
+	// defaultSpawnWaitSeconds / maxSpawnWaitSeconds wait 动作的默认与最大等待秒数
+	defaultSpawnWaitSeconds = 30
+	maxSpawnWaitSeconds     = 300
+)
+
 // SpawnTool 子代理工具 - 用于后台任务执行
 type SpawnTool struct {
 	BaseTool
-	callback     SpawnCallback
-	mu           sync.RWMutex
-	channel      string
-	chatID       string
-	runningTasks map[string]*SpawnTask
+	callback SpawnCallback
+	mu       sync.RWMutex
+	// tasks 运行中与最近结束的任务，结束的任务在 resultTTL 内保留结果以便查询
+	tasks     map[string]*SpawnTask
+	resultTTL time.Duration
+	// fallback SetContext 设置的默认 channel/chat
+	fallback runtimeContextFallback
 }
 
 // SpawnTask 表示一个正在运行的后台任务
 type SpawnTask struct {
-	ID         string
-	Label      string
-	Task       string
-	Model      string
-	Skills     []string
-	Sources    []string
-	SessionKey string
-	StartTime  time.Time
-	EndTime    *time.Time
-	Status     string
-	Result     string
-	Error      string
+	ID         string   `json:"id"`
+	Label      string   `json:"label,omitempty"`
+	Task       string   `json:"task"`
+	Model      string   `json:"model,omitempty"`
+	Skills     []string `json:"skills,omitempty"`
+	Sources    []string `json:"sources,omitempty"`
+	SessionKey string   `json:"sessionKey,omitempty"`
+	// OwnerSessionKey 发起 spawn 的会话，list / status / result / wait 只能看到本会话的任务
+	OwnerSessionKey string     `json:"ownerSessionKey,omitempty"`
+	StartTime       time.Time  `json:"startTime"`
+	EndTime         *time.Time `json:"endTime,omitempty"`
+	Status          string     `json:"status"`
+	Result          string     `json:"result,omitempty"`
+	Error           string     `json:"error,omitempty"`
+
+	// done 任务结束时关闭，供 wait 动作等待
+	done chan struct{}
 }
 
 // NewSpawnTool 创建子代理工具
@@ -63,14 +81,28 @@ func NewSpawnTool(callback SpawnCallback) *SpawnTool {
 	return &SpawnTool{
 		BaseTool: BaseTool{
 			name:        "spawn",
-			description: "Spawn a subagent to handle a task in the background. Use this for complex or time-consuming tasks that can run independently. The subagent will complete the task and report back when done.",
+			description: "Spawn a subagent to handle a task in the background. Use this for complex or time-consuming tasks that can run independently. The subagent will complete the task and report back when done. Use action=list to see spawned tasks, action=status or action=result with task_id to check a task, and action=wait to block until it finishes.",
 			parameters: map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"description": "spawn (default) starts a task; list shows spawned tasks; status returns running/completed/failed plus output; result returns full task details; wait blocks until the task finishes or timeout",
+						"enum":        []string{"spawn", "list", "status", "result", "wait"},
+					},
 					"task": map[string]interface{}{
 						"type":        "string",
-						"description": "The task for the subagent to complete",
-						"minLength":   1,
+						"description": "The task for the subagent to complete (required for spawn)",
+					},
+					"task_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Task ID returned by spawn (required for status, result and wait)",
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Seconds to wait for action=wait (default 30, max 300)",
+						"minimum":     1,
+						"maximum":     maxSpawnWaitSeconds,
 					},
 					"label": map[string]interface{}{
 						"type":        "string",
@@ -103,24 +135,70 @@ func NewSpawnTool(callback SpawnCallback) *SpawnTool {
 						"description": "Whether to notify parent channel/chat when sub-session starts/completes (default true)",
 					},
 				},
-				"required": []string{"task"},
 			},
 		},
-		callback:     callback,
-		runningTasks: make(map[string]*SpawnTask),
+		callback:  callback,
+		tasks:     make(map[string]*SpawnTask),
+		resultTTL: defaultSpawnResultTTL,
+	}
+}
+
+// Repeatable 只有 list / status / result / wait 是轮询后台子任务的只读操作，相同参数的重复调用是正常的；
+// 重复的 spawn 每次都会启动新的子任务，仍按死循环拦截
+func (t *SpawnTool) Repeatable(params map[string]interface{}) bool {
+	action, _ := params["action"].(string)
+	switch strings.TrimSpace(action) {
+	case "list", "status", "result", "wait":
+		return true
+	default:
+		return false
 	}
 }
 
-// SetContext 设置当前上下文
+// SetContext 设置默认的 channel/chat；本次调用的 context 中带有 channel/chat 时以 context 为准
 func (t *SpawnTool) SetContext(channel, chatID string) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.channel = channel
-	t.chatID = chatID
+	t.fallback.set(channel, chatID)
 }
 
 // Execute 执行子代理任务
 func (t *SpawnTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	t.mu.Lock()
+	t.pruneFinishedLocked()
+	t.mu.Unlock()
+
+	action, _ := params["action"].(string)
+	taskID, _ := params["task_id"].(string)
+	taskID = strings.TrimSpace(taskID)
+	owner := RuntimeSessionKeyFrom(ctx)
+	switch strings.TrimSpace(action) {
+	case "", "spawn":
+		return t.spawn(ctx, params)
+	case "list":
+		return t.formatTaskList(owner), nil
+	case "status":
+		task, err := t.lookupTask(owner, taskID)
+		if err != nil {
+			return "", err
+		}
+		return formatTaskStatus(task), nil
+	case "result":
+		return t.formatTaskResult(owner, taskID)
+	case "wait":
+		timeout := defaultSpawnWaitSeconds
+		if v, ok := intParam(params["timeout"]); ok && v > 0 {
+			timeout = v
+		}
+		if timeout > maxSpawnWaitSeconds {
+			timeout = maxSpawnWaitSeconds
+		}
+		return t.waitTask(ctx, owner, taskID, time.Duration(timeout)*time.Second)
+	default:
+		return "", fmt.Errorf("unknown action: %s", action)
+	}
+}
+
+// spawn 启动后台子任务
+func (t *SpawnTool) spawn(ctx context.Context, params map[string]interface{}) (string, error) {
 	task, _ := params["task"].(string)
 	if task == "" {
 		return "", fmt.Errorf("task is required")
@@ -138,7 +216,7 @@ func (t *SpawnTool) Execute(ctx context.Context, params map[string]interface{})
 	}
 	selectedSkills := toStringSlice(params["selected_skills"])
 	enabledSources := toStringSlice(params["enabled_sources"])
-	channel, chatID := RuntimeContextFrom(ctx)
+	channel, chatID := t.fallback.resolve(ctx)
 	parentSessionKey := RuntimeSessionKeyFrom(ctx)
 
 	// 生成任务ID
@@ -146,19 +224,21 @@ func (t *SpawnTool) Execute(ctx context.Context, params map[string]interface{})
 
 	// 记录任务
 	spawnTask := &SpawnTask{
-		ID:         taskID,
-		Label:      label,
-		Task:       task,
-		Model:      model,
-		Skills:     append([]string(nil), selectedSkills...),
-		Sources:    append([]string(nil), enabledSources...),
-		SessionKey: sessionKey,
-		StartTime:  time.Now(),
-		Status:     "running",
+		ID:              taskID,
+		Label:           label,
+		Task:            task,
+		Model:           model,
+		Skills:          append([]string(nil), selectedSkills...),
+		Sources:         append([]string(nil), enabledSources...),
+		SessionKey:      sessionKey,
+		OwnerSessionKey: parentSessionKey,
+		StartTime:       time.Now(),
+		Status:          "running",
+		done:            make(chan struct{}),
 	}
 
 	t.mu.Lock()
-	t.runningTasks[taskID] = spawnTask
+	t.tasks[taskID] = spawnTask
 	t.mu.Unlock()
 
 	// 在后台执行
@@ -192,46 +272,183 @@ func (t *SpawnTool) Execute(ctx context.Context, params map[string]interface{})
 
 // runTask 在后台运行任务
 func (t *SpawnTool) runTask(task *SpawnTask, request SpawnRequest) {
-	defer func() {
-		t.mu.Lock()
-		delete(t.runningTasks, task.ID)
-		t.mu.Unlock()
-	}()
 	startCtx := context.Background()
 	if t.callback == nil {
 		time.Sleep(100 * time.Millisecond)
-		task.Status = "completed"
-		task.Result = "completed with no callback handler"
-		now := time.Now()
-		task.EndTime = &now
+		t.finishTask(task, "completed", "completed with no callback handler", "", "")
 		return
 	}
 
 	result, err := t.callback(startCtx, request)
+	if err != nil {
+		t.finishTask(task, "failed", "", err.Error(), "")
+		return
+	}
+	t.finishTask(task, "completed", result.Message, "", result.SessionKey)
+}
+
+// finishTask 在锁内更新任务状态，避免与 ListRunningTasks 并发读写
+func (t *SpawnTool) finishTask(task *SpawnTask, status, result, errMsg, sessionKey string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	now := time.Now()
 	task.EndTime = &now
-	if err != nil {
-		task.Status = "failed"
-		task.Error = err.Error()
+	task.Status = status
+	task.Result = result
+	task.Error = errMsg
+	if sessionKey != "" {
+		task.SessionKey = sessionKey
+	}
+	if task.done != nil {
+		close(task.done)
+	}
+	t.pruneFinishedLocked()
+}
+
+// pruneFinishedLocked 丢弃超过 resultTTL 的已结束任务，数量仍超过上限时再丢弃最早结束的，调用方需持有写锁
+func (t *SpawnTool) pruneFinishedLocked() {
+	now := time.Now()
+	finished := make([]*SpawnTask, 0, len(t.tasks))
+	for id, task := range t.tasks {
+		if task.EndTime == nil {
+			continue
+		}
+		if t.resultTTL > 0 && now.Sub(*task.EndTime) > t.resultTTL {
+			delete(t.tasks, id)
+			continue
+		}
+		finished = append(finished, task)
+	}
+	if len(finished) <= maxFinishedSpawnTasks {
 		return
 	}
-	task.Status = "completed"
-	task.SessionKey = result.SessionKey
-	task.Result = result.Message
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].EndTime.Before(*finished[j].EndTime)
+	})
+	for _, task := range finished[:len(finished)-maxFinishedSpawnTasks] {
+		delete(t.tasks, task.ID)
+	}
 }
 
-// ListRunningTasks 列出正在运行的任务
+// ListRunningTasks 列出正在运行的任务（返回快照，按开始时间排序）
 func (t *SpawnTool) ListRunningTasks() []*SpawnTask {
+	tasks := t.ListTasks()
+	running := tasks[:0]
+	for _, task := range tasks {
+		if task.Status == "running" {
+			running = append(running, task)
+		}
+	}
+	return running
+}
+
+// ListTasks 列出运行中与最近结束的任务（返回快照，按开始时间排序）
+func (t *SpawnTool) ListTasks() []*SpawnTask {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
-	tasks := make([]*SpawnTask, 0, len(t.runningTasks))
-	for _, task := range t.runningTasks {
-		tasks = append(tasks, task)
+	tasks := make([]*SpawnTask, 0, len(t.tasks))
+	for _, task := range t.tasks {
+		snapshot := *task
+		tasks = append(tasks, &snapshot)
 	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].StartTime.Before(tasks[j].StartTime)
+	})
 	return tasks
 }
 
+// GetTask 按 ID 获取任务快照
+func (t *SpawnTool) GetTask(id string) (*SpawnTask, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	task, ok := t.tasks[id]
+	if !ok {
+		return nil, false
+	}
+	snapshot := *task
+	return &snapshot, true
+}
+
+// formatTaskList 生成 list 动作的输出，只列出 owner 会话发起的任务
+func (t *SpawnTool) formatTaskList(owner string) string {
+	var b strings.Builder
+	for _, task := range t.ListTasks() {
+		if task.OwnerSessionKey != owner {
+			continue
+		}
+		fmt.Fprintf(&b, "- %s [%s] %s (started %s)\n", task.ID, task.Status, task.Label, task.StartTime.Format(time.RFC3339))
+	}
+	if b.Len() == 0 {
+		return "No spawned tasks."
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// lookupTask 按 task_id 查找 owner 会话发起的任务；找不到、已过期或属于其他会话时返回同样的错误
+func (t *SpawnTool) lookupTask(owner, taskID string) (*SpawnTask, error) {
+	if taskID == "" {
+		return nil, fmt.Errorf("task_id is required")
+	}
+	task, ok := t.GetTask(taskID)
+	if !ok || task.OwnerSessionKey != owner {
+		return nil, fmt.Errorf("spawned task not found (unknown id or result expired): %s", taskID)
+	}
+	return task, nil
+}
+
+// formatTaskStatus 生成 status / wait 动作的输出：状态行加上子任务输出或错误
+func formatTaskStatus(task *SpawnTask) string {
+	header := fmt.Sprintf("%s (%s): %s", task.ID, task.Label, task.Status)
+	switch {
+	case task.Status == "running":
+		return fmt.Sprintf("%s, started %s ago", header, time.Since(task.StartTime).Round(time.Second))
+	case task.Error != "":
+		return header + "\nError: " + task.Error
+	case task.Result != "":
+		return header + "\n" + task.Result
+	default:
+		return header
+	}
+}
+
+// waitTask 等待任务结束或超时，返回当前状态
+func (t *SpawnTool) waitTask(ctx context.Context, owner, taskID string, timeout time.Duration) (string, error) {
+	task, err := t.lookupTask(owner, taskID)
+	if err != nil {
+		return "", err
+	}
+	if task.done != nil && task.Status == "running" {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-task.done:
+		case <-timer.C:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+	if task, err = t.lookupTask(owner, taskID); err != nil {
+		return "", err
+	}
+	return formatTaskStatus(task), nil
+}
+
+// formatTaskResult 生成 result 动作的输出（任务快照 JSON）
+func (t *SpawnTool) formatTaskResult(owner, taskID string) (string, error) {
+	task, err := t.lookupTask(owner, taskID)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.MarshalIndent(task, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(payload), nil
+}
+
 // generateTaskID 生成任务ID
 func generateTaskID() string {
 	return fmt.Sprintf("task_%d", time.Now().UnixNano())
@@ -11,16 +11,12 @@ import (
 )
 
 func TestSpawnTool(t *testing.T) {
-	var receivedTask string
 	callback := func(ctx context.Context, req SpawnRequest) (SpawnResult, error) {
-		receivedTask = req.Task
 		return SpawnResult{SessionKey: "spawn:test"}, nil
 	}
-	_ = receivedTask // 避免未使用错误
 
 	tool := NewSpawnTool(callback)
-	tool.SetContext("telegram", "123456")
-	ctx := context.Background()
+	ctx := WithRuntimeContext(context.Background(), "telegram", "123456")
 
 	t.Run("spawn simple task", func(t *testing.T) {
 		result, err := tool.Execute(ctx, map[string]interface{}{
@@ -103,3 +99,136 @@ func TestSpawnToolLongTaskTruncation(t *testing.T) {
 	require.NoError(t, err)
 	assert.Contains(t, result, "Spawned subagent")
 }
+
+func TestSpawnToolListAndResultActions(t *testing.T) {
+	release := make(chan struct{})
+	tool := NewSpawnTool(func(ctx context.Context, req SpawnRequest) (SpawnResult, error) {
+		<-release
+		return SpawnResult{SessionKey: "spawn:child", Message: "processed: " + req.Task}, nil
+	})
+	ctx := WithRuntimeContext(context.Background(), "telegram", "123456")
+
+	_, err := tool.Execute(ctx, map[string]interface{}{"task": "summarize logs", "label": "logs"})
+	require.NoError(t, err)
+
+	tasks := tool.ListTasks()
+	require.Len(t, tasks, 1)
+	taskID := tasks[0].ID
+
+	listed, err := tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	require.NoError(t, err)
+	assert.Contains(t, listed, taskID+" [running] logs")
+
+	running, err := tool.Execute(ctx, map[string]interface{}{"action": "result", "task_id": taskID})
+	require.NoError(t, err)
+	assert.Contains(t, running, `"status": "running"`)
+
+	close(release)
+	require.Eventually(t, func() bool {
+		task, ok := tool.GetTask(taskID)
+		return ok && task.Status == "completed"
+	}, time.Second, 10*time.Millisecond)
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"action": "result", "task_id": taskID})
+	require.NoError(t, err)
+	assert.Contains(t, result, `"result": "processed: summarize logs"`)
+	assert.Contains(t, result, `"sessionKey": "spawn:child"`)
+	assert.Empty(t, tool.ListRunningTasks())
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "result", "task_id": "task_missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestSpawnToolStatusWaitAndResultTTL(t *testing.T) {
+	release := make(chan struct{})
+	tool := NewSpawnTool(func(ctx context.Context, req SpawnRequest) (SpawnResult, error) {
+		<-release
+		return SpawnResult{Message: "report ready"}, nil
+	})
+	ctx := context.Background()
+
+	_, err := tool.Execute(ctx, map[string]interface{}{"task": "build report", "label": "report"})
+	require.NoError(t, err)
+	taskID := tool.ListTasks()[0].ID
+
+	status, err := tool.Execute(ctx, map[string]interface{}{"action": "status", "task_id": taskID})
+	require.NoError(t, err)
+	assert.Contains(t, status, taskID+" (report): running")
+
+	waited, err := tool.Execute(ctx, map[string]interface{}{"action": "wait", "task_id": taskID, "timeout": float64(1)})
+	require.NoError(t, err)
+	assert.Contains(t, waited, "running", "wait should time out while the task is blocked")
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+	}()
+	waited, err = tool.Execute(ctx, map[string]interface{}{"action": "wait", "task_id": taskID, "timeout": float64(5)})
+	require.NoError(t, err)
+	assert.Equal(t, taskID+" (report): completed\nreport ready", waited)
+
+	status, err = tool.Execute(ctx, map[string]interface{}{"action": "status", "task_id": taskID})
+	require.NoError(t, err)
+	assert.Equal(t, waited, status)
+
+	// 过期后结果被清理
+	tool.resultTTL = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "status", "task_id": taskID})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expired")
+	assert.Empty(t, tool.ListTasks())
+}
+
+func TestSpawnToolStatusReportsFailure(t *testing.T) {
+	tool := NewSpawnTool(func(ctx context.Context, req SpawnRequest) (SpawnResult, error) {
+		return SpawnResult{}, assert.AnError
+	})
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"task": "doomed", "label": "doomed"})
+	require.NoError(t, err)
+	taskID := tool.ListTasks()[0].ID
+
+	waited, err := tool.Execute(context.Background(), map[string]interface{}{"action": "wait", "task_id": taskID})
+	require.NoError(t, err)
+	assert.Contains(t, waited, "doomed): failed\nError: "+assert.AnError.Error())
+}
+
+func TestSpawnToolScopesTasksToOwnerSession(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	tool := NewSpawnTool(func(ctx context.Context, req SpawnRequest) (SpawnResult, error) {
+		<-release
+		return SpawnResult{Message: "secret findings"}, nil
+	})
+	alice := WithRuntimeContextWithSession(context.Background(), "telegram", "1", "telegram:1")
+	bob := WithRuntimeContextWithSession(context.Background(), "telegram", "2", "telegram:2")
+
+	_, err := tool.Execute(alice, map[string]interface{}{"task": "private research", "label": "research"})
+	require.NoError(t, err)
+	taskID := tool.ListTasks()[0].ID
+	assert.Equal(t, "telegram:1", tool.ListTasks()[0].OwnerSessionKey)
+
+	listed, err := tool.Execute(alice, map[string]interface{}{"action": "list"})
+	require.NoError(t, err)
+	assert.Contains(t, listed, taskID)
+
+	listed, err = tool.Execute(bob, map[string]interface{}{"action": "list"})
+	require.NoError(t, err)
+	assert.Equal(t, "No spawned tasks.", listed)
+
+	for _, action := range []string{"status", "result", "wait"} {
+		_, err = tool.Execute(bob, map[string]interface{}{"action": action, "task_id": taskID, "timeout": float64(1)})
+		require.Error(t, err, action)
+		assert.Contains(t, err.Error(), "not found", action)
+	}
+}
+
+func TestSpawnToolRepeatableOnlyForPollingActions(t *testing.T) {
+	tool := NewSpawnTool(nil)
+	for _, action := range []string{"list", "status", "result", "wait"} {
+		assert.True(t, tool.Repeatable(map[string]interface{}{"action": action}), action)
+	}
+	assert.False(t, tool.Repeatable(map[string]interface{}{"action": "spawn", "task": "crawl"}))
+	assert.False(t, tool.Repeatable(map[string]interface{}{"task": "crawl"}))
+}
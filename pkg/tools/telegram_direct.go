@@ -12,7 +12,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 	"time"
 )
 
@@ -20,11 +19,10 @@ import (
 // 这个工具直接调用 Telegram API，不通过 channel 架构
 type TelegramDirectTool struct {
 	BaseTool
-	mu       sync.RWMutex
-	token    string
-	channel  string
-	chatID   string
-	proxy    string
+	token string
+	proxy string
+	// fallback SetContext 设置的默认 channel/chat
+	fallback runtimeContextFallback
 }
 
 // NewTelegramDirectTool 创建 Telegram 直接文件发送工具
@@ -63,12 +61,9 @@ func NewTelegramDirectTool(token, proxy string) *TelegramDirectTool {
 	}
 }
 
-// SetContext 设置当前上下文
+// SetContext 设置默认的 channel/chat；本次调用的 context 中带有 channel/chat 时以 context 为准
 func (t *TelegramDirectTool) SetContext(channel, chatID string) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.channel = channel
-	t.chatID = chatID
+	t.fallback.set(channel, chatID)
 }
 
 // Execute 执行文件发送
@@ -93,17 +88,8 @@ func (t *TelegramDirectTool) Execute(ctx context.Context, params map[string]inte
 	// 获取可选的 caption
 	caption, _ := params["caption"].(string)
 
-	// 获取 chat_id
-	t.mu.RLock()
-	chatID := t.chatID
-	t.mu.RUnlock()
-
-	// 优先使用当前请求上下文
-	if _, ctxChatID := RuntimeContextFrom(ctx); ctxChatID != "" {
-		if chatID == "" {
-			chatID = ctxChatID
-		}
-	}
+	// 获取本次调用的 chat_id，缺失时使用 SetContext 设置的默认值
+	_, chatID := t.fallback.resolve(ctx)
 
 	// 允许通过参数覆盖
 	if v, ok := params["chat_id"].(string); ok && v != "" {
@@ -152,7 +138,7 @@ func (t *TelegramDirectTool) Execute(ctx context.Context, params map[string]inte
 	}
 
 	// 发送文件
-	if err := t.sendFile(chatID, absPath, apiMethod, fileField, caption); err != nil {
+	if err := t.sendFile(ctx, chatID, absPath, apiMethod, fileField, caption); err != nil {
 		return "", fmt.Errorf("failed to send file: %w", err)
 	}
 
@@ -160,7 +146,7 @@ func (t *TelegramDirectTool) Execute(ctx context.Context, params map[string]inte
 }
 
 // sendFile 发送文件到 Telegram
-func (t *TelegramDirectTool) sendFile(chatID, filePath, apiMethod, fileField, caption string) error {
+func (t *TelegramDirectTool) sendFile(ctx context.Context, chatID, filePath, apiMethod, fileField, caption string) error {
 	// 打开文件
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -217,7 +203,7 @@ func (t *TelegramDirectTool) sendFile(chatID, filePath, apiMethod, fileField, ca
 
 	// 发送请求
 	apiURL := fmt.Sprintf("https://api.telegram.org/bot%s/%s", t.token, apiMethod)
-	req, err := http.NewRequest("POST", apiURL, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, body)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -6,7 +6,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"sync"
 )
 
 // TelegramFileCallback Telegram 文件发送回调函数类型
@@ -16,9 +15,8 @@ type TelegramFileCallback func(channel, chatID, filePath, fileType, caption stri
 type TelegramFileTool struct {
 	BaseTool
 	callback TelegramFileCallback
-	mu       sync.RWMutex
-	channel  string
-	chatID   string
+	// fallback SetContext 设置的默认 channel/chat
+	fallback runtimeContextFallback
 }
 
 // NewTelegramFileTool 创建 Telegram 文件发送工具
@@ -60,12 +58,9 @@ func NewTelegramFileTool(callback TelegramFileCallback) *TelegramFileTool {
 	}
 }
 
-// SetContext 设置当前上下文
+// SetContext 设置默认的 channel/chat；本次调用的 context 中带有 channel/chat 时以 context 为准
 func (t *TelegramFileTool) SetContext(channel, chatID string) {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-	t.channel = channel
-	t.chatID = chatID
+	t.fallback.set(channel, chatID)
 }
 
 // Execute 执行文件发送
@@ -90,21 +85,8 @@ func (t *TelegramFileTool) Execute(ctx context.Context, params map[string]interf
 	// 获取可选的 caption
 	caption, _ := params["caption"].(string)
 
-	// 获取当前上下文
-	t.mu.RLock()
-	channel := t.channel
-	chatID := t.chatID
-	t.mu.RUnlock()
-
-	// 优先使用当前请求上下文，避免并发请求时的上下文串线
-	if ctxChannel, ctxChatID := RuntimeContextFrom(ctx); ctxChannel != "" || ctxChatID != "" {
-		if channel == "" {
-			channel = ctxChannel
-		}
-		if chatID == "" {
-			chatID = ctxChatID
-		}
-	}
+	// 获取本次调用的上下文，缺失时使用 SetContext 设置的默认值
+	channel, chatID := t.fallback.resolve(ctx)
 
 	// 允许通过参数覆盖
 	if v, ok := params["channel"].(string); ok && v != "" {
@@ -0,0 +1,225 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TodoItem 待办清单中的一项
+type TodoItem struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+type todoFile struct {
+	Items []TodoItem `json:"items"`
+}
+
+// TodoListPath 返回会话待办清单的存储路径（与 plan.json 同目录）
+func TodoListPath(workspace, sessionKey string) string {
+	return filepath.Join(workspace, ".sessions", sanitizePathSegment(sessionKey), "todo.json")
+}
+
+// LoadTodoList 读取会话的待办清单；文件不存在时返回空清单
+func LoadTodoList(workspace, sessionKey string) ([]TodoItem, error) {
+	data, err := os.ReadFile(TodoListPath(workspace, sessionKey))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var file todoFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse todo list: %w", err)
+	}
+	return file.Items, nil
+}
+
+// ClearTodoList 删除会话的待办清单
+func ClearTodoList(workspace, sessionKey string) error {
+	err := os.Remove(TodoListPath(workspace, sessionKey))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func saveTodoList(workspace, sessionKey string, items []TodoItem) error {
+	if len(items) == 0 {
+		return ClearTodoList(workspace, sessionKey)
+	}
+	path := TodoListPath(workspace, sessionKey)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(todoFile{Items: items}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// FormatTodoList 把待办清单格式化为 Markdown 勾选列表
+func FormatTodoList(items []TodoItem) string {
+	lines := make([]string, 0, len(items))
+	for _, item := range items {
+		mark := " "
+		if item.Done {
+			mark = "x"
+		}
+		lines = append(lines, fmt.Sprintf("- [%s] %d. %s", mark, item.ID, item.Text))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TodoTool 维护当前会话的待办清单，帮助模型在多步任务中跟踪进度；清单保存在工作区，并注入系统提示
+type TodoTool struct {
+	BaseTool
+	workspace string
+	mu        sync.Mutex
+}
+
+// NewTodoTool 创建待办清单工具
+func NewTodoTool(workspace string) *TodoTool {
+	return &TodoTool{
+		BaseTool: BaseTool{
+			name:        "todo",
+			description: "Keep a checklist for multi-step work in this conversation. Use action=add with items to record steps, action=complete with ids when a step is done, action=remove to drop steps, action=list to review and action=clear when the task is finished. The current list is shown in your system prompt on every iteration.",
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"add", "complete", "remove", "list", "clear"},
+						"description": "Operation to perform",
+					},
+					"items": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Step descriptions to append (for add)",
+					},
+					"ids": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "integer"},
+						"description": "Item ids (for complete and remove)",
+					},
+				},
+				"required": []string{"action"},
+			},
+		},
+		workspace: workspace,
+	}
+}
+
+// Execute 执行待办操作并返回更新后的清单
+func (t *TodoTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	sessionKey := RuntimeSessionKeyFrom(ctx)
+	if sessionKey == "" {
+		return "", fmt.Errorf("no session context")
+	}
+	action, _ := params["action"].(string)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	items, err := LoadTodoList(t.workspace, sessionKey)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.TrimSpace(action) {
+	case "add":
+		texts := toStringSlice(params["items"])
+		if len(texts) == 0 {
+			return "", fmt.Errorf("items is required for add")
+		}
+		nextID := 1
+		for _, item := range items {
+			if item.ID >= nextID {
+				nextID = item.ID + 1
+			}
+		}
+		for _, text := range texts {
+			items = append(items, TodoItem{ID: nextID, Text: text})
+			nextID++
+		}
+	case "complete", "remove":
+		ids, err := todoIDs(params["ids"])
+		if err != nil {
+			return "", err
+		}
+		if items, err = applyTodoIDs(items, ids, action == "remove"); err != nil {
+			return "", err
+		}
+	case "list":
+	case "clear":
+		items = nil
+	default:
+		return "", fmt.Errorf("unknown action %q (expected add, complete, remove, list or clear)", action)
+	}
+
+	if action != "list" {
+		if err := saveTodoList(t.workspace, sessionKey, items); err != nil {
+			return "", fmt.Errorf("failed to save todo list: %w", err)
+		}
+	}
+	if len(items) == 0 {
+		return "Todo list is empty.", nil
+	}
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return fmt.Sprintf("Todo list (%d/%d done):\n%s", done, len(items), FormatTodoList(items)), nil
+}
+
+func todoIDs(raw interface{}) ([]int, error) {
+	values, _ := raw.([]interface{})
+	if len(values) == 0 {
+		return nil, fmt.Errorf("ids is required")
+	}
+	ids := make([]int, 0, len(values))
+	for _, v := range values {
+		if !isInteger(v) {
+			return nil, fmt.Errorf("invalid id %v", v)
+		}
+		ids = append(ids, int(toFloat64(v)))
+	}
+	return ids, nil
+}
+
+// applyTodoIDs 把 ids 对应的项标记为完成或删除；任一 id 不存在时不做修改并报错
+func applyTodoIDs(items []TodoItem, ids []int, remove bool) ([]TodoItem, error) {
+	index := make(map[int]int, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+	}
+	selected := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		if _, ok := index[id]; !ok {
+			return nil, fmt.Errorf("todo item %d not found", id)
+		}
+		selected[id] = true
+	}
+
+	result := make([]TodoItem, 0, len(items))
+	for _, item := range items {
+		if selected[item.ID] {
+			if remove {
+				continue
+			}
+			item.Done = true
+		}
+		result = append(result, item)
+	}
+	return result, nil
+}
@@ -0,0 +1,85 @@
+package tools
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTodoToolAddCompleteList(t *testing.T) {
+	workspace := t.TempDir()
+	tool := NewTodoTool(workspace)
+	ctx := WithRuntimeContextWithSession(context.Background(), "telegram", "42", "telegram:42")
+
+	result, err := tool.Execute(ctx, map[string]interface{}{
+		"action": "add",
+		"items":  []interface{}{"Read the spec", "Write tests", " "},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Todo list (0/2 done):\n- [ ] 1. Read the spec\n- [ ] 2. Write tests", result)
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"action": "complete", "ids": []interface{}{float64(1)}})
+	require.NoError(t, err)
+	assert.Contains(t, result, "(1/2 done)")
+	assert.Contains(t, result, "- [x] 1. Read the spec")
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"action": "add", "items": []interface{}{"Ship it"}})
+	require.NoError(t, err)
+	assert.Contains(t, result, "- [ ] 3. Ship it")
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	require.NoError(t, err)
+	assert.Equal(t, "Todo list (1/3 done):\n- [x] 1. Read the spec\n- [ ] 2. Write tests\n- [ ] 3. Ship it", result)
+
+	// 清单持久化在工作区，按会话隔离
+	items, err := LoadTodoList(workspace, "telegram:42")
+	require.NoError(t, err)
+	require.Len(t, items, 3)
+	assert.True(t, items[0].Done)
+	other, err := LoadTodoList(workspace, "telegram:99")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestTodoToolRemoveAndClear(t *testing.T) {
+	workspace := t.TempDir()
+	tool := NewTodoTool(workspace)
+	ctx := WithRuntimeContextWithSession(context.Background(), "cli", "direct", "cli:direct")
+
+	_, err := tool.Execute(ctx, map[string]interface{}{"action": "add", "items": []interface{}{"a", "b"}})
+	require.NoError(t, err)
+
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "complete", "ids": []interface{}{float64(1), float64(7)}})
+	require.EqualError(t, err, "todo item 7 not found")
+	items, err := LoadTodoList(workspace, "cli:direct")
+	require.NoError(t, err)
+	assert.False(t, items[0].Done, "a failed update leaves the list unchanged")
+
+	result, err := tool.Execute(ctx, map[string]interface{}{"action": "remove", "ids": []interface{}{float64(1)}})
+	require.NoError(t, err)
+	assert.Equal(t, "Todo list (0/1 done):\n- [ ] 2. b", result)
+
+	result, err = tool.Execute(ctx, map[string]interface{}{"action": "clear"})
+	require.NoError(t, err)
+	assert.Equal(t, "Todo list is empty.", result)
+	_, err = os.Stat(TodoListPath(workspace, "cli:direct"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestTodoToolRequiresSessionAndValidInput(t *testing.T) {
+	tool := NewTodoTool(t.TempDir())
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"action": "list"})
+	require.EqualError(t, err, "no session context")
+
+	ctx := WithRuntimeContextWithSession(context.Background(), "cli", "direct", "cli:direct")
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "add"})
+	require.EqualError(t, err, "items is required for add")
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "complete"})
+	require.EqualError(t, err, "ids is required")
+	_, err = tool.Execute(ctx, map[string]interface{}{"action": "rename"})
+	require.Error(t, err)
+}
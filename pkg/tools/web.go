@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,10 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/Lichas/maxclaw/internal/logging"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
 )
 
 // WebSearchTool 网页搜索工具
@@ -18,12 +23,23 @@ type WebSearchTool struct {
 	BaseTool
 	APIKey     string
 	MaxResults int
+
+	endpoint string
 }
 
-// NewWebSearchTool 创建网页搜索工具
+const (
+	defaultWebSearchResults = 5
+	maxWebSearchResults     = 10
+	braveSearchEndpoint     = "https://api.search.brave.com/res/v1/web/search"
+)
+
+// NewWebSearchTool 创建网页搜索工具；maxResults 为未指定 count 时的默认条数（限制在 1-10）
 func NewWebSearchTool(apiKey string, maxResults int) *WebSearchTool {
 	if maxResults <= 0 {
-		maxResults = 5
+		maxResults = defaultWebSearchResults
+	}
+	if maxResults > maxWebSearchResults {
+		maxResults = maxWebSearchResults
 	}
 
 	return &WebSearchTool{
@@ -51,9 +67,15 @@ func NewWebSearchTool(apiKey string, maxResults int) *WebSearchTool {
 		},
 		APIKey:     apiKey,
 		MaxResults: maxResults,
+		endpoint:   braveSearchEndpoint,
 	}
 }
 
+// ConcurrencySafe 搜索请求互不依赖且不修改状态，可以并发执行
+func (t *WebSearchTool) ConcurrencySafe() bool {
+	return true
+}
+
 // Execute 执行网页搜索
 func (t *WebSearchTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
 	query, _ := params["query"].(string)
@@ -68,14 +90,13 @@ func (t *WebSearchTool) Execute(ctx context.Context, params map[string]interface
 	count := t.MaxResults
 	if v, ok := params["count"].(float64); ok {
 		c := int(v)
-		if c > 0 && c <= 10 {
+		if c > 0 && c <= maxWebSearchResults {
 			count = c
 		}
 	}
 
 	// Brave Search API
-	apiURL := fmt.Sprintf("https://api.search.brave.com/res/v1/web/search?q=%s&count=%d",
-		url.QueryEscape(query), count)
+	apiURL := fmt.Sprintf("%s?q=%s&count=%d", t.endpoint, url.QueryEscape(query), count)
 
 	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
@@ -129,6 +150,14 @@ func (t *WebSearchTool) Execute(ctx context.Context, params map[string]interface
 type WebFetchTool struct {
 	BaseTool
 	options WebFetchOptions
+	// browserSlots 限制同时运行的 browser/chrome 抓取子进程数量，超出的请求排队等待
+	browserSlots chan struct{}
+	// robots 抓取前检查 robots.txt；IgnoreRobots 时为 nil
+	robots *robotsChecker
+	// guard 阻止访问内网地址；AllowPrivateNetwork 时为 nil
+	guard *hostGuard
+	// transport 建立连接时校验地址的 Transport；nil 使用默认 Transport
+	transport http.RoundTripper
 }
 
 // WebFetchOptions 网页抓取选项
@@ -146,6 +175,16 @@ type WebFetchOptions struct {
 	WaitForText     string
 	WaitForNoText   string
 	Chrome          WebFetchChromeOptions
+	// MaxConcurrentBrowsers 同时运行的 browser/chrome 抓取子进程上限，<=0 使用默认值
+	MaxConcurrentBrowsers int
+	// HTTPFallback browser/chrome 模式失败时退回普通 HTTP 抓取
+	HTTPFallback bool
+	// IgnoreRobots 不检查目标站点的 robots.txt
+	IgnoreRobots bool
+	// AllowPrivateNetwork 允许访问回环、私有、链路本地等内部地址（默认阻止，防止 SSRF）
+	AllowPrivateNetwork bool
+	// AllowedHosts 阻止内部地址时仍放行的主机名或 IP/CIDR
+	AllowedHosts []string
 }
 
 // WebFetchChromeOptions Chrome 抓取选项
@@ -170,11 +209,22 @@ const (
 	defaultChromeProfileName     = "chrome"
 	defaultChromeChannel         = "chrome"
 	defaultChromeLaunchTimeoutMs = 15000
+	defaultMaxConcurrentBrowsers = 2
 )
 
 // NewWebFetchTool 创建网页抓取工具
 func NewWebFetchTool(options WebFetchOptions) *WebFetchTool {
 	options = normalizeWebFetchOptions(options)
+	var guard *hostGuard
+	var transport http.RoundTripper
+	if !options.AllowPrivateNetwork {
+		guard = newHostGuard(options.AllowedHosts)
+		transport = guard.transport()
+	}
+	var robots *robotsChecker
+	if !options.IgnoreRobots {
+		robots = newRobotsChecker(options.UserAgent, transport)
+	}
 	return &WebFetchTool{
 		BaseTool: BaseTool{
 			name:        "web_fetch",
@@ -228,21 +278,64 @@ func NewWebFetchTool(options WebFetchOptions) *WebFetchTool {
 						"type":        "string",
 						"description": "Wait until page text no longer contains this string",
 					},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional CSS selector (e.g. \"article\", \"main .content\"); only text inside matching elements is returned",
+					},
+					"headers": map[string]interface{}{
+						"type":                 "object",
+						"additionalProperties": map[string]interface{}{"type": "string"},
+						"description":          "Extra request headers, e.g. {\"Authorization\": \"Bearer ...\"}; User-Agent overrides the configured one. Requests with headers use a plain HTTP request. Use http_request for POST/PUT/PATCH/DELETE.",
+					},
 				},
 				"required": []string{"url"},
 			},
 		},
-		options: options,
+		options:      options,
+		browserSlots: make(chan struct{}, options.MaxConcurrentBrowsers),
+		robots:       robots,
+		guard:        guard,
+		transport:    transport,
 	}
 }
 
+// ConcurrencySafe web_fetch 只发 GET 请求（修改远端状态的请求由 http_request 发送），可与其他只读工具并发执行
+func (t *WebFetchTool) ConcurrencySafe() bool {
+	return true
+}
+
 // Execute 执行网页抓取
 func (t *WebFetchTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	request, err := parseWebFetchRequest(params)
+	if err != nil {
+		return "", err
+	}
+	if request.method != http.MethodGet {
+		return "", fmt.Errorf("web_fetch only sends GET requests; use http_request to send %s", request.method)
+	}
+	return t.fetch(ctx, params, request)
+}
+
+// fetch 执行 web_fetch 与 http_request 共用的请求流程：内网防护、robots.txt（只对 GET）与按模式抓取
+func (t *WebFetchTool) fetch(ctx context.Context, params map[string]interface{}, request webFetchRequest) (string, error) {
 	fetchURL, _ := params["url"].(string)
 	if fetchURL == "" {
 		return "", fmt.Errorf("url is required")
 	}
 
+	if u, err := url.Parse(fetchURL); err == nil {
+		// 在任何模式发出请求（包括 robots.txt）之前拦截内网地址
+		if t.guard != nil {
+			if err := t.guard.checkURL(ctx, u); err != nil {
+				return "", err
+			}
+		}
+		// robots.txt 约束的是爬取，调用 API 的非 GET 请求不检查
+		if t.robots != nil && request.method == http.MethodGet && !t.robots.Allowed(ctx, u) {
+			return "", fmt.Errorf("robots.txt of %s disallows fetching %s for this user agent, so it was not fetched (set tools.web.fetch.ignoreRobots to override)", u.Host, fetchURL)
+		}
+	}
+
 	maxLength := 10000
 	if v, ok := params["max_length"].(float64); ok {
 		m := int(v)
@@ -251,6 +344,11 @@ func (t *WebFetchTool) Execute(ctx context.Context, params map[string]interface{
 		}
 	}
 
+	// 浏览器模式只能发 GET，自定义请求直接走 HTTP
+	if request.custom() {
+		return t.executeHTTPFetch(ctx, fetchURL, maxLength, params)
+	}
+
 	mode := strings.ToLower(strings.TrimSpace(t.options.Mode))
 	if mode == "" {
 		mode = "http"
@@ -258,7 +356,7 @@ func (t *WebFetchTool) Execute(ctx context.Context, params map[string]interface{
 
 	switch mode {
 	case "browser", "chrome":
-		return t.executeBrowserFetch(ctx, fetchURL, maxLength, mode, params)
+		return t.executeBrowserFetchWithFallback(ctx, fetchURL, maxLength, mode, params)
 	case "auto":
 		return t.executeAutoFetch(ctx, fetchURL, maxLength, params)
 	case "http":
@@ -268,16 +366,77 @@ func (t *WebFetchTool) Execute(ctx context.Context, params map[string]interface{
 	}
 }
 
+// webFetchMethods http_request 支持的 HTTP 方法（web_fetch 只发 GET）
+var webFetchMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// webFetchRequest web_fetch / http_request 调用指定的请求方法、请求头和请求体
+type webFetchRequest struct {
+	method  string
+	headers map[string]string
+	body    string
+	// plain 始终以普通 HTTP 发出（http_request）
+	plain bool
+}
+
+// custom 是否为默认 GET 以外的自定义请求
+func (r webFetchRequest) custom() bool {
+	return r.plain || r.method != http.MethodGet || len(r.headers) > 0 || r.body != ""
+}
+
+func parseWebFetchRequest(params map[string]interface{}) (webFetchRequest, error) {
+	req := webFetchRequest{method: http.MethodGet}
+	if method, _ := params["method"].(string); strings.TrimSpace(method) != "" {
+		req.method = strings.ToUpper(strings.TrimSpace(method))
+		if !containsString(webFetchMethods, req.method) {
+			return req, fmt.Errorf("unsupported method %q (expected one of %s)", method, strings.Join(webFetchMethods, ", "))
+		}
+	}
+	if raw, ok := params["headers"].(map[string]interface{}); ok && len(raw) > 0 {
+		req.headers = make(map[string]string, len(raw))
+		for name, value := range raw {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			req.headers[name] = fmt.Sprint(value)
+		}
+	}
+	req.body, _ = params["body"].(string)
+	if req.body != "" && req.method == http.MethodGet {
+		return req, fmt.Errorf("body requires a method other than GET")
+	}
+	return req, nil
+}
+
 func (t *WebFetchTool) executeHTTPFetch(ctx context.Context, fetchURL string, maxLength int, params map[string]interface{}) (string, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", fetchURL, nil)
+	request, err := parseWebFetchRequest(params)
+	if err != nil {
+		return "", err
+	}
+	var reqBody io.Reader
+	if request.body != "" {
+		reqBody = strings.NewReader(request.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, request.method, fetchURL, reqBody)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", t.options.UserAgent)
+	if request.body != "" {
+		if json.Valid([]byte(request.body)) {
+			req.Header.Set("Content-Type", "application/json")
+		} else {
+			req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		}
+	}
+	for name, value := range request.headers {
+		req.Header.Set(name, value)
+	}
 
 	client := &http.Client{
-		Timeout: time.Duration(resolveWebFetchTimeoutSec(params, t.options.TimeoutSec)) * time.Second,
+		Transport: t.transport,
+		Timeout:   time.Duration(resolveWebFetchTimeoutSec(params, t.options.TimeoutSec)) * time.Second,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			if len(via) >= 5 {
 				return fmt.Errorf("too many redirects")
@@ -292,11 +451,19 @@ func (t *WebFetchTool) executeHTTPFetch(ctx context.Context, fetchURL string, ma
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	contentType := resp.Header.Get("Content-Type")
+	// 按最终 URL（跟随重定向后）判断扩展名
+	if isPDFResponse(contentType, resp.Request.URL.String()) {
+		text, err := readPDFBody(resp.Body)
+		if err != nil {
+			return "", err
+		}
+		return truncateText(text, maxLength), nil
+	}
 	if strings.Contains(contentType, "application/json") {
 		// JSON content
 		body, err := io.ReadAll(resp.Body)
@@ -312,6 +479,14 @@ func (t *WebFetchTool) executeHTTPFetch(ctx context.Context, fetchURL string, ma
 		return "", fmt.Errorf("failed to read body: %w", err)
 	}
 
+	if selector := webFetchSelector(params); selector != "" {
+		selected, err := extractTextBySelector(string(body), selector)
+		if err != nil {
+			return "", err
+		}
+		return truncateText(selected, maxLength), nil
+	}
+
 	// Simple HTML to text conversion
 	text := extractTextFromHTML(string(body))
 
@@ -319,6 +494,17 @@ func (t *WebFetchTool) executeHTTPFetch(ctx context.Context, fetchURL string, ma
 }
 
 func (t *WebFetchTool) executeBrowserFetch(ctx context.Context, fetchURL string, maxLength int, mode string, params map[string]interface{}) (string, error) {
+	// 浏览器在独立进程中联网：启动前先检查目标主机（auto 模式回退时同样经过这里），
+	// 之后浏览器的全部连接经本地防护代理，重定向和子资源在拨号时校验
+	if t.guard != nil {
+		target, err := url.Parse(fetchURL)
+		if err != nil {
+			return "", fmt.Errorf("invalid url: %w", err)
+		}
+		if err := t.guard.checkURL(ctx, target); err != nil {
+			return "", err
+		}
+	}
 	scriptPath := strings.TrimSpace(t.options.ScriptPath)
 	if scriptPath == "" {
 		return "", fmt.Errorf("web_fetch browser/chrome mode requires tools.web.fetch.scriptPath")
@@ -350,6 +536,13 @@ func (t *WebFetchTool) executeBrowserFetch(ctx context.Context, fetchURL string,
 		WaitForText:     resolveWebFetchStringOption(params, "wait_for_text", t.options.WaitForText),
 		WaitForNoText:   resolveWebFetchStringOption(params, "wait_for_no_text", t.options.WaitForNoText),
 	}
+	selector := webFetchSelector(params)
+	if selector != "" {
+		if _, err := cascadia.Compile(selector); err != nil {
+			return "", fmt.Errorf("invalid selector %q: %w", selector, err)
+		}
+		req.IncludeHTML = true
+	}
 	if mode == "chrome" {
 		req.Chrome = &browserChromeRequest{
 			CDPEndpoint:      t.options.Chrome.CDPEndpoint,
@@ -363,12 +556,27 @@ func (t *WebFetchTool) executeBrowserFetch(ctx context.Context, fetchURL string,
 			LaunchTimeoutMs:  t.options.Chrome.LaunchTimeoutMs,
 		}
 	}
+	var proxy *guardProxy
+	if t.guard != nil {
+		var err error
+		proxy, err = t.guard.startProxy()
+		if err != nil {
+			return "", fmt.Errorf("failed to start guarded browser proxy: %w", err)
+		}
+		defer proxy.Close()
+		req.Proxy = proxy.URL()
+	}
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return "", fmt.Errorf("failed to encode browser fetch request: %w", err)
 	}
 
+	release, err := t.acquireBrowserSlot(ctx)
+	if err != nil {
+		return "", fmt.Errorf("browser fetch canceled while waiting for a free browser slot: %w", err)
+	}
 	output, err := runNodeScriptWithPlaywrightRetry(ctx, nodePath, scriptPath, payload)
+	release()
 	if err != nil {
 		return "", fmt.Errorf("browser fetch failed: %s", err)
 	}
@@ -378,11 +586,31 @@ func (t *WebFetchTool) executeBrowserFetch(ctx context.Context, fetchURL string,
 		return "", fmt.Errorf("browser fetch parse error: %w", err)
 	}
 	if !result.OK {
+		// 浏览器只看到连接失败，被防护代理拦截时返回拦截原因
+		if proxy != nil {
+			if blocked := proxy.Blocked(); blocked != nil {
+				return "", blocked
+			}
+		}
 		if result.Error == "" {
 			result.Error = "unknown browser fetch error"
 		}
 		return "", fmt.Errorf("browser fetch error: %s", result.Error)
 	}
+	if proxy != nil && !result.Proxied {
+		return "", fmt.Errorf("web_fetch script does not route the browser through the private-network guard (update webfetcher/fetch.mjs)")
+	}
+
+	if selector != "" {
+		if result.HTML == "" {
+			return "", fmt.Errorf("browser fetch returned no HTML for selector extraction (update webfetcher script)")
+		}
+		selected, err := extractTextBySelector(result.HTML, selector)
+		if err != nil {
+			return "", err
+		}
+		return truncateText(selected, maxLength), nil
+	}
 
 	text := strings.TrimSpace(result.Text)
 	if result.Title != "" {
@@ -391,11 +619,51 @@ func (t *WebFetchTool) executeBrowserFetch(ctx context.Context, fetchURL string,
 	return truncateText(text, maxLength), nil
 }
 
+// executeBrowserFetchWithFallback browser/chrome 抓取失败且开启 HTTPFallback 时退回普通 HTTP 抓取，
+// 结果前附带说明，让模型知道拿到的是尽力而为的静态内容
+func (t *WebFetchTool) executeBrowserFetchWithFallback(ctx context.Context, fetchURL string, maxLength int, mode string, params map[string]interface{}) (string, error) {
+	text, err := t.executeBrowserFetch(ctx, fetchURL, maxLength, mode, params)
+	if err == nil || !t.options.HTTPFallback || ctx.Err() != nil {
+		return text, err
+	}
+	var blocked *blockedHostError
+	if errors.As(err, &blocked) {
+		return "", err
+	}
+
+	if lg := logging.Get(); lg != nil && lg.Tools != nil {
+		lg.Tools.Printf("web_fetch %s mode failed, falling back to http url=%s err=%v", mode, fetchURL, err)
+	}
+	httpText, httpErr := t.executeHTTPFetch(ctx, fetchURL, maxLength, params)
+	if httpErr != nil {
+		return "", fmt.Errorf("%v; http fallback failed: %v", err, httpErr)
+	}
+	return fmt.Sprintf("[Note: %s mode failed (%v); showing plain HTTP fetch result]\n\n%s", mode, err, httpText), nil
+}
+
+// acquireBrowserSlot 占用一个浏览器子进程名额，名额用尽时排队直到有空闲或 ctx 结束
+func (t *WebFetchTool) acquireBrowserSlot(ctx context.Context) (func(), error) {
+	select {
+	case t.browserSlots <- struct{}{}:
+		return func() { <-t.browserSlots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
 func (t *WebFetchTool) executeAutoFetch(ctx context.Context, fetchURL string, maxLength int, params map[string]interface{}) (string, error) {
 	httpText, httpErr := t.executeHTTPFetch(ctx, fetchURL, maxLength, params)
 	if httpErr == nil && !shouldFallbackToBrowserFetch(httpText) {
 		return httpText, nil
 	}
+	var blocked *blockedHostError
+	if errors.As(httpErr, &blocked) {
+		return "", httpErr
+	}
+	// 已取消时不再尝试浏览器回退
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("web_fetch canceled: %w", err)
+	}
 
 	chromeText, chromeErr := t.executeBrowserFetch(ctx, fetchURL, maxLength, "chrome", params)
 	if chromeErr == nil {
@@ -435,7 +703,10 @@ type browserFetchRequest struct {
 	WaitForSelector string                `json:"waitForSelector,omitempty"`
 	WaitForText     string                `json:"waitForText,omitempty"`
 	WaitForNoText   string                `json:"waitForNoText,omitempty"`
+	IncludeHTML     bool                  `json:"includeHtml,omitempty"`
 	Chrome          *browserChromeRequest `json:"chrome,omitempty"`
+	// Proxy 内网防护开启时浏览器必须使用的本地防护代理
+	Proxy string `json:"proxy,omitempty"`
 }
 
 type browserChromeRequest struct {
@@ -455,7 +726,10 @@ type browserFetchResult struct {
 	URL   string `json:"url,omitempty"`
 	Title string `json:"title,omitempty"`
 	Text  string `json:"text,omitempty"`
+	HTML  string `json:"html,omitempty"`
 	Error string `json:"error,omitempty"`
+	// Proxied 脚本确认浏览器使用了请求中的 Proxy；旧脚本不回报，防护开启时拒绝其结果
+	Proxied bool `json:"proxied,omitempty"`
 }
 
 var browserFallbackKeywords = []string{
@@ -561,6 +835,9 @@ func normalizeWebFetchOptions(options WebFetchOptions) WebFetchOptions {
 	options.WaitForSelector = strings.TrimSpace(options.WaitForSelector)
 	options.WaitForText = strings.TrimSpace(options.WaitForText)
 	options.WaitForNoText = strings.TrimSpace(options.WaitForNoText)
+	if options.MaxConcurrentBrowsers <= 0 {
+		options.MaxConcurrentBrowsers = defaultMaxConcurrentBrowsers
+	}
 	if strings.TrimSpace(options.Chrome.ProfileName) == "" {
 		options.Chrome.ProfileName = defaultChromeProfileName
 	}
@@ -614,6 +891,7 @@ func resolveScriptPath(path string) string {
 	return path
 }
 
+// truncateText 超过 maxLength 字节时截断（不切断 UTF-8 字符）并追加统一的截断提示
 func truncateText(text string, maxLength int) string {
 	if maxLength <= 0 {
 		return text
@@ -621,7 +899,39 @@ func truncateText(text string, maxLength int) string {
 	if len(text) <= maxLength {
 		return text
 	}
-	return text[:maxLength] + "\n\n... (content truncated)"
+	return text[:runeBoundary(text, maxLength)] + "\n\n... (content truncated)"
+}
+
+func webFetchSelector(params map[string]interface{}) string {
+	selector, _ := params["selector"].(string)
+	return strings.TrimSpace(selector)
+}
+
+// extractTextBySelector 只提取匹配 CSS selector 的元素文本，多个匹配之间空行分隔
+func extractTextBySelector(html, selector string) (string, error) {
+	matcher, err := cascadia.Compile(selector)
+	if err != nil {
+		return "", fmt.Errorf("invalid selector %q: %w", selector, err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var parts []string
+	doc.FindMatcher(matcher).Each(func(_ int, sel *goquery.Selection) {
+		outer, err := goquery.OuterHtml(sel)
+		if err != nil {
+			return
+		}
+		if text := extractTextFromHTML(outer); text != "" {
+			parts = append(parts, text)
+		}
+	})
+	if len(parts) == 0 {
+		return "", fmt.Errorf("selector %q matched no content", selector)
+	}
+	return strings.Join(parts, "\n\n"), nil
 }
 
 // extractTextFromHTML 简单的 HTML 到文本提取
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxWebFetchManyURLs 单次调用允许的 URL 数量上限
+	maxWebFetchManyURLs = 10
+	// webFetchManyWorkers 同时抓取的 URL 数量
+	webFetchManyWorkers = 4
+	// defaultWebFetchManyMaxLength 每个 URL 默认返回的最大字符数
+	defaultWebFetchManyMaxLength = 5000
+)
+
+// WebFetchManyTool 并发抓取多个网页，复用 web_fetch 的抓取逻辑（模式、回退、选择器）
+type WebFetchManyTool struct {
+	BaseTool
+	fetch *WebFetchTool
+}
+
+// NewWebFetchManyTool 创建批量网页抓取工具
+func NewWebFetchManyTool(fetch *WebFetchTool) *WebFetchManyTool {
+	return &WebFetchManyTool{
+		BaseTool: BaseTool{
+			name:        "web_fetch_many",
+			description: fmt.Sprintf("Fetch several web pages concurrently and return their text, each labeled with its URL. Use instead of repeated web_fetch calls when you already know the URLs (up to %d). Pages that fail are reported inline without failing the others.", maxWebFetchManyURLs),
+			parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"urls": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "URLs to fetch",
+						"minItems":    1,
+						"maxItems":    maxWebFetchManyURLs,
+					},
+					"max_length": map[string]interface{}{
+						"type":        "integer",
+						"description": fmt.Sprintf("Maximum content length per URL (default: %d)", defaultWebFetchManyMaxLength),
+						"minimum":     100,
+						"maximum":     20000,
+					},
+					"timeout": map[string]interface{}{
+						"type":        "integer",
+						"description": "Overall timeout in seconds shared by all URLs (default from config)",
+						"minimum":     1,
+						"maximum":     180,
+					},
+					"selector": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional CSS selector applied to every page",
+					},
+				},
+				"required": []string{"urls"},
+			},
+		},
+		fetch: fetch,
+	}
+}
+
+// ConcurrencySafe 批量发送 GET 请求，与 web_fetch 一样不修改远端状态，可以并发执行
+func (t *WebFetchManyTool) ConcurrencySafe() bool {
+	return true
+}
+
+// Execute 并发抓取所有 URL，按输入顺序返回带标签的结果
+func (t *WebFetchManyTool) Execute(ctx context.Context, params map[string]interface{}) (string, error) {
+	urls := uniqueStrings(toStringSlice(params["urls"]))
+	if len(urls) == 0 {
+		return "", fmt.Errorf("urls is required")
+	}
+	if len(urls) > maxWebFetchManyURLs {
+		return "", fmt.Errorf("too many urls: %d (max %d)", len(urls), maxWebFetchManyURLs)
+	}
+
+	maxLength := defaultWebFetchManyMaxLength
+	if v, ok := params["max_length"].(float64); ok && int(v) >= 100 && int(v) <= 20000 {
+		maxLength = int(v)
+	}
+	timeout := time.Duration(resolveWebFetchTimeoutSec(params, t.fetch.options.TimeoutSec)) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	perURL := map[string]interface{}{"max_length": float64(maxLength)}
+	if selector := webFetchSelector(params); selector != "" {
+		perURL["selector"] = selector
+	}
+	if v, ok := params["timeout"]; ok {
+		perURL["timeout"] = v
+	}
+
+	results := make([]string, len(urls))
+	errs := make([]error, len(urls))
+	slots := make(chan struct{}, webFetchManyWorkers)
+	var wg sync.WaitGroup
+	for i, fetchURL := range urls {
+		wg.Add(1)
+		go func(i int, fetchURL string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			args := make(map[string]interface{}, len(perURL)+1)
+			for k, v := range perURL {
+				args[k] = v
+			}
+			args["url"] = fetchURL
+			results[i], errs[i] = t.fetch.Execute(ctx, args)
+		}(i, fetchURL)
+	}
+	wg.Wait()
+
+	// 调用方取消时整体失败；共享超时到期时保留已完成的结果
+	if err := ctx.Err(); errors.Is(err, context.Canceled) {
+		return "", fmt.Errorf("web_fetch_many canceled: %w", err)
+	}
+
+	var sb strings.Builder
+	failed := 0
+	for i, fetchURL := range urls {
+		fmt.Fprintf(&sb, "## [%d] %s\n", i+1, fetchURL)
+		if errs[i] != nil {
+			failed++
+			fmt.Fprintf(&sb, "Error: %v\n\n", errs[i])
+			continue
+		}
+		sb.WriteString(strings.TrimSpace(results[i]))
+		sb.WriteString("\n\n")
+	}
+	fmt.Fprintf(&sb, "Fetched %d/%d URLs", len(urls)-failed, len(urls))
+	return sb.String(), nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
@@ -1,10 +1,21 @@
 package tools
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNormalizeWebFetchOptionsChromeDefaults(t *testing.T) {
@@ -45,3 +56,352 @@ func TestShouldFallbackToBrowserFetch(t *testing.T) {
 	assert.True(t, shouldFallbackToBrowserFetch("Access denied"))
 	assert.False(t, shouldFallbackToBrowserFetch("Welcome to dashboard. Latest report is ready."))
 }
+
+func TestWebSearchToolUsesConfiguredMaxResults(t *testing.T) {
+	var gotCount string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCount = r.URL.Query().Get("count")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"web":{"results":[{"title":"Go","url":"https://go.dev","description":"The Go language"}]}}`))
+	}))
+	defer server.Close()
+
+	tool := NewWebSearchTool("key", 8)
+	tool.endpoint = server.URL
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"query": "golang"})
+	require.NoError(t, err)
+	assert.Equal(t, "8", gotCount)
+	assert.Contains(t, result, "https://go.dev")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"query": "golang", "count": float64(3)})
+	require.NoError(t, err)
+	assert.Equal(t, "3", gotCount)
+}
+
+func TestNewWebSearchToolClampsMaxResults(t *testing.T) {
+	assert.Equal(t, 5, NewWebSearchTool("", 0).MaxResults)
+	assert.Equal(t, 10, NewWebSearchTool("", 50).MaxResults)
+	assert.Equal(t, 7, NewWebSearchTool("", 7).MaxResults)
+}
+
+func TestWebFetchBrowserModeSerializesBeyondConcurrencyLimit(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "events.log")
+	script := filepath.Join(dir, "fetch.sh")
+	body := "cat >/dev/null\n" +
+		"echo start >> " + logPath + "\n" +
+		"sleep 0.1\n" +
+		"echo end >> " + logPath + "\n" +
+		"echo '{\"ok\":true,\"proxied\":true,\"text\":\"page\"}'\n"
+	require.NoError(t, os.WriteFile(script, []byte(body), 0755))
+
+	tool := NewWebFetchTool(WebFetchOptions{
+		Mode:                  "browser",
+		NodePath:              "/bin/sh",
+		ScriptPath:            script,
+		MaxConcurrentBrowsers: 1,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := tool.Execute(context.Background(), map[string]interface{}{"url": "https://example.com"})
+			assert.NoError(t, err)
+			assert.Equal(t, "page", result)
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("start\nend\n", 3), string(data), "browser fetches should not overlap")
+}
+
+func TestWebFetchBrowserSlotQueueHonorsContext(t *testing.T) {
+	tool := NewWebFetchTool(WebFetchOptions{MaxConcurrentBrowsers: 1})
+	release, err := tool.acquireBrowserSlot(context.Background())
+	require.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = tool.acquireBrowserSlot(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWebFetchBrowserFailureFallsBackToHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte("<html><body><p>Static article body</p></body></html>"))
+	}))
+	defer server.Close()
+
+	options := WebFetchOptions{
+		Mode:                "browser",
+		ScriptPath:          filepath.Join(t.TempDir(), "missing.mjs"),
+		AllowPrivateNetwork: true,
+	}
+
+	_, err := NewWebFetchTool(options).Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "web_fetch script not found")
+
+	options.HTTPFallback = true
+	result, err := NewWebFetchTool(options).Execute(context.Background(), map[string]interface{}{"url": server.URL})
+	require.NoError(t, err)
+	assert.Contains(t, result, "[Note: browser mode failed")
+	assert.Contains(t, result, "Static article body")
+}
+
+const selectorFixtureHTML = `<html><head><title>Blog</title><style>.x{}</style></head><body>
+<nav><a href="/">Home</a> | <a href="/about">About</a></nav>
+<article class="post"><h1>Release notes</h1><p>Version 2 ships <b>faster</b> builds &amp; smaller binaries.</p><script>track()</script></article>
+<aside>Subscribe to our newsletter</aside>
+<div class="comment">First comment</div><div class="comment">Second comment</div>
+</body></html>`
+
+func TestWebFetchSelectorExtractsMatchingElements(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(selectorFixtureHTML))
+	}))
+	defer server.Close()
+
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": server.URL, "selector": "article.post"})
+	require.NoError(t, err)
+	assert.Contains(t, result, "Release notes")
+	assert.Contains(t, result, "Version 2 ships faster builds & smaller binaries.")
+	assert.NotContains(t, result, "Home")
+	assert.NotContains(t, result, "newsletter")
+	assert.NotContains(t, result, "track()")
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"url": server.URL, "selector": "div.comment"})
+	require.NoError(t, err)
+	assert.Equal(t, "First comment\n\nSecond comment", result)
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"url": server.URL, "selector": "table.missing"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "matched no content")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"url": server.URL, "selector": "div[["})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid selector")
+}
+
+func TestWebFetchSelectorAppliesToBrowserHTML(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fetch.sh")
+	body := "grep -q '\"includeHtml\":true' || { echo '{\"ok\":false,\"error\":\"includeHtml missing\"}'; exit 0; }\n" +
+		"echo '{\"ok\":true,\"proxied\":true,\"title\":\"Blog\",\"text\":\"everything\",\"html\":\"<main><p>Rendered main</p></main><footer>foot</footer>\"}'\n"
+	require.NoError(t, os.WriteFile(script, []byte(body), 0755))
+
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script})
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"url": "https://example.com", "selector": "main"})
+	require.NoError(t, err)
+	assert.Equal(t, "Rendered main", result)
+}
+
+func TestWebFetchAbortsWhenContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/body" {
+			// 先返回部分内容，再卡住剩余响应体
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true, TimeoutSec: 30})
+	for _, path := range []string{"/headers", "/body"} {
+		t.Run(path, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(100*time.Millisecond, cancel)
+
+			start := time.Now()
+			_, err := tool.Execute(ctx, map[string]interface{}{"url": server.URL + path})
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, context.Canceled), "unexpected error: %v", err)
+			assert.Less(t, time.Since(start), 2*time.Second)
+		})
+	}
+}
+
+func TestWebFetchBrowserModeAbortsWhenContextCanceled(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "fetch.sh")
+	require.NoError(t, os.WriteFile(script, []byte("exec sleep 30\n"), 0755))
+
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "browser", NodePath: "/bin/sh", ScriptPath: script, TimeoutSec: 60})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := tool.Execute(ctx, map[string]interface{}{"url": "https://example.com"})
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second)
+}
+
+func TestWebFetchManyFetchesConcurrentlyAndLabelsResults(t *testing.T) {
+	// /a 与 /b 都收到请求后才响应，逐个抓取时会等待超时并返回错误
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	allArrived := make(chan struct{})
+	go func() {
+		arrived.Wait()
+		close(allArrived)
+	}()
+	respondTogether := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			arrived.Done()
+			select {
+			case <-allArrived:
+			case <-time.After(5 * time.Second):
+				http.Error(w, "urls were not fetched concurrently", http.StatusGatewayTimeout)
+				return
+			}
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte(body))
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a", respondTogether("alpha page"))
+	mux.HandleFunc("/b", respondTogether(strings.Repeat("b", 500)))
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	tool := NewWebFetchManyTool(NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true}))
+	assert.True(t, tool.ConcurrencySafe())
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"urls":       []interface{}{server.URL + "/a", server.URL + "/b", server.URL + "/missing", server.URL + "/a"},
+		"max_length": float64(100),
+	})
+	require.NoError(t, err)
+
+	assert.Contains(t, result, "## [1] "+server.URL+"/a\nalpha page")
+	assert.Contains(t, result, "## [2] "+server.URL+"/b\n"+strings.Repeat("b", 100)+"\n\n... (content truncated)")
+	assert.NotContains(t, result, strings.Repeat("b", 101))
+	assert.Contains(t, result, "## [3] "+server.URL+"/missing\nError: ")
+	assert.Contains(t, result, "HTTP 404")
+	assert.NotContains(t, result, "## [4]")
+	assert.True(t, strings.HasSuffix(result, "Fetched 2/3 URLs"))
+}
+
+func TestWebFetchManyRejectsTooManyURLs(t *testing.T) {
+	tool := NewWebFetchManyTool(NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true}))
+
+	urls := make([]interface{}, 0, maxWebFetchManyURLs+1)
+	for i := 0; i <= maxWebFetchManyURLs; i++ {
+		urls = append(urls, "https://example.com/"+string(rune('a'+i)))
+	}
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"urls": urls})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many urls")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"urls": []interface{}{}})
+	require.Error(t, err)
+}
+
+func TestWebFetchManySharedTimeoutKeepsFinishedResults(t *testing.T) {
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("fast page"))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close(release)
+
+	tool := NewWebFetchManyTool(NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true}))
+	start := time.Now()
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"urls":    []interface{}{server.URL + "/fast", server.URL + "/slow"},
+		"timeout": float64(1),
+	})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 3*time.Second)
+	assert.Contains(t, result, "fast page")
+	assert.Contains(t, result, "## [2] "+server.URL+"/slow\nError:")
+	assert.True(t, strings.HasSuffix(result, "Fetched 1/2 URLs"))
+}
+
+func TestHTTPRequestPostWithJSONBodyAndHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			// robots.txt 只约束抓取，API 请求不受影响
+			_, _ = w.Write([]byte("User-agent: *\nDisallow: /\n"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"method":        r.Method,
+			"contentType":   r.Header.Get("Content-Type"),
+			"authorization": r.Header.Get("Authorization"),
+			"body":          string(body),
+		})
+	}))
+	defer server.Close()
+
+	fetch := NewWebFetchTool(WebFetchOptions{Mode: "chrome", AllowPrivateNetwork: true})
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(fetch))
+	require.NoError(t, registry.Register(NewHTTPRequestTool(fetch)))
+	assert.True(t, registry.IsConcurrencySafe("web_fetch"))
+	assert.False(t, registry.IsConcurrencySafe("http_request"), "requests may change remote state")
+
+	result, err := registry.Execute(context.Background(), "http_request", map[string]interface{}{
+		"url":     server.URL + "/items",
+		"method":  "post",
+		"headers": map[string]interface{}{"Authorization": "Bearer secret"},
+		"body":    `{"name":"widget"}`,
+	})
+	require.NoError(t, err)
+
+	var echoed map[string]string
+	require.NoError(t, json.Unmarshal([]byte(result), &echoed), result)
+	assert.Equal(t, "POST", echoed["method"])
+	assert.Equal(t, "application/json", echoed["contentType"])
+	assert.Equal(t, "Bearer secret", echoed["authorization"])
+	assert.Equal(t, `{"name":"widget"}`, echoed["body"])
+
+	// web_fetch 只发 GET，且遵守 robots.txt
+	_, err = fetch.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/items", "method": "POST"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "use http_request")
+	_, err = fetch.Execute(context.Background(), map[string]interface{}{"url": server.URL + "/items"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "robots.txt")
+}
+
+func TestWebFetchRejectsInvalidRequestOptions(t *testing.T) {
+	tool := NewWebFetchTool(WebFetchOptions{Mode: "http", AllowPrivateNetwork: true, IgnoreRobots: true})
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"url": "http://127.0.0.1:1/", "body": "x"})
+	require.EqualError(t, err, "body requires a method other than GET")
+
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"url": "http://127.0.0.1:1/", "method": "TRACE"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported method")
+}
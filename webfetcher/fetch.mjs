@@ -353,10 +353,18 @@ function normalizeRequest(raw) {
     waitForSelector: typeof raw.waitForSelector === 'string' ? raw.waitForSelector.trim() : '',
     waitForText: typeof raw.waitForText === 'string' ? raw.waitForText.trim() : '',
     waitForNoText: typeof raw.waitForNoText === 'string' ? raw.waitForNoText.trim() : '',
+    includeHtml: raw.includeHtml === true,
     chrome: normalizeChromeConfig(raw.chrome),
+    proxy: typeof raw.proxy === 'string' ? raw.proxy.trim() : '',
   };
 }
 
+// 内网防护开启时 Go 侧提供本地防护代理，浏览器的全部请求（含重定向和子资源）必须经过它；
+// Playwright 默认连回环地址也走代理
+function guardProxyOptions(req) {
+  return req.proxy ? { proxy: { server: req.proxy } } : {};
+}
+
 function browserContextOptions(req) {
   return {
     userAgent: req.userAgent,
@@ -487,13 +495,15 @@ async function readPage(page, req) {
     return { title: pageTitle, text: merged };
   });
 
-  return { url: req.url, title: normalizeText(title), text: normalizeText(text) };
+  // 需要按 CSS selector 提取时把渲染后的 HTML 一并返回，由 Go 侧解析
+  const html = req.includeHtml ? await page.content() : '';
+  return { url: req.url, title: normalizeText(title), text: normalizeText(text), html };
 }
 
 async function fetchWithBrowserMode(req) {
   let browser;
   try {
-    browser = await chromium.launch({ headless: true });
+    browser = await chromium.launch({ headless: true, ...guardProxyOptions(req) });
     const context = await browser.newContext(browserContextOptions(req));
     const page = await context.newPage();
     return await readPage(page, req);
@@ -507,11 +517,14 @@ async function fetchWithBrowserMode(req) {
 async function fetchWithChromeCDP(req, chrome) {
   let browser;
   let page;
+  let ownContext;
   try {
     browser = await chromium.connectOverCDP(chrome.cdpEndpoint, { timeout: req.timeoutMs });
-    let context = browser.contexts()[0];
+    // 已有的默认上下文无法改用代理，防护开启时改建一个经防护代理的新上下文（不带已登录的 cookie）
+    let context = req.proxy ? undefined : browser.contexts()[0];
     if (!context) {
-      context = await browser.newContext(browserContextOptions(req));
+      context = await browser.newContext({ ...browserContextOptions(req), ...guardProxyOptions(req) });
+      ownContext = context;
     }
     page = await context.newPage();
     return await readPage(page, req);
@@ -519,6 +532,9 @@ async function fetchWithChromeCDP(req, chrome) {
     if (page) {
       await page.close().catch(() => {});
     }
+    if (ownContext) {
+      await ownContext.close().catch(() => {});
+    }
     if (browser) {
       await browser.close().catch(() => {});
     }
@@ -533,6 +549,7 @@ async function fetchWithChromeProfile(req, chrome) {
   try {
     context = await chromium.launchPersistentContext(userDataDir, {
       ...browserContextOptions(req),
+      ...guardProxyOptions(req),
       channel: chrome.channel,
       headless: chrome.headless,
       args: DEFAULT_CHROME_ARGS,
@@ -624,7 +641,11 @@ async function main() {
       });
       return;
     }
-    writeResult({ ok: true, url, title, text });
+    const payload = { ok: true, url, title, text, proxied: Boolean(normalized.proxy) };
+    if (normalized.includeHtml && result.html) {
+      payload.html = result.html;
+    }
+    writeResult(payload);
   } catch (err) {
     const message = errorMessage(err);
     if (normalized.mode === 'chrome' && normalized.chrome.cdpEndpoint) {